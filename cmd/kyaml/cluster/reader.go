@@ -0,0 +1,182 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cluster reads Resource Config from a Kubernetes cluster.
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	metav1 "sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/pseudo/k8s/client-go/dynamic"
+	"sigs.k8s.io/kustomize/pseudo/k8s/client-go/dynamic/dynamicinformer"
+	"sigs.k8s.io/kustomize/pseudo/k8s/client-go/tools/cache"
+)
+
+// defaultSyncTimeout is how long Start waits for the Resources to be listed
+const defaultSyncTimeout = time.Minute
+
+// CachedReader reads Resources from a cluster.
+//
+// The Resources are listed once when the reader is started, and are then kept up to
+// date by watches.  Each Read returns a point-in-time snapshot of the cached Resources
+// without calling the apiserver, so workflows which repeatedly read the same Resources
+// -- e.g. re-rendering and comparing a package on each change -- don't list them on
+// every read.
+type CachedReader struct {
+	// Client is used to list and watch the Resources
+	Client dynamic.Interface
+
+	// Resources are the resource types to read
+	Resources []schema.GroupVersionResource
+
+	// Namespace, if set, only reads Resources in the namespace
+	Namespace string
+
+	// LabelSelector, if set, only reads Resources matching the selector
+	LabelSelector string
+
+	// ResyncPeriod, if set, is the period at which the cached Resources are relisted
+	ResyncPeriod time.Duration
+
+	// SyncTimeout is how long Start waits for the Resources to be listed.  Defaults to
+	// 1 minute.
+	SyncTimeout time.Duration
+
+	// OmitReaderAnnotations will configure Read to skip setting the
+	// config.kubernetes.io/index annotation on Resources as they are Read.
+	OmitReaderAnnotations bool
+
+	mu        sync.Mutex
+	stop      chan struct{}
+	informers []cache.SharedIndexInformer
+}
+
+var _ kio.Reader = &CachedReader{}
+
+// Start lists the Resources and starts watching them.  Start returns once the Resources
+// have been listed.  Read calls Start if the reader hasn't been started.
+func (r *CachedReader) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.start()
+}
+
+func (r *CachedReader) start() error {
+	if r.stop != nil {
+		return nil
+	}
+	if len(r.Resources) == 0 {
+		return fmt.Errorf("must specify at least one resource type to read")
+	}
+
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
+		r.Client, r.ResyncPeriod, r.Namespace, func(o *metav1.ListOptions) {
+			o.LabelSelector = r.LabelSelector
+		})
+	var informers []cache.SharedIndexInformer
+	var synced []cache.InformerSynced
+	for i := range r.Resources {
+		informer := factory.ForResource(r.Resources[i]).Informer()
+		informers = append(informers, informer)
+		synced = append(synced, informer.HasSynced)
+	}
+	stop := make(chan struct{})
+	factory.Start(stop)
+
+	timeout := r.SyncTimeout
+	if timeout == 0 {
+		timeout = defaultSyncTimeout
+	}
+	timer := time.AfterFunc(timeout, func() { close(stop) })
+	if !cache.WaitForCacheSync(stop, synced...) {
+		return fmt.Errorf("timed out listing Resources after %v", timeout)
+	}
+	if !timer.Stop() {
+		// the timeout closed stop after the caches synced
+		return fmt.Errorf("timed out listing Resources after %v", timeout)
+	}
+
+	r.stop = stop
+	r.informers = informers
+	return nil
+}
+
+// Stop stops watching the Resources.  The reader may be started again.
+func (r *CachedReader) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		close(r.stop)
+	}
+	r.stop = nil
+	r.informers = nil
+}
+
+// Read returns a snapshot of the cached Resources, ordered by resource type in the
+// order of Resources, then by namespace and name.  The returned Resources are copies
+// and may be modified.
+func (r *CachedReader) Read() ([]*yaml.RNode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.start(); err != nil {
+		return nil, err
+	}
+
+	var objects []map[string]interface{}
+	for i := range r.informers {
+		var items []*unstructured.Unstructured
+		for _, o := range r.informers[i].GetStore().List() {
+			if u, ok := o.(*unstructured.Unstructured); ok {
+				items = append(items, u)
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].GetNamespace() != items[j].GetNamespace() {
+				return items[i].GetNamespace() < items[j].GetNamespace()
+			}
+			return items[i].GetName() < items[j].GetName()
+		})
+		for j := range items {
+			objects = append(objects, items[j].Object)
+		}
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	// copy the Resources out of the cache by round-tripping them through json
+	b, err := json.Marshal(objects)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := (&kio.JSONReader{
+		Reader:                bytes.NewReader(b),
+		OmitReaderAnnotations: r.OmitReaderAnnotations,
+	}).Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		blockStyle(nodes[i].YNode())
+	}
+	return nodes, nil
+}
+
+// blockStyle clears the flow styles of nodes read from json so they are written as
+// idiomatic yaml.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for i := range node.Content {
+		blockStyle(node.Content[i])
+	}
+}
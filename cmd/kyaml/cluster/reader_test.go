@@ -0,0 +1,156 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/cmd/kyaml/cluster"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	metav1 "sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/pseudo/k8s/client-go/dynamic/fake"
+)
+
+var configMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func configMap(namespace, name string, labels map[string]interface{}) *unstructured.Unstructured {
+	metadata := map[string]interface{}{"name": name, "namespace": namespace}
+	if labels != nil {
+		metadata["labels"] = labels
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   metadata,
+		"data":       map[string]interface{}{"a": "b"},
+	}}
+}
+
+func write(t *testing.T, r kio.Reader) string {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{r},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return out.String()
+}
+
+func TestCachedReader_Read(t *testing.T) {
+	app := map[string]interface{}{"app": "nginx"}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		configMap("default", "foo", app),
+		configMap("default", "bar", app),
+		configMap("other", "baz", app),
+		configMap("default", "unlabelled", nil),
+	)
+	r := &CachedReader{
+		Client:        client,
+		Resources:     []schema.GroupVersionResource{configMaps},
+		Namespace:     "default",
+		LabelSelector: "app=nginx",
+	}
+	defer r.Stop()
+
+	assert.Equal(t, `apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  labels:
+    app: nginx
+  name: bar
+  namespace: default
+---
+apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  labels:
+    app: nginx
+  name: foo
+  namespace: default
+`, write(t, r))
+
+	// the cache is updated by the watch
+	_, err := client.Resource(configMaps).Namespace("default").Create(
+		configMap("default", "new", app), metav1.CreateOptions{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = client.Resource(configMaps).Namespace("default").Delete("foo", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var names []string
+	for i := 0; i < 50; i++ {
+		nodes, err := r.Read()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		names = nil
+		for j := range nodes {
+			meta, err := nodes[j].GetMeta()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			names = append(names, meta.Name)
+		}
+		if len(names) == 2 && names[1] == "new" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"bar", "new"}, names)
+}
+
+func TestCachedReader_Read_copies(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), configMap("default", "foo", nil))
+	r := &CachedReader{
+		Client:                client,
+		Resources:             []schema.GroupVersionResource{configMaps},
+		OmitReaderAnnotations: true,
+	}
+	defer r.Stop()
+
+	nodes, err := r.Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, nodes, 1) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, nodes[0].PipeE(yaml.SetLabel("changed", "true"))) {
+		t.FailNow()
+	}
+
+	nodes, err = r.Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+`, nodes[0].MustString())
+}
+
+func TestCachedReader_Start_noResources(t *testing.T) {
+	r := &CachedReader{Client: fake.NewSimpleDynamicClient(runtime.NewScheme())}
+	assert.EqualError(t, r.Start(), "must specify at least one resource type to read")
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/conformance"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// AffinityCommand returns the affinity command and its subcommands.
+func AffinityCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "affinity",
+		Short: "Commands for the scheduling constraints of workloads",
+		Long: `Commands for the scheduling constraints of workloads.
+
+See the subcommands for details.
+`,
+	}
+	c.AddCommand(AffinityAuditCommand())
+	return c
+}
+
+// GetAffinityAuditRunner returns a command AffinityAuditRunner.
+func GetAffinityAuditRunner() *AffinityAuditRunner {
+	r := &AffinityAuditRunner{Checks: conformance.AffinityChecks()}
+	c := &cobra.Command{
+		Use:   "audit [DIR]...",
+		Short: "Audit the scheduling constraints of workloads before they are deployed",
+		Long: `Audit the scheduling constraints of workloads from a local directory or stdin.
+
+audit checks the node selectors, node and pod affinity, tolerations and topology
+spread constraints of each workload for combinations which can never be scheduled,
+or which look unsatisfiable, and warns of replicated workloads which don't spread
+their pods.  audit runs the following rules:
+
+  node-affinity:   (error)   required node affinity must not contradict itself or the
+                             nodeSelector
+                   (warning) preferred node affinity should not contradict the
+                             nodeSelector
+  pod-affinity:    (error)   required pod anti-affinity must not contradict the pod
+                             affinity for the same pods
+                   (warning) required anti-affinity between replicas should not
+                             require more zones than regions typically have
+  tolerations:     (error)   tolerations must be well formed
+                   (warning) tolerations should not tolerate all taints, except for
+                             DaemonSets
+  topology-spread: (error)   topology spread constraints must be well formed
+                   (warning) replicated workloads should set topology spread
+                             constraints or pod anti-affinity, and the constraints
+                             should select the pods of the workload
+
+The nodes of the cluster aren't known, so a workload which passes the audit may
+still be unschedulable.
+
+audit exits non-zero if any rule fails with an error -- or with a warning if
+--fail-on is 'warning'.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# audit the scheduling constraints of a package
+kyaml affinity audit my-dir/
+
+# audit kustomize output in CI, failing on warnings
+kustomize build | kyaml affinity audit --fail-on warning --output json
+
+# don't warn of workloads without spread constraints
+kyaml affinity audit my-dir/ --disable-rule topology-spread
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also audit resources from subpackages.")
+	c.Flags().StringSliceVar(&r.Rules, "rule", []string{},
+		"rule to run.  may be specified multiple times.  defaults to all rules.")
+	c.Flags().StringSliceVar(&r.DisabledRules, "disable-rule", []string{},
+		"rule to skip.  may be specified multiple times.")
+	c.Flags().StringVar(&r.FailOn, "fail-on", string(conformance.SeverityError),
+		"severity of the findings to exit non-zero on.  may be 'error' or 'warning'.")
+	markFlagValues(c, "fail-on",
+		string(conformance.SeverityError), string(conformance.SeverityWarning))
+	SetOutputFormats(c, OutputJSON)
+	r.Command = c
+	return r
+}
+
+func AffinityAuditCommand() *cobra.Command {
+	return GetAffinityAuditRunner().Command
+}
+
+// AffinityAuditRunner contains the run function
+type AffinityAuditRunner struct {
+	IncludeSubpackages bool
+	Rules              []string
+	DisabledRules      []string
+	FailOn             string
+	Command            *cobra.Command
+
+	// Checks are the rules which may be run, indexed by name.  Defaults to the
+	// conformance.AffinityChecks.
+	Checks map[string]conformance.Check
+}
+
+// affinityReport is the machine-readable output of affinity audit
+type affinityReport struct {
+	Errors   int                  `json:"errors"`
+	Warnings int                  `json:"warnings"`
+	Results  []conformance.Result `json:"results"`
+}
+
+func (r *AffinityAuditRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+	if r.FailOn != string(conformance.SeverityError) && r.FailOn != string(conformance.SeverityWarning) {
+		return handleError(c, fmt.Errorf("--fail-on must be one of '%s' or '%s', got '%s'",
+			conformance.SeverityError, conformance.SeverityWarning, r.FailOn))
+	}
+	checks, err := conformance.SelectChecks(r.Checks, r.Rules, r.DisabledRules)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	buff := &kio.PackageBuffer{}
+	if err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Outputs: []kio.Writer{buff}}).Execute(); err != nil {
+		return handleError(c, err)
+	}
+	results, err := conformance.Run(buff.Nodes, checks)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	report := affinityReport{Errors: conformance.Failed(results), Results: results}
+	report.Warnings = len(results) - report.Errors
+	if report.Results == nil {
+		report.Results = []conformance.Result{}
+	}
+
+	if output == OutputJSON {
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		if err := e.Encode(report); err != nil {
+			return handleError(c, err)
+		}
+	} else {
+		for _, res := range results {
+			file := res.File
+			if file == "" {
+				file = "stdin"
+			}
+			id := res.Name
+			if res.Namespace != "" {
+				id = res.Namespace + "/" + res.Name
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s: %s %s: [%s] %s: %s\n",
+				file, res.Line, res.Severity, res.Kind, id, res.Check, res.Field, res.Message)
+		}
+	}
+
+	failed := report.Errors
+	if r.FailOn == string(conformance.SeverityWarning) {
+		failed = len(results)
+	}
+	if failed > 0 {
+		return handleError(c, fmt.Errorf("%d scheduling findings failed", failed))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+	"sigs.k8s.io/kustomize/kyaml/conformance"
+)
+
+const affinityInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 2
+  template:
+    spec:
+      nodeSelector:
+        pool: web
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - {key: pool, operator: In, values: [batch]}
+      containers:
+      - name: web
+        image: web:1.0
+`
+
+func TestAffinityAuditCommand(t *testing.T) {
+	r := cmd.GetAffinityAuditRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(affinityInput))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{})
+	assert.EqualError(t, r.Command.Execute(), "1 scheduling findings failed")
+	assert.Equal(t, "stdin:10: warning: Deployment default/web: [topology-spread] "+
+		"spec.template.spec.topologySpreadConstraints: replicated workload does not set topology "+
+		"spread constraints or pod anti-affinity -- its replicas may all be scheduled onto the same node\n"+
+		"stdin:16: error: Deployment default/web: [node-affinity] "+
+		"spec.template.spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[0]: "+
+		"node selector term can never match: the requirements of label 'pool' contradict the nodeSelector "+
+		"-- no term may match, pods can never be scheduled\n",
+		b.String())
+
+	// warnings don't fail by default
+	r = cmd.GetAffinityAuditRunner()
+	b = &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(affinityInput))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"--rule", "topology-spread"})
+	if assert.NoError(t, r.Command.Execute()) {
+		assert.Contains(t, b.String(), "[topology-spread]")
+	}
+
+	// warnings fail with --fail-on warning
+	r = cmd.GetAffinityAuditRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetIn(strings.NewReader(affinityInput))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"--fail-on", "warning", "--disable-rule", "node-affinity"})
+	assert.EqualError(t, r.Command.Execute(), "1 scheduling findings failed")
+}
+
+func TestAffinityAuditCommand_json(t *testing.T) {
+	r := cmd.GetAffinityAuditRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(affinityInput))
+	r.Command.SetOut(b)
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{"--output", "json"})
+	assert.Error(t, r.Command.Execute())
+
+	report := struct {
+		Errors   int
+		Warnings int
+		Results  []conformance.Result
+	}{}
+	if !assert.NoError(t, json.Unmarshal(b.Bytes(), &report)) {
+		return
+	}
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, 1, report.Warnings)
+	if assert.Len(t, report.Results, 2) {
+		assert.Equal(t, "topology-spread", report.Results[0].Check)
+		assert.Equal(t, "node-affinity", report.Results[1].Check)
+	}
+}
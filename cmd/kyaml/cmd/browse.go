@@ -0,0 +1,580 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetBrowseRunner returns a command BrowseRunner.
+func GetBrowseRunner() *BrowseRunner {
+	r := &BrowseRunner{}
+	c := &cobra.Command{
+		Use:   "browse [DIR]",
+		Short: "Browse the Resources of a directory or stdin in the terminal",
+		Long: `Browse the Resources of a directory or stdin in the terminal.
+
+browse displays the tree printed by 'kyaml tree' as an interactive terminal UI.  The
+packages and Resources are collapsed, and may be expanded to display the packages and
+Resources beneath them, and the fields and Events of the Resources.
+
+Keys:
+
+  up, down, k, j      move the selection
+  page up, page down  move the selection by a page
+  right, l            expand the selected node
+  left, h             collapse the selected node, or select its parent
+  space               expand or collapse the selected node
+  enter, y            view the yaml of the selected Resource
+  /                   filter the Resources by kind or name -- e.g. 'deploy' or 'nginx'.
+                      Resources whose kind and name contain the filter are displayed,
+                      with the packages and Resources containing them expanded.
+  esc                 clear the filter, or return from the yaml of a Resource
+  q, ctrl-c           quit
+
+When reading from stdin, the keys are read from the terminal.
+
+Expanding a Resource displays the fields printed by 'kyaml tree --all', and the
+fields selected by '--field'.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# browse the Resources of a package
+kyaml browse my-dir/
+
+# also display the serviceAccountName of the Resources
+kyaml browse my-dir/ --field spec.template.spec.serviceAccountName
+
+# browse live Resources using the graph structure
+kubectl get all -o yaml | kyaml browse --graph-structure=graph
+`,
+		RunE: r.runE,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also browse resources from subpackages.")
+	c.Flags().StringVar(&r.Structure, "graph-structure", "directory",
+		"Graph structure to use for the tree.  may be 'directory' or 'graph'.")
+	markFlagValues(c, "graph-structure",
+		string(kio.TreeStructurePackage), string(kio.TreeStructureGraph))
+	c.Flags().StringSliceVar(&r.Fields, "field", []string{},
+		"also display the field beneath the Resources.")
+	c.Flags().BoolVar(&r.Events, "events", false,
+		"display Warning Events beneath the Resources they are about -- only for the graph structure.")
+	r.Command = c
+	return r
+}
+
+func BrowseCommand() *cobra.Command {
+	return GetBrowseRunner().Command
+}
+
+// BrowseRunner contains the run function
+type BrowseRunner struct {
+	IncludeSubpackages bool
+	Structure          string
+	Fields             []string
+	Events             bool
+	Command            *cobra.Command
+}
+
+// browseSize is the size of the screen if the output isn't a terminal
+var browseSize = [2]int{80, 24}
+
+func (r *BrowseRunner) runE(c *cobra.Command, args []string) error {
+	// the keys are read from stdin, or from the terminal if the Resources are
+	var input kio.Reader
+	var keys io.Reader = c.InOrStdin()
+	root := "."
+	if len(args) == 1 {
+		root = filepath.Clean(args[0])
+		input = kio.LocalPackageReader{
+			PackagePath: args[0], IncludeSubpackages: r.IncludeSubpackages}
+	} else {
+		input = &kio.ByteReader{Reader: c.InOrStdin()}
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return handleError(c, fmt.Errorf(
+				"browse requires a terminal to read keys from when reading from stdin: %v", err))
+		}
+		defer tty.Close()
+		keys = tty
+	}
+
+	fields := browseFields()
+	for _, field := range r.Fields {
+		path, err := parseFieldPath(field)
+		if err != nil {
+			return handleError(c, err)
+		}
+		fields = append(fields, newField(path...))
+	}
+
+	var tree *kio.TreeNode
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs:  []kio.Reader{input},
+		Filters: []kio.Filter{&filters.IsLocalConfig{}},
+		Outputs: []kio.Writer{kio.WriterFunc(func(nodes []*yaml.RNode) error {
+			var err error
+			tree, err = kio.TreeWriter{
+				Root:      root,
+				Structure: kio.TreeStructure(r.Structure),
+				Fields:    fields,
+				Events:    r.Events,
+			}.BuildTree(nodes)
+			return err
+		})},
+	}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+	if tree == nil {
+		// no Resources
+		tree = &kio.TreeNode{Value: root}
+	}
+
+	out := c.OutOrStdout()
+	size := func() (int, int) { return browseSize[0], browseSize[1] }
+	if f, ok := keys.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		state, err := terminal.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return handleError(c, err)
+		}
+		defer func() { _ = terminal.Restore(int(f.Fd()), state) }()
+		size = func() (int, int) {
+			if w, h, err := terminal.GetSize(int(f.Fd())); err == nil {
+				return w, h
+			}
+			return browseSize[0], browseSize[1]
+		}
+		// use the alternate screen, and hide the cursor
+		fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+		defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+	}
+
+	b := newBrowser(tree)
+	in := bufio.NewReader(keys)
+	for {
+		b.width, b.height = size()
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+strings.Join(b.render(), "\r\n"))
+		key, err := readBrowseKey(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return handleError(c, err)
+		}
+		if b.handleKey(key) {
+			return nil
+		}
+	}
+}
+
+// browseFields returns the fields displayed beneath the Resources -- the fields printed
+// by tree --all
+func browseFields() []kio.TreeWriterField {
+	var fields []kio.TreeWriterField
+	for _, name := range []string{"name", "image", "command", "args", "env"} {
+		fields = append(fields, containerFields(name)...)
+	}
+	fields = append(fields, newField("spec", "replicas"))
+	for _, name := range []string{"resources", "ports"} {
+		fields = append(fields, containerFields(name)...)
+	}
+	return append(fields, newField("spec", "ports"))
+}
+
+// readBrowseKey reads the next key from the terminal -- a printable character, or the
+// name of a special key, e.g. "up" or "enter"
+func readBrowseKey(in *bufio.Reader) (string, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch r {
+	case '\r', '\n':
+		return "enter", nil
+	case 0x7f, 0x08:
+		return "backspace", nil
+	case 0x03:
+		return "ctrl-c", nil
+	case 0x1b:
+		// escape sequences arrive together -- a lone escape is the escape key
+		if in.Buffered() == 0 {
+			return "esc", nil
+		}
+		if next, _ := in.Peek(1); next[0] != '[' && next[0] != 'O' {
+			return "esc", nil
+		}
+		_, _ = in.ReadByte()
+		var seq []byte
+		for {
+			c, err := in.ReadByte()
+			if err != nil {
+				return "esc", nil
+			}
+			seq = append(seq, c)
+			if c >= 0x40 && c <= 0x7e {
+				break
+			}
+		}
+		switch string(seq) {
+		case "A":
+			return "up", nil
+		case "B":
+			return "down", nil
+		case "C":
+			return "right", nil
+		case "D":
+			return "left", nil
+		case "H", "1~":
+			return "home", nil
+		case "F", "4~":
+			return "end", nil
+		case "5~":
+			return "pgup", nil
+		case "6~":
+			return "pgdown", nil
+		}
+		return "", nil
+	}
+	return string(r), nil
+}
+
+// browseRow is a node of the tree displayed as a row of the browser
+type browseRow struct {
+	node  *kio.TreeNode
+	depth int
+	// expanded is true if the children of the node are displayed
+	expanded bool
+	// parent is the index of the row of the parent node, or -1
+	parent int
+}
+
+// browser is the state of the browse terminal UI
+type browser struct {
+	root *kio.TreeNode
+
+	// expanded records the nodes expanded or collapsed by the user
+	expanded map[*kio.TreeNode]bool
+
+	// rows are the displayed nodes
+	rows []browseRow
+
+	// cursor is the index of the selected row, and offset the index of the first row
+	// on the screen
+	cursor, offset int
+
+	// filter matches the kind and name of the Resources to display
+	filter string
+
+	// filtering is true while the filter is edited
+	filtering bool
+
+	// view is the yaml of the Resource being viewed, if any, split into lines
+	view       []string
+	viewTitle  string
+	viewOffset int
+
+	width, height int
+}
+
+func newBrowser(root *kio.TreeNode) *browser {
+	b := &browser{root: root, expanded: map[*kio.TreeNode]bool{}}
+	b.update()
+	return b
+}
+
+// matches returns true if the node is a Resource matching the filter
+func (b *browser) matches(n *kio.TreeNode) bool {
+	return n.Resource != nil &&
+		strings.Contains(strings.ToLower(n.Value), strings.ToLower(b.filter))
+}
+
+// containsMatch returns true if a node beneath n matches the filter
+func (b *browser) containsMatch(n *kio.TreeNode) bool {
+	for _, c := range n.Children {
+		if b.matches(c) || b.containsMatch(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// update recomputes the displayed rows, keeping the selected node selected if it is
+// still displayed
+func (b *browser) update() {
+	var selected *kio.TreeNode
+	if b.cursor < len(b.rows) {
+		selected = b.rows[b.cursor].node
+	}
+	b.rows = nil
+	b.walk(b.root, 0, -1, b.filter == "")
+	b.cursor = 0
+	for i := range b.rows {
+		if b.rows[i].node == selected {
+			b.cursor = i
+		}
+	}
+}
+
+// walk adds the rows of the children of n.  matched is true if n is beneath a Resource
+// matching the filter, so its children are all displayed.
+func (b *browser) walk(n *kio.TreeNode, depth, parent int, matched bool) {
+	for _, c := range n.Children {
+		m := matched || b.matches(c)
+		if !m && !b.containsMatch(c) {
+			continue
+		}
+		// nodes containing matches are expanded unless the user collapsed them
+		expanded, set := b.expanded[c]
+		if !set {
+			expanded = !m
+		}
+		expanded = expanded && len(c.Children) > 0
+		b.rows = append(b.rows, browseRow{
+			node: c, depth: depth, expanded: expanded, parent: parent})
+		if expanded {
+			b.walk(c, depth+1, len(b.rows)-1, m)
+		}
+	}
+}
+
+// handleKey updates the browser for the key, and returns true if the browser should quit
+func (b *browser) handleKey(key string) bool {
+	if key == "ctrl-c" {
+		return true
+	}
+	switch {
+	case b.filtering:
+		b.handleFilterKey(key)
+	case b.view != nil:
+		return b.handleViewKey(key)
+	default:
+		return b.handleTreeKey(key)
+	}
+	return false
+}
+
+func (b *browser) handleFilterKey(key string) {
+	switch key {
+	case "enter":
+		b.filtering = false
+	case "esc":
+		b.filtering, b.filter = false, ""
+	case "backspace":
+		if b.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(b.filter)
+			b.filter = b.filter[:len(b.filter)-size]
+		}
+	default:
+		if utf8.RuneCountInString(key) != 1 {
+			return
+		}
+		b.filter += key
+	}
+	b.update()
+}
+
+func (b *browser) handleViewKey(key string) bool {
+	page := b.pageSize()
+	switch key {
+	case "q":
+		return true
+	case "esc", "left", "h", "enter":
+		b.view = nil
+	case "up", "k":
+		b.viewOffset--
+	case "down", "j":
+		b.viewOffset++
+	case "pgup":
+		b.viewOffset -= page
+	case "pgdown", " ":
+		b.viewOffset += page
+	case "home":
+		b.viewOffset = 0
+	case "end":
+		b.viewOffset = len(b.view)
+	}
+	return false
+}
+
+func (b *browser) handleTreeKey(key string) bool {
+	if key == "q" {
+		return true
+	}
+	if key == "/" {
+		b.filtering = true
+		return false
+	}
+	if key == "esc" {
+		b.filter = ""
+		b.update()
+		return false
+	}
+	if len(b.rows) == 0 {
+		return false
+	}
+	row := b.rows[b.cursor]
+	switch key {
+	case "up", "k":
+		b.cursor--
+	case "down", "j":
+		b.cursor++
+	case "pgup":
+		b.cursor -= b.pageSize()
+	case "pgdown":
+		b.cursor += b.pageSize()
+	case "home":
+		b.cursor = 0
+	case "end":
+		b.cursor = len(b.rows) - 1
+	case "right", "l":
+		b.expanded[row.node] = true
+		b.update()
+	case "left", "h":
+		if row.expanded {
+			b.expanded[row.node] = false
+			b.update()
+		} else if row.parent >= 0 {
+			b.cursor = row.parent
+		}
+	case " ":
+		b.expanded[row.node] = !row.expanded
+		b.update()
+	case "enter", "y":
+		if row.node.Resource == nil {
+			b.expanded[row.node] = !row.expanded
+			b.update()
+			break
+		}
+		b.viewResource(row.node)
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+	if b.cursor >= len(b.rows) {
+		b.cursor = len(b.rows) - 1
+	}
+	return false
+}
+
+// viewResource displays the yaml of the Resource of the node
+func (b *browser) viewResource(n *kio.TreeNode) {
+	out := &bytes.Buffer{}
+	err := kio.ByteWriter{Writer: out, ClearAnnotations: []string{
+		kioutil.PathAnnotation, kioutil.PackageAnnotation}}.Write(
+		[]*yaml.RNode{n.Resource.Copy()})
+	if err != nil {
+		b.view = []string{err.Error()}
+	} else {
+		b.view = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	}
+	b.viewTitle, b.viewOffset = n.Value, 0
+}
+
+// pageSize is the number of rows displayed between the title and help lines
+func (b *browser) pageSize() int {
+	if b.height < 3 {
+		return 1
+	}
+	return b.height - 2
+}
+
+// render returns the lines of the screen
+func (b *browser) render() []string {
+	page := b.pageSize()
+	var lines []string
+	if b.view != nil {
+		if b.viewOffset > len(b.view)-page {
+			b.viewOffset = len(b.view) - page
+		}
+		if b.viewOffset < 0 {
+			b.viewOffset = 0
+		}
+		end := b.viewOffset + page
+		if end > len(b.view) {
+			end = len(b.view)
+		}
+		lines = append(lines, fmt.Sprintf("%s (lines %d-%d of %d)",
+			b.viewTitle, b.viewOffset+1, end, len(b.view)))
+		lines = append(lines, b.view[b.viewOffset:end]...)
+		for len(lines) < page+1 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "↑/↓ scroll  esc back  q quit")
+		return b.truncate(lines)
+	}
+
+	title := b.root.Value
+	if b.filter != "" || b.filtering {
+		title = fmt.Sprintf("%s  filter: %s", title, b.filter)
+	}
+	lines = append(lines, title)
+
+	// scroll the selected row onto the screen
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+page {
+		b.offset = b.cursor - page + 1
+	}
+	for i := b.offset; i < len(b.rows) && i < b.offset+page; i++ {
+		row := b.rows[i]
+		line := "  "
+		if i == b.cursor {
+			line = "> "
+		}
+		line += strings.Repeat("  ", row.depth)
+		switch {
+		case row.expanded:
+			line += "▾ "
+		case len(row.node.Children) > 0:
+			line += "▸ "
+		default:
+			line += "  "
+		}
+		if row.node.Meta != "" {
+			line += fmt.Sprintf("[%s]  ", row.node.Meta)
+		}
+		lines = append(lines, line+row.node.Value)
+	}
+	if len(b.rows) == 0 {
+		lines = append(lines, "  no Resources")
+	}
+	for len(lines) < page+1 {
+		lines = append(lines, "")
+	}
+	if b.filtering {
+		lines = append(lines, "filter: "+b.filter+"_  enter done  esc clear")
+	} else {
+		lines = append(lines, "↑/↓ move  →/← expand/collapse  enter view yaml  / filter  q quit")
+	}
+	return b.truncate(lines)
+}
+
+// truncate truncates the lines to the width of the screen
+func (b *browser) truncate(lines []string) []string {
+	for i := range lines {
+		if b.width > 0 && utf8.RuneCountInString(lines[i]) > b.width {
+			lines[i] = string([]rune(lines[i])[:b.width])
+		}
+	}
+	return lines
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestBrowseCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"f1.yaml": `kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 1
+---
+kind: Service
+metadata:
+  name: nginx
+`,
+		"sub/f2.yaml": `kind: Deployment
+metadata:
+  name: redis
+  annotations:
+    app: redis
+spec:
+  replicas: 3
+`,
+	})
+
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{
+			name: "collapsed",
+			expected: `${DIR}
+> ▸ [f1.yaml]  Deployment nginx
+    [f1.yaml]  Service nginx
+  ▸ sub
+`,
+		},
+		{
+			name: "expand",
+			keys: "jjl\x1b[Bl",
+			expected: `${DIR}
+  ▸ [f1.yaml]  Deployment nginx
+    [f1.yaml]  Service nginx
+  ▾ sub
+>   ▾ [f2.yaml]  Deployment redis
+        spec.replicas: 3
+`,
+		},
+		{
+			name: "collapse and select the parent",
+			keys: "jj \x1b[Bh\x1b[Dh",
+			expected: `${DIR}
+  ▸ [f1.yaml]  Deployment nginx
+    [f1.yaml]  Service nginx
+> ▸ sub
+`,
+		},
+		{
+			name: "filter",
+			keys: "/REDIS\r",
+			expected: `${DIR}  filter: REDIS
+> ▾ sub
+    ▸ [f2.yaml]  Deployment redis
+`,
+		},
+		{
+			name: "edit the filter",
+			keys: "/depx\x7f",
+			expected: `${DIR}  filter: dep
+> ▸ [f1.yaml]  Deployment nginx
+  ▾ sub
+    ▸ [f2.yaml]  Deployment redis
+`,
+		},
+		{
+			name: "clear the filter",
+			keys: "/redis\r\x1b",
+			expected: `${DIR}
+  ▸ [f1.yaml]  Deployment nginx
+    [f1.yaml]  Service nginx
+> ▸ sub
+`,
+		},
+		{
+			name: "view yaml",
+			keys: "/redis\rj\r",
+			expected: `Deployment redis (lines 1-7 of 7)
+kind: Deployment
+metadata:
+  name: redis
+  annotations:
+    app: redis
+spec:
+  replicas: 3
+`,
+		},
+		{
+			name: "return from the yaml",
+			keys: "j\r\x1b",
+			expected: `${DIR}
+  ▸ [f1.yaml]  Deployment nginx
+>   [f1.yaml]  Service nginx
+  ▸ sub
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := cmd.GetBrowseRunner()
+			out := &bytes.Buffer{}
+			r.Command.SetIn(bytes.NewBufferString(test.keys))
+			r.Command.SetOut(out)
+			r.Command.SetArgs([]string{d})
+			if !assert.NoError(t, r.Command.Execute()) {
+				t.FailNow()
+			}
+
+			// compare the lines of the last frame above the help line
+			frames := strings.Split(out.String(), "\x1b[H\x1b[2J")
+			lines := strings.Split(frames[len(frames)-1], "\r\n")
+			actual := strings.TrimRight(strings.Join(lines[:len(lines)-1], "\n"), "\n") + "\n"
+			assert.Equal(t, strings.Replace(test.expected, "${DIR}", d, -1), actual)
+		})
+	}
+
+	// q quits before reading the rest of the keys
+	r := cmd.GetBrowseRunner()
+	out := &bytes.Buffer{}
+	r.Command.SetIn(bytes.NewBufferString("qj"))
+	r.Command.SetOut(out)
+	r.Command.SetArgs([]string{d})
+	if !assert.NoError(t, r.Command.Execute()) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, strings.Count(out.String(), "\x1b[H\x1b[2J"))
+}
@@ -0,0 +1,476 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/copyutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// BundleCommand returns the bundle command and its subcommands.
+func BundleCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "bundle",
+		Short: "Commands for self-contained archives of packages",
+		Long: `Commands for self-contained archives of packages.
+
+A bundle is a gzipped tar archive of a kustomization and everything it references, with
+a manifest -- bundle.yaml -- recording the origins of the vendored references and the
+digests of the files, so that configuration may be transferred to air-gapped
+environments and checked on arrival.
+
+See the subcommands for details.
+`,
+	}
+	c.AddCommand(BundleCreateCommand())
+	c.AddCommand(BundleVerifyCommand())
+	c.AddCommand(BundleUnpackCommand())
+	return c
+}
+
+// GetBundleCreateRunner returns a command BundleCreateRunner.
+func GetBundleCreateRunner() *BundleCreateRunner {
+	r := &BundleCreateRunner{}
+	c := &cobra.Command{
+		Use:   "create DIR ARCHIVE",
+		Short: "Write a kustomization and its references to a bundle",
+		Long: `Write a kustomization and its references to a bundle.
+
+The kustomization in DIR is resolved as by 'kyaml resolve' -- the bases, resources,
+components, patches and generator files outside of DIR, local or in git repositories,
+are vendored and referenced from the copy -- and the resolved package is written to
+ARCHIVE.  The manifest records the source of each vendored copy, with the commit of git
+repositories, and the sha256 digest of each file.
+
+The archive is reproducible -- the files are written in order, with no timestamps -- so
+bundles of the same package have the same digest.
+
+  DIR:
+    Path to the directory containing the kustomization file.
+
+  ARCHIVE:
+    Path of the archive to write -- e.g. my-app.tar.gz.
+`,
+		Example: `# bundle an overlay and its bases
+kyaml bundle create my-app/overlays/prod/ prod.tar.gz
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(2),
+	}
+	r.Command = c
+	return r
+}
+
+func BundleCreateCommand() *cobra.Command {
+	return GetBundleCreateRunner().Command
+}
+
+// BundleCreateRunner contains the run function
+type BundleCreateRunner struct {
+	Command *cobra.Command
+}
+
+func (r *BundleCreateRunner) runE(c *cobra.Command, args []string) error {
+	src, err := filepath.Abs(args[0])
+	if err != nil {
+		return handleError(c, err)
+	}
+	if findKustomization(src) == "" {
+		return handleError(c, fmt.Errorf("no kustomization file found in %s", args[0]))
+	}
+	if _, err := os.Stat(filepath.Join(src, bundleManifestFile)); err == nil {
+		return handleError(c, fmt.Errorf("%s contains a %s", args[0], bundleManifestFile))
+	}
+
+	tmp, err := ioutil.TempDir("", "kyaml-bundle")
+	if err != nil {
+		return handleError(c, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// resolve the package before writing it to the archive
+	dest := filepath.Join(tmp, "package")
+	res := newResolver(dest, filepath.Join(tmp, "repositories"), c.OutOrStdout())
+	if err := copyutil.CopyDir(src, dest); err != nil {
+		return handleError(c, err)
+	}
+	if err := res.resolve(src, dest, src); err != nil {
+		return handleError(c, err)
+	}
+
+	files, err := readBundleFiles(dest)
+	if err != nil {
+		return handleError(c, err)
+	}
+	m, err := res.manifest(src, files)
+	if err != nil {
+		return handleError(c, err)
+	}
+	b := &bytes.Buffer{}
+	if err := yaml.NewEncoder(b).Encode(m); err != nil {
+		return handleError(c, err)
+	}
+	files[bundleManifestFile] = b.Bytes()
+
+	f, err := os.Create(args[1])
+	if err != nil {
+		return handleError(c, err)
+	}
+	if err := writeBundle(f, files); err != nil {
+		f.Close()
+		return handleError(c, err)
+	}
+	if err := f.Close(); err != nil {
+		return handleError(c, err)
+	}
+	fmt.Fprintf(c.OutOrStdout(), "wrote %d files to %s\n", len(m.Files), args[1])
+	return nil
+}
+
+// GetBundleVerifyRunner returns a command BundleVerifyRunner.
+func GetBundleVerifyRunner() *BundleVerifyRunner {
+	r := &BundleVerifyRunner{}
+	c := &cobra.Command{
+		Use:   "verify ARCHIVE",
+		Short: "Verify the files of a bundle against its manifest",
+		Long: `Verify the files of a bundle against its manifest.
+
+Each file listed by the manifest must be in the archive with its digest, and the archive
+must not contain files the manifest doesn't list.  The files which don't are printed,
+and the command fails.
+
+  ARCHIVE:
+    Path of the archive written by 'kyaml bundle create'.
+`,
+		Example: `# verify a bundle after transferring it
+kyaml bundle verify prod.tar.gz
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	r.Command = c
+	return r
+}
+
+func BundleVerifyCommand() *cobra.Command {
+	return GetBundleVerifyRunner().Command
+}
+
+// BundleVerifyRunner contains the run function
+type BundleVerifyRunner struct {
+	Command *cobra.Command
+}
+
+func (r *BundleVerifyRunner) runE(c *cobra.Command, args []string) error {
+	m, _, err := verifyBundle(c.OutOrStdout(), args[0])
+	if err != nil {
+		return handleError(c, err)
+	}
+	fmt.Fprintf(c.OutOrStdout(), "verified %d files\n", len(m.Files))
+	return nil
+}
+
+// GetBundleUnpackRunner returns a command BundleUnpackRunner.
+func GetBundleUnpackRunner() *BundleUnpackRunner {
+	r := &BundleUnpackRunner{}
+	c := &cobra.Command{
+		Use:   "unpack ARCHIVE DEST",
+		Short: "Verify a bundle and write its package to a directory",
+		Long: `Verify a bundle and write its package to a directory.
+
+The bundle is verified as by 'kyaml bundle verify' before any file is written, and the
+package -- including the manifest -- is written to DEST, so that it may be built with
+'kustomize build DEST'.
+
+  ARCHIVE:
+    Path of the archive written by 'kyaml bundle create'.
+
+  DEST:
+    Path to the directory to write the package to.  Must not exist or be empty.
+`,
+		Example: `# unpack and build a bundle
+kyaml bundle unpack prod.tar.gz prod/
+kustomize build prod/
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(2),
+	}
+	r.Command = c
+	return r
+}
+
+func BundleUnpackCommand() *cobra.Command {
+	return GetBundleUnpackRunner().Command
+}
+
+// BundleUnpackRunner contains the run function
+type BundleUnpackRunner struct {
+	Command *cobra.Command
+}
+
+func (r *BundleUnpackRunner) runE(c *cobra.Command, args []string) error {
+	if files, err := ioutil.ReadDir(args[1]); err == nil && len(files) > 0 {
+		return handleError(c, fmt.Errorf("%s is not empty", args[1]))
+	}
+	_, files, err := verifyBundle(c.OutOrStdout(), args[0])
+	if err != nil {
+		return handleError(c, err)
+	}
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		target := filepath.Join(args[1], filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return handleError(c, err)
+		}
+		if err := ioutil.WriteFile(target, files[p], 0600); err != nil {
+			return handleError(c, err)
+		}
+	}
+	fmt.Fprintf(c.OutOrStdout(), "unpacked %d files to %s\n", len(paths), args[1])
+	return nil
+}
+
+// bundleManifestFile is the name of the manifest of a bundle, at the root of the archive
+const bundleManifestFile = "bundle.yaml"
+
+// bundleManifest records the origins of the vendored copies of a bundle and the digests
+// of its files
+type bundleManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+
+	// Origins are the sources of the vendored copies, by path
+	Origins []bundleOrigin `yaml:"origins,omitempty"`
+
+	// Files are the digests of the files of the bundle, except the manifest, by path
+	Files []bundleFile `yaml:"files"`
+}
+
+// bundleOrigin is the source of a vendored copy
+type bundleOrigin struct {
+	// Path is the slash separated path of the copy within the bundle
+	Path string `yaml:"path"`
+
+	// Source is the path of the source relative to the bundled kustomization, or the git
+	// reference it was fetched from
+	Source string `yaml:"source"`
+
+	// Commit is the commit git references were fetched at
+	Commit string `yaml:"commit,omitempty"`
+}
+
+// bundleFile is the digest of a file of a bundle
+type bundleFile struct {
+	Path   string `yaml:"path"`
+	Digest string `yaml:"digest"`
+}
+
+// manifest returns the manifest of the files of the package resolved from src
+func (r *resolver) manifest(src string, files map[string][]byte) (bundleManifest, error) {
+	m := bundleManifest{APIVersion: "config.kubernetes.io/v1alpha1", Kind: "Bundle"}
+	for source, target := range r.vendored {
+		rel, err := filepath.Rel(r.dest, target)
+		if err != nil {
+			return m, err
+		}
+		o := bundleOrigin{Path: filepath.ToSlash(rel)}
+		if o.Source, o.Commit = r.origin(source); o.Source == "" {
+			if rel, err = filepath.Rel(src, source); err != nil {
+				return m, err
+			}
+			o.Source = filepath.ToSlash(rel)
+		}
+		m.Origins = append(m.Origins, o)
+	}
+	sort.Slice(m.Origins, func(i, j int) bool { return m.Origins[i].Path < m.Origins[j].Path })
+
+	for p, b := range files {
+		m.Files = append(m.Files, bundleFile{Path: p, Digest: digest(b)})
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m, nil
+}
+
+// origin returns the git reference and commit the vendored source was fetched from, or ""
+// if the source is local.  Sources within fetched repositories -- e.g. the files
+// referenced by a remote base -- are returned as references to the repository.
+func (r *resolver) origin(source string) (string, string) {
+	if isRemoteReference("", source) {
+		repo, _, version := parseRemoteReference(source)
+		if version == "" {
+			version = "HEAD"
+		}
+		return source, r.commits[repo+"@"+version]
+	}
+	for key, dir := range r.fetched {
+		if rel, err := filepath.Rel(dir, source); err == nil && !strings.HasPrefix(rel, "..") {
+			i := strings.LastIndex(key, "@")
+			ref := key[:i] + "//" + filepath.ToSlash(rel)
+			if key[i+1:] != "HEAD" {
+				ref += "?ref=" + key[i+1:]
+			}
+			return ref, r.commits[key]
+		}
+	}
+	return "", ""
+}
+
+// digest returns the sha256 digest of b
+func digest(b []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+}
+
+// readBundleFiles reads the files of the package in dir, by slash separated path
+func readBundleFiles(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = b
+		return nil
+	})
+	return files, err
+}
+
+// writeBundle writes the files to w as a gzipped tar archive.  The files are written in
+// order without timestamps, so that the archive is reproducible.
+func writeBundle(w io.Writer, files map[string][]byte) error {
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	gz := gzip.NewWriter(w)
+	gz.ModTime = time.Unix(0, 0)
+	tw := tar.NewWriter(gz)
+	for _, p := range paths {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     p,
+			Mode:     0600,
+			Size:     int64(len(files[p])),
+			ModTime:  time.Unix(0, 0),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[p]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// readBundle reads the manifest and files of the bundle at archive
+func readBundle(archive string) (bundleManifest, map[string][]byte, error) {
+	var m bundleManifest
+	f, err := os.Open(archive)
+	if err != nil {
+		return m, nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return m, nil, fmt.Errorf("%s: %v", archive, err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, nil, fmt.Errorf("%s: %v", archive, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		// don't write outside of the directory the bundle is unpacked to
+		name := path.Clean(h.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return m, nil, fmt.Errorf("%s: invalid path %s", archive, h.Name)
+		}
+		if files[name], err = ioutil.ReadAll(tr); err != nil {
+			return m, nil, fmt.Errorf("%s: %v", archive, err)
+		}
+	}
+	b, found := files[bundleManifestFile]
+	if !found {
+		return m, nil, fmt.Errorf("%s: missing %s", archive, bundleManifestFile)
+	}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return m, nil, fmt.Errorf("%s: %s: %v", archive, bundleManifestFile, err)
+	}
+	return m, files, nil
+}
+
+// verifyBundle reads the bundle at archive and verifies its files against its manifest,
+// printing the files which fail verification to w
+func verifyBundle(w io.Writer, archive string) (bundleManifest, map[string][]byte, error) {
+	m, files, err := readBundle(archive)
+	if err != nil {
+		return m, nil, err
+	}
+	failed := 0
+	listed := map[string]bool{bundleManifestFile: true}
+	for _, f := range m.Files {
+		listed[f.Path] = true
+		b, found := files[f.Path]
+		if !found {
+			fmt.Fprintf(w, "missing: %s\n", f.Path)
+			failed++
+		} else if digest(b) != f.Digest {
+			fmt.Fprintf(w, "modified: %s\n", f.Path)
+			failed++
+		}
+	}
+	var unexpected []string
+	for p := range files {
+		if !listed[p] {
+			unexpected = append(unexpected, p)
+		}
+	}
+	sort.Strings(unexpected)
+	for _, p := range unexpected {
+		fmt.Fprintf(w, "unexpected: %s\n", p)
+		failed++
+	}
+	if failed > 0 {
+		return m, nil, fmt.Errorf("%d files failed verification", failed)
+	}
+	return m, files, nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestBundleCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"base/kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"base/deployment.yaml":    "kind: Deployment\n",
+		"patches/replicas.yaml":   "kind: Deployment\nspec:\n  replicas: 3\n",
+		"app/kustomization.yaml": `resources:
+- ../base
+patchesStrategicMerge:
+- ../patches/replicas.yaml
+configMapGenerator:
+- name: config
+  files:
+  - config.properties
+`,
+		"app/config.properties": "a=b\n",
+	})
+
+	// create the bundle
+	archive := filepath.Join(d, "app.tar.gz")
+	b := &bytes.Buffer{}
+	c := cmd.BundleCommand()
+	c.SetOut(b)
+	c.SetArgs([]string{"create", filepath.Join(d, "app"), archive})
+	if !assert.NoError(t, c.Execute()) {
+		return
+	}
+	assert.Equal(t, `vendored `+filepath.Join(d, "base")+` to vendor/base
+vendored `+filepath.Join(d, "patches", "replicas.yaml")+` to vendor/replicas.yaml
+wrote 5 files to `+archive+`
+`, b.String())
+
+	// verify the bundle
+	b.Reset()
+	c = cmd.BundleCommand()
+	c.SetOut(b)
+	c.SetArgs([]string{"verify", archive})
+	if !assert.NoError(t, c.Execute()) {
+		return
+	}
+	assert.Equal(t, "verified 5 files\n", b.String())
+
+	// unpack the bundle
+	dest := filepath.Join(d, "out")
+	c = cmd.BundleCommand()
+	c.SetOut(&bytes.Buffer{})
+	c.SetArgs([]string{"unpack", archive, dest})
+	if !assert.NoError(t, c.Execute()) {
+		return
+	}
+	assertFile(t, filepath.Join(dest, "kustomization.yaml"), `resources:
+- vendor/base
+patchesStrategicMerge:
+- vendor/replicas.yaml
+configMapGenerator:
+- name: config
+  files:
+  - config.properties
+`)
+	assertFile(t, filepath.Join(dest, "vendor", "replicas.yaml"), "kind: Deployment\nspec:\n  replicas: 3\n")
+	assertFile(t, filepath.Join(dest, "vendor", "base", "deployment.yaml"), "kind: Deployment\n")
+	assertFile(t, filepath.Join(dest, "bundle.yaml"), `apiVersion: config.kubernetes.io/v1alpha1
+kind: Bundle
+origins:
+- path: vendor/base
+  source: ../base
+- path: vendor/replicas.yaml
+  source: ../patches/replicas.yaml
+files:
+- path: config.properties
+  digest: sha256:77e7ce77c707a8147bb65a710ac1af3fca02c8dd2be36762ec9611d90fb5c041
+- path: kustomization.yaml
+  digest: sha256:0b5d3edbb1285acb9673cbe4b1ff79352ee51b6ef16092f3f700dd78f699180e
+- path: vendor/base/deployment.yaml
+  digest: sha256:2e15259aa2d978f7affbf2000945c972ebf0beed0e2dcb7b0c490ee33871f120
+- path: vendor/base/kustomization.yaml
+  digest: sha256:17a6bcf23161dfd81192fc973fe9a6c25a3b2a775ca06e597f06108954ea431b
+- path: vendor/replicas.yaml
+  digest: sha256:78cbe818a95e19fdd3fd74f5cf26df4c7046433ff04a1f589db21939a27659a0
+`)
+
+	// the same package is bundled to the same archive
+	again := filepath.Join(d, "again.tar.gz")
+	c = cmd.BundleCommand()
+	c.SetOut(&bytes.Buffer{})
+	c.SetArgs([]string{"create", filepath.Join(d, "app"), again})
+	if !assert.NoError(t, c.Execute()) {
+		return
+	}
+	first, _ := ioutil.ReadFile(archive)
+	second, _ := ioutil.ReadFile(again)
+	assert.Equal(t, first, second)
+}
+
+// writeArchive writes the files to a gzipped tar archive at path
+func writeArchive(t *testing.T, path string, files [][2]string) {
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		if !assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg, Name: f[0], Mode: 0600, Size: int64(len(f[1]))})) {
+			t.FailNow()
+		}
+		_, err := tw.Write([]byte(f[1]))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	if !assert.NoError(t, ioutil.WriteFile(path, b.Bytes(), 0600)) {
+		t.FailNow()
+	}
+}
+
+func TestBundleCommand_verifyFailed(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	archive := filepath.Join(d, "app.tar.gz")
+	writeArchive(t, archive, [][2]string{
+		{"bundle.yaml", `apiVersion: config.kubernetes.io/v1alpha1
+kind: Bundle
+files:
+- path: kustomization.yaml
+  digest: sha256:0b5d3edbb1285acb9673cbe4b1ff79352ee51b6ef16092f3f700dd78f699180e
+- path: deployment.yaml
+  digest: sha256:2e15259aa2d978f7affbf2000945c972ebf0beed0e2dcb7b0c490ee33871f120
+`},
+		{"kustomization.yaml", "resources:\n- deployment.yaml\n- service.yaml\n"},
+		{"service.yaml", "kind: Service\n"},
+	})
+
+	b := &bytes.Buffer{}
+	c := cmd.BundleCommand()
+	c.SetOut(b)
+	c.SilenceUsage = true
+	c.SilenceErrors = true
+	c.SetArgs([]string{"verify", archive})
+	assert.EqualError(t, c.Execute(), "3 files failed verification")
+	assert.Equal(t, `modified: kustomization.yaml
+missing: deployment.yaml
+unexpected: service.yaml
+`, b.String())
+
+	// nothing is unpacked from bundles failing verification
+	dest := filepath.Join(d, "out")
+	c = cmd.BundleCommand()
+	c.SetOut(&bytes.Buffer{})
+	c.SetArgs([]string{"unpack", archive, dest})
+	assert.EqualError(t, c.Execute(), "3 files failed verification")
+	_, err = os.Stat(dest)
+	assert.True(t, os.IsNotExist(err))
+
+	// files aren't unpacked outside of DEST
+	writeArchive(t, archive, [][2]string{{"../evil.yaml", "kind: Secret\n"}})
+	c = cmd.BundleCommand()
+	c.SetOut(&bytes.Buffer{})
+	c.SetArgs([]string{"unpack", archive, dest})
+	assert.EqualError(t, c.Execute(), archive+": invalid path ../evil.yaml")
+}
+
+func TestBundleCommand_remote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	// create a repository with a tagged base
+	repo := filepath.Join(d, "repo")
+	writeFiles(t, repo, map[string]string{
+		"base/kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"base/deployment.yaml":    "kind: Deployment\n",
+	})
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com",
+			"commit", "--quiet", "-m", "base"},
+		{"tag", "v1"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = repo
+		out, err := c.CombinedOutput()
+		if !assert.NoError(t, err, string(out)) {
+			return
+		}
+	}
+	c := exec.Command("git", "rev-parse", "HEAD")
+	c.Dir = repo
+	commit, err := c.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ref := "file://" + repo + "//base?ref=v1"
+	writeFiles(t, d, map[string]string{"app/kustomization.yaml": "resources:\n- " + ref + "\n"})
+	archive := filepath.Join(d, "app.tar.gz")
+	bc := cmd.BundleCommand()
+	bc.SetOut(&bytes.Buffer{})
+	bc.SetArgs([]string{"create", filepath.Join(d, "app"), archive})
+	if !assert.NoError(t, bc.Execute()) {
+		return
+	}
+	dest := filepath.Join(d, "out")
+	bc = cmd.BundleCommand()
+	bc.SetOut(&bytes.Buffer{})
+	bc.SetArgs([]string{"unpack", archive, dest})
+	if !assert.NoError(t, bc.Execute()) {
+		return
+	}
+	manifest, err := ioutil.ReadFile(filepath.Join(dest, "bundle.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(manifest), `origins:
+- path: vendor/base
+  source: `+ref+`
+  commit: `+strings.TrimSpace(string(commit))+`
+`)
+}
@@ -26,6 +26,9 @@ func GetCatRunner() *CatRunner {
 		Example: `# print Resource config from a directory
 kyaml cat my-dir/
 
+# print Resource config from a directory, skipping test data
+kyaml cat my-dir/ --exclude '**/testdata/**'
+
 # wrap Resource config from a directory in an ResourceList
 kyaml cat my-dir/ --wrap-kind ResourceList --wrap-version config.kubernetes.io/v1alpha1 --function-config fn.yaml
 
@@ -36,6 +39,14 @@ kyaml cat my-dir/ --wrap-kind ResourceList --wrap-version config.kubernetes.io/v
 	}
 	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
 		"also print resources from subpackages.")
+	c.Flags().StringSliceVar(&r.Include, "include", []string{},
+		"only read files whose path relative to the directory matches a pattern, e.g. 'manifests/**'.")
+	c.Flags().StringSliceVar(&r.Exclude, "exclude", []string{},
+		"skip files and directories whose path relative to the directory matches a pattern, e.g. '**/testdata/**'.")
+	c.Flags().BoolVar(&r.FollowSymlinks, "follow-symlinks", false,
+		"also read resources from symlinked directories.")
+	c.Flags().StringVar(&r.SymlinkRoot, "symlink-root", "",
+		"directory the followed symlinks must resolve within, e.g. the repository root.  defaults to the directory.")
 	c.Flags().BoolVar(&r.Format, "format", true,
 		"format resource config yaml before printing.")
 	c.Flags().BoolVar(&r.KeepAnnotations, "annotate", false,
@@ -55,6 +66,10 @@ kyaml cat my-dir/ --wrap-kind ResourceList --wrap-version config.kubernetes.io/v
 		"if true, include local-config in the output.")
 	c.Flags().BoolVar(&r.ExcludeNonLocal, "exclude-non-local", false,
 		"if true, exclude non-local-config in the output.")
+	c.Flags().BoolVar(&r.RequireSigned, "require-signed", false,
+		"verify the signatures of the packages before reading them -- see kyaml verify-signatures.")
+	c.Flags().StringSliceVar(&r.SignatureKeys, "key", []string{},
+		"files containing the public keys trusted to sign the packages, for --require-signed.")
 	r.Command = c
 	return r
 }
@@ -66,6 +81,10 @@ func CatCommand() *cobra.Command {
 // CatRunner contains the run function
 type CatRunner struct {
 	IncludeSubpackages bool
+	Include            []string
+	Exclude            []string
+	FollowSymlinks     bool
+	SymlinkRoot        string
 	Format             bool
 	KeepAnnotations    bool
 	WrapKind           string
@@ -75,10 +94,18 @@ type CatRunner struct {
 	StripComments      bool
 	IncludeLocal       bool
 	ExcludeNonLocal    bool
+	RequireSigned      bool
+	SignatureKeys      []string
 	Command            *cobra.Command
 }
 
 func (r *CatRunner) runE(c *cobra.Command, args []string) error {
+	if r.RequireSigned {
+		if err := requireSigned(args, r.SignatureKeys); err != nil {
+			return handleError(c, err)
+		}
+	}
+
 	// if there is a function-config specified, emit it
 	var functionConfig *yaml.RNode
 	if r.FunctionConfig != "" {
@@ -97,6 +124,10 @@ func (r *CatRunner) runE(c *cobra.Command, args []string) error {
 	for _, a := range args {
 		inputs = append(inputs, kio.LocalPackageReader{
 			PackagePath:        a,
+			Include:            r.Include,
+			Exclude:            r.Exclude,
+			FollowSymlinks:     r.FollowSymlinks,
+			SymlinkRoot:        r.SymlinkRoot,
 			IncludeSubpackages: r.IncludeSubpackages,
 		})
 	}
@@ -126,5 +157,6 @@ func (r *CatRunner) runE(c *cobra.Command, args []string) error {
 		Style:                 yaml.GetStyle(r.Styles...),
 	})
 
-	return handleError(c, kio.Pipeline{Inputs: inputs, Filters: fltr, Outputs: outputs}.Execute())
+	return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Filters: fltr, Outputs: outputs}).Execute())
 }
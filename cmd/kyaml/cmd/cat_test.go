@@ -325,3 +325,83 @@ spec:
 		return
 	}
 }
+
+func TestCmd_filesIncludeExclude(t *testing.T) {
+	d, err := ioutil.TempDir("", "kustomize-cat-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	for path, value := range map[string]string{
+		"f1.yaml":                            "kind: Deployment\nmetadata:\n  name: foo\n",
+		"f2.yml":                             "kind: Service\nmetadata:\n  name: foo\n",
+		filepath.Join("testdata", "f3.yaml"): "kind: Deployment\nmetadata:\n  name: test\n",
+	} {
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(d, path)), 0700)) {
+			return
+		}
+		if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, path), []byte(value), 0600)) {
+			return
+		}
+	}
+
+	b := &bytes.Buffer{}
+	r := cmd.GetCatRunner()
+	r.Command.SetArgs([]string{d, "--include", "*.yaml", "--exclude", "**/testdata/**"})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	if !assert.Equal(t, `kind: Deployment
+metadata:
+  name: foo
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: f1.yaml
+`, b.String()) {
+		return
+	}
+}
+
+func TestCmd_filesFollowSymlinks(t *testing.T) {
+	d, err := ioutil.TempDir("", "kustomize-cat-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	if !assert.NoError(t, os.MkdirAll(filepath.Join(d, "base"), 0700)) {
+		return
+	}
+	if !assert.NoError(t, os.MkdirAll(filepath.Join(d, "app"), 0700)) {
+		return
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "base", "f1.yaml"),
+		[]byte("kind: Deployment\nmetadata:\n  name: foo\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, os.Symlink(filepath.Join("..", "base"), filepath.Join(d, "app", "base"))) {
+		return
+	}
+
+	b := &bytes.Buffer{}
+	r := cmd.GetCatRunner()
+	r.Command.SetArgs([]string{filepath.Join(d, "app"), "--follow-symlinks", "--symlink-root", d})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	if !assert.Equal(t, `kind: Deployment
+metadata:
+  name: foo
+  annotations:
+    config.kubernetes.io/package: base
+    config.kubernetes.io/path: base/f1.yaml
+`, b.String()) {
+		return
+	}
+}
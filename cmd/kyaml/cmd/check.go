@@ -0,0 +1,119 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/conformance"
+)
+
+// GetCheckRunner returns a command CheckRunner.
+func GetCheckRunner() *CheckRunner {
+	r := &CheckRunner{}
+	c := &cobra.Command{
+		Use:   "check DIR",
+		Short: "Check the consistency of a package",
+		Long: `Check the consistency of a package.
+
+check verifies the following package invariants:
+
+  parse:            each file parses, and each Resource has an apiVersion and kind --
+                    kustomization files and Kptfiles aren't Resources
+  duplicate-id:     no two Resources have the same kind, namespace and name
+  path-annotation:  path annotations in the files match the file locations
+  references:       the Resources referenced by name are in the package (see
+                    'kyaml rename --help' for the list of references)
+  package-boundary: Resources only reference Resources in their own package --
+                    subpackages are identified by the --package-file-name file
+
+check exits non-zero if any invariant is violated.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# check a package
+kyaml check my-dir/
+
+# check a package in CI, printing the violations as json
+kyaml check my-dir/ --output json
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also check subpackages.")
+	c.Flags().StringVar(&r.PackageFileName, "package-file-name", "Kptfile",
+		"name of the file identifying subpackages.")
+	SetOutputFormats(c, OutputJSON)
+	r.Command = c
+	return r
+}
+
+func CheckCommand() *cobra.Command {
+	return GetCheckRunner().Command
+}
+
+// CheckRunner contains the run function
+type CheckRunner struct {
+	IncludeSubpackages bool
+	PackageFileName    string
+	Command            *cobra.Command
+}
+
+// checkReport is the machine-readable output of check
+type checkReport struct {
+	Failed  int                  `json:"failed"`
+	Results []conformance.Result `json:"results"`
+}
+
+func (r *CheckRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	results, err := conformance.PackageChecker{
+		Path:               args[0],
+		PackageFileName:    r.PackageFileName,
+		IncludeSubpackages: r.IncludeSubpackages,
+	}.Check()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	report := checkReport{Failed: conformance.Failed(results), Results: results}
+	if report.Results == nil {
+		report.Results = []conformance.Result{}
+	}
+
+	if output == OutputJSON {
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		if err := e.Encode(report); err != nil {
+			return handleError(c, err)
+		}
+	} else {
+		for _, res := range results {
+			id := res.Name
+			if res.Namespace != "" {
+				id = res.Namespace + "/" + res.Name
+			}
+			if res.Kind != "" {
+				fmt.Fprintf(c.OutOrStdout(), "%s:%d: [%s] %s %s: %s\n",
+					res.File, res.Line, res.Check, res.Kind, id, res.Message)
+			} else {
+				fmt.Fprintf(c.OutOrStdout(), "%s:%d: [%s] %s\n",
+					res.File, res.Line, res.Check, res.Message)
+			}
+		}
+	}
+
+	if report.Failed > 0 {
+		return handleError(c, fmt.Errorf("%d package checks failed", report.Failed))
+	}
+	return nil
+}
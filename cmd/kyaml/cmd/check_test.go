@@ -0,0 +1,71 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestCheckCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "pod.yaml"), []byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  volumes:
+  - name: config
+    configMap:
+      name: config
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetCheckRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d})
+	assert.EqualError(t, r.Command.Execute(), "1 package checks failed")
+	assert.Equal(t, "pod.yaml:9: [references] Pod nginx: ConfigMap config not found in the package\n",
+		b.String())
+
+	// the package is consistent once the ConfigMap is added
+	err = ioutil.WriteFile(filepath.Join(d, "configmap.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+	r = cmd.GetCheckRunner()
+	b = &bytes.Buffer{}
+	r.Command.SetOut(b)
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{d, "-o", "json"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	var report map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(b.Bytes(), &report)) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{"failed": float64(0), "results": []interface{}{}}, report)
+}
@@ -107,8 +107,8 @@ func (r *WrapRunner) runE(c *cobra.Command, args []string) error {
 	}
 	inputs = append(inputs, &kio.ByteReader{Reader: xargsOut})
 
-	if err := (kio.Pipeline{Inputs: inputs, Filters: fltrs, Outputs: []kio.Writer{buff}}).
-		Execute(); err != nil {
+	if err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Filters: fltrs, Outputs: []kio.Writer{buff}}).Execute(); err != nil {
 		return err
 	}
 
@@ -126,7 +126,7 @@ func (r *WrapRunner) runE(c *cobra.Command, args []string) error {
 			FilenamePattern: filepath.Join("config", filters.DefaultFilenamePattern)},
 		&filters.FormatFilter{})
 
-	err := kio.Pipeline{
+	err := ConfigurePipeline(c, kio.Pipeline{
 		Inputs:  inputs,
 		Filters: fltrs,
 		Outputs: []kio.Writer{kio.ByteWriter{
@@ -134,7 +134,7 @@ func (r *WrapRunner) runE(c *cobra.Command, args []string) error {
 			KeepReaderAnnotations: true,
 			Writer:                c.OutOrStdout(),
 			WrappingKind:          kio.ResourceListKind,
-			WrappingApiVersion:    kio.ResourceListApiVersion}}}.Execute()
+			WrappingApiVersion:    kio.ResourceListApiVersion}}}).Execute()
 	if err != nil {
 		return err
 	}
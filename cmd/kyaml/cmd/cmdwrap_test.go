@@ -116,8 +116,8 @@ items:
       name: test
       app: nginx
     ports:
-    - name: http
-      # This i the port.
+    - # This i the port.
+      name: http
       port: 8080
       targetPort: 8080
 `
@@ -168,8 +168,8 @@ items:
       name: test
       app: nginx
     ports:
-    - name: http
-      # This i the port.
+    - # This i the port.
+      name: http
       port: 8080
       targetPort: 8080
 `
@@ -223,8 +223,8 @@ items:
       name: test
       app: nginx
     ports:
-    - name: http
-      # This i the port.
+    - # This i the port.
+      name: http
       port: 8080
       targetPort: 8080
 `
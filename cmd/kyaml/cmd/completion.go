@@ -0,0 +1,235 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// GetCompletionRunner returns a command CompletionRunner.
+func GetCompletionRunner() *CompletionRunner {
+	r := &CompletionRunner{}
+	c := &cobra.Command{
+		Use:   "completion SHELL",
+		Short: "Generate shell completion scripts",
+		Long: `Generate shell completion scripts.
+
+completion prints a script completing the kyaml commands, flags and arguments to stdout.
+SHELL may be one of 'bash', 'zsh', 'fish' or 'powershell'.
+
+In addition to the commands and flags:
+
+  - the bash and fish scripts complete the values of flags accepting a fixed set of
+    values -- e.g. tree --graph-structure
+  - the bash, zsh and fish scripts complete directories for DIR arguments
+
+  SHELL:
+    Shell to generate the completion script for.
+`,
+		Example: `# load completion in the current bash shell
+source <(kyaml completion bash)
+
+# install completion for zsh
+kyaml completion zsh > "${fpath[1]}/_kyaml"
+
+# install completion for fish
+kyaml completion fish > ~/.config/fish/completions/kyaml.fish
+
+# load completion in the current powershell
+kyaml completion powershell | Out-String | Invoke-Expression
+`,
+		RunE:      r.runE,
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	}
+	r.Command = c
+	return r
+}
+
+func CompletionCommand() *cobra.Command {
+	return GetCompletionRunner().Command
+}
+
+// CompletionRunner contains the run function
+type CompletionRunner struct {
+	Command *cobra.Command
+}
+
+const (
+	// flagValuesAnnotation is the flag annotation containing the values completed for the flag
+	flagValuesAnnotation = "kyaml_completion_flag_values"
+
+	// bashCompleteValues is the bash function completing the values of flags marked with
+	// markFlagValues
+	bashCompleteValues = "__kyaml_complete_values"
+)
+
+// markFlagValues marks the flag name of c as accepting one of values, so that the values
+// are completed by the completion scripts.  The flag may be a local or persistent flag.
+func markFlagValues(c *cobra.Command, name string, values ...string) {
+	flags := c.Flags()
+	if flags.Lookup(name) == nil {
+		flags = c.PersistentFlags()
+	}
+	_ = flags.SetAnnotation(name, flagValuesAnnotation, values)
+	_ = cobra.MarkFlagCustom(flags, name, bashCompleteValues+" "+strings.Join(values, " "))
+}
+
+// hasDirArgs returns true if the arguments of c are directories -- e.g. 'tree [DIR]'
+func hasDirArgs(c *cobra.Command) bool {
+	return strings.Contains(c.Use, "DIR")
+}
+
+// completionCommands returns the commands below root which are completed, depth first
+func completionCommands(root *cobra.Command) []*cobra.Command {
+	var cmds []*cobra.Command
+	for _, c := range root.Commands() {
+		if !c.IsAvailableCommand() && c.Name() != "help" {
+			continue
+		}
+		cmds = append(cmds, c)
+		cmds = append(cmds, completionCommands(c)...)
+	}
+	return cmds
+}
+
+func (r *CompletionRunner) runE(c *cobra.Command, args []string) error {
+	root := c.Root()
+	var err error
+	switch args[0] {
+	case "bash":
+		err = genBashCompletion(root, c.OutOrStdout())
+	case "zsh":
+		err = genZshCompletion(root, c.OutOrStdout())
+	case "fish":
+		err = genFishCompletion(root, c.OutOrStdout())
+	case "powershell":
+		err = root.GenPowerShellCompletion(c.OutOrStdout())
+	}
+	return handleError(c, err)
+}
+
+// genBashCompletion writes the bash completion script for root to w.  The flag values
+// are completed by the cobra flag completion handlers set by markFlagValues, and the
+// directory arguments by the custom function called when there is nothing else to complete.
+func genBashCompletion(root *cobra.Command, w io.Writer) error {
+	var dirCmds []string
+	for _, c := range completionCommands(root) {
+		if hasDirArgs(c) {
+			dirCmds = append(dirCmds, strings.Replace(c.CommandPath(), " ", "_", -1))
+		}
+	}
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, `%s()
+{
+    COMPREPLY=( $(compgen -W "$*" -- "$cur") )
+}
+
+__%s_custom_func()
+{
+`, bashCompleteValues, root.Name())
+	if len(dirCmds) > 0 {
+		fmt.Fprintf(b, `    case ${last_command} in
+        %s)
+            _filedir -d
+            return
+            ;;
+    esac
+`, strings.Join(dirCmds, " | "))
+	}
+	b.WriteString("}\n")
+
+	saved := root.BashCompletionFunction
+	defer func() { root.BashCompletionFunction = saved }()
+	root.BashCompletionFunction = saved + b.String()
+	return root.GenBashCompletion(w)
+}
+
+// genZshCompletion writes the zsh completion script for root to w.  The directory
+// arguments are completed using zsh positional argument hints.
+func genZshCompletion(root *cobra.Command, w io.Writer) error {
+	for _, c := range completionCommands(root) {
+		if !hasDirArgs(c) {
+			continue
+		}
+		// fails if the hint was set by a previous call -- the hint is the same
+		_ = c.MarkZshCompPositionalArgumentFile(1, "*(-/)")
+	}
+	return root.GenZshCompletion(w)
+}
+
+// genFishCompletion writes the fish completion script for root to w.
+func genFishCompletion(root *cobra.Command, w io.Writer) error {
+	b := &bytes.Buffer{}
+	name := root.Name()
+	fmt.Fprintf(b, "# fish completion for %s\n\n", name)
+	fmt.Fprintf(b, "complete -c %s -f\n", name)
+
+	writeFlags := func(cond string, flags *pflag.FlagSet) {
+		var lines []string
+		flags.VisitAll(func(f *pflag.Flag) {
+			if f.Hidden || f.Name == "help" {
+				return
+			}
+			line := fmt.Sprintf("complete -c %s", name)
+			if cond != "" {
+				line += fmt.Sprintf(" -n '%s'", cond)
+			}
+			line += " -l " + f.Name
+			if f.Shorthand != "" {
+				line += " -s " + f.Shorthand
+			}
+			if values := f.Annotations[flagValuesAnnotation]; len(values) > 0 {
+				line += fmt.Sprintf(" -xa '%s'", strings.Join(values, " "))
+			} else if f.NoOptDefVal == "" {
+				line += " -r"
+			}
+			line += fmt.Sprintf(" -d '%s'", fishQuote(f.Usage))
+			lines = append(lines, line)
+		})
+		sort.Strings(lines)
+		for _, l := range lines {
+			fmt.Fprintln(b, l)
+		}
+	}
+	writeFlags("", root.PersistentFlags())
+
+	for _, c := range completionCommands(root) {
+		fmt.Fprintln(b)
+		parent := c.Parent()
+		cond := "__fish_use_subcommand"
+		if parent != root {
+			cond = "__fish_seen_subcommand_from " + parent.Name()
+		}
+		fmt.Fprintf(b, "complete -c %s -n '%s' -a %s -d '%s'\n",
+			name, cond, c.Name(), fishQuote(c.Short))
+
+		cond = "__fish_seen_subcommand_from " + c.Name()
+		if hasDirArgs(c) {
+			fmt.Fprintf(b, "complete -c %s -n '%s' -xa '(__fish_complete_directories)'\n",
+				name, cond)
+		}
+		if len(c.ValidArgs) > 0 {
+			fmt.Fprintf(b, "complete -c %s -n '%s' -xa '%s'\n",
+				name, cond, strings.Join(c.ValidArgs, " "))
+		}
+		writeFlags(cond, c.LocalNonPersistentFlags())
+	}
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// fishQuote escapes s for use within a single quoted fish string
+func fishQuote(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
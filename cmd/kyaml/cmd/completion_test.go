@@ -0,0 +1,94 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+// completionRoot returns a root command with the completion command and the commands
+// it completes
+func completionRoot(b *bytes.Buffer) *cobra.Command {
+	root := &cobra.Command{Use: "kyaml"}
+	root.AddCommand(cmd.CompletionCommand())
+	root.AddCommand(cmd.TreeCommand())
+	root.AddCommand(cmd.OwnershipCommand())
+	root.SetOut(b)
+	return root
+}
+
+func TestCompletionCommand_bash(t *testing.T) {
+	b := &bytes.Buffer{}
+	root := completionRoot(b)
+	root.SetArgs([]string{"completion", "bash"})
+	if !assert.NoError(t, root.Execute()) {
+		return
+	}
+	assert.Contains(t, b.String(), `__kyaml_complete_values()`)
+	assert.Contains(t, b.String(),
+		`flags_completion+=("__kyaml_complete_values directory graph")`)
+	assert.Contains(t, b.String(), `        kyaml_ownership_transfer | kyaml_tree)
+            _filedir -d`)
+	assert.Empty(t, root.BashCompletionFunction)
+}
+
+func TestCompletionCommand_zsh(t *testing.T) {
+	b := &bytes.Buffer{}
+	root := completionRoot(b)
+	root.SetArgs([]string{"completion", "zsh"})
+	if !assert.NoError(t, root.Execute()) {
+		return
+	}
+	assert.Contains(t, b.String(), `function _kyaml_tree {`)
+	assert.Contains(t, b.String(), `'1: :_files -g "*(-/)"'`)
+}
+
+func TestCompletionCommand_fish(t *testing.T) {
+	b := &bytes.Buffer{}
+	root := completionRoot(b)
+	root.SetArgs([]string{"completion", "fish"})
+	if !assert.NoError(t, root.Execute()) {
+		return
+	}
+	assert.Contains(t, b.String(), `complete -c kyaml -f
+`)
+	assert.Contains(t, b.String(), `complete -c kyaml -n '__fish_use_subcommand' -a tree -d 'Display Resource structure from a directory or stdin'
+complete -c kyaml -n '__fish_seen_subcommand_from tree' -xa '(__fish_complete_directories)'
+`)
+	assert.Contains(t, b.String(), `complete -c kyaml -n '__fish_seen_subcommand_from tree' -l graph-structure -xa 'directory graph' -d 'Graph structure to use for printing the tree.  may be \'directory\' or \'graph\'.'
+`)
+	assert.Contains(t, b.String(), `complete -c kyaml -n '__fish_seen_subcommand_from ownership' -a transfer -d 'Prepare Resources for adoption from another tool'
+`)
+	assert.Contains(t, b.String(), `complete -c kyaml -n '__fish_seen_subcommand_from completion' -xa 'bash zsh fish powershell'
+`)
+}
+
+func TestCompletionCommand_powershell(t *testing.T) {
+	b := &bytes.Buffer{}
+	root := completionRoot(b)
+	root.SetArgs([]string{"completion", "powershell"})
+	if !assert.NoError(t, root.Execute()) {
+		return
+	}
+	assert.Contains(t, b.String(), `Register-ArgumentCompleter -Native -CommandName 'kyaml'`)
+	assert.Contains(t, b.String(), `'kyaml;tree' {`)
+}
+
+func TestCompletionCommand_unknownShell(t *testing.T) {
+	b := &bytes.Buffer{}
+	root := completionRoot(b)
+	root.SilenceUsage = true
+	root.SilenceErrors = true
+	root.SetArgs([]string{"completion", "tcsh"})
+	err := root.Execute()
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.Contains(t, err.Error(), `invalid argument "tcsh"`)
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/conformance"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// GetConformanceRunner returns a command ConformanceRunner.
+func GetConformanceRunner() *ConformanceRunner {
+	r := &ConformanceRunner{}
+	c := &cobra.Command{
+		Use:   "conformance [DIR]...",
+		Short: "Check Resources against a profile of conformance checks",
+		Long: `Check Resources from a local directory or stdin against a profile of conformance checks.
+
+conformance runs the following checks:
+
+  deprecated-api:   Resources must not use deprecated or removed apiVersions
+  security-context: workloads must not share host namespaces, mount host paths, run
+                    privileged containers, or add capabilities
+  names:            Resource names and labels must be valid
+  schema:           Resources must match the OpenAPI schemas (see validate)
+  resource-limits:  containers should set cpu and memory limits
+  probes:           containers of long running workloads should set liveness and
+                    readiness probes
+
+The profile selects how strict the checks are:
+
+  baseline:   resource-limits and probes are reported as warnings
+  restricted: resource-limits and probes are errors, and containers must also run
+              as non-root, disallow privilege escalation, and drop all capabilities
+
+conformance exits non-zero if any check fails with an error.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# check the Resources in a directory
+kyaml conformance my-dir/
+
+# check the Resources in a directory using the restricted profile
+kyaml conformance my-dir/ --profile restricted
+
+# check kustomize output, printing the results as json
+kustomize build | kyaml conformance --output json
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also check resources from subpackages.")
+	c.Flags().StringVar(&r.Profile, "profile", string(conformance.ProfileBaseline),
+		"profile of checks to run.  may be 'baseline' or 'restricted'.")
+	markFlagValues(c, "profile",
+		string(conformance.ProfileBaseline), string(conformance.ProfileRestricted))
+	c.Flags().StringSliceVar(&r.CRDSchemas, "crd-schema", []string{},
+		"path to a file containing CustomResourceDefinitions to validate against.")
+	SetOutputFormats(c, OutputJSON)
+	r.Command = c
+	return r
+}
+
+func ConformanceCommand() *cobra.Command {
+	return GetConformanceRunner().Command
+}
+
+// ConformanceRunner contains the run function
+type ConformanceRunner struct {
+	IncludeSubpackages bool
+	Profile            string
+	CRDSchemas         []string
+	Command            *cobra.Command
+}
+
+// conformanceReport is the machine-readable output of conformance
+type conformanceReport struct {
+	Profile  conformance.Profile  `json:"profile"`
+	Failed   int                  `json:"failed"`
+	Warnings int                  `json:"warnings"`
+	Results  []conformance.Result `json:"results"`
+}
+
+func (r *ConformanceRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	schemas, err := loadSchemas(r.CRDSchemas)
+	if err != nil {
+		return handleError(c, err)
+	}
+	checks, err := conformance.ProfileChecks(conformance.Profile(r.Profile), schemas)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	buff := &kio.PackageBuffer{}
+	if err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Outputs: []kio.Writer{buff}}).Execute(); err != nil {
+		return handleError(c, err)
+	}
+	results, err := conformance.Run(buff.Nodes, checks)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	report := conformanceReport{
+		Profile: conformance.Profile(r.Profile),
+		Failed:  conformance.Failed(results),
+		Results: results,
+	}
+	report.Warnings = len(results) - report.Failed
+	if report.Results == nil {
+		report.Results = []conformance.Result{}
+	}
+
+	if output == OutputJSON {
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		if err := e.Encode(report); err != nil {
+			return handleError(c, err)
+		}
+	} else {
+		for _, res := range results {
+			file := res.File
+			if file == "" {
+				file = "stdin"
+			}
+			id := res.Name
+			if res.Namespace != "" {
+				id = res.Namespace + "/" + res.Name
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s: %s %s: [%s] %s: %s\n",
+				file, res.Line, res.Severity, res.Kind, id, res.Check, res.Field, res.Message)
+		}
+	}
+
+	if report.Failed > 0 {
+		return handleError(c, fmt.Errorf("%d conformance checks failed", report.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const conformanceInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.17
+        livenessProbe:
+          httpGet:
+            port: 80
+        readinessProbe:
+          httpGet:
+            port: 80
+        resources:
+          limits:
+            cpu: 100m
+`
+
+func TestConformanceCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(conformanceInput), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// warnings don't fail the baseline profile
+	r := cmd.GetConformanceRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "f1.yaml:10: warning: Deployment default/nginx: [resource-limits] "+
+		"spec.template.spec.containers[name=nginx].resources.limits.memory: "+
+		"container does not set a memory limit\n", b.String())
+
+	// the restricted profile fails
+	r = cmd.GetConformanceRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	b = &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--profile", "restricted"})
+	assert.EqualError(t, r.Command.Execute(), "4 conformance checks failed")
+	assert.Equal(t, 4, strings.Count(b.String(), ": error: "))
+}
+
+func TestConformanceCommand_json(t *testing.T) {
+	r := cmd.GetConformanceRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(`apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: foo
+`))
+	r.Command.SetOut(b)
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{"-o", "json"})
+	assert.EqualError(t, r.Command.Execute(), "1 conformance checks failed")
+
+	var report map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(b.Bytes(), &report)) {
+		return
+	}
+	assert.Equal(t, "baseline", report["profile"])
+	assert.Equal(t, float64(1), report["failed"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"check":      "deprecated-api",
+		"severity":   "error",
+		"apiVersion": "extensions/v1beta1",
+		"kind":       "Ingress",
+		"name":       "foo",
+		"line":       float64(1),
+		"field":      "apiVersion",
+		"message": "extensions/v1beta1 Ingress is not served since Kubernetes 1.22, " +
+			"use networking.k8s.io/v1beta1",
+	}}, report["results"])
+}
+
+func TestConformanceCommand_profile(t *testing.T) {
+	r := cmd.GetConformanceRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetIn(strings.NewReader(conformanceInput))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"--profile", "strict"})
+	assert.EqualError(t, r.Command.Execute(),
+		"unknown profile 'strict': may be one of 'baseline' or 'restricted'")
+}
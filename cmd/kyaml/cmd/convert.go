@@ -0,0 +1,185 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+// GetConvertRunner returns a command ConvertRunner.
+func GetConvertRunner() *ConvertRunner {
+	r := &ConvertRunner{}
+	c := &cobra.Command{
+		Use:   "convert [FILE]...",
+		Short: "Convert Resource Config between YAML and JSON",
+		Long: `Convert Resource Config between YAML and JSON.
+
+The input format is detected from the content: input starting with '{' or '[' is
+read as JSON, anything else as YAML.  If --to is unset, the Resources are converted
+to the other format.
+
+YAML input may contain multiple documents.  JSON input may contain a stream of
+objects, or arrays of objects.  Resources wrapped in a List or ResourceList are
+written wrapped in the same kind, unless --wrap-kind or --json-format array is set.
+
+JSON is written as a stream of indented objects, unless --json-format is set --
+'array' writes an indented array and 'ndjson' writes each Resource as compact JSON on
+its own line, e.g. for jq.
+
+The order of the fields is preserved.  Comments are dropped when converting to JSON.
+
+  FILE:
+    Path to a file to convert.  Reads from stdin if unset.
+`,
+		Example: `# convert a json file to yaml
+kyaml convert deployment.json
+
+# convert a multi-document yaml stream to a json List
+kyaml cat my-dir/ | kyaml convert --to json --wrap-kind List --wrap-version v1
+
+# query the images of a package with jq
+kyaml cat my-dir/ | kyaml convert --json-format ndjson | jq -r '.. | .image? // empty'
+`,
+		RunE: r.runE,
+	}
+	c.Flags().StringVar(&r.To, "to", "",
+		"output format.  may be 'json' or 'yaml'.  defaults to the format not read.")
+	markFlagValues(c, "to", "json", "yaml")
+	c.Flags().StringVar(&r.WrapKind, "wrap-kind", "",
+		"if set, wrap the output in this list type kind.")
+	c.Flags().StringVar(&r.WrapApiVersion, "wrap-version", "",
+		"if set, wrap the output in this list type apiVersion.")
+	c.Flags().StringVar(&r.JSONFormat, "json-format", "stream",
+		"format of json output.  may be 'stream', 'array' or 'ndjson'.")
+	markFlagValues(c, "json-format", "stream", "array", "ndjson")
+	r.Command = c
+	return r
+}
+
+func ConvertCommand() *cobra.Command {
+	return GetConvertRunner().Command
+}
+
+// ConvertRunner contains the run function
+type ConvertRunner struct {
+	To             string
+	WrapKind       string
+	WrapApiVersion string
+	JSONFormat     string
+	Command        *cobra.Command
+}
+
+func (r *ConvertRunner) runE(c *cobra.Command, args []string) error {
+	if r.To != "" && r.To != formatJSON && r.To != formatYAML {
+		return handleError(c, fmt.Errorf(
+			"--to must be one of '%s' or '%s', got '%s'", formatJSON, formatYAML, r.To))
+	}
+	jsonFormat := kio.JSONFormat(r.JSONFormat)
+	if r.JSONFormat == "stream" {
+		jsonFormat = kio.JSONFormatStream
+	}
+
+	var inputs [][]byte
+	for _, a := range args {
+		b, err := ioutil.ReadFile(a)
+		if err != nil {
+			return handleError(c, err)
+		}
+		inputs = append(inputs, b)
+	}
+	if len(inputs) == 0 {
+		b, err := ioutil.ReadAll(c.InOrStdin())
+		if err != nil {
+			return handleError(c, err)
+		}
+		inputs = append(inputs, b)
+	}
+
+	var nodes []*yaml.RNode
+	var functionConfig *yaml.RNode
+	kind, apiVersion := r.WrapKind, r.WrapApiVersion
+	for i := range inputs {
+		var wrapKind, wrapApiVersion string
+		var fc *yaml.RNode
+		var n []*yaml.RNode
+		var err error
+		if isJSON(inputs[i]) {
+			if r.To == "" {
+				r.To = formatYAML
+			}
+			jr := &kio.JSONReader{Reader: bytes.NewReader(inputs[i]), OmitReaderAnnotations: true}
+			n, err = jr.Read()
+			wrapKind, wrapApiVersion, fc = jr.WrappingKind, jr.WrappingApiVersion, jr.FunctionConfig
+		} else {
+			if r.To == "" {
+				r.To = formatJSON
+			}
+			br := &kio.ByteReader{Reader: bytes.NewReader(inputs[i]), OmitReaderAnnotations: true}
+			n, err = br.Read()
+			wrapKind, wrapApiVersion, fc = br.WrappingKind, br.WrappingApiVersion, br.FunctionConfig
+		}
+		if err != nil {
+			return handleError(c, err)
+		}
+		nodes = append(nodes, n...)
+
+		// keep the list kind if the Resources were read from a single list, unless they are
+		// written as an array
+		if r.WrapKind == "" && len(inputs) == 1 && jsonFormat != kio.JSONFormatArray {
+			kind, apiVersion, functionConfig = wrapKind, wrapApiVersion, fc
+		}
+	}
+
+	var w kio.Writer
+	if r.To == formatJSON {
+		w = kio.JSONWriter{
+			Writer:             c.OutOrStdout(),
+			WrappingKind:       kind,
+			WrappingApiVersion: apiVersion,
+			FunctionConfig:     functionConfig,
+			Format:             jsonFormat,
+		}
+	} else {
+		for i := range nodes {
+			blockStyle(nodes[i].YNode())
+		}
+		if functionConfig != nil {
+			blockStyle(functionConfig.YNode())
+		}
+		w = kio.ByteWriter{
+			Writer:             c.OutOrStdout(),
+			WrappingKind:       kind,
+			WrappingApiVersion: apiVersion,
+			FunctionConfig:     functionConfig,
+		}
+	}
+	return handleError(c, w.Write(nodes))
+}
+
+// isJSON returns true if the input looks like JSON rather than YAML.
+func isJSON(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	return bytes.HasPrefix(b, []byte("{")) || bytes.HasPrefix(b, []byte("["))
+}
+
+// blockStyle clears the flow and quoting styles of nodes read from JSON, so that they
+// are written as idiomatic YAML.  Strings which would otherwise be read as another type
+// are still quoted by the encoder.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for i := range node.Content {
+		blockStyle(node.Content[i])
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestConvertCommand_toJSON(t *testing.T) {
+	r := cmd.GetConvertRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(`kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: foo # comment
+spec:
+  replicas: 3
+---
+kind: Service
+apiVersion: v1
+metadata:
+  name: foo
+`))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `{
+  "kind": "Deployment",
+  "apiVersion": "apps/v1",
+  "metadata": {
+    "name": "foo"
+  },
+  "spec": {
+    "replicas": 3
+  }
+}
+{
+  "kind": "Service",
+  "apiVersion": "v1",
+  "metadata": {
+    "name": "foo"
+  }
+}
+`, b.String())
+}
+
+func TestConvertCommand_toYAML(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "list.json"), []byte(`{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "kind": "ConfigMap",
+      "apiVersion": "v1",
+      "metadata": {"name": "foo"},
+      "data": {"enabled": "true", "count": "1", "name": "bar"}
+    }
+  ]
+}
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetConvertRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{filepath.Join(d, "list.json")})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `apiVersion: v1
+kind: List
+items:
+- kind: ConfigMap
+  apiVersion: v1
+  metadata:
+    name: foo
+  data:
+    enabled: "true"
+    count: "1"
+    name: bar
+`, b.String())
+}
+
+func TestConvertCommand_wrap(t *testing.T) {
+	r := cmd.GetConvertRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(`[{"kind": "Deployment"}, {"kind": "Service"}]`))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"--to", "json", "--wrap-kind", "List", "--wrap-version", "v1"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "kind": "Deployment"
+    },
+    {
+      "kind": "Service"
+    }
+  ]
+}
+`, b.String())
+}
+
+func TestConvertCommand_invalidFormat(t *testing.T) {
+	r := cmd.GetConvertRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetIn(strings.NewReader(`kind: Deployment`))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"--to", "xml"})
+	assert.EqualError(t, r.Command.Execute(),
+		"--to must be one of 'json' or 'yaml', got 'xml'")
+}
+
+func TestConvertCommand_jsonFormat(t *testing.T) {
+	input := `apiVersion: v1
+kind: List
+items:
+- kind: Deployment
+  metadata:
+    name: foo
+- kind: Service
+`
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "ndjson", expected: `{"apiVersion":"v1","kind":"List","items":[{"kind":"Deployment","metadata":{"name":"foo"}},{"kind":"Service"}]}
+`},
+		{format: "array", expected: `[
+  {
+    "kind": "Deployment",
+    "metadata": {
+      "name": "foo"
+    }
+  },
+  {
+    "kind": "Service"
+  }
+]
+`},
+	}
+	for _, test := range tests {
+		r := cmd.GetConvertRunner()
+		b := &bytes.Buffer{}
+		r.Command.SetIn(strings.NewReader(input))
+		r.Command.SetOut(b)
+		r.Command.SetArgs([]string{"--json-format", test.format})
+		if !assert.NoError(t, r.Command.Execute(), test.format) {
+			continue
+		}
+		assert.Equal(t, test.expected, b.String(), test.format)
+	}
+}
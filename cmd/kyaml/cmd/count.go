@@ -85,8 +85,9 @@ func (r *CountRunner) runE(c *cobra.Command, args []string) error {
 			return nil
 		}))
 	}
-	return handleError(c, kio.Pipeline{
-		Inputs:  inputs,
-		Outputs: out,
-	}.Execute())
+	return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+		Inputs:    inputs,
+		Outputs:   out,
+		ParseMode: kio.ParseModeFast,
+	}).Execute())
 }
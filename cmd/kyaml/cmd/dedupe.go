@@ -0,0 +1,132 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetDedupeRunner returns a command DedupeRunner.
+func GetDedupeRunner() *DedupeRunner {
+	r := &DedupeRunner{}
+	c := &cobra.Command{
+		Use:   "dedupe DIR...",
+		Short: "Find duplicate Resources in a local directory",
+		Long: `Find duplicate Resources in a local directory.
+
+Resources are duplicates if they have the same apiVersion, kind, namespace and
+name.  Duplicate Resources silently break kustomize builds.
+
+Each duplicate is printed with the files containing it.  If --remove, --rename
+or --strategy is specified, the duplicates are fixed and the files written back.
+
+--strategy resolves the duplicates with one of:
+
+  keep-first: remove all but the first instance of each duplicate Resource
+  keep-last:  remove all but the last instance of each duplicate Resource
+  merge:      merge the instances of each duplicate Resource into the first,
+              with the later instances taking precedence
+  error:      fail if any Resource is duplicated -- e.g. in CI
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# print duplicate Resources
+kyaml dedupe my-dir/
+
+# remove all but the first instance of each duplicate Resource
+kyaml dedupe my-dir/ --remove
+
+# rename the second and later instances of each duplicate Resource
+kyaml dedupe my-dir/ --rename
+
+# merge the instances of each duplicate Resource
+kyaml dedupe my-dir/ --strategy merge
+
+# fail if any Resource is duplicated
+kyaml dedupe my-dir/ --strategy error
+`,
+		RunE: r.runE,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also dedupe resources from subpackages.")
+	c.Flags().BoolVar(&r.Remove, "remove", false,
+		"remove all but the first instance of each duplicate resource.")
+	c.Flags().BoolVar(&r.Rename, "rename", false,
+		"rename all but the first instance of each duplicate resource.")
+	c.Flags().StringVar((*string)(&r.Strategy), "strategy", "",
+		"strategy resolving the duplicate resources.  may be 'keep-first', 'keep-last', 'merge' or 'error'.")
+	var strategies []string
+	for _, s := range filters.DedupeStrategies {
+		strategies = append(strategies, string(s))
+	}
+	markFlagValues(c, "strategy", strategies...)
+
+	r.Command = c
+	return r
+}
+
+func DedupeCommand() *cobra.Command {
+	return GetDedupeRunner().Command
+}
+
+// DedupeRunner contains the run function
+type DedupeRunner struct {
+	IncludeSubpackages bool
+	Command            *cobra.Command
+	filters.DedupeFilter
+}
+
+func (r *DedupeRunner) runE(c *cobra.Command, args []string) error {
+	for _, path := range args {
+		rw := &kio.LocalPackageReadWriter{
+			PackagePath:        path,
+			IncludeSubpackages: r.IncludeSubpackages,
+		}
+		var outputs []kio.Writer
+		if r.Remove || r.Rename ||
+			(r.Strategy != filters.DedupeStrategyNone && r.Strategy != filters.DedupeStrategyError) {
+			outputs = append(outputs, rw)
+		}
+		// record the files of the Resources before the writer clears their annotations
+		files := map[*yaml.RNode]string{}
+		record := kio.FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+			for _, n := range nodes {
+				p, i, err := kioutil.GetFileAnnotations(n)
+				if err != nil {
+					return nil, err
+				}
+				files[n] = fmt.Sprintf("%s [%s]", p, i)
+			}
+			return nodes, nil
+		})
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs:  []kio.Reader{rw},
+			Filters: []kio.Filter{record, &r.DedupeFilter},
+			Outputs: outputs,
+		}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+
+		for _, d := range r.Duplicates {
+			id := d.Name
+			if d.Namespace != "" {
+				id = d.Namespace + "/" + d.Name
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s %s %s\n", d.ApiVersion, d.Kind, id)
+			for _, n := range d.Resources {
+				fmt.Fprintf(c.OutOrStdout(), "  %s\n", files[n])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func writeDedupeFiles(t *testing.T) string {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "f2.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return d
+}
+
+func TestDedupeCommand(t *testing.T) {
+	d := writeDedupeFiles(t)
+	defer os.RemoveAll(d)
+
+	b := &bytes.Buffer{}
+	r := cmd.GetDedupeRunner()
+	r.Command.SetArgs([]string{d})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `apps/v1 Deployment foo
+  f1.yaml [0]
+  f2.yaml [0]
+`, b.String())
+
+	// files should be unchanged
+	_, err := os.Stat(filepath.Join(d, "f2.yaml"))
+	assert.NoError(t, err)
+}
+
+func TestDedupeCommand_remove(t *testing.T) {
+	d := writeDedupeFiles(t)
+	defer os.RemoveAll(d)
+
+	out := &bytes.Buffer{}
+	r := cmd.GetDedupeRunner()
+	r.Command.SetArgs([]string{d, "--remove"})
+	r.Command.SetOut(out)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `apps/v1 Deployment foo
+  f1.yaml [0]
+  f2.yaml [0]
+`, out.String())
+
+	// f2.yaml only contained the duplicate and should be removed
+	_, err := os.Stat(filepath.Join(d, "f2.yaml"))
+	assert.True(t, os.IsNotExist(err))
+
+	b, err := ioutil.ReadFile(filepath.Join(d, "f1.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`, string(b))
+}
+
+func TestDedupeCommand_rename(t *testing.T) {
+	d := writeDedupeFiles(t)
+	defer os.RemoveAll(d)
+
+	out := &bytes.Buffer{}
+	r := cmd.GetDedupeRunner()
+	r.Command.SetArgs([]string{d, "--rename"})
+	r.Command.SetOut(out)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `apps/v1 Deployment foo
+  f1.yaml [0]
+  f2.yaml [0]
+`, out.String())
+
+	b, err := ioutil.ReadFile(filepath.Join(d, "f2.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-2
+`, string(b))
+}
+
+func TestDedupeCommand_strategy(t *testing.T) {
+	d := writeDedupeFiles(t)
+	defer os.RemoveAll(d)
+
+	// keep-last removes the first instance
+	out := &bytes.Buffer{}
+	r := cmd.GetDedupeRunner()
+	r.Command.SetArgs([]string{d, "--strategy", "keep-last"})
+	r.Command.SetOut(out)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `apps/v1 Deployment foo
+  f1.yaml [0]
+  f2.yaml [0]
+`, out.String())
+	b, err := ioutil.ReadFile(filepath.Join(d, "f1.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`, string(b))
+	_, err = os.Stat(filepath.Join(d, "f2.yaml"))
+	assert.NoError(t, err)
+
+	// error fails on the duplicates without changing the files
+	d2 := writeDedupeFiles(t)
+	defer os.RemoveAll(d2)
+	r = cmd.GetDedupeRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetArgs([]string{d2, "--strategy", "error"})
+	r.Command.SetOut(&bytes.Buffer{})
+	assert.EqualError(t, r.Command.Execute(), "found 1 duplicate resources: apps/v1 Deployment foo")
+	_, err = os.Stat(filepath.Join(d2, "f2.yaml"))
+	assert.NoError(t, err)
+}
+
+func TestDedupeCommand_subpackages(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	kustomization := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- service.yaml
+`
+	writeFiles(t, d, map[string]string{
+		"a/kustomization.yaml": kustomization,
+		"a/service.yaml":       "apiVersion: v1\nkind: Service\nmetadata:\n  name: a\n",
+		"b/kustomization.yaml": kustomization,
+		"b/service.yaml":       "apiVersion: v1\nkind: Service\nmetadata:\n  name: b\n",
+	})
+
+	b := &bytes.Buffer{}
+	r := cmd.GetDedupeRunner()
+	r.Command.SetArgs([]string{d, "--remove"})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	// the kustomization files of the subpackages aren't duplicates
+	assert.Equal(t, "", b.String())
+	assertFile(t, filepath.Join(d, "a", "kustomization.yaml"), kustomization)
+	assertFile(t, filepath.Join(d, "b", "kustomization.yaml"), kustomization)
+}
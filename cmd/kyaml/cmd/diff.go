@@ -0,0 +1,121 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/diff"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetDiffRunner returns a command DiffRunner.
+func GetDiffRunner() *DiffRunner {
+	r := &DiffRunner{}
+	c := &cobra.Command{
+		Use:   "diff OLD_DIR NEW_DIR",
+		Short: "Compare the Resources in two directories",
+		Long: `Compare the Resources in two directories.
+
+Resources are matched by apiVersion, kind, namespace and name, and each added,
+removed or modified Resource is printed.
+
+The diff command exits non-zero if the directories contain different Resources,
+so that it may be used to detect unintended changes in CI.
+
+  OLD_DIR:
+    Path to the local directory containing the old Resources.
+
+  NEW_DIR:
+    Path to the local directory containing the new Resources.
+`,
+		Example: `# print a unified diff of the Resources in two directories
+kyaml diff old-dir/ new-dir/
+
+# print the modified fields of each Resource
+kyaml diff old-dir/ new-dir/ --format structured
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(2),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also compare resources from subpackages.")
+	c.Flags().StringVar(&r.Format, "format", "unified",
+		"diff format.  may be 'unified' or 'structured'.")
+	c.Flags().IntVar(&r.Context, "context", 3,
+		"number of unchanged lines to print around changes in unified diffs.")
+
+	r.Command = c
+	return r
+}
+
+func DiffCommand() *cobra.Command {
+	return GetDiffRunner().Command
+}
+
+// DiffRunner contains the run function
+type DiffRunner struct {
+	IncludeSubpackages bool
+	Format             string
+	Context            int
+	Command            *cobra.Command
+}
+
+func (r *DiffRunner) runE(c *cobra.Command, args []string) error {
+	if r.Format != "unified" && r.Format != "structured" {
+		return handleError(c, fmt.Errorf("unsupported format %q", r.Format))
+	}
+
+	var packages [2][]*yaml.RNode
+	for i := range packages {
+		nodes, err := kio.LocalPackageReader{
+			PackagePath:           args[i],
+			IncludeSubpackages:    r.IncludeSubpackages,
+			OmitReaderAnnotations: true,
+		}.Read()
+		if err != nil {
+			return handleError(c, err)
+		}
+		packages[i] = nodes
+	}
+
+	diffs, err := diff.Resources(packages[0], packages[1])
+	if err != nil {
+		return handleError(c, err)
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	if r.Format == "structured" {
+		e := yaml.NewEncoder(c.OutOrStdout())
+		if err := e.Encode(diffs); err != nil {
+			return handleError(c, err)
+		}
+		if err := e.Close(); err != nil {
+			return handleError(c, err)
+		}
+	} else {
+		for _, d := range diffs {
+			var old, new string
+			if d.Old != nil {
+				if old, err = d.Old.String(); err != nil {
+					return handleError(c, err)
+				}
+			}
+			if d.New != nil {
+				if new, err = d.New.String(); err != nil {
+					return handleError(c, err)
+				}
+			}
+			fmt.Fprint(c.OutOrStdout(), diff.Lines(
+				fmt.Sprintf("%s %s", args[0], d.ID()),
+				fmt.Sprintf("%s %s", args[1], d.ID()),
+				old, new, r.Context))
+		}
+	}
+	return handleError(c, fmt.Errorf("%d resources differ", len(diffs)))
+}
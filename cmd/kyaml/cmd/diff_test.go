@@ -0,0 +1,129 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func writeDiffPackages(t *testing.T) (string, func()) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for _, p := range []string{"old", "new"} {
+		if !assert.NoError(t, os.Mkdir(filepath.Join(d, p), 0700)) {
+			t.FailNow()
+		}
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "old", "f1.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "new", "f1.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return d, func() { os.RemoveAll(d) }
+}
+
+func TestDiffCommand_unified(t *testing.T) {
+	d, clean := writeDiffPackages(t)
+	defer clean()
+
+	b := &bytes.Buffer{}
+	r := cmd.GetDiffRunner()
+	r.Command.SetArgs([]string{"old", "new"})
+	r.Command.SetOut(b)
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+
+	wd, err := os.Getwd()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, os.Chdir(d)) {
+		return
+	}
+	defer os.Chdir(wd)
+
+	err = r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Equal(t, "1 resources differ", err.Error())
+	}
+	assert.Equal(t, `--- old apps/v1 Deployment foo
++++ new apps/v1 Deployment foo
+@@ -3,4 +3,4 @@
+ metadata:
+   name: foo
+ spec:
+-  replicas: 1
++  replicas: 3
+`, b.String())
+}
+
+func TestDiffCommand_structured(t *testing.T) {
+	d, clean := writeDiffPackages(t)
+	defer clean()
+
+	b := &bytes.Buffer{}
+	r := cmd.GetDiffRunner()
+	r.Command.SetArgs([]string{
+		filepath.Join(d, "old"), filepath.Join(d, "new"), "--format", "structured"})
+	r.Command.SetOut(b)
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	assert.Error(t, r.Command.Execute())
+	assert.Equal(t, `- apiVersion: apps/v1
+  kind: Deployment
+  name: foo
+  type: Modified
+  fields:
+  - path: .spec.replicas
+    type: Modified
+    old: "1"
+    new: "3"
+`, b.String())
+}
+
+func TestDiffCommand_same(t *testing.T) {
+	d, clean := writeDiffPackages(t)
+	defer clean()
+
+	b := &bytes.Buffer{}
+	r := cmd.GetDiffRunner()
+	r.Command.SetArgs([]string{filepath.Join(d, "old"), filepath.Join(d, "old")})
+	r.Command.SetOut(b)
+	assert.NoError(t, r.Command.Execute())
+	assert.Equal(t, "", b.String())
+}
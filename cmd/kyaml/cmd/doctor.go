@@ -0,0 +1,104 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/conformance"
+)
+
+// GetDoctorRunner returns a command DoctorRunner.
+func GetDoctorRunner() *DoctorRunner {
+	r := &DoctorRunner{}
+	c := &cobra.Command{
+		Use:   "doctor DIR",
+		Short: "Diagnose common problems of a package",
+		Long: `Diagnose common problems of a package, as a first step in fixing a broken package.
+
+doctor looks for the following problems:
+
+  missing-file:      (error)   files referenced by kustomizations do not exist, or
+                               directories referenced by kustomizations do not contain
+                               kustomizations
+  missing-entry:     (warning) files containing Resources beside a kustomization are not
+                               in its resources
+  unreferenced-file: (warning) other files are not referenced by any kustomization --
+                               if the package contains kustomizations
+  tab-indentation:   (warning) lines of YAML files are indented with tabs, which YAML does
+                               not allow
+  large-file:        (warning) files are larger than --max-file-size
+
+doctor exits non-zero if any problem is an error.  For the consistency of the Resources
+of a package, see 'kyaml check'.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# diagnose a package
+kyaml doctor my-dir/
+
+# diagnose a package, printing the problems as json
+kyaml doctor my-dir/ --output json
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().Int64Var(&r.MaxFileSize, "max-file-size", 1<<20,
+		"size in bytes above which files are reported.")
+	SetOutputFormats(c, OutputJSON)
+	r.Command = c
+	return r
+}
+
+func DoctorCommand() *cobra.Command {
+	return GetDoctorRunner().Command
+}
+
+// DoctorRunner contains the run function
+type DoctorRunner struct {
+	MaxFileSize int64
+	Command     *cobra.Command
+}
+
+func (r *DoctorRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	results, err := conformance.PackageDoctor{Path: args[0], MaxFileSize: r.MaxFileSize}.Diagnose()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	report := checkReport{Failed: conformance.Failed(results), Results: results}
+	if report.Results == nil {
+		report.Results = []conformance.Result{}
+	}
+
+	if output == OutputJSON {
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		if err := e.Encode(report); err != nil {
+			return handleError(c, err)
+		}
+	} else {
+		for _, res := range results {
+			location := res.File
+			if res.Line > 0 {
+				location = fmt.Sprintf("%s:%d", res.File, res.Line)
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s: %s: [%s] %s\n",
+				location, res.Severity, res.Check, res.Message)
+		}
+	}
+
+	if report.Failed > 0 {
+		return handleError(c, fmt.Errorf("found %d problems", report.Failed))
+	}
+	return nil
+}
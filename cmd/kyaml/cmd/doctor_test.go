@@ -0,0 +1,83 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"kustomization.yaml": `resources:
+- deployment.yaml
+- service.yaml
+`,
+		"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+		"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: nginx
+`,
+	})
+
+	r := cmd.GetDoctorRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetArgs([]string{d})
+	err = r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Equal(t, "found 1 problems", err.Error())
+	}
+	assert.Equal(t, `configmap.yaml: warning: [missing-entry] file contains Resources, but is not in the resources of kustomization.yaml
+kustomization.yaml:3: error: [missing-file] 'service.yaml' does not exist
+`, b.String())
+}
+
+func TestDoctorCommand_ok(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+	})
+
+	r := cmd.GetDoctorRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{d, "--output", "json"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `{
+  "failed": 0,
+  "results": []
+}
+`, b.String())
+}
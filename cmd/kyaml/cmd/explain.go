@@ -0,0 +1,188 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+)
+
+// GetExplainRunner returns a command ExplainRunner.
+func GetExplainRunner() *ExplainRunner {
+	r := &ExplainRunner{}
+	c := &cobra.Command{
+		Use:   "explain KIND[.FIELD]...",
+		Short: "Print the documentation of Resource fields",
+		Long: `Print the documentation and type of a Resource or one of its fields.
+
+explain is similar to 'kubectl explain', but reads the schemas of the built-in
+Kubernetes types embedded in kyaml, and of the CustomResourceDefinitions provided with
+--crd-schema, so it does not require a cluster.
+
+The fields of list elements are written as fields of the list -- e.g.
+'pod.spec.containers.image'.
+
+  KIND:
+    Resource kind, matched case insensitively -- e.g. Deployment, deployment or
+    deployments.  If the kind has several versions the most stable version is explained
+    unless --api-version is set.
+
+  FIELD:
+    Field of the Resource to explain.
+`,
+		Example: `# explain the fields of a Deployment
+kyaml explain deployment
+
+# explain a field
+kyaml explain deployment.spec.template.spec.containers.image
+
+# explain a field of a custom resource
+kyaml explain widget.spec --crd-schema my-crd.yaml
+
+# print all the fields of a Deployment spec
+kyaml explain deployment.spec --recursive
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().StringVar(&r.APIVersion, "api-version", "",
+		"apiVersion of the Resource to explain -- e.g. apps/v1.")
+	c.Flags().StringSliceVar(&r.CRDSchemas, "crd-schema", []string{},
+		"path to a file containing CustomResourceDefinitions to explain.")
+	c.Flags().BoolVar(&r.Recursive, "recursive", false,
+		"print the fields of the fields, without their documentation.")
+	r.Command = c
+	return r
+}
+
+func ExplainCommand() *cobra.Command {
+	return GetExplainRunner().Command
+}
+
+// ExplainRunner contains the run function
+type ExplainRunner struct {
+	APIVersion string
+	CRDSchemas []string
+	Recursive  bool
+	Command    *cobra.Command
+}
+
+// explainIndent is the indent of the descriptions
+const explainIndent = "     "
+
+// explainWidth is the width descriptions are wrapped to
+const explainWidth = 80
+
+func (r *ExplainRunner) runE(c *cobra.Command, args []string) error {
+	schemas, err := loadSchemas(r.CRDSchemas)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	path := strings.Split(args[0], ".")
+	gvks := schemas.Find(path[0], r.APIVersion)
+	if len(gvks) == 0 {
+		if r.APIVersion != "" {
+			return handleError(c, fmt.Errorf(
+				"no schema for kind %s with apiVersion %s", path[0], r.APIVersion))
+		}
+		return handleError(c, fmt.Errorf("no schema for kind %s", path[0]))
+	}
+	gvk := gvks[0]
+	s, err := schemas[gvk].Field(path[1:]...)
+	if err != nil {
+		return handleError(c, fmt.Errorf("%s: %v", gvk.Kind, err))
+	}
+
+	out := c.OutOrStdout()
+	fmt.Fprintf(out, "KIND:     %s\nVERSION:  %s\n\n", gvk.Kind, gvk.ApiVersion())
+	if len(path) > 1 {
+		fmt.Fprintf(out, "FIELD:    %s <%s>\n\n", path[len(path)-1], s.TypeName())
+	}
+	fmt.Fprintln(out, "DESCRIPTION:")
+	if s.Description == "" {
+		fmt.Fprintf(out, "%s<empty>\n", explainIndent)
+	} else {
+		writeWrapped(out, explainIndent, s.Description)
+	}
+
+	for s.Items != nil {
+		s = s.Items
+	}
+	if len(s.Properties) == 0 {
+		return nil
+	}
+	fmt.Fprintln(out, "\nFIELDS:")
+	if r.Recursive {
+		writeFieldsRecursive(out, "   ", s, map[uintptr]bool{})
+		return nil
+	}
+	for i, name := range sortedFields(s) {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		f := s.Properties[name]
+		fmt.Fprintf(out, "   %s\t<%s>\n", name, f.TypeName())
+		if f.Description != "" {
+			writeWrapped(out, explainIndent+" ", f.Description)
+		}
+	}
+	return nil
+}
+
+// writeFieldsRecursive writes the names and types of the fields of s, and of their fields
+// indented beneath them.  seen contains the fields of the parents, to stop at recursive
+// types.
+func writeFieldsRecursive(out io.Writer, indent string, s *openapi.Schema, seen map[uintptr]bool) {
+	// field schemas are copied to document them, but share their properties
+	p := reflect.ValueOf(s.Properties).Pointer()
+	if seen[p] {
+		return
+	}
+	seen[p] = true
+	defer delete(seen, p)
+
+	for _, name := range sortedFields(s) {
+		f := s.Properties[name]
+		fmt.Fprintf(out, "%s%s\t<%s>\n", indent, name, f.TypeName())
+		for f.Items != nil {
+			f = f.Items
+		}
+		writeFieldsRecursive(out, indent+"   ", f, seen)
+	}
+}
+
+// sortedFields returns the names of the fields of s sorted alphabetically
+func sortedFields(s *openapi.Schema) []string {
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeWrapped writes text indented and wrapped at explainWidth, keeping its line breaks
+func writeWrapped(out io.Writer, indent, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		current := indent
+		for _, word := range strings.Fields(line) {
+			if current != indent && len(current)+1+len(word) > explainWidth {
+				fmt.Fprintln(out, current)
+				current = indent
+			}
+			if current != indent {
+				current += " "
+			}
+			current += word
+		}
+		fmt.Fprintln(out, current)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestExplainCommand_field(t *testing.T) {
+	r := cmd.GetExplainRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"deployments.spec.replicas"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `KIND:     Deployment
+VERSION:  apps/v1
+
+FIELD:    replicas <integer>
+
+DESCRIPTION:
+     Number of desired pods. This is a pointer to distinguish between explicit
+     zero and not specified. Defaults to 1.
+`, b.String())
+}
+
+func TestExplainCommand_crd(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "crd.yaml"), []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  version: v1
+  names:
+    kind: Widget
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          description: Spec is the desired state of the Widget.
+          properties:
+            replicas:
+              type: integer
+              description: Number of widgets.
+            parts:
+              type: array
+              items:
+                type: object
+                properties:
+                  name:
+                    type: string
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetExplainRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"widget.spec", "--crd-schema", filepath.Join(d, "crd.yaml")})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `KIND:     Widget
+VERSION:  example.com/v1
+
+FIELD:    spec <Object>
+
+DESCRIPTION:
+     Spec is the desired state of the Widget.
+
+FIELDS:
+   parts	<[]Object>
+
+   replicas	<integer>
+      Number of widgets.
+`, b.String())
+
+	r = cmd.GetExplainRunner()
+	b = &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{
+		"widget", "--recursive", "--crd-schema", filepath.Join(d, "crd.yaml")})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `KIND:     Widget
+VERSION:  example.com/v1
+
+DESCRIPTION:
+     <empty>
+
+FIELDS:
+   spec	<Object>
+      parts	<[]Object>
+         name	<string>
+      replicas	<integer>
+`, b.String())
+}
+
+func TestExplainCommand_errors(t *testing.T) {
+	r := cmd.GetExplainRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetErr(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"deployment.spec.size"})
+	assert.EqualError(t, r.Command.Execute(), "Deployment: field spec.size does not exist")
+
+	r = cmd.GetExplainRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetErr(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"deployment", "--api-version", "example.com/v1"})
+	assert.EqualError(t, r.Command.Execute(),
+		"no schema for kind deployment with apiVersion example.com/v1")
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetFilterRunner returns a command FilterRunner.
+func GetFilterRunner() *FilterRunner {
+	r := &FilterRunner{}
+	c := &cobra.Command{
+		Use:   "filter EXPRESSION [DIR]...",
+		Short: "Print the Resources matching an expression",
+		Long: `Print the Resources from a directory or stdin for which an expression is true.
+
+filter is more expressive than grep -- fields may be compared to each other and conditions
+combined -- without requiring a Go program.
+
+  EXPRESSION:
+    Fields are referenced by their paths -- e.g. spec.replicas, or
+    metadata.labels["app.kubernetes.io/name"] for keys which are not identifiers.
+    List elements are referenced by index -- e.g. spec.containers[0].image.
+    Fields which are not set are null.
+
+    Literals are strings ("nginx" or 'nginx'), numbers, true, false, null and lists
+    (["a", "b"]).
+
+    Comparisons are ==, !=, <, <=, >, >=, =~ and !~ (regular expression match) and in
+    (list membership).  Values of different types are not equal and not ordered.
+
+    Conditions are combined with &&, || and !, and grouped with parentheses.
+
+    has(PATH) is true if the field is set, and len(VALUE) is the length of a string,
+    list or map.
+
+    Values other than booleans are true unless they are null.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# print the Deployments with more than 3 replicas
+kyaml filter 'kind == "Deployment" && spec.replicas > 3' my-dir/
+
+# print the Resources without an app label
+kyaml filter '!has(metadata.labels.app)' my-dir/
+
+# print the names and images of the Pods using images from docker hub
+kubectl get pods -o yaml | kyaml filter \
+  'kind == "Pod" && !(spec.containers[0].image =~ "^[a-z0-9.-]+\\.[a-z]+/")' \
+  --project spec.containers
+`,
+		RunE: r.runE,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also print resources from subpackages.")
+	c.Flags().BoolVar(&r.KeepAnnotations, "annotate", true,
+		"annotate resources with their file origins.")
+	c.Flags().StringSliceVar(&r.Project, "project", []string{},
+		"only print these fields of the Resources, in addition to their apiVersion, kind, name and namespace.")
+	r.Command = c
+	return r
+}
+
+func FilterCommand() *cobra.Command {
+	return GetFilterRunner().Command
+}
+
+// FilterRunner contains the run function
+type FilterRunner struct {
+	IncludeSubpackages bool
+	KeepAnnotations    bool
+	Project            []string
+	Command            *cobra.Command
+}
+
+func (r *FilterRunner) runE(c *cobra.Command, args []string) error {
+	var inputs []kio.Reader
+	for _, a := range args[1:] {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+		Inputs:  inputs,
+		Filters: []kio.Filter{&filters.ExprFilter{Expr: args[0], Project: r.Project}},
+		Outputs: []kio.Writer{kio.ByteWriter{
+			Writer:                c.OutOrStdout(),
+			KeepReaderAnnotations: r.KeepAnnotations,
+		}},
+		ParseMode: kio.ParseModeFast,
+	}).Execute())
+}
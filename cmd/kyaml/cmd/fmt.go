@@ -107,8 +107,8 @@ func (r *FmtRunner) runE(c *cobra.Command, args []string) error {
 			Writer:                c.OutOrStdout(),
 			KeepReaderAnnotations: r.KeepAnnotations,
 		}
-		return handleError(c, kio.Pipeline{
-			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}.Execute())
+		return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}).Execute())
 	}
 
 	for i := range args {
@@ -117,8 +117,8 @@ func (r *FmtRunner) runE(c *cobra.Command, args []string) error {
 			NoDeleteFiles:         true,
 			PackagePath:           path,
 			KeepReaderAnnotations: r.KeepAnnotations}
-		err := kio.Pipeline{
-			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}.Execute()
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}).Execute()
 		if err != nil {
 			return handleError(c, err)
 		}
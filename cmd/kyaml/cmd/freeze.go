@@ -0,0 +1,137 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/registry"
+)
+
+// FreezeCommand returns the freeze command and its subcommands.
+func FreezeCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "freeze",
+		Short: "Pin the references of Resources to immutable versions",
+		Long: `Pin the references of Resources to immutable versions.
+
+See the subcommands for details.
+`,
+	}
+	c.AddCommand(FreezeImagesCommand())
+	return c
+}
+
+// GetFreezeImagesRunner returns a command FreezeImagesRunner.
+func GetFreezeImagesRunner() *FreezeImagesRunner {
+	r := &FreezeImagesRunner{}
+	c := &cobra.Command{
+		Use:   "images [DIR]...",
+		Short: "Pin container image tags to their digests",
+		Long: `Pin container image tags to their digests.
+
+images resolves the tag of each container image to the digest of its manifest in the
+registry, and replaces the image with the digest -- e.g. nginx:1.19 with
+nginx@sha256:... -- so that the package runs the same images when the tags are pushed
+again.  The images of init containers, ephemeral containers, and Pod templates nested
+in any Resource -- e.g. CronJobs -- are pinned.  Images already pinned to a digest are
+not modified.
+
+The registries are authenticated with the credentials of the docker configuration --
+written by docker login, including credential helpers -- and public images are
+resolved anonymously.
+
+Each tag is resolved once per run.  With --cache-file the digests are also cached
+across runs, and resolved again once older than --cache-max-age.
+
+If DIR is specified, the Resources are updated in place and the pinned images are
+printed.  Otherwise the Resources are read from stdin and written to stdout.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# pin the images of a package, recording the tags in comments
+kyaml freeze images my-dir/ --tag-comment
+
+# pin the images of rendered Resources
+kustomize build my-dir/ | kyaml freeze images > pinned.yaml
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.TagComment, "tag-comment", false,
+		"record the tag of each pinned image in a line comment.")
+	c.Flags().StringVar(&r.DockerConfig, "docker-config", registry.DefaultDockerConfigPath(),
+		"path to the docker configuration containing the registry credentials.")
+	c.Flags().StringVar(&r.CacheFile, "cache-file", "",
+		"path to a file caching the resolved digests across runs.")
+	c.Flags().DurationVar(&r.CacheMaxAge, "cache-max-age", registry.DefaultCacheMaxAge,
+		"age after which the cached digests are resolved again.")
+	r.Command = c
+	return r
+}
+
+func FreezeImagesCommand() *cobra.Command {
+	return GetFreezeImagesRunner().Command
+}
+
+// FreezeImagesRunner contains the run function
+type FreezeImagesRunner struct {
+	TagComment   bool
+	DockerConfig string
+	CacheFile    string
+	CacheMaxAge  time.Duration
+	// Client sends the requests to the registries, if set
+	Client  *http.Client
+	Command *cobra.Command
+}
+
+func (r *FreezeImagesRunner) runE(c *cobra.Command, args []string) error {
+	auth, err := registry.LoadDockerConfig(r.DockerConfig)
+	if err != nil {
+		return handleError(c, err)
+	}
+	resolver := &registry.Resolver{
+		Client:      &registry.Client{HTTP: r.Client, Auth: auth},
+		CacheFile:   r.CacheFile,
+		CacheMaxAge: r.CacheMaxAge,
+	}
+	f := &filters.ImageDigestFilter{Resolve: resolver.Resolve, TagComment: r.TagComment}
+
+	// pin stdin if there are no args
+	if len(args) == 0 {
+		rw := &kio.ByteReadWriter{
+			Reader: c.InOrStdin(),
+			Writer: c.OutOrStdout(),
+		}
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{rw}}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+		return handleError(c, resolver.SaveCache())
+	}
+
+	for i := range args {
+		rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[i]}
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{rw}}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+		for _, m := range f.Pinned {
+			id := m.Name
+			if m.Namespace != "" {
+				id = m.Namespace + "/" + m.Name
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s %s: pinned %s to %s\n",
+				m.File, m.Line, m.Kind, id, m.Path, m.Value)
+		}
+	}
+	return handleError(c, resolver.SaveCache())
+}
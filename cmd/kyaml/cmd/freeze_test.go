@@ -0,0 +1,157 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+// fakeDigestRegistry serves the digests of the app:v1 and app:v2 manifests
+func fakeDigestRegistry() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/app/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", "sha256:1111")
+		case "/v2/app/manifests/v2":
+			w.Header().Set("Docker-Content-Digest", "sha256:2222")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestFreezeImagesCommand(t *testing.T) {
+	s := fakeDigestRegistry()
+	defer s.Close()
+	host := strings.TrimPrefix(s.URL, "https://")
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"deploy.yaml": strings.Replace(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: HOST/app:v1
+      containers:
+      - name: app
+        image: HOST/app:v2
+      - name: pinned
+        image: HOST/app@sha256:3333
+`, "HOST", host, -1),
+	})
+
+	b := &bytes.Buffer{}
+	r := cmd.GetFreezeImagesRunner()
+	r.Client = s.Client()
+	r.Command.SetArgs([]string{d, "--tag-comment", "--docker-config", filepath.Join(d, "none.json")})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, strings.Replace(`deploy.yaml:11: Deployment default/app: pinned spec.template.spec.initContainers[name=init].image to HOST/app@sha256:1111
+deploy.yaml:14: Deployment default/app: pinned spec.template.spec.containers[name=app].image to HOST/app@sha256:2222
+`, "HOST", host, -1), b.String())
+	assertFile(t, filepath.Join(d, "deploy.yaml"), strings.Replace(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: HOST/app@sha256:1111 # HOST/app:v1
+      containers:
+      - name: app
+        image: HOST/app@sha256:2222 # HOST/app:v2
+      - name: pinned
+        image: HOST/app@sha256:3333
+`, "HOST", host, -1))
+}
+
+func TestFreezeImagesCommand_stdin(t *testing.T) {
+	s := fakeDigestRegistry()
+	defer s.Close()
+	host := strings.TrimPrefix(s.URL, "https://")
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	b := &bytes.Buffer{}
+	r := cmd.GetFreezeImagesRunner()
+	r.Client = s.Client()
+	r.Command.SetIn(strings.NewReader(strings.Replace(`apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+  - name: app
+    image: HOST/app:v1
+`, "HOST", host, -1)))
+	r.Command.SetArgs([]string{"--docker-config", filepath.Join(d, "none.json"),
+		"--cache-file", filepath.Join(d, "digests.json")})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, strings.Replace(`apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+  - name: app
+    image: HOST/app@sha256:1111
+`, "HOST", host, -1), b.String())
+
+	// the digests are cached
+	cache, err := ioutil.ReadFile(filepath.Join(d, "digests.json"))
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(cache), `"digest": "sha256:1111"`)
+	}
+
+	r = cmd.GetFreezeImagesRunner()
+	r.Client = s.Client()
+	r.Command.SetIn(strings.NewReader(`apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+  - name: app
+    image: ` + host + `/app:v3
+`))
+	r.Command.SetArgs([]string{"--docker-config", filepath.Join(d, "none.json")})
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	err = r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Pod app: spec.containers[name=app].image: could not resolve "+
+			host+"/app:v3: unexpected response status 404 Not Found")
+	}
+}
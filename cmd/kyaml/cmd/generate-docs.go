@@ -0,0 +1,399 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetGenerateDocsRunner returns a command GenerateDocsRunner.
+func GetGenerateDocsRunner() *GenerateDocsRunner {
+	r := &GenerateDocsRunner{}
+	c := &cobra.Command{
+		Use:   "docs DIR",
+		Short: "Generate the Markdown documentation of a package",
+		Long: `Generate the Markdown documentation of a package.
+
+The documentation contains:
+
+  - a table of the Resources of the package, and the files they are in
+  - a table of the values of the fields provided by --field, for the Resources setting them
+  - the tree of the package, as printed by 'kyaml tree'
+  - a table of the container images, and the Resources using them
+  - a table of the endpoints -- the ports of the Services and the rules of the Ingresses
+
+The documentation is printed to stdout, or written to the file provided by --output-file.
+With --check the file isn't written, and the command fails if the file is out of date --
+e.g. to check in CI that the documentation committed next to a package is current.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# print the documentation of a package
+kyaml generate docs my-dir/
+
+# write the documentation, including the replicas, next to the package
+kyaml generate docs my-dir/ --field spec.replicas --output-file my-dir/README.md
+
+# check that the documentation is current
+kyaml generate docs my-dir/ --field spec.replicas --output-file my-dir/README.md --check
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also include resources from subpackages.")
+	c.Flags().StringSliceVar(&r.Fields, "field", []string{},
+		"'.' separated path of a field to include the values of -- e.g. spec.replicas.")
+	c.Flags().StringVar(&r.Title, "title", "",
+		"title of the documentation.  defaults to the name of the package directory.")
+	c.Flags().StringVar(&r.OutputFile, "output-file", "",
+		"path of the file to write the documentation to.  defaults to stdout.")
+	c.Flags().BoolVar(&r.Check, "check", false,
+		"fail if the --output-file is out of date rather than writing it.")
+	r.Command = c
+	return r
+}
+
+func GenerateDocsCommand() *cobra.Command {
+	return GetGenerateDocsRunner().Command
+}
+
+// GenerateDocsRunner contains the run function
+type GenerateDocsRunner struct {
+	IncludeSubpackages bool
+	Fields             []string
+	Title              string
+	OutputFile         string
+	Check              bool
+	Command            *cobra.Command
+}
+
+func (r *GenerateDocsRunner) runE(c *cobra.Command, args []string) error {
+	if r.Check && r.OutputFile == "" {
+		return handleError(c, fmt.Errorf("--check requires --output-file"))
+	}
+	var fields [][]string
+	for _, f := range r.Fields {
+		path, err := parseFieldPath(f)
+		if err != nil {
+			return handleError(c, err)
+		}
+		fields = append(fields, path)
+	}
+	title := r.Title
+	if title == "" {
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			return handleError(c, err)
+		}
+		title = filepath.Base(abs)
+	}
+
+	var docs string
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{kio.LocalPackageReader{
+			PackagePath:        args[0],
+			IncludeSubpackages: r.IncludeSubpackages,
+		}},
+		Outputs: []kio.Writer{kio.WriterFunc(func(nodes []*yaml.RNode) error {
+			var err error
+			docs, err = generateDocs(title, r.Fields, fields, nodes)
+			return err
+		})},
+	}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	if r.OutputFile == "" {
+		_, err := fmt.Fprint(c.OutOrStdout(), docs)
+		return handleError(c, err)
+	}
+	if r.Check {
+		b, err := ioutil.ReadFile(r.OutputFile)
+		if err != nil && !os.IsNotExist(err) {
+			return handleError(c, err)
+		}
+		if string(b) != docs {
+			return handleError(c, fmt.Errorf(
+				"%s is out of date, run 'kyaml generate docs' to update it", r.OutputFile))
+		}
+		Logf(c, LogLevelInfo, "%s is up to date", r.OutputFile)
+		return nil
+	}
+	if err := ioutil.WriteFile(r.OutputFile, []byte(docs), 0600); err != nil {
+		return handleError(c, err)
+	}
+	Logf(c, LogLevelInfo, "wrote %s", r.OutputFile)
+	return nil
+}
+
+// docsHeader is written below the title of the generated documentation
+const docsHeader = "<!-- Code generated by kyaml generate docs. DO NOT EDIT. -->"
+
+// generateDocs renders the Markdown documentation of the Resources of a package.  names
+// are the flag values of the field paths, for the table headers.
+func generateDocs(title string, names []string, fields [][]string,
+	nodes []*yaml.RNode) (string, error) {
+	var resources []*yaml.RNode
+	for i := range nodes {
+		if meta, err := nodes[i].GetMeta(); err == nil && meta.Kind != "" {
+			resources = append(resources, nodes[i])
+		}
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		metai, _ := resources[i].GetMeta()
+		metaj, _ := resources[j].GetMeta()
+		for _, c := range [][2]string{
+			{metai.Annotations[kioutil.PathAnnotation], metaj.Annotations[kioutil.PathAnnotation]},
+			{metai.Kind, metaj.Kind},
+			{metai.Namespace, metaj.Namespace},
+			{metai.Name, metaj.Name}} {
+			if c[0] != c[1] {
+				return c[0] < c[1]
+			}
+		}
+		return false
+	})
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "# %s\n\n%s\n", title, docsHeader)
+
+	// resource inventory
+	fmt.Fprintf(b, "\n## Resources\n\n")
+	rows := [][]string{}
+	for i := range resources {
+		meta, _ := resources[i].GetMeta()
+		rows = append(rows, []string{meta.Kind, meta.Namespace, meta.Name,
+			meta.Annotations[kioutil.PathAnnotation]})
+	}
+	writeDocsTable(b, []string{"Kind", "Namespace", "Name", "File"}, rows)
+
+	// field excerpts
+	if len(fields) > 0 {
+		fmt.Fprintf(b, "\n## Fields\n\n")
+		rows = [][]string{}
+		for i := range resources {
+			for j := range fields {
+				f, err := resources[i].Pipe(yaml.Lookup(fields[j]...))
+				if err != nil {
+					return "", err
+				}
+				if yaml.IsMissingOrNull(f) {
+					continue
+				}
+				value, err := yaml.String(f.YNode(), yaml.Trim, yaml.Flow)
+				if err != nil {
+					return "", err
+				}
+				rows = append(rows, []string{docsResource(resources[i]), names[j], value})
+			}
+		}
+		writeDocsTable(b, []string{"Resource", "Field", "Value"}, rows)
+	}
+
+	// tree diagram
+	tree := &bytes.Buffer{}
+	if err := (kio.TreeWriter{Writer: tree, Root: title}).Write(resources); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(b, "\n## Tree\n\n```\n%s```\n", tree.String())
+
+	// images
+	images := map[string][]string{}
+	for i := range resources {
+		used := sets.String{}
+		for _, podSpec := range findPodSpecs(resources[i].YNode()) {
+			for _, field := range []string{"initContainers", "containers"} {
+				f := podSpec.Field(field)
+				if yaml.IsFieldEmpty(f) {
+					continue
+				}
+				for _, container := range f.Value.Content() {
+					image := yaml.NewRNode(container).Field("image")
+					if yaml.IsFieldEmpty(image) || used.Has(image.Value.YNode().Value) {
+						continue
+					}
+					used.Insert(image.Value.YNode().Value)
+					images[image.Value.YNode().Value] = append(
+						images[image.Value.YNode().Value], docsResource(resources[i]))
+				}
+			}
+		}
+	}
+	if len(images) > 0 {
+		fmt.Fprintf(b, "\n## Images\n\n")
+		rows = [][]string{}
+		for _, image := range docsKeys(images) {
+			rows = append(rows, []string{image, strings.Join(images[image], ", ")})
+		}
+		writeDocsTable(b, []string{"Image", "Resources"}, rows)
+	}
+
+	// endpoints
+	rows = [][]string{}
+	for i := range resources {
+		endpoints, err := docsEndpoints(resources[i])
+		if err != nil {
+			return "", err
+		}
+		rows = append(rows, endpoints...)
+	}
+	if len(rows) > 0 {
+		fmt.Fprintf(b, "\n## Endpoints\n\n")
+		writeDocsTable(b, []string{"Resource", "Type", "Endpoint"}, rows)
+	}
+	return b.String(), nil
+}
+
+// docsResource returns the name of the Resource in the documentation
+func docsResource(rn *yaml.RNode) string {
+	meta, _ := rn.GetMeta()
+	if meta.Namespace == "" {
+		return fmt.Sprintf("%s %s", meta.Kind, meta.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", meta.Kind, meta.Namespace, meta.Name)
+}
+
+// docsKeys returns the sorted keys of m
+func docsKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// docsValue returns the value of the field of rn, or "" if it isn't set
+func docsValue(rn *yaml.RNode, field ...string) string {
+	f, err := rn.Pipe(yaml.Lookup(field...))
+	if err != nil || yaml.IsMissingOrNull(f) {
+		return ""
+	}
+	return f.YNode().Value
+}
+
+// docsEndpoints returns the endpoint table rows of the Service or Ingress
+func docsEndpoints(rn *yaml.RNode) ([][]string, error) {
+	meta, _ := rn.GetMeta()
+	var rows [][]string
+	switch meta.Kind {
+	case "Service":
+		t := docsValue(rn, "spec", "type")
+		if t == "" {
+			t = "ClusterIP"
+		}
+		host := meta.Name
+		if meta.Namespace != "" {
+			host = fmt.Sprintf("%s.%s.svc", meta.Name, meta.Namespace)
+		}
+		if t == "ExternalName" {
+			rows = append(rows, []string{docsResource(rn), t,
+				fmt.Sprintf("%s -> %s", host, docsValue(rn, "spec", "externalName"))})
+		}
+		ports, err := rn.Pipe(yaml.Lookup("spec", "ports"))
+		if err != nil {
+			return nil, err
+		}
+		if ports == nil {
+			return rows, nil
+		}
+		elements, err := ports.Elements()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range elements {
+			protocol := docsValue(p, "protocol")
+			if protocol == "" {
+				protocol = "TCP"
+			}
+			endpoint := fmt.Sprintf("%s:%s/%s", host, docsValue(p, "port"), protocol)
+			if target := docsValue(p, "targetPort"); target != "" {
+				endpoint = fmt.Sprintf("%s -> %s", endpoint, target)
+			}
+			if nodePort := docsValue(p, "nodePort"); nodePort != "" {
+				endpoint = fmt.Sprintf("%s (node port %s)", endpoint, nodePort)
+			}
+			rows = append(rows, []string{docsResource(rn), t, endpoint})
+		}
+	case "Ingress":
+		rules, err := rn.Pipe(yaml.Lookup("spec", "rules"))
+		if err != nil || rules == nil {
+			return nil, err
+		}
+		elements, err := rules.Elements()
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range elements {
+			host := docsValue(rule, "host")
+			if host == "" {
+				host = "*"
+			}
+			paths, err := rule.Pipe(yaml.Lookup("http", "paths"))
+			if err != nil {
+				return nil, err
+			}
+			if paths == nil {
+				continue
+			}
+			pathElements, err := paths.Elements()
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range pathElements {
+				path := docsValue(p, "path")
+				if path == "" {
+					path = "/"
+				}
+				// extensions and networking.k8s.io v1beta1, and networking.k8s.io v1
+				service := docsValue(p, "backend", "serviceName")
+				port := docsValue(p, "backend", "servicePort")
+				if service == "" {
+					service = docsValue(p, "backend", "service", "name")
+					port = docsValue(p, "backend", "service", "port", "number")
+					if port == "" {
+						port = docsValue(p, "backend", "service", "port", "name")
+					}
+				}
+				rows = append(rows, []string{docsResource(rn), "Ingress",
+					fmt.Sprintf("%s%s -> %s:%s", host, path, service, port)})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// writeDocsTable writes the rows as a Markdown table with the headers
+func writeDocsTable(b *bytes.Buffer, headers []string, rows [][]string) {
+	escape := func(s string) string {
+		s = strings.Replace(s, "|", `\|`, -1)
+		return strings.Replace(s, "\n", " ", -1)
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(headers, " | "))
+	for range headers {
+		b.WriteString("|---")
+	}
+	b.WriteString("|\n")
+	for _, row := range rows {
+		for i := range row {
+			row[i] = escape(row[i])
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(row, " | "))
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const generateDocsOutput = `# web
+
+<!-- Code generated by kyaml generate docs. DO NOT EDIT. -->
+
+## Resources
+
+| Kind | Namespace | Name | File |
+|---|---|---|---|
+| Deployment | prod | web | app.yaml |
+| Service | prod | web | app.yaml |
+| Ingress | prod | web | ingress/ingress.yaml |
+
+## Fields
+
+| Resource | Field | Value |
+|---|---|---|
+| Deployment prod/web | spec.replicas | 3 |
+
+## Tree
+
+` + "```" + `
+web
+├── [app.yaml]  Deployment prod/web
+├── [app.yaml]  Service prod/web
+└── ingress
+    └── [ingress.yaml]  Ingress prod/web
+` + "```" + `
+
+## Images
+
+| Image | Resources |
+|---|---|
+| busybox:1.31 | Deployment prod/web |
+| nginx:1.17 | Deployment prod/web |
+
+## Endpoints
+
+| Resource | Type | Endpoint |
+|---|---|---|
+| Service prod/web | NodePort | web.prod.svc:80/TCP -> 8080 (node port 30080) |
+| Ingress prod/web | Ingress | example.com/api -> web:80 |
+| Ingress prod/web | Ingress | */ -> web:http |
+`
+
+func TestGenerateDocsCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	pkg := filepath.Join(d, "web")
+	writeFiles(t, pkg, map[string]string{
+		"app.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox:1.31
+      containers:
+      - name: web
+        image: nginx:1.17
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: prod
+spec:
+  type: NodePort
+  ports:
+  - port: 80
+    targetPort: 8080
+    nodePort: 30080
+`,
+		"ingress/ingress.yaml": `apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: prod
+spec:
+  rules:
+  - host: example.com
+    http:
+      paths:
+      - path: /api
+        backend:
+          serviceName: web
+          servicePort: 80
+  - http:
+      paths:
+      - backend:
+          serviceName: web
+          servicePort: http
+`,
+	})
+
+	// print the documentation
+	b := &bytes.Buffer{}
+	r := cmd.GetGenerateDocsRunner()
+	r.Command.SetArgs([]string{pkg, "--field", "spec.replicas"})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, generateDocsOutput, b.String())
+
+	// check a missing file
+	readme := filepath.Join(pkg, "README.md")
+	r = cmd.GetGenerateDocsRunner()
+	r.Command.SetArgs([]string{pkg, "--field", "spec.replicas", "--output-file", readme, "--check"})
+	r.Command.SetOut(ioutil.Discard)
+	r.Command.SetErr(ioutil.Discard)
+	assert.EqualError(t, r.Command.Execute(),
+		readme+" is out of date, run 'kyaml generate docs' to update it")
+
+	// write the file
+	r = cmd.GetGenerateDocsRunner()
+	r.Command.SetArgs([]string{pkg, "--field", "spec.replicas", "--output-file", readme})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assertFile(t, readme, generateDocsOutput)
+
+	// check the current file
+	r = cmd.GetGenerateDocsRunner()
+	r.Command.SetArgs([]string{pkg, "--field", "spec.replicas", "--output-file", readme, "--check"})
+	assert.NoError(t, r.Command.Execute())
+
+	// check an out of date file
+	writeFiles(t, pkg, map[string]string{"ingress/ingress.yaml": ""})
+	r = cmd.GetGenerateDocsRunner()
+	r.Command.SetArgs([]string{pkg, "--field", "spec.replicas", "--output-file", readme, "--check"})
+	r.Command.SetOut(ioutil.Discard)
+	r.Command.SetErr(ioutil.Discard)
+	assert.EqualError(t, r.Command.Execute(),
+		readme+" is out of date, run 'kyaml generate docs' to update it")
+	assertFile(t, readme, generateDocsOutput)
+}
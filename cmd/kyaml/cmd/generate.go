@@ -0,0 +1,37 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// GetGenerateRunner returns a command GenerateRunner.
+func GetGenerateRunner() *GenerateRunner {
+	r := &GenerateRunner{}
+	c := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate files from a package",
+		Long: `Generate files from a package.
+
+The generated files are intended to be committed next to the package, and regenerated
+when it changes -- e.g. in CI.
+`,
+		Example: `# generate the Markdown documentation of a package
+kyaml generate docs my-dir/ --output-file my-dir/README.md
+`,
+	}
+	c.AddCommand(GenerateDocsCommand())
+	r.Command = c
+	return r
+}
+
+func GenerateCommand() *cobra.Command {
+	return GetGenerateRunner().Command
+}
+
+// GenerateRunner contains the subcommands
+type GenerateRunner struct {
+	Command *cobra.Command
+}
@@ -0,0 +1,224 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// Output formats selected by the --output flag.  Commands may support a subset of the
+// formats, and formats of their own -- e.g. tree --output metrics.  "" selects OutputText.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// outputFormatsAnnotation is the command annotation containing the output formats the
+// command supports besides OutputText, set by SetOutputFormats
+const outputFormatsAnnotation = "kyaml_output_formats"
+
+// Log levels selected by the --log-level flag, from least to most verbose
+const (
+	LogLevelError = "error"
+	LogLevelWarn  = "warn"
+	LogLevelInfo  = "info"
+	LogLevelDebug = "debug"
+)
+
+// logLevels are the log levels, from least to most verbose
+var logLevels = []string{LogLevelError, LogLevelWarn, LogLevelInfo, LogLevelDebug}
+
+// Output is the output format selected by the global --output flag
+var Output = OutputText
+
+// LogLevel is the log level selected by the global --log-level flag
+var LogLevel = LogLevelWarn
+
+// Default limits of the inputs read by the commands, large enough for any intentional input
+// yet failing before enormous or malformed streams exhaust the memory
+const (
+	DefaultMaxResourceBytes = 16 << 20
+	DefaultMaxResources     = 100000
+)
+
+// MaxResourceBytes and MaxResources are the limits selected by the global
+// --max-resource-bytes and --max-resources flags.  ConfigurePipeline sets them as the Limits
+// of the Pipelines of the commands, so that they fail once their inputs exceed them.
+var (
+	MaxResourceBytes = DefaultMaxResourceBytes
+	MaxResources     = DefaultMaxResources
+)
+
+// Timings is selected by the global --timings flag.  ConfigurePipeline records the Pipelines
+// of the commands to pipelineTimings, so that the cost of each of their stages is printed
+// once they have run.
+var Timings bool
+
+// pipelineTimings records the timings of the Pipelines of the command run if Timings is set
+var pipelineTimings *kio.PipelineTimings
+
+// AddGlobalFlags adds the persistent flags shared by all of the kyaml commands to root,
+// and validates their values before running the commands.
+//
+// Runners query the selected format with GetOutputFormat.  Commands fail if the format
+// isn't one of the formats set with SetOutputFormats -- e.g. 'kyaml -o json cat' fails
+// rather than printing yaml.
+func AddGlobalFlags(root *cobra.Command) {
+	root.PersistentFlags().BoolVar(&StackOnError, "stack-trace", false,
+		"print a stack-trace on failure")
+	root.PersistentFlags().StringVarP(&Output, "output", "o", OutputText,
+		"output format.  may be 'text', 'json' or 'yaml' -- commands may not support every format.")
+	markFlagValues(root, "output", OutputText, OutputJSON, OutputYAML, "metrics")
+	root.PersistentFlags().StringVar(&LogLevel, "log-level", LogLevelWarn,
+		"verbosity of the messages written to stderr.  may be 'error', 'warn', 'info' or 'debug'.")
+	markFlagValues(root, "log-level", logLevels...)
+	root.PersistentFlags().IntVar(&MaxResourceBytes, "max-resource-bytes", DefaultMaxResourceBytes,
+		"maximum size of each Resource read, in bytes.  0 disables the limit.")
+	root.PersistentFlags().IntVar(&MaxResources, "max-resources", DefaultMaxResources,
+		"maximum number of Resources read.  0 disables the limit.")
+	root.PersistentFlags().BoolVar(&Timings, "timings", false,
+		"print the time, Resources and allocations of each stage of the Pipelines to stderr.")
+
+	root.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		if _, err := outputFormat(c); err != nil {
+			return handleError(c, err)
+		}
+		if logLevelIndex(LogLevel) < 0 {
+			return handleError(c, fmt.Errorf("unsupported log level %q, may be one of: %s",
+				LogLevel, strings.Join(logLevels, ", ")))
+		}
+		if MaxResourceBytes < 0 || MaxResources < 0 {
+			return handleError(c, fmt.Errorf("--max-resource-bytes and --max-resources must not be negative"))
+		}
+		pipelineTimings = nil
+		if Timings {
+			pipelineTimings = &kio.PipelineTimings{}
+		}
+		return nil
+	}
+	root.PersistentPostRunE = func(c *cobra.Command, args []string) error {
+		if pipelineTimings != nil {
+			return handleError(c, writeTimings(c.ErrOrStderr(), pipelineTimings.Stages()))
+		}
+		return nil
+	}
+}
+
+// ConfigurePipeline returns p configured by the global flags -- commands pass each of their
+// Pipelines through it before executing them.  The Limits, Timings and Warnings p sets are
+// kept.  Warnings are logged at LogLevelWarn.
+func ConfigurePipeline(c *cobra.Command, p kio.Pipeline) kio.Pipeline {
+	if p.Limits == nil {
+		p.Limits = &kio.Limits{MaxResourceBytes: MaxResourceBytes, MaxResources: MaxResources}
+	}
+	if p.Timings == nil {
+		p.Timings = pipelineTimings
+	}
+	if p.Warnings == nil {
+		p.Warnings = logWriter{c: c, level: LogLevelWarn}
+	}
+	return p
+}
+
+// writeTimings writes the timings of the stages of the Pipelines as a table
+func writeTimings(out io.Writer, stages []kio.StageTiming) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PIPELINE\tSTAGE\tNAME\tRESOURCES\tTIME\tALLOCS\tBYTES")
+	for _, s := range stages {
+		fmt.Fprintf(w, "%d\t%s %d\t%s\t%d\t%v\t%d\t%d\n", s.Pipeline, s.Stage, s.Index, s.Name,
+			s.Resources, s.Elapsed, s.Allocs, s.AllocBytes)
+	}
+	return w.Flush()
+}
+
+// GetOutputFormat returns the output format selected for the command by the global
+// --output flag.  Returns OutputText if no format is selected, or if the command doesn't
+// have the global flags.
+func GetOutputFormat(c *cobra.Command) string {
+	f := c.Flag("output")
+	if f == nil || f.Value.String() == "" {
+		return OutputText
+	}
+	return f.Value.String()
+}
+
+// SetOutputFormats sets the output formats c supports besides OutputText.  The other formats
+// selected with the global --output flag fail the command before it is run.
+func SetOutputFormats(c *cobra.Command, formats ...string) {
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
+	}
+	c.Annotations[outputFormatsAnnotation] = strings.Join(formats, ",")
+}
+
+// outputFormat returns the output format selected for the command, or an error if the
+// command doesn't support it
+func outputFormat(c *cobra.Command) (string, error) {
+	output := GetOutputFormat(c)
+	var formats []string
+	if f := c.Annotations[outputFormatsAnnotation]; f != "" {
+		formats = strings.Split(f, ",")
+	}
+	return output, checkOutputFormat(output, formats...)
+}
+
+// checkOutputFormat returns an error if the output format isn't OutputText or one of
+// the formats supported by a command
+func checkOutputFormat(output string, formats ...string) error {
+	if output == "" || output == OutputText {
+		return nil
+	}
+	for _, f := range formats {
+		if output == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, may be one of: %s",
+		output, strings.Join(append([]string{OutputText}, formats...), ", "))
+}
+
+// logLevelIndex returns the verbosity of the log level, or -1 if it isn't a log level
+func logLevelIndex(level string) int {
+	for i := range logLevels {
+		if logLevels[i] == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Logf writes the message to the stderr of the command if level is at most as verbose as
+// the log level selected by the global --log-level flag.  Messages are prefixed with
+// their level -- e.g. "info: wrote README.md".
+func Logf(c *cobra.Command, level string, format string, args ...interface{}) {
+	selected := logLevelIndex(LogLevel)
+	if selected < 0 {
+		selected = logLevelIndex(LogLevelWarn)
+	}
+	if logLevelIndex(level) > selected {
+		return
+	}
+	fmt.Fprintf(c.ErrOrStderr(), "%s: %s\n", level, fmt.Sprintf(format, args...))
+}
+
+// logWriter writes each line written to it with Logf at its level -- e.g. the warnings of
+// the kio Readers
+type logWriter struct {
+	c     *cobra.Command
+	level string
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		Logf(w.c, w.level, "%s", line)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// newGlobalRoot returns a root command with the global flags, and the commands:
+//
+//   - global, which prints the global output format and logs a message at each level
+//   - cat, which only supports the text output format
+func newGlobalRoot(out *bytes.Buffer) *cobra.Command {
+	cmd.Output, cmd.LogLevel = cmd.OutputText, cmd.LogLevelWarn
+	root := &cobra.Command{Use: "kyaml"}
+	cmd.AddGlobalFlags(root)
+	global := &cobra.Command{
+		Use: "global",
+		RunE: func(c *cobra.Command, args []string) error {
+			out.WriteString(cmd.GetOutputFormat(c) + "\n")
+			for _, level := range []string{
+				cmd.LogLevelError, cmd.LogLevelWarn, cmd.LogLevelInfo, cmd.LogLevelDebug} {
+				cmd.Logf(c, level, "%s message", level)
+			}
+			return nil
+		},
+	}
+	cmd.SetOutputFormats(global, cmd.OutputJSON, cmd.OutputYAML)
+	root.AddCommand(global)
+	root.AddCommand(cmd.GetCatRunner().Command)
+	root.SetIn(bytes.NewBufferString("a: b\n"))
+	root.SetOut(out)
+	root.SetErr(out)
+	return root
+}
+
+func TestAddGlobalFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		err      string
+	}{
+		{args: []string{"global"}, expected: `text
+error: error message
+warn: warn message
+`},
+		{args: []string{"-o", "yaml", "global", "--log-level", "debug"}, expected: `yaml
+error: error message
+warn: warn message
+info: info message
+debug: debug message
+`},
+		{args: []string{"global", "--output", "json", "--log-level", "error"}, expected: `json
+error: error message
+`},
+		{args: []string{"global", "-o", "metrics"},
+			err: `unsupported output format "metrics", may be one of: text, json, yaml`},
+		// commands fail rather than ignoring the formats they don't support
+		{args: []string{"cat"}, expected: "a: b\n"},
+		{args: []string{"-o", "json", "cat"},
+			err: `unsupported output format "json", may be one of: text`},
+		{args: []string{"global", "--log-level", "trace"},
+			err: `unsupported log level "trace", may be one of: error, warn, info, debug`},
+	}
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		root := newGlobalRoot(out)
+		root.SetArgs(test.args)
+		err := root.Execute()
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.args)
+			continue
+		}
+		if assert.NoError(t, err, test.args) {
+			assert.Equal(t, test.expected, out.String(), test.args)
+		}
+	}
+	cmd.Output, cmd.LogLevel = cmd.OutputText, cmd.LogLevelWarn
+}
+
+func TestAddGlobalFlags_limits(t *testing.T) {
+	input := "a: b\n---\nc: d\n---\ne: f\n"
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{args: []string{"cat"}},
+		{args: []string{"--max-resources", "2", "cat"},
+			err: "input exceeds the maximum of 2 documents"},
+		{args: []string{"cat", "--max-resource-bytes", "4"},
+			err: "document at line 1 exceeds the maximum size of 4 bytes"},
+		// 0 disables the limits for intentionally large inputs
+		{args: []string{"cat", "--max-resources", "0", "--max-resource-bytes", "0"}},
+		{args: []string{"cat", "--max-resources", "-1"},
+			err: "--max-resource-bytes and --max-resources must not be negative"},
+	}
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		root := &cobra.Command{Use: "kyaml"}
+		cmd.AddGlobalFlags(root)
+		root.AddCommand(cmd.GetCatRunner().Command)
+		root.SetIn(bytes.NewBufferString(input))
+		root.SetOut(out)
+		root.SetErr(out)
+		root.SetArgs(test.args)
+		err := root.Execute()
+		if test.err != "" {
+			if assert.Error(t, err, test.args) {
+				assert.Contains(t, err.Error(), test.err, test.args)
+			}
+			continue
+		}
+		if assert.NoError(t, err, test.args) {
+			assert.Contains(t, out.String(), "e: f", test.args)
+		}
+	}
+	cmd.MaxResourceBytes, cmd.MaxResources = cmd.DefaultMaxResourceBytes, cmd.DefaultMaxResources
+}
+
+func TestAddGlobalFlags_timings(t *testing.T) {
+	defer func() { cmd.Timings = false }()
+	out, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	root := &cobra.Command{Use: "kyaml"}
+	cmd.AddGlobalFlags(root)
+	root.AddCommand(cmd.GetCatRunner().Command)
+	root.SetIn(bytes.NewBufferString("a: b\n---\nc: d\n"))
+	root.SetOut(out)
+	root.SetErr(stderr)
+	root.SetArgs([]string{"--timings", "cat"})
+	if !assert.NoError(t, root.Execute()) {
+		return
+	}
+	assert.Contains(t, out.String(), "c: d")
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if assert.True(t, len(lines) > 2, stderr.String()) {
+		assert.Regexp(t, `^PIPELINE +STAGE +NAME +RESOURCES +TIME +ALLOCS +BYTES$`, lines[0])
+		assert.Regexp(t, `^0 +read 0 +kio\.ByteReader +2 `, lines[1])
+		assert.Regexp(t, `^0 +write 0 +\S+ +2 `, lines[len(lines)-1])
+	}
+}
+
+func TestAddGlobalFlags_warnings(t *testing.T) {
+	defer func() { cmd.LogLevel = cmd.LogLevelWarn }()
+	for _, level := range []string{cmd.LogLevelWarn, cmd.LogLevelError} {
+		stderr := &bytes.Buffer{}
+		root := &cobra.Command{Use: "kyaml"}
+		cmd.AddGlobalFlags(root)
+		root.AddCommand(&cobra.Command{
+			Use: "read",
+			RunE: func(c *cobra.Command, args []string) error {
+				return cmd.ConfigurePipeline(c, kio.Pipeline{
+					Inputs:           []kio.Reader{&kio.ByteReader{Reader: c.InOrStdin()}},
+					DuplicateKeyMode: kio.DuplicateKeyModeLastWins,
+				}).Execute()
+			},
+		})
+		root.SetIn(bytes.NewBufferString("a: b\na: c\n"))
+		root.SetErr(stderr)
+		root.SetArgs([]string{"--log-level", level, "read"})
+		if !assert.NoError(t, root.Execute()) {
+			return
+		}
+		if level == cmd.LogLevelWarn {
+			assert.Equal(t, "warn: 1:1: duplicate key \"a\", using the value at line 2\n",
+				stderr.String())
+		} else {
+			assert.Equal(t, "", stderr.String())
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const (
+	graphFormatTree = "tree"
+	graphFormatDot  = "dot"
+	graphFormatJSON = "json"
+)
+
+// GetGraphRunner returns a command GraphRunner.
+func GetGraphRunner() *GraphRunner {
+	r := &GraphRunner{}
+	c := &cobra.Command{
+		Use:   "graph [DIR]...",
+		Short: "Print the dependency graph of Resource Config",
+		Long: `Print the dependency graph of Resource Config.
+
+A Resource depends on:
+
+- the owners in its metadata.ownerReferences
+- the Resources it references by name -- e.g. the ConfigMaps, Secrets,
+  ServiceAccounts and PersistentVolumeClaims of workloads, or the Services of
+  Ingresses (see 'kyaml rename --help' for the list of references)
+- for Services, the workloads whose pods match the selector
+
+Only dependencies on Resources in the input are included.
+
+The graph may be printed as:
+
+- tree: each Resource nothing depends on, with its dependencies beneath it
+- dot: a graphviz digraph
+- json: a list of the Resources, each with the list of Resources it depends on
+
+  DIR:
+    Path to local directory.  Reads from stdin if unset.
+`,
+		Example: `# print the dependencies of a package as a tree
+kyaml graph my-dir/
+
+# render the graph with graphviz
+kyaml graph my-dir/ --format dot | dot -Tsvg > graph.svg
+`,
+		RunE: r.runE,
+	}
+	c.Flags().StringVar(&r.Format, "format", graphFormatTree,
+		"output format.  may be 'tree', 'dot' or 'json'.")
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also include resources from subpackages.")
+	r.Command = c
+	return r
+}
+
+func GraphCommand() *cobra.Command {
+	return GetGraphRunner().Command
+}
+
+// GraphRunner contains the run function
+type GraphRunner struct {
+	Format             string
+	IncludeSubpackages bool
+	Command            *cobra.Command
+}
+
+func (r *GraphRunner) runE(c *cobra.Command, args []string) error {
+	if r.Format != graphFormatTree && r.Format != graphFormatDot && r.Format != graphFormatJSON {
+		return handleError(c, fmt.Errorf("--format must be one of '%s', '%s' or '%s', got '%s'",
+			graphFormatTree, graphFormatDot, graphFormatJSON, r.Format))
+	}
+
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	g := &filters.GraphFilter{}
+	if err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Filters: []kio.Filter{g}}).Execute(); err != nil {
+		return handleError(c, err)
+	}
+
+	switch r.Format {
+	case graphFormatDot:
+		return handleError(c, writeGraphDot(c.OutOrStdout(), g))
+	case graphFormatJSON:
+		return handleError(c, writeGraphJSON(c.OutOrStdout(), g))
+	default:
+		return handleError(c, writeGraphTree(c.OutOrStdout(), g))
+	}
+}
+
+// graphDependency is a dependency of a graphNode in the json output
+type graphDependency struct {
+	ID   string           `json:"id"`
+	Type filters.EdgeType `json:"type"`
+	Path string           `json:"path,omitempty"`
+}
+
+// graphNode is a Resource and its dependencies in the json output
+type graphNode struct {
+	ID string `json:"id"`
+	filters.ResourceID
+	DependsOn []graphDependency `json:"dependsOn"`
+}
+
+func writeGraphJSON(w io.Writer, g *filters.GraphFilter) error {
+	nodes := []graphNode{}
+	index := map[string]int{}
+	for _, n := range g.Nodes {
+		index[n.String()] = len(nodes)
+		nodes = append(nodes, graphNode{ID: n.String(), ResourceID: n, DependsOn: []graphDependency{}})
+	}
+	for _, e := range g.Edges {
+		i := index[e.From.String()]
+		nodes[i].DependsOn = append(nodes[i].DependsOn,
+			graphDependency{ID: e.To.String(), Type: e.Type, Path: e.Path})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(nodes)
+}
+
+func writeGraphDot(w io.Writer, g *filters.GraphFilter) error {
+	b := &strings.Builder{}
+	b.WriteString("digraph {\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(b, "  %s;\n", strconv.Quote(n.String()))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s -> %s [label=%s];\n",
+			strconv.Quote(e.From.String()), strconv.Quote(e.To.String()), strconv.Quote(string(e.Type)))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGraphTree prints each Resource nothing depends on, with its dependencies
+// beneath it.  Resources which are only part of dependency cycles are printed as
+// roots after them.
+func writeGraphTree(w io.Writer, g *filters.GraphFilter) error {
+	dependencies := map[string][]filters.Edge{}
+	dependents := map[string]int{}
+	for _, e := range g.Edges {
+		dependencies[e.From.String()] = append(dependencies[e.From.String()], e)
+		dependents[e.To.String()]++
+	}
+
+	// find the roots, and the Resources reachable from them
+	reached := map[string]bool{}
+	var reach func(id string)
+	reach = func(id string) {
+		if reached[id] {
+			return
+		}
+		reached[id] = true
+		for _, e := range dependencies[id] {
+			reach(e.To.String())
+		}
+	}
+	var roots []string
+	for _, n := range g.Nodes {
+		if dependents[n.String()] == 0 {
+			roots = append(roots, n.String())
+			reach(n.String())
+		}
+	}
+	for _, n := range g.Nodes {
+		if !reached[n.String()] {
+			roots = append(roots, n.String())
+			reach(n.String())
+		}
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(".\n")
+	ancestors := map[string]bool{}
+	var doNode func(id, label, prefix string, last bool)
+	doNode = func(id, label, prefix string, last bool) {
+		branch, indent := "├── ", "│   "
+		if last {
+			branch, indent = "└── ", "    "
+		}
+		if ancestors[id] {
+			fmt.Fprintf(b, "%s%s%s (cycle)\n", prefix, branch, label)
+			return
+		}
+		fmt.Fprintf(b, "%s%s%s\n", prefix, branch, label)
+		ancestors[id] = true
+		deps := dependencies[id]
+		for i, e := range deps {
+			doNode(e.To.String(), fmt.Sprintf("[%s] %s", e.Type, e.To), prefix+indent,
+				i == len(deps)-1)
+		}
+		delete(ancestors, id)
+	}
+	for i, id := range roots {
+		doNode(id, id, "", i == len(roots)-1)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
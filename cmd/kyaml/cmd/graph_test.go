@@ -0,0 +1,125 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const graphInput = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      volumes:
+      - name: config
+        configMap:
+          name: config
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+spec:
+  selector:
+    app: nginx
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: unused
+`
+
+func runGraph(t *testing.T, args ...string) string {
+	r := cmd.GetGraphRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(graphInput))
+	r.Command.SetOut(b)
+	r.Command.SetArgs(args)
+	if !assert.NoError(t, r.Command.Execute()) {
+		t.FailNow()
+	}
+	return b.String()
+}
+
+func TestGraphCommand_tree(t *testing.T) {
+	assert.Equal(t, `.
+├── Service nginx
+│   └── [selector] Deployment nginx
+│       └── [reference] ConfigMap config
+└── Secret unused
+`, runGraph(t))
+}
+
+func TestGraphCommand_kustomization(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"kustomization.yaml": "resources:\n- resources.yaml\n",
+		"resources.yaml":     graphInput,
+	})
+
+	assert.Equal(t, `.
+├── Service nginx
+│   └── [selector] Deployment nginx
+│       └── [reference] ConfigMap config
+└── Secret unused
+`, runGraph(t, d))
+}
+
+func TestGraphCommand_dot(t *testing.T) {
+	assert.Equal(t, `digraph {
+  "ConfigMap config";
+  "Deployment nginx";
+  "Service nginx";
+  "Secret unused";
+  "Deployment nginx" -> "ConfigMap config" [label="reference"];
+  "Service nginx" -> "Deployment nginx" [label="selector"];
+}
+`, runGraph(t, "--format", "dot"))
+}
+
+func TestGraphCommand_json(t *testing.T) {
+	actual := runGraph(t, "--format", "json")
+	assert.Contains(t, actual, `  {
+    "id": "Deployment nginx",
+    "apiVersion": "apps/v1",
+    "kind": "Deployment",
+    "name": "nginx",
+    "dependsOn": [
+      {
+        "id": "ConfigMap config",
+        "type": "reference",
+        "path": "spec.template.spec.volumes[name=config].configMap.name"
+      }
+    ]
+  }`)
+	assert.Contains(t, actual, `  {
+    "id": "Secret unused",
+    "apiVersion": "v1",
+    "kind": "Secret",
+    "name": "unused",
+    "dependsOn": []
+  }`)
+}
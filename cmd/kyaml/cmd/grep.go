@@ -36,6 +36,9 @@ kyaml grep "kind=Deployment" my-dir/
 # find Resources named nginx
 kyaml grep "metadata.name=nginx" my-dir/
 
+# find Deployment Resources in the yaml files of a directory, skipping tests
+kyaml grep "kind=Deployment" my-dir/ --include "*.yaml" --exclude "**/testdata/**"
+
 # use tree to display matching Resources
 kyaml grep "metadata.name=nginx" my-dir/ | kyaml tree
 
@@ -48,6 +51,14 @@ kyaml grep "spec.template.spec.containers[name=nginx].image=nginx:1\.7\.9" my-di
 	}
 	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
 		"also print resources from subpackages.")
+	c.Flags().StringSliceVar(&r.Include, "include", []string{},
+		"only read files whose path relative to the directory matches a pattern, e.g. 'manifests/**'.")
+	c.Flags().StringSliceVar(&r.Exclude, "exclude", []string{},
+		"skip files and directories whose path relative to the directory matches a pattern, e.g. '**/testdata/**'.")
+	c.Flags().BoolVar(&r.FollowSymlinks, "follow-symlinks", false,
+		"also read resources from symlinked directories.")
+	c.Flags().StringVar(&r.SymlinkRoot, "symlink-root", "",
+		"directory the followed symlinks must resolve within, e.g. the repository root.  defaults to the directory.")
 	c.Flags().BoolVar(&r.KeepAnnotations, "annotate", true,
 		"annotate resources with their file origins.")
 	c.Flags().BoolVarP(&r.InvertMatch, "invert-match", "v", false,
@@ -64,6 +75,10 @@ func GrepCommand() *cobra.Command {
 // GrepRunner contains the run function
 type GrepRunner struct {
 	IncludeSubpackages bool
+	Include            []string
+	Exclude            []string
+	FollowSymlinks     bool
+	SymlinkRoot        string
 	KeepAnnotations    bool
 	Command            *cobra.Command
 	filters.GrepFilter
@@ -126,6 +141,10 @@ func (r *GrepRunner) runE(c *cobra.Command, args []string) error {
 	for _, a := range args[1:] {
 		inputs = append(inputs, kio.LocalPackageReader{
 			PackagePath:        a,
+			Include:            r.Include,
+			Exclude:            r.Exclude,
+			FollowSymlinks:     r.FollowSymlinks,
+			SymlinkRoot:        r.SymlinkRoot,
 			IncludeSubpackages: r.IncludeSubpackages,
 		})
 	}
@@ -133,12 +152,13 @@ func (r *GrepRunner) runE(c *cobra.Command, args []string) error {
 		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
 	}
 
-	return handleError(c, kio.Pipeline{
+	return handleError(c, ConfigurePipeline(c, kio.Pipeline{
 		Inputs:  inputs,
 		Filters: filters,
 		Outputs: []kio.Writer{kio.ByteWriter{
 			Writer:                c.OutOrStdout(),
 			KeepReaderAnnotations: r.KeepAnnotations,
 		}},
-	}.Execute())
+		ParseMode: kio.ParseModeFast,
+	}).Execute())
 }
@@ -0,0 +1,121 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/labels"
+)
+
+// GetHashRunner returns a command HashRunner.
+func GetHashRunner() *HashRunner {
+	r := &HashRunner{}
+	c := &cobra.Command{
+		Use:   "hash [DIR]",
+		Short: "Append content hashes to the names of ConfigMaps and Secrets",
+		Long: `Append content hashes to the names of ConfigMaps and Secrets, and update the
+references to them.
+
+The hashes are computed as kustomize computes the name suffix hashes of generated
+ConfigMaps and Secrets, so that workloads referencing them are rolled out when their
+contents change.  A previous hash suffix of a name is replaced, so names are only
+changed when the contents change.
+
+References are updated as by 'kyaml rename' -- e.g. the env, envFrom and volumes of
+workloads.
+
+If DIR is provided, the Resources of the package are written back in place.
+Otherwise Resources are read from stdin and written to stdout, and the renames are
+logged to stderr with --log-level info.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# hash the ConfigMaps and Secrets of a package
+kyaml hash my-dir/
+
+# hash the output of another command
+kyaml cat my-dir/ | kyaml hash | kubectl apply -f -
+
+# only hash the Secrets
+kyaml hash my-dir/ --kind Secret
+`,
+		RunE: r.runE,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.Flags().StringVar(&r.Target.Kind, "kind", "",
+		"only hash Resources of this kind -- ConfigMap or Secret.")
+	c.Flags().StringVar(&r.Target.Name, "name", "",
+		"only hash Resources with names matching this glob.")
+	c.Flags().StringVar(&r.Target.Namespace, "namespace", "",
+		"only hash Resources with namespaces matching this glob.")
+	c.Flags().StringVarP(&r.Selector, "selector", "l", "",
+		"only hash Resources with these labels -- e.g. 'app=nginx,tier=web'.")
+	markFlagValues(c, "kind", "ConfigMap", "Secret")
+	r.Command = c
+	return r
+}
+
+func HashCommand() *cobra.Command {
+	return GetHashRunner().Command
+}
+
+// HashRunner contains the run function
+type HashRunner struct {
+	Target   filters.PatchTarget
+	Selector string
+	Command  *cobra.Command
+}
+
+func (r *HashRunner) runE(c *cobra.Command, args []string) error {
+	if r.Selector != "" {
+		l, err := labels.ConvertSelectorToLabelsMap(r.Selector)
+		if err != nil {
+			return handleError(c, err)
+		}
+		r.Target.Labels = l
+	}
+
+	f := &filters.HashFilter{Target: r.Target}
+	rw := &kio.ByteReadWriter{Reader: c.InOrStdin(), Writer: c.OutOrStdout()}
+	input, output := kio.Reader(rw), kio.Writer(rw)
+	if len(args) == 1 {
+		pkg := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[0]}
+		input, output = pkg, pkg
+	}
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{input}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{output}}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+	for _, line := range hashRenames(f) {
+		// stdout contains the Resources when they are read from stdin
+		if len(args) == 1 {
+			fmt.Fprintln(c.OutOrStdout(), line)
+		} else {
+			Logf(c, LogLevelInfo, "%s", line)
+		}
+	}
+	return nil
+}
+
+// hashRenames returns the lines describing the Resources renamed by the filter and the
+// number of references updated
+func hashRenames(f *filters.HashFilter) []string {
+	references := 0
+	lines := []string{fmt.Sprintf("hashed %d resources", len(f.Renamed))}
+	for _, r := range f.Renamed {
+		id := r.Name
+		if r.Namespace != "" {
+			id = r.Namespace + "/" + r.Name
+		}
+		lines = append(lines, fmt.Sprintf("%s %s -> %s", r.ResourceKind, id, r.NewName))
+		references += len(r.References)
+	}
+	return append(lines, fmt.Sprintf("updated %d references", references))
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const hashInput = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  PORT: "8080"
+  MODE: prod
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        envFrom:
+        - configMapRef:
+            name: web-config
+`
+
+const hashOutput = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config-tgmmtkfdcd
+data:
+  PORT: "8080"
+  MODE: prod
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        envFrom:
+        - configMapRef:
+            name: web-config-tgmmtkfdcd
+`
+
+func TestHashCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"app.yaml": hashInput})
+
+	r := cmd.GetHashRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `hashed 1 resources
+ConfigMap web-config -> web-config-tgmmtkfdcd
+updated 1 references
+`, b.String())
+	assertFile(t, filepath.Join(d, "app.yaml"), hashOutput)
+}
+
+func TestHashCommand_stdin(t *testing.T) {
+	cmd.LogLevel = cmd.LogLevelInfo
+	defer func() { cmd.LogLevel = cmd.LogLevelWarn }()
+	r := cmd.GetHashRunner()
+	out, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	r.Command.SetIn(bytes.NewBufferString(hashInput))
+	r.Command.SetOut(out)
+	r.Command.SetErr(stderr)
+	r.Command.SetArgs([]string{})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, hashOutput, out.String())
+	assert.Contains(t, stderr.String(), "info: ConfigMap web-config -> web-config-tgmmtkfdcd\n")
+}
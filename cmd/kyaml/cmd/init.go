@@ -0,0 +1,265 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// GetInitRunner returns a command InitRunner.
+func GetInitRunner() *InitRunner {
+	r := &InitRunner{}
+	c := &cobra.Command{
+		Use:   "init DIR",
+		Short: "Create a new package from a template",
+		Long: `Create a new package from a template.
+
+init creates DIR, and writes the package layout of the template to it:
+
+  app:            a single package containing the Resources of an application
+  base-overlays:  a base package containing the Resources of an application, and
+                  dev and prod overlays customizing it
+  component:      a kustomize Component, which may be included by other packages
+
+The app template may be created without a kustomization.yaml using
+--kustomization=false.  The example Resources may be omitted using
+--examples=false.
+
+  DIR:
+    Path to the package directory to create.  Must not exist, or be empty.
+`,
+		Example: `# create a package for an application
+kyaml init my-app/
+
+# create a base and overlays for an application in the my-team namespace
+kyaml init my-app/ --template base-overlays --namespace my-team
+
+# create an empty package without a kustomization.yaml
+kyaml init my-app/ --kustomization=false --examples=false
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().StringVar(&r.Template, "template", "app",
+		"template of the package.  may be 'app', 'base-overlays' or 'component'.")
+	markFlagValues(c, "template", "app", "base-overlays", "component")
+	c.Flags().StringVar(&r.Name, "name", "",
+		"name of the application.  defaults to the name of DIR.")
+	c.Flags().StringVar(&r.Namespace, "namespace", "",
+		"namespace of the application Resources.")
+	c.Flags().BoolVar(&r.Kustomization, "kustomization", true,
+		"write kustomization.yaml files.")
+	c.Flags().BoolVar(&r.Examples, "examples", true,
+		"write example Resources.")
+	r.Command = c
+	return r
+}
+
+func InitCommand() *cobra.Command {
+	return GetInitRunner().Command
+}
+
+// InitRunner contains the run function
+type InitRunner struct {
+	Template      string
+	Name          string
+	Namespace     string
+	Kustomization bool
+	Examples      bool
+	Command       *cobra.Command
+}
+
+// initFile is a file written by init
+type initFile struct {
+	// kustomization is true for kustomization.yaml files
+	kustomization bool
+	// example is true for example Resources
+	example bool
+	// content is the text/template of the file content, executed with the InitRunner
+	content string
+}
+
+// initTemplates are the package layouts written by init, indexed by the template name
+var initTemplates = map[string]map[string]initFile{
+	"app": {
+		"kustomization.yaml": {kustomization: true, content: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+{{- if .Namespace}}
+namespace: {{.Namespace}}
+{{- end}}
+resources:
+{{- if .Examples}}
+- deployment.yaml
+- service.yaml
+{{- else}} []
+{{- end}}
+`},
+		"deployment.yaml": {example: true, content: initDeployment},
+		"service.yaml":    {example: true, content: initService},
+	},
+	"base-overlays": {
+		"base/kustomization.yaml": {kustomization: true, content: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+{{- if .Examples}}
+- deployment.yaml
+- service.yaml
+{{- else}} []
+{{- end}}
+`},
+		"base/deployment.yaml": {example: true, content: initDeployment},
+		"base/service.yaml":    {example: true, content: initService},
+		"overlays/dev/kustomization.yaml": {kustomization: true, content: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: {{if .Namespace}}{{.Namespace}}{{else}}{{.Name}}{{end}}-dev
+resources:
+- ../../base
+`},
+		"overlays/prod/kustomization.yaml": {kustomization: true, content: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: {{if .Namespace}}{{.Namespace}}{{else}}{{.Name}}{{end}}-prod
+resources:
+- ../../base
+{{- if .Examples}}
+patchesStrategicMerge:
+- replicas.yaml
+{{- end}}
+`},
+		"overlays/prod/replicas.yaml": {example: true, content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+spec:
+  replicas: 3
+`},
+	},
+	"component": {
+		"kustomization.yaml": {kustomization: true, content: `apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+{{- if .Examples}}
+resources:
+- configmap.yaml
+{{- end}}
+`},
+		"configmap.yaml": {example: true, content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{.Name}}
+{{- if .Namespace}}
+  namespace: {{.Namespace}}
+{{- end}}
+data:
+  key: value
+`},
+	},
+}
+
+const initDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+{{- if and .Namespace (not .Kustomization)}}
+  namespace: {{.Namespace}}
+{{- end}}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: {{.Name}}
+  template:
+    metadata:
+      labels:
+        app: {{.Name}}
+    spec:
+      containers:
+      - name: {{.Name}}
+        image: nginx:1.17
+        ports:
+        - containerPort: 80
+`
+
+const initService = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+{{- if and .Namespace (not .Kustomization)}}
+  namespace: {{.Namespace}}
+{{- end}}
+spec:
+  selector:
+    app: {{.Name}}
+  ports:
+  - port: 80
+    targetPort: 80
+`
+
+func (r *InitRunner) runE(c *cobra.Command, args []string) error {
+	files, found := initTemplates[r.Template]
+	if !found {
+		return handleError(c, fmt.Errorf(
+			"unknown template '%s': may be one of 'app', 'base-overlays' or 'component'", r.Template))
+	}
+	if !r.Kustomization && r.Template != "app" {
+		return handleError(c, fmt.Errorf(
+			"the %s template requires kustomization.yaml files", r.Template))
+	}
+
+	dir := args[0]
+	if r.Name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return handleError(c, err)
+		}
+		r.Name = strings.ToLower(filepath.Base(abs))
+	}
+	if existing, err := ioutil.ReadDir(dir); err == nil && len(existing) > 0 {
+		return handleError(c, fmt.Errorf("%s already exists and is not empty", dir))
+	} else if err != nil && !os.IsNotExist(err) {
+		return handleError(c, err)
+	}
+
+	var paths []string
+	for path, f := range files {
+		if (f.kustomization && !r.Kustomization) || (f.example && !r.Examples) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		t, err := template.New(path).Parse(files[path].content)
+		if err != nil {
+			return handleError(c, err)
+		}
+		b := &bytes.Buffer{}
+		if err := t.Execute(b, r); err != nil {
+			return handleError(c, err)
+		}
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return handleError(c, err)
+		}
+		if err := ioutil.WriteFile(path, b.Bytes(), 0600); err != nil {
+			return handleError(c, err)
+		}
+		fmt.Fprintf(c.OutOrStdout(), "created %s\n", path)
+	}
+	if len(paths) == 0 {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return handleError(c, err)
+		}
+		fmt.Fprintf(c.OutOrStdout(), "created %s\n", dir)
+	}
+	return nil
+}
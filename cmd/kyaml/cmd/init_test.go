@@ -0,0 +1,147 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestInitCommand_app(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	dir := filepath.Join(d, "My-App")
+
+	r := cmd.GetInitRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{dir, "--namespace", "default"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "created "+filepath.Join(dir, "deployment.yaml")+"\n"+
+		"created "+filepath.Join(dir, "kustomization.yaml")+"\n"+
+		"created "+filepath.Join(dir, "service.yaml")+"\n", b.String())
+
+	actual, err := ioutil.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: default
+resources:
+- deployment.yaml
+- service.yaml
+`, string(actual))
+
+	actual, err = ioutil.ReadFile(filepath.Join(dir, "service.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app
+spec:
+  selector:
+    app: my-app
+  ports:
+  - port: 80
+    targetPort: 80
+`, string(actual))
+
+	// the directory must be empty
+	r = cmd.GetInitRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{dir})
+	assert.EqualError(t, r.Command.Execute(), dir+" already exists and is not empty")
+}
+
+func TestInitCommand_noKustomization(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	r := cmd.GetInitRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{d, "--name", "nginx", "--namespace", "web", "--kustomization=false"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	files, err := ioutil.ReadDir(d)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{"deployment.yaml", "service.yaml"}, names)
+
+	actual, err := ioutil.ReadFile(filepath.Join(d, "service.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(actual), "  name: nginx\n  namespace: web\n")
+}
+
+func TestInitCommand_baseOverlays(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	r := cmd.GetInitRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{d, "--name", "nginx", "--template", "base-overlays", "--examples=false"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(d, "base", "kustomization.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources: []
+`, string(actual))
+
+	actual, err = ioutil.ReadFile(filepath.Join(d, "overlays", "prod", "kustomization.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: nginx-prod
+resources:
+- ../../base
+`, string(actual))
+	_, err = os.Stat(filepath.Join(d, "overlays", "prod", "replicas.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestInitCommand_unknownTemplate(t *testing.T) {
+	r := cmd.GetInitRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"my-app", "--template", "microservice"})
+	assert.EqualError(t, r.Command.Execute(),
+		"unknown template 'microservice': may be one of 'app', 'base-overlays' or 'component'")
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetLabelRunner returns a command LabelRunner.
+func GetLabelRunner() *LabelRunner {
+	r := &LabelRunner{}
+	c := &cobra.Command{
+		Use:   "label KEY=VALUE[,KEY=VALUE]... [DIR]...",
+		Short: "Set labels on Resources in a directory or from stdin",
+		Long: `Set labels on Resources in a directory or from stdin.
+
+  KEY=VALUE:
+    Comma separated list of labels to set on each Resource.
+
+  DIR:
+    Path to local directory.  Resources are updated and written back to
+    their files.  If no directories are provided, Resources are read from
+    stdin and written to stdout.
+
+Labels are set on .metadata.labels.  If --include-selectors is set, labels are
+also added to selectors and pod templates the same way the kustomize
+commonLabels transformer does -- e.g. .spec.selector.matchLabels and
+.spec.template.metadata.labels for Deployments, and .spec.selector for
+Services.
+`,
+		Example: `# label the Resources in a directory
+kyaml label app=nginx my-dir/
+
+# label the Resources and their selectors
+kyaml label app=nginx,tier=web --include-selectors my-dir/
+
+# label kustomize output
+kustomize build | kyaml label env=prod
+`,
+		PreRunE: r.preRunE,
+		RunE:    r.runE,
+		Args:    cobra.MinimumNArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSelectors, "include-selectors", false,
+		"also set the labels on selectors and pod templates.")
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also label resources from subpackages.")
+
+	r.Command = c
+	return r
+}
+
+func LabelCommand() *cobra.Command {
+	return GetLabelRunner().Command
+}
+
+// LabelRunner contains the run function
+type LabelRunner struct {
+	IncludeSubpackages bool
+	Command            *cobra.Command
+	filters.LabelSetter
+}
+
+func (r *LabelRunner) preRunE(c *cobra.Command, args []string) error {
+	r.Labels = map[string]string{}
+	for _, l := range strings.Split(args[0], ",") {
+		parts := strings.SplitN(l, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("label must be specified as KEY=VALUE: %s", l)
+		}
+		r.Labels[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+func (r *LabelRunner) runE(c *cobra.Command, args []string) error {
+	f := []kio.Filter{r.LabelSetter}
+
+	// label stdin if there are no directories
+	if len(args) == 1 {
+		rw := &kio.ByteReadWriter{Reader: c.InOrStdin(), Writer: c.OutOrStdout()}
+		return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}).Execute())
+	}
+
+	for _, path := range args[1:] {
+		rw := &kio.LocalPackageReadWriter{
+			NoDeleteFiles:      true,
+			PackagePath:        path,
+			IncludeSubpackages: r.IncludeSubpackages,
+		}
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+	}
+	return nil
+}
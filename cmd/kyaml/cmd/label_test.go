@@ -0,0 +1,115 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestLabelCommand_files(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the kustomization file isn't labeled
+	writeFiles(t, d, map[string]string{"kustomization.yaml": "resources:\n- f1.yaml\n"})
+
+	r := cmd.GetLabelRunner()
+	r.Command.SetArgs([]string{"tier=web,env=prod", d, "--include-selectors"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(d, "f1.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  labels:
+    env: prod
+    tier: web
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+      env: prod
+      tier: web
+  template:
+    metadata:
+      labels:
+        app: nginx
+        env: prod
+        tier: web
+`, string(b))
+	assertFile(t, filepath.Join(d, "kustomization.yaml"), "resources:\n- f1.yaml\n")
+}
+
+func TestLabelCommand_stdin(t *testing.T) {
+	out := &bytes.Buffer{}
+	r := cmd.GetLabelRunner()
+	r.Command.SetArgs([]string{"tier=web"})
+	r.Command.SetOut(out)
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+spec:
+  selector:
+    app: nginx
+`))
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  labels:
+    tier: web
+spec:
+  selector:
+    app: nginx
+`, out.String())
+}
+
+func TestLabelCommand_invalid(t *testing.T) {
+	r := cmd.GetLabelRunner()
+	r.Command.SetArgs([]string{"tier"})
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SilenceErrors = true
+	err := r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "KEY=VALUE")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/conformance"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// GetLintRunner returns a command LintRunner.
+func GetLintRunner() *LintRunner {
+	r := &LintRunner{Checks: conformance.LintChecks()}
+	c := &cobra.Command{
+		Use:   "lint [DIR]...",
+		Short: "Lint Resources for deprecations and best practices",
+		Long: `Lint Resources from a local directory or stdin.
+
+lint runs the following rules:
+
+  deprecated-api:  (error)   Resources must not use deprecated or removed apiVersions
+  resource-limits: (warning) containers should set cpu and memory limits
+  image-tag:       (warning) container images should be pinned to a tag other than
+                             latest, or to a digest
+  probes:          (warning) containers of long running workloads should set liveness
+                             and readiness probes
+
+lint exits non-zero if any rule fails with an error -- or with a warning if
+--fail-on is 'warning'.
+
+For stricter policy checks, see 'kyaml conformance'.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# lint the Resources in a directory
+kyaml lint my-dir/
+
+# lint kustomize output in CI, failing on warnings
+kustomize build | kyaml lint --fail-on warning --output json
+
+# only check for deprecated apiVersions and unpinned images
+kyaml lint my-dir/ --rule deprecated-api --rule image-tag
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also lint resources from subpackages.")
+	c.Flags().StringSliceVar(&r.Rules, "rule", []string{},
+		"rule to run.  may be specified multiple times.  defaults to all rules.")
+	c.Flags().StringSliceVar(&r.DisabledRules, "disable-rule", []string{},
+		"rule to skip.  may be specified multiple times.")
+	c.Flags().StringVar(&r.FailOn, "fail-on", string(conformance.SeverityError),
+		"severity of the findings to exit non-zero on.  may be 'error' or 'warning'.")
+	markFlagValues(c, "fail-on",
+		string(conformance.SeverityError), string(conformance.SeverityWarning))
+	SetOutputFormats(c, OutputJSON)
+	r.Command = c
+	return r
+}
+
+func LintCommand() *cobra.Command {
+	return GetLintRunner().Command
+}
+
+// LintRunner contains the run function
+type LintRunner struct {
+	IncludeSubpackages bool
+	Rules              []string
+	DisabledRules      []string
+	FailOn             string
+	Command            *cobra.Command
+
+	// Checks are the rules which may be run, indexed by name.  Defaults to the
+	// conformance.LintChecks, and may be extended with additional rules.
+	Checks map[string]conformance.Check
+}
+
+// lintReport is the machine-readable output of lint
+type lintReport struct {
+	Errors   int                  `json:"errors"`
+	Warnings int                  `json:"warnings"`
+	Results  []conformance.Result `json:"results"`
+}
+
+func (r *LintRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+	if r.FailOn != string(conformance.SeverityError) && r.FailOn != string(conformance.SeverityWarning) {
+		return handleError(c, fmt.Errorf("--fail-on must be one of '%s' or '%s', got '%s'",
+			conformance.SeverityError, conformance.SeverityWarning, r.FailOn))
+	}
+	checks, err := conformance.SelectChecks(r.Checks, r.Rules, r.DisabledRules)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	buff := &kio.PackageBuffer{}
+	if err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Outputs: []kio.Writer{buff}}).Execute(); err != nil {
+		return handleError(c, err)
+	}
+	results, err := conformance.Run(buff.Nodes, checks)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	report := lintReport{Errors: conformance.Failed(results), Results: results}
+	report.Warnings = len(results) - report.Errors
+	if report.Results == nil {
+		report.Results = []conformance.Result{}
+	}
+
+	if output == OutputJSON {
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		if err := e.Encode(report); err != nil {
+			return handleError(c, err)
+		}
+	} else {
+		for _, res := range results {
+			file := res.File
+			if file == "" {
+				file = "stdin"
+			}
+			id := res.Name
+			if res.Namespace != "" {
+				id = res.Namespace + "/" + res.Name
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s: %s %s: [%s] %s: %s\n",
+				file, res.Line, res.Severity, res.Kind, id, res.Check, res.Field, res.Message)
+		}
+	}
+
+	failed := report.Errors
+	if r.FailOn == string(conformance.SeverityWarning) {
+		failed = len(results)
+	}
+	if failed > 0 {
+		return handleError(c, fmt.Errorf("%d lint findings failed", failed))
+	}
+	return nil
+}
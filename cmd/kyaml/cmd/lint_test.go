@@ -0,0 +1,148 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+	"sigs.k8s.io/kustomize/kyaml/conformance"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const lintInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:latest
+        livenessProbe:
+          httpGet:
+            port: 80
+        readinessProbe:
+          httpGet:
+            port: 80
+        resources:
+          limits:
+            cpu: 100m
+            memory: 128Mi
+`
+
+func TestLintCommand(t *testing.T) {
+	// warnings don't fail by default
+	r := cmd.GetLintRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(lintInput))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "stdin:11: warning: Deployment default/nginx: [image-tag] "+
+		"spec.template.spec.containers[name=nginx].image: "+
+		"image 'nginx:latest' should be pinned to a tag other than latest\n", b.String())
+
+	// warnings fail with --fail-on warning
+	r = cmd.GetLintRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetIn(strings.NewReader(lintInput))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"--fail-on", "warning"})
+	assert.EqualError(t, r.Command.Execute(), "1 lint findings failed")
+
+	// disabled rules are not run
+	r = cmd.GetLintRunner()
+	b = &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(lintInput))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"--fail-on", "warning", "--disable-rule", "image-tag"})
+	if assert.NoError(t, r.Command.Execute()) {
+		assert.Empty(t, b.String())
+	}
+}
+
+func TestLintCommand_json(t *testing.T) {
+	r := cmd.GetLintRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(`apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: foo
+`))
+	r.Command.SetOut(b)
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{"-o", "json", "--rule", "deprecated-api"})
+	assert.EqualError(t, r.Command.Execute(), "1 lint findings failed")
+
+	var report map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(b.Bytes(), &report)) {
+		return
+	}
+	assert.Equal(t, float64(1), report["errors"])
+	assert.Equal(t, float64(0), report["warnings"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"check":      "deprecated-api",
+		"severity":   "error",
+		"apiVersion": "extensions/v1beta1",
+		"kind":       "Ingress",
+		"name":       "foo",
+		"line":       float64(1),
+		"field":      "apiVersion",
+		"message": "extensions/v1beta1 Ingress is not served since Kubernetes 1.22, " +
+			"use networking.k8s.io/v1beta1",
+	}}, report["results"])
+}
+
+// namespaceCheck is a custom rule requiring Resources to set a namespace
+type namespaceCheck struct{}
+
+func (namespaceCheck) Name() string { return "namespace" }
+
+func (namespaceCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]conformance.Result, error) {
+	if meta.Namespace != "" {
+		return nil, nil
+	}
+	return []conformance.Result{{
+		Severity: conformance.SeverityError,
+		Field:    "metadata.namespace",
+		Message:  "Resources must set a namespace",
+	}}, nil
+}
+
+func TestLintCommand_customRule(t *testing.T) {
+	r := cmd.GetLintRunner()
+	r.Checks["namespace"] = namespaceCheck{}
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n"))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"--rule", "namespace"})
+	assert.EqualError(t, r.Command.Execute(), "1 lint findings failed")
+	assert.Equal(t, "stdin:0: error: ConfigMap foo: [namespace] metadata.namespace: "+
+		"Resources must set a namespace\n", b.String())
+}
+
+func TestLintCommand_unknownRule(t *testing.T) {
+	r := cmd.GetLintRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetIn(strings.NewReader(lintInput))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"--rule", "images"})
+	assert.EqualError(t, r.Command.Execute(), "unknown check 'images'")
+}
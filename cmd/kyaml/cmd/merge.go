@@ -76,5 +76,6 @@ func (r *MergeRunner) runE(c *cobra.Command, args []string) error {
 	}
 
 	filters := []kio.Filter{filters.MergeFilter{}, filters.FormatFilter{}}
-	return handleError(c, kio.Pipeline{Inputs: inputs, Filters: filters, Outputs: outputs}.Execute())
+	return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Filters: filters, Outputs: outputs}).Execute())
 }
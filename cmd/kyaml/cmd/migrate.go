@@ -0,0 +1,97 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetMigrateRunner returns a command MigrateRunner.
+func GetMigrateRunner() *MigrateRunner {
+	r := &MigrateRunner{}
+	c := &cobra.Command{
+		Use:   "migrate DIR",
+		Short: "Migrate Resources from deprecated apiVersions",
+		Long: `Migrate the Resources of a package from deprecated or removed apiVersions to their
+replacements, and write them back in place.
+
+The apiVersions reported by the deprecated-api check of 'kyaml conformance' are
+migrated -- e.g. extensions/v1beta1 Deployments to apps/v1, and extensions/v1beta1
+Ingresses to networking.k8s.io/v1beta1.  The structural changes required by the
+replacements are made:
+
+  - apps/v1 workloads: spec.selector is set from the pod template labels if it is not
+    set, the update strategy of DaemonSets and StatefulSets is set to the previous
+    default, and spec.rollbackTo of Deployments is removed
+  - apiextensions.k8s.io/v1 CustomResourceDefinitions: spec.version, spec.validation,
+    spec.subresources and spec.additionalPrinterColumns are moved to spec.versions,
+    and the conversion webhook is moved to spec.conversion.webhook
+  - admissionregistration.k8s.io/v1 webhooks: admissionReviewVersions, failurePolicy,
+    matchPolicy and timeoutSeconds are set to the previous defaults if they are not set
+
+Each migrated Resource is printed with the follow-ups which must be made or checked
+manually -- e.g. removed fields, or defaults which differ in the replacement.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# migrate the Resources of a package
+kyaml migrate my-dir/
+
+# print the migrations and follow-ups without writing the Resources
+kyaml migrate my-dir/ --dry-run
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().BoolVar(&r.DryRun, "dry-run", false,
+		"print the migrations rather than writing the Resources back to DIR.")
+	r.Command = c
+	return r
+}
+
+func MigrateCommand() *cobra.Command {
+	return GetMigrateRunner().Command
+}
+
+// MigrateRunner contains the run function
+type MigrateRunner struct {
+	DryRun  bool
+	Command *cobra.Command
+}
+
+func (r *MigrateRunner) runE(c *cobra.Command, args []string) error {
+	f := &filters.MigrateFilter{}
+	rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[0]}
+	p := ConfigurePipeline(c, kio.Pipeline{Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}})
+	if !r.DryRun {
+		p.Outputs = []kio.Writer{rw}
+	}
+	if err := p.Execute(); err != nil {
+		return handleError(c, err)
+	}
+
+	followUps := 0
+	fmt.Fprintf(c.OutOrStdout(), "migrated %d resources\n", len(f.Migrated))
+	for _, m := range f.Migrated {
+		id := m.Name
+		if m.Namespace != "" {
+			id = m.Namespace + "/" + m.Name
+		}
+		fmt.Fprintf(c.OutOrStdout(), "%s: %s %s: %s -> %s\n",
+			m.File, m.Kind, id, m.ApiVersion, m.NewApiVersion)
+		for _, s := range m.FollowUps {
+			fmt.Fprintf(c.OutOrStdout(), "  follow-up: %s\n", s)
+		}
+		followUps += len(m.FollowUps)
+	}
+	if followUps > 0 {
+		fmt.Fprintf(c.OutOrStdout(), "%d follow-ups must be made or checked manually\n", followUps)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const migrateInput = `apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  revisionHistoryLimit: 5
+  progressDeadlineSeconds: 300
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: system
+spec:
+  selector:
+    matchLabels:
+      app: agent
+`
+
+func TestMigrateCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"app.yaml": migrateInput})
+
+	r := cmd.GetMigrateRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `migrated 2 resources
+app.yaml: Deployment web: extensions/v1beta1 -> apps/v1
+app.yaml: DaemonSet system/agent: extensions/v1beta1 -> apps/v1
+  follow-up: spec.updateStrategy was set to OnDelete, the default of extensions/v1beta1 -- remove it to use RollingUpdate
+1 follow-ups must be made or checked manually
+`, b.String())
+	assertFile(t, filepath.Join(d, "app.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  revisionHistoryLimit: 5
+  progressDeadlineSeconds: 300
+  template:
+    metadata:
+      labels:
+        app: web
+  selector:
+    matchLabels:
+      app: web
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: system
+spec:
+  selector:
+    matchLabels:
+      app: agent
+  updateStrategy:
+    type: OnDelete
+`)
+}
+
+func TestMigrateCommand_dryRun(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"app.yaml": migrateInput})
+
+	r := cmd.GetMigrateRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--dry-run"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Contains(t, b.String(), "migrated 2 resources\n")
+	assertFile(t, filepath.Join(d, "app.yaml"), migrateInput)
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// OwnershipCommand returns the ownership command and its subcommands.
+func OwnershipCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "ownership",
+		Short: "Manage the tools which own Resources",
+		Long: `Manage the tools which own Resources.
+
+See the subcommands for details.
+`,
+	}
+	c.AddCommand(OwnershipTransferCommand())
+	return c
+}
+
+// GetOwnershipTransferRunner returns a command OwnershipTransferRunner.
+func GetOwnershipTransferRunner() *OwnershipTransferRunner {
+	r := &OwnershipTransferRunner{}
+	c := &cobra.Command{
+		Use:   "transfer [DIR]...",
+		Short: "Prepare Resources for adoption from another tool",
+		Long: `Prepare Resources for adoption from another tool.
+
+transfer removes the labels and annotations set by the tools the Resources are
+transferred from, so that the Resources may be adopted into a kustomize or GitOps
+workflow.  By default the fields set by the apiserver are also removed (see strip).
+
+The tools are selected by name using --from.  Built-in tools are:
+
+  argocd:  argocd.argoproj.io instance and tracking labels and annotations
+  flux:    kustomize.toolkit.fluxcd.io and helm.toolkit.fluxcd.io labels and annotations
+  helm:    meta.helm.sh annotations, and the Helm managed-by, chart and heritage labels
+  kubectl: the last-applied-configuration annotation
+
+Additional tools may be defined in a --profile-file, containing one or more yaml
+documents of the form:
+
+  name: my-tool
+  labels:
+  - my-tool.example.com/*
+  annotations:
+  - my-tool.example.com/*
+  - app.kubernetes.io/managed-by=my-tool
+
+Labels and annotations are matched by key, by a key prefix ending in '*', or by key
+and value as 'key=value'.
+
+Only the labels and annotations of the Resources themselves are removed.  Pod
+template labels are left untouched, since they may be matched by selectors.
+
+If DIR is specified, the Resources are updated in place and the removed fields are
+printed.  Otherwise the Resources are read from stdin and written to stdout.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# adopt Resources exported from a cluster where they were deployed by helm
+kubectl get deploy,svc -o yaml | kyaml ownership transfer --from helm,kubectl
+
+# adopt Resources in a directory using a custom profile
+kyaml ownership transfer my-dir/ --from my-tool --profile-file profiles.yaml
+`,
+		RunE: r.runE,
+	}
+	c.Flags().StringSliceVar(&r.From, "from", []string{"kubectl"},
+		"tools to transfer ownership from.")
+	c.Flags().StringSliceVar(&r.ProfileFiles, "profile-file", []string{},
+		"path to a file containing additional tool profiles.")
+	c.Flags().BoolVar(&r.Strip, "strip", true,
+		"also remove the fields set by the apiserver.")
+	r.Command = c
+	return r
+}
+
+func OwnershipTransferCommand() *cobra.Command {
+	return GetOwnershipTransferRunner().Command
+}
+
+// OwnershipTransferRunner contains the run function
+type OwnershipTransferRunner struct {
+	From         []string
+	ProfileFiles []string
+	Strip        bool
+	Command      *cobra.Command
+}
+
+func (r *OwnershipTransferRunner) runE(c *cobra.Command, args []string) error {
+	profiles := map[string]filters.TransferProfile{}
+	for k, v := range filters.TransferProfiles {
+		profiles[k] = v
+	}
+	for _, file := range r.ProfileFiles {
+		if err := readTransferProfiles(file, profiles); err != nil {
+			return handleError(c, err)
+		}
+	}
+
+	f := &filters.OwnershipTransferFilter{}
+	for _, name := range r.From {
+		p, found := profiles[name]
+		if !found {
+			var names []string
+			for k := range profiles {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			return handleError(c, fmt.Errorf("unknown tool '%s': may be one of: [%s]",
+				name, strings.Join(names, ",")))
+		}
+		f.Profiles = append(f.Profiles, p)
+	}
+	fltrs := []kio.Filter{f}
+	if r.Strip {
+		fltrs = append(fltrs, filters.StripFilter{
+			Status:            true,
+			ManagedFields:     true,
+			CreationTimestamp: true,
+			ResourceVersion:   true,
+			UID:               true,
+		})
+	}
+
+	// transfer stdin if there are no args
+	if len(args) == 0 {
+		rw := &kio.ByteReadWriter{
+			Reader: c.InOrStdin(),
+			Writer: c.OutOrStdout(),
+		}
+		return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: fltrs, Outputs: []kio.Writer{rw}}).Execute())
+	}
+
+	for i := range args {
+		rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[i]}
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: fltrs, Outputs: []kio.Writer{rw}}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+		for _, m := range f.Removed {
+			id := m.Name
+			if m.Namespace != "" {
+				id = m.Namespace + "/" + m.Name
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s %s: removed %s\n",
+				m.File, m.Line, m.Kind, id, m.Path)
+		}
+	}
+	return nil
+}
+
+// readTransferProfiles reads the profiles in file into profiles
+func readTransferProfiles(file string, profiles map[string]filters.TransferProfile) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		p := filters.TransferProfile{}
+		if err := d.Decode(&p); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		if p.Name == "" {
+			return fmt.Errorf("%s: profiles must specify a name", file)
+		}
+		profiles[p.Name] = p
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestOwnershipTransferCommand_stdin(t *testing.T) {
+	r := cmd.GetOwnershipTransferRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(`apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+  uid: 1234
+  labels:
+    app.kubernetes.io/managed-by: Helm
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{}'
+    meta.helm.sh/release-name: nginx
+spec:
+  type: ClusterIP
+status:
+  loadBalancer: {}
+`))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"--from", "helm,kubectl"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  type: ClusterIP
+`, b.String())
+}
+
+func TestOwnershipTransferCommand_profileFile(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "profiles.yaml"), []byte(`name: my-tool
+labels:
+- app.kubernetes.io/managed-by=my-tool
+annotations:
+- my-tool.example.com/*
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+	pkg := filepath.Join(d, "pkg")
+	if !assert.NoError(t, os.Mkdir(pkg, 0700)) {
+		return
+	}
+	err = ioutil.WriteFile(filepath.Join(pkg, "configmap.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  labels:
+    app: nginx
+    app.kubernetes.io/managed-by: my-tool
+  annotations:
+    my-tool.example.com/revision: "3"
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetOwnershipTransferRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{pkg, "--from", "my-tool",
+		"--profile-file", filepath.Join(d, "profiles.yaml")})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `configmap.yaml:7: ConfigMap config: removed metadata.labels.app\.kubernetes\.io/managed-by
+configmap.yaml:9: ConfigMap config: removed metadata.annotations.my-tool\.example\.com/revision
+`, b.String())
+
+	actual, err := ioutil.ReadFile(filepath.Join(pkg, "configmap.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  labels:
+    app: nginx
+`, string(actual))
+}
+
+func TestOwnershipTransferCommand_unknownTool(t *testing.T) {
+	r := cmd.GetOwnershipTransferRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetIn(strings.NewReader("kind: ConfigMap\n"))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{"--from", "puppet"})
+	assert.EqualError(t, r.Command.Execute(),
+		"unknown tool 'puppet': may be one of: [argocd,flux,helm,kubectl]")
+}
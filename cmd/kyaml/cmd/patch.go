@@ -0,0 +1,164 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/labels"
+)
+
+// GetPatchRunner returns a command PatchRunner.
+func GetPatchRunner() *PatchRunner {
+	r := &PatchRunner{}
+	c := &cobra.Command{
+		Use:   "patch DIR",
+		Short: "Apply a patch to the Resources of a package",
+		Long: `Apply a strategic merge patch or a JSON 6902 patch to the Resources of a local
+package, and write them back in place.
+
+The patch is applied to the Resources matching all of the target flags.  Without target
+flags, a strategic merge patch is applied to the Resource with its kind, name and
+namespace, and a JSON 6902 patch is applied to all Resources.
+
+Strategic merge patches may delete or replace maps and list elements with the
+'$patch: delete' and '$patch: replace' directives, replace a whole list with an element
+'{$patch: replace}', and delete or replace the Resources with a directive on the patch
+itself.  The other directives -- e.g. '$retainKeys' -- are not supported.
+
+The comments and formatting of the fields not modified by the patch are retained.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# set the image of a container of a Deployment
+kyaml patch my-dir/ --patch '
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.17
+'
+
+# remove a container from a Deployment
+kyaml patch my-dir/ --patch '
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        $patch: delete
+'
+
+# scale all the Deployments with the label tier=web
+kyaml patch my-dir/ --kind Deployment -l tier=web \
+  --json-patch '[{"op": "replace", "path": "/spec/replicas", "value": 3}]'
+
+# apply a patch from a file to the Resources with names starting with 'nginx-'
+kyaml patch my-dir/ --name 'nginx-*' --patch-file patch.yaml
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().StringVar(&r.Patch, "patch", "",
+		"strategic merge patch to apply.  supports the '$patch: delete' and '$patch: replace' directives.")
+	c.Flags().StringVar(&r.PatchFile, "patch-file", "",
+		"path to a file containing a strategic merge patch to apply.")
+	c.Flags().StringVar(&r.JSONPatch, "json-patch", "",
+		"JSON 6902 patch to apply, as json or yaml.")
+	c.Flags().StringVar(&r.JSONPatchFile, "json-patch-file", "",
+		"path to a file containing a JSON 6902 patch to apply.")
+	c.Flags().StringVar(&r.Target.Group, "group", "",
+		"patch Resources in this API group.")
+	c.Flags().StringVar(&r.Target.Version, "version", "",
+		"patch Resources with this API version.")
+	c.Flags().StringVar(&r.Target.Kind, "kind", "",
+		"patch Resources of this kind.")
+	c.Flags().StringVar(&r.Target.Name, "name", "",
+		"patch Resources with names matching this glob.")
+	c.Flags().StringVar(&r.Target.Namespace, "namespace", "",
+		"patch Resources with namespaces matching this glob.")
+	c.Flags().StringVarP(&r.Selector, "selector", "l", "",
+		"patch Resources with these labels -- e.g. 'app=nginx,tier=web'.")
+	r.Command = c
+	return r
+}
+
+func PatchCommand() *cobra.Command {
+	return GetPatchRunner().Command
+}
+
+// PatchRunner contains the run function
+type PatchRunner struct {
+	Patch         string
+	PatchFile     string
+	JSONPatch     string
+	JSONPatchFile string
+	Target        filters.PatchTarget
+	Selector      string
+	Command       *cobra.Command
+}
+
+func (r *PatchRunner) runE(c *cobra.Command, args []string) error {
+	f := &filters.PatchFilter{Target: r.Target}
+	var set int
+	for _, p := range []struct {
+		value, file string
+		patch       *string
+	}{{r.Patch, r.PatchFile, &f.Patch}, {r.JSONPatch, r.JSONPatchFile, &f.JSONPatch}} {
+		if p.value != "" {
+			set++
+			*p.patch = p.value
+		}
+		if p.file != "" {
+			set++
+			b, err := ioutil.ReadFile(p.file)
+			if err != nil {
+				return handleError(c, err)
+			}
+			*p.patch = string(b)
+		}
+	}
+	if set != 1 {
+		return handleError(c, fmt.Errorf(
+			"exactly one of --patch, --patch-file, --json-patch and --json-patch-file must be set"))
+	}
+	if r.Selector != "" {
+		l, err := labels.ConvertSelectorToLabelsMap(r.Selector)
+		if err != nil {
+			return handleError(c, err)
+		}
+		f.Target.Labels = l
+	}
+
+	rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[0]}
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{rw}}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	fmt.Fprintf(c.OutOrStdout(), "patched %d resources\n", len(f.Patched))
+	for _, meta := range f.Patched {
+		id := meta.Name
+		if meta.Namespace != "" {
+			id = meta.Namespace + "/" + meta.Name
+		}
+		fmt.Fprintf(c.OutOrStdout(), "%s %s\n", meta.Kind, id)
+	}
+	return nil
+}
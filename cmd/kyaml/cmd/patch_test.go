@@ -0,0 +1,231 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const patchPackage = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    tier: web
+spec:
+  replicas: 1 # default
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.16
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: redis
+spec:
+  replicas: 1
+`
+
+func TestPatchCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"deploy.yaml": patchPackage})
+
+	r := cmd.GetPatchRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--patch", `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.17
+`})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "patched 1 resources\nDeployment nginx\n", b.String())
+	assertFile(t, filepath.Join(d, "deploy.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    tier: web
+spec:
+  replicas: 1 # default
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.17
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: redis
+spec:
+  replicas: 1
+`)
+}
+
+func TestPatchCommand_delete(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"deploy.yaml": patchPackage})
+
+	r := cmd.GetPatchRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--patch", `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: redis
+$patch: delete
+`})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "patched 1 resources\nDeployment redis\n", b.String())
+	assertFile(t, filepath.Join(d, "deploy.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    tier: web
+spec:
+  replicas: 1 # default
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.16
+`)
+}
+
+func TestPatchCommand_annotations(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"deploy.yaml":        patchPackage,
+		"kustomization.yaml": "resources:\n- deploy.yaml\n",
+	})
+
+	// the annotations of the reader don't stop the Resources from being written back, and
+	// the kustomization isn't patched
+	r := cmd.GetPatchRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{d, "--name", "redis", "--json-patch",
+		`[{"op": "add", "path": "/metadata/annotations", "value": {"owner": "db"}}]`})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assertFile(t, filepath.Join(d, "deploy.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    tier: web
+spec:
+  replicas: 1 # default
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.16
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: redis
+  annotations:
+    "owner": "db"
+spec:
+  replicas: 1
+`)
+	assertFile(t, filepath.Join(d, "kustomization.yaml"), "resources:\n- deploy.yaml\n")
+}
+
+func TestPatchCommand_jsonPatchFile(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"deploy.yaml": patchPackage})
+	patch := filepath.Join(d, "patch.json")
+	err = ioutil.WriteFile(patch, []byte(`[{"op": "replace", "path": "/spec/replicas", "value": 3}]`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetPatchRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{filepath.Join(d, "deploy.yaml"),
+		"--json-patch-file", patch, "--kind", "Deployment", "-l", "tier=web"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "patched 1 resources\nDeployment nginx\n", b.String())
+	assertFile(t, filepath.Join(d, "deploy.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    tier: web
+spec:
+  replicas: 3 # default
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.16
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: redis
+spec:
+  replicas: 1
+`)
+}
+
+func TestPatchCommand_noPatch(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	r := cmd.GetPatchRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetArgs([]string{d, "--kind", "Deployment"})
+	err = r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "exactly one of --patch")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// PluginPrefix is the prefix of the names of the executables run as kyaml commands
+const PluginPrefix = "kyaml-"
+
+// GetPluginRunner returns a command PluginRunner.
+func GetPluginRunner() *PluginRunner {
+	r := &PluginRunner{}
+	c := &cobra.Command{
+		Use:   "plugin",
+		Short: "Provides utilities for interacting with plugins",
+		Long: `Provides utilities for interacting with plugins.
+
+Plugins are executables on the PATH named kyaml-NAME, which are run as the command
+'kyaml NAME' if kyaml has no NAME command -- letting teams extend kyaml without forking it.
+The arguments following NAME are passed to the plugin, and the plugin is run with the
+stdin, stdout, stderr and environment of kyaml.  kyaml exits with the exit code of the plugin.
+
+Plugins may provide subcommands by including them in their names -- e.g. kyaml-foo-bar is
+run as 'kyaml foo bar'.  The longest name matching the arguments is used, and dashes in the
+arguments are matched by underscores in the names -- e.g. kyaml-foo_bar is run as
+'kyaml foo-bar'.
+
+Plugins may not override the kyaml commands.
+`,
+		Example: `# list the plugins on the PATH
+kyaml plugin list
+
+# run the kyaml-lint-rbac plugin with the args my-dir/
+kyaml lint-rbac my-dir/
+`,
+	}
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the plugins on the PATH",
+		Long: `List the plugins on the PATH.
+
+Prints the paths of the plugin executables in the order they are found on the PATH, with
+warnings for plugins which are never run because they are shadowed by a plugin with the same
+name earlier on the PATH, or overshadowed by a kyaml command.
+
+The plugins are printed as json with the global '--output json' flag.
+`,
+		Example: `# list the plugins on the PATH
+kyaml plugin list
+
+# list the plugins on the PATH as json
+kyaml plugin list --output json
+`,
+		RunE: r.runE,
+		Args: cobra.NoArgs,
+	}
+	SetOutputFormats(list, OutputJSON)
+	c.AddCommand(list)
+	r.Command = c
+	return r
+}
+
+func PluginCommand() *cobra.Command {
+	return GetPluginRunner().Command
+}
+
+// PluginRunner contains the run function
+type PluginRunner struct {
+	Command *cobra.Command
+}
+
+// pluginInfo is a plugin found on the PATH
+type pluginInfo struct {
+	Path string `json:"path"`
+	// ShadowedBy is the plugin with the same name earlier on the PATH, if any
+	ShadowedBy string `json:"shadowedBy,omitempty"`
+	// OvershadowedBy is the kyaml command with the same name, if any
+	OvershadowedBy string `json:"overshadowedBy,omitempty"`
+}
+
+func (r *PluginRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+	paths := findPlugins(c, os.Getenv("PATH"))
+	if len(paths) == 0 {
+		return handleError(c, fmt.Errorf("no plugins found on the PATH"))
+	}
+
+	var plugins []pluginInfo
+	found := map[string]string{}
+	for _, p := range paths {
+		plugin := pluginInfo{Path: p}
+		name := filepath.Base(p)
+		if found[name] != "" {
+			plugin.ShadowedBy = found[name]
+		} else if command := pluginCommand(c.Root(), name); command != nil {
+			plugin.OvershadowedBy = command.CommandPath()
+		}
+		if found[name] == "" {
+			found[name] = p
+		}
+		plugins = append(plugins, plugin)
+	}
+
+	if output == OutputJSON {
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		return handleError(c, e.Encode(plugins))
+	}
+	for _, p := range plugins {
+		switch {
+		case p.ShadowedBy != "":
+			fmt.Fprintf(c.OutOrStdout(), "%s  (shadowed by %s)\n", p.Path, p.ShadowedBy)
+		case p.OvershadowedBy != "":
+			fmt.Fprintf(c.OutOrStdout(), "%s  (overshadowed by the %s command)\n",
+				p.Path, p.OvershadowedBy)
+		default:
+			fmt.Fprintln(c.OutOrStdout(), p.Path)
+		}
+	}
+	return nil
+}
+
+// findPlugins returns the plugin executables in the directories of path, in order
+func findPlugins(c *cobra.Command, path string) []string {
+	var plugins []string
+	for _, dir := range filepath.SplitList(path) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			// skip directories which don't exist or can't be read, as the shell does
+			Logf(c, LogLevelDebug, "skipping %s: %v", dir, err)
+			continue
+		}
+		for _, f := range files {
+			if !strings.HasPrefix(f.Name(), PluginPrefix) || f.IsDir() ||
+				f.Mode()&0111 == 0 {
+				continue
+			}
+			plugins = append(plugins, filepath.Join(dir, f.Name()))
+		}
+	}
+	return plugins
+}
+
+// pluginCommand returns the command of root which the plugin named name would be run
+// as, or nil if root has no such command
+func pluginCommand(root *cobra.Command, name string) *cobra.Command {
+	var args []string
+	for _, part := range strings.Split(strings.TrimPrefix(name, PluginPrefix), "-") {
+		args = append(args, strings.Replace(part, "_", "-", -1))
+	}
+	c, rest, err := root.Find(args)
+	if err != nil || c == root || len(rest) > 0 {
+		return nil
+	}
+	return c
+}
+
+// RunPlugin runs the plugin executable for args -- e.g. kyaml-foo for the args
+// "foo bar" -- if the first of args isn't a command or flag of root.  The remaining args
+// are passed to the plugin, and it is run with the stdin, stdout and stderr of root.
+// Returns false if args are for a command of root or no plugin was found.
+//
+// If the plugin fails, the error is an *exec.ExitError with the exit code of the plugin.
+func RunPlugin(root *cobra.Command, args []string) (bool, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false, nil
+	}
+	if c, _, err := root.Find(args); err == nil && c != root {
+		return false, nil
+	}
+	if args[0] == "help" {
+		// added by cobra when root is executed
+		return false, nil
+	}
+
+	// use the longest name matching the args
+	var parts []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		parts = append(parts, strings.Replace(arg, "-", "_", -1))
+	}
+	for i := len(parts); i > 0; i-- {
+		path, err := exec.LookPath(PluginPrefix + strings.Join(parts[:i], "-"))
+		if err != nil {
+			continue
+		}
+		plugin := exec.Command(path, args[i:]...)
+		plugin.Stdin = root.InOrStdin()
+		plugin.Stdout = root.OutOrStdout()
+		plugin.Stderr = root.ErrOrStderr()
+		plugin.Env = os.Environ()
+		return true, plugin.Run()
+	}
+	return false, nil
+}
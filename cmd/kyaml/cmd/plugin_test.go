@@ -0,0 +1,166 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+// writePlugins writes the plugin scripts to a new directory, and sets the PATH to the
+// directory followed by paths.  Returns the directory and a func restoring the PATH.
+func writePlugins(t *testing.T, plugins map[string]string, paths ...string) (string, func()) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for name, script := range plugins {
+		if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, name),
+			[]byte("#!/bin/sh\n"+script+"\n"), 0700)) {
+			t.FailNow()
+		}
+	}
+	path := os.Getenv("PATH")
+	if !assert.NoError(t, os.Setenv("PATH", strings.Join(
+		append([]string{d}, paths...), string(filepath.ListSeparator)))) {
+		t.FailNow()
+	}
+	return d, func() {
+		_ = os.Setenv("PATH", path)
+		_ = os.RemoveAll(d)
+	}
+}
+
+func newPluginRoot() *cobra.Command {
+	root := &cobra.Command{Use: "kyaml"}
+	root.AddCommand(cmd.TreeCommand())
+	root.AddCommand(cmd.PluginCommand())
+	return root
+}
+
+func TestRunPlugin(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+	_, cleanup := writePlugins(t, map[string]string{
+		"kyaml-foo":     `echo "foo $@"; cat`,
+		"kyaml-foo-bar": `echo "foo-bar $@"`,
+		"kyaml-baz_qux": `echo "baz_qux $@"`,
+		"kyaml-fail":    `echo failed >&2; exit 3`,
+		"kyaml-tree":    `echo tree`,
+	}, os.Getenv("PATH"))
+	defer cleanup()
+
+	tests := []struct {
+		args   []string
+		found  bool
+		stdout string
+	}{
+		{args: []string{"foo", "a", "--b"}, found: true, stdout: "foo a --b\ninput\n"},
+		{args: []string{"foo", "bar", "a"}, found: true, stdout: "foo-bar a\n"},
+		{args: []string{"foo", "--bar", "a"}, found: true, stdout: "foo --bar a\ninput\n"},
+		{args: []string{"baz-qux"}, found: true, stdout: "baz_qux \n"},
+		// commands are never overridden
+		{args: []string{"tree", "a"}},
+		{args: []string{"help"}},
+		{args: []string{"--stack-trace", "foo"}},
+		{args: []string{"unknown"}},
+		{},
+	}
+	for _, test := range tests {
+		root := newPluginRoot()
+		out := &bytes.Buffer{}
+		root.SetIn(bytes.NewBufferString("input\n"))
+		root.SetOut(out)
+		found, err := cmd.RunPlugin(root, test.args)
+		if !assert.NoError(t, err, test.args) {
+			continue
+		}
+		assert.Equal(t, test.found, found, test.args)
+		assert.Equal(t, test.stdout, out.String(), test.args)
+	}
+
+	// the exit code of the plugin is returned
+	root := newPluginRoot()
+	errOut := &bytes.Buffer{}
+	root.SetErr(errOut)
+	found, err := cmd.RunPlugin(root, []string{"fail"})
+	assert.True(t, found)
+	if exitErr, ok := err.(*exec.ExitError); assert.True(t, ok, err) {
+		assert.Equal(t, 3, exitErr.ExitCode())
+	}
+	assert.Equal(t, "failed\n", errOut.String())
+}
+
+func TestPluginCommand_list(t *testing.T) {
+	other, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(other)
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(other, "kyaml-foo"), []byte("#!/bin/sh\n"), 0700)) {
+		t.FailNow()
+	}
+	// not executable
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(other, "kyaml-bar"), []byte("#!/bin/sh\n"), 0600)) {
+		t.FailNow()
+	}
+
+	d, cleanup := writePlugins(t, map[string]string{
+		"kyaml-foo":  ``,
+		"kyaml-tree": ``,
+		"kustomize":  ``,
+	}, other)
+	defer cleanup()
+
+	root := newPluginRoot()
+	b := &bytes.Buffer{}
+	root.SetOut(b)
+	root.SetArgs([]string{"plugin", "list"})
+	if !assert.NoError(t, root.Execute()) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, filepath.Join(d, "kyaml-foo")+"\n"+
+		filepath.Join(d, "kyaml-tree")+"  (overshadowed by the kyaml tree command)\n"+
+		filepath.Join(other, "kyaml-foo")+"  (shadowed by "+filepath.Join(d, "kyaml-foo")+")\n",
+		b.String())
+
+	// list the plugins as json
+	root = newPluginRoot()
+	cmd.AddGlobalFlags(root)
+	defer func() { cmd.Output = cmd.OutputText }()
+	b.Reset()
+	root.SetOut(b)
+	root.SetArgs([]string{"plugin", "list", "-o", "json"})
+	if !assert.NoError(t, root.Execute()) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, `[
+  {
+    "path": "`+filepath.Join(d, "kyaml-foo")+`"
+  },
+  {
+    "path": "`+filepath.Join(d, "kyaml-tree")+`",
+    "overshadowedBy": "kyaml tree"
+  },
+  {
+    "path": "`+filepath.Join(other, "kyaml-foo")+`",
+    "shadowedBy": "`+filepath.Join(d, "kyaml-foo")+`"
+  }
+]
+`, b.String())
+}
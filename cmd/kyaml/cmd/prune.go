@@ -0,0 +1,293 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/labels"
+)
+
+// PruneCommand returns the prune command and its subcommands.
+func PruneCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "prune",
+		Short: "Commands for pruning Resources",
+	}
+	c.AddCommand(PruneListCommand())
+	c.AddCommand(PrunePreviewCommand())
+	return c
+}
+
+// GetPrunePreviewRunner returns a command PrunePreviewRunner.
+func GetPrunePreviewRunner() *PrunePreviewRunner {
+	r := &PrunePreviewRunner{}
+	c := &cobra.Command{
+		Use:   "preview DIR",
+		Short: "List live Resources which would be pruned when applying a package",
+		Long: `List live Resources which would be pruned when applying a package.
+
+Live Resources are read from stdin (e.g. from kubectl get -o yaml).  Live Resources
+matching --selector are previously applied Resources, and are listed if they are no
+longer present in the package.
+
+Resources are matched by group, kind, namespace and name.  Resources in the package
+without a namespace match live Resources in any namespace.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# list the Resources which would be pruned
+kubectl get all -l app=nginx -o yaml | kyaml prune preview my-dir/ --selector app=nginx
+
+# list the Resources which would be pruned as json
+kubectl get all -l app=nginx -o yaml | kyaml prune preview my-dir/ --selector app=nginx --output json
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also include resources from subpackages.")
+	c.Flags().StringVarP(&r.Selector, "selector", "l", "",
+		"label selector identifying previously applied resources.")
+	SetOutputFormats(c, OutputJSON, OutputYAML)
+
+	r.Command = c
+	return r
+}
+
+func PrunePreviewCommand() *cobra.Command {
+	return GetPrunePreviewRunner().Command
+}
+
+// PrunePreviewRunner contains the run function
+type PrunePreviewRunner struct {
+	IncludeSubpackages bool
+	Selector           string
+	Command            *cobra.Command
+}
+
+// pruneResource identifies a Resource which would be pruned
+type pruneResource struct {
+	ApiVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+func (r *PrunePreviewRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+	selector, err := labels.Parse(r.Selector)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	pkg, err := kio.LocalPackageReader{
+		PackagePath:        args[0],
+		IncludeSubpackages: r.IncludeSubpackages,
+	}.Read()
+	if err != nil {
+		return handleError(c, err)
+	}
+	live, err := (&kio.ByteReader{Reader: c.InOrStdin()}).Read()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	// index the package Resources
+	inPackage := map[pruneResource]bool{}
+	for i := range pkg {
+		meta, err := pkg[i].GetMeta()
+		if err != nil {
+			return handleError(c, err)
+		}
+		inPackage[pruneKey(meta, meta.Namespace)] = true
+	}
+
+	pruned := []pruneResource{}
+	for i := range live {
+		meta, err := live[i].GetMeta()
+		if err != nil {
+			return handleError(c, err)
+		}
+		if !selector.Matches(labels.Set(meta.Labels)) {
+			continue
+		}
+		if inPackage[pruneKey(meta, meta.Namespace)] || inPackage[pruneKey(meta, "")] {
+			continue
+		}
+		pruned = append(pruned, pruneResource{
+			ApiVersion: meta.ApiVersion,
+			Kind:       meta.Kind,
+			Namespace:  meta.Namespace,
+			Name:       meta.Name,
+		})
+	}
+
+	return handleError(c, writePruneResources(c, output, pruned))
+}
+
+// GetPruneListRunner returns a command PruneListRunner.
+func GetPruneListRunner() *PruneListRunner {
+	r := &PruneListRunner{}
+	c := &cobra.Command{
+		Use:   "list DIR",
+		Short: "List Resources removed from a package since a previous build",
+		Long: `List Resources removed from a package since a previous build.
+
+The previous build is read from --previous, which may be a directory or a file, or
+from stdin if --previous is not set (e.g. from kustomize build).  Resources in the
+previous build which are no longer present in the package are listed, so that they may
+be deleted from the cluster.
+
+Resources are matched by group, kind, namespace and name.  Resources in the package
+without a namespace match previous Resources in any namespace.
+
+With --output yaml the Resources are written as Resource Config containing only their
+apiVersion, kind and metadata -- e.g. for kubectl delete -f -.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# list the Resources removed since the previous build
+kyaml prune list my-dir/ --previous previous-build.yaml
+
+# delete the Resources removed since the last commit
+git show HEAD~:build.yaml | kyaml prune list my-dir/ --output yaml | kubectl delete -f -
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also include resources from subpackages.")
+	c.Flags().StringVar(&r.Previous, "previous", "",
+		"path to a directory or file containing the previous build.  defaults to stdin.")
+	SetOutputFormats(c, OutputJSON, OutputYAML)
+
+	r.Command = c
+	return r
+}
+
+func PruneListCommand() *cobra.Command {
+	return GetPruneListRunner().Command
+}
+
+// PruneListRunner contains the run function
+type PruneListRunner struct {
+	IncludeSubpackages bool
+	Previous           string
+	Command            *cobra.Command
+}
+
+func (r *PruneListRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	pkg, err := kio.LocalPackageReader{
+		PackagePath:        args[0],
+		IncludeSubpackages: r.IncludeSubpackages,
+	}.Read()
+	if err != nil {
+		return handleError(c, err)
+	}
+	var previous []*yaml.RNode
+	if r.Previous == "" {
+		previous, err = (&kio.ByteReader{Reader: c.InOrStdin()}).Read()
+	} else {
+		previous, err = kio.LocalPackageReader{
+			PackagePath:        r.Previous,
+			IncludeSubpackages: r.IncludeSubpackages,
+		}.Read()
+	}
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	// index the package Resources
+	inPackage := map[pruneResource]bool{}
+	for i := range pkg {
+		meta, err := pkg[i].GetMeta()
+		if err != nil {
+			return handleError(c, err)
+		}
+		inPackage[pruneKey(meta, meta.Namespace)] = true
+	}
+
+	pruned := []pruneResource{}
+	listed := map[pruneResource]bool{}
+	for i := range previous {
+		meta, err := previous[i].GetMeta()
+		if err != nil {
+			return handleError(c, err)
+		}
+		key := pruneKey(meta, meta.Namespace)
+		if inPackage[key] || inPackage[pruneKey(meta, "")] || listed[key] {
+			continue
+		}
+		listed[key] = true
+		pruned = append(pruned, pruneResource{
+			ApiVersion: meta.ApiVersion,
+			Kind:       meta.Kind,
+			Namespace:  meta.Namespace,
+			Name:       meta.Name,
+		})
+	}
+	return handleError(c, writePruneResources(c, output, pruned))
+}
+
+// writePruneResources writes the Resources which would be pruned in the output format
+func writePruneResources(c *cobra.Command, output string, pruned []pruneResource) error {
+	switch output {
+	case OutputJSON:
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		return e.Encode(pruned)
+	case OutputYAML:
+		e := yaml.NewEncoder(c.OutOrStdout())
+		for _, p := range pruned {
+			err := e.Encode(yaml.ResourceMeta{
+				ApiVersion: p.ApiVersion,
+				Kind:       p.Kind,
+				ObjectMeta: yaml.ObjectMeta{Name: p.Name, Namespace: p.Namespace},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return e.Close()
+	}
+	for _, p := range pruned {
+		id := p.Name
+		if p.Namespace != "" {
+			id = p.Namespace + "/" + p.Name
+		}
+		fmt.Fprintf(c.OutOrStdout(), "%s %s %s\n", p.ApiVersion, p.Kind, id)
+	}
+	return nil
+}
+
+// pruneKey returns the key used to match package and live Resources.  The version is
+// dropped from the apiVersion since live Resources may be read at a different version.
+func pruneKey(meta yaml.ResourceMeta, namespace string) pruneResource {
+	group := ""
+	if i := strings.Index(meta.ApiVersion, "/"); i >= 0 {
+		group = meta.ApiVersion[:i]
+	}
+	return pruneResource{
+		ApiVersion: group,
+		Kind:       meta.Kind,
+		Namespace:  namespace,
+		Name:       meta.Name,
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const pruneLive = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: foo
+    namespace: default
+    labels:
+      app: nginx
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: old
+    namespace: default
+    labels:
+      app: nginx
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: other
+    namespace: default
+    labels:
+      app: other
+`
+
+func writePrunePackage(t *testing.T) string {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  labels:
+    app: nginx
+`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return d
+}
+
+func TestPrunePreviewCommand(t *testing.T) {
+	d := writePrunePackage(t)
+	defer os.RemoveAll(d)
+
+	b := &bytes.Buffer{}
+	r := cmd.GetPrunePreviewRunner()
+	r.Command.SetArgs([]string{d, "--selector", "app=nginx"})
+	r.Command.SetIn(bytes.NewBufferString(pruneLive))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "v1 Service default/old\n", b.String())
+}
+
+func TestPrunePreviewCommand_json(t *testing.T) {
+	d := writePrunePackage(t)
+	defer os.RemoveAll(d)
+
+	b := &bytes.Buffer{}
+	r := cmd.GetPrunePreviewRunner()
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{d, "--selector", "app", "--output", "json"})
+	r.Command.SetIn(bytes.NewBufferString(pruneLive))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `[
+  {
+    "apiVersion": "v1",
+    "kind": "Service",
+    "namespace": "default",
+    "name": "old"
+  },
+  {
+    "apiVersion": "v1",
+    "kind": "Service",
+    "namespace": "default",
+    "name": "other"
+  }
+]
+`, b.String())
+}
+
+const prunePrevious = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: old
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+func TestPruneListCommand(t *testing.T) {
+	d := writePrunePackage(t)
+	defer os.RemoveAll(d)
+
+	b := &bytes.Buffer{}
+	r := cmd.GetPruneListRunner()
+	r.Command.SetArgs([]string{d})
+	r.Command.SetIn(bytes.NewBufferString(prunePrevious))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "v1 Service default/old\nv1 ConfigMap config\n", b.String())
+}
+
+func TestPruneListCommand_yaml(t *testing.T) {
+	d := writePrunePackage(t)
+	defer os.RemoveAll(d)
+	previous, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(previous)
+	err = ioutil.WriteFile(filepath.Join(previous, "build.yaml"), []byte(prunePrevious), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b := &bytes.Buffer{}
+	r := cmd.GetPruneListRunner()
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{d, "--previous", previous, "--output", "yaml"})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: old
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`, b.String())
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetRedactRunner returns a command RedactRunner.
+func GetRedactRunner() *RedactRunner {
+	r := &RedactRunner{}
+	c := &cobra.Command{
+		Use:   "redact [DIR]...",
+		Short: "Replace Secret values in Resource Config",
+		Long: `Replace Secret values in Resource Config.
+
+redact replaces the values of Secrets, so that packages and Resources exported from a
+cluster can be shared -- e.g. attached to a bug report.  The following values are
+replaced:
+
+- the data and stringData values of Secrets
+- the kubectl.kubernetes.io/last-applied-configuration annotation of Secrets
+- the fields matching the --path patterns, in all Resources
+
+Values are replaced by --placeholder, or by their sha256 hash if --hash is set.  Hashes
+may be used to compare values without sharing them, but may be reversed for guessable
+values.  The replacements of Secret data values are base64 encoded.
+
+Path patterns are written as 'field.field', with list elements written as '[name=value]'
+if the element has a name, and as '[index]' otherwise.  Each segment is matched as a
+glob, and '**' matches any number of segments -- see 'kyaml search --help'.
+
+If DIR is specified, the Resources are updated in place and the redacted fields are
+printed.  Otherwise the Resources are read from stdin and written to stdout.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# redact Resources exported from a cluster
+kubectl get secrets,deployments -o yaml | kyaml redact
+
+# also redact the values of environment variables named *PASSWORD or *TOKEN
+kyaml redact my-dir/ --path '**.env[name=*PASSWORD].value' --path '**.env[name=*TOKEN].value'
+
+# replace the values with hashes, so equal values can be compared
+kubectl get secrets -o yaml | kyaml redact --hash
+`,
+		RunE: r.runE,
+	}
+	c.Flags().StringSliceVar(&r.Paths, "path", []string{},
+		"path pattern of additional fields to redact.  may be specified multiple times.")
+	c.Flags().StringVar(&r.Placeholder, "placeholder", filters.DefaultRedactPlaceholder,
+		"value to replace the values with.")
+	c.Flags().BoolVar(&r.Hash, "hash", false,
+		"replace the values with their sha256 hash rather than the placeholder.")
+	r.Command = c
+	return r
+}
+
+func RedactCommand() *cobra.Command {
+	return GetRedactRunner().Command
+}
+
+// RedactRunner contains the run function
+type RedactRunner struct {
+	Paths       []string
+	Placeholder string
+	Hash        bool
+	Command     *cobra.Command
+}
+
+func (r *RedactRunner) runE(c *cobra.Command, args []string) error {
+	f := &filters.RedactFilter{Paths: r.Paths, Placeholder: r.Placeholder, Hash: r.Hash}
+
+	// redact stdin if there are no args
+	if len(args) == 0 {
+		rw := &kio.ByteReadWriter{
+			Reader: c.InOrStdin(),
+			Writer: c.OutOrStdout(),
+		}
+		return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{rw}}).Execute())
+	}
+
+	for i := range args {
+		rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[i]}
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{rw}}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+		for _, m := range f.Redacted {
+			id := m.Name
+			if m.Namespace != "" {
+				id = m.Namespace + "/" + m.Name
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s %s: redacted %s\n",
+				m.File, m.Line, m.Kind, id, m.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestRedactCommand_stdin(t *testing.T) {
+	r := cmd.GetRedactRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(`apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+data:
+  password: YmFy
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  token: abc
+  user: bar
+`))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"--path", "data.token", "--placeholder", "xxx"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+data:
+  password: eHh4
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  token: xxx
+  user: bar
+`, b.String())
+}
+
+func TestRedactCommand_dir(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "secret.yaml"), []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: default
+stringData:
+  password: bar
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetRedactRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--hash"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `secret.yaml:7: Secret default/creds: redacted stringData.password
+`, b.String())
+
+	actual, err := ioutil.ReadFile(filepath.Join(d, "secret.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: default
+stringData:
+  password: sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9
+`, string(actual))
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetRenameRunner returns a command RenameRunner.
+func GetRenameRunner() *RenameRunner {
+	r := &RenameRunner{}
+	c := &cobra.Command{
+		Use:   "rename DIR KIND NAME NEW_NAME",
+		Short: "Rename a Resource and update the references to it",
+		Long: `Rename a Resource and update the references to it.
+
+Rename sets the name of the Resource, and updates the fields of the other Resources
+in the package which reference it by name:
+
+- ConfigMap: env valueFrom, envFrom and volumes of workloads
+- Secret: env valueFrom, envFrom, volumes and imagePullSecrets of workloads,
+  ServiceAccount secrets and Ingress tls
+- Service: Ingress backends and StatefulSet serviceName
+- ServiceAccount: serviceAccountName of workloads and RoleBinding subjects
+- PersistentVolumeClaim: volumes of workloads
+- Role, ClusterRole: RoleBinding roleRef
+
+References are only updated in Resources in the same namespace as the renamed
+Resource, unless the reference sets its own namespace.
+
+  DIR:
+    Path to local directory.
+
+  KIND:
+    Kind of the Resource to rename.
+
+  NAME:
+    Name of the Resource to rename.
+
+  NEW_NAME:
+    New name of the Resource.
+`,
+		Example: `# rename a ConfigMap in the default namespace
+kyaml rename my-dir/ ConfigMap nginx-config nginx-settings --namespace default
+
+# rename a Service without a namespace
+kyaml rename my-dir/ Service web frontend
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(4),
+	}
+	c.Flags().StringVar(&r.Namespace, "namespace", "",
+		"namespace of the Resource to rename.")
+	r.Command = c
+	return r
+}
+
+func RenameCommand() *cobra.Command {
+	return GetRenameRunner().Command
+}
+
+// RenameRunner contains the run function
+type RenameRunner struct {
+	Namespace string
+	Command   *cobra.Command
+}
+
+func (r *RenameRunner) runE(c *cobra.Command, args []string) error {
+	f := &filters.RenameFilter{
+		ResourceKind: args[1],
+		Namespace:    r.Namespace,
+		Name:         args[2],
+		NewName:      args[3],
+	}
+	rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[0]}
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{rw}}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	fmt.Fprintf(c.OutOrStdout(), "renamed %s %s to %s, updated %d references\n",
+		f.ResourceKind, f.Name, f.NewName, len(f.References))
+	for _, ref := range f.References {
+		id := ref.Name
+		if ref.Namespace != "" {
+			id = ref.Namespace + "/" + ref.Name
+		}
+		fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s %s: %s\n",
+			ref.File, ref.Line, ref.Kind, id, ref.Path)
+	}
+	return nil
+}
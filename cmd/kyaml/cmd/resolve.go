@@ -0,0 +1,491 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/copyutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetResolveRunner returns a command ResolveRunner.
+func GetResolveRunner() *ResolveRunner {
+	r := &ResolveRunner{}
+	c := &cobra.Command{
+		Use:   "resolve DIR DEST",
+		Short: "Write a kustomization and its bases to a self-contained package",
+		Long: `Write a kustomization and the kustomizations it references to a self-contained
+package, so that it may be built without network access or the rest of the repository
+-- e.g. for vendoring or air-gapped environments.
+
+DIR is copied to DEST.  The resources, bases and components of its kustomization file
+outside of DIR -- local paths such as ../base, and git repositories such as
+github.com/example/repo//base?ref=v1 -- are copied to DEST/vendor, and the
+kustomization is updated to reference the copies.  The files referenced outside of DIR
+-- patches, the files and envs of generators, crds, configurations and openapi schemas
+-- are copied the same way.  The kustomizations referenced by the copies are resolved
+the same way, and kustomizations referenced several times are copied once.
+
+Git repositories are fetched with git, which must be installed.
+
+  DIR:
+    Path to the directory containing the kustomization file.
+
+  DEST:
+    Path to the directory to write the package to.  Must not exist or be empty.
+`,
+		Example: `# write an overlay and its bases to a package
+kyaml resolve my-app/overlays/prod/ vendored-prod/
+
+# build the package
+kustomize build vendored-prod/
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(2),
+	}
+	r.Command = c
+	return r
+}
+
+func ResolveCommand() *cobra.Command {
+	return GetResolveRunner().Command
+}
+
+// ResolveRunner contains the run function
+type ResolveRunner struct {
+	Command *cobra.Command
+}
+
+func (r *ResolveRunner) runE(c *cobra.Command, args []string) error {
+	src, err := filepath.Abs(args[0])
+	if err != nil {
+		return handleError(c, err)
+	}
+	dest, err := filepath.Abs(args[1])
+	if err != nil {
+		return handleError(c, err)
+	}
+	if findKustomization(src) == "" {
+		return handleError(c, fmt.Errorf("no kustomization file found in %s", args[0]))
+	}
+	if rel, err := filepath.Rel(src, dest); err == nil && !strings.HasPrefix(rel, "..") {
+		return handleError(c, fmt.Errorf("DEST must not be inside DIR"))
+	}
+	if files, err := ioutil.ReadDir(dest); err == nil && len(files) > 0 {
+		return handleError(c, fmt.Errorf("%s is not empty", args[1]))
+	}
+
+	tmp, err := ioutil.TempDir("", "kyaml-resolve")
+	if err != nil {
+		return handleError(c, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	res := newResolver(dest, tmp, c.OutOrStdout())
+	if err := copyutil.CopyDir(src, dest); err != nil {
+		return handleError(c, err)
+	}
+	return handleError(c, res.resolve(src, dest, src))
+}
+
+// kustomizationReferenceFields are the fields of kustomizations referencing other
+// kustomizations or Resource files
+var kustomizationReferenceFields = []string{"resources", "bases", "components"}
+
+// resolver copies the kustomizations referenced by a kustomization into a package
+type resolver struct {
+	// dest is the package directory
+	dest string
+
+	// tmp is the directory git repositories are fetched to
+	tmp string
+
+	// vendored are the directories or files copied to the package, by their source
+	vendored map[string]string
+
+	// fetched are the directories git repositories were fetched to, by repository and ref
+	fetched map[string]string
+
+	// commits are the commits git repositories were fetched at, by repository and ref
+	commits map[string]string
+
+	// resolved are the source directories which have been resolved
+	resolved map[string]bool
+
+	out io.Writer
+}
+
+// newResolver returns a resolver writing the package to dest and fetching git
+// repositories beneath tmp
+func newResolver(dest, tmp string, out io.Writer) *resolver {
+	return &resolver{
+		dest:     dest,
+		tmp:      tmp,
+		vendored: map[string]string{},
+		fetched:  map[string]string{},
+		commits:  map[string]string{},
+		resolved: map[string]bool{},
+		out:      out,
+	}
+}
+
+// resolve updates the references of the kustomization in dir, which is copied to the
+// package from the directory srcDir beneath srcRoot.  References within srcRoot have
+// been copied with it, and references outside srcRoot are vendored.
+func (r *resolver) resolve(srcRoot, destRoot, srcDir string) error {
+	if r.resolved[srcDir] {
+		return nil
+	}
+	r.resolved[srcDir] = true
+
+	rel, err := filepath.Rel(srcRoot, srcDir)
+	if err != nil {
+		return err
+	}
+	destDir := filepath.Join(destRoot, rel)
+	path := findKustomization(destDir)
+	if path == "" {
+		// a directory of Resources rather than a kustomization
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	k, err := yaml.Parse(string(b))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	changed := false
+	for _, field := range kustomizationReferenceFields {
+		refs, err := k.Pipe(yaml.Lookup(field))
+		if err != nil {
+			return err
+		}
+		if refs == nil || refs.YNode().Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, elem := range refs.YNode().Content {
+			ref, err := r.reference(srcRoot, destRoot, srcDir, destDir, elem.Value)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %v", path, elem.Value, err)
+			}
+			if ref != elem.Value {
+				elem.Value = ref
+				changed = true
+			}
+		}
+	}
+	for _, f := range fileReferences(k) {
+		ref, err := r.file(srcRoot, srcDir, destDir, f.path)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %v", path, f.path, err)
+		}
+		if ref != f.path {
+			f.node.Value = f.prefix + filepath.ToSlash(ref)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	s, err := k.String()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(s), 0600)
+}
+
+// reference resolves the reference of the kustomization in srcDir, and returns the
+// reference to use from the copy of the kustomization in destDir.
+func (r *resolver) reference(srcRoot, destRoot, srcDir, destDir, ref string) (string, error) {
+	if isRemoteReference(srcDir, ref) {
+		local, err := r.fetch(ref)
+		if err != nil {
+			return "", err
+		}
+		return r.vendor(ref, local, destDir)
+	}
+
+	path := filepath.Join(srcDir, ref)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(srcRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		// copied with the root, but may reference kustomizations outside of it
+		if info.IsDir() {
+			if err := r.resolve(srcRoot, destRoot, path); err != nil {
+				return "", err
+			}
+		}
+		return ref, nil
+	}
+	return r.vendor(path, path, destDir)
+}
+
+// file resolves the file referenced by the kustomization in srcDir, and returns the
+// reference to use from the copy of the kustomization in destDir.
+func (r *resolver) file(srcRoot, srcDir, destDir, ref string) (string, error) {
+	path := filepath.Join(srcDir, ref)
+	if rel, err := filepath.Rel(srcRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		// copied with the root
+		return ref, nil
+	}
+	return r.vendor(path, path, destDir)
+}
+
+// fileReference is a reference of a kustomization to a file.  The value of node is the
+// path prefixed by prefix -- e.g. the key of a generator file.
+type fileReference struct {
+	node   *yaml.Node
+	prefix string
+	path   string
+}
+
+// fileReferences returns the references of the kustomization k to files
+func fileReferences(k *yaml.RNode) []fileReference {
+	var refs []fileReference
+	add := func(node *yaml.Node) {
+		// inline patches aren't references
+		if node == nil || node.Kind != yaml.ScalarNode || node.Value == "" ||
+			strings.Contains(node.Value, "\n") {
+			return
+		}
+		ref := fileReference{node: node, path: node.Value}
+		if i := strings.Index(node.Value, "="); i >= 0 {
+			ref.prefix, ref.path = node.Value[:i+1], node.Value[i+1:]
+		}
+		refs = append(refs, ref)
+	}
+	elements := func(node *yaml.RNode, field string) []*yaml.Node {
+		f := node.Field(field)
+		if f == nil || f.Value.YNode().Kind != yaml.SequenceNode {
+			return nil
+		}
+		return f.Value.YNode().Content
+	}
+	field := func(node *yaml.Node, field string) *yaml.Node {
+		if f := yaml.NewRNode(node).Field(field); f != nil {
+			return f.Value.YNode()
+		}
+		return nil
+	}
+
+	for _, name := range []string{"patchesStrategicMerge", "crds", "configurations"} {
+		for _, elem := range elements(k, name) {
+			add(elem)
+		}
+	}
+	for _, name := range []string{"patches", "patchesJson6902"} {
+		for _, elem := range elements(k, name) {
+			if elem.Kind == yaml.MappingNode {
+				add(field(elem, "path"))
+			}
+		}
+	}
+	for _, name := range []string{"configMapGenerator", "secretGenerator"} {
+		for _, elem := range elements(k, name) {
+			if elem.Kind != yaml.MappingNode {
+				continue
+			}
+			for _, list := range []string{"files", "envs"} {
+				for _, f := range elements(yaml.NewRNode(elem), list) {
+					add(f)
+				}
+			}
+			add(field(elem, "env"))
+		}
+	}
+	if openapi := k.Field("openapi"); openapi != nil && openapi.Value.YNode().Kind == yaml.MappingNode {
+		add(field(openapi.Value.YNode(), "path"))
+	}
+	return refs
+}
+
+// vendor copies the local directory or file for source to the package, if it has not
+// already been copied, and returns its path relative to destDir.
+func (r *resolver) vendor(source, local string, destDir string) (string, error) {
+	target, found := r.vendored[source]
+	if !found {
+		info, err := os.Stat(local)
+		if err != nil {
+			return "", err
+		}
+		target = r.vendorPath(local, info.IsDir())
+		r.vendored[source] = target
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			err = copyutil.CopyDir(local, target)
+		} else {
+			var b []byte
+			if b, err = ioutil.ReadFile(local); err == nil {
+				err = ioutil.WriteFile(target, b, info.Mode())
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(r.dest, target)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(r.out, "vendored %s to %s\n", source, rel)
+
+		if info.IsDir() {
+			// the vendored kustomization is the root of its copy
+			if err := r.resolve(local, target, local); err != nil {
+				return "", err
+			}
+		}
+	}
+	return filepath.Rel(destDir, target)
+}
+
+// vendorPath returns an unused path beneath the vendor directory of the package, named
+// after local.  Files keep their name in a directory of their own if the name is already
+// used, since generators derive the keys of their data from the file names.
+func (r *resolver) vendorPath(local string, isDir bool) string {
+	name := filepath.Base(local)
+	ext := filepath.Ext(name)
+	for i := 1; ; i++ {
+		path := filepath.Join(r.dest, "vendor", name)
+		if i > 1 {
+			unique := strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(i)
+			if isDir {
+				path = filepath.Join(r.dest, "vendor", unique+ext)
+			} else {
+				path = filepath.Join(r.dest, "vendor", unique, name)
+				if _, err := os.Stat(filepath.Dir(path)); err == nil {
+					continue
+				}
+			}
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+	}
+}
+
+// fetch fetches the git repository of the remote reference, if it has not already been
+// fetched, and returns the local path of the referenced directory.
+func (r *resolver) fetch(ref string) (string, error) {
+	repo, path, version := parseRemoteReference(ref)
+	if version == "" {
+		version = "HEAD"
+	}
+	// don't let the reference pass options to git -- e.g. ?ref=--upload-pack=...
+	if strings.HasPrefix(repo, "-") || strings.HasPrefix(version, "-") {
+		return "", fmt.Errorf("invalid remote reference %s: must not start with '-'", ref)
+	}
+	key := repo + "@" + version
+	dir, found := r.fetched[key]
+	if !found {
+		dir = filepath.Join(r.tmp, strconv.Itoa(len(r.fetched)))
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+		for _, args := range [][]string{
+			{"init", "--quiet"},
+			{"remote", "add", "origin", "--", repo},
+			{"fetch", "--quiet", "--depth=1", "--", "origin", version},
+			{"checkout", "--quiet", "FETCH_HEAD"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return "", fmt.Errorf("git %s: %v: %s",
+					strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+			}
+		}
+		cmd := exec.Command("git", "rev-parse", "HEAD")
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git rev-parse HEAD: %v", err)
+		}
+		r.commits[key] = strings.TrimSpace(string(out))
+		r.fetched[key] = dir
+	}
+	return filepath.Join(dir, path), nil
+}
+
+// remoteHosts are the hosts of git repositories which may be referenced without a scheme
+var remoteHosts = []string{"github.com/", "gitlab.com/", "bitbucket.org/"}
+
+// isRemoteReference returns true if the reference of the kustomization in dir is a git
+// repository rather than a local path.
+func isRemoteReference(dir, ref string) bool {
+	if strings.Contains(ref, "://") || strings.HasPrefix(ref, "git@") {
+		return true
+	}
+	if _, err := os.Stat(filepath.Join(dir, ref)); err == nil {
+		return false
+	}
+	for _, host := range remoteHosts {
+		if strings.HasPrefix(ref, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRemoteReference returns the repository, path within the repository and ref of a
+// remote reference -- e.g. github.com/example/repo//base?ref=v1.  The path may be
+// separated from the repository with '//', otherwise the repository is the host and the
+// first two path segments.
+func parseRemoteReference(ref string) (repo, path, version string) {
+	if i := strings.Index(ref, "?"); i >= 0 {
+		q, _ := url.ParseQuery(ref[i+1:])
+		version = q.Get("ref")
+		ref = ref[:i]
+	}
+	scheme := ""
+	if i := strings.Index(ref, "://"); i >= 0 {
+		scheme, ref = ref[:i+3], ref[i+3:]
+	}
+	if i := strings.Index(ref, "//"); i >= 0 {
+		repo, path = ref[:i], ref[i+2:]
+	} else {
+		n := 3
+		if strings.HasPrefix(ref, "git@") {
+			// git@host:org/repo
+			n = 2
+		}
+		parts := strings.SplitN(ref, "/", n+1)
+		if len(parts) > n {
+			repo, path = strings.Join(parts[:n], "/"), parts[n]
+		} else {
+			repo = ref
+		}
+	}
+	if scheme == "" && !strings.HasPrefix(repo, "git@") {
+		scheme = "https://"
+	}
+	return scheme + repo, path, version
+}
+
+// findKustomization returns the path of the kustomization file in dir, or "" if there is
+// none.
+func findKustomization(dir string) string {
+	for _, name := range kustomizationFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+// writeFiles writes the files to dir, creating their directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700)) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600)) {
+			t.FailNow()
+		}
+	}
+}
+
+// assertFile asserts the content of the file at path
+func assertFile(t *testing.T, path, expected string) {
+	b, err := ioutil.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, string(b), path)
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"app/base/kustomization.yaml": `resources:
+- deployment.yaml
+- ../../common
+`,
+		"app/base/deployment.yaml": "kind: Deployment\n",
+		"common/kustomization.yaml": `resources:
+- namespace.yaml
+`,
+		"common/namespace.yaml": "kind: Namespace\n",
+		"app/overlays/prod/kustomization.yaml": `# production
+resources:
+- ../../base
+- ../../../common
+- service.yaml
+namePrefix: prod-
+`,
+		"app/overlays/prod/service.yaml": "kind: Service\n",
+	})
+
+	dest := filepath.Join(d, "out")
+	b := &bytes.Buffer{}
+	r := cmd.GetResolveRunner()
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{filepath.Join(d, "app", "overlays", "prod"), dest})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `vendored `+filepath.Join(d, "app", "base")+` to vendor/base
+vendored `+filepath.Join(d, "common")+` to vendor/common
+`, b.String())
+
+	assertFile(t, filepath.Join(dest, "kustomization.yaml"), `# production
+resources:
+- vendor/base
+- vendor/common
+- service.yaml
+namePrefix: prod-
+`)
+	assertFile(t, filepath.Join(dest, "service.yaml"), "kind: Service\n")
+	assertFile(t, filepath.Join(dest, "vendor", "base", "kustomization.yaml"), `resources:
+- deployment.yaml
+- ../common
+`)
+	assertFile(t, filepath.Join(dest, "vendor", "base", "deployment.yaml"), "kind: Deployment\n")
+	assertFile(t, filepath.Join(dest, "vendor", "common", "namespace.yaml"), "kind: Namespace\n")
+}
+
+func TestResolveCommand_remote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	// create a repository with a tagged base
+	repo := filepath.Join(d, "repo")
+	writeFiles(t, repo, map[string]string{
+		"base/kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"base/deployment.yaml":    "kind: Deployment\n",
+	})
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com",
+			"commit", "--quiet", "-m", "base"},
+		{"tag", "v1"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = repo
+		out, err := c.CombinedOutput()
+		if !assert.NoError(t, err, string(out)) {
+			return
+		}
+	}
+
+	writeFiles(t, d, map[string]string{
+		"app/kustomization.yaml": "resources:\n- file://" + repo + "//base?ref=v1\n",
+	})
+	dest := filepath.Join(d, "out")
+	r := cmd.GetResolveRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{filepath.Join(d, "app"), dest})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assertFile(t, filepath.Join(dest, "kustomization.yaml"), "resources:\n- vendor/base\n")
+	assertFile(t, filepath.Join(dest, "vendor", "base", "deployment.yaml"), "kind: Deployment\n")
+	_, err = os.Stat(filepath.Join(dest, "vendor", "base", ".git"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestResolveCommand_optionRef(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	pwned := filepath.Join(d, "pwned")
+	writeFiles(t, d, map[string]string{
+		"app/kustomization.yaml": "resources:\n- file://" + d + "/repo//base?ref=" +
+			"--upload-pack=touch%20" + pwned + "%20%26%26%20git-upload-pack\n",
+	})
+
+	r := cmd.GetResolveRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SilenceUsage, r.Command.SilenceErrors = true, true
+	r.Command.SetArgs([]string{filepath.Join(d, "app"), filepath.Join(d, "out")})
+	err = r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "must not start with '-'")
+	}
+	_, err = os.Stat(pwned)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestResolveCommand_sameFileName(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"app/kustomization.yaml": `configMapGenerator:
+- name: config
+  files:
+  - ../a/x.txt
+  - ../b/x.txt
+`,
+		"a/x.txt": "a\n",
+		"b/x.txt": "b\n",
+	})
+
+	dest := filepath.Join(d, "out")
+	r := cmd.GetResolveRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{filepath.Join(d, "app"), dest})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	// the files keep their names, which are the keys of the ConfigMap data
+	assertFile(t, filepath.Join(dest, "kustomization.yaml"), `configMapGenerator:
+- name: config
+  files:
+  - vendor/x.txt
+  - vendor/x-2/x.txt
+`)
+	assertFile(t, filepath.Join(dest, "vendor", "x.txt"), "a\n")
+	assertFile(t, filepath.Join(dest, "vendor", "x-2", "x.txt"), "b\n")
+}
+
+func TestResolveCommand_notEmpty(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"app/kustomization.yaml": "resources: []\n",
+		"out/f.yaml":             "kind: Service\n",
+	})
+	r := cmd.GetResolveRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetErr(&bytes.Buffer{})
+	r.Command.SetArgs([]string{filepath.Join(d, "app"), filepath.Join(d, "out")})
+	assert.EqualError(t, r.Command.Execute(), filepath.Join(d, "out")+" is not empty")
+}
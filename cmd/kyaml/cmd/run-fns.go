@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/runfn"
 )
 
@@ -71,6 +72,10 @@ kyaml run-fns example/
 	r.Command.Flags().StringSliceVar(
 		&r.FnPaths, "fn-path", []string{},
 		"directories containing functions without configuration")
+	r.Command.Flags().BoolVar(&r.RequireSigned, "require-signed", false,
+		"verify the signature of the package before running its functions -- see kyaml verify-signatures.")
+	r.Command.Flags().StringSliceVar(&r.SignatureKeys, "key", []string{},
+		"files containing the public keys trusted to sign the package, for --require-signed.")
 	r.Command.AddCommand(XArgsCommand())
 	r.Command.AddCommand(WrapCommand())
 	return r
@@ -86,10 +91,19 @@ type RunFnRunner struct {
 	Command            *cobra.Command
 	DryRun             bool
 	FnPaths            []string
+	RequireSigned      bool
+	SignatureKeys      []string
 }
 
 func (r *RunFnRunner) runE(c *cobra.Command, args []string) error {
-	rec := runfn.RunFns{Path: args[0], FunctionPaths: r.FnPaths}
+	if r.RequireSigned {
+		if err := requireSigned(args, r.SignatureKeys); err != nil {
+			return handleError(c, err)
+		}
+	}
+	p := ConfigurePipeline(c, kio.Pipeline{})
+	rec := runfn.RunFns{Path: args[0], FunctionPaths: r.FnPaths,
+		Limits: p.Limits, Timings: p.Timings, Warnings: p.Warnings}
 	if r.DryRun {
 		rec.Output = c.OutOrStdout()
 	}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/runfn"
+)
+
+// GetRunRunner returns a command RunRunner.
+func GetRunRunner() *RunRunner {
+	r := &RunRunner{}
+	c := &cobra.Command{
+		Use:   "run DIR",
+		Short: "Run config functions against the Resources in a directory",
+		Long: `Run config functions against the Resources in a directory.
+
+run discovers the functions configured in DIR, and invokes each of them with a
+ResourceList containing the Resources in DIR and the function config.  The
+Resources output by the last function are written back to DIR.
+
+Functions are configured by Resources annotated with config.kubernetes.io/function.
+The annotation specifies whether the function is run as a container or as a local
+executable.
+
+  # run as a container
+  apiVersion: example.com/v1
+  kind: ExampleFunction
+  metadata:
+    annotations:
+      config.kubernetes.io/function: |
+        container:
+          image: gcr.io/example/examplefunction:v1.0.1
+  spec:
+    configField: configValue
+
+  # run as a local executable -- the path is relative to the function config
+  apiVersion: example.com/v1
+  kind: ExampleFunction
+  metadata:
+    annotations:
+      config.kubernetes.io/function: |
+        exec:
+          path: ./bin/examplefunction
+
+Containers are run without network access, with DIR mounted read-only.  Local
+executables are not sandboxed, and are only run if --enable-exec is set.
+
+Resources configuring functions through metadata.configFn (see run-fns) are also run.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# run the functions in a directory, writing the results back to the directory
+kyaml run my-dir/
+
+# print the results rather than writing them back
+kyaml run my-dir/ --dry-run
+
+# run functions configured in another directory
+kyaml run my-dir/ --fn-path fns/ --enable-exec
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(1),
+	}
+	c.Flags().BoolVar(&r.DryRun, "dry-run", false,
+		"print the results to stdout rather than writing them back to DIR.")
+	c.Flags().StringSliceVar(&r.FnPaths, "fn-path", []string{},
+		"directories containing additional function configs.")
+	c.Flags().BoolVar(&r.EnableExec, "enable-exec", false,
+		"allow functions to be run as local executables.")
+	r.Command = c
+	return r
+}
+
+func RunCommand() *cobra.Command {
+	return GetRunRunner().Command
+}
+
+// RunRunner contains the run function
+type RunRunner struct {
+	DryRun     bool
+	FnPaths    []string
+	EnableExec bool
+	Command    *cobra.Command
+}
+
+func (r *RunRunner) runE(c *cobra.Command, args []string) error {
+	p := ConfigurePipeline(c, kio.Pipeline{})
+	rec := runfn.RunFns{Path: args[0], FunctionPaths: r.FnPaths, EnableExec: r.EnableExec,
+		Limits: p.Limits, Timings: p.Timings, Warnings: p.Warnings}
+	if r.DryRun {
+		rec.Output = c.OutOrStdout()
+	}
+	return handleError(c, rec.Execute())
+}
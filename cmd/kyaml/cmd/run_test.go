@@ -0,0 +1,109 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func writeRunPackage(t *testing.T) string {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 1
+`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "fn.yaml"), []byte(`apiVersion: example.com/v1
+kind: Scaler
+metadata:
+  name: scaler
+  annotations:
+    config.kubernetes.io/local-config: "true"
+    config.kubernetes.io/function: |
+      exec:
+        path: ./scale.sh
+`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "scale.sh"), []byte(`#!/bin/sh
+sed "s/replicas: 1/replicas: 3/"
+`), 0700)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return d
+}
+
+func TestRunCommand(t *testing.T) {
+	d := writeRunPackage(t)
+	defer os.RemoveAll(d)
+
+	r := cmd.GetRunRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{d, "--enable-exec"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(d, "f1.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 3
+`, string(b))
+}
+
+func TestRunCommand_dryRun(t *testing.T) {
+	d := writeRunPackage(t)
+	defer os.RemoveAll(d)
+
+	r := cmd.GetRunRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--enable-exec", "--dry-run"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Contains(t, b.String(), "replicas: 3")
+
+	f, err := ioutil.ReadFile(filepath.Join(d, "f1.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(f), "replicas: 1")
+}
+
+func TestRunCommand_execDisabled(t *testing.T) {
+	d := writeRunPackage(t)
+	defer os.RemoveAll(d)
+
+	r := cmd.GetRunRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{d})
+	assert.EqualError(t, r.Command.Execute(),
+		"Scaler scaler is run as a local executable, which requires enabling exec")
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetSearchRunner returns a command SearchRunner.
+func GetSearchRunner() *SearchRunner {
+	r := &SearchRunner{}
+	c := &cobra.Command{
+		Use:   "search [DIR]...",
+		Short: "Search for fields matching a path pattern and value in Resource Config",
+		Long: `Search for fields matching a path pattern and value in Resource Config.
+
+Search prints the file, line, Resource, path and value of each scalar field matching
+--path and --value.  At least one of --path or --value must be set.
+
+  --path:
+    Pattern the field path must match.  Paths are written as 'field.field', with
+    list elements written as '[name=value]' if the element has a name, and as
+    '[index]' otherwise.  '.' as part of a field name is escaped as '\.'.
+    Each segment of the pattern is matched as a glob against a segment of the path,
+    and '**' matches any number of segments.
+
+  --value:
+    Regular expression the field value must match.
+
+  DIR:
+    Path to local directory.  Reads from stdin if unset.
+`,
+		Example: `# find the images of all containers
+kyaml search my-dir/ --path "**.containers[*].image"
+
+# find images pulled from docker.io
+kyaml search my-dir/ --path "**.image" --value "^docker\.io/"
+
+# find every field referencing a hostname
+kyaml search my-dir/ --value "example\.com"
+
+# print the matches as json
+kyaml search my-dir/ --path "**.containers[name=nginx].image" --output json
+`,
+		RunE: r.runE,
+	}
+	c.Flags().StringVar(&r.Path, "path", "",
+		"pattern the field path must match.")
+	c.Flags().StringVar(&r.Value, "value", "",
+		"regular expression the field value must match.")
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also search resources from subpackages.")
+	SetOutputFormats(c, OutputJSON)
+	r.Command = c
+	return r
+}
+
+func SearchCommand() *cobra.Command {
+	return GetSearchRunner().Command
+}
+
+// SearchRunner contains the run function
+type SearchRunner struct {
+	Path               string
+	Value              string
+	IncludeSubpackages bool
+	Command            *cobra.Command
+}
+
+func (r *SearchRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+	if r.Path == "" && r.Value == "" {
+		return handleError(c, fmt.Errorf("must specify at least one of --path or --value"))
+	}
+
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	f := &filters.SearchFilter{Path: r.Path, Value: r.Value}
+	if err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs, Filters: []kio.Filter{f}}).Execute(); err != nil {
+		return handleError(c, err)
+	}
+
+	if output == OutputJSON {
+		matches := f.Matches
+		if matches == nil {
+			matches = []filters.SearchMatch{}
+		}
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		return handleError(c, e.Encode(matches))
+	}
+
+	for _, m := range f.Matches {
+		file := m.File
+		if file == "" {
+			file = "stdin"
+		}
+		id := m.Name
+		if m.Namespace != "" {
+			id = m.Namespace + "/" + m.Name
+		}
+		fmt.Fprintf(c.OutOrStdout(), "%s:%d: %s %s: %s: %s\n",
+			file, m.Line, m.Kind, id, m.Path, m.Value)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const searchInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: docker.io/nginx:1.7.9
+      - name: sidecar
+        image: gcr.io/sidecar:1.0
+`
+
+func TestSearchCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(searchInput), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetSearchRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--path", "**.image", "--value", `^docker\.io/`})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t,
+		"f1.yaml:11: Deployment default/nginx: spec.template.spec.containers[name=nginx].image: "+
+			"docker.io/nginx:1.7.9\n", b.String())
+}
+
+func TestSearchCommand_json(t *testing.T) {
+	r := cmd.GetSearchRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(searchInput))
+	r.Command.SetOut(b)
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{"--path", "spec.template.spec.containers[name=sidecar].image", "-o", "json"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `[
+  {
+    "apiVersion": "apps/v1",
+    "kind": "Deployment",
+    "namespace": "default",
+    "name": "nginx",
+    "line": 13,
+    "path": "spec.template.spec.containers[name=sidecar].image",
+    "value": "gcr.io/sidecar:1.0"
+  }
+]
+`, b.String())
+}
+
+func TestSearchCommand_noQuery(t *testing.T) {
+	r := cmd.GetSearchRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetIn(strings.NewReader(searchInput))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{})
+	assert.EqualError(t, r.Command.Execute(), "must specify at least one of --path or --value")
+}
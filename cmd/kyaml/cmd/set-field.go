@@ -0,0 +1,175 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/labels"
+)
+
+// GetSetFieldRunner returns a command SetFieldRunner.
+func GetSetFieldRunner() *SetFieldRunner {
+	r := &SetFieldRunner{}
+	c := &cobra.Command{
+		Use:   "set-field DIR [PATH=VALUE]...",
+		Short: "Set or delete fields of the Resources of a package",
+		Long: `Set or delete arbitrary fields of the Resources of a local package, and write them
+back in place.
+
+The fields are set on the Resources matching all of the target flags, or on all
+Resources if no target flags are set.  Missing parent fields are created.
+
+The type of VALUE is inferred as it would be read from yaml -- e.g. 3 is an int, true is
+a bool and nginx is a string -- unless --string is set.  VALUE may also be a flow style
+list or map -- e.g. '[a, b]'.  The comments of the fields are retained.
+
+  DIR:
+    Path to local directory.
+
+  PATH:
+    Path to the field.  Maps and fields are matched as '.field-name' or '.map-key', and
+    list elements are matched as '[list-elem-field=field-value]'.  '.' as part of a key
+    may be escaped as '\.'.
+
+  VALUE:
+    Value to set the field to.
+`,
+		Example: `# set the revisionHistoryLimit of the Deployments
+kyaml set-field my-dir/ --kind Deployment spec.revisionHistoryLimit=3
+
+# set the image of a container
+kyaml set-field my-dir/ --kind Deployment --name nginx \
+  'spec.template.spec.containers[name=nginx].image=nginx:1.17'
+
+# set an annotation which would be read as a number as a string
+kyaml set-field my-dir/ --string metadata.annotations.revision=3
+
+# delete a field and a list element
+kyaml set-field my-dir/ --kind Deployment --delete spec.paused \
+  --delete 'spec.template.spec.containers[name=sidecar]'
+`,
+		RunE: r.runE,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.Flags().StringSliceVar(&r.Delete, "delete", []string{},
+		"path of a field to delete.  may be specified multiple times.")
+	c.Flags().BoolVar(&r.String, "string", false,
+		"set the values as strings rather than inferring their types.")
+	c.Flags().StringVar(&r.Target.Group, "group", "",
+		"modify Resources in this API group.")
+	c.Flags().StringVar(&r.Target.Version, "version", "",
+		"modify Resources with this API version.")
+	c.Flags().StringVar(&r.Target.Kind, "kind", "",
+		"modify Resources of this kind.")
+	c.Flags().StringVar(&r.Target.Name, "name", "",
+		"modify Resources with names matching this glob.")
+	c.Flags().StringVar(&r.Target.Namespace, "namespace", "",
+		"modify Resources with namespaces matching this glob.")
+	c.Flags().StringVarP(&r.Selector, "selector", "l", "",
+		"modify Resources with these labels -- e.g. 'app=nginx,tier=web'.")
+	r.Command = c
+	return r
+}
+
+func SetFieldCommand() *cobra.Command {
+	return GetSetFieldRunner().Command
+}
+
+// SetFieldRunner contains the run function
+type SetFieldRunner struct {
+	Delete   []string
+	String   bool
+	Target   filters.PatchTarget
+	Selector string
+	Command  *cobra.Command
+}
+
+func (r *SetFieldRunner) runE(c *cobra.Command, args []string) error {
+	if len(args) == 1 && len(r.Delete) == 0 {
+		return handleError(c, fmt.Errorf("must specify a PATH=VALUE or --delete"))
+	}
+	if r.Selector != "" {
+		l, err := labels.ConvertSelectorToLabelsMap(r.Selector)
+		if err != nil {
+			return handleError(c, err)
+		}
+		r.Target.Labels = l
+	}
+
+	var fltrs []*filters.SetFieldFilter
+	for _, a := range args[1:] {
+		path, value, err := splitFieldAssignment(a)
+		if err != nil {
+			return handleError(c, err)
+		}
+		f := &filters.SetFieldFilter{Target: r.Target, Path: path, Value: value}
+		if r.String {
+			f.Tag = "!!str"
+		}
+		fltrs = append(fltrs, f)
+	}
+	for _, d := range r.Delete {
+		path, err := parseFieldPath(d)
+		if err != nil {
+			return handleError(c, err)
+		}
+		fltrs = append(fltrs, &filters.SetFieldFilter{Target: r.Target, Path: path, Delete: true})
+	}
+
+	var kf []kio.Filter
+	for _, f := range fltrs {
+		kf = append(kf, f)
+	}
+	rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[0]}
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{rw}, Filters: kf, Outputs: []kio.Writer{rw}}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	// print each modified Resource once, in the order it was first modified
+	var modified []string
+	seen := map[string]bool{}
+	for _, f := range fltrs {
+		for _, meta := range f.Modified {
+			id := meta.Kind + " " + meta.Name
+			if meta.Namespace != "" {
+				id = meta.Kind + " " + meta.Namespace + "/" + meta.Name
+			}
+			if !seen[id] {
+				seen[id] = true
+				modified = append(modified, id)
+			}
+		}
+	}
+	fmt.Fprintf(c.OutOrStdout(), "modified %d resources\n", len(modified))
+	for _, id := range modified {
+		fmt.Fprintln(c.OutOrStdout(), id)
+	}
+	return nil
+}
+
+// splitFieldAssignment splits PATH=VALUE into the field path and value.  The '=' of list
+// elements in PATH are not split on.
+func splitFieldAssignment(s string) ([]string, string, error) {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '=':
+			if depth == 0 {
+				path, err := parseFieldPath(s[:i])
+				return path, s[i+1:], err
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("expected PATH=VALUE, got '%s'", s)
+}
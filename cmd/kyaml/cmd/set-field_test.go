@@ -0,0 +1,146 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestSetFieldCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"app.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  paused: true
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.16 # pinned
+      - name: sidecar
+        image: sidecar
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`})
+
+	r := cmd.GetSetFieldRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--kind", "Deployment",
+		"spec.revisionHistoryLimit=3",
+		"spec.template.spec.containers[name=nginx].image=nginx:1.17",
+		"--delete", "spec.paused",
+		"--delete", "spec.template.spec.containers[name=sidecar]"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "modified 1 resources\nDeployment nginx\n", b.String())
+	assertFile(t, filepath.Join(d, "app.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.17 # pinned
+  revisionHistoryLimit: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`)
+}
+
+func TestSetFieldCommand_kustomization(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	kustomization := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- service.yaml
+`
+	writeFiles(t, d, map[string]string{
+		"kustomization.yaml": kustomization,
+		"service.yaml":       "apiVersion: v1\nkind: Service\nmetadata:\n  name: nginx\n",
+	})
+
+	r := cmd.GetSetFieldRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "metadata.annotations.owner=web"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "modified 1 resources\nService nginx\n", b.String())
+	assertFile(t, filepath.Join(d, "service.yaml"), `apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  annotations:
+    owner: web
+`)
+	assertFile(t, filepath.Join(d, "kustomization.yaml"), kustomization)
+}
+
+func TestSetFieldCommand_string(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{"service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`})
+
+	r := cmd.GetSetFieldRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "--string", `metadata.annotations.example\.com/revision=3`})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "modified 1 resources\nService nginx\n", b.String())
+	assertFile(t, filepath.Join(d, "service.yaml"), `apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  annotations:
+    example.com/revision: "3"
+`)
+}
+
+func TestSetFieldCommand_noFields(t *testing.T) {
+	r := cmd.GetSetFieldRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetArgs([]string{"dir", "spec.replicas"})
+	err := r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Equal(t, "expected PATH=VALUE, got 'spec.replicas'", err.Error())
+	}
+}
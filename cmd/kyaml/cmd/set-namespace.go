@@ -0,0 +1,93 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetSetNamespaceRunner returns a command SetNamespaceRunner.
+func GetSetNamespaceRunner() *SetNamespaceRunner {
+	r := &SetNamespaceRunner{}
+	c := &cobra.Command{
+		Use:   "namespace DIR NAMESPACE",
+		Short: "Set the namespace of the Resources in a package",
+		Long: `Set the namespace of the Resources in a package.
+
+metadata.namespace is set on every namespaced Resource.  Resources of cluster-scoped
+kinds -- e.g. Namespaces, ClusterRoles and CustomResourceDefinitions -- are not
+modified.  The scope of custom kinds is read from the CustomResourceDefinitions
+provided by --crd-schema, and Resources of unknown kinds are namespaced.
+
+With --update-references, references to the previous namespaces of the Resources are
+also updated:
+
+  - the namespace of the ServiceAccount subjects of RoleBindings and ClusterRoleBindings
+  - the DNS names of the Services in the package in string values, e.g.
+    db.staging.svc.cluster.local
+
+  DIR:
+    Path to local directory.
+
+  NAMESPACE:
+    Namespace to set.
+`,
+		Example: `# move the package to the prod namespace
+kyaml set namespace my-dir/ prod
+
+# also update RoleBinding subjects and service DNS names
+kyaml set namespace my-dir/ prod --update-references
+`,
+		RunE: r.runE,
+		Args: cobra.ExactArgs(2),
+	}
+	c.Flags().BoolVar(&r.UpdateReferences, "update-references", false,
+		"also update references to the previous namespaces.")
+	c.Flags().StringSliceVar(&r.CRDSchemas, "crd-schema", []string{},
+		"path to a file containing CustomResourceDefinitions with the scope of custom kinds.")
+	r.Command = c
+	return r
+}
+
+func SetNamespaceCommand() *cobra.Command {
+	return GetSetNamespaceRunner().Command
+}
+
+// SetNamespaceRunner contains the run function
+type SetNamespaceRunner struct {
+	UpdateReferences bool
+	CRDSchemas       []string
+	Command          *cobra.Command
+}
+
+func (r *SetNamespaceRunner) runE(c *cobra.Command, args []string) error {
+	schemas, err := loadSchemas(r.CRDSchemas)
+	if err != nil {
+		return handleError(c, err)
+	}
+	f := &filters.NamespaceFilter{
+		Namespace:        args[1],
+		UpdateReferences: r.UpdateReferences,
+		IsNamespaced: func(meta yaml.ResourceMeta) bool {
+			// the built-in schemas do not include CustomResourceDefinitions
+			return meta.Kind != "CustomResourceDefinition" && schemas.IsNamespaced(meta)
+		},
+	}
+	rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[0]}
+	err = ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{rw}, Filters: []kio.Filter{f}, Outputs: []kio.Writer{rw}}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+	fmt.Fprintf(c.OutOrStdout(), "set the namespace of %d resources\n", f.Count)
+	if r.UpdateReferences {
+		fmt.Fprintf(c.OutOrStdout(), "updated %d references\n", f.References)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestSetNamespaceCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"app.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: staging
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: staging
+data:
+  DB_HOST: db.staging.svc.cluster.local
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: web
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: staging
+roleRef:
+  kind: ClusterRole
+  name: view
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: gadget
+`,
+		"crd.yaml": `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  scope: Cluster
+  names:
+    kind: Gadget
+  versions:
+  - name: v1
+`,
+		"kustomization.yaml": "resources:\n- app.yaml\n",
+	})
+
+	r := cmd.GetSetRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"namespace", d, "prod", "--update-references",
+		"--crd-schema", filepath.Join(d, "crd.yaml")})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "set the namespace of 2 resources\nupdated 2 references\n", b.String())
+	assertFile(t, filepath.Join(d, "kustomization.yaml"), "resources:\n- app.yaml\n")
+	assertFile(t, filepath.Join(d, "app.yaml"), `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: prod
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: prod
+data:
+  DB_HOST: db.prod.svc.cluster.local
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: web
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: prod
+roleRef:
+  kind: ClusterRole
+  name: view
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: gadget
+`)
+}
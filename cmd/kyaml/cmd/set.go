@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetSetRunner returns a command SetRunner.
+func GetSetRunner() *SetRunner {
+	r := &SetRunner{}
+	c := &cobra.Command{
+		Use:   "set DIR [NAME VALUE]",
+		Short: "List or set the field setters of a package",
+		Long: `List or set the field setters of a package.
+
+Setters parameterize a package without templates.  A setter is declared on a field
+by a line comment containing the OpenAPI for the field, and sets the whole field
+value:
+
+  replicas: 3 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"3"}}}
+
+Partial setters each set a substring of the field value:
+
+  image: nginx:1.7.9 # {"x-kustomize":{"partialSetters":[{"name":"image","value":"nginx"},{"name":"tag","value":"1.7.9"}]}}
+
+If only DIR is provided, the setters of the package are listed.  If NAME and VALUE
+are provided, VALUE is substituted into every field the setter is declared on and
+the setter definitions are updated to the new value.
+
+If set, the field "type" is used to tag the value -- e.g. values of "integer" fields
+are written as ints, and values of "string" fields are quoted if they would be read
+as another type.
+
+  DIR:
+    Path to local directory.
+
+  NAME:
+    Name of the setter to set.
+
+  VALUE:
+    Value to set.
+`,
+		Example: `# list the setters of a package
+kyaml set my-dir/
+
+# set the replicas of all workloads in the package
+kyaml set my-dir/ replicas 5
+
+# set the namespace of the package
+kyaml set namespace my-dir/ prod
+`,
+		RunE: r.runE,
+		Args: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 && len(args) != 3 {
+				return fmt.Errorf("accepts DIR or DIR NAME VALUE, received %d args", len(args))
+			}
+			return nil
+		},
+	}
+	c.AddCommand(SetNamespaceCommand())
+	r.Command = c
+	return r
+}
+
+func SetCommand() *cobra.Command {
+	return GetSetRunner().Command
+}
+
+// SetRunner contains the run function
+type SetRunner struct {
+	Command *cobra.Command
+}
+
+func (r *SetRunner) runE(c *cobra.Command, args []string) error {
+	rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[0]}
+
+	if len(args) == 1 {
+		l := &filters.ListSettersFilter{}
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: []kio.Filter{l}}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+		w := tabwriter.NewWriter(c.OutOrStdout(), 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVALUE\tTYPE\tCOUNT")
+		for _, s := range l.Setters {
+			t := s.Type
+			if s.Partial {
+				t = "partial"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", s.Name, s.Value, t, s.Count)
+		}
+		return handleError(c, w.Flush())
+	}
+
+	s := &filters.SetFilter{Name: args[1], Value: args[2]}
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{rw}, Filters: []kio.Filter{s}, Outputs: []kio.Writer{rw}}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+	fmt.Fprintf(c.OutOrStdout(), "set %d fields\n", s.Count)
+	return nil
+}
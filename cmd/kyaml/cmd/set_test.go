@@ -0,0 +1,101 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const setInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 3 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"3"}}}
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7.9 # {"x-kustomize":{"partialSetters":[{"name":"image","value":"nginx"},{"name":"tag","value":"1.7.9"}]}}
+`
+
+func setTestDir(t *testing.T) string {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(setInput), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return d
+}
+
+func TestSetCommand_list(t *testing.T) {
+	d := setTestDir(t)
+	defer os.RemoveAll(d)
+
+	r := cmd.GetSetRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `NAME       VALUE   TYPE      COUNT
+image      nginx   partial   1
+replicas   3       integer   1
+tag        1.7.9   partial   1
+`, b.String())
+}
+
+func TestSetCommand_set(t *testing.T) {
+	d := setTestDir(t)
+	defer os.RemoveAll(d)
+
+	r := cmd.GetSetRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{d, "replicas", "5"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, "set 1 fields\n", b.String())
+
+	actual, err := ioutil.ReadFile(filepath.Join(d, "f1.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 5 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"5"}}}
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7.9 # {"x-kustomize":{"partialSetters":[{"name":"image","value":"nginx"},{"name":"tag","value":"1.7.9"}]}}
+`, string(actual))
+}
+
+func TestSetCommand_notFound(t *testing.T) {
+	d := setTestDir(t)
+	defer os.RemoveAll(d)
+
+	r := cmd.GetSetRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{d, "namespace", "foo"})
+	assert.EqualError(t, r.Command.Execute(), "setter namespace not found")
+}
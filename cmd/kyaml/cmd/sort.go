@@ -0,0 +1,82 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetSortRunner returns a command SortRunner.
+func GetSortRunner() *SortRunner {
+	r := &SortRunner{}
+	c := &cobra.Command{
+		Use:   "sort [DIR]...",
+		Short: "Print Resources sorted in apply order",
+		Long: `Print Resources from a local directory or stdin sorted in apply order.
+
+Resources are ordered so that they may be safely applied in order -- e.g.
+Namespaces and CustomResourceDefinitions are first, and webhooks are last.
+Resources of the same kind are ordered by apiVersion, namespace and name.
+
+If --owner-references is set, Resources are additionally ordered after the
+owners listed in their metadata.ownerReferences.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# apply the Resources in a directory in order
+kyaml sort my-dir/ | kubectl apply -f -
+
+# sort kustomize output
+kustomize build | kyaml sort --owner-references
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also print resources from subpackages.")
+	c.Flags().BoolVar(&r.KeepAnnotations, "annotate", false,
+		"annotate resources with their file origins.")
+	c.Flags().BoolVar(&r.OwnerReferences, "owner-references", false,
+		"order resources after their owners.")
+
+	r.Command = c
+	return r
+}
+
+func SortCommand() *cobra.Command {
+	return GetSortRunner().Command
+}
+
+// SortRunner contains the run function
+type SortRunner struct {
+	IncludeSubpackages bool
+	KeepAnnotations    bool
+	Command            *cobra.Command
+	filters.SortFilter
+}
+
+func (r *SortRunner) runE(c *cobra.Command, args []string) error {
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:           a,
+			IncludeSubpackages:    r.IncludeSubpackages,
+			OmitReaderAnnotations: !r.KeepAnnotations,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+		Inputs:  inputs,
+		Filters: []kio.Filter{r.SortFilter},
+		Outputs: []kio.Writer{kio.ByteWriter{
+			Writer:                c.OutOrStdout(),
+			KeepReaderAnnotations: r.KeepAnnotations,
+		}},
+	}).Execute())
+}
@@ -0,0 +1,264 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetSplitRunner returns a command SplitRunner.
+func GetSplitRunner() *SplitRunner {
+	r := &SplitRunner{}
+	c := &cobra.Command{
+		Use:   "split DIR [FILE]...",
+		Short: "Write each Resource to its own file",
+		Long: `Write each Resource from files or stdin to its own file in a directory.
+
+  DIR:
+    Path to the directory to write the Resources to.  Created if it does not exist.
+
+  FILE:
+    Path to a file containing one or more Resources.  If no files are provided,
+    Resources are read from stdin.
+
+File names are generated from --pattern, which may contain the following
+substitutions:
+
+- '{kind}' or '%k': kind
+- '{name}' or '%n': metadata.name
+- '{namespace}' or '%s': metadata.namespace
+
+The pattern must give each Resource its own file -- e.g. Resources with the same kind and
+name in different namespaces require a pattern containing '{namespace}'.  Documents which
+aren't Resources, such as kustomization files, are not split.
+
+If DIR, or the directory of a FILE, contains a kustomization file listing the FILE in its
+resources, the FILE is replaced in the list by the files its Resources were written to.
+The FILE itself is not removed.
+`,
+		Example: `# split a file into one file per resource
+kyaml split my-dir/ resources.yaml
+
+# split a kustomization resource into one file per resource, and update the
+# kustomization.yaml resources
+kyaml split my-app/ my-app/all.yaml && rm my-app/all.yaml
+
+# split kustomize output
+kustomize build | kyaml split my-dir/ --pattern '{kind}-{name}.yaml'
+`,
+		RunE: r.runE,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.Flags().StringVar(&r.FilenamePattern, "pattern", "{kind}-{name}.yaml",
+		"pattern to use for generating filenames for resources.")
+	c.Flags().BoolVar(&r.KeepAnnotations, "keep-annotations", false,
+		"if true, keep the path annotations set on Resources.")
+
+	r.Command = c
+	return r
+}
+
+func SplitCommand() *cobra.Command {
+	return GetSplitRunner().Command
+}
+
+// SplitRunner contains the run function
+type SplitRunner struct {
+	Command         *cobra.Command
+	FilenamePattern string
+	KeepAnnotations bool
+}
+
+func (r *SplitRunner) runE(c *cobra.Command, args []string) error {
+	var nodes []*yaml.RNode
+	// sources are the files the Resources were read from, and outputs the files
+	// the Resources from each source were written to
+	sources := map[*yaml.RNode]string{}
+	outputs := map[string][]string{}
+	var sourceFiles []string
+	for _, a := range args[1:] {
+		info, err := os.Stat(a)
+		if err != nil {
+			return handleError(c, err)
+		}
+		// paths are relative to the directory, or to the parent of a file
+		dir := a
+		if !info.IsDir() {
+			dir = filepath.Dir(a)
+		}
+		read, err := kio.LocalPackageReader{PackagePath: a}.Read()
+		if err != nil {
+			return handleError(c, err)
+		}
+		for i := range read {
+			if !kio.IsResource(read[i]) {
+				continue
+			}
+			meta, err := read[i].GetMeta()
+			if err != nil {
+				return handleError(c, err)
+			}
+			source := filepath.Join(dir, meta.Annotations[kioutil.PathAnnotation])
+			if _, found := outputs[source]; !found {
+				outputs[source] = nil
+				sourceFiles = append(sourceFiles, source)
+			}
+			sources[read[i]] = source
+		}
+		nodes = append(nodes, read...)
+	}
+	var input kio.Reader = &kio.PackageBuffer{Nodes: nodes}
+	if len(args) == 1 {
+		input = &kio.ByteReader{Reader: c.InOrStdin()}
+	}
+
+	if err := os.MkdirAll(args[0], 0700); err != nil {
+		return handleError(c, err)
+	}
+
+	// written are the Resources written to each file, to fail if the pattern
+	// doesn't give each Resource its own file
+	written := map[string]string{}
+	err := ConfigurePipeline(c, kio.Pipeline{
+		Inputs: []kio.Reader{input},
+		Filters: []kio.Filter{
+			kio.FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+				var resources []*yaml.RNode
+				for i := range nodes {
+					if kio.IsResource(nodes[i]) {
+						resources = append(resources, nodes[i])
+					}
+				}
+				return resources, nil
+			}),
+			&filters.FileSetter{FilenamePattern: r.FilenamePattern, Override: true},
+			kio.FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+				for i := range nodes {
+					meta, err := nodes[i].GetMeta()
+					if err != nil {
+						return nil, err
+					}
+					path := meta.Annotations[kioutil.PathAnnotation]
+					id := meta.Kind + " " + meta.Name
+					if meta.Namespace != "" {
+						id = meta.Kind + " " + meta.Namespace + "/" + meta.Name
+					}
+					if other, found := written[path]; found {
+						return nil, fmt.Errorf(
+							"%s and %s would both be written to %s, --pattern must give each Resource its own file",
+							other, id, path)
+					}
+					written[path] = id
+					if source, found := sources[nodes[i]]; found {
+						outputs[source] = appendUnique(outputs[source], filepath.Join(args[0], path))
+					}
+				}
+				return nodes, nil
+			}),
+		},
+		Outputs: []kio.Writer{kio.LocalPackageWriter{
+			PackagePath:           args[0],
+			KeepReaderAnnotations: r.KeepAnnotations,
+		}},
+	}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	// update the kustomizations listing the split files
+	for source := range outputs {
+		sort.Strings(outputs[source])
+	}
+	dirs := []string{filepath.Clean(args[0])}
+	for _, source := range sourceFiles {
+		dirs = appendUnique(dirs, filepath.Dir(source))
+	}
+	for _, dir := range dirs {
+		if err := updateKustomization(dir, sourceFiles, outputs); err != nil {
+			return handleError(c, err)
+		}
+	}
+	return nil
+}
+
+// kustomizationFileNames are the names of kustomization files
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// updateKustomization replaces the sources in the resources of the kustomization in dir,
+// if any, with their outputs.
+func updateKustomization(dir string, sources []string, outputs map[string][]string) error {
+	path := findKustomization(dir)
+	if path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	k, err := yaml.Parse(string(b))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	resources, err := k.Pipe(yaml.Lookup("resources"))
+	if err != nil || resources == nil || resources.YNode().Kind != yaml.SequenceNode {
+		return err
+	}
+
+	changed := false
+	listed := map[string]bool{}
+	var content []*yaml.Node
+	for _, elem := range resources.YNode().Content {
+		replaced := false
+		for _, source := range sources {
+			if filepath.Clean(filepath.Join(dir, elem.Value)) != filepath.Clean(source) {
+				continue
+			}
+			replaced, changed = true, true
+			for _, output := range outputs[source] {
+				rel, err := filepath.Rel(dir, output)
+				if err != nil {
+					return err
+				}
+				if !listed[rel] {
+					listed[rel] = true
+					content = append(content, yaml.NewScalarRNode(rel).YNode())
+				}
+			}
+		}
+		if !replaced && !listed[elem.Value] {
+			listed[elem.Value] = true
+			content = append(content, elem)
+		}
+	}
+	if !changed {
+		return nil
+	}
+	resources.YNode().Content = content
+
+	s, err := k.String()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(s), 0600)
+}
+
+// appendUnique appends value to values if it is not already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
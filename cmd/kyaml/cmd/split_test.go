@@ -0,0 +1,203 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const splitInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`
+
+func TestSplitCommand_stdin(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	r := cmd.GetSplitRunner()
+	r.Command.SetArgs([]string{filepath.Join(d, "out")})
+	r.Command.SetIn(bytes.NewBufferString(splitInput))
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(d, "out", "deployment-foo.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+`, string(b))
+
+	b, err = ioutil.ReadFile(filepath.Join(d, "out", "service-foo.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`, string(b))
+}
+
+func TestSplitCommand_files(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	in := filepath.Join(d, "resources.yaml")
+	if !assert.NoError(t, ioutil.WriteFile(in, []byte(splitInput), 0600)) {
+		return
+	}
+
+	out := filepath.Join(d, "out")
+	r := cmd.GetSplitRunner()
+	r.Command.SetArgs([]string{out, in, "--pattern", "%n_%k.yaml"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	files, err := ioutil.ReadDir(out)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{"foo_deployment.yaml", "foo_service.yaml"}, names)
+}
+
+func TestSplitCommand_kustomization(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(d, "all.yaml"), []byte(splitInput), 0600)) {
+		return
+	}
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "kustomization.yaml"), []byte(`# app resources
+resources:
+- configmap.yaml
+- all.yaml
+namePrefix: app-
+`), 0600)) {
+		return
+	}
+
+	r := cmd.GetSplitRunner()
+	r.Command.SetArgs([]string{d, filepath.Join(d, "all.yaml")})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(d, "kustomization.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `# app resources
+resources:
+- configmap.yaml
+- deployment-foo.yaml
+- service-foo.yaml
+namePrefix: app-
+`, string(b))
+	assert.FileExists(t, filepath.Join(d, "deployment-foo.yaml"))
+	assert.FileExists(t, filepath.Join(d, "service-foo.yaml"))
+}
+
+func TestSplitCommand_kustomizationDir(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		filepath.Join("app", "all.yaml"):           splitInput,
+		filepath.Join("app", "kustomization.yaml"): "resources:\n- all.yaml\n",
+	})
+
+	r := cmd.GetSplitRunner()
+	r.Command.SetArgs([]string{filepath.Join(d, "out"), filepath.Join(d, "app")})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	// the kustomization file isn't a Resource
+	files, err := ioutil.ReadDir(filepath.Join(d, "out"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{"deployment-foo.yaml", "service-foo.yaml"}, names)
+	assertFile(t, filepath.Join(d, "app", "kustomization.yaml"), `resources:
+- ../out/deployment-foo.yaml
+- ../out/service-foo.yaml
+`)
+}
+
+func TestSplitCommand_conflict(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	input := `apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  namespace: staging
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  namespace: production
+`
+
+	r := cmd.GetSplitRunner()
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	r.Command.SetArgs([]string{filepath.Join(d, "out")})
+	r.Command.SetIn(bytes.NewBufferString(input))
+	assert.EqualError(t, r.Command.Execute(), "Service staging/foo and Service production/foo "+
+		"would both be written to service-foo.yaml, --pattern must give each Resource its own file")
+
+	// the namespace gives each Resource its own file
+	r = cmd.GetSplitRunner()
+	r.Command.SetArgs([]string{filepath.Join(d, "out"), "--pattern", "{namespace}-{kind}-{name}.yaml"})
+	r.Command.SetIn(bytes.NewBufferString(input))
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.FileExists(t, filepath.Join(d, "out", "staging-service-foo.yaml"))
+	assert.FileExists(t, filepath.Join(d, "out", "production-service-foo.yaml"))
+}
@@ -0,0 +1,317 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/api/resource"
+)
+
+// GetStatsRunner returns a command StatsRunner.
+func GetStatsRunner() *StatsRunner {
+	r := &StatsRunner{}
+	c := &cobra.Command{
+		Use:   "stats [DIR]...",
+		Short: "Print statistics of the Resources of a package",
+		Long: `Print statistics of the Resources from a local directory or stdin.
+
+The following statistics are printed:
+
+- the number of Resources, and of Resources by kind
+- the number of replicas of the workloads, and of their containers
+- the total cpu and memory requests and limits of the containers, multiplied by the
+  replicas of their workloads
+- the images used, by registry
+- the number of distinct values of each label
+
+Workloads are the Resources containing a pod spec -- e.g. Pods, Deployments and
+CronJobs.  Their replicas are read from spec.replicas, and default to 1.  The requests
+and limits of init containers are not included.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# print the statistics of a package
+kyaml stats my-dir/
+
+# print the statistics of kustomize output as json
+kustomize build | kyaml stats --output json
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also include resources from subpackages.")
+	SetOutputFormats(c, OutputJSON)
+
+	r.Command = c
+	return r
+}
+
+func StatsCommand() *cobra.Command {
+	return GetStatsRunner().Command
+}
+
+// StatsRunner contains the run function
+type StatsRunner struct {
+	IncludeSubpackages bool
+	Command            *cobra.Command
+}
+
+// packageStats are the statistics of the Resources of a package
+type packageStats struct {
+	Resources  int               `json:"resources"`
+	Kinds      map[string]int    `json:"kinds"`
+	Workloads  int               `json:"workloads"`
+	Replicas   int64             `json:"replicas"`
+	Containers int64             `json:"containers"`
+	Requests   map[string]string `json:"requests"`
+	Limits     map[string]string `json:"limits"`
+	Registries map[string]int    `json:"registries"`
+	Labels     map[string]int    `json:"labels"`
+}
+
+// statsRegistry is the registry of images without a registry host
+const statsRegistry = "docker.io"
+
+func (r *StatsRunner) runE(c *cobra.Command, args []string) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin()})
+	}
+
+	var stats *packageStats
+	err = ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs,
+		Outputs: []kio.Writer{kio.WriterFunc(func(nodes []*yaml.RNode) error {
+			var err error
+			stats, err = computeStats(nodes)
+			return err
+		})},
+		ParseMode: kio.ParseModeFast,
+	}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+	if stats == nil {
+		// no Resources
+		stats, _ = computeStats(nil)
+	}
+
+	if output == OutputJSON {
+		e := json.NewEncoder(c.OutOrStdout())
+		e.SetIndent("", "  ")
+		return handleError(c, e.Encode(stats))
+	}
+
+	w := tabwriter.NewWriter(c.OutOrStdout(), 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "RESOURCES\t%d\n", stats.Resources)
+	fmt.Fprintf(w, "WORKLOADS\t%d\n", stats.Workloads)
+	fmt.Fprintf(w, "REPLICAS\t%d\n", stats.Replicas)
+	fmt.Fprintf(w, "CONTAINERS\t%d\n", stats.Containers)
+	for _, res := range []string{"cpu", "memory"} {
+		fmt.Fprintf(w, "%s REQUESTS\t%s\n", strings.ToUpper(res), stats.Requests[res])
+		fmt.Fprintf(w, "%s LIMITS\t%s\n", strings.ToUpper(res), stats.Limits[res])
+	}
+	writeStatsTable(w, "KIND", "RESOURCES", stats.Kinds)
+	writeStatsTable(w, "REGISTRY", "IMAGES", stats.Registries)
+	writeStatsTable(w, "LABEL", "VALUES", stats.Labels)
+	return handleError(c, w.Flush())
+}
+
+// writeStatsTable writes the counts sorted by key, as a table with the headers
+func writeStatsTable(w *tabwriter.Writer, key, value string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	var keys []string
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, "\n%s\t%s\n", key, value)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%d\n", k, counts[k])
+	}
+}
+
+// computeStats computes the statistics of the Resources
+func computeStats(nodes []*yaml.RNode) (*packageStats, error) {
+	stats := &packageStats{
+		Resources:  len(nodes),
+		Kinds:      map[string]int{},
+		Requests:   map[string]string{},
+		Limits:     map[string]string{},
+		Registries: map[string]int{},
+		Labels:     map[string]int{},
+	}
+	// cpu is summed in millicores and memory in bytes
+	requests, limits := map[string]int64{}, map[string]int64{}
+	images := map[string]sets.String{}
+	labels := map[string]sets.String{}
+
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		stats.Kinds[meta.Kind]++
+		for k, v := range meta.Labels {
+			if labels[k] == nil {
+				labels[k] = sets.String{}
+			}
+			labels[k].Insert(v)
+		}
+
+		podSpecs := findPodSpecs(nodes[i].YNode())
+		if len(podSpecs) == 0 {
+			continue
+		}
+		stats.Workloads++
+		replicas := int64(1)
+		if f, err := nodes[i].Pipe(yaml.Lookup("spec", "replicas")); err == nil && f != nil {
+			if replicas, err = strconv.ParseInt(f.YNode().Value, 10, 64); err != nil {
+				return nil, fmt.Errorf("%s %s: invalid replicas '%s'",
+					meta.Kind, meta.Name, f.YNode().Value)
+			}
+		}
+		stats.Replicas += replicas
+
+		for _, podSpec := range podSpecs {
+			containers := podSpec.Field("containers")
+			initContainers := podSpec.Field("initContainers")
+			for _, field := range []*yaml.MapNode{containers, initContainers} {
+				if yaml.IsFieldEmpty(field) {
+					continue
+				}
+				for _, container := range field.Value.Content() {
+					c := yaml.NewRNode(container)
+					if image := c.Field("image"); !yaml.IsFieldEmpty(image) {
+						registry := imageRegistry(image.Value.YNode().Value)
+						if images[registry] == nil {
+							images[registry] = sets.String{}
+						}
+						images[registry].Insert(image.Value.YNode().Value)
+					}
+					if field == initContainers {
+						continue
+					}
+					stats.Containers += replicas
+					for _, kind := range []struct {
+						field  string
+						totals map[string]int64
+					}{{"requests", requests}, {"limits", limits}} {
+						err := sumResources(c, kind.field, replicas, kind.totals)
+						if err != nil {
+							return nil, fmt.Errorf("%s %s: %v", meta.Kind, meta.Name, err)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, res := range []string{"cpu", "memory"} {
+		for _, t := range []struct {
+			totals map[string]int64
+			stats  map[string]string
+		}{{requests, stats.Requests}, {limits, stats.Limits}} {
+			if res == "cpu" {
+				t.stats[res] = resource.NewMilliQuantity(t.totals[res], resource.DecimalSI).String()
+			} else {
+				t.stats[res] = resource.NewQuantity(t.totals[res], resource.BinarySI).String()
+			}
+		}
+	}
+	for k, v := range images {
+		stats.Registries[k] = v.Len()
+	}
+	for k, v := range labels {
+		stats.Labels[k] = v.Len()
+	}
+	return stats, nil
+}
+
+// sumResources adds the cpu and memory of the container resources field, multiplied by
+// replicas, to totals
+func sumResources(c *yaml.RNode, field string, replicas int64, totals map[string]int64) error {
+	for _, res := range []string{"cpu", "memory"} {
+		f, err := c.Pipe(yaml.Lookup("resources", field, res))
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			continue
+		}
+		q, err := resource.ParseQuantity(f.YNode().Value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s '%s': %v",
+				res, strings.TrimSuffix(field, "s"), f.YNode().Value, err)
+		}
+		if res == "cpu" {
+			totals[res] += q.MilliValue() * replicas
+		} else {
+			totals[res] += q.Value() * replicas
+		}
+	}
+	return nil
+}
+
+// findPodSpecs returns the pod specs in node -- the mappings with a containers list
+func findPodSpecs(node *yaml.Node) []*yaml.RNode {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		var specs []*yaml.RNode
+		for i := range node.Content {
+			specs = append(specs, findPodSpecs(node.Content[i])...)
+		}
+		return specs
+	case yaml.MappingNode:
+		rn := yaml.NewRNode(node)
+		if f := rn.Field("containers"); !yaml.IsFieldEmpty(f) &&
+			f.Value.YNode().Kind == yaml.SequenceNode {
+			return []*yaml.RNode{rn}
+		}
+		var specs []*yaml.RNode
+		for i := 1; i < len(node.Content); i += 2 {
+			specs = append(specs, findPodSpecs(node.Content[i])...)
+		}
+		return specs
+	}
+	return nil
+}
+
+// imageRegistry returns the registry of the image -- the first component of the image
+// if it is a host, and docker.io otherwise.
+func imageRegistry(image string) string {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return statsRegistry
+	}
+	host := image[:i]
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+	return statsRegistry
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const statsInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+    tier: frontend
+spec:
+  replicas: 3
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox
+      containers:
+      - name: nginx
+        image: gcr.io/example/nginx:1.7
+        resources:
+          requests:
+            cpu: 250m
+            memory: 64Mi
+          limits:
+            cpu: 500m
+            memory: 128Mi
+      - name: sidecar
+        image: example.com:5000/sidecar
+        resources:
+          requests:
+            cpu: 100m
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: report
+  labels:
+    app: report
+    tier: backend
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: report
+            image: library/report:v1
+            resources:
+              requests:
+                cpu: "1"
+                memory: 1Gi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  labels:
+    app: web
+`
+
+func TestStatsCommand(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetStatsRunner()
+	r.Command.SetIn(bytes.NewBufferString(statsInput))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, `RESOURCES         3
+WORKLOADS         2
+REPLICAS          4
+CONTAINERS        7
+CPU REQUESTS      2050m
+CPU LIMITS        1500m
+MEMORY REQUESTS   1216Mi
+MEMORY LIMITS     384Mi
+
+KIND         RESOURCES
+CronJob      1
+Deployment   1
+Service      1
+
+REGISTRY           IMAGES
+docker.io          2
+example.com:5000   1
+gcr.io             1
+
+LABEL   VALUES
+app     2
+tier    2
+`, b.String())
+}
+
+func TestStatsCommand_json(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetStatsRunner()
+	r.Command.SetIn(bytes.NewBufferString(statsInput))
+	r.Command.SetOut(b)
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{"--output", "json"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	var stats struct {
+		Replicas int               `json:"replicas"`
+		Requests map[string]string `json:"requests"`
+		Labels   map[string]int    `json:"labels"`
+	}
+	if !assert.NoError(t, json.Unmarshal(b.Bytes(), &stats)) {
+		return
+	}
+	assert.Equal(t, 4, stats.Replicas)
+	assert.Equal(t, map[string]string{"cpu": "2050m", "memory": "1216Mi"}, stats.Requests)
+	assert.Equal(t, map[string]int{"app": 2, "tier": 2}, stats.Labels)
+}
+
+func TestStatsCommand_invalidQuantity(t *testing.T) {
+	r := cmd.GetStatsRunner()
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: nginx
+    resources:
+      limits:
+        memory: lots
+`))
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetErr(&bytes.Buffer{})
+	r.Command.SetArgs([]string{})
+	assert.EqualError(t, r.Command.Execute(),
+		"Pod web: invalid memory limit 'lots': quantities must match the regular expression "+
+			"'^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'")
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+// GetStripRunner returns a command StripRunner.
+func GetStripRunner() *StripRunner {
+	r := &StripRunner{}
+	c := &cobra.Command{
+		Use:   "strip [DIR]...",
+		Short: "Remove server populated fields and comments from Resource Config",
+		Long: `Remove server populated fields and comments from Resource Config.
+
+Strip removes the fields set on Resources by the apiserver so that Resource Config
+exported from a cluster can be committed back to a package.  By default the
+following fields are removed:
+
+- status
+- metadata.managedFields
+- metadata.creationTimestamp (and the creationTimestamp of pod templates)
+- metadata.resourceVersion
+- metadata.uid
+
+Each field may be kept by setting its flag to false.  Comments are only removed
+if --comments is set.
+
+- Stdin inputs are stripped and written to stdout
+- Directory inputs (args) are stripped and written back to their files
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# strip a directory of Resource Config in place
+kyaml strip my-dir/
+
+# strip kubectl output, keeping the status
+kubectl get -o yaml deployments | kyaml strip --status=false
+
+# remove only the comments
+kyaml strip my-dir/ --comments --status=false --managed-fields=false \
+    --creation-timestamp=false --resource-version=false --uid=false
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.Status, "status", true,
+		"remove the status field.")
+	c.Flags().BoolVar(&r.ManagedFields, "managed-fields", true,
+		"remove the metadata.managedFields field.")
+	c.Flags().BoolVar(&r.CreationTimestamp, "creation-timestamp", true,
+		"remove the metadata.creationTimestamp field.")
+	c.Flags().BoolVar(&r.ResourceVersion, "resource-version", true,
+		"remove the metadata.resourceVersion field.")
+	c.Flags().BoolVar(&r.UID, "uid", true,
+		"remove the metadata.uid field.")
+	c.Flags().BoolVar(&r.Comments, "comments", false,
+		"remove comments.")
+	r.Command = c
+	return r
+}
+
+func StripCommand() *cobra.Command {
+	return GetStripRunner().Command
+}
+
+// StripRunner contains the run function
+type StripRunner struct {
+	Status            bool
+	ManagedFields     bool
+	CreationTimestamp bool
+	ResourceVersion   bool
+	UID               bool
+	Comments          bool
+	Command           *cobra.Command
+}
+
+func (r *StripRunner) runE(c *cobra.Command, args []string) error {
+	f := []kio.Filter{filters.StripFilter{
+		Status:            r.Status,
+		ManagedFields:     r.ManagedFields,
+		CreationTimestamp: r.CreationTimestamp,
+		ResourceVersion:   r.ResourceVersion,
+		UID:               r.UID,
+	}}
+	if r.Comments {
+		f = append(f, filters.StripCommentsFilter{})
+	}
+
+	// strip stdin if there are no args
+	if len(args) == 0 {
+		rw := &kio.ByteReadWriter{
+			Reader: c.InOrStdin(),
+			Writer: c.OutOrStdout(),
+		}
+		return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}).Execute())
+	}
+
+	for i := range args {
+		rw := &kio.LocalPackageReadWriter{NoDeleteFiles: true, PackagePath: args[i]}
+		err := ConfigurePipeline(c, kio.Pipeline{
+			Inputs: []kio.Reader{rw}, Filters: f, Outputs: []kio.Writer{rw}}).Execute()
+		if err != nil {
+			return handleError(c, err)
+		}
+	}
+	return nil
+}
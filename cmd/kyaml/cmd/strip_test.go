@@ -0,0 +1,80 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+const stripInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo # the name
+  uid: 6c3b1a5e-1f0e-4d8e-9a3b-2a1c5e6f7d8e
+  resourceVersion: "1234"
+  creationTimestamp: "2019-10-26T11:06:19Z"
+spec:
+  replicas: 1
+status:
+  replicas: 1
+`
+
+func TestStripCommand_stdin(t *testing.T) {
+	r := cmd.GetStripRunner()
+	b := &bytes.Buffer{}
+	r.Command.SetIn(strings.NewReader(stripInput))
+	r.Command.SetOut(b)
+	r.Command.SetArgs([]string{"--uid=false", "--comments"})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  uid: 6c3b1a5e-1f0e-4d8e-9a3b-2a1c5e6f7d8e
+spec:
+  replicas: 1
+`, b.String())
+}
+
+func TestStripCommand_dir(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(stripInput), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := cmd.GetStripRunner()
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetArgs([]string{d})
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(d, "f1.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo # the name
+spec:
+  replicas: 1
+`, string(b))
+}
@@ -6,6 +6,7 @@ package cmd
 import (
 	"path/filepath"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/kustomize/kyaml/kio/filters"
 
@@ -26,17 +27,83 @@ kyaml tree may be used to print Resources in a directory or cluster, preserving
 Args:
 
   DIR:
-    Path to local directory directory.
+    Path to local directory directory, or git URL of a remote package --
+    e.g. https://github.com/org/repo//manifests?ref=v1.2.3.  Remote packages are
+    cloned using git.
 
 Resource fields may be printed as part of the Resources by specifying the fields as flags.
 
 kyaml tree has build-in support for printing common fields, such as replicas, container images,
-container names, etc.
+container names, etc.  The fields of init containers and ephemeral containers are printed
+separately from the fields of containers -- e.g. under spec.template.spec.initContainers.
 
 kyaml tree supports printing arbitrary fields using the '--field' flag.
 
 By default, kyaml tree uses the directory structure for the tree structure, however when printing
 from the cluster, the Resource graph structure may be used instead.
+
+When using the graph structure, Resources managed by well-known tools (argocd, flux, helm,
+kubectl apply) are badged with the names of the tools managing them.
+
+When using the graph structure, '--events' correlates the Events in the input to the Resources
+they are about, and prints the most recent Warning Events beneath each Resource.
+
+'--age' prints the age of each live Resource from its creationTimestamp, and '--restarts' prints
+the number of times the containers of each Pod have restarted, as 'kubectl get' prints them, so
+that failing workloads may be triaged from the tree.
+
+When using the graph structure, '--resolve-selectors' prints the Deployments and StatefulSets
+beneath the Services whose selectors match their pod template labels, for a view of the
+Resources by application rather than by owner.  Services whose selectors match no workloads
+are flagged.
+
+'--group-by=crd' prints the instances of the CustomResourceDefinitions in the input beneath
+their definition, with the number of instances of each definition, so that operator-heavy
+packages may be audited by custom resource kind.  Instances are matched to definitions by their
+group and kind.  When using the graph structure, instances with owners are still printed beneath
+their owners.
+
+When using the directory structure, '--generators' prints the ConfigMaps and Secrets generated
+by the configMapGenerator and secretGenerator of kustomization files beneath them, with the
+name suffix hash predicted from the generator sources, so the names may be seen without running
+kustomize build.  The Resources referencing a generated ConfigMap or Secret by its base name are
+printed beneath it.
+
+'--template' renders the tree with a Go text/template rather than printing it as ascii,
+e.g. to print Markdown or HTML.  The template is executed with the root node of the tree.
+Each node has the fields:
+
+  .Meta        the bracketed prefix of the node -- e.g. the file name of a Resource
+  .Value       the text of the node -- e.g. "Deployment default/nginx" or "spec.replicas: 3"
+  .Resource    the Resource the node is for, if any
+  .Namespaces  the namespaces of the Resources folded into the node, if any
+  .Depth       the depth of the node -- 0 for the root
+  .Children    the nodes beneath the node
+
+In addition to the text/template builtins, the template may use the functions:
+
+  indent N STRING     indent each line of STRING by N spaces
+  repeat N STRING     repeat STRING N times
+  field RESOURCE PATH the value of the '.' separated field PATH of RESOURCE
+
+'--fold-duplicates' prints the sibling Resources which are identical except for their namespace
+as a single Resource with the number of namespaces it is in, e.g. "Deployment web (12 namespaces)",
+to keep the trees of clusters running the same Resources in many namespaces manageable.  Server-set
+metadata -- e.g. uid and resourceVersion -- and status are ignored when comparing Resources.  When
+using the graph structure, the children of the folded Resources are merged and folded in turn.
+'--expand-folded' prints the namespaces of the folded Resources beneath them.
+
+'--output metrics' prints a summary of the Resources in the OpenMetrics text format rather
+than the tree, e.g. for a cron job feeding cluster dumps to Prometheus to alert on
+configuration drift.  The gauges are:
+
+  kyaml_resources{kind,namespace}              the number of Resources
+  kyaml_images{image,tag}                      the number of containers using each image tag
+  kyaml_image_tag_age_seconds{image,tag}       the time since the oldest Resource using each
+                                               image tag was created, from its
+                                               metadata.creationTimestamp
+  kyaml_validation_failures{kind,namespace}    the number of failures validating the
+                                               Resources against the built-in schemas
 `,
 		Example: `# print Resources using directory structure
 kyaml tree my-dir/
@@ -65,6 +132,32 @@ kubectl get all,applications,releasetracks -o yaml | kyaml tree --structure=grap
   --field="status.conditions[type=Complete].status" \
   --field="status.conditions[type=Ready].status" \
   --field="status.conditions[type=ContainersReady].status"
+
+# print the Resources of a remote package at a tag
+kyaml tree https://github.com/org/repo//manifests?ref=v1.2.3
+
+# print live Resources with their recent Warning Events
+kubectl get all,events -o yaml | kyaml tree --graph-structure=graph --events
+
+# print live Resources with their ages and the restarts of their Pods
+kubectl get all -o yaml | kyaml tree --graph-structure=graph --age --restarts
+
+# print live Resources beneath the Services selecting them
+kubectl get all -o yaml | kyaml tree --graph-structure=graph --resolve-selectors
+
+# print the custom resources of a package beneath their CustomResourceDefinitions
+kyaml tree my-dir/ --group-by=crd
+
+# print live Resources, folding the Resources duplicated across namespaces
+kubectl get all -A -o yaml | kyaml tree --graph-structure=graph --fold-duplicates
+
+# print a summary of live Resources for Prometheus
+kubectl get all -o yaml | kyaml tree --output metrics > /var/lib/node_exporter/kyaml.prom
+
+# print Resources as a Markdown list
+kyaml tree my-dir/ --replicas --template '{{define "n"}}{{range .Children}}
+{{- repeat .Depth "  "}}- {{.Value}}
+{{template "n" .}}{{end}}{{end}}{{template "n" .}}'
 `,
 		RunE: r.runE,
 		Args: cobra.MaximumNArgs(1),
@@ -89,7 +182,33 @@ kubectl get all,applications,releasetracks -o yaml | kyaml tree --structure=grap
 	c.Flags().BoolVar(&r.excludeNonLocal, "exclude-non-local", false,
 		"if true, exclude non-local-config in the output.")
 	c.Flags().StringVar(&r.structure, "graph-structure", "directory",
-		"Graph structure to use for printing the tree.  may be 'directory' or 'owners'.")
+		"Graph structure to use for printing the tree.  may be 'directory' or 'graph'.")
+	markFlagValues(c, "graph-structure",
+		string(kio.TreeStructurePackage), string(kio.TreeStructureGraph))
+	c.Flags().BoolVar(&r.events, "events", false,
+		"print Warning Events beneath the Resources they are about -- only for the graph structure.")
+	c.Flags().IntVar(&r.maxEvents, "max-events", 3,
+		"maximum number of Events to print beneath each Resource.")
+	c.Flags().BoolVar(&r.age, "age", false,
+		"print the age of the Resources from their creationTimestamp.")
+	c.Flags().BoolVar(&r.restarts, "restarts", false,
+		"print the number of times the containers of Pods have restarted.")
+	c.Flags().BoolVar(&r.resolveSelectors, "resolve-selectors", false,
+		"print workloads beneath the Services selecting them -- only for the graph structure.")
+	c.Flags().StringVar(&r.groupBy, "group-by", "",
+		"grouping of the Resources within the structure.  may be 'crd'.")
+	markFlagValues(c, "group-by", string(kio.TreeGroupByCRD))
+	c.Flags().BoolVar(&r.generators, "generators", false,
+		"print the ConfigMaps and Secrets generated by kustomization files, with their predicted names.")
+	c.Flags().StringVar(&r.template, "template", "",
+		"Go text/template used to render the tree rather than printing it as ascii.")
+	c.Flags().BoolVar(&r.foldDuplicates, "fold-duplicates", false,
+		"print Resources which are identical except for their namespace as a single Resource.")
+	c.Flags().BoolVar(&r.expandFolded, "expand-folded", false,
+		"print the namespaces of the Resources folded by --fold-duplicates beneath them.")
+	c.Flags().StringVar(&r.gitCacheDir, "git-cache-dir", "",
+		"directory where the clones of remote packages are kept and reused.  defaults to cloning into a temporary directory.")
+	SetOutputFormats(c, "metrics")
 
 	r.Command = c
 	return r
@@ -116,18 +235,37 @@ type TreeRunner struct {
 	includeLocal       bool
 	excludeNonLocal    bool
 	structure          string
+	events             bool
+	maxEvents          int
+	age                bool
+	restarts           bool
+	resolveSelectors   bool
+	groupBy            string
+	generators         bool
+	template           string
+	foldDuplicates     bool
+	expandFolded       bool
+	gitCacheDir        string
 }
 
 func (r *TreeRunner) runE(c *cobra.Command, args []string) error {
 	var input kio.Reader
 	var root = "."
-	if len(args) == 1 {
+	if len(args) == 1 && kio.IsGitURL(args[0]) {
+		root = args[0]
+		input = kio.GitReader{URL: args[0], CacheDir: r.gitCacheDir}
+	} else if len(args) == 1 {
 		root = filepath.Clean(args[0])
 		input = kio.LocalPackageReader{PackagePath: args[0]}
 	} else {
 		input = &kio.ByteReader{Reader: c.InOrStdin()}
 	}
 
+	output, err := outputFormat(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
 	var fields []kio.TreeWriterField
 	for _, field := range r.fields {
 		path, err := parseFieldPath(field)
@@ -138,35 +276,20 @@ func (r *TreeRunner) runE(c *cobra.Command, args []string) error {
 	}
 
 	if r.name || (r.all && !c.Flag("name").Changed) {
-		fields = append(fields,
-			newField("spec", "containers", "[name=.*]", "name"),
-			newField("spec", "template", "spec", "containers", "[name=.*]", "name"),
-		)
+		fields = append(fields, containerFields("name")...)
 	}
 	if r.images || (r.all && !c.Flag("image").Changed) {
-		fields = append(fields,
-			newField("spec", "containers", "[name=.*]", "image"),
-			newField("spec", "template", "spec", "containers", "[name=.*]", "image"),
-		)
+		fields = append(fields, containerFields("image")...)
 	}
 
 	if r.cmd || (r.all && !c.Flag("command").Changed) {
-		fields = append(fields,
-			newField("spec", "containers", "[name=.*]", "command"),
-			newField("spec", "template", "spec", "containers", "[name=.*]", "command"),
-		)
+		fields = append(fields, containerFields("command")...)
 	}
 	if r.args || (r.all && !c.Flag("args").Changed) {
-		fields = append(fields,
-			newField("spec", "containers", "[name=.*]", "args"),
-			newField("spec", "template", "spec", "containers", "[name=.*]", "args"),
-		)
+		fields = append(fields, containerFields("args")...)
 	}
 	if r.env || (r.all && !c.Flag("env").Changed) {
-		fields = append(fields,
-			newField("spec", "containers", "[name=.*]", "env"),
-			newField("spec", "template", "spec", "containers", "[name=.*]", "env"),
-		)
+		fields = append(fields, containerFields("env")...)
 	}
 
 	if r.replicas || (r.all && !c.Flag("replicas").Changed) {
@@ -175,17 +298,11 @@ func (r *TreeRunner) runE(c *cobra.Command, args []string) error {
 		)
 	}
 	if r.resources || (r.all && !c.Flag("resources").Changed) {
-		fields = append(fields,
-			newField("spec", "containers", "[name=.*]", "resources"),
-			newField("spec", "template", "spec", "containers", "[name=.*]", "resources"),
-		)
+		fields = append(fields, containerFields("resources")...)
 	}
 	if r.ports || (r.all && !c.Flag("ports").Changed) {
-		fields = append(fields,
-			newField("spec", "containers", "[name=.*]", "ports"),
-			newField("spec", "template", "spec", "containers", "[name=.*]", "ports"),
-			newField("spec", "ports"),
-		)
+		fields = append(fields, containerFields("ports")...)
+		fields = append(fields, newField("spec", "ports"))
 	}
 
 	// show reconcilers in tree
@@ -194,36 +311,74 @@ func (r *TreeRunner) runE(c *cobra.Command, args []string) error {
 		ExcludeNonLocalConfig: r.excludeNonLocal,
 	}}
 
-	return handleError(c, kio.Pipeline{
+	if output == "metrics" {
+		schemas, err := loadSchemas(nil)
+		if err != nil {
+			return handleError(c, err)
+		}
+		return handleError(c, ConfigurePipeline(c, kio.Pipeline{
+			Inputs:  []kio.Reader{input},
+			Filters: fltrs,
+			Outputs: []kio.Writer{metricsWriter{
+				Writer: c.OutOrStdout(), Schemas: schemas, Now: time.Now()}},
+		}).Execute())
+	}
+
+	return handleError(c, ConfigurePipeline(c, kio.Pipeline{
 		Inputs:  []kio.Reader{input},
 		Filters: fltrs,
 		Outputs: []kio.Writer{kio.TreeWriter{
-			Root:      root,
-			Writer:    c.OutOrStdout(),
-			Fields:    fields,
-			Structure: kio.TreeStructure(r.structure)}},
-	}.Execute())
+			Root:             root,
+			Writer:           c.OutOrStdout(),
+			Fields:           fields,
+			Structure:        kio.TreeStructure(r.structure),
+			Events:           r.events,
+			MaxEvents:        r.maxEvents,
+			Age:              r.age,
+			Restarts:         r.restarts,
+			ResolveSelectors: r.resolveSelectors,
+			GroupBy:          kio.TreeGroupBy(r.groupBy),
+			Generators:       r.generators,
+			Template:         r.template,
+			FoldDuplicates:   r.foldDuplicates,
+			ExpandFolded:     r.expandFolded}},
+	}).Execute())
 }
 
-func newField(val ...string) kio.TreeWriterField {
-	if strings.HasPrefix(strings.Join(val, "."), "spec.template.spec.containers") {
-		return kio.TreeWriterField{
-			Name:        "spec.template.spec.containers",
-			PathMatcher: yaml.PathMatcher{Path: val, StripComments: true},
-			SubName:     val[len(val)-1],
-		}
+// containerLists are the fields of a Pod spec listing containers.  Each is printed
+// separately, so that e.g. the images of the init containers are distinguished from the
+// images of the containers.
+var containerLists = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// containerFields returns the fields printing the field of each container of the Pod
+// specs of Resources, and of the Pod templates of workload Resources
+func containerFields(field string) []kio.TreeWriterField {
+	var fields []kio.TreeWriterField
+	for _, list := range containerLists {
+		fields = append(fields,
+			newField("spec", list, "[name=.*]", field),
+			newField("spec", "template", "spec", list, "[name=.*]", field),
+		)
 	}
+	return fields
+}
 
-	if strings.HasPrefix(strings.Join(val, "."), "spec.containers") {
-		return kio.TreeWriterField{
-			Name:        "spec.containers",
-			PathMatcher: yaml.PathMatcher{Path: val, StripComments: true},
-			SubName:     val[len(val)-1],
+func newField(val ...string) kio.TreeWriterField {
+	path := strings.Join(val, ".")
+	for _, list := range containerLists {
+		for _, prefix := range []string{"spec.template.spec." + list, "spec." + list} {
+			if path == prefix || strings.HasPrefix(path, prefix+".") {
+				return kio.TreeWriterField{
+					Name:        prefix,
+					PathMatcher: yaml.PathMatcher{Path: val, StripComments: true},
+					SubName:     val[len(val)-1],
+				}
+			}
 		}
 	}
 
 	return kio.TreeWriterField{
-		Name:        strings.Join(val, "."),
+		Name:        path,
 		PathMatcher: yaml.PathMatcher{Path: val, StripComments: true},
 	}
 }
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -208,6 +210,69 @@ spec:
 	}
 }
 
+func TestTreeCommand_initContainers(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{"--name", "--image"})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: f1.yaml
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: migrate:1
+      containers:
+      - name: nginx
+        image: nginx:1.7.9
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: bar
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: f1.yaml
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7.9
+  ephemeralContainers:
+  - name: debug
+    image: busybox
+`))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `.
+├── [f1.yaml]  Pod bar
+│   ├── spec.containers
+│   │   └── 0
+│   │       ├── name: nginx
+│   │       └── image: nginx:1.7.9
+│   └── spec.ephemeralContainers
+│       └── 0
+│           ├── name: debug
+│           └── image: busybox
+└── [f1.yaml]  Deployment foo
+    ├── spec.template.spec.containers
+    │   └── 0
+    │       ├── name: nginx
+    │       └── image: nginx:1.7.9
+    └── spec.template.spec.initContainers
+        └── 0
+            ├── name: migrate
+            └── image: migrate:1
+`, b.String())
+}
+
 func TestTreeCommand_includeReconcilers(t *testing.T) {
 	d, err := ioutil.TempDir("", "kustomize-tree-test")
 	defer os.RemoveAll(d)
@@ -353,3 +418,401 @@ spec:
 		return
 	}
 }
+
+func TestTreeCommand_events(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{"--graph-structure", "graph", "--events", "--replicas"})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: foo.1
+  namespace: default
+involvedObject:
+  apiVersion: apps/v1
+  kind: Deployment
+  name: foo
+  namespace: default
+type: Warning
+reason: FailedCreate
+message: quota exceeded
+count: 2
+`))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	if !assert.Equal(t, `.
+└── [Resource]  Deployment default/foo
+    ├── spec.replicas: 1
+    └── [Event]  FailedCreate (x2): quota exceeded
+`, b.String()) {
+		return
+	}
+}
+
+func TestTreeCommand_resolveSelectors(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{"--graph-structure", "graph", "--resolve-selectors"})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        app: foo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  namespace: default
+spec:
+  selector:
+    app: foo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: bar
+  namespace: default
+spec:
+  selector:
+    app: bar
+`))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	if !assert.Equal(t, `.
+├── [Resource]  Service default/bar
+│   └── [Selector]  matches no workloads
+└── [Resource]  Service default/foo
+    └── [Resource]  Deployment default/foo
+`, b.String()) {
+		return
+	}
+}
+
+func TestTreeCommand_groupByCRD(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{"--graph-structure", "graph", "--group-by", "crd"})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: foo
+  namespace: default
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  namespace: default
+`))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	if !assert.Equal(t, `.
+├── [Resource]  CustomResourceDefinition widgets.example.com
+│   ├── [Instances]  1
+│   └── [Resource]  Widget default/foo
+└── [Resource]  Service default/foo
+`, b.String()) {
+		return
+	}
+}
+
+func TestTreeCommand_template(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{"--replicas", "--template", `{{define "n"}}{{range .Children}}
+{{- repeat .Depth "  "}}- {{.Value}}
+{{template "n" .}}{{end}}{{end}}{{template "n" .}}`})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: f1.yaml
+spec:
+  replicas: 1
+---
+kind: Deployment
+metadata:
+  name: bar
+  annotations:
+    config.kubernetes.io/package: bar-package
+    config.kubernetes.io/path: f2.yaml
+spec:
+  replicas: 3
+`))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `  - Deployment default/foo
+    - spec.replicas: 1
+  - bar-package
+    - Deployment bar
+      - spec.replicas: 3
+`, b.String())
+}
+
+func TestTreeCommand_foldDuplicates(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{"--graph-structure", "graph", "--fold-duplicates",
+		"--template", `{{range .Children}}{{.Value}}:{{range .Namespaces}} {{.}}{{end}}
+{{end}}`})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: team-a
+  uid: 1
+  creationTimestamp: "2019-11-20T17:32:00Z"
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: team-b
+  uid: 2
+  creationTimestamp: "2019-11-21T09:12:00Z"
+spec:
+  ports:
+  - port: 80
+`))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `Service web (2 namespaces): team-a team-b
+`, b.String())
+}
+
+func TestTreeCommand_restarts(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{"--graph-structure", "graph", "--restarts"})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web-1
+  namespace: default
+status:
+  containerStatuses:
+  - name: web
+    restartCount: 7
+`))
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, `.
+└── [Resource]  Pod default/web-1
+    └── restarts: 7
+`, b.String())
+}
+
+func TestTreeCommand_generators(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	defer os.RemoveAll(d)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = ioutil.WriteFile(filepath.Join(d, "kustomization.yaml"), []byte(`namePrefix: app-
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: config
+  literals:
+  - color="blue"
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "deployment.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        envFrom:
+        - configMapRef:
+            name: config
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{d, "--generators"})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	assert.Equal(t, fmt.Sprintf(`%s
+├── [deployment.yaml]  Deployment nginx
+└── [kustomization.yaml]  Kustomization 
+    └── [generated]  ConfigMap app-config-k55bgk4dk8
+        └── [deployment.yaml]  Deployment nginx
+`, d), b.String())
+}
+
+func TestTreeCommand_metrics(t *testing.T) {
+	r := cmd.GetTreeRunner()
+	cmd.AddGlobalFlags(r.Command)
+	defer func() { cmd.Output = cmd.OutputText }()
+	r.Command.SetArgs([]string{"--output", "metrics"})
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web-1
+  namespace: default
+  creationTimestamp: "2019-01-01T00:00:00Z"
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.17
+  - name: sidecar
+    image: gcr.io/example/sidecar@sha256:abc
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: "three"
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: localhost:5000/nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+`))
+	b := &bytes.Buffer{}
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+
+	out := b.String()
+	assert.Regexp(t, `(?m)^kyaml_image_tag_age_seconds\{image="nginx",tag="1.17"\} \d+`, out)
+	ages := regexp.MustCompile(`(?m)^kyaml_image_tag_age_seconds\{.*\n`)
+	assert.Equal(t, `# TYPE kyaml_resources gauge
+# HELP kyaml_resources Number of Resources.
+kyaml_resources{kind="Deployment",namespace="default"} 1
+kyaml_resources{kind="Pod",namespace="default"} 1
+kyaml_resources{kind="Service",namespace="default"} 1
+# TYPE kyaml_images gauge
+# HELP kyaml_images Number of containers using the image tag.
+kyaml_images{image="gcr.io/example/sidecar",tag="sha256:abc"} 1
+kyaml_images{image="localhost:5000/nginx",tag="latest"} 1
+kyaml_images{image="nginx",tag="1.17"} 1
+# TYPE kyaml_image_tag_age_seconds gauge
+# HELP kyaml_image_tag_age_seconds Time since the oldest Resource using the image tag was created.
+# TYPE kyaml_validation_failures gauge
+# HELP kyaml_validation_failures Number of schema validation failures.
+kyaml_validation_failures{kind="Deployment",namespace="default"} 1
+# EOF
+`, ages.ReplaceAllString(out, ""))
+}
+
+func TestTreeCommand_git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	d, err := ioutil.TempDir("", "kustomize-tree-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	writeFiles(t, d, map[string]string{
+		"manifests/f1.yaml": `kind: Deployment
+metadata:
+  name: foo
+`,
+		"manifests/sub/f2.yaml": `kind: Service
+metadata:
+  name: foo
+`,
+		"other.yaml": `kind: Deployment
+metadata:
+  name: other
+`,
+	})
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com",
+			"commit", "--quiet", "-m", "init"},
+		{"tag", "v1"},
+	} {
+		git := exec.Command("git", args...)
+		git.Dir = d
+		if out, err := git.CombinedOutput(); !assert.NoError(t, err, string(out)) {
+			return
+		}
+	}
+
+	url := "file://" + filepath.ToSlash(d) + "//manifests?ref=v1"
+	b := &bytes.Buffer{}
+	r := cmd.GetTreeRunner()
+	r.Command.SetArgs([]string{url, "--git-cache-dir", filepath.Join(d, ".cache")})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, url+`
+├── [f1.yaml]  Deployment foo
+└── sub
+    └── [f2.yaml]  Service foo
+`, b.String())
+}
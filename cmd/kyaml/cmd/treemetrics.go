@@ -0,0 +1,156 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// metricsWriter writes a summary of Resources as OpenMetrics text
+type metricsWriter struct {
+	Writer io.Writer
+
+	// Schemas are the schemas the Resources are validated against
+	Schemas openapi.Schemas
+
+	// Now is the time image tag ages are computed at
+	Now time.Time
+}
+
+// metricSample is a sample of a metric, by its label values
+type metricSample struct {
+	labels []string
+	value  float64
+}
+
+// metricFamily is an OpenMetrics gauge
+type metricFamily struct {
+	name, help string
+	labels     []string
+	samples    map[string]*metricSample
+}
+
+// add adds value to the sample with the label values
+func (m *metricFamily) add(value float64, labels ...string) {
+	key := strings.Join(labels, "\x00")
+	if m.samples[key] == nil {
+		m.samples[key] = &metricSample{labels: labels}
+	}
+	m.samples[key].value += value
+}
+
+// max sets the sample with the label values to value, if it is greater than its value
+func (m *metricFamily) max(value float64, labels ...string) {
+	key := strings.Join(labels, "\x00")
+	if s := m.samples[key]; s != nil && s.value >= value {
+		return
+	}
+	m.samples[key] = &metricSample{labels: labels, value: value}
+}
+
+// metricsEscaper escapes OpenMetrics label values
+var metricsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (m *metricFamily) write(w io.Writer) {
+	fmt.Fprintf(w, "# TYPE %s gauge\n# HELP %s %s\n", m.name, m.name, m.help)
+	var keys []string
+	for k := range m.samples {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := m.samples[k]
+		var labels []string
+		for i := range m.labels {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, m.labels[i],
+				metricsEscaper.Replace(s.labels[i])))
+		}
+		fmt.Fprintf(w, "%s{%s} %v\n", m.name, strings.Join(labels, ","), s.value)
+	}
+}
+
+func (w metricsWriter) Write(nodes []*yaml.RNode) error {
+	resources := &metricFamily{name: "kyaml_resources",
+		help:   "Number of Resources.",
+		labels: []string{"kind", "namespace"}, samples: map[string]*metricSample{}}
+	images := &metricFamily{name: "kyaml_images",
+		help:   "Number of containers using the image tag.",
+		labels: []string{"image", "tag"}, samples: map[string]*metricSample{}}
+	ages := &metricFamily{name: "kyaml_image_tag_age_seconds",
+		help:   "Time since the oldest Resource using the image tag was created.",
+		labels: []string{"image", "tag"}, samples: map[string]*metricSample{}}
+	failures := &metricFamily{name: "kyaml_validation_failures",
+		help:   "Number of schema validation failures.",
+		labels: []string{"kind", "namespace"}, samples: map[string]*metricSample{}}
+
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil {
+			return err
+		}
+		resources.add(1, meta.Kind, meta.Namespace)
+
+		var created time.Time
+		if f, err := nodes[i].Pipe(yaml.Lookup("metadata", "creationTimestamp")); err == nil && f != nil {
+			if created, err = time.Parse(time.RFC3339, f.YNode().Value); err != nil {
+				return fmt.Errorf("%s %s: invalid creationTimestamp '%s'",
+					meta.Kind, meta.Name, f.YNode().Value)
+			}
+		}
+		for _, podSpec := range findPodSpecs(nodes[i].YNode()) {
+			for _, field := range []string{"containers", "initContainers"} {
+				f := podSpec.Field(field)
+				if yaml.IsFieldEmpty(f) {
+					continue
+				}
+				for _, container := range f.Value.Content() {
+					image := yaml.NewRNode(container).Field("image")
+					if yaml.IsFieldEmpty(image) {
+						continue
+					}
+					name, tag := imageTag(image.Value.YNode().Value)
+					images.add(1, name, tag)
+					if !created.IsZero() {
+						ages.max(w.Now.Sub(created).Truncate(time.Second).Seconds(), name, tag)
+					}
+				}
+			}
+		}
+
+		if s := w.Schemas.Lookup(meta); s != nil {
+			if err := clearReaderAnnotations(nodes[i]); err != nil {
+				return err
+			}
+			if errs := openapi.Validate(nodes[i], s); len(errs) > 0 {
+				failures.add(float64(len(errs)), meta.Kind, meta.Namespace)
+			}
+		}
+	}
+
+	for _, m := range []*metricFamily{resources, images, ages, failures} {
+		m.write(w.Writer)
+	}
+	_, err := fmt.Fprintln(w.Writer, "# EOF")
+	return err
+}
+
+// imageTag returns the name and tag of an image -- the tag is latest if it is not set,
+// or the digest if the image is referenced by digest.
+func imageTag(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	// the registry host may contain a port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
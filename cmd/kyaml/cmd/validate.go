@@ -0,0 +1,157 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/cmd/kyaml/kubeschema"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// GetValidateRunner returns a command ValidateRunner.
+func GetValidateRunner() *ValidateRunner {
+	r := &ValidateRunner{}
+	c := &cobra.Command{
+		Use:   "validate [DIR]...",
+		Short: "Validate Resources against OpenAPI schemas",
+		Long: `Validate Resources from a local directory or stdin against OpenAPI schemas.
+
+Resources are validated against the built-in Kubernetes schemas, and the schemas
+of the CustomResourceDefinitions provided with --crd-schema.  Unknown fields and
+fields with the wrong type are reported with their file and line.  Resources
+without a known schema are not validated.
+
+  DIR:
+    Path to local directory.
+`,
+		Example: `# validate the Resources in a directory
+kyaml validate my-dir/
+
+# validate the Resources in a directory, including custom resources
+kyaml validate my-dir/ --crd-schema my-crd.json
+
+# validate kustomize output
+kustomize build | kyaml validate
+`,
+		RunE: r.runE,
+	}
+	c.Flags().BoolVar(&r.IncludeSubpackages, "include-subpackages", true,
+		"also validate resources from subpackages.")
+	c.Flags().StringSliceVar(&r.CRDSchemas, "crd-schema", []string{},
+		"path to a file containing CustomResourceDefinitions to validate against.")
+
+	r.Command = c
+	return r
+}
+
+func ValidateCommand() *cobra.Command {
+	return GetValidateRunner().Command
+}
+
+// ValidateRunner contains the run function
+type ValidateRunner struct {
+	IncludeSubpackages bool
+	CRDSchemas         []string
+	Command            *cobra.Command
+}
+
+func (r *ValidateRunner) runE(c *cobra.Command, args []string) error {
+	schemas, err := loadSchemas(r.CRDSchemas)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	var inputs []kio.Reader
+	for _, a := range args {
+		inputs = append(inputs, kio.LocalPackageReader{
+			PackagePath:        a,
+			IncludeSubpackages: r.IncludeSubpackages,
+		})
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, &kio.ByteReader{Reader: c.InOrStdin(), OmitReaderAnnotations: true})
+	}
+
+	count := 0
+	err = ConfigurePipeline(c, kio.Pipeline{
+		Inputs: inputs,
+		Outputs: []kio.Writer{kio.WriterFunc(func(nodes []*yaml.RNode) error {
+			for i := range nodes {
+				meta, err := nodes[i].GetMeta()
+				if err != nil {
+					return err
+				}
+				s := schemas.Lookup(meta)
+				if s == nil {
+					continue
+				}
+				path := meta.Annotations[kioutil.PathAnnotation]
+				if path == "" {
+					path = "stdin"
+				}
+				// don't validate the annotations set by the reader
+				if err := clearReaderAnnotations(nodes[i]); err != nil {
+					return err
+				}
+				errs := openapi.Validate(nodes[i], s)
+				openapi.SortErrors(errs)
+				for _, e := range errs {
+					fmt.Fprintf(c.OutOrStdout(), "%s:%d:%d: %s %s: %s: %s\n",
+						path, e.Line, e.Column, meta.Kind, meta.Name, e.Path, e.Message)
+				}
+				count += len(errs)
+			}
+			return nil
+		})},
+		ParseMode: kio.ParseModeFast,
+	}).Execute()
+	if err != nil {
+		return handleError(c, err)
+	}
+	if count > 0 {
+		return handleError(c, fmt.Errorf("found %d validation errors", count))
+	}
+	return nil
+}
+
+// clearReaderAnnotations clears the annotations set on the Resource by kio Readers
+func clearReaderAnnotations(node *yaml.RNode) error {
+	for _, a := range []string{
+		kioutil.IndexAnnotation, kioutil.PathAnnotation, kioutil.PackageAnnotation} {
+		if err := node.PipeE(yaml.ClearAnnotation(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSchemas returns the built-in Kubernetes schemas and the schemas of the CRDs
+// read from crdPaths.
+func loadSchemas(crdPaths []string) (openapi.Schemas, error) {
+	schemas := openapi.Schemas{}
+	for k, v := range kubeschema.Schemas() {
+		schemas[k] = v
+	}
+	for _, path := range crdPaths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		crds, err := (&kio.ByteReader{Reader: f, OmitReaderAnnotations: true}).Read()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := schemas.AddCRDs(crds...); err != nil {
+			return nil, err
+		}
+	}
+	return schemas, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+func TestValidateCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+
+	err = ioutil.WriteFile(filepath.Join(d, "f1.yaml"), []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+spec:
+  selector:
+    app: nginx
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: "3"
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7.9
+        imagePullPolicy: Always
+        imagePolicy: Always
+        resources:
+          limits:
+            cpu: 1
+            memory: 1Gi
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: foo
+spec:
+  size: large
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ioutil.WriteFile(filepath.Join(d, "crd.json"), []byte(`{
+  "apiVersion": "apiextensions.k8s.io/v1beta1",
+  "kind": "CustomResourceDefinition",
+  "metadata": {"name": "widgets.example.com"},
+  "spec": {
+    "group": "example.com",
+    "version": "v1",
+    "names": {"kind": "Widget"},
+    "validation": {
+      "openAPIV3Schema": {
+        "type": "object",
+        "properties": {
+          "spec": {"type": "object", "properties": {"size": {"type": "integer"}}}
+        }
+      }
+    }
+  }
+}
+`), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b := &bytes.Buffer{}
+	r := cmd.GetValidateRunner()
+	r.Command.SetArgs([]string{d, "--crd-schema", filepath.Join(d, "crd.json")})
+	r.Command.SetOut(b)
+	r.Command.SilenceUsage = true
+	r.Command.SilenceErrors = true
+	err = r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Equal(t, "found 3 validation errors", err.Error())
+	}
+	assert.Equal(t, `f1.yaml:14:13: Deployment foo: .spec.replicas: expected integer, found string
+f1.yaml:21:9: Deployment foo: .spec.template.spec.containers[0].imagePolicy: unknown field
+f1.yaml:32:9: Widget foo: .spec.size: expected integer, found string
+`, b.String())
+}
+
+func TestValidateCommand_valid(t *testing.T) {
+	b := &bytes.Buffer{}
+	r := cmd.GetValidateRunner()
+	r.Command.SetArgs([]string{})
+	r.Command.SetOut(b)
+	r.Command.SetIn(bytes.NewBufferString(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  labels:
+    app: nginx
+data:
+  a: b
+`))
+	assert.NoError(t, r.Command.Execute())
+	assert.Equal(t, "", b.String())
+}
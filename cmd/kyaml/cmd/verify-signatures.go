@@ -0,0 +1,141 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/cmd/kyaml/signature"
+)
+
+// GetVerifySignaturesRunner returns a command VerifySignaturesRunner.
+func GetVerifySignaturesRunner() *VerifySignaturesRunner {
+	r := &VerifySignaturesRunner{}
+	c := &cobra.Command{
+		Use:   "verify-signatures DIR...",
+		Short: "Verify the signatures of packages",
+		Long: `Verify the signatures of packages, so that their provenance is checked before they are
+processed.
+
+Packages are signed by signing their digest with ssh-keygen or cosign, and writing the
+signature to the package.  The digest is the sha256 of the sha256sum of the files of the
+package, excluding the signature file and .git directories, and is printed by
+'--print-digest'.
+
+ssh signatures must be made in the 'file' namespace -- ssh-keygen -Y sign -n file.  cosign
+signatures are made by cosign sign-blob.
+
+'--key' files contain the keys trusted to sign the packages, either as ssh authorized_keys
+lines or as PEM encoded public keys written by cosign.  A package is verified if it is signed
+by any of the keys.
+
+The cat and run-fns commands verify the packages before reading them with '--require-signed'.
+
+  DIR:
+    Path to local package directory.
+`,
+		Example: `# sign a package with ssh-keygen
+kyaml verify-signatures my-dir/ --print-digest > digest.txt
+ssh-keygen -Y sign -f ~/.ssh/id_ed25519 -n file digest.txt
+mv digest.txt.sig my-dir/package.sig
+
+# sign a package with cosign
+cosign sign-blob --key cosign.key digest.txt > my-dir/package.sig
+
+# verify the signature of a package
+kyaml verify-signatures my-dir/ --key ~/.ssh/id_ed25519.pub
+
+# only print the packages if they are signed
+kyaml cat my-dir/ --require-signed --key cosign.pub | kubectl apply -f -
+`,
+		RunE: r.runE,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.Flags().StringSliceVar(&r.Keys, "key", []string{},
+		"files containing the public keys trusted to sign the packages.")
+	c.Flags().StringVar(&r.SignatureFile, "signature-file", signature.DefaultSignatureFile,
+		"path of the signature file within the packages.")
+	c.Flags().BoolVar(&r.PrintDigest, "print-digest", false,
+		"print the digest of the packages to sign rather than verifying them.")
+	r.Command = c
+	return r
+}
+
+func VerifySignaturesCommand() *cobra.Command {
+	return GetVerifySignaturesRunner().Command
+}
+
+// VerifySignaturesRunner contains the run function
+type VerifySignaturesRunner struct {
+	Keys          []string
+	SignatureFile string
+	PrintDigest   bool
+	Command       *cobra.Command
+}
+
+func (r *VerifySignaturesRunner) runE(c *cobra.Command, args []string) error {
+	if r.PrintDigest {
+		for _, a := range args {
+			digest, err := signature.Digest(a, r.SignatureFile)
+			if err != nil {
+				return handleError(c, err)
+			}
+			fmt.Fprintf(c.OutOrStdout(), "%s", signature.Payload(digest))
+		}
+		return nil
+	}
+
+	keys, err := readSignatureKeys(r.Keys)
+	if err != nil {
+		return handleError(c, err)
+	}
+	for _, a := range args {
+		digest, key, err := signature.VerifyPackage(a, r.SignatureFile, keys)
+		if err != nil {
+			return handleError(c, err)
+		}
+		fmt.Fprintf(c.OutOrStdout(), "%s: %s signed by %s\n", a, digest, key.Name)
+	}
+	return nil
+}
+
+// readSignatureKeys reads the trusted keys from the files
+func readSignatureKeys(files []string) ([]signature.Key, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("must specify --key to verify signatures")
+	}
+	var keys []signature.Key
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		k, err := signature.ParseKeys(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+		keys = append(keys, k...)
+	}
+	return keys, nil
+}
+
+// requireSigned verifies the signatures of the package directories with the keys read from
+// the files, for the commands with --require-signed
+func requireSigned(dirs []string, files []string) error {
+	if len(dirs) == 0 {
+		return fmt.Errorf("--require-signed requires package directories rather than stdin")
+	}
+	keys, err := readSignatureKeys(files)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		if _, _, err := signature.VerifyPackage(d, signature.DefaultSignatureFile, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
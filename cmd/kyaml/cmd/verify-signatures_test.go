@@ -0,0 +1,137 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
+)
+
+// signedPackage is signed by signedPackageKey with ssh-keygen -Y sign -n file
+var signedPackage = map[string]string{
+	"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`,
+	filepath.Join("sub", "service.yaml"): `apiVersion: v1
+kind: Service
+metadata:
+  name: app
+`,
+	"package.sig": `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAg1DTI/zPYsDAcXiLSN1Dek79tgD
+cQ2BlUWj5WMjRj0RwAAAAEZmlsZQAAAAAAAAAGc2hhNTEyAAAAUwAAAAtzc2gtZWQyNTUx
+OQAAAEClWVZsU/FBach8pB5gyFZC5JocKpN2n/i6PgqomqBX4xJLG8VLX9ZcMRnNT6BUSd
+jDKX7wmOv8eqT7kdnhBTIC
+-----END SSH SIGNATURE-----
+`,
+}
+
+const signedPackageKey = `ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINQ0yP8z2LAwHF4i0jdQ3pO/bYA3ENgZVFo+VjI0Y9Ec test
+`
+
+// writeSignedPackage writes the signed package and the key, returning their paths
+func writeSignedPackage(t *testing.T) (string, string, func()) {
+	d, err := ioutil.TempDir("", "kyaml-verify-signatures-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	files := map[string]string{"key.pub": signedPackageKey}
+	for path, value := range signedPackage {
+		files[filepath.Join("pkg", path)] = value
+	}
+	for path, value := range files {
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(d, path)), 0700)) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, path), []byte(value), 0600)) {
+			t.FailNow()
+		}
+	}
+	return filepath.Join(d, "pkg"), filepath.Join(d, "key.pub"), func() { os.RemoveAll(d) }
+}
+
+func TestVerifySignaturesCommand(t *testing.T) {
+	pkg, key, clean := writeSignedPackage(t)
+	defer clean()
+
+	b := &bytes.Buffer{}
+	r := cmd.GetVerifySignaturesRunner()
+	r.Command.SetArgs([]string{pkg, "--print-digest"})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t,
+		"sha256:197b300cf10d0ef58b16aaffdd6640848e56ec0f5efbd6ccfd2ca505106541fe\n", b.String())
+
+	b.Reset()
+	r = cmd.GetVerifySignaturesRunner()
+	r.Command.SetArgs([]string{pkg, "--key", key})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Equal(t, pkg+": sha256:197b300cf10d0ef58b16aaffdd6640848e56ec0f5efbd6ccfd2ca505106541fe "+
+		"signed by SHA256:yCKCBlOry1IhvCvgVUtYGFCOwoSTuJ+bfA2JAswOZ+w\n", b.String())
+
+	// modifying the package invalidates its signature
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(pkg, "deployment.yaml"), []byte("a: b\n"), 0600)) {
+		return
+	}
+	r = cmd.GetVerifySignaturesRunner()
+	r.Command.SetArgs([]string{pkg, "--key", key})
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetErr(&bytes.Buffer{})
+	err := r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid ssh signature")
+	}
+}
+
+func TestCatCommand_requireSigned(t *testing.T) {
+	pkg, key, clean := writeSignedPackage(t)
+	defer clean()
+
+	b := &bytes.Buffer{}
+	r := cmd.GetCatRunner()
+	r.Command.SetArgs([]string{pkg, "--require-signed", "--key", key})
+	r.Command.SetOut(b)
+	if !assert.NoError(t, r.Command.Execute()) {
+		return
+	}
+	assert.Contains(t, b.String(), "kind: Deployment")
+
+	// the keys are required
+	r = cmd.GetCatRunner()
+	r.Command.SetArgs([]string{pkg, "--require-signed"})
+	r.Command.SetOut(&bytes.Buffer{})
+	r.Command.SetErr(&bytes.Buffer{})
+	err := r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "must specify --key to verify signatures")
+	}
+
+	// unsigned packages aren't read
+	if !assert.NoError(t, os.Remove(filepath.Join(pkg, "package.sig"))) {
+		return
+	}
+	b.Reset()
+	r = cmd.GetCatRunner()
+	r.Command.SetArgs([]string{pkg, "--require-signed", "--key", key})
+	r.Command.SetOut(b)
+	r.Command.SetErr(&bytes.Buffer{})
+	err = r.Command.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "is not signed: package.sig not found")
+	}
+	assert.NotContains(t, b.String(), "kind: Deployment")
+}
@@ -5,8 +5,9 @@ go 1.13
 require (
 	github.com/go-errors/errors v1.0.1
 	github.com/spf13/cobra v0.0.5
-	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.4.0
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
 	sigs.k8s.io/kustomize/kyaml v0.0.0
 	sigs.k8s.io/kustomize/pseudo/k8s v0.0.0
 )
@@ -0,0 +1,183 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kubeschema generates OpenAPI schemas for the built-in Kubernetes types.
+package kubeschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/pseudo/k8s/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/kustomize/pseudo/k8s/client-go/kubernetes/scheme"
+)
+
+var (
+	once    sync.Once
+	schemas openapi.Schemas
+)
+
+// Schemas returns the schemas for the Kubernetes types known to client-go.
+// The schemas are generated from the go types on first use.
+func Schemas() openapi.Schemas {
+	once.Do(func() {
+		g := generator{types: map[reflect.Type]*openapi.Schema{}}
+		schemas = openapi.Schemas{}
+		for gvk, t := range scheme.Scheme.AllKnownTypes() {
+			s := g.schema(t)
+			s.Scope = openapi.ScopeNamespaced
+			if clusterScopedKinds[gvk.Kind] {
+				s.Scope = openapi.ScopeCluster
+			}
+			schemas[openapi.GroupVersionKind{
+				Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}] = s
+		}
+	})
+	return schemas
+}
+
+// clusterScopedKinds are the built-in kinds whose Resources are not in namespaces
+var clusterScopedKinds = map[string]bool{
+	"APIService":                     true,
+	"AuditSink":                      true,
+	"CertificateSigningRequest":      true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"ComponentStatus":                true,
+	"CSIDriver":                      true,
+	"CSINode":                        true,
+	"CustomResourceDefinition":       true,
+	"MutatingWebhookConfiguration":   true,
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"PodSecurityPolicy":              true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"SelfSubjectAccessReview":        true,
+	"SelfSubjectRulesReview":         true,
+	"StorageClass":                   true,
+	"SubjectAccessReview":            true,
+	"TokenReview":                    true,
+	"ValidatingWebhookConfiguration": true,
+	"VolumeAttachment":               true,
+}
+
+var (
+	marshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+)
+
+// swaggerDoc is implemented by the Kubernetes types with generated documentation.  The
+// documentation of the type is keyed by "", and of its fields by their json names.
+type swaggerDoc interface {
+	SwaggerDoc() map[string]string
+}
+
+// docs returns the documentation of the type t and its fields
+func docs(t reflect.Type) map[string]string {
+	if d, ok := reflect.New(t).Interface().(swaggerDoc); ok {
+		return d.SwaggerDoc()
+	}
+	return nil
+}
+
+// generator generates schemas from go types using their json tags
+type generator struct {
+	// types caches the schemas for types -- also allows for recursive types
+	types map[reflect.Type]*openapi.Schema
+}
+
+func (g generator) schema(t reflect.Type) *openapi.Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, found := g.types[t]; found {
+		return s
+	}
+	s := &openapi.Schema{}
+	g.types[t] = s
+
+	if t == intOrStringType {
+		s.IntOrString = true
+		return s
+	}
+	if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+		// custom serialization -- e.g. Time and Quantity -- accept any value
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s.Type = "object"
+		s.Description = docs(t)[""]
+		s.Properties = map[string]*openapi.Schema{}
+		g.properties(t, s.Properties)
+	case reflect.Map:
+		s.Type = "object"
+		s.AdditionalProperties = g.schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is serialized as a base64 string
+			s.Type = "string"
+			return s
+		}
+		s.Type = "array"
+		s.Items = g.schema(t.Elem())
+	case reflect.String:
+		s.Type = "string"
+	case reflect.Bool:
+		s.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		s.Type = "number"
+	}
+	return s
+}
+
+// properties adds the json fields of the struct t to properties
+func (g generator) properties(t reflect.Type, properties map[string]*openapi.Schema) {
+	doc := docs(t)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		inline := false
+		for _, o := range tag[1:] {
+			inline = inline || o == "inline"
+		}
+		if inline || (f.Anonymous && name == "") {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.properties(ft, properties)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = g.schema(f.Type)
+		if d := doc[name]; d != "" {
+			// schemas are shared between the fields of a type, so copy the schema
+			// to document the field
+			fs := *properties[name]
+			fs.Description = d
+			properties[name] = &fs
+		}
+	}
+}
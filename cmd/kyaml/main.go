@@ -4,7 +4,9 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/cmd/kyaml/cmd"
@@ -22,20 +24,68 @@ var root = &cobra.Command{
 }
 
 func main() {
-	root.PersistentFlags().BoolVar(&cmd.StackOnError, "stack-trace", false,
-		"print a stack-trace on failure")
+	cmd.AddGlobalFlags(root)
 
 	cmd.ExitOnError = true
 	root.AddCommand(cmd.GrepCommand())
 	root.AddCommand(cmd.TreeCommand())
+	root.AddCommand(cmd.AffinityCommand())
+	root.AddCommand(cmd.BrowseCommand())
+	root.AddCommand(cmd.BundleCommand())
 	root.AddCommand(cmd.CatCommand())
+	root.AddCommand(cmd.CheckCommand())
+	root.AddCommand(cmd.CompletionCommand())
 	root.AddCommand(cmd.FmtCommand())
+	root.AddCommand(cmd.FreezeCommand())
 	root.AddCommand(cmd.MergeCommand())
+	root.AddCommand(cmd.PruneCommand())
+	root.AddCommand(cmd.ConformanceCommand())
+	root.AddCommand(cmd.ConvertCommand())
 	root.AddCommand(cmd.CountCommand())
+	root.AddCommand(cmd.DiffCommand())
+	root.AddCommand(cmd.DedupeCommand())
+	root.AddCommand(cmd.DoctorCommand())
+	root.AddCommand(cmd.ExplainCommand())
+	root.AddCommand(cmd.FilterCommand())
+	root.AddCommand(cmd.GenerateCommand())
+	root.AddCommand(cmd.GraphCommand())
+	root.AddCommand(cmd.HashCommand())
+	root.AddCommand(cmd.InitCommand())
+	root.AddCommand(cmd.LabelCommand())
+	root.AddCommand(cmd.LintCommand())
+	root.AddCommand(cmd.MigrateCommand())
+	root.AddCommand(cmd.OwnershipCommand())
+	root.AddCommand(cmd.PatchCommand())
+	root.AddCommand(cmd.PluginCommand())
+	root.AddCommand(cmd.RedactCommand())
+	root.AddCommand(cmd.RenameCommand())
+	root.AddCommand(cmd.ResolveCommand())
+	root.AddCommand(cmd.RunCommand())
 	root.AddCommand(cmd.RunFnCommand())
+	root.AddCommand(cmd.SearchCommand())
+	root.AddCommand(cmd.SetCommand())
+	root.AddCommand(cmd.SetFieldCommand())
+	root.AddCommand(cmd.SortCommand())
+	root.AddCommand(cmd.SplitCommand())
+	root.AddCommand(cmd.StatsCommand())
+	root.AddCommand(cmd.StripCommand())
+	root.AddCommand(cmd.ValidateCommand())
+	root.AddCommand(cmd.VerifySignaturesCommand())
 	root.AddCommand(&cobra.Command{Use: "merge", Long: merge2.Help})
 	root.AddCommand(&cobra.Command{Use: "merge3", Long: merge3.Help})
 
+	// run the kyaml-NAME plugin on the PATH for 'kyaml NAME' if there is no NAME command
+	if found, err := cmd.RunPlugin(root, os.Args[1:]); found {
+		if err, ok := err.(*exec.ExitError); ok {
+			os.Exit(err.ExitCode())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if err := root.Execute(); err != nil {
 		os.Exit(1)
 	}
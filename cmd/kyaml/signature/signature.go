@@ -0,0 +1,285 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package signature verifies the provenance of packages from signatures over their
+// contents.
+//
+// Packages are signed by signing their digest -- as printed by Digest -- with either
+// ssh-keygen or cosign, and writing the signature to the package:
+//
+//	kyaml verify-signatures my-dir/ --print-digest > digest.txt
+//	ssh-keygen -Y sign -f ~/.ssh/id_ed25519 -n file digest.txt
+//	mv digest.txt.sig my-dir/package.sig
+//
+//	cosign sign-blob --key cosign.key digest.txt > my-dir/package.sig
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultSignatureFile is the name of the signature file within a package
+const DefaultSignatureFile = "package.sig"
+
+// SSHNamespace is the namespace of ssh signatures -- ssh-keygen -Y sign -n file
+const SSHNamespace = "file"
+
+// sshSignatureArmor are the lines armoring ssh signatures
+const (
+	sshSignatureBegin = "-----BEGIN SSH SIGNATURE-----"
+	sshSignatureEnd   = "-----END SSH SIGNATURE-----"
+)
+
+// Digest returns the canonical digest of the package directory, excluding the signature file
+// at its root and .git directories.  The digest is the sha256 of the sorted lines
+// "<sha256 of the file>  <slash-separated path of the file>", as printed by sha256sum.
+func Digest(dir, signatureFile string) (string, error) {
+	var lines []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case info.IsDir() && info.Name() == ".git":
+			return filepath.SkipDir
+		case info.IsDir() || rel == signatureFile:
+			return nil
+		case !info.Mode().IsRegular():
+			return fmt.Errorf("cannot digest %s: not a regular file", rel)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(b)
+		lines = append(lines, fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), rel))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "")))
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Payload returns the bytes signed for the digest -- the digest followed by a newline
+func Payload(digest string) []byte {
+	return []byte(digest + "\n")
+}
+
+// Key is a public key trusted to sign packages
+type Key struct {
+	// Name identifies the key in errors and results -- e.g. its fingerprint
+	Name string
+
+	ssh    ssh.PublicKey
+	crypto crypto.PublicKey
+}
+
+// ParseKeys parses the public keys in b -- either PEM encoded PUBLIC KEY blocks, as written
+// by cosign, or ssh authorized_keys lines.
+func ParseKeys(b []byte) ([]Key, error) {
+	var keys []Key
+	if bytes.Contains(b, []byte("-----BEGIN")) {
+		for {
+			var block *pem.Block
+			block, b = pem.Decode(b)
+			if block == nil {
+				break
+			}
+			if block.Type != "PUBLIC KEY" {
+				return nil, fmt.Errorf("unsupported PEM block %s", block.Type)
+			}
+			k, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			sum := sha256.Sum256(block.Bytes)
+			keys = append(keys, Key{
+				Name:   "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]),
+				crypto: k,
+			})
+		}
+		return keys, nil
+	}
+
+	for len(bytes.TrimSpace(b)) > 0 {
+		k, _, _, rest, err := ssh.ParseAuthorizedKey(b)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, Key{Name: ssh.FingerprintSHA256(k), ssh: k})
+		b = rest
+	}
+	return keys, nil
+}
+
+// Verify verifies that the signature over payload was made by one of the keys, and returns
+// the key.  The signature may be an armored ssh signature in the namespace SSHNamespace,
+// or a base64 encoded cosign signature.
+func Verify(payload, signature []byte, keys []Key) (Key, error) {
+	if len(keys) == 0 {
+		return Key{}, fmt.Errorf("no keys to verify the signature with")
+	}
+	signature = bytes.TrimSpace(signature)
+	if bytes.HasPrefix(signature, []byte(sshSignatureBegin)) {
+		return verifySSH(payload, signature, keys)
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(signature))
+	if err != nil {
+		return Key{}, fmt.Errorf("signature is neither an ssh signature nor base64 encoded")
+	}
+	for _, k := range keys {
+		if k.crypto != nil && verifyCrypto(k.crypto, payload, sig) {
+			return k, nil
+		}
+	}
+	return Key{}, fmt.Errorf("signature not made by any of the keys")
+}
+
+// verifyCrypto returns true if sig is a cosign signature over payload made by the key
+func verifyCrypto(key crypto.PublicKey, payload, sig []byte) bool {
+	sum := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		var rs struct{ R, S *big.Int }
+		if rest, err := asn1.Unmarshal(sig, &rs); err != nil || len(rest) > 0 {
+			return false
+		}
+		return ecdsa.Verify(k, sum[:], rs.R, rs.S)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, sig)
+	default:
+		return false
+	}
+}
+
+// sshSignature is the wire format of ssh signatures, documented by PROTOCOL.sshsig
+type sshSignature struct {
+	Magic         [6]byte
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data signed by ssh signatures
+type sshSignedData struct {
+	Magic         [6]byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+var sshMagic = [6]byte{'S', 'S', 'H', 'S', 'I', 'G'}
+
+// verifySSH verifies an armored ssh signature over payload
+func verifySSH(payload, armored []byte, keys []Key) (Key, error) {
+	body := strings.TrimSuffix(strings.TrimPrefix(string(armored), sshSignatureBegin), sshSignatureEnd)
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid ssh signature: %v", err)
+	}
+	sig := sshSignature{}
+	if err := ssh.Unmarshal(b, &sig); err != nil {
+		return Key{}, fmt.Errorf("invalid ssh signature: %v", err)
+	}
+	if sig.Magic != sshMagic || sig.Version != 1 {
+		return Key{}, fmt.Errorf("invalid ssh signature: unsupported version")
+	}
+	if sig.Namespace != SSHNamespace {
+		return Key{}, fmt.Errorf("ssh signature namespace must be %s: %s", SSHNamespace, sig.Namespace)
+	}
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return Key{}, fmt.Errorf("unsupported ssh signature hash %s", sig.HashAlgorithm)
+	}
+	h.Write(payload)
+	signed := ssh.Marshal(sshSignedData{
+		Magic:         sshMagic,
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})
+	s := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, s); err != nil {
+		return Key{}, fmt.Errorf("invalid ssh signature: %v", err)
+	}
+
+	for _, k := range keys {
+		if k.ssh == nil || !bytes.Equal(k.ssh.Marshal(), sig.PublicKey) {
+			continue
+		}
+		if err := k.ssh.Verify(signed, s); err != nil {
+			return Key{}, fmt.Errorf("invalid ssh signature by %s: %v", k.Name, err)
+		}
+		return k, nil
+	}
+	return Key{}, fmt.Errorf("signature not made by any of the keys")
+}
+
+// VerifyPackage verifies the signature of the package directory, read from signatureFile
+// within it, and returns the digest of the package and the key which signed it.
+func VerifyPackage(dir, signatureFile string, keys []Key) (string, Key, error) {
+	if signatureFile == "" {
+		signatureFile = DefaultSignatureFile
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return "", Key{}, err
+	} else if !info.IsDir() {
+		return "", Key{}, fmt.Errorf("%s is not a package directory", dir)
+	}
+	signature, err := ioutil.ReadFile(filepath.Join(dir, signatureFile))
+	if os.IsNotExist(err) {
+		return "", Key{}, fmt.Errorf("%s is not signed: %s not found", dir, signatureFile)
+	} else if err != nil {
+		return "", Key{}, err
+	}
+	digest, err := Digest(dir, signatureFile)
+	if err != nil {
+		return "", Key{}, err
+	}
+	key, err := Verify(Payload(digest), signature, keys)
+	if err != nil {
+		return "", Key{}, fmt.Errorf("%s: %v", dir, err)
+	}
+	return digest, key, nil
+}
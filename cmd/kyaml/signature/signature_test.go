@@ -0,0 +1,197 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/cmd/kyaml/signature"
+)
+
+// sshKey signed sshSignature over the digest of the package written by writePackage, with
+// ssh-keygen -Y sign -n file
+const sshKey = `ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINQ0yP8z2LAwHF4i0jdQ3pO/bYA3ENgZVFo+VjI0Y9Ec test
+`
+
+const otherSSHKey = `ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJz3/e1gakJ1XqX7Y0k/hxpJLR6avFoyq8WV5lGXYO4W other
+`
+
+const sshSignature = `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAg1DTI/zPYsDAcXiLSN1Dek79tgD
+cQ2BlUWj5WMjRj0RwAAAAEZmlsZQAAAAAAAAAGc2hhNTEyAAAAUwAAAAtzc2gtZWQyNTUx
+OQAAAEClWVZsU/FBach8pB5gyFZC5JocKpN2n/i6PgqomqBX4xJLG8VLX9ZcMRnNT6BUSd
+jDKX7wmOv8eqT7kdnhBTIC
+-----END SSH SIGNATURE-----
+`
+
+// sshGitSignature signs the same digest in the git namespace
+const sshGitSignature = `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAg1DTI/zPYsDAcXiLSN1Dek79tgD
+cQ2BlUWj5WMjRj0RwAAAADZ2l0AAAAAAAAAAZzaGE1MTIAAABTAAAAC3NzaC1lZDI1NTE5
+AAAAQGB3lEpSpCxZJeDJI+RvdeZjQuvqVBev+Mw37G4eH87M4OP8v0eQ+t+6KXdWETVLFb
++N9akLq0p7IhyeBP0VbgM=
+-----END SSH SIGNATURE-----
+`
+
+const packageDigest = "sha256:197b300cf10d0ef58b16aaffdd6640848e56ec0f5efbd6ccfd2ca505106541fe"
+
+// writePackage writes the package signed by sshSignature, and returns its directory
+func writePackage(t *testing.T) string {
+	d, err := ioutil.TempDir("", "kyaml-signature-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for path, value := range map[string]string{
+		"deployment.yaml":                    "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n",
+		filepath.Join("sub", "service.yaml"): "apiVersion: v1\nkind: Service\nmetadata:\n  name: app\n",
+		filepath.Join(".git", "HEAD"):        "ref: refs/heads/master\n",
+		DefaultSignatureFile:                 sshSignature,
+	} {
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(d, path)), 0700)) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, path), []byte(value), 0600)) {
+			t.FailNow()
+		}
+	}
+	return d
+}
+
+func TestDigest(t *testing.T) {
+	d := writePackage(t)
+	defer os.RemoveAll(d)
+
+	digest, err := Digest(d, DefaultSignatureFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, packageDigest, digest)
+
+	// changing a file changes the digest
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "deployment.yaml"), []byte("a: b\n"), 0600)) {
+		return
+	}
+	digest, err = Digest(d, DefaultSignatureFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEqual(t, packageDigest, digest)
+}
+
+func TestVerifyPackage_ssh(t *testing.T) {
+	d := writePackage(t)
+	defer os.RemoveAll(d)
+
+	keys, err := ParseKeys([]byte(otherSSHKey + sshKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+	digest, key, err := VerifyPackage(d, "", keys)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, packageDigest, digest)
+	assert.Equal(t, "SHA256:yCKCBlOry1IhvCvgVUtYGFCOwoSTuJ+bfA2JAswOZ+w", key.Name)
+
+	// the signature must be made by one of the keys
+	keys, err = ParseKeys([]byte(otherSSHKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, _, err = VerifyPackage(d, "", keys)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "signature not made by any of the keys")
+	}
+
+	// the signature must be made over the package
+	keys, err = ParseKeys([]byte(sshKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "extra.yaml"), []byte("a: b\n"), 0600)) {
+		return
+	}
+	_, _, err = VerifyPackage(d, "", keys)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid ssh signature by SHA256:")
+	}
+}
+
+func TestVerify_sshNamespace(t *testing.T) {
+	keys, err := ParseKeys([]byte(sshKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = Verify(Payload(packageDigest), []byte(sshGitSignature), keys)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "ssh signature namespace must be file: git")
+	}
+}
+
+func TestVerify_cosign(t *testing.T) {
+	// cosign sign-blob signs the sha256 of the blob with an ecdsa P-256 key
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if !assert.NoError(t, err) {
+		return
+	}
+	keys, err := ParseKeys(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if !assert.NoError(t, err) || !assert.Len(t, keys, 1) {
+		return
+	}
+	sum := sha256.Sum256(Payload(packageDigest))
+	r, s, err := ecdsa.Sign(rand.Reader, private, sum[:])
+	if !assert.NoError(t, err) {
+		return
+	}
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if !assert.NoError(t, err) {
+		return
+	}
+	encoded := base64.StdEncoding.EncodeToString(sig) + "\n"
+
+	key, err := Verify(Payload(packageDigest), []byte(encoded), keys)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, keys[0].Name, key.Name)
+
+	_, err = Verify(Payload("sha256:other"), []byte(encoded), keys)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "signature not made by any of the keys")
+	}
+	_, err = Verify(Payload(packageDigest), []byte("not a signature"), keys)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "signature is neither an ssh signature nor base64 encoded")
+	}
+}
+
+func TestVerifyPackage_unsigned(t *testing.T) {
+	d := writePackage(t)
+	defer os.RemoveAll(d)
+	keys, err := ParseKeys([]byte(sshKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, _, err = VerifyPackage(d, "missing.sig", keys)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "is not signed: missing.sig not found")
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -9,19 +10,39 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/gomodule/redigo/redis"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 
+	"sigs.k8s.io/kustomize/hack/crawl/crawler/github"
+	"sigs.k8s.io/kustomize/hack/crawl/httpclient"
 	"sigs.k8s.io/kustomize/hack/crawl/index"
 )
 
+const (
+	// How often the ranking configuration is reloaded from elasticsearch.
+	rankingReloadInterval = 30 * time.Second
+)
+
 type kustomizeSearch struct {
 	ctx context.Context
 	// Eventually pIndex *index.PlugginIndex
 	idx    *index.KustomizeIndex
 	router *mux.Router
 	log    *log.Logger
+
+	rankingMu sync.RWMutex
+	ranking   *index.RankingConfig
+
+	// Bearer token authenticating /purge requests. Purging is disabled if
+	// it is empty.
+	purgeToken string
+	// URL of the redis http cache of the crawlers, from which the purged
+	// responses are deleted.
+	cacheURL string
 }
 
 // New server. Creating a server does not launch it. To launch simply:
@@ -35,14 +56,29 @@ type kustomizeSearch struct {
 //
 // /search: processes the ?q= parameter for a text query and
 // returns a list of 10 resutls starting from the ?from= value provided,
-// with the default being zero.
+// with the default being zero. The breakdowns of the results by kind, and by
+// components and composition depth, are omitted with ?nokinds and
+// ?nocomposition respectively. The results are restricted to the documents
+// written in a language with ?lang=, e.g. ?lang=es.
 //
 // /metrics: returns overall metrics about the files indexed. Returns
 // timeseries data for kustomization files, and returns breakdown of file
-// counts by their 'kind' fields
+// counts by their 'kind' fields, by the components they use, and by their
+// composition depth.
 //
 // /register: not implemented, but meant as an endpoint for adding new
 // kustomization files to the corpus.
+//
+// /purge: removes the documents of the ?repository= or ?owner= provided
+// (e.g. github.com/owner/repo or github.com/owner), their links from the
+// other documents, and the crawlers' cached http responses for them, to honor
+// takedown and opt-out requests. Each purge is recorded in an audit index
+// along with the ?requester= and ?reason= provided. Requests must be
+// authenticated with the bearer token set in $PURGE_TOKEN, and purging is
+// disabled if it is not set. The http cache is reached at $REDIS_CACHE_URL.
+//
+// Search results are ranked according to the ranking configuration stored in
+// elasticsearch, which is reloaded periodically while the server is running.
 func NewKustomizeSearch(ctx context.Context) (*kustomizeSearch, error) {
 	idx, err := index.NewKustomizeIndex(ctx)
 	if err != nil {
@@ -55,11 +91,50 @@ func NewKustomizeSearch(ctx context.Context) (*kustomizeSearch, error) {
 		router: mux.NewRouter(),
 		log: log.New(os.Stdout, "Kustomize server: ",
 			log.LstdFlags|log.Llongfile|log.LUTC),
+		ranking:    index.DefaultRankingConfig(),
+		purgeToken: os.Getenv("PURGE_TOKEN"),
+		cacheURL:   os.Getenv("REDIS_CACHE_URL"),
 	}
 
 	return ks, nil
 }
 
+// Fetch the ranking configuration from elasticsearch. On failure, the
+// previously loaded configuration is kept.
+func (ks *kustomizeSearch) reloadRanking() error {
+	rc, err := ks.idx.GetRankingConfig()
+	if err != nil {
+		return err
+	}
+
+	ks.rankingMu.Lock()
+	defer ks.rankingMu.Unlock()
+	ks.ranking = rc
+	return nil
+}
+
+// Reload the ranking configuration every interval until the context is done.
+func (ks *kustomizeSearch) watchRanking(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ks.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.reloadRanking(); err != nil {
+				ks.log.Println("Error reloading ranking config: ", err)
+			}
+		}
+	}
+}
+
+func (ks *kustomizeSearch) rankingConfig() *index.RankingConfig {
+	ks.rankingMu.RLock()
+	defer ks.rankingMu.RUnlock()
+	return ks.ranking
+}
+
 // Set up common middleware and the routes for the server.
 func (ks *kustomizeSearch) routes() {
 
@@ -76,10 +151,16 @@ func (ks *kustomizeSearch) routes() {
 	ks.router.HandleFunc("/search", ks.search()).Methods(http.MethodGet)
 	ks.router.HandleFunc("/metrics", ks.metrics()).Methods(http.MethodGet)
 	ks.router.HandleFunc("/register", ks.register()).Methods(http.MethodPost)
+	ks.router.HandleFunc("/purge", ks.purge()).Methods(http.MethodPost)
 }
 
 // Start listening and serving on the provided port.
 func (ks *kustomizeSearch) Serve(port int) error {
+	if err := ks.reloadRanking(); err != nil {
+		ks.log.Println("Error loading ranking config, using defaults: ", err)
+	}
+	go ks.watchRanking(rankingReloadInterval)
+
 	ks.routes()
 	handler := cors.Default().Handler(ks.router)
 	s := &http.Server{
@@ -120,6 +201,101 @@ func (ks *kustomizeSearch) register() http.HandlerFunc {
 	}
 }
 
+// Check the bearer token of a /purge request.
+func (ks *kustomizeSearch) authorizedPurge(r *http.Request) bool {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+	token := []byte(strings.TrimPrefix(auth, prefix))
+	return subtle.ConstantTimeCompare(token, []byte(ks.purgeToken)) == 1
+}
+
+// Delete the crawlers' cached http responses for the target.
+func (ks *kustomizeSearch) purgeCache(target string) (int, error) {
+	const githubHost = "github.com/"
+	if ks.cacheURL == "" || !strings.HasPrefix(target, githubHost) {
+		return 0, nil
+	}
+	conn, err := redis.DialURL(ks.cacheURL)
+	if err != nil {
+		return 0, fmt.Errorf("could not connect to the http cache: %v", err)
+	}
+	defer conn.Close()
+
+	return httpclient.PurgeCache(conn,
+		github.CacheURLPatterns(strings.TrimPrefix(target, githubHost))...)
+}
+
+// /purge endpoint.
+func (ks *kustomizeSearch) purge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ks.purgeToken == "" {
+			http.Error(w, `{ "error": "purging is not enabled" }`,
+				http.StatusForbidden)
+			return
+		}
+		if !ks.authorizedPurge(r) {
+			http.Error(w, `{ "error": "unauthorized" }`,
+				http.StatusUnauthorized)
+			return
+		}
+
+		values := r.URL.Query()
+		repository, owner := values.Get("repository"), values.Get("owner")
+		if (repository == "") == (owner == "") {
+			http.Error(w, `{ "error": "must specify one of repository or owner" }`,
+				http.StatusBadRequest)
+			return
+		}
+		target, err := index.PurgeTarget(repository + owner)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{ "error": %q }`, err.Error()),
+				http.StatusBadRequest)
+			return
+		}
+
+		record := &index.PurgeRecord{
+			Target:    target,
+			Requester: values.Get("requester"),
+			Reason:    values.Get("reason"),
+			Time:      time.Now().UTC(),
+		}
+		ks.log.Printf("Purging %s for %q: %q\n",
+			target, record.Requester, record.Reason)
+		record.Documents, record.References, err = ks.idx.Purge(target)
+		if err == nil {
+			record.CacheEntries, err = ks.purgeCache(target)
+		}
+		if err != nil {
+			ks.log.Println("Error: ", err)
+			record.Error = err.Error()
+		}
+
+		// Record the purge even if it failed, so that it can be retried.
+		if _, auditErr := ks.idx.PutPurgeRecord(record); auditErr != nil {
+			ks.log.Println("Error: ", auditErr)
+			http.Error(w, `{ "error": "could not record the purge" }`,
+				http.StatusInternalServerError)
+			return
+		}
+		if err != nil {
+			http.Error(w, `{ "error": "could not complete the purge" }`,
+				http.StatusInternalServerError)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		setIndent(enc)
+		if err := enc.Encode(record); err != nil {
+			http.Error(w, `{ "error": "failed to send back results" }`,
+				http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
 // /search endpoint.
 func (ks *kustomizeSearch) search() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -137,13 +313,22 @@ func (ks *kustomizeSearch) search() http.HandlerFunc {
 			}
 		}
 		_, noKinds := values["nokinds"]
+		_, noComposition := values["nocomposition"]
+		var language string
+		if langParam := values["lang"]; len(langParam) > 0 {
+			language = langParam[0]
+		}
 
 		opt := index.KustomizeSearchOptions{
 			SearchOptions: index.SearchOptions{
 				Size: 10,
 				From: from,
 			},
-			KindAggregation: !noKinds,
+			KindAggregation:             !noKinds,
+			ComponentAggregation:        !noComposition,
+			CompositionDepthAggregation: !noComposition,
+			Ranking:                     ks.rankingConfig(),
+			Language:                    language,
 		}
 
 		results, err := ks.idx.Search(strings.Join(queries, " "), opt)
@@ -170,8 +355,10 @@ func (ks *kustomizeSearch) search() http.HandlerFunc {
 func (ks *kustomizeSearch) metrics() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		res, err := ks.idx.Search("", index.KustomizeSearchOptions{
-			KindAggregation:       true,
-			TimeseriesAggregation: true,
+			KindAggregation:             true,
+			TimeseriesAggregation:       true,
+			ComponentAggregation:        true,
+			CompositionDepthAggregation: true,
 		})
 		if err != nil {
 			http.Error(w, `{ "error": "could not perform the search."}`,
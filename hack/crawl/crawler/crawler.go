@@ -55,6 +55,9 @@ func CrawlFromSeed(ctx context.Context, seed CrawlSeed,
 	crawlers []Crawler, conv Converter, indx IndexFunc) {
 
 	seen := make(map[string]struct{})
+	// IDs of the kustomizations referencing each document. Documents that
+	// were already indexed pick up new links the next time they are indexed.
+	referrers := make(map[string][]string)
 
 	logIfErr := func(err error) {
 		if err == nil {
@@ -81,19 +84,22 @@ func CrawlFromSeed(ctx context.Context, seed CrawlSeed,
 		}
 
 		seen[cdoc.ID()] = struct{}{}
+		// Get the dependencies before inserting into the index, so that
+		// the links to them are indexed with the document.
+		deps, depsErr := cdoc.GetResources()
+		logIfErr(depsErr)
+		d := cdoc.GetDocument()
+		d.KustomizationIDs = mergeIDs(d.KustomizationIDs, referrers[cdoc.ID()])
+
 		// Insert into index
 		err := indx(cdoc, match)
 		logIfErr(err)
-		if err != nil {
+		if err != nil || depsErr != nil {
 			return
 		}
 
-		deps, err := cdoc.GetResources()
-		logIfErr(err)
-		if err != nil {
-			return
-		}
 		for _, dep := range deps {
+			referrers[dep.ID()] = append(referrers[dep.ID()], cdoc.ID())
 			if _, ok := seen[dep.ID()]; ok {
 				continue
 			}
@@ -114,8 +120,14 @@ func CrawlFromSeed(ctx context.Context, seed CrawlSeed,
 				continue
 			}
 
+			// Fetching may resolve a directory to its kustomization file.
+			id := next.ID()
 			err := match.FetchDocument(ctx, next)
 			logIfErr(err)
+			if next.ID() != id {
+				referrers[next.ID()] = append(referrers[next.ID()],
+					referrers[id]...)
+			}
 			// If there was no change or there is an error, we don't have
 			// to branch out, since the dependencies are already in the
 			// index, or we cannot find the document.
@@ -175,6 +187,23 @@ func CrawlFromSeed(ctx context.Context, seed CrawlSeed,
 	doCrawl(&stack)
 }
 
+// Append the ids that are not already in ids.
+func mergeIDs(ids []string, more []string) []string {
+	for _, id := range more {
+		found := false
+		for _, existing := range ids {
+			if existing == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // CRunner is a blocking function and only returns once all of the
 // crawlers are finished with execution.
 //
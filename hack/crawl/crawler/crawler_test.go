@@ -354,3 +354,107 @@ resources:
 		}
 	}
 }
+
+func TestCrawlFromSeedLinks(t *testing.T) {
+	corpus := []doc.KustomizationDocument{
+		{Document: doc.Document{
+			RepositoryURL: kustomizeRepo,
+			FilePath:      "examples/dev/kustomization.yaml",
+			DocumentData: `
+resources:
+- ../base
+- patch.yaml
+`,
+		}},
+		{Document: doc.Document{
+			RepositoryURL: kustomizeRepo,
+			FilePath:      "examples/prod/kustomization.yaml",
+			DocumentData: `
+resources:
+- ../base
+`,
+		}},
+		{Document: doc.Document{
+			RepositoryURL: kustomizeRepo,
+			FilePath:      "examples/base/kustomization.yaml",
+			DocumentData: `
+resources:
+- deployment.yaml
+`,
+		}},
+		{Document: doc.Document{
+			RepositoryURL: kustomizeRepo,
+			FilePath:      "examples/base/deployment.yaml",
+		}},
+		{Document: doc.Document{
+			RepositoryURL: kustomizeRepo,
+			FilePath:      "examples/dev/patch.yaml",
+		}},
+	}
+	seed := CrawlSeed{
+		{RepositoryURL: kustomizeRepo, FilePath: "examples/dev/kustomization.yaml"},
+		{RepositoryURL: kustomizeRepo, FilePath: "examples/prod/kustomization.yaml"},
+	}
+	id := func(file string) string {
+		return kustomizeRepo + "//examples/" + file
+	}
+	expected := map[string]struct {
+		kustomizations []string
+		resources      []string
+	}{
+		id("dev/kustomization.yaml"): {
+			resources: []string{id("base"), id("dev/patch.yaml")},
+		},
+		id("prod/kustomization.yaml"): {
+			resources: []string{id("base")},
+		},
+		id("base/kustomization.yaml"): {
+			kustomizations: []string{
+				id("dev/kustomization.yaml"),
+				id("prod/kustomization.yaml"),
+			},
+			resources: []string{id("base/deployment.yaml")},
+		},
+		id("base/deployment.yaml"): {
+			kustomizations: []string{id("base/kustomization.yaml")},
+		},
+		id("dev/patch.yaml"): {
+			kustomizations: []string{id("dev/kustomization.yaml")},
+		},
+	}
+
+	cr := newCrawler(kustomizeRepo, nil, corpus)
+	indexed := make(map[string]*doc.KustomizationDocument)
+	CrawlFromSeed(context.Background(), seed, []Crawler{cr},
+		func(d *doc.Document) (CrawledDocument, error) {
+			return &doc.KustomizationDocument{
+				Document: *d,
+			}, nil
+		},
+		func(d CrawledDocument, cr Crawler) error {
+			indexed[d.ID()] = d.(*doc.KustomizationDocument)
+			return nil
+		},
+	)
+
+	if li, le := len(indexed), len(expected); li != le {
+		t.Errorf("error: %d of %d documents indexed: %v", li, le, indexed)
+	}
+	for id, e := range expected {
+		d, ok := indexed[id]
+		if !ok {
+			t.Errorf("%s not indexed", id)
+			continue
+		}
+		sort.Strings(d.KustomizationIDs)
+		sort.Strings(d.ResourceIDs)
+		if !reflect.DeepEqual(d.KustomizationIDs, e.kustomizations) {
+			t.Errorf("%s: expected kustomizations %v to equal %v",
+				id, d.KustomizationIDs, e.kustomizations)
+		}
+		if !reflect.DeepEqual(d.ResourceIDs, e.resources) {
+			t.Errorf("%s: expected resources %v to equal %v",
+				id, d.ResourceIDs, e.resources)
+		}
+	}
+}
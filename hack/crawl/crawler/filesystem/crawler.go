@@ -0,0 +1,307 @@
+// Package filesystem implements the crawler.Crawler interface, getting data
+// from local mirrors of source repositories.
+//
+// The mirrors are plain git clones kept up to date by a separate sync job, so
+// documents can be indexed without using any API quota, and without network
+// access to the source repositories.
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/api/pgmconfig"
+	"sigs.k8s.io/kustomize/hack/crawl/crawler"
+	"sigs.k8s.io/kustomize/hack/crawl/doc"
+)
+
+var logger = log.New(os.Stdout, "Filesystem Crawler: ",
+	log.LstdFlags|log.LUTC|log.Llongfile)
+
+// Mirror is a local clone of a source repository.
+type Mirror struct {
+	// RepositoryURL is the URL of the mirrored repository, as it is
+	// stored in the documents. e.g. https://github.com/org/repo
+	RepositoryURL string
+	// DefaultBranch is the branch checked out in the mirror.
+	DefaultBranch string
+	// Dir is the directory the repository is cloned in.
+	Dir string
+}
+
+// Implements crawler.Crawler.
+type filesystemCrawler struct {
+	mirrors map[string]Mirror
+}
+
+// NewCrawler returns a crawler for the mirrors.
+func NewCrawler(mirrors []Mirror) filesystemCrawler {
+	fc := filesystemCrawler{mirrors: make(map[string]Mirror)}
+	for _, m := range mirrors {
+		fc.mirrors[normalizeURL(m.RepositoryURL)] = m
+	}
+	return fc
+}
+
+// MirrorsFromRoot finds the mirrors cloned under root. Each mirror is expected
+// to be cloned at root/host/org/repo, and is given the repository URL
+// https://host/org/repo. The default branch of a mirror is the branch it has
+// checked out.
+func MirrorsFromRoot(root string) ([]Mirror, error) {
+	mirrors := make([]Mirror, 0)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(p, ".git")); err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		branch, err := checkedOutBranch(p)
+		if err != nil {
+			logger.Printf("(error: %v) setting default_branch of %s to master\n",
+				err, p)
+			branch = "master"
+		}
+		mirrors = append(mirrors, Mirror{
+			RepositoryURL: "https://" + filepath.ToSlash(rel),
+			DefaultBranch: branch,
+			Dir:           p,
+		})
+		// Don't look for mirrors within mirrors.
+		return filepath.SkipDir
+	})
+	return mirrors, err
+}
+
+// checkedOutBranch reads the branch checked out in a clone from .git/HEAD.
+func checkedOutBranch(dir string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ".git", "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	const prefix = "ref: refs/heads/"
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, prefix) {
+		return "", fmt.Errorf("HEAD of %s is detached", dir)
+	}
+	return strings.TrimPrefix(head, prefix), nil
+}
+
+// normalizeURL strips the parts of a repository URL which don't change the
+// repository it refers to.
+func normalizeURL(url string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
+
+// isKustomization returns true if the file name is a kustomization file name.
+func isKustomization(name string) bool {
+	for _, file := range pgmconfig.RecognizedKustomizationFileNames() {
+		if name == file {
+			return true
+		}
+	}
+	return false
+}
+
+// Implements crawler.Crawler.
+func (fc filesystemCrawler) Crawl(
+	ctx context.Context, output chan<- crawler.CrawledDocument) error {
+
+	errs := make(multiError, 0)
+	for _, m := range fc.mirrors {
+		cnt, err := crawlMirror(ctx, m, output)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		logger.Printf("got %d files from %s\n", cnt, m.RepositoryURL)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// crawlMirror forwards the kustomization files in a mirror to the output
+// channel, and returns the number of files forwarded.
+func crawlMirror(ctx context.Context, m Mirror,
+	output chan<- crawler.CrawledDocument) (int, error) {
+
+	cnt := 0
+	err := filepath.Walk(m.Dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isKustomization(info.Name()) {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.Dir, p)
+		if err != nil {
+			return err
+		}
+		output <- &doc.KustomizationDocument{
+			Document: doc.Document{
+				DocumentData:  string(data),
+				FilePath:      filepath.ToSlash(rel),
+				DefaultBranch: m.DefaultBranch,
+				RepositoryURL: m.RepositoryURL,
+			},
+		}
+		cnt++
+		return nil
+	})
+	if err != nil {
+		return cnt, fmt.Errorf("could not crawl %s: %v", m.Dir, err)
+	}
+	return cnt, nil
+}
+
+// mirror returns the mirror of the repository of the document.
+func (fc filesystemCrawler) mirror(d *doc.Document) (Mirror, bool) {
+	m, ok := fc.mirrors[normalizeURL(d.RepositoryURL)]
+	return m, ok
+}
+
+// localPath returns the path of the document in the mirror. Paths are rooted
+// at the mirror, so they can't refer to files outside of it.
+func localPath(m Mirror, filePath string) (string, error) {
+	cleaned := path.Clean("/" + filePath)
+	if cleaned == "/" {
+		return "", fmt.Errorf("invalid file path: %s", filePath)
+	}
+	return filepath.Join(m.Dir, filepath.FromSlash(cleaned)), nil
+}
+
+// Implements crawler.Crawler.
+func (fc filesystemCrawler) FetchDocument(_ context.Context, d *doc.Document) error {
+	m, ok := fc.mirror(d)
+	if !ok {
+		return fmt.Errorf("no mirror of %s", d.RepositoryURL)
+	}
+	p, err := localPath(m, d.FilePath)
+	if err != nil {
+		return err
+	}
+
+	handle := func(file, suffix string) error {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// The mirror is the only source of truth, so an unchanged file
+		// has already been indexed.
+		d.IsSame = d.DocumentData == string(data)
+		d.DocumentData = string(data)
+		d.FilePath = d.FilePath + suffix
+		return nil
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("file not found: %s", p)
+	}
+	if !info.IsDir() {
+		return handle(p, "")
+	}
+	for _, file := range pgmconfig.RecognizedKustomizationFileNames() {
+		if err := handle(filepath.Join(p, file), "/"+file); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("file not found: %s", p)
+}
+
+// Implements crawler.Crawler.
+//
+// The creation time is the time of the commit which added the file, or the
+// file modification time if the git history is unavailable.
+func (fc filesystemCrawler) SetCreated(ctx context.Context, d *doc.Document) error {
+	m, ok := fc.mirror(d)
+	if !ok {
+		return fmt.Errorf("no mirror of %s", d.RepositoryURL)
+	}
+	p, err := localPath(m, d.FilePath)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "log", "--diff-filter=A",
+		"--follow", "--format=%aI", "--", strings.TrimPrefix(path.Clean("/"+d.FilePath), "/"))
+	cmd.Dir = m.Dir
+	out, err := cmd.Output()
+	if err == nil {
+		lines := strings.Fields(string(out))
+		if len(lines) > 0 {
+			// The log is in reverse chronological order, the first
+			// commit adding the file is last.
+			created, err := time.Parse(time.RFC3339, lines[len(lines)-1])
+			if err == nil {
+				d.CreationTime = &created
+				return nil
+			}
+		}
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	created := info.ModTime().UTC()
+	d.CreationTime = &created
+	return nil
+}
+
+// Implements crawler.Crawler.
+func (fc filesystemCrawler) Match(d *doc.Document) bool {
+	if d == nil {
+		return false
+	}
+	_, ok := fc.mirror(d)
+	return ok
+}
+
+type multiError []error
+
+func (e multiError) Error() string {
+	size := len(e) + 2
+	strs := make([]string, size)
+	strs[0] = "Errors ["
+	for i, err := range e {
+		strs[i+1] = "\t" + err.Error()
+	}
+	strs[size-1] = "]"
+	return strings.Join(strs, "\n")
+}
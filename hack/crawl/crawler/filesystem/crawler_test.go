@@ -0,0 +1,201 @@
+package filesystem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"sigs.k8s.io/kustomize/hack/crawl/crawler"
+	"sigs.k8s.io/kustomize/hack/crawl/doc"
+)
+
+const (
+	repoURL        = "https://github.com/org/repo"
+	kustomization  = "resources:\n- deployment.yaml\n"
+	kustomization2 = "namePrefix: prod-\n"
+)
+
+// writeMirror writes a mirror of repoURL under root, and returns the
+// directory of the mirror.
+func writeMirror(t *testing.T, root string) string {
+	dir := filepath.Join(root, "github.com", "org", "repo")
+	files := map[string]string{
+		".git/HEAD":                 "ref: refs/heads/main\n",
+		".git/kustomization.yaml":   "ignored",
+		"base/kustomization.yaml":   kustomization,
+		"base/deployment.yaml":      "kind: Deployment\n",
+		"prod/kustomization.yml":    kustomization2,
+		"docs/README.md":            "not a kustomization",
+		"nested/deep/Kustomization": kustomization,
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMirrorsFromRoot(t *testing.T) {
+	root, err := ioutil.TempDir("", "crawl-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := writeMirror(t, root)
+
+	mirrors, err := MirrorsFromRoot(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Mirror{{
+		RepositoryURL: repoURL,
+		DefaultBranch: "main",
+		Dir:           dir,
+	}}
+	if !reflect.DeepEqual(mirrors, expected) {
+		t.Errorf("got %v, expected %v", mirrors, expected)
+	}
+}
+
+func TestCrawl(t *testing.T) {
+	root, err := ioutil.TempDir("", "crawl-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := writeMirror(t, root)
+	fc := NewCrawler([]Mirror{{RepositoryURL: repoURL, DefaultBranch: "main", Dir: dir}})
+
+	output := make(chan crawler.CrawledDocument, 10)
+	if err := fc.Crawl(context.Background(), output); err != nil {
+		t.Fatal(err)
+	}
+	close(output)
+
+	var ids []string
+	for cdoc := range output {
+		ids = append(ids, cdoc.ID())
+		if cdoc.GetDocument().DocumentData == "" {
+			t.Errorf("%s has no data", cdoc.ID())
+		}
+	}
+	sort.Strings(ids)
+	expected := []string{
+		repoURL + "/main/base/kustomization.yaml",
+		repoURL + "/main/nested/deep/Kustomization",
+		repoURL + "/main/prod/kustomization.yml",
+	}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("got %v, expected %v", ids, expected)
+	}
+}
+
+func TestFetchDocument(t *testing.T) {
+	root, err := ioutil.TempDir("", "crawl-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := writeMirror(t, root)
+	fc := NewCrawler([]Mirror{{RepositoryURL: repoURL + ".git", DefaultBranch: "main", Dir: dir}})
+
+	testCases := []struct {
+		doc      doc.Document
+		expected doc.Document
+		err      bool
+	}{
+		{
+			doc: doc.Document{RepositoryURL: repoURL, FilePath: "base/kustomization.yaml"},
+			expected: doc.Document{RepositoryURL: repoURL, FilePath: "base/kustomization.yaml",
+				DocumentData: kustomization},
+		},
+		{
+			doc: doc.Document{RepositoryURL: repoURL, FilePath: "prod"},
+			expected: doc.Document{RepositoryURL: repoURL, FilePath: "prod/kustomization.yml",
+				DocumentData: kustomization2},
+		},
+		{
+			doc: doc.Document{RepositoryURL: repoURL, FilePath: "base/kustomization.yaml",
+				DocumentData: kustomization},
+			expected: doc.Document{RepositoryURL: repoURL, FilePath: "base/kustomization.yaml",
+				DocumentData: kustomization, IsSame: true},
+		},
+		{
+			doc: doc.Document{RepositoryURL: repoURL, FilePath: "docs"},
+			err: true,
+		},
+		{
+			doc: doc.Document{RepositoryURL: "https://github.com/org/other", FilePath: "base"},
+			err: true,
+		},
+	}
+
+	for _, test := range testCases {
+		d := test.doc
+		err := fc.FetchDocument(context.Background(), &d)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected an error fetching %v", test.doc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("fetching %v: %v", test.doc, err)
+			continue
+		}
+		if !reflect.DeepEqual(d, test.expected) {
+			t.Errorf("got %v, expected %v", d, test.expected)
+		}
+	}
+}
+
+func TestSetCreated(t *testing.T) {
+	root, err := ioutil.TempDir("", "crawl-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := writeMirror(t, root)
+	fc := NewCrawler([]Mirror{{RepositoryURL: repoURL, DefaultBranch: "main", Dir: dir}})
+
+	// The mirror has no git history, so the modification time is used.
+	d := doc.Document{RepositoryURL: repoURL, FilePath: "base/kustomization.yaml"}
+	if err := fc.SetCreated(context.Background(), &d); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "base", "kustomization.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.CreationTime == nil || !d.CreationTime.Equal(info.ModTime()) {
+		t.Errorf("got creation time %v, expected %v", d.CreationTime, info.ModTime())
+	}
+}
+
+func TestMatch(t *testing.T) {
+	fc := NewCrawler([]Mirror{{RepositoryURL: repoURL, Dir: "unused"}})
+	testCases := []struct {
+		doc      *doc.Document
+		expected bool
+	}{
+		{doc: &doc.Document{RepositoryURL: repoURL}, expected: true},
+		{doc: &doc.Document{RepositoryURL: repoURL + "/"}, expected: true},
+		{doc: &doc.Document{RepositoryURL: repoURL + ".git"}, expected: true},
+		{doc: &doc.Document{RepositoryURL: "https://github.com/org/other"}, expected: false},
+		{doc: nil, expected: false},
+	}
+	for _, test := range testCases {
+		if result := fc.Match(test.doc); result != test.expected {
+			t.Errorf("Match(%v) got %v, expected %v", test.doc, result, test.expected)
+		}
+	}
+}
@@ -209,19 +209,20 @@ func kustomizationResultAdapter(gcl GhClient, k GhFileSpec) (
 	}
 
 	url := gcl.ReposRequest(k.Repository.FullName)
-	defaultBranch, err := gcl.GetDefaultBranch(url)
+	repo, err := gcl.GetRepositoryMetadata(url)
 	if err != nil {
 		logger.Printf(
 			"(error: %v) setting default_branch to master\n", err)
-		defaultBranch = "master"
+		repo.DefaultBranch = "master"
 	}
 
 	d := doc.KustomizationDocument{
 		Document: doc.Document{
-			DocumentData:  string(data),
-			FilePath:      k.Path,
-			DefaultBranch: defaultBranch,
-			RepositoryURL: k.Repository.URL,
+			DocumentData:          string(data),
+			FilePath:              k.Path,
+			DefaultBranch:         repo.DefaultBranch,
+			RepositoryURL:         k.Repository.URL,
+			RepositoryDescription: repo.Description,
 		},
 	}
 
@@ -300,30 +301,42 @@ func (gcl GhClient) GetFileData(k GhFileSpec) ([]byte, error) {
 	return data, err
 }
 
+// GetDefaultBranch gets the default branch of the repository from its repos
+// API url.
 func (gcl GhClient) GetDefaultBranch(url string) (string, error) {
+	repo, err := gcl.GetRepositoryMetadata(url)
+	return repo.DefaultBranch, err
+}
+
+// The repository metadata stored with the documents.
+type GhRepositoryMetadata struct {
+	DefaultBranch string `json:"default_branch,omitempty"`
+	Description   string `json:"description,omitempty"`
+}
+
+// GetRepositoryMetadata gets the default branch and the description of the
+// repository from its repos API url.
+func (gcl GhClient) GetRepositoryMetadata(url string) (GhRepositoryMetadata, error) {
+	var repo GhRepositoryMetadata
 	resp, err := gcl.GetReposData(url)
 	if err != nil {
-		return "", fmt.Errorf(
-			"'%s' could not get default_branch: %v", url, err)
+		return repo, fmt.Errorf(
+			"'%s' could not get repository metadata: %v", url, err)
 	}
 	defer resp.Body.Close()
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf(
-			"could not read default_branch: %v", err)
+		return repo, fmt.Errorf(
+			"could not read repository metadata: %v", err)
 	}
 
-	type defaultBranch struct {
-		DefaultBranch string `json:"default_branch,omitempty"`
-	}
-	var branch defaultBranch
-	err = json.Unmarshal(data, &branch)
+	err = json.Unmarshal(data, &repo)
 	if err != nil {
-		return "", fmt.Errorf(
-			"default_branch json malformed: %v", err)
+		return repo, fmt.Errorf(
+			"repository metadata json malformed: %v", err)
 	}
 
-	return branch.DefaultBranch, nil
+	return repo, nil
 }
 
 // GetFileCreationTime gets the earliest date of a file.
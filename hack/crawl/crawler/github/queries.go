@@ -130,6 +130,18 @@ func (rc RequestConfig) ReposRequest(fullRepoName string) string {
 	return rc.makeRequest(uri, Query{}).URL()
 }
 
+// CacheURLPatterns given the repo name (owner/repo) or the owner, returns the
+// patterns of the URLs requested for its files, e.g. to purge the responses
+// from the http cache.
+func CacheURLPatterns(fullRepoName string) []string {
+	return []string{
+		"https://raw.githubusercontent.com/" + fullRepoName + "/*",
+		"https://api.github.com/repos/" + fullRepoName,
+		"https://api.github.com/repos/" + fullRepoName + "/*",
+		"https://api.github.com/repos/" + fullRepoName + "?*",
+	}
+}
+
 // CommitsRequest given the repo name, and a filepath returns a formatted query
 // for the Github API to find the commits that affect this file.
 func (rc RequestConfig) CommitsRequest(fullRepoName, path string) string {
@@ -0,0 +1,53 @@
+package doc
+
+// Compute the CompositionDepth of each of the kustomization documents from the
+// links of the kustomization graph, keyed by document ID. A kustomization that
+// does not reference any of the other kustomizations through its ResourceIDs or
+// ComponentIDs has a depth of 1, the others are one level deeper than the
+// deepest kustomization they reference. References to kustomizations that are
+// not part of docs are ignored, and so are the links closing a cycle.
+func CompositionDepths(docs []*KustomizationDocument) map[string]int {
+	// Kustomizations are referenced by their directory, or by their ID.
+	byRef := make(map[string]*KustomizationDocument)
+	for _, d := range docs {
+		for _, id := range d.ReferenceIDs() {
+			byRef[id] = d
+		}
+	}
+
+	depths := make(map[string]int, len(docs))
+	visiting := make(set)
+	var depth func(d *KustomizationDocument) int
+	depth = func(d *KustomizationDocument) int {
+		id := d.ID()
+		if n, ok := depths[id]; ok {
+			return n
+		}
+		if _, ok := visiting[id]; ok {
+			return 0
+		}
+		visiting[id] = struct{}{}
+
+		deepest := 0
+		for _, ids := range [][]string{d.ResourceIDs, d.ComponentIDs} {
+			for _, ref := range ids {
+				next, ok := byRef[ref]
+				if !ok {
+					continue
+				}
+				if n := depth(next); n > deepest {
+					deepest = n
+				}
+			}
+		}
+
+		delete(visiting, id)
+		depths[id] = deepest + 1
+		return deepest + 1
+	}
+
+	for _, d := range docs {
+		depth(d)
+	}
+	return depths
+}
@@ -0,0 +1,52 @@
+package doc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompositionDepths(t *testing.T) {
+	kustomization := func(path string, resourceIDs, componentIDs []string) *KustomizationDocument {
+		return &KustomizationDocument{
+			Document: Document{
+				RepositoryURL: "example.com/repo",
+				FilePath:      path,
+			},
+			ResourceIDs:  resourceIDs,
+			ComponentIDs: componentIDs,
+		}
+	}
+	docs := []*KustomizationDocument{
+		kustomization("overlays/prod/kustomization.yaml",
+			[]string{"example.com/repo//overlays/staging"},
+			[]string{"example.com/repo//components/monitoring"}),
+		kustomization("overlays/staging/kustomization.yaml",
+			[]string{"example.com/repo//base", "example.com/repo//overlays/staging/patch.yaml"},
+			nil),
+		kustomization("base/kustomization.yaml",
+			[]string{"example.com/repo//base/deployment.yaml", "example.com/other//base"},
+			nil),
+		kustomization("components/monitoring/kustomization.yaml",
+			nil,
+			[]string{"example.com/repo//components/alerts/kustomization.yaml"}),
+		kustomization("components/alerts/kustomization.yaml", nil, nil),
+		// The links closing a cycle are ignored.
+		kustomization("cycle/a/kustomization.yaml",
+			[]string{"example.com/repo//cycle/b"}, nil),
+		kustomization("cycle/b/kustomization.yaml",
+			[]string{"example.com/repo//cycle/a"}, nil),
+	}
+
+	expected := map[string]int{
+		"example.com/repo//overlays/prod/kustomization.yaml":         3,
+		"example.com/repo//overlays/staging/kustomization.yaml":      2,
+		"example.com/repo//base/kustomization.yaml":                  1,
+		"example.com/repo//components/monitoring/kustomization.yaml": 2,
+		"example.com/repo//components/alerts/kustomization.yaml":     1,
+		"example.com/repo//cycle/a/kustomization.yaml":               2,
+		"example.com/repo//cycle/b/kustomization.yaml":               1,
+	}
+	if depths := CompositionDepths(docs); !reflect.DeepEqual(depths, expected) {
+		t.Errorf("Expected depths %v to equal %v", depths, expected)
+	}
+}
@@ -30,6 +30,28 @@ var fileReader = kunstruct.NewKunstructuredFactoryImpl()
 // - FilePath is the path of the file.
 // - RepositoryURL is the URL of the source repository.
 // - CreationTime is the time at which the file was created.
+// - KustomizationIDs are the IDs of the kustomizations referencing the file.
+// - ResourceIDs are the IDs of the resources and bases referenced by the
+//   kustomization file, as returned by GetResources.
+// - ComponentIDs are the IDs of the components referenced by the kustomization
+//   file, as returned by GetResources.
+// - CompositionDepth is the number of levels of kustomizations stacked by the
+//   kustomization file through its bases and components, as computed by
+//   CompositionDepths. It is 1 for a kustomization that does not reference any
+//   other kustomization, and 0 for the other files.
+// - Features are the kustomize features used by the kustomization file, as
+//   the kustomization fields that are set, e.g. patchesStrategicMerge.
+// - Hash is the hex encoded sha256 of the DocumentData.
+// - Popularity is the number of kustomizations referencing the file.
+// - Language is the natural language of the repository description and of the
+//   comments of the file, as an ISO 639-1 code, e.g. "en". It is empty if the
+//   language cannot be detected.
+// - Text is the natural language text of the document, keyed by its Language
+//   (or "default"), so that each language is indexed with its own analyzer.
+//
+// The Kinds, Identifiers, Values, Features, Hash, Popularity, Language and Text
+// are derived from the other fields by the Enrichers before the document is
+// indexed.
 //
 // Representing each Identifier and Value as a flat string representation
 // facilitates the use of complex text search features from elasticsearch such
@@ -39,11 +61,30 @@ type KustomizationDocument struct {
 	Kinds       []string `json:"kinds,omitempty"`
 	Identifiers []string `json:"identifiers,omitempty"`
 	Values      []string `json:"values,omitempty"`
+	ResourceIDs []string `json:"resourceIds,omitempty"`
+
+	ComponentIDs     []string `json:"componentIds,omitempty"`
+	CompositionDepth int      `json:"compositionDepth,omitempty"`
+
+	Features   []string `json:"features,omitempty"`
+	Hash       string   `json:"hash,omitempty"`
+	Popularity int      `json:"popularity,omitempty"`
+
+	Language string            `json:"language,omitempty"`
+	Text     map[string]string `json:"text,omitempty"`
 }
 
 type set map[string]struct{}
 
-// Implements the CrawlerDocument interface.
+// The components field of a kustomization, which the kustomize api version used
+// by the crawler does not know about.
+type kustomizationComponents struct {
+	Components []string `json:"components,omitempty"`
+}
+
+// Implements the CrawlerDocument interface. Also links the kustomization and the
+// returned documents to each other through ResourceIDs (or ComponentIDs for the
+// components) and KustomizationIDs.
 func (doc *KustomizationDocument) GetResources() ([]*Document, error) {
 	isResource := true
 	for _, suffix := range pgmconfig.RecognizedKustomizationFileNames() {
@@ -69,15 +110,30 @@ func (doc *KustomizationDocument) GetResources() ([]*Document, error) {
 	}
 	k.FixKustomizationPostUnmarshalling()
 
-	res := make([]*Document, 0, len(k.Resources))
-	for _, r := range k.Resources {
-		next, err := doc.Document.FromRelativePath(r)
-		if err != nil {
-			fmt.Printf("GetResources error: %v\n", err)
-			continue
+	var c kustomizationComponents
+	err = yaml.Unmarshal(content, &c)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not parse kustomization components: %v", err)
+	}
+
+	res := make([]*Document, 0, len(k.Resources)+len(c.Components))
+	link := func(paths []string) []string {
+		ids := make([]string, 0, len(paths))
+		for _, r := range paths {
+			next, err := doc.Document.FromRelativePath(r)
+			if err != nil {
+				fmt.Printf("GetResources error: %v\n", err)
+				continue
+			}
+			next.KustomizationIDs = []string{doc.ID()}
+			ids = append(ids, next.ID())
+			res = append(res, &next)
 		}
-		res = append(res, &next)
+		return ids
 	}
+	doc.ResourceIDs = link(k.Resources)
+	doc.ComponentIDs = link(c.Components)
 
 	return res, nil
 }
@@ -159,8 +159,9 @@ metadata:
 
 func TestGetResources(t *testing.T) {
 	tests := []struct {
-		doc       KustomizationDocument
-		resources []*Document
+		doc          KustomizationDocument
+		resources    []*Document
+		componentIDs []string
 	}{
 		{
 			doc: KustomizationDocument{
@@ -181,22 +182,67 @@ resources:
 				{
 					RepositoryURL: "sigs.k8s.io/kustomize",
 					FilePath:      "some/path/to/base",
+					KustomizationIDs: []string{
+						"sigs.k8s.io/kustomize//some/path/to/kdir/kustomization.yaml",
+					},
 				},
 				{
 					RepositoryURL: "sigs.k8s.io/kustomize",
 					FilePath:      "some/path/to/otherbase",
+					KustomizationIDs: []string{
+						"sigs.k8s.io/kustomize//some/path/to/kdir/kustomization.yaml",
+					},
 				},
 				{
 					RepositoryURL: "sigs.k8s.io/kustomize",
 					FilePath:      "some/path/to/kdir/file.yaml",
+					KustomizationIDs: []string{
+						"sigs.k8s.io/kustomize//some/path/to/kdir/kustomization.yaml",
+					},
 				},
 				{
 					RepositoryURL: "https://github.com/kubernetes-sigs/kustomize",
 					FilePath:      "examples/helloWorld",
 					DefaultBranch: "v3.1.0",
+					KustomizationIDs: []string{
+						"sigs.k8s.io/kustomize//some/path/to/kdir/kustomization.yaml",
+					},
 				},
 			},
 		},
+		{
+			doc: KustomizationDocument{
+				Document: Document{
+					RepositoryURL: "sigs.k8s.io/kustomize",
+					FilePath:      "overlays/prod/kustomization.yaml",
+					DocumentData: `
+resources:
+- ../../base
+
+components:
+- ../../components/monitoring
+`},
+			},
+			resources: []*Document{
+				{
+					RepositoryURL: "sigs.k8s.io/kustomize",
+					FilePath:      "base",
+					KustomizationIDs: []string{
+						"sigs.k8s.io/kustomize//overlays/prod/kustomization.yaml",
+					},
+				},
+				{
+					RepositoryURL: "sigs.k8s.io/kustomize",
+					FilePath:      "components/monitoring",
+					KustomizationIDs: []string{
+						"sigs.k8s.io/kustomize//overlays/prod/kustomization.yaml",
+					},
+				},
+			},
+			componentIDs: []string{
+				"sigs.k8s.io/kustomize//components/monitoring",
+			},
+		},
 		{
 			doc: KustomizationDocument{
 				Document: Document{
@@ -227,6 +273,19 @@ resources:
 			t.Errorf("Number of resources does not match.")
 			continue
 		}
+		ids := make([]string, 0, len(res))
+		for _, r := range res[:len(res)-len(test.componentIDs)] {
+			ids = append(ids, r.ID())
+		}
+		if len(ids) > 0 && !reflect.DeepEqual(test.doc.ResourceIDs, ids) {
+			t.Errorf("Expected resource ids %v to equal %v\n",
+				test.doc.ResourceIDs, ids)
+		}
+		if len(test.componentIDs) > 0 &&
+			!reflect.DeepEqual(test.doc.ComponentIDs, test.componentIDs) {
+			t.Errorf("Expected component ids %v to equal %v\n",
+				test.doc.ComponentIDs, test.componentIDs)
+		}
 		cmp := func(docs []*Document) func(i, j int) bool {
 			return func(i, j int) bool {
 				if docs[i].RepositoryURL != docs[j].RepositoryURL {
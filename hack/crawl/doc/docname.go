@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"sigs.k8s.io/kustomize/api/git"
+	"sigs.k8s.io/kustomize/api/pgmconfig"
 )
 
 type Document struct {
@@ -14,6 +15,10 @@ type Document struct {
 	DocumentData  string     `json:"document,omitempty"`
 	CreationTime  *time.Time `json:"creationTime,omitempty"`
 	IsSame        bool       `json:"-"`
+	// Description of the source repository, as set by its owners.
+	RepositoryDescription string `json:"repositoryDescription,omitempty"`
+	// IDs of the kustomizations referencing this document.
+	KustomizationIDs []string `json:"kustomizationIds,omitempty"`
 }
 
 // Implements the CrawlerDocument interface.
@@ -38,8 +43,9 @@ func (doc *Document) FromRelativePath(newFile string) (Document, error) {
 	// else document is probably relative path.
 
 	ret := Document{
-		RepositoryURL: doc.RepositoryURL,
-		DefaultBranch: doc.DefaultBranch,
+		RepositoryURL:         doc.RepositoryURL,
+		DefaultBranch:         doc.DefaultBranch,
+		RepositoryDescription: doc.RepositoryDescription,
 	}
 	ogDir, _ := path.Split(doc.FilePath)
 
@@ -56,3 +62,18 @@ func (doc *Document) ID() string {
 	return doc.RepositoryURL + "/" +
 		doc.DefaultBranch + "/" + doc.FilePath
 }
+
+// Get the IDs a kustomization may have stored in its ResourceIDs to reference
+// this document. Kustomization files are referenced by their directory.
+func (doc *Document) ReferenceIDs() []string {
+	ids := []string{doc.ID()}
+	dir, file := path.Split(doc.FilePath)
+	for _, suffix := range pgmconfig.RecognizedKustomizationFileNames() {
+		if file == suffix {
+			ids = append(ids, doc.RepositoryURL+"/"+
+				doc.DefaultBranch+"/"+path.Clean(dir))
+			break
+		}
+	}
+	return ids
+}
@@ -62,3 +62,39 @@ func TestFromRelativePath(t *testing.T) {
 		}
 	}
 }
+
+func TestReferenceIDs(t *testing.T) {
+	testCases := []struct {
+		Doc      Document
+		Expected []string
+	}{
+		{
+			Doc: Document{
+				RepositoryURL: "example.com/repo",
+				FilePath:      "path/to/file/resource.yaml",
+				DefaultBranch: "master",
+			},
+			Expected: []string{
+				"example.com/repo/master/path/to/file/resource.yaml",
+			},
+		},
+		{
+			Doc: Document{
+				RepositoryURL: "example.com/repo",
+				FilePath:      "path/to/file/kustomization.yaml",
+				DefaultBranch: "master",
+			},
+			Expected: []string{
+				"example.com/repo/master/path/to/file/kustomization.yaml",
+				"example.com/repo/master/path/to/file",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		ids := tc.Doc.ReferenceIDs()
+		if !reflect.DeepEqual(ids, tc.Expected) {
+			t.Errorf("Expected %v to equal %v", ids, tc.Expected)
+		}
+	}
+}
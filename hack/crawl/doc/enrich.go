@@ -0,0 +1,137 @@
+package doc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/pgmconfig"
+	"sigs.k8s.io/yaml"
+)
+
+// An Enricher computes derived fields of a kustomization document before it is
+// inserted into the index. Adding a derived field only requires adding an
+// Enricher, the crawler control flow stays the same.
+type Enricher interface {
+	Enrich(*KustomizationDocument) error
+}
+
+// Adapts a function to the Enricher interface.
+type EnricherFunc func(*KustomizationDocument) error
+
+func (f EnricherFunc) Enrich(d *KustomizationDocument) error {
+	return f(d)
+}
+
+// The built-in enrichers, in the order they are run.
+var (
+	// Sets the Kinds, Identifiers and Values of the document, see ParseYAML.
+	KindsEnricher Enricher = EnricherFunc(func(d *KustomizationDocument) error {
+		return d.ParseYAML()
+	})
+
+	// Sets the Features of the kustomization files.
+	FeaturesEnricher Enricher = EnricherFunc(enrichFeatures)
+
+	// Sets the Hash of the document data.
+	HashEnricher Enricher = EnricherFunc(func(d *KustomizationDocument) error {
+		sum := sha256.Sum256([]byte(d.DocumentData))
+		d.Hash = hex.EncodeToString(sum[:])
+		return nil
+	})
+
+	// Sets the Popularity of the document from the number of kustomizations
+	// referencing it, so that it can be used by the popularity ranking.
+	PopularityEnricher Enricher = EnricherFunc(func(d *KustomizationDocument) error {
+		d.Popularity = len(d.KustomizationIDs)
+		return nil
+	})
+
+	// Sets the Language and Text of the document from the repository
+	// description and the comments of the document, see DetectLanguage.
+	LanguageEnricher Enricher = EnricherFunc(enrichLanguage)
+)
+
+// The enrichers run on each document before it is inserted into the index.
+func DefaultEnrichers() []Enricher {
+	return []Enricher{
+		KindsEnricher,
+		FeaturesEnricher,
+		HashEnricher,
+		PopularityEnricher,
+		LanguageEnricher,
+	}
+}
+
+// Run the enrichers on the document in order, stopping at the first error.
+func (doc *KustomizationDocument) Enrich(enrichers ...Enricher) error {
+	for i, e := range enrichers {
+		if err := e.Enrich(doc); err != nil {
+			return fmt.Errorf("enricher %d failed on %s: %v", i, doc.ID(), err)
+		}
+	}
+	return nil
+}
+
+// The fields of a kustomization that are reported as features, so that the
+// use of kustomize features can be searched and aggregated.
+var kustomizationFeatures = []string{
+	"bases",
+	"commonAnnotations",
+	"commonLabels",
+	"components",
+	"configMapGenerator",
+	"crds",
+	"generatorOptions",
+	"generators",
+	"images",
+	"namePrefix",
+	"nameSuffix",
+	"namespace",
+	"patches",
+	"patchesJson6902",
+	"patchesStrategicMerge",
+	"replicas",
+	"resources",
+	"secretGenerator",
+	"transformers",
+	"vars",
+}
+
+func isKustomizationFile(filePath string) bool {
+	for _, suffix := range pgmconfig.RecognizedKustomizationFileNames() {
+		if strings.HasSuffix(filePath, "/"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set the features used by a kustomization file: the fields of
+// kustomizationFeatures that are set, in the same order. The other documents
+// have no features.
+func enrichFeatures(d *KustomizationDocument) error {
+	d.Features = nil
+	if !isKustomizationFile(d.FilePath) {
+		return nil
+	}
+	var k map[string]interface{}
+	if err := yaml.Unmarshal([]byte(d.DocumentData), &k); err != nil {
+		return fmt.Errorf("unable to parse kustomization: %v", err)
+	}
+	for _, f := range kustomizationFeatures {
+		v, ok := k[f]
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		if s, ok := v.([]interface{}); ok && len(s) == 0 {
+			continue
+		}
+		if m, ok := v.(map[string]interface{}); ok && len(m) == 0 {
+			continue
+		}
+		d.Features = append(d.Features, f)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package doc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnrich(t *testing.T) {
+	d := KustomizationDocument{
+		Document: Document{
+			RepositoryURL: "github.com/user/repo",
+			FilePath:      "overlays/dev/kustomization.yaml",
+			DefaultBranch: "master",
+			DocumentData: `namePrefix: dev-
+resources:
+- ../../base
+images: []
+patchesStrategicMerge:
+- patch.yaml
+`,
+			KustomizationIDs: []string{"github.com/user/repo/master/overlays"},
+		},
+	}
+	if err := d.Enrich(DefaultEnrichers()...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(d.Kinds, []string{"Kustomization"}) {
+		t.Errorf("expected kinds [Kustomization], got %v", d.Kinds)
+	}
+	features := []string{"namePrefix", "patchesStrategicMerge", "resources"}
+	if !reflect.DeepEqual(d.Features, features) {
+		t.Errorf("expected features %v, got %v", features, d.Features)
+	}
+	if len(d.Hash) != 64 {
+		t.Errorf("expected a hex encoded sha256 hash, got %q", d.Hash)
+	}
+	if d.Popularity != 1 {
+		t.Errorf("expected popularity 1, got %d", d.Popularity)
+	}
+
+	// Enriching is idempotent, and the hash changes with the data.
+	before := d.Hash
+	d.DocumentData += "nameSuffix: -v1\n"
+	if err := d.Enrich(DefaultEnrichers()...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Hash == before {
+		t.Errorf("expected the hash to change with the document data")
+	}
+	features = []string{"namePrefix", "nameSuffix", "patchesStrategicMerge", "resources"}
+	if !reflect.DeepEqual(d.Features, features) {
+		t.Errorf("expected features %v, got %v", features, d.Features)
+	}
+}
+
+func TestEnrich_resource(t *testing.T) {
+	d := KustomizationDocument{
+		Document: Document{
+			FilePath: "base/deployment.yaml",
+			DocumentData: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`,
+		},
+	}
+	if err := d.Enrich(DefaultEnrichers()...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(d.Kinds, []string{"Deployment"}) {
+		t.Errorf("expected kinds [Deployment], got %v", d.Kinds)
+	}
+	if d.Features != nil {
+		t.Errorf("expected no features for a resource, got %v", d.Features)
+	}
+	if d.Popularity != 0 {
+		t.Errorf("expected popularity 0, got %d", d.Popularity)
+	}
+}
+
+func TestEnrich_error(t *testing.T) {
+	d := KustomizationDocument{}
+	ran := false
+	err := d.Enrich(
+		EnricherFunc(func(*KustomizationDocument) error { return errors.New("failed") }),
+		EnricherFunc(func(*KustomizationDocument) error { ran = true; return nil }),
+	)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if ran {
+		t.Errorf("expected the enrichers after the error not to run")
+	}
+}
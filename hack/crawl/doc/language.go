@@ -0,0 +1,135 @@
+package doc
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Languages whose text is detected by their script, in the order they are
+// checked. Japanese is checked before Chinese since Japanese text mixes kana
+// with Han characters.
+var scriptLanguages = []struct {
+	language string
+	scripts  []*unicode.RangeTable
+}{
+	{"ja", []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana}},
+	{"ko", []*unicode.RangeTable{unicode.Hangul}},
+	{"zh", []*unicode.RangeTable{unicode.Han}},
+	{"ru", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"ar", []*unicode.RangeTable{unicode.Arabic}},
+	{"he", []*unicode.RangeTable{unicode.Hebrew}},
+	{"el", []*unicode.RangeTable{unicode.Greek}},
+	{"hi", []*unicode.RangeTable{unicode.Devanagari}},
+	{"th", []*unicode.RangeTable{unicode.Thai}},
+}
+
+// Common words of the languages written with the latin script, which are
+// told apart by counting them.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "of", "to", "for", "with", "this", "that", "are", "be", "on", "it", "an"},
+	"es": {"el", "los", "las", "del", "y", "para", "con", "una", "es", "que", "por", "se", "su", "como"},
+	"fr": {"le", "les", "des", "du", "et", "pour", "avec", "une", "est", "que", "dans", "sur", "ce", "qui"},
+	"de": {"der", "die", "das", "und", "ist", "mit", "für", "ein", "eine", "nicht", "zu", "auf", "den", "von"},
+	"pt": {"os", "as", "do", "da", "e", "para", "com", "uma", "um", "não", "em", "por", "que", "dos"},
+	"it": {"il", "gli", "della", "di", "e", "per", "con", "una", "è", "che", "non", "nel", "sono", "questo"},
+	"nl": {"het", "een", "en", "van", "voor", "met", "is", "niet", "op", "te", "dat", "zijn", "deze"},
+}
+
+// The languages written with the latin script, sorted so that ties are
+// always broken the same way.
+var latinLanguages = []string{"en", "de", "es", "fr", "it", "nl", "pt"}
+
+// The Text field of the documents whose language cannot be detected.
+const DefaultTextField = "default"
+
+// The minimum number of common words for text written with the latin script
+// to be considered written in a language.
+const minStopwords = 2
+
+// Detect the natural language of the text, as an ISO 639-1 code, e.g. "en" or
+// "ja". Returns "" if the language cannot be detected, e.g. for text that is
+// too short or only contains identifiers.
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, sl := range scriptLanguages {
+			if unicode.In(r, sl.scripts...) {
+				counts[sl.language]++
+				break
+			}
+		}
+	}
+	// A language with its own script is detected when at least a fifth of
+	// the letters are written with it: the rest is often latin identifiers.
+	for _, sl := range scriptLanguages {
+		if n := counts[sl.language]; n > 0 && n*5 >= letters {
+			return sl.language
+		}
+	}
+
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		for lang, common := range stopwords {
+			for _, s := range common {
+				if w == s {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+	language, best := "", minStopwords-1
+	for _, lang := range latinLanguages {
+		if scores[lang] > best {
+			language, best = lang, scores[lang]
+		}
+	}
+	return language
+}
+
+// The natural language text of the document: the description of its
+// repository, and the comments of its YAML data.
+func (doc *KustomizationDocument) naturalText() string {
+	var lines []string
+	if doc.RepositoryDescription != "" {
+		lines = append(lines, doc.RepositoryDescription)
+	}
+	for _, line := range strings.Split(doc.DocumentData, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		if comment := strings.TrimSpace(strings.TrimLeft(line, "#")); comment != "" {
+			lines = append(lines, comment)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Set the Language of the document from its natural language text, and index
+// that text in the Text field of the language, so that it is analyzed with the
+// analyzer of the language. The text of a document whose language cannot be
+// detected is indexed in the "default" field.
+func enrichLanguage(d *KustomizationDocument) error {
+	d.Language = ""
+	d.Text = nil
+	text := d.naturalText()
+	if text == "" {
+		return nil
+	}
+	d.Language = DetectLanguage(text)
+	field := d.Language
+	if field == "" {
+		field = DefaultTextField
+	}
+	d.Text = map[string]string{field: text}
+	return nil
+}
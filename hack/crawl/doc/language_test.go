@@ -0,0 +1,111 @@
+package doc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	testCases := []struct {
+		text     string
+		language string
+	}{
+		{
+			text:     "",
+			language: "",
+		},
+		{
+			text:     "kustomize",
+			language: "",
+		},
+		{
+			text:     "Kubernetes manifests for the deployment of this application",
+			language: "en",
+		},
+		{
+			text:     "Configuración de los servicios para el clúster de producción",
+			language: "es",
+		},
+		{
+			text:     "Les manifestes Kubernetes pour le déploiement des services",
+			language: "fr",
+		},
+		{
+			text:     "Die Konfiguration der Dienste für das Cluster",
+			language: "de",
+		},
+		{
+			text:     "Kubernetes のマニフェスト",
+			language: "ja",
+		},
+		{
+			text:     "Kubernetes 集群配置",
+			language: "zh",
+		},
+		{
+			text:     "쿠버네티스 설정",
+			language: "ko",
+		},
+		{
+			text:     "Манифесты для развертывания в Kubernetes",
+			language: "ru",
+		},
+	}
+
+	for _, tc := range testCases {
+		if language := DetectLanguage(tc.text); language != tc.language {
+			t.Errorf("expected language %q for %q, got %q",
+				tc.language, tc.text, language)
+		}
+	}
+}
+
+func TestEnrich_language(t *testing.T) {
+	d := KustomizationDocument{
+		Document: Document{
+			RepositoryURL:         "github.com/user/repo",
+			FilePath:              "base/kustomization.yaml",
+			DefaultBranch:         "master",
+			RepositoryDescription: "Configuración de Kubernetes para los servicios",
+			DocumentData: `# Recursos de la aplicación
+resources:
+- deployment.yaml
+`,
+		},
+	}
+	if err := d.Enrich(DefaultEnrichers()...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Language != "es" {
+		t.Errorf("expected language es, got %q", d.Language)
+	}
+	text := map[string]string{
+		"es": "Configuración de Kubernetes para los servicios\nRecursos de la aplicación",
+	}
+	if !reflect.DeepEqual(d.Text, text) {
+		t.Errorf("expected text %v, got %v", text, d.Text)
+	}
+
+	// The text of documents in an unknown language is still indexed.
+	d.RepositoryDescription = ""
+	d.DocumentData = "# kustomize\nresources:\n- deployment.yaml\n"
+	if err := d.Enrich(DefaultEnrichers()...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Language != "" {
+		t.Errorf("expected no language, got %q", d.Language)
+	}
+	text = map[string]string{DefaultTextField: "kustomize"}
+	if !reflect.DeepEqual(d.Text, text) {
+		t.Errorf("expected text %v, got %v", text, d.Text)
+	}
+
+	// Documents without natural language text have no language fields.
+	d.DocumentData = "resources:\n- deployment.yaml\n"
+	if err := d.Enrich(DefaultEnrichers()...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Language != "" || d.Text != nil {
+		t.Errorf("expected no language fields, got %q and %v", d.Language, d.Text)
+	}
+}
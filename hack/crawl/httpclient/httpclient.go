@@ -9,6 +9,10 @@ import (
 	rediscache "github.com/gregjones/httpcache/redis"
 )
 
+// Prefix of the redis keys of the cached responses, see
+// github.com/gregjones/httpcache/redis.
+const cacheKeyPrefix = "rediscache:"
+
 func FromCache(header http.Header) bool {
 	return header.Get(httpcache.XFromCache) != ""
 }
@@ -21,3 +25,35 @@ func NewClient(conn redis.Conn) *http.Client {
 		Timeout:   10 * time.Second,
 	}
 }
+
+// Delete the cached responses of the URLs matching the patterns (redis glob
+// patterns, e.g. https://api.github.com/repos/owner/*). Returns the number of
+// responses deleted.
+func PurgeCache(conn redis.Conn, patterns ...string) (int, error) {
+	deleted := 0
+	for _, pattern := range patterns {
+		cursor := "0"
+		for {
+			values, err := redis.Values(conn.Do(
+				"SCAN", cursor, "MATCH", cacheKeyPrefix+pattern, "COUNT", 1000))
+			if err != nil {
+				return deleted, err
+			}
+			var keys []string
+			if _, err := redis.Scan(values, &cursor, &keys); err != nil {
+				return deleted, err
+			}
+			if len(keys) > 0 {
+				n, err := redis.Int(conn.Do("DEL", redis.Args{}.AddFlat(keys)...))
+				if err != nil {
+					return deleted, err
+				}
+				deleted += n
+			}
+			if cursor == "0" {
+				break
+			}
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,83 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/kustomize/api/pgmconfig"
+
+	"sigs.k8s.io/kustomize/hack/crawl/doc"
+)
+
+// Build an elasticsearch query for the kustomization files, which are the
+// nodes of the kustomization graph.
+func KustomizationFilesQuery() map[string]interface{} {
+	should := make([]map[string]interface{}, 0)
+	for _, name := range pgmconfig.RecognizedKustomizationFileNames() {
+		should = append(should,
+			map[string]interface{}{
+				"term": map[string]interface{}{
+					"filePath.keyword": name,
+				},
+			},
+			map[string]interface{}{
+				"wildcard": map[string]interface{}{
+					"filePath.keyword": "*/" + name,
+				},
+			})
+	}
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		},
+	}
+}
+
+// Recompute the composition depth of every kustomization in the index from the
+// links stored by the crawlers, and update the kustomizations whose depth
+// changed. The depth of a kustomization depends on the kustomizations it
+// references, which may be indexed after it, so this is meant to run once a
+// crawl is done. Returns the number of kustomizations updated.
+func (ki *KustomizeIndex) UpdateCompositionDepths(batchSize int,
+	timeout time.Duration) (int, error) {
+
+	query, err := json.Marshal(KustomizationFilesQuery())
+	if err != nil {
+		return 0, fmt.Errorf("failed to format kustomization query: %v", err)
+	}
+
+	docs := make([]*doc.KustomizationDocument, 0)
+	// Elasticsearch IDs of the documents.
+	ids := make(map[string]string)
+	it := ki.IterateQuery(query, batchSize, timeout)
+	for it.Next() {
+		for _, hit := range it.Value().Hits.Hits {
+			d := hit.Document
+			docs = append(docs, &d)
+			ids[d.ID()] = hit.ID
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	depths := doc.CompositionDepths(docs)
+	for _, d := range docs {
+		depth := depths[d.ID()]
+		if depth == d.CompositionDepth {
+			continue
+		}
+		d.CompositionDepth = depth
+		if _, err := ki.Put(ids[d.ID()], d); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
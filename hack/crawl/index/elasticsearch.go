@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"time"
 
 	es "github.com/elastic/go-elasticsearch/v6"
@@ -264,3 +265,78 @@ func (idx *index) Delete(id string) error {
 		fmt.Sprintf("could not delete id(%s) from index(%s)", id, idx.name),
 		res, err, ignoreResponseBody)
 }
+
+// Delete the documents matching the query (json query dsl). Returns the number
+// of documents deleted.
+func (idx *index) DeleteByQuery(query []byte) (int, error) {
+	op := idx.client.DeleteByQuery
+	res, err := op(
+		[]string{idx.name},
+		bytes.NewReader(query),
+		op.WithContext(idx.ctx),
+		op.WithConflicts("proceed"),
+		op.WithRefresh(true),
+	)
+
+	var deleted int
+	err = idx.responseErrorOrNil(
+		fmt.Sprintf("could not delete documents matching %s", query),
+		res, err, readCount("deleted", &deleted))
+
+	return deleted, err
+}
+
+// Update the documents matching the query with the script of the query (json
+// query dsl). Returns the number of documents updated.
+func (idx *index) UpdateByQuery(query []byte) (int, error) {
+	op := idx.client.UpdateByQuery
+	res, err := op(
+		[]string{idx.name},
+		op.WithBody(bytes.NewReader(query)),
+		op.WithContext(idx.ctx),
+		op.WithConflicts("proceed"),
+		op.WithRefresh(true),
+	)
+
+	var updated int
+	err = idx.responseErrorOrNil(
+		fmt.Sprintf("could not update documents matching %s", query),
+		res, err, readCount("updated", &updated))
+
+	return updated, err
+}
+
+// Read a count (e.g. "deleted") from the response of a by query operation.
+func readCount(field string, count *int) readerFunc {
+	return func(reader io.Reader) error {
+		counts := map[string]interface{}{}
+		if err := json.NewDecoder(reader).Decode(&counts); err != nil {
+			return err
+		}
+		if n, ok := counts[field].(float64); ok {
+			*count = int(n)
+		}
+		return nil
+	}
+}
+
+// Get a document by Id, and use the reader func to extract the response.
+// Returns false if the document (or the index) does not exist.
+func (idx *index) Get(id string, responseReader readerFunc) (bool, error) {
+	op := idx.client.Get
+	res, err := op(
+		idx.name,
+		id,
+		op.WithContext(idx.ctx),
+	)
+	if err == nil && res != nil && res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return false, nil
+	}
+
+	err = idx.responseErrorOrNil(
+		fmt.Sprintf("could not get id(%s) from index(%s)", id, idx.name),
+		res, err, responseReader)
+
+	return err == nil, err
+}
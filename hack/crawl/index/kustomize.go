@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strconv"
 	"strings"
 	"time"
 
@@ -49,6 +50,21 @@ type KustomizeResult struct {
 				Count int    `json:"count"`
 			} `json:"buckets"`
 		} `json:"kinds,omitempty"`
+
+		Components *struct {
+			OtherCount int `json:"otherResults"`
+			Buckets    []struct {
+				Key   string `json:"key"`
+				Count int    `json:"count"`
+			} `json:"buckets"`
+		} `json:"components,omitempty"`
+
+		CompositionDepths *struct {
+			Buckets []struct {
+				Key   int `json:"key"`
+				Count int `json:"count"`
+			} `json:"buckets"`
+		} `json:"compositionDepths,omitempty"`
 	} `json:"aggregations,omitempty"`
 }
 
@@ -83,11 +99,32 @@ type ElasticKustomizeResult struct {
 				Count int    `json:"doc_count"`
 			}
 		} `json:"kinds,omitempty"`
+
+		Components *struct {
+			OtherCount int `json:"sum_other_doc_count"`
+			Buckets    []struct {
+				Key   string `json:"key"`
+				Count int    `json:"doc_count"`
+			}
+		} `json:"components,omitempty"`
+
+		CompositionDepths *struct {
+			Buckets []struct {
+				Key   int `json:"key"`
+				Count int `json:"doc_count"`
+			}
+		} `json:"compositionDepths,omitempty"`
 	} `json:"aggregations,omitempty"`
 }
 
 type KustomizeIndex struct {
 	*index
+	// Index containing the search configuration documents.
+	config *index
+	// Index containing the audit records of the purges.
+	audit *index
+	// Enrichers run on each document by Put, before it is inserted.
+	Enrichers []doc.Enricher
 }
 
 // Create index reference to the index containing the kustomize documents.
@@ -96,7 +133,51 @@ func NewKustomizeIndex(ctx context.Context) (*KustomizeIndex, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &KustomizeIndex{idx}, nil
+	config, err := newIndex(ctx, "kustomize-config")
+	if err != nil {
+		return nil, err
+	}
+	audit, err := newIndex(ctx, auditIndexName)
+	if err != nil {
+		return nil, err
+	}
+	return &KustomizeIndex{
+		index:     idx,
+		config:    config,
+		audit:     audit,
+		Enrichers: doc.DefaultEnrichers(),
+	}, nil
+}
+
+// Get the ranking configuration stored in elasticsearch. If none is stored,
+// the default ranking configuration is returned.
+func (ki *KustomizeIndex) GetRankingConfig() (*RankingConfig, error) {
+	var rc *RankingConfig
+	found, err := ki.config.Get(RankingConfigID, func(reader io.Reader) error {
+		var err error
+		rc, err = readRankingConfig(reader)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return DefaultRankingConfig(), nil
+	}
+	return rc, nil
+}
+
+// Store the ranking configuration in elasticsearch. Search services pick it
+// up the next time they reload their configuration.
+func (ki *KustomizeIndex) PutRankingConfig(rc *RankingConfig) error {
+	if err := rc.Validate(); err != nil {
+		return err
+	}
+	_, err := ki.config.Put(RankingConfigID, rc)
+	if err != nil {
+		return fmt.Errorf("could not store ranking config: %v", err)
+	}
+	return nil
 }
 
 // Return a timeseries of kustomization file counts.
@@ -130,32 +211,67 @@ func KindAggregation(maxBuckets int) (string, map[string]interface{}) {
 	}
 }
 
+// Return aggregation of results based off of the components they reference.
+func ComponentAggregation(maxBuckets int) (string, map[string]interface{}) {
+	if maxBuckets < 1 {
+		maxBuckets = 1
+	}
+	return "components", map[string]interface{}{
+		"terms": map[string]interface{}{
+			"field": "componentIds.keyword",
+			"size":  maxBuckets,
+		},
+	}
+}
+
+// Return aggregation of kustomization file counts by their composition depth,
+// ordered by depth. Files that are not kustomizations have no depth and are not
+// counted.
+func CompositionDepthAggregation(maxBuckets int) (string, map[string]interface{}) {
+	if maxBuckets < 1 {
+		maxBuckets = 1
+	}
+	return "compositionDepths", map[string]interface{}{
+		"terms": map[string]interface{}{
+			"field": "compositionDepth",
+			"size":  maxBuckets,
+			"order": map[string]interface{}{
+				"_key": "asc",
+			},
+		},
+	}
+}
+
 // The multi_match search type in elasticsearch will check each field according
 // to their respective analyzers for the identifier.
 func multiMatch(query string) map[string]interface{} {
+	return multiMatchFields(query, DefaultRankingConfig().fields())
+}
+
+func multiMatchFields(query string, fields []string) map[string]interface{} {
 	return map[string]interface{}{
 		"multi_match": map[string]interface{}{
-			"type": "cross_fields",
-			"fields": []string{
-				"values.keyword^3",
-				"identifiers.keyword^3",
-				"values.ngram",
-				"identifiers.ngram",
-				// TODO(damienr74) remove document with default
-				// analyzer. It does not handle special (=,: etc)
-				// characters properly, and matches with false
-				// positives. document.whitespace does not exist
-				// yet, but should use the whitespace analyzer.
-				"document",
-				"document.whitespace",
-			},
-			"query": query,
+			"type":   "cross_fields",
+			"fields": fields,
+			"query":  query,
 		},
 	}
 }
 
 // Build an elasticsearch query from a user query.
 func BuildQuery(query string) map[string]interface{} {
+	return BuildRankedQuery(query, DefaultRankingConfig())
+}
+
+// Build an elasticsearch query from a user query, weighing the fields and
+// scoring the results according to the ranking configuration.
+//
+// Besides text, the query may filter the results with the following facets:
+//	kind=Deployment       files containing a Deployment.
+//	component=monitoring  kustomizations using a component whose ID contains monitoring.
+//	depth=3               kustomizations stacking 3 levels of kustomizations.
+//	lang=ja               files whose repository description and comments are in Japanese.
+func BuildRankedQuery(query string, rc *RankingConfig) map[string]interface{} {
 	queryTokens := strings.Fields(query)
 	if len(queryTokens) == 0 {
 		return map[string]interface{}{
@@ -163,10 +279,12 @@ func BuildQuery(query string) map[string]interface{} {
 		}
 	}
 
+	fields := rc.fields()
 	mustMatch := make([]map[string]interface{}, len(queryTokens))
 
 	for i, tok := range queryTokens {
-		if strings.HasPrefix(strings.ToLower(tok), "kind=") {
+		lower := strings.ToLower(tok)
+		if strings.HasPrefix(lower, "kind=") {
 			mustMatch[i] = map[string]interface{}{
 				"term": map[string]interface{}{
 					"kinds.keyword": tok[5:],
@@ -174,20 +292,108 @@ func BuildQuery(query string) map[string]interface{} {
 			}
 			continue
 		}
-		mustMatch[i] = multiMatch(tok)
+		if strings.HasPrefix(lower, "component=") {
+			mustMatch[i] = map[string]interface{}{
+				"wildcard": map[string]interface{}{
+					"componentIds.keyword": "*" + tok[10:] + "*",
+				},
+			}
+			continue
+		}
+		if strings.HasPrefix(lower, "depth=") {
+			if depth, err := strconv.Atoi(tok[6:]); err == nil {
+				mustMatch[i] = map[string]interface{}{
+					"term": map[string]interface{}{
+						"compositionDepth": depth,
+					},
+				}
+				continue
+			}
+		}
+		if strings.HasPrefix(lower, "lang=") {
+			mustMatch[i] = languageFilter(lower[5:])
+			continue
+		}
+		mustMatch[i] = multiMatchFields(tok, fields)
 	}
 
 	structuredQuery := map[string]interface{}{
-		"query": map[string]interface{}{
+		"query": rc.wrap(map[string]interface{}{
 			"bool": map[string]interface{}{
 				"must": mustMatch,
 			},
-		},
+		}),
 	}
 
 	return structuredQuery
 }
 
+// Filter the documents written in the language, as detected by
+// doc.DetectLanguage.
+func languageFilter(language string) map[string]interface{} {
+	return map[string]interface{}{
+		"term": map[string]interface{}{
+			"language": language,
+		},
+	}
+}
+
+// The elasticsearch analyzers of the languages detected by doc.DetectLanguage.
+// Hebrew has no built-in analyzer, its text is analyzed with the standard
+// analyzer as the text of unknown languages is.
+var languageAnalyzers = map[string]string{
+	"ar":                 "arabic",
+	"de":                 "german",
+	"el":                 "greek",
+	"en":                 "english",
+	"es":                 "spanish",
+	"fr":                 "french",
+	"he":                 "standard",
+	"hi":                 "hindi",
+	"it":                 "italian",
+	"ja":                 "cjk",
+	"ko":                 "cjk",
+	"nl":                 "dutch",
+	"pt":                 "portuguese",
+	"ru":                 "russian",
+	"th":                 "thai",
+	"zh":                 "cjk",
+	doc.DefaultTextField: "standard",
+}
+
+// Return the index mappings of the language fields of the documents: the
+// language is a keyword used as a filter, and the text of each language is
+// analyzed with the analyzer of the language.
+func LanguageMappings() map[string]interface{} {
+	text := make(map[string]interface{}, len(languageAnalyzers))
+	for language, analyzer := range languageAnalyzers {
+		text[language] = map[string]interface{}{
+			"type":     "text",
+			"analyzer": analyzer,
+		}
+	}
+	return map[string]interface{}{
+		"properties": map[string]interface{}{
+			"language": map[string]interface{}{
+				"type": "keyword",
+			},
+			"text": map[string]interface{}{
+				"properties": text,
+			},
+		},
+	}
+}
+
+// Add the language fields to the mappings of the index. Documents indexed
+// before the mappings are added must be reindexed to be found by language.
+func (ki *KustomizeIndex) UpdateLanguageMappings() error {
+	mappings, err := json.Marshal(LanguageMappings())
+	if err != nil {
+		return fmt.Errorf("could not format language mappings: %v", err)
+	}
+	return ki.UpdateMapping(mappings)
+}
+
 // Iterator based off of the way bufio.Scanner works.
 //
 // Example:
@@ -274,8 +480,12 @@ func (ki *KustomizeIndex) IterateQuery(query []byte, batchSize int,
 	}
 }
 
-// type specific Put for inserting structured kustomization documents.
+// type specific Put for inserting structured kustomization documents. The
+// Enrichers of the index are run on the document before it is inserted.
 func (ki *KustomizeIndex) Put(id string, doc *doc.KustomizationDocument) (string, error) {
+	if err := doc.Enrich(ki.Enrichers...); err != nil {
+		return id, err
+	}
 	id, err := ki.index.Put(id, doc)
 	if err != nil {
 		return id, fmt.Errorf("could not insert in elastic: %v", err)
@@ -286,10 +496,17 @@ func (ki *KustomizeIndex) Put(id string, doc *doc.KustomizationDocument) (string
 // Kustomize search options: What metrics should be returned? Kind Aggregation,
 // TimeseriesAggregation, etc. Also embedds the SearchOptions field to specify
 // the position in the sorted list of results and the number of results to return.
+// Ranking specifies how results are scored, the default ranking configuration
+// is used if it is nil. Language restricts the results to the documents
+// written in the language, e.g. "es", if it is set.
 type KustomizeSearchOptions struct {
 	SearchOptions
-	KindAggregation       bool
-	TimeseriesAggregation bool
+	KindAggregation             bool
+	TimeseriesAggregation       bool
+	ComponentAggregation        bool
+	CompositionDepthAggregation bool
+	Ranking                     *RankingConfig
+	Language                    string
 }
 
 // Search the index with the given query string. Returns a structured result and possible
@@ -306,20 +523,114 @@ func (ki *KustomizeIndex) Search(query string,
 		t, tAgg := TimeseriesAggregation()
 		aggMap[t] = tAgg
 	}
+	if opts.ComponentAggregation {
+		c, cAgg := ComponentAggregation(15)
+		aggMap[c] = cAgg
+	}
+	if opts.CompositionDepthAggregation {
+		d, dAgg := CompositionDepthAggregation(20)
+		aggMap[d] = dAgg
+	}
+
+	ranking := opts.Ranking
+	if ranking == nil {
+		ranking = DefaultRankingConfig()
+	}
 
-	esQuery := BuildQuery(query)
+	esQuery := BuildRankedQuery(query, ranking)
+	if opts.Language != "" {
+		esQuery = filterLanguage(esQuery, strings.ToLower(opts.Language))
+	}
 	if len(aggMap) > 0 {
 		esQuery[AggregationKeyword] = aggMap
 	}
 
+	return ki.search(esQuery, opts.SearchOptions)
+}
+
+// Restrict the results of an elasticsearch query built by BuildRankedQuery to
+// the documents written in the language.
+func filterLanguage(esQuery map[string]interface{},
+	language string) map[string]interface{} {
+
+	query, ok := esQuery["query"]
+	if !ok {
+		return esQuery
+	}
+	esQuery["query"] = map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":   query,
+			"filter": languageFilter(language),
+		},
+	}
+	return esQuery
+}
+
+// Build an elasticsearch query for the resources (and bases) indexed as
+// referenced by the kustomization with the given document ID.
+func ResourcesQuery(kustomizationID string) map[string]interface{} {
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{
+				"kustomizationIds.keyword": kustomizationID,
+			},
+		},
+	}
+}
+
+// Build an elasticsearch query for the kustomizations referencing the
+// document as a resource or as a component, either by its ID, or by its
+// directory for kustomization files.
+func KustomizationsQuery(d *doc.Document) map[string]interface{} {
+	ids := d.ReferenceIDs()
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{
+						"terms": map[string]interface{}{
+							"resourceIds.keyword": ids,
+						},
+					},
+					{
+						"terms": map[string]interface{}{
+							"componentIds.keyword": ids,
+						},
+					},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+	}
+}
+
+// Get the resources referenced by the kustomization with the given document
+// ID, without having to traverse the kustomization graph.
+func (ki *KustomizeIndex) GetResources(kustomizationID string,
+	opts SearchOptions) (*KustomizeResult, error) {
+
+	return ki.search(ResourcesQuery(kustomizationID), opts)
+}
+
+// Get the kustomizations referencing the document, without having to traverse
+// the kustomization graph.
+func (ki *KustomizeIndex) GetKustomizations(d *doc.Document,
+	opts SearchOptions) (*KustomizeResult, error) {
+
+	return ki.search(KustomizationsQuery(d), opts)
+}
+
+func (ki *KustomizeIndex) search(esQuery map[string]interface{},
+	opts SearchOptions) (*KustomizeResult, error) {
+
 	data, err := json.Marshal(&esQuery)
 	if err != nil {
-		return nil, fmt.Errorf("failed to format query %s", query)
+		return nil, fmt.Errorf("failed to format query %v", esQuery)
 	}
 	fmt.Printf("formated query: %s\n", data)
 
 	var kr ElasticKustomizeResult
-	err = ki.index.Search(data, opts.SearchOptions, func(results io.Reader) error {
+	err = ki.index.Search(data, opts, func(results io.Reader) error {
 		data, err = ioutil.ReadAll(results)
 		if err != nil {
 			return fmt.Errorf("could not read results from search: %v", err)
@@ -3,6 +3,8 @@ package index
 import (
 	"reflect"
 	"testing"
+
+	"sigs.k8s.io/kustomize/hack/crawl/doc"
 )
 
 func TestBuildQuery(t *testing.T) {
@@ -61,6 +63,45 @@ func TestBuildQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			query: "component=monitoring depth=2 depth=two",
+			result: map[string]interface{}{
+				"query": map[string]interface{}{
+					"bool": map[string]interface{}{
+						"must": []map[string]interface{}{
+							{
+								"wildcard": map[string]interface{}{
+									"componentIds.keyword": "*monitoring*",
+								},
+							},
+							{
+								"term": map[string]interface{}{
+									"compositionDepth": 2,
+								},
+							},
+							multiMatch("depth=two"),
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "lang=JA ingress",
+			result: map[string]interface{}{
+				"query": map[string]interface{}{
+					"bool": map[string]interface{}{
+						"must": []map[string]interface{}{
+							{
+								"term": map[string]interface{}{
+									"language": "ja",
+								},
+							},
+							multiMatch("ingress"),
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -70,3 +111,81 @@ func TestBuildQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterLanguage(t *testing.T) {
+	result := filterLanguage(BuildQuery("ingress"), "es")
+	expected := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"bool": map[string]interface{}{
+						"must": []map[string]interface{}{
+							multiMatch("ingress"),
+						},
+					},
+				},
+				"filter": map[string]interface{}{
+					"term": map[string]interface{}{
+						"language": "es",
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected %#v to match %#v", result, expected)
+	}
+
+	// Empty queries have no results to filter.
+	empty := map[string]interface{}{"size": 0}
+	if result := filterLanguage(BuildQuery(""), "es"); !reflect.DeepEqual(empty, result) {
+		t.Errorf("Expected %#v to match %#v", result, empty)
+	}
+}
+
+func TestLanguageMappings(t *testing.T) {
+	properties := LanguageMappings()["properties"].(map[string]interface{})
+	text := properties["text"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, language := range []string{"en", "es", "ja", "ru", doc.DefaultTextField} {
+		if _, ok := text[language]; !ok {
+			t.Errorf("expected a text field for language %q", language)
+		}
+	}
+	expected := map[string]interface{}{"type": "text", "analyzer": "spanish"}
+	if !reflect.DeepEqual(text["es"], expected) {
+		t.Errorf("Expected %#v to match %#v", text["es"], expected)
+	}
+}
+
+func TestKustomizationsQuery(t *testing.T) {
+	d := &doc.Document{
+		RepositoryURL: "example.com/repo",
+		FilePath:      "base/kustomization.yaml",
+	}
+	ids := []string{
+		"example.com/repo//base/kustomization.yaml",
+		"example.com/repo//base",
+	}
+	expected := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{
+						"terms": map[string]interface{}{
+							"resourceIds.keyword": ids,
+						},
+					},
+					{
+						"terms": map[string]interface{}{
+							"componentIds.keyword": ids,
+						},
+					},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+	}
+	if result := KustomizationsQuery(d); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %#v to match %#v", result, expected)
+	}
+}
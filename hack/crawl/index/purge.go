@@ -0,0 +1,178 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Name of the index containing the audit records of the purges.
+	auditIndexName = "kustomize-audit"
+
+	// Remove the links to the purged documents from the kustomizationIds,
+	// resourceIds and componentIds of the other documents.
+	purgeReferencesScript = `for (field in ['kustomizationIds', 'resourceIds', 'componentIds']) {
+	if (ctx._source[field] != null) {
+		ctx._source[field].removeIf(id -> params.prefixes.stream().anyMatch(p -> id.startsWith(p)));
+	}
+}`
+)
+
+// Audit record of a purge, stored in the audit index so that takedown and
+// opt-out requests can be shown to have been honored.
+//
+// Example:
+//	{
+//		"target": "github.com/owner/repo",
+//		"requester": "owner@example.com",
+//		"reason": "opt-out request",
+//		"time": "2019-11-20T17:32:00Z",
+//		"documents": 12,
+//		"references": 3,
+//		"cacheEntries": 40
+//	}
+type PurgeRecord struct {
+	// Repository (host/owner/repo) or owner (host/owner) that was purged.
+	Target string `json:"target"`
+	// Who requested the purge, and why.
+	Requester string    `json:"requester,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Time      time.Time `json:"time"`
+	// Number of documents deleted.
+	Documents int `json:"documents"`
+	// Number of other documents whose links to the deleted documents were
+	// removed.
+	References int `json:"references"`
+	// Number of cached HTTP entries deleted.
+	CacheEntries int `json:"cacheEntries"`
+	// Error that interrupted the purge, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Normalize the repository or owner to purge, e.g.
+// https://github.com/owner/repo/ becomes github.com/owner/repo. The target must
+// include the host and the owner, and may not contain wildcards, so that a purge
+// never matches more than the requested repositories.
+func PurgeTarget(target string) (string, error) {
+	t := strings.TrimSpace(target)
+	for _, scheme := range []string{"https://", "http://"} {
+		t = strings.TrimPrefix(t, scheme)
+	}
+	t = strings.TrimSuffix(strings.TrimSuffix(t, "/"), ".git")
+	if strings.ContainsAny(t, "*?[]\\ ") {
+		return "", fmt.Errorf("purge target '%s' may not contain wildcards", target)
+	}
+	parts := strings.Split(t, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", fmt.Errorf(
+			"purge target '%s' must be a repository or an owner, e.g. github.com/owner", target)
+	}
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid purge target '%s'", target)
+		}
+	}
+	return t, nil
+}
+
+// The repository URLs of the target, which are stored with or without a
+// scheme. The IDs of the documents of the target start with one of them
+// followed by a "/".
+func purgeURLs(target string) []string {
+	return []string{target, "https://" + target, "http://" + target}
+}
+
+// Build an elasticsearch query for the documents in the repositories of the
+// (normalized) target.
+func PurgeQuery(target string) map[string]interface{} {
+	should := make([]map[string]interface{}, 0)
+	for _, url := range purgeURLs(target) {
+		should = append(should,
+			map[string]interface{}{
+				"term": map[string]interface{}{
+					"repositoryUrl.keyword": url,
+				},
+			},
+			map[string]interface{}{
+				"prefix": map[string]interface{}{
+					"repositoryUrl.keyword": url + "/",
+				},
+			})
+	}
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		},
+	}
+}
+
+// Build an elasticsearch update query removing the links of the other
+// documents to the documents of the target, so that no edge of the kustomization
+// graph points to a purged document.
+func PurgeReferencesQuery(target string) map[string]interface{} {
+	prefixes := make([]string, 0)
+	should := make([]map[string]interface{}, 0)
+	for _, url := range purgeURLs(target) {
+		prefixes = append(prefixes, url+"/")
+		for _, field := range []string{"kustomizationIds.keyword",
+			"resourceIds.keyword", "componentIds.keyword"} {
+			should = append(should, map[string]interface{}{
+				"prefix": map[string]interface{}{
+					field: url + "/",
+				},
+			})
+		}
+	}
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		},
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": purgeReferencesScript,
+			"params": map[string]interface{}{
+				"prefixes": prefixes,
+			},
+		},
+	}
+}
+
+// Delete the documents of the (normalized) target, and the links of the other
+// documents to them. Returns the number of documents deleted, and the number
+// of documents whose links were removed.
+func (ki *KustomizeIndex) Purge(target string) (int, int, error) {
+	query, err := json.Marshal(PurgeQuery(target))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to format purge query: %v", err)
+	}
+	deleted, err := ki.index.DeleteByQuery(query)
+	if err != nil {
+		return deleted, 0, err
+	}
+
+	query, err = json.Marshal(PurgeReferencesQuery(target))
+	if err != nil {
+		return deleted, 0, fmt.Errorf("failed to format purge query: %v", err)
+	}
+	updated, err := ki.index.UpdateByQuery(query)
+	return deleted, updated, err
+}
+
+// Store the audit record of a purge.
+func (ki *KustomizeIndex) PutPurgeRecord(pr *PurgeRecord) (string, error) {
+	id, err := ki.audit.Put("", pr)
+	if err != nil {
+		return id, fmt.Errorf("could not store purge record: %v", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,119 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPurgeTarget(t *testing.T) {
+	testCases := []struct {
+		target   string
+		expected string
+		isErr    bool
+	}{
+		{
+			target:   "github.com/owner/repo",
+			expected: "github.com/owner/repo",
+		},
+		{
+			target:   " https://github.com/owner/repo.git/\n",
+			expected: "github.com/owner/repo",
+		},
+		{
+			target:   "http://github.com/owner",
+			expected: "github.com/owner",
+		},
+		{
+			target: "github.com",
+			isErr:  true,
+		},
+		{
+			target: "github.com/owner/repo/path",
+			isErr:  true,
+		},
+		{
+			target: "github.com//repo",
+			isErr:  true,
+		},
+		{
+			target: "github.com/../repo",
+			isErr:  true,
+		},
+		{
+			target: "github.com/owner*",
+			isErr:  true,
+		},
+	}
+
+	for _, test := range testCases {
+		target, err := PurgeTarget(test.target)
+		if test.isErr {
+			if err == nil {
+				t.Errorf("expected an error for target %q, got %q", test.target, target)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for target %q: %v", test.target, err)
+			continue
+		}
+		if target != test.expected {
+			t.Errorf("expected target %q, got %q", test.expected, target)
+		}
+	}
+}
+
+func TestPurgeQuery(t *testing.T) {
+	term := func(url string) map[string]interface{} {
+		return map[string]interface{}{
+			"term": map[string]interface{}{"repositoryUrl.keyword": url},
+		}
+	}
+	prefix := func(field, url string) map[string]interface{} {
+		return map[string]interface{}{
+			"prefix": map[string]interface{}{field: url},
+		}
+	}
+
+	expected := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					term("github.com/owner"),
+					prefix("repositoryUrl.keyword", "github.com/owner/"),
+					term("https://github.com/owner"),
+					prefix("repositoryUrl.keyword", "https://github.com/owner/"),
+					term("http://github.com/owner"),
+					prefix("repositoryUrl.keyword", "http://github.com/owner/"),
+				},
+				"minimum_should_match": 1,
+			},
+		},
+	}
+	if q := PurgeQuery("github.com/owner"); !reflect.DeepEqual(q, expected) {
+		t.Errorf("expected query %#v, got %#v", expected, q)
+	}
+
+	q := PurgeReferencesQuery("github.com/owner/repo")
+	should := q["query"].(map[string]interface{})["bool"].(map[string]interface{})["should"]
+	expectedShould := []map[string]interface{}{
+		prefix("kustomizationIds.keyword", "github.com/owner/repo/"),
+		prefix("resourceIds.keyword", "github.com/owner/repo/"),
+		prefix("componentIds.keyword", "github.com/owner/repo/"),
+		prefix("kustomizationIds.keyword", "https://github.com/owner/repo/"),
+		prefix("resourceIds.keyword", "https://github.com/owner/repo/"),
+		prefix("componentIds.keyword", "https://github.com/owner/repo/"),
+		prefix("kustomizationIds.keyword", "http://github.com/owner/repo/"),
+		prefix("resourceIds.keyword", "http://github.com/owner/repo/"),
+		prefix("componentIds.keyword", "http://github.com/owner/repo/"),
+	}
+	if !reflect.DeepEqual(should, expectedShould) {
+		t.Errorf("expected references query %#v, got %#v", expectedShould, should)
+	}
+	params := q["script"].(map[string]interface{})["params"].(map[string]interface{})
+	expectedPrefixes := []string{"github.com/owner/repo/",
+		"https://github.com/owner/repo/", "http://github.com/owner/repo/"}
+	if !reflect.DeepEqual(params["prefixes"], expectedPrefixes) {
+		t.Errorf("expected prefixes %v, got %v", expectedPrefixes, params["prefixes"])
+	}
+}
@@ -0,0 +1,202 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+)
+
+const (
+	// Document id of the ranking configuration in the configuration index.
+	RankingConfigID = "ranking"
+)
+
+// Ranking configuration for the kustomize search results. It is stored as a
+// document in elasticsearch so that the relevance of the results can be
+// tuned without redeploying the search service.
+//
+// Example:
+//	{
+//		"fieldBoosts": {
+//			"values.keyword": 3,
+//			"identifiers.keyword": 3,
+//			"values.ngram": 1,
+//			"identifiers.ngram": 1,
+//			"document": 1,
+//			"document.whitespace": 1
+//		},
+//		"recency": { "scale": "180d", "offset": "30d", "decay": 0.5 },
+//		"popularity": { "field": "stars", "weight": 0.1 }
+//	}
+type RankingConfig struct {
+	// Fields searched by the multi_match query along with their boost. A
+	// field with a boost of 0 or less is not searched.
+	FieldBoosts map[string]float64 `json:"fieldBoosts,omitempty"`
+
+	// Favor documents that were created recently. Disabled if nil or if
+	// the scale is empty.
+	Recency *RecencyDecay `json:"recency,omitempty"`
+
+	// Favor documents with a higher popularity metric. Disabled if nil,
+	// if the field is empty or if the weight is 0 or less.
+	Popularity *PopularityWeight `json:"popularity,omitempty"`
+}
+
+// Gaussian decay of the score based off of the creationTime of a document.
+// Documents within Offset of now are not penalized, documents Offset+Scale
+// from now have their score multiplied by Decay.
+type RecencyDecay struct {
+	Scale  string  `json:"scale,omitempty"`
+	Offset string  `json:"offset,omitempty"`
+	Decay  float64 `json:"decay,omitempty"`
+}
+
+// Score contribution of a numeric field of the document. The value is
+// smoothed with log1p so that very popular documents do not overwhelm the
+// text relevance.
+type PopularityWeight struct {
+	Field  string  `json:"field,omitempty"`
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// Ranking configuration used when none is stored in elasticsearch. Matches
+// the boosts the search service has always used, along with the natural
+// language text of the documents analyzed for its language.
+func DefaultRankingConfig() *RankingConfig {
+	return &RankingConfig{
+		FieldBoosts: map[string]float64{
+			"values.keyword":      3,
+			"identifiers.keyword": 3,
+			"values.ngram":        1,
+			"identifiers.ngram":   1,
+			// TODO(damienr74) remove document with default
+			// analyzer. It does not handle special (=,: etc)
+			// characters properly, and matches with false
+			// positives. document.whitespace does not exist
+			// yet, but should use the whitespace analyzer.
+			"document":            1,
+			"document.whitespace": 1,
+			"text.*":              1,
+		},
+	}
+}
+
+// Check that the configuration can be used to build a query.
+func (rc *RankingConfig) Validate() error {
+	if len(rc.fields()) == 0 {
+		return fmt.Errorf("ranking config must boost at least one field")
+	}
+	if rc.recencyEnabled() && (rc.Recency.Decay <= 0 || rc.Recency.Decay >= 1) {
+		return fmt.Errorf("recency decay must be in (0, 1), got %v",
+			rc.Recency.Decay)
+	}
+	return nil
+}
+
+// Fields in the multi_match format (field^boost), sorted so that the
+// generated queries are stable.
+func (rc *RankingConfig) fields() []string {
+	fields := make([]string, 0, len(rc.FieldBoosts))
+	for field, boost := range rc.FieldBoosts {
+		switch {
+		case boost <= 0:
+			continue
+		case boost == 1:
+			fields = append(fields, field)
+		default:
+			fields = append(fields, field+"^"+
+				strconv.FormatFloat(boost, 'f', -1, 64))
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (rc *RankingConfig) recencyEnabled() bool {
+	return rc.Recency != nil && rc.Recency.Scale != ""
+}
+
+func (rc *RankingConfig) popularityEnabled() bool {
+	return rc.Popularity != nil && rc.Popularity.Field != "" &&
+		rc.Popularity.Weight > 0
+}
+
+// Score functions for the function_score query. Empty if the ranking only
+// depends on the text relevance.
+func (rc *RankingConfig) scoreFunctions() []map[string]interface{} {
+	functions := make([]map[string]interface{}, 0)
+	if rc.recencyEnabled() {
+		decay := map[string]interface{}{
+			"origin": "now",
+			"scale":  rc.Recency.Scale,
+		}
+		if rc.Recency.Offset != "" {
+			decay["offset"] = rc.Recency.Offset
+		}
+		if rc.Recency.Decay > 0 {
+			decay["decay"] = rc.Recency.Decay
+		}
+		functions = append(functions, map[string]interface{}{
+			"gauss": map[string]interface{}{
+				"creationTime": decay,
+			},
+		})
+	}
+	if rc.popularityEnabled() {
+		functions = append(functions, map[string]interface{}{
+			"field_value_factor": map[string]interface{}{
+				"field":    rc.Popularity.Field,
+				"modifier": "log1p",
+				"missing":  0,
+			},
+			"weight": rc.Popularity.Weight,
+		})
+	}
+	return functions
+}
+
+// Wrap the query in a function_score query if the configuration has score
+// functions. The functions are summed (when a function does not apply, it
+// should not zero out the others) and multiplied by the text relevance.
+func (rc *RankingConfig) wrap(query map[string]interface{}) map[string]interface{} {
+	functions := rc.scoreFunctions()
+	if len(functions) == 0 {
+		return query
+	}
+	return map[string]interface{}{
+		"function_score": map[string]interface{}{
+			"query":      query,
+			"functions":  functions,
+			"score_mode": "sum",
+			"boost_mode": "multiply",
+		},
+	}
+}
+
+// Parse a ranking configuration from the elasticsearch get document API
+// response.
+func readRankingConfig(reader io.Reader) (*RankingConfig, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not read ranking config: %v", err)
+	}
+
+	var res struct {
+		Source *RankingConfig `json:"_source"`
+	}
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("could not parse ranking config %s: %v",
+			data, err)
+	}
+	if res.Source == nil {
+		return nil, fmt.Errorf("ranking config document is empty")
+	}
+	if err = res.Source.Validate(); err != nil {
+		return nil, err
+	}
+
+	return res.Source, nil
+}
@@ -0,0 +1,206 @@
+package index
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRankingConfigFields(t *testing.T) {
+	testCases := []struct {
+		boosts map[string]float64
+		fields []string
+	}{
+		{
+			boosts: DefaultRankingConfig().FieldBoosts,
+			fields: []string{
+				"document",
+				"document.whitespace",
+				"identifiers.keyword^3",
+				"identifiers.ngram",
+				"text.*",
+				"values.keyword^3",
+				"values.ngram",
+			},
+		},
+		{
+			boosts: map[string]float64{
+				"values.keyword": 2.5,
+				"document":       0,
+				"identifiers":    -1,
+			},
+			fields: []string{
+				"values.keyword^2.5",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		rc := RankingConfig{FieldBoosts: tc.boosts}
+		if fields := rc.fields(); !reflect.DeepEqual(fields, tc.fields) {
+			t.Errorf("Expected %v to be equal to %v\n", fields, tc.fields)
+		}
+	}
+}
+
+func TestRankingConfigValidate(t *testing.T) {
+	testCases := []struct {
+		config *RankingConfig
+		err    bool
+	}{
+		{
+			config: DefaultRankingConfig(),
+		},
+		{
+			config: &RankingConfig{
+				FieldBoosts: map[string]float64{"document": 0},
+			},
+			err: true,
+		},
+		{
+			config: &RankingConfig{
+				FieldBoosts: map[string]float64{"document": 1},
+				Recency:     &RecencyDecay{Scale: "30d", Decay: 1},
+			},
+			err: true,
+		},
+		{
+			config: &RankingConfig{
+				FieldBoosts: map[string]float64{"document": 1},
+				Recency:     &RecencyDecay{Scale: "30d", Decay: 0.5},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.config.Validate()
+		if (err != nil) != tc.err {
+			t.Errorf("Expected error %v, got %v for %v\n",
+				tc.err, err, tc.config)
+		}
+	}
+}
+
+func TestBuildRankedQuery(t *testing.T) {
+	rc := &RankingConfig{
+		FieldBoosts: map[string]float64{"document": 2},
+		Recency: &RecencyDecay{
+			Scale:  "180d",
+			Offset: "30d",
+			Decay:  0.5,
+		},
+		Popularity: &PopularityWeight{
+			Field:  "stars",
+			Weight: 0.1,
+		},
+	}
+
+	expected := map[string]interface{}{
+		"query": map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query": map[string]interface{}{
+					"bool": map[string]interface{}{
+						"must": []map[string]interface{}{
+							multiMatchFields("identifier",
+								[]string{"document^2"}),
+						},
+					},
+				},
+				"functions": []map[string]interface{}{
+					{
+						"gauss": map[string]interface{}{
+							"creationTime": map[string]interface{}{
+								"origin": "now",
+								"scale":  "180d",
+								"offset": "30d",
+								"decay":  0.5,
+							},
+						},
+					},
+					{
+						"field_value_factor": map[string]interface{}{
+							"field":    "stars",
+							"modifier": "log1p",
+							"missing":  0,
+						},
+						"weight": 0.1,
+					},
+				},
+				"score_mode": "sum",
+				"boost_mode": "multiply",
+			},
+		},
+	}
+
+	if result := BuildRankedQuery("identifier", rc); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v to be equal to %v\n", result, expected)
+	}
+
+	// Without score functions, the query is not wrapped.
+	rc.Recency = nil
+	rc.Popularity.Weight = 0
+	expected = map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []map[string]interface{}{
+					multiMatchFields("identifier",
+						[]string{"document^2"}),
+				},
+			},
+		},
+	}
+	if result := BuildRankedQuery("identifier", rc); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v to be equal to %v\n", result, expected)
+	}
+}
+
+func TestReadRankingConfig(t *testing.T) {
+	testCases := []struct {
+		response string
+		config   *RankingConfig
+		err      bool
+	}{
+		{
+			response: `{
+				"_index": "kustomize-config",
+				"_id": "ranking",
+				"found": true,
+				"_source": {
+					"fieldBoosts": { "values.keyword": 4 },
+					"popularity": { "field": "stars", "weight": 0.2 }
+				}
+			}`,
+			config: &RankingConfig{
+				FieldBoosts: map[string]float64{"values.keyword": 4},
+				Popularity: &PopularityWeight{
+					Field:  "stars",
+					Weight: 0.2,
+				},
+			},
+		},
+		{
+			response: `{ "found": true }`,
+			err:      true,
+		},
+		{
+			response: `{ "found": true, "_source": { "fieldBoosts": {} } }`,
+			err:      true,
+		},
+		{
+			response: `not json`,
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		rc, err := readRankingConfig(strings.NewReader(tc.response))
+		if (err != nil) != tc.err {
+			t.Errorf("Expected error %v, got %v for %s\n",
+				tc.err, err, tc.response)
+			continue
+		}
+		if !reflect.DeepEqual(rc, tc.config) {
+			t.Errorf("Expected %v to be equal to %v\n", rc, tc.config)
+		}
+	}
+}
@@ -0,0 +1,552 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// requirement is a node selector or label selector requirement
+type requirement struct {
+	Key      string   `yaml:"key"`
+	Operator string   `yaml:"operator"`
+	Values   []string `yaml:"values"`
+}
+
+// matches returns true if a label with the value -- or no label if present is false --
+// satisfies the requirement
+func (r requirement) matches(present bool, value string) bool {
+	switch r.Operator {
+	case "In":
+		return present && contains(r.Values, value)
+	case "NotIn":
+		return !present || !contains(r.Values, value)
+	case "Exists":
+		return present
+	case "DoesNotExist":
+		return !present
+	case "Gt", "Lt":
+		if !present || len(r.Values) != 1 {
+			return false
+		}
+		v, err := strconv.Atoi(value)
+		bound, boundErr := strconv.Atoi(r.Values[0])
+		if err != nil || boundErr != nil {
+			return false
+		}
+		return (r.Operator == "Gt" && v > bound) || (r.Operator == "Lt" && v < bound)
+	default:
+		// unknown operators are rejected by the API server, not reported here
+		return true
+	}
+}
+
+// labelSelector is a label selector -- e.g. of pod affinity terms
+type labelSelector struct {
+	MatchLabels      map[string]string `yaml:"matchLabels"`
+	MatchExpressions []requirement     `yaml:"matchExpressions"`
+}
+
+// matches returns true if the selector selects the labels
+func (s labelSelector) matches(labels map[string]string) bool {
+	for k, v := range s.MatchLabels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	for _, r := range s.MatchExpressions {
+		v, present := labels[r.Key]
+		if !r.matches(present, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// unsatisfiable returns the first key whose requirements no label can satisfy, or ""
+// if the requirements may all be satisfied.  The candidate values of each key are the
+// values of its requirements, the integers adjacent to their bounds, and any other value
+// -- or no label.
+func unsatisfiable(reqs []requirement) string {
+	var keys []string
+	byKey := map[string][]requirement{}
+	for _, r := range reqs {
+		if _, found := byKey[r.Key]; !found {
+			keys = append(keys, r.Key)
+		}
+		byKey[r.Key] = append(byKey[r.Key], r)
+	}
+	for _, k := range keys {
+		candidates := []string{"\x00other"}
+		for _, r := range byKey[k] {
+			candidates = append(candidates, r.Values...)
+			if r.Operator == "Gt" || r.Operator == "Lt" {
+				for _, v := range r.Values {
+					if n, err := strconv.Atoi(v); err == nil {
+						candidates = append(candidates, strconv.Itoa(n+1), strconv.Itoa(n-1))
+					}
+				}
+			}
+		}
+		satisfied := func(present bool, value string) bool {
+			for _, r := range byKey[k] {
+				if !r.matches(present, value) {
+					return false
+				}
+			}
+			return true
+		}
+		ok := satisfied(false, "")
+		for i := 0; !ok && i < len(candidates); i++ {
+			ok = satisfied(true, candidates[i])
+		}
+		if !ok {
+			return k
+		}
+	}
+	return ""
+}
+
+// nodeSelectorRequirements returns the nodeSelector of the pod spec as In requirements
+func nodeSelectorRequirements(spec *yaml.RNode) ([]requirement, error) {
+	selector := map[string]string{}
+	if err := decodeField(spec, &selector, "nodeSelector"); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for k := range selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var reqs []requirement
+	for _, k := range keys {
+		reqs = append(reqs, requirement{Key: k, Operator: "In", Values: []string{selector[k]}})
+	}
+	return reqs, nil
+}
+
+// decodeField decodes the field at path into v, if it is set
+func decodeField(node *yaml.RNode, v interface{}, path ...string) error {
+	f, err := node.Pipe(yaml.Lookup(path...))
+	if err != nil || yaml.IsMissingOrNull(f) {
+		return err
+	}
+	return f.YNode().Decode(v)
+}
+
+// podLabels returns the labels of the pods of the workload
+func podLabels(node *yaml.RNode, meta yaml.ResourceMeta) (map[string]string, error) {
+	path := podSpecPaths[meta.Kind]
+	path = append(append([]string{}, path[:len(path)-1]...), "metadata", "labels")
+	labels := map[string]string{}
+	err := decodeField(node, &labels, path...)
+	return labels, err
+}
+
+// elements returns the elements of the list at path, or nil if it is not set
+func elements(node *yaml.RNode, path ...string) ([]*yaml.RNode, error) {
+	list, err := node.Pipe(yaml.Lookup(path...))
+	if err != nil || yaml.IsMissingOrNull(list) {
+		return nil, err
+	}
+	return list.Elements()
+}
+
+// NodeAffinityCheck checks that the required node affinity of each workload may be
+// satisfied by some node, given its nodeSelector.  The pods of a workload whose terms
+// all contradict themselves or the nodeSelector can never be scheduled, and preferred
+// terms which contradict the nodeSelector never apply.
+type NodeAffinityCheck struct{}
+
+func (NodeAffinityCheck) Name() string { return "node-affinity" }
+
+func (NodeAffinityCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	selector, err := nodeSelectorRequirements(spec)
+	if err != nil {
+		return nil, err
+	}
+	nodeAffinity := []string{"affinity", "nodeAffinity"}
+	nodeAffinityPath := specPath + ".affinity.nodeAffinity"
+
+	var results []Result
+	// contradiction returns why the term can never match, or "" if it may
+	contradiction := func(term *yaml.RNode) (string, error) {
+		var reqs []requirement
+		if err := decodeField(term, &reqs, "matchExpressions"); err != nil {
+			return "", err
+		}
+		if key := unsatisfiable(reqs); key != "" {
+			return fmt.Sprintf("the requirements of label '%s' contradict each other", key), nil
+		}
+		if key := unsatisfiable(append(reqs, selector...)); key != "" {
+			return fmt.Sprintf("the requirements of label '%s' contradict the nodeSelector", key), nil
+		}
+		return "", nil
+	}
+
+	required := append(nodeAffinity, "requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+	terms, err := elements(spec, required...)
+	if err != nil {
+		return nil, err
+	}
+	var termResults []Result
+	for i := range terms {
+		reason, err := contradiction(terms[i])
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+		termResults = append(termResults, Result{
+			Line: terms[i].YNode().Line,
+			Field: fmt.Sprintf("%s.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[%d]",
+				nodeAffinityPath, i),
+			Message: "node selector term can never match: " + reason,
+		})
+	}
+	// the terms are ORed -- the pods may still be scheduled unless they all contradict
+	for i := range termResults {
+		termResults[i].Severity = SeverityWarning
+		if len(termResults) == len(terms) {
+			termResults[i].Severity = SeverityError
+			termResults[i].Message += " -- no term may match, pods can never be scheduled"
+		}
+	}
+	results = append(results, termResults...)
+
+	preferred, err := elements(spec, append(nodeAffinity, "preferredDuringSchedulingIgnoredDuringExecution")...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range preferred {
+		term, err := preferred[i].Pipe(yaml.Lookup("preference"))
+		if err != nil {
+			return nil, err
+		}
+		if term == nil {
+			continue
+		}
+		reason, err := contradiction(term)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+		results = append(results, Result{
+			Severity: SeverityWarning,
+			Line:     term.YNode().Line,
+			Field: fmt.Sprintf("%s.preferredDuringSchedulingIgnoredDuringExecution[%d].preference",
+				nodeAffinityPath, i),
+			Message: "preferred node affinity never applies: " + reason,
+		})
+	}
+	return results, nil
+}
+
+// podAffinityTerm is a required pod affinity or anti-affinity term
+type podAffinityTerm struct {
+	LabelSelector labelSelector `yaml:"labelSelector"`
+	Namespaces    []string      `yaml:"namespaces"`
+	TopologyKey   string        `yaml:"topologyKey"`
+}
+
+// zoneTopologyKeys are the well-known topology keys of zones
+var zoneTopologyKeys = map[string]bool{
+	"topology.kubernetes.io/zone":              true,
+	"failure-domain.beta.kubernetes.io/zone":   true,
+	"topology.kubernetes.io/region":            true,
+	"failure-domain.beta.kubernetes.io/region": true,
+}
+
+// maxZones is the number of zones beyond which requiring a zone per replica looks
+// unsatisfiable -- most regions have 3 zones.
+const maxZones = 3
+
+// PodAffinityCheck checks that the required pod affinity and anti-affinity of each
+// workload don't contradict each other -- requiring pods to be both co-located with and
+// apart from the same pods -- and that the workload doesn't require more zones than
+// regions typically have to keep its replicas apart.
+type PodAffinityCheck struct{}
+
+func (PodAffinityCheck) Name() string { return "pod-affinity" }
+
+func (PodAffinityCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	labels, err := podLabels(node, meta)
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := value(node, "spec", "replicas")
+	if err != nil {
+		return nil, err
+	}
+
+	terms := map[string][]podAffinityTerm{}
+	nodes := map[string][]*yaml.RNode{}
+	for _, kind := range []string{"podAffinity", "podAntiAffinity"} {
+		nodes[kind], err = elements(spec, "affinity", kind, "requiredDuringSchedulingIgnoredDuringExecution")
+		if err != nil {
+			return nil, err
+		}
+		for i := range nodes[kind] {
+			t := podAffinityTerm{}
+			if err := nodes[kind][i].YNode().Decode(&t); err != nil {
+				return nil, err
+			}
+			terms[kind] = append(terms[kind], t)
+		}
+	}
+
+	var results []Result
+	field := func(kind string, i int) string {
+		return fmt.Sprintf("%s.affinity.%s.requiredDuringSchedulingIgnoredDuringExecution[%d]",
+			specPath, kind, i)
+	}
+	for i, anti := range terms["podAntiAffinity"] {
+		for _, affinity := range terms["podAffinity"] {
+			if anti.TopologyKey != affinity.TopologyKey ||
+				!reflect.DeepEqual(anti.LabelSelector, affinity.LabelSelector) ||
+				!reflect.DeepEqual(anti.Namespaces, affinity.Namespaces) {
+				continue
+			}
+			results = append(results, Result{
+				Severity: SeverityError,
+				Line:     nodes["podAntiAffinity"][i].YNode().Line,
+				Field:    field("podAntiAffinity", i),
+				Message: fmt.Sprintf("pod anti-affinity contradicts pod affinity for the same pods "+
+					"and topology key '%s' -- pods can never be scheduled", anti.TopologyKey),
+			})
+			break
+		}
+
+		n, err := strconv.Atoi(replicas)
+		if err != nil || n <= maxZones || !zoneTopologyKeys[anti.TopologyKey] ||
+			!anti.LabelSelector.matches(labels) {
+			continue
+		}
+		results = append(results, Result{
+			Severity: SeverityWarning,
+			Line:     nodes["podAntiAffinity"][i].YNode().Line,
+			Field:    field("podAntiAffinity", i),
+			Message: fmt.Sprintf("pod anti-affinity requires a separate '%s' for each of the %d "+
+				"replicas -- pods beyond the number of zones can't be scheduled", anti.TopologyKey, n),
+		})
+	}
+	return results, nil
+}
+
+// taintEffects are the valid effects of tolerations
+var taintEffects = map[string]bool{
+	"":                 true,
+	"NoSchedule":       true,
+	"PreferNoSchedule": true,
+	"NoExecute":        true,
+}
+
+// TolerationsCheck checks that the tolerations of each workload are well formed, and
+// warns of tolerations which tolerate all taints.  DaemonSets typically tolerate all
+// taints to run on every node, so they aren't warned of.
+type TolerationsCheck struct{}
+
+func (TolerationsCheck) Name() string { return "tolerations" }
+
+func (TolerationsCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	tolerations, err := elements(spec, "tolerations")
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for i := range tolerations {
+		t := struct {
+			Key               string `yaml:"key"`
+			Operator          string `yaml:"operator"`
+			Value             string `yaml:"value"`
+			Effect            string `yaml:"effect"`
+			TolerationSeconds *int   `yaml:"tolerationSeconds"`
+		}{}
+		if err := tolerations[i].YNode().Decode(&t); err != nil {
+			return nil, err
+		}
+		result := func(severity Severity, field, format string, args ...interface{}) {
+			results = append(results, Result{
+				Severity: severity,
+				Line:     tolerations[i].YNode().Line,
+				Field:    fmt.Sprintf("%s.tolerations[%d]%s", specPath, i, field),
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		switch t.Operator {
+		case "", "Equal":
+			if t.Key == "" {
+				result(SeverityError, ".key", "toleration with operator Equal must set a key")
+			}
+		case "Exists":
+			if t.Value != "" {
+				result(SeverityError, ".value", "toleration with operator Exists must not set a value")
+			}
+			if t.Key == "" && t.Effect == "" && meta.Kind != "DaemonSet" {
+				result(SeverityWarning, "", "toleration tolerates all taints -- pods may be scheduled "+
+					"onto any node, including dedicated and unhealthy nodes")
+			}
+		default:
+			result(SeverityError, ".operator", "unknown toleration operator '%s'", t.Operator)
+		}
+		if !taintEffects[t.Effect] {
+			result(SeverityError, ".effect", "unknown taint effect '%s'", t.Effect)
+		}
+		if t.TolerationSeconds != nil && t.Effect != "NoExecute" {
+			result(SeverityWarning, ".tolerationSeconds",
+				"tolerationSeconds only applies to the NoExecute effect, it is ignored")
+		}
+	}
+	return results, nil
+}
+
+// replicatedKinds are the workload kinds which run replicas of the same pod
+var replicatedKinds = map[string]bool{
+	"Deployment":            true,
+	"StatefulSet":           true,
+	"ReplicaSet":            true,
+	"ReplicationController": true,
+}
+
+// TopologySpreadCheck checks that each replicated workload spreads its pods with
+// topology spread constraints or pod anti-affinity, so that a single node or zone failure
+// doesn't take down all of its replicas, and that the constraints are well formed and
+// select the pods of the workload.  Workloads with 0 or 1 replicas aren't required to
+// spread their pods.  Missing spread constraints are reported with the Severity.
+type TopologySpreadCheck struct {
+	Severity Severity
+}
+
+func (TopologySpreadCheck) Name() string { return "topology-spread" }
+
+func (c TopologySpreadCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	labels, err := podLabels(node, meta)
+	if err != nil {
+		return nil, err
+	}
+	constraints, err := elements(spec, "topologySpreadConstraints")
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	seen := map[[2]string]bool{}
+	for i := range constraints {
+		t := struct {
+			MaxSkew           *int           `yaml:"maxSkew"`
+			TopologyKey       string         `yaml:"topologyKey"`
+			WhenUnsatisfiable string         `yaml:"whenUnsatisfiable"`
+			LabelSelector     *labelSelector `yaml:"labelSelector"`
+		}{}
+		if err := constraints[i].YNode().Decode(&t); err != nil {
+			return nil, err
+		}
+		result := func(severity Severity, field, format string, args ...interface{}) {
+			results = append(results, Result{
+				Severity: severity,
+				Line:     constraints[i].YNode().Line,
+				Field:    fmt.Sprintf("%s.topologySpreadConstraints[%d]%s", specPath, i, field),
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		if t.MaxSkew == nil || *t.MaxSkew < 1 {
+			result(SeverityError, ".maxSkew", "topology spread constraint must set a maxSkew of at least 1")
+		}
+		if t.TopologyKey == "" {
+			result(SeverityError, ".topologyKey", "topology spread constraint must set a topologyKey")
+		}
+		if t.WhenUnsatisfiable != "DoNotSchedule" && t.WhenUnsatisfiable != "ScheduleAnyway" {
+			result(SeverityError, ".whenUnsatisfiable",
+				"whenUnsatisfiable must be one of 'DoNotSchedule' or 'ScheduleAnyway', got '%s'",
+				t.WhenUnsatisfiable)
+		}
+		key := [2]string{t.TopologyKey, t.WhenUnsatisfiable}
+		if seen[key] {
+			result(SeverityError, "", "duplicate topology spread constraint for topologyKey '%s' "+
+				"and whenUnsatisfiable '%s'", t.TopologyKey, t.WhenUnsatisfiable)
+		}
+		seen[key] = true
+		if t.LabelSelector == nil || !t.LabelSelector.matches(labels) {
+			result(SeverityWarning, ".labelSelector", "topology spread constraint does not select "+
+				"the pods of the workload -- they aren't spread by it")
+		}
+	}
+
+	if !replicatedKinds[meta.Kind] || len(constraints) > 0 {
+		return results, nil
+	}
+	if replicas, err := value(node, "spec", "replicas"); err != nil {
+		return nil, err
+	} else if replicas == "0" || replicas == "1" {
+		return results, nil
+	}
+	for _, f := range []string{"requiredDuringSchedulingIgnoredDuringExecution",
+		"preferredDuringSchedulingIgnoredDuringExecution"} {
+		terms, err := elements(spec, "affinity", "podAntiAffinity", f)
+		if err != nil {
+			return nil, err
+		}
+		if len(terms) > 0 {
+			return results, nil
+		}
+	}
+	return append(results, Result{
+		Severity: c.Severity,
+		Line:     spec.YNode().Line,
+		Field:    specPath + ".topologySpreadConstraints",
+		Message: "replicated workload does not set topology spread constraints or pod " +
+			"anti-affinity -- its replicas may all be scheduled onto the same node",
+	}), nil
+}
+
+// AffinityChecks returns the checks run by affinity audit, indexed by name.  Workloads
+// without spread constraints are warnings.
+func AffinityChecks() map[string]Check {
+	checks := map[string]Check{}
+	for _, c := range []Check{
+		NodeAffinityCheck{},
+		PodAffinityCheck{},
+		TolerationsCheck{},
+		TopologySpreadCheck{Severity: SeverityWarning},
+	} {
+		checks[c.Name()] = c
+	}
+	return checks
+}
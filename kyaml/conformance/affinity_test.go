@@ -0,0 +1,213 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/conformance"
+)
+
+const scheduled = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      nodeSelector:
+        kubernetes.io/os: linux
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - {key: pool, operator: In, values: [web, general]}
+              - {key: pool, operator: NotIn, values: [general]}
+              - {key: cpus, operator: Gt, values: ["4"]}
+              - {key: cpus, operator: Lt, values: ["6"]}
+      tolerations:
+      - {key: dedicated, operator: Equal, value: web, effect: NoSchedule}
+      topologySpreadConstraints:
+      - maxSkew: 1
+        topologyKey: topology.kubernetes.io/zone
+        whenUnsatisfiable: ScheduleAnyway
+        labelSelector:
+          matchLabels:
+            app: web
+      containers:
+      - name: web
+        image: web:1.0
+`
+
+func TestAffinityChecks_scheduled(t *testing.T) {
+	checks, err := SelectChecks(AffinityChecks(), nil, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results, err := Run(read(t, scheduled), checks)
+	if assert.NoError(t, err) {
+		assert.Empty(t, results)
+	}
+}
+
+const unschedulable = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      nodeSelector:
+        pool: web
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - {key: pool, operator: In, values: [batch]}
+            - matchExpressions:
+              - {key: gpu, operator: Exists}
+              - {key: gpu, operator: DoesNotExist}
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 1
+            preference:
+              matchExpressions:
+              - {key: pool, operator: NotIn, values: [web]}
+        podAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+          - labelSelector:
+              matchLabels:
+                app: cache
+            topologyKey: kubernetes.io/hostname
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+          - labelSelector:
+              matchLabels:
+                app: cache
+            topologyKey: kubernetes.io/hostname
+      containers:
+      - name: web
+        image: web:1.0
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  replicas: 5
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      affinity:
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+          - labelSelector:
+              matchLabels:
+                app: db
+            topologyKey: topology.kubernetes.io/zone
+      tolerations:
+      - operator: Exists
+      - {key: disk, operator: Exists, value: ssd, tolerationSeconds: 60}
+      topologySpreadConstraints:
+      - maxSkew: 0
+        topologyKey: kubernetes.io/hostname
+        whenUnsatisfiable: DoNotSchedule
+        labelSelector:
+          matchLabels:
+            app: other
+      containers:
+      - name: db
+        image: db:1.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: single
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: single
+        image: single:1.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: unspread
+spec:
+  template:
+    spec:
+      containers:
+      - name: unspread
+        image: unspread:1.0
+`
+
+func TestAffinityChecks_unschedulable(t *testing.T) {
+	checks, err := SelectChecks(AffinityChecks(), nil, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results, err := Run(read(t, unschedulable), checks)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	type finding struct {
+		Name, Check string
+		Severity    Severity
+		Field       string
+		Message     string
+	}
+	var findings []finding
+	for _, r := range results {
+		findings = append(findings, finding{r.Name, r.Check, r.Severity, r.Field, r.Message})
+	}
+	assert.Equal(t, []finding{
+		{"web", "node-affinity", SeverityError,
+			"spec.template.spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[0]",
+			"node selector term can never match: the requirements of label 'pool' contradict the nodeSelector" +
+				" -- no term may match, pods can never be scheduled"},
+		{"web", "node-affinity", SeverityError,
+			"spec.template.spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[1]",
+			"node selector term can never match: the requirements of label 'gpu' contradict each other" +
+				" -- no term may match, pods can never be scheduled"},
+		{"web", "node-affinity", SeverityWarning,
+			"spec.template.spec.affinity.nodeAffinity.preferredDuringSchedulingIgnoredDuringExecution[0].preference",
+			"preferred node affinity never applies: the requirements of label 'pool' contradict the nodeSelector"},
+		{"web", "pod-affinity", SeverityError,
+			"spec.template.spec.affinity.podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution[0]",
+			"pod anti-affinity contradicts pod affinity for the same pods and topology key " +
+				"'kubernetes.io/hostname' -- pods can never be scheduled"},
+		{"db", "pod-affinity", SeverityWarning,
+			"spec.template.spec.affinity.podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution[0]",
+			"pod anti-affinity requires a separate 'topology.kubernetes.io/zone' for each of the 5 replicas " +
+				"-- pods beyond the number of zones can't be scheduled"},
+		{"db", "tolerations", SeverityWarning, "spec.template.spec.tolerations[0]",
+			"toleration tolerates all taints -- pods may be scheduled onto any node, including dedicated " +
+				"and unhealthy nodes"},
+		{"db", "tolerations", SeverityError, "spec.template.spec.tolerations[1].value",
+			"toleration with operator Exists must not set a value"},
+		{"db", "tolerations", SeverityWarning, "spec.template.spec.tolerations[1].tolerationSeconds",
+			"tolerationSeconds only applies to the NoExecute effect, it is ignored"},
+		{"db", "topology-spread", SeverityError, "spec.template.spec.topologySpreadConstraints[0].maxSkew",
+			"topology spread constraint must set a maxSkew of at least 1"},
+		{"db", "topology-spread", SeverityWarning, "spec.template.spec.topologySpreadConstraints[0].labelSelector",
+			"topology spread constraint does not select the pods of the workload -- they aren't spread by it"},
+		{"unspread", "topology-spread", SeverityWarning, "spec.template.spec.topologySpreadConstraints",
+			"replicated workload does not set topology spread constraints or pod anti-affinity -- its " +
+				"replicas may all be scheduled onto the same node"},
+	}, findings)
+}
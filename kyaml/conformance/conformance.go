@@ -0,0 +1,162 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package conformance checks Resource Config against a curated set of rules for
+// apiVersion deprecations, workload health, security and naming.
+//
+// Checks are grouped into profiles.  The baseline profile fails on configuration
+// which is known to be broken or insecure, while the restricted profile also enforces
+// hardening and operational best practices.
+package conformance
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Severity is the severity of a Result
+type Severity string
+
+const (
+	// SeverityError is the severity of Results which fail the checks
+	SeverityError Severity = "error"
+
+	// SeverityWarning is the severity of Results which are reported, but don't fail the checks
+	SeverityWarning Severity = "warning"
+)
+
+// Profile is a named set of checks
+type Profile string
+
+const (
+	// ProfileBaseline checks for configuration which is known to be broken or insecure
+	ProfileBaseline Profile = "baseline"
+
+	// ProfileRestricted also checks for hardening and operational best practices
+	ProfileRestricted Profile = "restricted"
+)
+
+// Result is a check failure for a Resource
+type Result struct {
+	// Check is the name of the check which produced the Result
+	Check string `json:"check" yaml:"check"`
+
+	// Severity is the severity of the Result
+	Severity Severity `json:"severity" yaml:"severity"`
+
+	ApiVersion string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name       string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// File is the file the Resource was read from, if known
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+
+	// Line is the line of the field in File, if known
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+
+	// Field is the path to the field the Result is about, if any
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+
+	// Message describes the failure
+	Message string `json:"message" yaml:"message"`
+}
+
+// Check checks a Resource
+type Check interface {
+	// Name returns the name of the check -- e.g. deprecated-api
+	Name() string
+
+	// Check returns the failures for the Resource.  Check, the Resource identity and the
+	// File are set on the Results by Run.
+	Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error)
+}
+
+// ProfileChecks returns the checks for the profile.  The schema check is only included if
+// schemas is non-empty.
+func ProfileChecks(profile Profile, schemas openapi.Schemas) ([]Check, error) {
+	var checks []Check
+	switch profile {
+	case ProfileBaseline:
+		checks = []Check{
+			DeprecatedAPICheck{},
+			SecurityContextCheck{},
+			NameCheck{},
+			ResourceLimitsCheck{Severity: SeverityWarning},
+			ProbesCheck{Severity: SeverityWarning},
+		}
+	case ProfileRestricted:
+		checks = []Check{
+			DeprecatedAPICheck{},
+			SecurityContextCheck{Restricted: true},
+			NameCheck{},
+			ResourceLimitsCheck{Severity: SeverityError},
+			ProbesCheck{Severity: SeverityError},
+		}
+	default:
+		return nil, fmt.Errorf("unknown profile '%s': may be one of '%s' or '%s'",
+			profile, ProfileBaseline, ProfileRestricted)
+	}
+	if len(schemas) > 0 {
+		checks = append(checks, SchemaCheck{Schemas: schemas})
+	}
+	return checks, nil
+}
+
+// Run runs the checks against the Resources, and returns the Results sorted by file,
+// line and check.
+func Run(nodes []*yaml.RNode, checks []Check) ([]Result, error) {
+	var results []Result
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range checks {
+			r, err := c.Check(nodes[i], meta)
+			if err != nil {
+				return nil, err
+			}
+			for j := range r {
+				r[j].Check = c.Name()
+				r[j].ApiVersion = meta.ApiVersion
+				r[j].Kind = meta.Kind
+				r[j].Namespace = meta.Namespace
+				r[j].Name = meta.Name
+				r[j].File = meta.Annotations[kioutil.PathAnnotation]
+			}
+			results = append(results, r...)
+		}
+	}
+
+	sortResults(results)
+	return results, nil
+}
+
+// sortResults sorts the Results by file, line and check
+func sortResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		if results[i].Line != results[j].Line {
+			return results[i].Line < results[j].Line
+		}
+		return results[i].Check < results[j].Check
+	})
+}
+
+// Failed returns the number of Results which fail the checks
+func Failed(results []Result) int {
+	count := 0
+	for i := range results {
+		if results[i].Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
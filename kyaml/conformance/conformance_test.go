@@ -0,0 +1,283 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/conformance"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func read(t *testing.T, in string) []*yaml.RNode {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(in)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return nodes
+}
+
+const compliant = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    app.kubernetes.io/name: nginx
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+      containers:
+      - name: nginx
+        image: nginx:1.17
+        livenessProbe:
+          httpGet:
+            port: 80
+        readinessProbe:
+          httpGet:
+            port: 80
+        resources:
+          limits:
+            cpu: 100m
+            memory: 128Mi
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop: [ALL]
+            add: [NET_BIND_SERVICE]
+`
+
+func TestRun_compliant(t *testing.T) {
+	for _, p := range []Profile{ProfileBaseline, ProfileRestricted} {
+		checks, err := ProfileChecks(p, nil)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		results, err := Run(read(t, compliant), checks)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Empty(t, results, p)
+	}
+}
+
+const nonCompliant = `apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: Nginx
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      containers:
+      - name: nginx
+        image: nginx
+        securityContext:
+          privileged: true
+          capabilities:
+            add: [SYS_ADMIN, CHOWN]
+`
+
+func TestRun_baseline(t *testing.T) {
+	checks, err := ProfileChecks(ProfileBaseline, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results, err := Run(read(t, nonCompliant), checks)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var actual []string
+	for _, r := range results {
+		actual = append(actual, string(r.Severity)+" "+r.Check+" "+r.Field+": "+r.Message)
+		assert.Equal(t, "Deployment", r.Kind)
+		assert.Equal(t, "Nginx", r.Name)
+	}
+	assert.Equal(t, []string{
+		"error deprecated-api apiVersion: extensions/v1beta1 Deployment is not served " +
+			"since Kubernetes 1.16, use apps/v1",
+		"error names metadata.name: names must be at most 253 lowercase alphanumeric " +
+			"characters, '-' or '.'",
+		"error security-context spec.template.spec.hostNetwork: pod must not set hostNetwork",
+		"warning probes spec.template.spec.containers[name=nginx].livenessProbe: " +
+			"container does not set a livenessProbe",
+		"warning probes spec.template.spec.containers[name=nginx].readinessProbe: " +
+			"container does not set a readinessProbe",
+		"warning resource-limits spec.template.spec.containers[name=nginx].resources.limits.cpu: " +
+			"container does not set a cpu limit",
+		"warning resource-limits spec.template.spec.containers[name=nginx].resources.limits.memory: " +
+			"container does not set a memory limit",
+		"error security-context spec.template.spec.containers[name=nginx].securityContext.privileged: " +
+			"container must not be privileged",
+		"error security-context spec.template.spec.containers[name=nginx].securityContext.capabilities.add: " +
+			"container must not add capabilities SYS_ADMIN",
+	}, actual)
+	assert.Equal(t, 5, Failed(results))
+}
+
+func TestRun_restricted(t *testing.T) {
+	checks, err := ProfileChecks(ProfileRestricted, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results, err := Run(read(t, `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.17
+    livenessProbe: {exec: {command: [true]}}
+    readinessProbe: {exec: {command: [true]}}
+    resources:
+      limits: {cpu: 1, memory: 1Gi}
+    securityContext:
+      capabilities:
+        add: [CHOWN]
+`), checks)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var actual []string
+	for _, r := range results {
+		actual = append(actual, r.Field+": "+r.Message)
+	}
+	assert.Equal(t, []string{
+		"spec.containers[name=nginx].securityContext.capabilities.add: " +
+			"container must not add capabilities CHOWN",
+		"spec.containers[name=nginx].securityContext.runAsNonRoot: " +
+			"container must set runAsNonRoot to true",
+		"spec.containers[name=nginx].securityContext.allowPrivilegeEscalation: " +
+			"container must set allowPrivilegeEscalation to false",
+		"spec.containers[name=nginx].securityContext.capabilities.drop: " +
+			"container must drop ALL capabilities",
+	}, actual)
+}
+
+func TestNameCheck(t *testing.T) {
+	results, err := Run(read(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: my.service
+  labels:
+    "-invalid": value
+    valid: "not valid"
+`), []Check{NameCheck{}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var actual []string
+	for _, r := range results {
+		actual = append(actual, r.Field+": "+r.Message)
+	}
+	assert.Equal(t, []string{
+		"metadata.name: Service names must be at most 63 lowercase alphanumeric characters or '-'",
+		"metadata.labels.-invalid: invalid label key '-invalid'",
+		"metadata.labels.valid: invalid label value 'not valid'",
+	}, actual)
+}
+
+func TestSchemaCheck(t *testing.T) {
+	schemas := openapi.Schemas{
+		{Version: "v1", Kind: "ConfigMap"}: &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"data": {Type: "object", AdditionalProperties: &openapi.Schema{Type: "string"}},
+			},
+		},
+	}
+	checks, err := ProfileChecks(ProfileBaseline, schemas)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	results, err := Run(read(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+data:
+  a: b
+unknown: c
+`), checks)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, results, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "schema", results[0].Check)
+	assert.Equal(t, "unknown", results[0].Field)
+	assert.Equal(t, 7, results[0].Line)
+}
+
+func TestProfileChecks_unknown(t *testing.T) {
+	_, err := ProfileChecks("strict", nil)
+	assert.EqualError(t, err, "unknown profile 'strict': may be one of 'baseline' or 'restricted'")
+}
+
+func TestImageTagCheck(t *testing.T) {
+	results, err := Run(read(t, `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+  - name: untagged
+    image: nginx
+  - name: latest
+    image: nginx:latest
+  - name: port
+    image: registry:5000/nginx
+  - name: tagged
+    image: registry:5000/nginx:1.17
+  - name: digest
+    image: nginx@sha256:abcd
+`), []Check{ImageTagCheck{Severity: SeverityWarning}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var actual []string
+	for _, r := range results {
+		actual = append(actual, r.Field+": "+r.Message)
+	}
+	assert.Equal(t, []string{
+		"spec.containers[name=untagged].image: image 'nginx' should be pinned to a tag other than latest",
+		"spec.containers[name=latest].image: image 'nginx:latest' should be pinned to a tag other than latest",
+		"spec.containers[name=port].image: image 'registry:5000/nginx' should be pinned to a tag " +
+			"other than latest",
+	}, actual)
+}
+
+func TestSelectChecks(t *testing.T) {
+	names := func(checks []Check) []string {
+		var result []string
+		for _, c := range checks {
+			result = append(result, c.Name())
+		}
+		return result
+	}
+
+	checks, err := SelectChecks(LintChecks(), nil, []string{"probes"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"deprecated-api", "image-tag", "resource-limits"}, names(checks))
+	}
+
+	checks, err = SelectChecks(LintChecks(), []string{"probes", "image-tag"}, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"image-tag", "probes"}, names(checks))
+	}
+
+	_, err = SelectChecks(LintChecks(), []string{"images"}, nil)
+	assert.EqualError(t, err, "unknown check 'images'")
+}
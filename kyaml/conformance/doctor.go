@@ -0,0 +1,292 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// PackageDoctor diagnoses common problems of a local package:
+//
+//   - missing-file: files referenced by kustomizations do not exist, or directories
+//     referenced by kustomizations do not contain kustomizations
+//   - missing-entry: files containing Resources beside a kustomization are not referenced
+//     by it
+//   - unreferenced-file: other files not referenced by any kustomization of the package
+//   - tab-indentation: lines are indented with tabs, which YAML does not allow
+//   - large-file: files are larger than MaxFileSize
+//
+// missing-file Results are errors, and the other Results are warnings.
+type PackageDoctor struct {
+	// Path is the path to the package directory
+	Path string
+
+	// MaxFileSize is the size in bytes above which files are reported.  Defaults to 1MiB.
+	MaxFileSize int64
+}
+
+// doctorKustomizationFileNames are the names of kustomization files
+var doctorKustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// doctorIgnoredFiles are the patterns of the files which are not reported as unreferenced
+var doctorIgnoredFiles = []string{"*.md", "README*", "LICENSE*", "OWNERS", "Kptfile"}
+
+// Diagnose diagnoses the package, and returns the Results sorted by file, line and check.
+func (d PackageDoctor) Diagnose() ([]Result, error) {
+	if d.Path == "" {
+		return nil, fmt.Errorf("must specify package path")
+	}
+	root := filepath.Clean(d.Path)
+	maxSize := d.MaxFileSize
+	if maxSize == 0 {
+		maxSize = 1 << 20
+	}
+
+	var results []Result
+	warn := func(check, file string, line int, format string, args ...interface{}) {
+		results = append(results, Result{Check: check, Severity: SeverityWarning,
+			File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var files []string
+	kustomizations := map[string]string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		files = append(files, rel)
+		for _, name := range doctorKustomizationFileNames {
+			if info.Name() == name {
+				kustomizations[filepath.Dir(rel)] = rel
+			}
+		}
+
+		if info.Size() > maxSize {
+			warn("large-file", rel, 0, "file is %d bytes, larger than %d bytes", info.Size(), maxSize)
+			return nil
+		}
+		if !isYAMLFile(rel) {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		s := bufio.NewScanner(bytes.NewReader(b))
+		s.Buffer(nil, int(maxSize)+1)
+		for line := 1; s.Scan(); line++ {
+			indent := s.Text()[:len(s.Text())-len(strings.TrimLeft(s.Text(), " \t"))]
+			if strings.Contains(indent, "\t") {
+				warn("tab-indentation", rel, line, "line is indented with tabs")
+				break
+			}
+		}
+		return s.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(kustomizations) == 0 {
+		sortResults(results)
+		return results, nil
+	}
+
+	// referenced are the files and directories referenced by the kustomizations
+	referenced := map[string]bool{}
+	for dir, file := range kustomizations {
+		refs, err := kustomizationReferences(filepath.Join(root, file))
+		if err != nil {
+			results = append(results, Result{
+				Check: "parse", Severity: SeverityError, File: file, Message: err.Error()})
+			continue
+		}
+		for _, ref := range refs {
+			path := filepath.Join(dir, ref.path)
+			message := fmt.Sprintf("'%s' does not exist", ref.path)
+			if info, err := os.Stat(filepath.Join(root, path)); err == nil {
+				if !info.IsDir() {
+					referenced[path] = true
+					continue
+				}
+				// the files of referenced directories are referenced by their kustomizations,
+				// which may be outside the package
+				if _, found := kustomizations[path]; found || strings.HasPrefix(path, "..") {
+					continue
+				}
+				message = fmt.Sprintf("'%s' does not contain a kustomization", ref.path)
+			} else if isRemote(ref.path) {
+				continue
+			}
+			results = append(results, Result{
+				Check:    "missing-file",
+				Severity: SeverityError,
+				File:     file,
+				Line:     ref.line,
+				Field:    ref.field,
+				Message:  message,
+			})
+		}
+	}
+
+	for _, file := range files {
+		if referenced[file] || isIgnoredFile(file) {
+			continue
+		}
+		dir := filepath.Dir(file)
+		if kustomizations[dir] == file {
+			// kustomizations are built directly, or referenced by their directories
+			continue
+		}
+		k, found := kustomizations[dir]
+		if found && isYAMLFile(file) && containsResources(filepath.Join(root, file)) {
+			warn("missing-entry", file, 0, "file contains Resources, but is not in the resources of %s", k)
+			continue
+		}
+		if found || (isYAMLFile(file) && containsResources(filepath.Join(root, file))) {
+			warn("unreferenced-file", file, 0, "file is not referenced by any kustomization")
+		}
+	}
+
+	sortResults(results)
+	return results, nil
+}
+
+// kustomizationReference is a reference of a kustomization to a file or directory
+type kustomizationReference struct {
+	path  string
+	field string
+	line  int
+}
+
+// kustomizationReferences returns the references of the kustomization file to files and
+// directories.
+func kustomizationReferences(path string) ([]kustomizationReference, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k, err := yaml.Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []kustomizationReference
+	add := func(field string, node *yaml.Node) {
+		if node == nil || node.Kind != yaml.ScalarNode || node.Value == "" ||
+			strings.Contains(node.Value, "\n") {
+			// inline patches are not files
+			return
+		}
+		value := node.Value
+		if i := strings.Index(value, "="); i >= 0 && strings.HasSuffix(field, ".files") {
+			// the key of a generator file
+			value = value[i+1:]
+		}
+		refs = append(refs, kustomizationReference{path: value, field: field, line: node.Line})
+	}
+	elements := func(field string) []*yaml.Node {
+		n, err := k.Pipe(yaml.Lookup(strings.Split(field, ".")...))
+		if err != nil || n == nil || n.YNode().Kind != yaml.SequenceNode {
+			return nil
+		}
+		return n.YNode().Content
+	}
+
+	for _, field := range []string{"resources", "bases", "components", "crds", "configurations",
+		"generators", "transformers", "validators", "patchesStrategicMerge"} {
+		for _, e := range elements(field) {
+			add(field, e)
+		}
+	}
+	for _, field := range []string{"patches", "patchesJson6902"} {
+		for _, e := range elements(field) {
+			add(field+".path", fieldNode(yaml.NewRNode(e), "path"))
+		}
+	}
+	for _, field := range []string{"configMapGenerator", "secretGenerator"} {
+		for _, e := range elements(field) {
+			g := yaml.NewRNode(e)
+			for _, list := range []string{"files", "envs"} {
+				if f := fieldNode(g, list); f != nil && f.Kind == yaml.SequenceNode {
+					for _, n := range f.Content {
+						add(field+"."+list, n)
+					}
+				}
+			}
+			add(field+".env", fieldNode(g, "env"))
+		}
+	}
+	return refs, nil
+}
+
+// fieldNode returns the value of the field of rn, or nil if it is not set
+func fieldNode(rn *yaml.RNode, field string) *yaml.Node {
+	f := rn.Field(field)
+	if yaml.IsFieldEmpty(f) {
+		return nil
+	}
+	return f.Value.YNode()
+}
+
+// isRemote returns true if the reference is a git repository or url
+func isRemote(ref string) bool {
+	return strings.Contains(ref, "://") || strings.HasPrefix(ref, "git@") ||
+		strings.HasPrefix(ref, "github.com/") || strings.Contains(ref, "?ref=")
+}
+
+func isYAMLFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func isIgnoredFile(path string) bool {
+	for _, pattern := range doctorIgnoredFiles {
+		if m, _ := filepath.Match(pattern, filepath.Base(path)); m {
+			return true
+		}
+	}
+	return false
+}
+
+// containsResources returns true if the file parses and contains a Resource with an
+// apiVersion and kind
+func containsResources(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	nodes, err := (&kio.ByteReader{Reader: f, OmitReaderAnnotations: true}).Read()
+	if err != nil {
+		return false
+	}
+	for i := range nodes {
+		if meta, err := nodes[i].GetMeta(); err == nil && meta.ApiVersion != "" && meta.Kind != "" {
+			return true
+		}
+	}
+	return false
+}
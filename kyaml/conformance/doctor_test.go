@@ -0,0 +1,114 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/conformance"
+)
+
+func TestPackageDoctor_Diagnose(t *testing.T) {
+	d := writePackage(t, map[string]string{
+		"base/kustomization.yaml": `resources:
+- deployment.yaml
+- service.yaml
+- github.com/example/repo//base?ref=v1
+configMapGenerator:
+- name: config
+  files:
+  - app.properties
+  - extra=extra.properties
+patches:
+- path: patch.yaml
+`,
+		"base/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+		"base/app.properties": "a=b\n",
+		"base/old.properties": "a=b\n",
+		"base/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: forgotten
+`,
+		"base/README.md": "# base\n",
+		"overlays/prod/kustomization.yaml": `resources:
+- ../../base
+`,
+		"orphans/pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: orphan
+spec:
+	containers: []
+`,
+		"docs/notes.txt":   "notes\n",
+		"big/big.yaml":     "a: " + strings.Repeat("b", 300) + "\n",
+		".git/config.yaml": "ignored: true\n",
+	})
+	defer os.RemoveAll(d)
+
+	results, err := PackageDoctor{Path: d, MaxFileSize: 250}.Diagnose()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []Result{
+		{Check: "missing-entry", Severity: SeverityWarning, File: "base/configmap.yaml",
+			Message: "file contains Resources, but is not in the resources of base/kustomization.yaml"},
+		{Check: "missing-file", Severity: SeverityError, File: "base/kustomization.yaml", Line: 3,
+			Field: "resources", Message: "'service.yaml' does not exist"},
+		{Check: "missing-file", Severity: SeverityError, File: "base/kustomization.yaml", Line: 9,
+			Field: "configMapGenerator.files", Message: "'extra.properties' does not exist"},
+		{Check: "missing-file", Severity: SeverityError, File: "base/kustomization.yaml", Line: 11,
+			Field: "patches.path", Message: "'patch.yaml' does not exist"},
+		{Check: "unreferenced-file", Severity: SeverityWarning, File: "base/old.properties",
+			Message: "file is not referenced by any kustomization"},
+		{Check: "large-file", Severity: SeverityWarning, File: "big/big.yaml",
+			Message: "file is 304 bytes, larger than 250 bytes"},
+		{Check: "tab-indentation", Severity: SeverityWarning, File: "orphans/pod.yaml", Line: 6,
+			Message: "line is indented with tabs"},
+	}, results)
+}
+
+func TestPackageDoctor_Diagnose_unreferenced(t *testing.T) {
+	d := writePackage(t, map[string]string{
+		"kustomization.yaml":     "resources:\n- app\n- config\n",
+		"app/kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"config/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`,
+		"app/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+		"unused/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`,
+	})
+	defer os.RemoveAll(d)
+
+	results, err := PackageDoctor{Path: d}.Diagnose()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []Result{
+		{Check: "unreferenced-file", Severity: SeverityWarning, File: "config/configmap.yaml",
+			Message: "file is not referenced by any kustomization"},
+		{Check: "missing-file", Severity: SeverityError, File: "kustomization.yaml", Line: 3,
+			Field: "resources", Message: "'config' does not contain a kustomization"},
+		{Check: "unreferenced-file", Severity: SeverityWarning, File: "unused/service.yaml",
+			Message: "file is not referenced by any kustomization"},
+	}, results)
+}
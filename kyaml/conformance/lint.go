@@ -0,0 +1,102 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ImageTagCheck checks that each container image is pinned to a tag other than latest,
+// or to a digest.
+type ImageTagCheck struct {
+	Severity Severity
+}
+
+func (ImageTagCheck) Name() string { return "image-tag" }
+
+func (c ImageTagCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	cs, err := containers(spec, specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for i := range cs {
+		image := cs[i].Field("image")
+		if yaml.IsFieldEmpty(image) || strings.Contains(image.Value.YNode().Value, "@") {
+			continue
+		}
+		name := image.Value.YNode().Value
+		tag := ""
+		// the tag follows the last ':' after the registry -- which may have a port
+		if j := strings.LastIndex(name, ":"); j > strings.LastIndex(name, "/") {
+			tag = name[j+1:]
+		}
+		if tag != "" && tag != "latest" {
+			continue
+		}
+		results = append(results, Result{
+			Severity: c.Severity,
+			Line:     image.Value.YNode().Line,
+			Field:    cs[i].path + ".image",
+			Message:  fmt.Sprintf("image '%s' should be pinned to a tag other than latest", name),
+		})
+	}
+	return results, nil
+}
+
+// LintChecks returns the checks run by lint, indexed by name.  Deprecated apiVersions
+// are errors, while the other checks are warnings.
+func LintChecks() map[string]Check {
+	checks := map[string]Check{}
+	for _, c := range []Check{
+		DeprecatedAPICheck{},
+		ResourceLimitsCheck{Severity: SeverityWarning},
+		ImageTagCheck{Severity: SeverityWarning},
+		ProbesCheck{Severity: SeverityWarning},
+	} {
+		checks[c.Name()] = c
+	}
+	return checks
+}
+
+// SelectChecks returns the checks with the enabled names, or all checks if enabled is
+// empty, less the checks with the disabled names.  The checks are sorted by name.
+func SelectChecks(checks map[string]Check, enabled, disabled []string) ([]Check, error) {
+	for _, name := range append(append([]string{}, enabled...), disabled...) {
+		if _, found := checks[name]; !found {
+			return nil, fmt.Errorf("unknown check '%s'", name)
+		}
+	}
+
+	names := enabled
+	if len(names) == 0 {
+		for name := range checks {
+			names = append(names, name)
+		}
+	}
+	skip := map[string]bool{}
+	for _, name := range disabled {
+		skip[name] = true
+	}
+
+	var result []Check
+	seen := map[string]bool{}
+	for _, name := range names {
+		if !skip[name] && !seen[name] {
+			seen[name] = true
+			result = append(result, checks[name])
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// PackageChecker checks the invariants of a local package:
+//
+// - parse: each file parses, and each Resource has an apiVersion and kind
+// - duplicate-id: no two Resources have the same kind, namespace and name
+// - path-annotation: path annotations in the files match the file locations
+// - references: the Resources referenced by name are in the package
+// - package-boundary: Resources only reference Resources in their own package
+type PackageChecker struct {
+	// Path is the path to the package directory
+	Path string
+
+	// PackageFileName is the name of the file identifying subpackages -- e.g. Kptfile.
+	// If unset, subpackages are not distinguished from their parent.
+	PackageFileName string
+
+	// IncludeSubpackages also checks the subpackages
+	IncludeSubpackages bool
+
+	// MatchFilesGlob are the patterns of the files the Resources are read from.
+	// Defaults to ["*.yaml", "*.yml"].
+	MatchFilesGlob []string
+}
+
+// packageResource is a Resource read by PackageChecker
+type packageResource struct {
+	node *yaml.RNode
+	meta yaml.ResourceMeta
+	// file is the path to the file the Resource was read from, relative to the package
+	file string
+	// pkg is the path to the package the file is in, relative to the package
+	pkg string
+}
+
+// Check checks the package, and returns the Results sorted by file, line and check.
+func (c PackageChecker) Check() ([]Result, error) {
+	if c.Path == "" {
+		return nil, fmt.Errorf("must specify package path")
+	}
+	root := filepath.Clean(c.Path)
+	globs := c.MatchFilesGlob
+	if len(globs) == 0 {
+		globs = []string{"*.yaml", "*.yml"}
+	}
+
+	var results []Result
+	var resources []packageResource
+	packages := map[string]string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			packages[rel] = packages[filepath.Dir(rel)]
+			if rel == "." || c.PackageFileName == "" {
+				packages[rel] = "."
+				return nil
+			}
+			if _, err := os.Stat(filepath.Join(path, c.PackageFileName)); os.IsNotExist(err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if !c.IncludeSubpackages {
+				return filepath.SkipDir
+			}
+			packages[rel] = rel
+			return nil
+		}
+
+		match := false
+		for _, g := range globs {
+			if m, err := filepath.Match(g, info.Name()); err != nil {
+				return err
+			} else if m {
+				match = true
+			}
+		}
+		if !match {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		nodes, err := (&kio.ByteReader{
+			Reader: f, DisableUnwrapping: true, OmitReaderAnnotations: true}).Read()
+		if err != nil {
+			results = append(results, Result{
+				Check: "parse", Severity: SeverityError, File: rel, Message: err.Error()})
+			return nil
+		}
+		// package metadata files must parse, but aren't Resources
+		if kio.IsPackageMetadataFile(info.Name()) || info.Name() == c.PackageFileName {
+			return nil
+		}
+		for i := range nodes {
+			meta, err := nodes[i].GetMeta()
+			if err != nil && err != yaml.ErrMissingMetadata {
+				return err
+			}
+			if meta.ApiVersion == "" || meta.Kind == "" {
+				results = append(results, Result{
+					Check:    "parse",
+					Severity: SeverityError,
+					File:     rel,
+					Line:     nodes[i].YNode().Line,
+					Message:  "Resources must have an apiVersion and kind",
+				})
+				continue
+			}
+			resources = append(resources, packageResource{
+				node: nodes[i], meta: meta, file: rel, pkg: packages[filepath.Dir(rel)]})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fail := func(r packageResource, check string, line int, field, format string, args ...interface{}) {
+		results = append(results, Result{
+			Check:      check,
+			Severity:   SeverityError,
+			ApiVersion: r.meta.ApiVersion,
+			Kind:       r.meta.Kind,
+			Namespace:  r.meta.Namespace,
+			Name:       r.meta.Name,
+			File:       r.file,
+			Line:       line,
+			Field:      field,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	byID := map[string]packageResource{}
+	var nodes []*yaml.RNode
+	for _, r := range resources {
+		id := filters.ResourceID{Kind: r.meta.Kind, Namespace: r.meta.Namespace, Name: r.meta.Name}
+		if first, found := byID[id.String()]; found {
+			fail(r, "duplicate-id", r.node.YNode().Line, "",
+				"duplicate of the Resource in %s:%d", first.file, first.node.YNode().Line)
+		} else {
+			byID[id.String()] = r
+		}
+
+		if path := r.meta.Annotations[kioutil.PathAnnotation]; path != "" &&
+			filepath.Clean(path) != r.file {
+			annotation, err := r.node.Pipe(yaml.Lookup("metadata", "annotations", kioutil.PathAnnotation))
+			if err != nil {
+				return nil, err
+			}
+			fail(r, "path-annotation", annotation.YNode().Line,
+				"metadata.annotations."+kioutil.PathAnnotation,
+				"path annotation '%s' does not match the file location '%s'", path, r.file)
+		}
+		nodes = append(nodes, r.node)
+	}
+
+	g := &filters.GraphFilter{}
+	if _, err := g.Filter(nodes); err != nil {
+		return nil, err
+	}
+	index := map[filters.ResourceID]packageResource{}
+	for i, id := range g.Nodes {
+		index[id] = resources[i]
+	}
+	for _, e := range g.Unresolved {
+		// the default ServiceAccount is created for each namespace
+		if e.Type != filters.EdgeReference || (e.To.Kind == "ServiceAccount" && e.To.Name == "default") {
+			continue
+		}
+		fail(index[e.From], "references", e.Line, e.Path, "%s not found in the package", e.To)
+	}
+	for _, e := range g.Edges {
+		from, to := index[e.From], index[e.To]
+		if e.Type == filters.EdgeReference && from.pkg != to.pkg {
+			fail(from, "package-boundary", e.Line, e.Path,
+				"references %s in package '%s' -- Resources may only reference Resources in "+
+					"their own package '%s'", e.To, to.pkg, from.pkg)
+		}
+	}
+
+	sortResults(results)
+	return results, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/conformance"
+)
+
+func writePackage(t *testing.T, files map[string]string) string {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for name, content := range files {
+		path := filepath.Join(d, name)
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700)) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600)) {
+			t.FailNow()
+		}
+	}
+	return d
+}
+
+func TestPackageChecker_Check(t *testing.T) {
+	d := writePackage(t, map[string]string{
+		"bad.yaml": "a: b: c\n",
+		"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: old/deployment.yaml
+spec:
+  template:
+    spec:
+      serviceAccountName: default
+      volumes:
+      - name: config
+        configMap:
+          name: config
+      - name: secret
+        secret:
+          secretName: missing
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+---
+data:
+  a: b
+`,
+		"sub/Kptfile":            "apiVersion: kpt.dev/v1alpha1\nkind: Kptfile\n",
+		"sub/kustomization.yaml": "resources: [\n",
+		"sub/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`,
+	})
+	defer os.RemoveAll(d)
+
+	results, err := PackageChecker{
+		Path: d, PackageFileName: "Kptfile", IncludeSubpackages: true}.Check()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var actual []string
+	for _, r := range results {
+		assert.Equal(t, SeverityError, r.Severity)
+		actual = append(actual, r.File+" "+r.Check+" "+r.Kind+" "+r.Field+": "+r.Message)
+	}
+	assert.Equal(t, []string{
+		"bad.yaml parse  : yaml: mapping values are not allowed in this context",
+		"deployment.yaml path-annotation Deployment metadata.annotations.config.kubernetes.io/path: " +
+			"path annotation 'old/deployment.yaml' does not match the file location 'deployment.yaml'",
+		"deployment.yaml package-boundary Deployment spec.template.spec.volumes[name=config].configMap.name: " +
+			"references ConfigMap config in package 'sub' -- Resources may only reference Resources " +
+			"in their own package '.'",
+		"deployment.yaml references Deployment spec.template.spec.volumes[name=secret].secret.secretName: " +
+			"Secret missing not found in the package",
+		"deployment.yaml duplicate-id Deployment : duplicate of the Resource in deployment.yaml:1",
+		"deployment.yaml parse  : Resources must have an apiVersion and kind",
+		"sub/kustomization.yaml parse  : yaml: line 1: did not find expected node content",
+	}, actual)
+
+	// without subpackages the reference is unresolved
+	results, err = PackageChecker{Path: d, PackageFileName: "Kptfile"}.Check()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	actual = nil
+	for _, r := range results {
+		actual = append(actual, r.Check+" "+r.Field)
+	}
+	assert.Contains(t, actual, "references spec.template.spec.volumes[name=config].configMap.name")
+	assert.NotContains(t, actual, "package-boundary spec.template.spec.volumes[name=config].configMap.name")
+}
+
+func TestPackageChecker_Check_valid(t *testing.T) {
+	d := writePackage(t, map[string]string{
+		"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`,
+		"pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  volumes:
+  - name: config
+    configMap:
+      name: config
+`,
+		// kustomization files are not Resources
+		"kustomization.yaml":     "resources:\n- configmap.yaml\n- pod.yaml\n",
+		"sub/kustomization.yaml": "namePrefix: sub-\n",
+	})
+	defer os.RemoveAll(d)
+
+	results, err := PackageChecker{Path: d, IncludeSubpackages: true}.Check()
+	if assert.NoError(t, err) {
+		assert.Empty(t, results)
+	}
+}
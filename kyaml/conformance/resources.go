@@ -0,0 +1,200 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DeprecatedAPI is an apiVersion which is deprecated for a kind
+type DeprecatedAPI struct {
+	// ApiVersion is the deprecated apiVersion
+	ApiVersion string
+	// Kind is the kind the apiVersion is deprecated for.  Matches any kind if empty.
+	Kind string
+	// Replacement is the apiVersion to use instead
+	Replacement string
+	// RemovedIn is the Kubernetes version the apiVersion is no longer served in
+	RemovedIn string
+}
+
+// DeprecatedAPIs are the well-known deprecated apiVersions
+var DeprecatedAPIs = []DeprecatedAPI{
+	{ApiVersion: "extensions/v1beta1", Kind: "Deployment", Replacement: "apps/v1", RemovedIn: "1.16"},
+	{ApiVersion: "extensions/v1beta1", Kind: "DaemonSet", Replacement: "apps/v1", RemovedIn: "1.16"},
+	{ApiVersion: "extensions/v1beta1", Kind: "ReplicaSet", Replacement: "apps/v1", RemovedIn: "1.16"},
+	{ApiVersion: "extensions/v1beta1", Kind: "NetworkPolicy", Replacement: "networking.k8s.io/v1", RemovedIn: "1.16"},
+	{ApiVersion: "extensions/v1beta1", Kind: "PodSecurityPolicy", Replacement: "policy/v1beta1", RemovedIn: "1.16"},
+	{ApiVersion: "extensions/v1beta1", Kind: "Ingress", Replacement: "networking.k8s.io/v1beta1", RemovedIn: "1.22"},
+	{ApiVersion: "apps/v1beta1", Replacement: "apps/v1", RemovedIn: "1.16"},
+	{ApiVersion: "apps/v1beta2", Replacement: "apps/v1", RemovedIn: "1.16"},
+	{ApiVersion: "batch/v2alpha1", Kind: "CronJob", Replacement: "batch/v1beta1"},
+	{ApiVersion: "rbac.authorization.k8s.io/v1alpha1", Replacement: "rbac.authorization.k8s.io/v1", RemovedIn: "1.22"},
+	{ApiVersion: "rbac.authorization.k8s.io/v1beta1", Replacement: "rbac.authorization.k8s.io/v1", RemovedIn: "1.22"},
+	{ApiVersion: "apiextensions.k8s.io/v1beta1", Replacement: "apiextensions.k8s.io/v1", RemovedIn: "1.22"},
+	{ApiVersion: "admissionregistration.k8s.io/v1beta1", Replacement: "admissionregistration.k8s.io/v1", RemovedIn: "1.22"},
+	{ApiVersion: "scheduling.k8s.io/v1beta1", Replacement: "scheduling.k8s.io/v1", RemovedIn: "1.22"},
+	{ApiVersion: "storage.k8s.io/v1beta1", Kind: "StorageClass", Replacement: "storage.k8s.io/v1", RemovedIn: "1.22"},
+}
+
+// LookupDeprecatedAPI returns the DeprecatedAPI matching the apiVersion and kind, or nil
+// if the apiVersion is not deprecated for the kind.
+func LookupDeprecatedAPI(apiVersion, kind string) *DeprecatedAPI {
+	for i := range DeprecatedAPIs {
+		d := DeprecatedAPIs[i]
+		if d.ApiVersion == apiVersion && (d.Kind == "" || d.Kind == kind) {
+			return &d
+		}
+	}
+	return nil
+}
+
+// DeprecatedAPICheck checks that Resources don't use deprecated apiVersions.
+type DeprecatedAPICheck struct{}
+
+func (DeprecatedAPICheck) Name() string { return "deprecated-api" }
+
+func (DeprecatedAPICheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	d := LookupDeprecatedAPI(meta.ApiVersion, meta.Kind)
+	if d == nil {
+		return nil, nil
+	}
+	msg := fmt.Sprintf("%s %s is deprecated, use %s", meta.ApiVersion, meta.Kind, d.Replacement)
+	if d.RemovedIn != "" {
+		msg = fmt.Sprintf("%s %s is not served since Kubernetes %s, use %s",
+			meta.ApiVersion, meta.Kind, d.RemovedIn, d.Replacement)
+	}
+	var line int
+	if f := node.Field("apiVersion"); f != nil {
+		line = f.Value.YNode().Line
+	}
+	return []Result{{
+		Severity: SeverityError,
+		Line:     line,
+		Field:    "apiVersion",
+		Message:  msg,
+	}}, nil
+}
+
+var (
+	// dns1123Subdomain matches valid Resource names
+	dns1123Subdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	// dns1123Label matches valid names for kinds whose names are used as DNS labels
+	dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// labelValue matches valid label values and label key names
+	labelValue = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+)
+
+// dns1123LabelKinds are the kinds whose names must be DNS labels
+var dns1123LabelKinds = map[string]bool{
+	"Namespace": true,
+	"Service":   true,
+}
+
+// NameCheck checks that Resource names and labels are valid.
+type NameCheck struct{}
+
+func (NameCheck) Name() string { return "names" }
+
+func (NameCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	var results []Result
+	fail := func(n *yaml.Node, field, format string, args ...interface{}) {
+		results = append(results, Result{
+			Severity: SeverityError,
+			Line:     n.Line,
+			Field:    field,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	name, err := node.Pipe(yaml.Lookup("metadata", "name"))
+	if err != nil {
+		return nil, err
+	}
+	if name != nil && meta.Name != "" {
+		switch {
+		case dns1123LabelKinds[meta.Kind] &&
+			(len(meta.Name) > 63 || !dns1123Label.MatchString(meta.Name)):
+			fail(name.YNode(), "metadata.name",
+				"%s names must be at most 63 lowercase alphanumeric characters or '-'", meta.Kind)
+		case len(meta.Name) > 253 || !dns1123Subdomain.MatchString(meta.Name):
+			fail(name.YNode(), "metadata.name",
+				"names must be at most 253 lowercase alphanumeric characters, '-' or '.'")
+		}
+	}
+
+	labels, err := node.Pipe(yaml.Lookup("metadata", "labels"))
+	if err != nil {
+		return nil, err
+	}
+	if labels == nil {
+		return results, nil
+	}
+	err = labels.VisitFields(func(f *yaml.MapNode) error {
+		key := f.Key.YNode().Value
+		field := "metadata.labels." + key
+		prefix, keyName := "", key
+		if i := strings.LastIndex(key, "/"); i >= 0 {
+			prefix, keyName = key[:i], key[i+1:]
+		}
+		if keyName == "" || len(keyName) > 63 || !labelValue.MatchString(keyName) ||
+			(prefix != "" && (len(prefix) > 253 || !dns1123Subdomain.MatchString(prefix))) {
+			fail(f.Key.YNode(), field, "invalid label key '%s'", key)
+		}
+		v := f.Value.YNode().Value
+		if len(v) > 63 || !labelValue.MatchString(v) {
+			fail(f.Value.YNode(), field, "invalid label value '%s'", v)
+		}
+		return nil
+	})
+	return results, err
+}
+
+// readerAnnotations are the annotations set by kio readers, which are not part
+// of the Resource
+var readerAnnotations = []string{
+	kioutil.IndexAnnotation, kioutil.PathAnnotation, kioutil.PackageAnnotation,
+}
+
+// SchemaCheck checks that Resources match their OpenAPI schemas.  Resources without a
+// schema are not checked.
+type SchemaCheck struct {
+	Schemas openapi.Schemas
+}
+
+func (SchemaCheck) Name() string { return "schema" }
+
+func (c SchemaCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	s := c.Schemas.Lookup(meta)
+	if s == nil {
+		return nil, nil
+	}
+
+	var results []Result
+	for _, e := range openapi.Validate(node, s) {
+		field := strings.TrimPrefix(e.Path, ".")
+		ignore := false
+		for _, a := range readerAnnotations {
+			if field == "metadata.annotations."+a {
+				ignore = true
+			}
+		}
+		if ignore {
+			continue
+		}
+		results = append(results, Result{
+			Severity: SeverityError,
+			Line:     e.Line,
+			Field:    field,
+			Message:  e.Message,
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,321 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package conformance
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// podSpecPaths are the paths to the pod specs of the workload kinds
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"PodTemplate":           {"template", "spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// podSpec returns the pod spec of a workload and the path to it, or nil if the Resource
+// isn't a workload.
+func podSpec(node *yaml.RNode, meta yaml.ResourceMeta) (*yaml.RNode, string, error) {
+	path, found := podSpecPaths[meta.Kind]
+	if !found {
+		return nil, "", nil
+	}
+	spec, err := node.Pipe(yaml.Lookup(path...))
+	if err != nil || spec == nil {
+		return nil, "", err
+	}
+	return spec, strings.Join(path, "."), nil
+}
+
+// container is a container in a pod spec
+type container struct {
+	*yaml.RNode
+	// path is the path to the container -- e.g. spec.containers[name=nginx]
+	path string
+	// init is true for init containers
+	init bool
+}
+
+// containers returns the containers and init containers in the pod spec
+func containers(spec *yaml.RNode, specPath string) ([]container, error) {
+	var result []container
+	for _, field := range []string{"initContainers", "containers"} {
+		list, err := spec.Pipe(yaml.Lookup(field))
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			continue
+		}
+		elements, err := list.Elements()
+		if err != nil {
+			return nil, err
+		}
+		for i := range elements {
+			name := fmt.Sprintf("%d", i)
+			if n := elements[i].Field("name"); !yaml.IsFieldEmpty(n) {
+				name = "name=" + n.Value.YNode().Value
+			}
+			result = append(result, container{
+				RNode: elements[i],
+				path:  fmt.Sprintf("%s.%s[%s]", specPath, field, name),
+				init:  field == "initContainers",
+			})
+		}
+	}
+	return result, nil
+}
+
+// value returns the scalar value of the field at path, or "" if it is not set
+func value(node *yaml.RNode, path ...string) (string, error) {
+	v, err := node.Pipe(yaml.Lookup(path...))
+	if err != nil || yaml.IsMissingOrNull(v) {
+		return "", err
+	}
+	return v.YNode().Value, nil
+}
+
+// ResourceLimitsCheck checks that each container sets cpu and memory limits.
+type ResourceLimitsCheck struct {
+	Severity Severity
+}
+
+func (ResourceLimitsCheck) Name() string { return "resource-limits" }
+
+func (c ResourceLimitsCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	cs, err := containers(spec, specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for i := range cs {
+		for _, r := range []string{"cpu", "memory"} {
+			v, err := value(cs[i].RNode, "resources", "limits", r)
+			if err != nil {
+				return nil, err
+			}
+			if v != "" {
+				continue
+			}
+			results = append(results, Result{
+				Severity: c.Severity,
+				Line:     cs[i].YNode().Line,
+				Field:    cs[i].path + ".resources.limits." + r,
+				Message:  fmt.Sprintf("container does not set a %s limit", r),
+			})
+		}
+	}
+	return results, nil
+}
+
+// ProbesCheck checks that each container of a long running workload sets liveness and
+// readiness probes.
+type ProbesCheck struct {
+	Severity Severity
+}
+
+func (ProbesCheck) Name() string { return "probes" }
+
+func (c ProbesCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	// Jobs run to completion, they aren't probed for readiness
+	if meta.Kind == "Job" || meta.Kind == "CronJob" {
+		return nil, nil
+	}
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	cs, err := containers(spec, specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for i := range cs {
+		if cs[i].init {
+			continue
+		}
+		for _, p := range []string{"livenessProbe", "readinessProbe"} {
+			if f := cs[i].Field(p); !yaml.IsFieldEmpty(f) {
+				continue
+			}
+			results = append(results, Result{
+				Severity: c.Severity,
+				Line:     cs[i].YNode().Line,
+				Field:    cs[i].path + "." + p,
+				Message:  fmt.Sprintf("container does not set a %s", p),
+			})
+		}
+	}
+	return results, nil
+}
+
+// baselineCapabilities are the capabilities which may be added to containers under the
+// baseline profile -- matches the docker default capabilities.
+var baselineCapabilities = map[string]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// SecurityContextCheck checks workloads for risky pod and container security settings.
+//
+// Workloads must not share the host namespaces, mount host paths, run privileged
+// containers or add capabilities beyond the defaults.  If Restricted is set, workloads
+// must also run as non-root, disallow privilege escalation, and drop all capabilities.
+type SecurityContextCheck struct {
+	Restricted bool
+}
+
+func (SecurityContextCheck) Name() string { return "security-context" }
+
+func (c SecurityContextCheck) Check(node *yaml.RNode, meta yaml.ResourceMeta) ([]Result, error) {
+	spec, specPath, err := podSpec(node, meta)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+
+	var results []Result
+	fail := func(n *yaml.RNode, field, format string, args ...interface{}) {
+		results = append(results, Result{
+			Severity: SeverityError,
+			Line:     n.YNode().Line,
+			Field:    field,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, f := range []string{"hostNetwork", "hostPID", "hostIPC"} {
+		v, err := value(spec, f)
+		if err != nil {
+			return nil, err
+		}
+		if v == "true" {
+			fail(spec, specPath+"."+f, "pod must not set %s", f)
+		}
+	}
+
+	volumes, err := spec.Pipe(yaml.Lookup("volumes"))
+	if err != nil {
+		return nil, err
+	}
+	if volumes != nil {
+		err = volumes.VisitElements(func(v *yaml.RNode) error {
+			if f := v.Field("hostPath"); !yaml.IsFieldEmpty(f) {
+				name, _ := value(v, "name")
+				fail(v, fmt.Sprintf("%s.volumes[name=%s].hostPath", specPath, name),
+					"pod must not mount host paths")
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	podNonRoot, err := value(spec, "securityContext", "runAsNonRoot")
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := containers(spec, specPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cs {
+		ctr := cs[i]
+		sc := ctr.path + ".securityContext"
+
+		privileged, err := value(ctr.RNode, "securityContext", "privileged")
+		if err != nil {
+			return nil, err
+		}
+		if privileged == "true" {
+			fail(ctr.RNode, sc+".privileged", "container must not be privileged")
+		}
+
+		add, err := ctr.Pipe(yaml.Lookup("securityContext", "capabilities", "add"))
+		if err != nil {
+			return nil, err
+		}
+		var added []string
+		if add != nil {
+			for _, n := range add.Content() {
+				if !baselineCapabilities[n.Value] || c.Restricted {
+					added = append(added, n.Value)
+				}
+			}
+		}
+		// the restricted profile only allows NET_BIND_SERVICE to be added
+		if c.Restricted && len(added) == 1 && added[0] == "NET_BIND_SERVICE" {
+			added = nil
+		}
+		if len(added) > 0 {
+			fail(ctr.RNode, sc+".capabilities.add",
+				"container must not add capabilities %s", strings.Join(added, ","))
+		}
+
+		if !c.Restricted {
+			continue
+		}
+
+		nonRoot, err := value(ctr.RNode, "securityContext", "runAsNonRoot")
+		if err != nil {
+			return nil, err
+		}
+		if nonRoot != "true" && (nonRoot != "" || podNonRoot != "true") {
+			fail(ctr.RNode, sc+".runAsNonRoot", "container must set runAsNonRoot to true")
+		}
+
+		escalation, err := value(ctr.RNode, "securityContext", "allowPrivilegeEscalation")
+		if err != nil {
+			return nil, err
+		}
+		if escalation != "false" {
+			fail(ctr.RNode, sc+".allowPrivilegeEscalation",
+				"container must set allowPrivilegeEscalation to false")
+		}
+
+		drop, err := ctr.Pipe(yaml.Lookup("securityContext", "capabilities", "drop"))
+		if err != nil {
+			return nil, err
+		}
+		dropsAll := false
+		if drop != nil {
+			for _, n := range drop.Content() {
+				if n.Value == "ALL" {
+					dropsAll = true
+				}
+			}
+		}
+		if !dropsAll {
+			fail(ctr.RNode, sc+".capabilities.drop", "container must drop ALL capabilities")
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,338 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package diff contains libraries for comparing collections of Resources.
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ChangeType is the type of change made to a Resource or field.
+type ChangeType string
+
+const (
+	Added    ChangeType = "Added"
+	Removed  ChangeType = "Removed"
+	Modified ChangeType = "Modified"
+)
+
+// ResourceDiff is the difference between two instances of a Resource with the same
+// apiVersion, kind, namespace and name.
+type ResourceDiff struct {
+	ApiVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Namespace  string `yaml:"namespace,omitempty"`
+	Name       string `yaml:"name"`
+
+	// Type is Added if the Resource is only in the new Resources, Removed if it is only
+	// in the old Resources, and Modified otherwise.
+	Type ChangeType `yaml:"type"`
+
+	// Fields are the fields which differ.  Only set for Modified Resources.
+	Fields []FieldDiff `yaml:"fields,omitempty"`
+
+	// Old is the old Resource, or nil if it was Added.
+	Old *yaml.RNode `yaml:"-"`
+	// New is the new Resource, or nil if it was Removed.
+	New *yaml.RNode `yaml:"-"`
+}
+
+// ID returns a string identifying the Resource.
+func (d ResourceDiff) ID() string {
+	if d.Namespace == "" {
+		return fmt.Sprintf("%s %s %s", d.ApiVersion, d.Kind, d.Name)
+	}
+	return fmt.Sprintf("%s %s %s/%s", d.ApiVersion, d.Kind, d.Namespace, d.Name)
+}
+
+// FieldDiff is a field which differs between two instances of a Resource.
+type FieldDiff struct {
+	// Path is the path to the field -- e.g. .spec.template.spec.containers[name=nginx].image
+	Path string     `yaml:"path"`
+	Type ChangeType `yaml:"type"`
+	// Old is the old value of the field, formatted as flow yaml.
+	Old string `yaml:"old,omitempty"`
+	// New is the new value of the field, formatted as flow yaml.
+	New string `yaml:"new,omitempty"`
+}
+
+type resourceKey struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+}
+
+// Resources compares the old and new Resources, matching Resources by their apiVersion,
+// kind, namespace and name.  Resources which are the same are omitted.  Diffs are
+// returned in the order the Resources appear in old, followed by Resources added in new.
+func Resources(old, new []*yaml.RNode) ([]ResourceDiff, error) {
+	index := map[resourceKey]*yaml.RNode{}
+	var newKeys []resourceKey
+	for i := range new {
+		k, err := getKey(new[i])
+		if err != nil {
+			return nil, err
+		}
+		index[k] = new[i]
+		newKeys = append(newKeys, k)
+	}
+
+	var diffs []ResourceDiff
+	seen := map[resourceKey]bool{}
+	for i := range old {
+		k, err := getKey(old[i])
+		if err != nil {
+			return nil, err
+		}
+		seen[k] = true
+		d := ResourceDiff{
+			ApiVersion: k.apiVersion, Kind: k.kind, Namespace: k.namespace, Name: k.name,
+			Old: old[i], New: index[k],
+		}
+		if d.New == nil {
+			d.Type = Removed
+			diffs = append(diffs, d)
+			continue
+		}
+		d.Fields = Fields(d.Old, d.New)
+		if len(d.Fields) > 0 {
+			d.Type = Modified
+			diffs = append(diffs, d)
+		}
+	}
+	for _, k := range newKeys {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		diffs = append(diffs, ResourceDiff{
+			ApiVersion: k.apiVersion, Kind: k.kind, Namespace: k.namespace, Name: k.name,
+			Type: Added, New: index[k],
+		})
+	}
+	return diffs, nil
+}
+
+func getKey(rn *yaml.RNode) (resourceKey, error) {
+	meta, err := rn.GetMeta()
+	if err != nil {
+		return resourceKey{}, err
+	}
+	return resourceKey{
+		apiVersion: meta.ApiVersion,
+		kind:       meta.Kind,
+		namespace:  meta.Namespace,
+		name:       meta.Name,
+	}, nil
+}
+
+// Fields returns the fields which differ between old and new.  Elements of lists with
+// an associative key are matched by the key, other list elements are matched by index.
+func Fields(old, new *yaml.RNode) []FieldDiff {
+	var diffs []FieldDiff
+	fields(&diffs, "", old.YNode(), new.YNode())
+	return diffs
+}
+
+func fields(diffs *[]FieldDiff, path string, old, new *yaml.Node) {
+	if old.Kind != new.Kind {
+		*diffs = append(*diffs, FieldDiff{
+			Path: path, Type: Modified, Old: toString(old), New: toString(new)})
+		return
+	}
+
+	switch old.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(old.Content)-1; i += 2 {
+			key := old.Content[i].Value
+			p := path + "." + key
+			n := yaml.NewRNode(new).Field(key)
+			if n == nil {
+				*diffs = append(*diffs, FieldDiff{
+					Path: p, Type: Removed, Old: toString(old.Content[i+1])})
+				continue
+			}
+			fields(diffs, p, old.Content[i+1], n.Value.YNode())
+		}
+		for i := 0; i < len(new.Content)-1; i += 2 {
+			key := new.Content[i].Value
+			if yaml.NewRNode(old).Field(key) == nil {
+				*diffs = append(*diffs, FieldDiff{
+					Path: path + "." + key, Type: Added, New: toString(new.Content[i+1])})
+			}
+		}
+	case yaml.SequenceNode:
+		elements(diffs, path, old, new)
+	default:
+		if old.Value != new.Value {
+			*diffs = append(*diffs, FieldDiff{
+				Path: path, Type: Modified, Old: toString(old), New: toString(new)})
+		}
+	}
+}
+
+func elements(diffs *[]FieldDiff, path string, old, new *yaml.Node) {
+	key := yaml.NewRNode(old).GetAssociativeKey()
+	if key == "" || key != yaml.NewRNode(new).GetAssociativeKey() {
+		// match elements by index
+		for i := range old.Content {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if i >= len(new.Content) {
+				*diffs = append(*diffs, FieldDiff{Path: p, Type: Removed, Old: toString(old.Content[i])})
+				continue
+			}
+			fields(diffs, p, old.Content[i], new.Content[i])
+		}
+		for i := len(old.Content); i < len(new.Content); i++ {
+			*diffs = append(*diffs, FieldDiff{
+				Path: fmt.Sprintf("%s[%d]", path, i), Type: Added, New: toString(new.Content[i])})
+		}
+		return
+	}
+
+	// match elements by the associative key
+	for i := range old.Content {
+		value := yaml.NewRNode(old.Content[i]).Field(key).Value.YNode().Value
+		p := fmt.Sprintf("%s[%s=%s]", path, key, value)
+		n := yaml.NewRNode(new).Element(key, value)
+		if n == nil {
+			*diffs = append(*diffs, FieldDiff{Path: p, Type: Removed, Old: toString(old.Content[i])})
+			continue
+		}
+		fields(diffs, p, old.Content[i], n.YNode())
+	}
+	for i := range new.Content {
+		value := yaml.NewRNode(new.Content[i]).Field(key).Value.YNode().Value
+		if yaml.NewRNode(old).Element(key, value) == nil {
+			*diffs = append(*diffs, FieldDiff{
+				Path: fmt.Sprintf("%s[%s=%s]", path, key, value),
+				Type: Added, New: toString(new.Content[i])})
+		}
+	}
+}
+
+func toString(node *yaml.Node) string {
+	s, err := yaml.String(node, yaml.Trim, yaml.Flow)
+	if err != nil {
+		return node.Value
+	}
+	return s
+}
+
+// Lines returns a unified diff of the lines of old and new, with context lines
+// of context around each change.  Returns "" if old and new are the same.
+func Lines(oldName, newName, old, new string, context int) string {
+	a := splitLines(old)
+	b := splitLines(new)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// build the edit script
+	type edit struct {
+		op   byte
+		line string
+		// ai and bi are the indexes of the line in a and b
+		ai, bi int
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		default:
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		}
+	}
+
+	// group the edits into hunks
+	out := &strings.Builder{}
+	for start := 0; start < len(edits); {
+		// find the next change
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(out, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		// extend the hunk until there are more than 2*context unchanged lines
+		end := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				end = k + 1
+			} else if k-end >= 2*context {
+				break
+			}
+		}
+		from := start - context
+		if from < 0 {
+			from = 0
+		}
+		to := end + context
+		if to > len(edits) {
+			to = len(edits)
+		}
+
+		var aCount, bCount int
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(out, "@@ -%s +%s @@\n",
+			hunkRange(edits[from].ai, aCount), hunkRange(edits[from].bi, bCount))
+		for _, e := range edits[from:to] {
+			fmt.Fprintf(out, "%c%s\n", e.op, e.line)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// hunkRange formats the start line and count of a hunk
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/diff"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestResources(t *testing.T) {
+	old := []*yaml.RNode{
+		yaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7.9
+      - name: sidecar
+        image: sidecar:1
+`),
+		yaml.MustParse(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`),
+		yaml.MustParse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: same
+data:
+  a: b
+`),
+	}
+	new := []*yaml.RNode{
+		yaml.MustParse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: same
+data:
+  a: b
+`),
+		yaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  labels:
+    app: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [a, b]
+`),
+		yaml.MustParse(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  namespace: bar
+`),
+	}
+
+	diffs, err := diff.Resources(old, new)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for i := range diffs {
+		diffs[i].Old, diffs[i].New = nil, nil
+	}
+	assert.Equal(t, []diff.ResourceDiff{
+		{
+			ApiVersion: "apps/v1", Kind: "Deployment", Name: "foo", Type: diff.Modified,
+			Fields: []diff.FieldDiff{
+				{Path: ".metadata.labels", Type: diff.Added, New: "{app: nginx}"},
+				{Path: ".spec.replicas", Type: diff.Removed, Old: "1"},
+				{Path: ".spec.template.spec.containers[name=nginx].image", Type: diff.Modified,
+					Old: "nginx:1.7.9", New: "nginx:1.8"},
+				{Path: ".spec.template.spec.containers[name=nginx].args", Type: diff.Added,
+					New: "[a, b]"},
+				{Path: ".spec.template.spec.containers[name=sidecar]", Type: diff.Removed,
+					Old: "{name: sidecar, image: 'sidecar:1'}"},
+			},
+		},
+		{ApiVersion: "v1", Kind: "Service", Name: "foo", Type: diff.Removed},
+		{ApiVersion: "v1", Kind: "Service", Namespace: "bar", Name: "foo", Type: diff.Added},
+	}, diffs)
+}
+
+func TestFields_index(t *testing.T) {
+	diffs := diff.Fields(
+		yaml.MustParse("a: [1, 2, 3]\nb: {c: d}\n"),
+		yaml.MustParse("a: [1, 4]\nb: e\n"))
+	assert.Equal(t, []diff.FieldDiff{
+		{Path: ".a[1]", Type: diff.Modified, Old: "2", New: "4"},
+		{Path: ".a[2]", Type: diff.Removed, Old: "3"},
+		{Path: ".b", Type: diff.Modified, Old: "{c: d}", New: "e"},
+	}, diffs)
+}
+
+func TestLines(t *testing.T) {
+	assert.Equal(t, "", diff.Lines("a", "b", "a\nb\n", "a\nb\n", 3))
+
+	assert.Equal(t, `--- a
++++ b
+@@ -1,4 +1,4 @@
+ 1
+-2
++two
+ 3
+ 4
+@@ -9,2 +9,3 @@
+ 9
+ 10
++11
+`, diff.Lines("a", "b",
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\n11\n", 2))
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// ApplySetIDLabel is the label of the parent of an apply set with the id of the set
+	ApplySetIDLabel = "applyset.kubernetes.io/id"
+
+	// ApplySetPartOfLabel is the label of the members of an apply set with the id of the set
+	ApplySetPartOfLabel = "applyset.kubernetes.io/part-of"
+
+	// ApplySetToolingAnnotation is the annotation of the parent of an apply set with the
+	// tooling managing the set
+	ApplySetToolingAnnotation = "applyset.kubernetes.io/tooling"
+
+	// ApplySetGroupKindsAnnotation is the annotation of the parent of an apply set with the
+	// group kinds of its members
+	ApplySetGroupKindsAnnotation = "applyset.kubernetes.io/contains-group-kinds"
+
+	// ApplySetNamespacesAnnotation is the annotation of the parent of an apply set with the
+	// namespaces of its members other than the namespace of the parent
+	ApplySetNamespacesAnnotation = "applyset.kubernetes.io/additional-namespaces"
+
+	// DefaultApplySetTooling is the default tooling of apply sets
+	DefaultApplySetTooling = "kyaml/v0"
+
+	// DefaultApplySetPath is the default path of the parent of apply sets written by
+	// LocalPackageWriter
+	DefaultApplySetPath = "applyset.yaml"
+
+	// localConfigAnnotation is the annotation of Resources which aren't applied -- see
+	// filters.LocalConfigAnnotation
+	localConfigAnnotation = "config.kubernetes.io/local-config"
+)
+
+// ApplySet labels Resources as the members of a kubectl apply set, and adds the parent
+// object of the set recording its members -- see
+// https://git.k8s.io/enhancements/keps/sig-cli/3659-kubectl-apply-prune -- so that
+// tooling applying the Resources may safely prune the Resources removed from the set.
+//
+// Each Resource is labeled with the id of the set, except local config Resources, which
+// aren't applied.  The parent is a Secret or ConfigMap with the id and the group kinds
+// and namespaces of the members.  If the Resources already contain the parent, it is
+// updated rather than added.
+type ApplySet struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Name is the name of the parent.
+	Name string `yaml:"name,omitempty"`
+
+	// Namespace is the namespace of the parent.
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// ParentKind is the kind of the parent -- Secret or ConfigMap.  Defaults to Secret.
+	ParentKind string `yaml:"parentKind,omitempty"`
+
+	// Tooling is the name and version of the tooling managing the set.  Defaults to
+	// DefaultApplySetTooling.
+	Tooling string `yaml:"tooling,omitempty"`
+
+	// Path, if set, is the config.kubernetes.io/path of the parent if it is added.
+	Path string `yaml:"path,omitempty"`
+}
+
+var _ Filter = ApplySet{}
+
+// ID returns the id of the set, computed from the identity of its parent as kubectl
+// computes it.
+func (a ApplySet) ID() string {
+	sum := sha256.Sum256([]byte(strings.Join(
+		[]string{a.Name, a.Namespace, a.parentKind(), ""}, ".")))
+	return "applyset-" + base64.RawURLEncoding.EncodeToString(sum[:]) + "-v1"
+}
+
+func (a ApplySet) parentKind() string {
+	if a.ParentKind == "" {
+		return "Secret"
+	}
+	return a.ParentKind
+}
+
+// Filter labels the members of the set and adds or updates its parent.
+func (a ApplySet) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if a.Name == "" || a.Namespace == "" {
+		return nil, errors.Errorf("apply set must specify the name and namespace of its parent")
+	}
+	if kind := a.parentKind(); kind != "Secret" && kind != "ConfigMap" {
+		return nil, errors.Errorf("apply set parent must be a Secret or ConfigMap: %s", kind)
+	}
+	id := a.ID()
+	tooling := a.Tooling
+	if tooling == "" {
+		tooling = DefaultApplySetTooling
+	}
+
+	var parent *yaml.RNode
+	groupKinds, namespaces := sets.String{}, sets.String{}
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if meta.ApiVersion == "v1" && meta.Kind == a.parentKind() &&
+			meta.Name == a.Name && meta.Namespace == a.Namespace {
+			parent = nodes[i]
+			continue
+		}
+		if meta.Annotations[localConfigAnnotation] == "true" {
+			continue
+		}
+		if err := nodes[i].PipeE(yaml.SetLabel(ApplySetPartOfLabel, id)); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		groupKind := meta.Kind
+		if i := strings.Index(meta.ApiVersion, "/"); i >= 0 {
+			groupKind += "." + meta.ApiVersion[:i]
+		}
+		groupKinds.Insert(groupKind)
+		if meta.Namespace != "" && meta.Namespace != a.Namespace {
+			namespaces.Insert(meta.Namespace)
+		}
+	}
+
+	if parent == nil {
+		var err error
+		parent, err = yaml.Parse(`apiVersion: v1
+kind: ` + a.parentKind() + `
+metadata:
+  name: ` + a.Name + `
+  namespace: ` + a.Namespace + `
+  labels:
+    ` + ApplySetIDLabel + `: ` + id + `
+`)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if a.Path != "" {
+			err = parent.PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, a.Path))
+			if err != nil {
+				return nil, errors.Wrap(err)
+			}
+			err = parent.PipeE(yaml.SetAnnotation(kioutil.IndexAnnotation, "0"))
+			if err != nil {
+				return nil, errors.Wrap(err)
+			}
+		}
+		nodes = append(nodes, parent)
+	}
+	err := parent.PipeE(yaml.SetLabel(ApplySetIDLabel, id))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	err = parent.PipeE(yaml.SetAnnotation(ApplySetToolingAnnotation, tooling))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	err = parent.PipeE(yaml.SetAnnotation(ApplySetGroupKindsAnnotation, joinSorted(groupKinds)))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if len(namespaces) == 0 {
+		err = parent.PipeE(yaml.ClearAnnotation(ApplySetNamespacesAnnotation))
+	} else {
+		err = parent.PipeE(yaml.SetAnnotation(ApplySetNamespacesAnnotation, joinSorted(namespaces)))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return nodes, nil
+}
+
+// joinSorted returns the values of s sorted and separated by commas
+func joinSorted(s sets.String) string {
+	values := s.List()
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
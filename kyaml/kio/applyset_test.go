@@ -0,0 +1,171 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+const applySetInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: other
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: app
+---
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+metadata:
+  name: local
+  annotations:
+    config.kubernetes.io/local-config: "true"
+`
+
+func TestApplySet_ID(t *testing.T) {
+	assert.Equal(t, "applyset-4SeA_RrtFubF-r96PJcBQ0Uok0PdHwLBiX7mPYjsNGc-v1",
+		ApplySet{Name: "app", Namespace: "default"}.ID())
+	assert.Equal(t, "applyset-YCzx7klw7VgCYqZFfSczaz8XilggEOA6GuX0PqvTQwE-v1",
+		ApplySet{Name: "app", Namespace: "default", ParentKind: "ConfigMap"}.ID())
+}
+
+func TestByteWriter_Write_applySet(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: strings.NewReader(applySetInput)}},
+		Outputs: []Writer{ByteWriter{Writer: out,
+			ApplySet: &ApplySet{Name: "app", Namespace: "default"}}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  labels:
+    applyset.kubernetes.io/part-of: applyset-4SeA_RrtFubF-r96PJcBQ0Uok0PdHwLBiX7mPYjsNGc-v1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: other
+  labels:
+    applyset.kubernetes.io/part-of: applyset-4SeA_RrtFubF-r96PJcBQ0Uok0PdHwLBiX7mPYjsNGc-v1
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: app
+  labels:
+    applyset.kubernetes.io/part-of: applyset-4SeA_RrtFubF-r96PJcBQ0Uok0PdHwLBiX7mPYjsNGc-v1
+---
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+metadata:
+  name: local
+  annotations:
+    config.kubernetes.io/local-config: "true"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: default
+  labels:
+    applyset.kubernetes.io/id: applyset-4SeA_RrtFubF-r96PJcBQ0Uok0PdHwLBiX7mPYjsNGc-v1
+  annotations:
+    applyset.kubernetes.io/tooling: kyaml/v0
+    applyset.kubernetes.io/contains-group-kinds: ClusterRole.rbac.authorization.k8s.io,Deployment.apps,Service
+    applyset.kubernetes.io/additional-namespaces: other
+`, out.String())
+}
+
+func TestLocalPackageWriter_Write_applySet(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(d)
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "app.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+`), 0600)) {
+		return
+	}
+	a := &ApplySet{Name: "app", Namespace: "default", ParentKind: "ConfigMap", Tooling: "pkg/v1"}
+	rw := &LocalPackageReadWriter{PackagePath: d}
+	if !assert.NoError(t, Pipeline{Inputs: []Reader{rw},
+		Outputs: []Writer{LocalPackageWriter{PackagePath: d, ApplySet: a}}}.Execute()) {
+		return
+	}
+	expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: default
+  labels:
+    applyset.kubernetes.io/id: applyset-YCzx7klw7VgCYqZFfSczaz8XilggEOA6GuX0PqvTQwE-v1
+  annotations:
+    applyset.kubernetes.io/tooling: pkg/v1
+    applyset.kubernetes.io/contains-group-kinds: Deployment.apps
+`
+	b, err := ioutil.ReadFile(filepath.Join(d, DefaultApplySetPath))
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, string(b))
+	}
+
+	// the parent is updated rather than added again
+	if !assert.NoError(t, Pipeline{Inputs: []Reader{rw},
+		Outputs: []Writer{LocalPackageWriter{PackagePath: d, ApplySet: a}}}.Execute()) {
+		return
+	}
+	b, err = ioutil.ReadFile(filepath.Join(d, DefaultApplySetPath))
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, string(b))
+	}
+	b, err = ioutil.ReadFile(filepath.Join(d, "app.yaml"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  labels:
+    applyset.kubernetes.io/part-of: applyset-YCzx7klw7VgCYqZFfSczaz8XilggEOA6GuX0PqvTQwE-v1
+`, string(b))
+	}
+}
+
+func TestApplySet_Filter_errors(t *testing.T) {
+	_, err := ApplySet{Name: "app"}.Filter(nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "apply set must specify the name and namespace of its parent")
+	}
+	_, err = ApplySet{Name: "app", Namespace: "default", ParentKind: "Deployment"}.Filter(nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "apply set parent must be a Secret or ConfigMap: Deployment")
+	}
+}
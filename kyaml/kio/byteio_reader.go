@@ -4,6 +4,7 @@
 package kio
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -39,6 +40,19 @@ type ByteReadWriter struct {
 	// Style is a style that is set on the Resource Node Document.
 	Style yaml.Style
 
+	// AliasMode configures how anchors and aliases are handled when reading.
+	AliasMode AliasMode
+
+	// DuplicateKeyMode configures how duplicate keys are handled when reading.
+	DuplicateKeyMode DuplicateKeyMode
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer
+
+	// Limits guard reading against inputs too large to be read into memory.
+	Limits Limits
+
 	FunctionConfig *yaml.RNode
 
 	WrappingApiVersion string
@@ -49,6 +63,10 @@ func (rw *ByteReadWriter) Read() ([]*yaml.RNode, error) {
 	b := &ByteReader{
 		Reader:                rw.Reader,
 		OmitReaderAnnotations: rw.OmitReaderAnnotations,
+		AliasMode:             rw.AliasMode,
+		DuplicateKeyMode:      rw.DuplicateKeyMode,
+		Warnings:              rw.Warnings,
+		Limits:                rw.Limits,
 	}
 	val, err := b.Read()
 	rw.FunctionConfig = b.FunctionConfig
@@ -57,6 +75,30 @@ func (rw *ByteReadWriter) Read() ([]*yaml.RNode, error) {
 	return val, errors.Wrap(err)
 }
 
+// withReadPolicy returns the ByteReadWriter configured to use the AliasMode and
+// DuplicateKeyMode
+func (rw *ByteReadWriter) withReadPolicy(aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	rw.AliasMode, rw.DuplicateKeyMode = aliases, duplicateKeys
+	return rw
+}
+
+// withWarnings returns the ByteReadWriter configured to write warnings to w, unless it
+// sets its own
+func (rw *ByteReadWriter) withWarnings(w io.Writer) Reader {
+	if rw.Warnings == nil {
+		rw.Warnings = w
+	}
+	return rw
+}
+
+// withLimits returns the ByteReadWriter configured to use the Limits, unless it sets its own
+func (rw *ByteReadWriter) withLimits(l Limits) Reader {
+	if rw.Limits == (Limits{}) {
+		rw.Limits = l
+	}
+	return rw
+}
+
 func (rw *ByteReadWriter) Write(nodes []*yaml.RNode) error {
 	return ByteWriter{
 		Writer:                rw.Writer,
@@ -71,6 +113,11 @@ func (rw *ByteReadWriter) Write(nodes []*yaml.RNode) error {
 // ByteReader decodes ResourceNodes from bytes.
 // By default, Read will set the config.kubernetes.io/index annotation on each RNode as it
 // is read so they can be written back in the same order.
+//
+// The bytes may be YAML documents, or a stream of JSON values -- e.g. the output of
+// kubectl get -o json -- which are read as block style YAML.  Lists and ResourceLists are
+// unwrapped in either format, recording the wrapping kind so that ByteWriter may wrap the
+// Resources again when they are written.
 type ByteReader struct {
 	// Reader is where ResourceNodes are decoded from.
 	Reader io.Reader
@@ -88,6 +135,31 @@ type ByteReader struct {
 	// DisableUnwrapping prevents Resources in Lists and ResourceLists from being unwrapped
 	DisableUnwrapping bool
 
+	// ParseMode configures how the Resources are parsed.  Defaults to ParseModePreserve.
+	ParseMode ParseMode
+
+	// AliasMode configures how anchors and aliases are handled.  Defaults to
+	// AliasModePreserve.
+	AliasMode AliasMode
+
+	// DuplicateKeyMode configures how duplicate keys are handled.  Defaults to
+	// DuplicateKeyModeError.
+	DuplicateKeyMode DuplicateKeyMode
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer
+
+	// Limits guard Read against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits
+
+	// ContinueOnError configures Read to skip the documents which can't be parsed, and to
+	// return the Resources of the other documents together with a ReadErrors listing the
+	// skipped documents.  Documents are only skipped using ParseModePreserve -- using
+	// ParseModeFast the input is parsed as a single stream, which can't be resumed.
+	ContinueOnError bool
+
 	// WrappingApiVersion is set by Read(), and is the apiVersion of the object that
 	// the read objects were originally wrapped in.
 	WrappingApiVersion string
@@ -100,24 +172,105 @@ type ByteReader struct {
 var _ Reader = &ByteReader{}
 
 func (r *ByteReader) Read() ([]*yaml.RNode, error) {
-	output := ResourceNodeSlice{}
+	nodes, err := r.read()
+	if err != nil && !partial(err) {
+		return nil, err
+	}
+	if err := r.Limits.checkCount(len(nodes)); err != nil {
+		return nil, err
+	}
+	return nodes, err
+}
+
+func (r *ByteReader) read() ([]*yaml.RNode, error) {
+	in := r.Limits.reader(r.Reader)
+	if r.ParseMode == ParseModeFast {
+		// the input is decoded as a stream, unless it is JSON
+		b := bufio.NewReader(in)
+		if !peekJSON(b) {
+			return r.readFast(b)
+		}
+		in = b
+	}
 
 	// by manually splitting resources -- otherwise the decoder will get the Resource
 	// boundaries wrong for header comments.
 	input := &bytes.Buffer{}
-	_, err := io.Copy(input, r.Reader)
+	_, err := io.Copy(input, in)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
-	values := strings.Split(input.String(), "\n---\n")
+	return r.readInput(input.Bytes())
+}
+
+// readInput reads the Resources from input, which is either YAML documents or a stream of
+// JSON values -- e.g. the output of kubectl get -o json.
+func (r *ByteReader) readInput(input []byte) ([]*yaml.RNode, error) {
+	if values, ok := jsonValues(input); ok {
+		return r.readJSON(values)
+	}
+	if r.ParseMode == ParseModeFast {
+		return r.readFast(bytes.NewReader(input))
+	}
+	return r.readDocuments(input)
+}
+
+// readJSON reads the Resources from JSON values.  The values are unwrapped in the same way
+// as YAML documents, and written as block style YAML rather than in the flow style of
+// JSON.
+func (r *ByteReader) readJSON(values []string) ([]*yaml.RNode, error) {
+	// JSON values cannot contain raw newlines in strings, so they can be safely
+	// joined as YAML documents
+	nodes, err := r.readDocuments([]byte(strings.Join(values, string(documentSeparator))))
+	if err != nil && !partial(err) {
+		return nil, err
+	}
+	for i := range nodes {
+		blockStyle(nodes[i].YNode())
+	}
+	if r.FunctionConfig != nil {
+		blockStyle(r.FunctionConfig.YNode())
+	}
+	return nodes, err
+}
+
+// documentSeparator separates the Resources of the input
+var documentSeparator = []byte("\n---\n")
+
+// readDocuments reads the Resources from input, split on documentSeparator.  The
+// documents are decoded one at a time from slices of input rather than copies of it, so
+// that input may be memory-mapped.
+func (r *ByteReader) readDocuments(input []byte) ([]*yaml.RNode, error) {
+	output := ResourceNodeSlice{}
+	var skipped ReadErrors
+
+	// the elements of a List or ResourceList are only unwrapped if it is the only value
+	single := !bytes.Contains(input, documentSeparator)
 
 	index := 0
-	for i := range values {
-		decoder := yaml.NewDecoder(bytes.NewBufferString(values[i]))
-		node, err := r.decode(index, decoder)
+	line := 0
+	for i, rest, done := 0, input, false; !done; i++ {
+		// slice the next value from the input, as strings.Split would
+		value := rest
+		if j := bytes.Index(rest, documentSeparator); j >= 0 {
+			value, rest = rest[:j], rest[j+len(documentSeparator):]
+		} else {
+			done = true
+		}
+
+		// line is the number of lines preceding this value in the input
+		offset := line
+		line += bytes.Count(value, []byte("\n")) + 2
+
+		decoder := yaml.NewDecoder(bytes.NewReader(value))
+		node, err := r.decode(index, offset, decoder)
 		if err == io.EOF {
 			continue
 		}
+		if err != nil && r.ContinueOnError {
+			skipped = append(skipped, newReadError(offset, err))
+			continue
+		}
 		if err != nil {
 			return nil, errors.Wrap(err)
 		}
@@ -128,35 +281,21 @@ func (r *ByteReader) Read() ([]*yaml.RNode, error) {
 
 		// ok if no metadata -- assume not an InputList
 		meta, err := node.GetMeta()
+		if err != yaml.ErrMissingMetadata && err != nil && r.ContinueOnError {
+			skipped = append(skipped, newReadError(offset, err))
+			continue
+		}
 		if err != yaml.ErrMissingMetadata && err != nil {
 			return nil, errors.WrapPrefixf(err, "[%d]", i)
 		}
 
 		// the elements are wrapped in an InputList, unwrap them
-		// don't check apiVersion, we haven't standardized on the domain
-		if !r.DisableUnwrapping &&
-			len(values) == 1 && // Only unwrap if there is only 1 value
-			(meta.Kind == ResourceListKind || meta.Kind == "List") &&
-			node.Field("items") != nil {
-
-			r.WrappingKind = meta.Kind
-			r.WrappingApiVersion = meta.ApiVersion
-
-			// unwrap the list
-			fc := node.Field("functionConfig")
-			if fc != nil {
-				r.FunctionConfig = fc.Value
-			}
-
-			items := node.Field("items")
-			if items != nil {
-				for i := range items.Value.Content() {
-					// add items
-					output = append(output, yaml.NewRNode(items.Value.Content()[i]))
-				}
-
+		// Only unwrap if there is only 1 value
+		if single {
+			if items, ok := r.unwrap(node, meta.ApiVersion, meta.Kind); ok {
+				output = append(output, items...)
+				continue
 			}
-			continue
 		}
 
 		// add the node to the list
@@ -165,16 +304,138 @@ func (r *ByteReader) Read() ([]*yaml.RNode, error) {
 		// increment the index annotation value
 		index++
 	}
+	return output, skipped.err()
+}
+
+// unwrap returns the items of node if it is a List or ResourceList which should be
+// unwrapped, and records the wrapping kind and functionConfig.
+func (r *ByteReader) unwrap(node *yaml.RNode, apiVersion, kind string) ([]*yaml.RNode, bool) {
+	// don't check apiVersion, we haven't standardized on the domain
+	if r.DisableUnwrapping || (kind != ResourceListKind && kind != "List") {
+		return nil, false
+	}
+	items := node.Field("items")
+	if items == nil {
+		return nil, false
+	}
+
+	r.WrappingKind = kind
+	r.WrappingApiVersion = apiVersion
+
+	// unwrap the list
+	fc := node.Field("functionConfig")
+	if fc != nil {
+		r.FunctionConfig = fc.Value
+	}
+
+	output := []*yaml.RNode{}
+	for i := range items.Value.Content() {
+		// add items
+		output = append(output, yaml.NewRNode(items.Value.Content()[i]))
+	}
+	return output, true
+}
+
+// readFast reads the Resources using ParseModeFast.  The input is decoded as a single
+// stream, rather than splitting it into Resources so that the comments between Resources
+// are attributed to the right Resource, and the metadata is only parsed for Lists.
+func (r *ByteReader) readFast(in io.Reader) ([]*yaml.RNode, error) {
+	decoder := yaml.NewDecoder(in)
+	output := ResourceNodeSlice{}
+	for index := 0; ; {
+		node, err := r.decode(index, 0, decoder)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if yaml.IsMissingOrNull(node) {
+			// empty value
+			continue
+		}
+		output = append(output, node)
+		index++
+	}
+
+	if len(output) == 1 {
+		kind, apiVersion := output[0].Field("kind"), output[0].Field("apiVersion")
+		if !yaml.IsFieldEmpty(kind) {
+			var v string
+			if !yaml.IsFieldEmpty(apiVersion) {
+				v = apiVersion.Value.YNode().Value
+			}
+			if items, ok := r.unwrap(output[0], v, kind.Value.YNode().Value); ok {
+				return items, nil
+			}
+		}
+	}
 	return output, nil
 }
 
+// withParseMode returns the ByteReader configured to use the ParseMode
+func (r *ByteReader) withParseMode(mode ParseMode) Reader {
+	r.ParseMode = mode
+	return r
+}
+
+// withLimits returns the ByteReader configured to use the Limits, unless it sets its own
+func (r *ByteReader) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
+
+// withContinueOnError returns the ByteReader configured to skip the documents which can't
+// be parsed
+func (r *ByteReader) withContinueOnError() Reader {
+	r.ContinueOnError = true
+	return r
+}
+
+// withReadPolicy returns the ByteReader configured to use the AliasMode and
+// DuplicateKeyMode
+func (r *ByteReader) withReadPolicy(aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns the ByteReader configured to write warnings to w, unless it sets
+// its own
+func (r *ByteReader) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// readPolicy returns the policy applied to the Resources as they are read
+func (r *ByteReader) readPolicy() readPolicy {
+	return readPolicy{
+		aliases: r.AliasMode, duplicateKeys: r.DuplicateKeyMode, warnings: r.Warnings}
+}
+
+// shiftLines adds offset to the line numbers of node and its descendants
+func shiftLines(node *yaml.Node, offset int) {
+	if node == nil || offset == 0 {
+		return
+	}
+	node.Line += offset
+	for i := range node.Content {
+		shiftLines(node.Content[i], offset)
+	}
+}
+
 func isEmptyDocument(node *yaml.Node) bool {
 	// node is a Document with no content -- e.g. "---\n---"
 	return node.Kind == yaml.DocumentNode &&
 		node.Content[0].Tag == yaml.NullNodeTag
 }
 
-func (r *ByteReader) decode(index int, decoder *yaml.Decoder) (*yaml.RNode, error) {
+// decode decodes the next Resource from decoder.  offset is the number of lines preceding
+// the decoded value in the input.
+func (r *ByteReader) decode(index, offset int, decoder *yaml.Decoder) (*yaml.RNode, error) {
 	node := &yaml.Node{}
 	err := decoder.Decode(node)
 	if err == io.EOF {
@@ -188,6 +449,13 @@ func (r *ByteReader) decode(index int, decoder *yaml.Decoder) (*yaml.RNode, erro
 		return nil, nil
 	}
 
+	// make the node line numbers relative to the input rather than the value
+	shiftLines(node, offset)
+	// apply the policy before setting annotations, which may be dropped with duplicate keys
+	if err := r.readPolicy().apply(node); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
 	// set annotations on the read Resources
 	// sort the annotations by key so the output Resources is consistent (otherwise the
 	// annotations will be in a random order)
@@ -308,3 +308,339 @@ metadata:
 		}
 	}
 }
+
+func TestByteReader_Read_lines(t *testing.T) {
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(`a: b
+c: d
+---
+e: f
+
+g: h
+---
+i: j
+`)}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, nodes, 3) {
+		return
+	}
+	// line numbers are relative to the input rather than the document
+	assert.Equal(t, 1, nodes[0].YNode().Content[0].Line)
+	assert.Equal(t, 2, nodes[0].YNode().Content[2].Line)
+	assert.Equal(t, 4, nodes[1].YNode().Content[0].Line)
+	assert.Equal(t, 6, nodes[1].YNode().Content[2].Line)
+	assert.Equal(t, 8, nodes[2].YNode().Content[0].Line)
+}
+
+func TestByteReader_Read_fast(t *testing.T) {
+	nodes, err := (&ByteReader{ParseMode: ParseModeFast, Reader: bytes.NewBufferString(`a: b
+c: d
+---
+e: f
+
+g: h
+---
+---
+i: j
+`)}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, nodes, 3) {
+		return
+	}
+	assert.Equal(t, 1, nodes[0].YNode().Content[0].Line)
+	assert.Equal(t, 2, nodes[0].YNode().Content[2].Line)
+	assert.Equal(t, 4, nodes[1].YNode().Content[0].Line)
+	assert.Equal(t, 6, nodes[1].YNode().Content[2].Line)
+	assert.Equal(t, 9, nodes[2].YNode().Content[0].Line)
+	assert.Equal(t, `i: j
+metadata:
+  annotations:
+    config.kubernetes.io/index: 2
+`, nodes[2].MustString())
+}
+
+func TestByteReader_Read_fastWrappedList(t *testing.T) {
+	r := &ByteReader{ParseMode: ParseModeFast, Reader: bytes.NewBufferString(`apiVersion: v1
+kind: List
+items:
+- kind: Deployment
+- kind: Service
+`)}
+	nodes, err := r.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, nodes, 2) {
+		return
+	}
+	assert.Equal(t, "kind: Deployment\n", nodes[0].MustString())
+	assert.Equal(t, "kind: Service\n", nodes[1].MustString())
+	assert.Equal(t, "List", r.WrappingKind)
+	assert.Equal(t, "v1", r.WrappingApiVersion)
+}
+
+// benchmarkInput returns n Deployments
+func benchmarkInput(n int) string {
+	b := &bytes.Buffer{}
+	for i := 0; i < n; i++ {
+		b.WriteString(`---
+# the nginx Deployment
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx # the name
+  labels:
+    app: nginx
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      containers:
+      - name: nginx
+        image: "nginx:1.17"
+        args: [a, b, c]
+        ports:
+        - containerPort: 80
+`)
+	}
+	return b.String()
+}
+
+func benchmarkByteReader(b *testing.B, mode ParseMode) {
+	in := benchmarkInput(500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := (&ByteReader{Reader: bytes.NewBufferString(in), ParseMode: mode}).Read()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkByteReader_Read(b *testing.B) { benchmarkByteReader(b, ParseModePreserve) }
+
+func BenchmarkByteReader_Read_fast(b *testing.B) { benchmarkByteReader(b, ParseModeFast) }
+
+const aliasInput = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: base
+    labels: &labels
+      app: nginx
+  data: &data
+    a: b
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: extended
+    labels: *labels
+  data:
+    <<: *data
+    c: d
+`
+
+func TestByteReader_Read_aliasModePreserve(t *testing.T) {
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(aliasInput)}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: extended
+  labels: *labels
+data:
+  !!merge <<: *data
+  c: d
+`, nodes[1].MustString())
+}
+
+func TestByteReader_Read_aliasModeExpand(t *testing.T) {
+	for _, mode := range []ParseMode{ParseModePreserve, ParseModeFast} {
+		nodes, err := (&ByteReader{
+			Reader:    bytes.NewBufferString(aliasInput),
+			ParseMode: mode,
+			AliasMode: AliasModeExpand,
+		}).Read()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: base
+  labels:
+    app: nginx
+data:
+  a: b
+`, nodes[0].MustString())
+		assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: extended
+  labels:
+    app: nginx
+data:
+  c: d
+  a: b
+`, nodes[1].MustString())
+	}
+}
+
+func TestByteReader_Read_aliasModeError(t *testing.T) {
+	_, err := (&ByteReader{
+		Reader: bytes.NewBufferString(aliasInput), AliasMode: AliasModeError}).Read()
+	assert.EqualError(t, err, "8:13: anchor &labels is not allowed")
+}
+
+const duplicateKeyInput = `a: b
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+data:
+  a: b
+metadata:
+  name: last
+`
+
+func TestByteReader_Read_duplicateKeyModeError(t *testing.T) {
+	// duplicate keys are errors for all parse modes
+	for _, mode := range []ParseMode{ParseModePreserve, ParseModeFast} {
+		_, err := (&ByteReader{
+			Reader:    bytes.NewBufferString(duplicateKeyInput),
+			ParseMode: mode,
+		}).Read()
+		assert.EqualError(t, err, `9:1: duplicate key "metadata", first defined at line 5`)
+	}
+}
+
+func TestByteReader_Read_duplicateKeyModeLastWins(t *testing.T) {
+	warnings := &bytes.Buffer{}
+	nodes, err := (&ByteReader{
+		Reader:           bytes.NewBufferString(duplicateKeyInput),
+		DuplicateKeyMode: DuplicateKeyModeLastWins,
+		Warnings:         warnings,
+	}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "5:1: duplicate key \"metadata\", using the value at line 9\n",
+		warnings.String())
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+data:
+  a: b
+metadata:
+  name: last
+  annotations:
+    config.kubernetes.io/index: 1
+`, nodes[1].MustString())
+}
+
+func TestByteReader_Read_unknownMode(t *testing.T) {
+	_, err := (&ByteReader{
+		Reader: bytes.NewBufferString("a: b\n"), AliasMode: "inline"}).Read()
+	assert.EqualError(t, err, `unknown alias mode "inline"`)
+}
+
+const jsonListInput = `{
+    "apiVersion": "v1",
+    "kind": "List",
+    "items": [
+        {
+            "apiVersion": "v1",
+            "kind": "ConfigMap",
+            "metadata": {"name": "a"},
+            "data": {"enabled": "true", "replicas": "1", "value": "b"}
+        },
+        {
+            "apiVersion": "v1",
+            "kind": "ConfigMap",
+            "metadata": {"name": "c"}
+        }
+    ]
+}
+`
+
+func TestByteReader_Read_json(t *testing.T) {
+	// JSON is read as block style YAML, and Lists are unwrapped for all parse modes
+	for _, mode := range []ParseMode{ParseModePreserve, ParseModeFast} {
+		r := &ByteReader{
+			Reader:                bytes.NewBufferString(jsonListInput),
+			OmitReaderAnnotations: true,
+			ParseMode:             mode,
+		}
+		nodes, err := r.Read()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "List", r.WrappingKind)
+		assert.Equal(t, "v1", r.WrappingApiVersion)
+
+		// the Resources are wrapped in the List when written
+		out := &bytes.Buffer{}
+		err = ByteWriter{
+			Writer:             out,
+			WrappingKind:       r.WrappingKind,
+			WrappingApiVersion: r.WrappingApiVersion,
+		}.Write(nodes)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: a
+  data:
+    enabled: "true"
+    replicas: "1"
+    value: b
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: c
+`, out.String())
+	}
+}
+
+func TestByteReader_Read_jsonStream(t *testing.T) {
+	// streams of JSON values and arrays are read as the Resources of each value
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(`{"kind": "A"}
+{"kind": "B"}
+[{"kind": "C"}, {"kind": "D"}]
+`)}).Read()
+	if !assert.NoError(t, err) || !assert.Len(t, nodes, 4) {
+		return
+	}
+	assert.Equal(t, `kind: D
+metadata:
+  annotations:
+    config.kubernetes.io/index: 3
+`, nodes[3].MustString())
+}
+
+func TestByteReader_Read_flowStyleYAML(t *testing.T) {
+	// YAML documents starting with flow mappings which aren't JSON are read as YAML
+	nodes, err := (&ByteReader{
+		Reader:                bytes.NewBufferString("{a: b}\n---\n{c: d}\n"),
+		OmitReaderAnnotations: true,
+	}).Read()
+	if !assert.NoError(t, err) || !assert.Len(t, nodes, 2) {
+		return
+	}
+	assert.Equal(t, "{c: d}\n", nodes[1].MustString())
+}
@@ -40,11 +40,42 @@ type ByteWriter struct {
 
 	// Sort if set, will cause ByteWriter to sort the the nodes before writing them.
 	Sort bool
+
+	// RenderMetadata if set will write the Pipeline Metadata of the Resources as
+	// annotations -- see Metadata.Render.  Otherwise Metadata is dropped.
+	RenderMetadata bool
+
+	// ApplySet if set will write the Resources as the members of an apply set, followed
+	// by the parent of the set if they don't contain it -- see ApplySet.
+	ApplySet *ApplySet
 }
 
-var _ Writer = ByteWriter{}
+var _ MetadataWriter = ByteWriter{}
+
+// WriteWithMetadata writes the nodes, rendering their Metadata if RenderMetadata is set.
+// The nodes are not modified.
+func (w ByteWriter) WriteWithMetadata(nodes []*yaml.RNode, m *Metadata) error {
+	if !w.RenderMetadata {
+		return w.Write(nodes)
+	}
+	var copies []*yaml.RNode
+	for i := range nodes {
+		c := nodes[i].Copy()
+		if err := m.Render(c); err != nil {
+			return err
+		}
+		copies = append(copies, c)
+	}
+	return w.Write(copies)
+}
 
 func (w ByteWriter) Write(nodes []*yaml.RNode) error {
+	if w.ApplySet != nil {
+		var err error
+		if nodes, err = w.ApplySet.Filter(nodes); err != nil {
+			return err
+		}
+	}
 	if w.Sort {
 		if err := kioutil.SortNodes(nodes); err != nil {
 			return errors.Wrap(err)
@@ -54,30 +85,8 @@ func (w ByteWriter) Write(nodes []*yaml.RNode) error {
 	encoder := yaml.NewEncoder(w.Writer)
 	defer encoder.Close()
 	for i := range nodes {
-
-		// clean resources by removing annotations set by the Reader
-		if !w.KeepReaderAnnotations {
-			_, err := nodes[i].Pipe(yaml.ClearAnnotation(kioutil.IndexAnnotation))
-			if err != nil {
-				return errors.Wrap(err)
-			}
-		}
-		for _, a := range w.ClearAnnotations {
-			_, err := nodes[i].Pipe(yaml.ClearAnnotation(a))
-			if err != nil {
-				return errors.Wrap(err)
-			}
-		}
-
-		// TODO(pwittrock): factor this into a a common module for pruning empty values
-		_, err := nodes[i].Pipe(yaml.Lookup("metadata"), yaml.FieldClearer{
-			Name: "annotations", IfEmpty: true})
-		if err != nil {
-			return errors.Wrap(err)
-		}
-		_, err = nodes[i].Pipe(yaml.FieldClearer{Name: "metadata", IfEmpty: true})
-		if err != nil {
-			return errors.Wrap(err)
+		if err := cleanNode(nodes[i], w.KeepReaderAnnotations, w.ClearAnnotations); err != nil {
+			return err
 		}
 
 		if w.Style != 0 {
@@ -121,3 +130,34 @@ func (w ByteWriter) Write(nodes []*yaml.RNode) error {
 	}
 	return errors.Wrap(encoder.Encode(doc))
 }
+
+// cleanNode removes the annotations set by the Reader unless keepReaderAnnotations is set,
+// removes the clear annotations, and then removes the annotations and metadata fields if
+// they are left empty.
+func cleanNode(node *yaml.RNode, keepReaderAnnotations bool, clear []string) error {
+	// clean resources by removing annotations set by the Reader
+	if !keepReaderAnnotations {
+		_, err := node.Pipe(yaml.ClearAnnotation(kioutil.IndexAnnotation))
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	for _, a := range clear {
+		_, err := node.Pipe(yaml.ClearAnnotation(a))
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+
+	// TODO(pwittrock): factor this into a a common module for pruning empty values
+	_, err := node.Pipe(yaml.Lookup("metadata"), yaml.FieldClearer{
+		Name: "annotations", IfEmpty: true})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	_, err = node.Pipe(yaml.FieldClearer{Name: "metadata", IfEmpty: true})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
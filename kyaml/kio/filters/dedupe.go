@@ -0,0 +1,228 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/merge2"
+)
+
+// DedupeStrategy configures how DedupeFilter resolves the duplicated Resources
+type DedupeStrategy string
+
+const (
+	// DedupeStrategyNone only records the duplicates.  This is the default.
+	DedupeStrategyNone DedupeStrategy = ""
+
+	// DedupeStrategyKeepFirst removes all but the first instance of each duplicated
+	// Resource.
+	DedupeStrategyKeepFirst DedupeStrategy = "keep-first"
+
+	// DedupeStrategyKeepLast removes all but the last instance of each duplicated
+	// Resource.
+	DedupeStrategyKeepLast DedupeStrategy = "keep-last"
+
+	// DedupeStrategyMerge merges the instances of each duplicated Resource into the first
+	// instance, with the later instances taking precedence, and removes them.  See
+	// MergeFilter for how the Resources are merged.
+	DedupeStrategyMerge DedupeStrategy = "merge"
+
+	// DedupeStrategyError fails if any Resource is duplicated.
+	DedupeStrategyError DedupeStrategy = "error"
+)
+
+// DedupeStrategies are the supported DedupeStrategies, other than DedupeStrategyNone
+var DedupeStrategies = []DedupeStrategy{
+	DedupeStrategyKeepFirst, DedupeStrategyKeepLast, DedupeStrategyMerge, DedupeStrategyError}
+
+// DedupeFilter finds Resources which share the same apiVersion, kind, namespace
+// and name -- e.g. when combining the Resources of multiple inputs.  Duplicates are
+// recorded in Duplicates, and may optionally be resolved by the Strategy, or renamed.
+// Documents which aren't Resources, such as kustomization files, are never duplicates.
+type DedupeFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Strategy resolves the duplicated Resources.  Defaults to DedupeStrategyNone.
+	Strategy DedupeStrategy `yaml:"strategy,omitempty"`
+
+	// Remove will remove all but the first instance of each duplicated Resource.
+	// Equivalent to DedupeStrategyKeepFirst.
+	Remove bool `yaml:"remove,omitempty"`
+
+	// Rename will rename all but the first instance of each duplicated Resource by
+	// appending a numeric suffix to its name.
+	Rename bool `yaml:"rename,omitempty"`
+
+	// Duplicates is populated by Filter with the duplicated Resources.
+	Duplicates []Duplicate `yaml:"-"`
+}
+
+var _ kio.Filter = &DedupeFilter{}
+
+// Duplicate is a set of Resources sharing the same identifier.
+type Duplicate struct {
+	ApiVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+
+	// Resources are the Resources sharing the identifier, in the order they were read.
+	Resources []*yaml.RNode
+}
+
+func (f *DedupeFilter) Filter(input []*yaml.RNode) ([]*yaml.RNode, error) {
+	strategy := f.Strategy
+	if (f.Remove && f.Rename) || ((f.Remove || f.Rename) && strategy != DedupeStrategyNone) {
+		return nil, fmt.Errorf("only one of remove, rename and strategy may be specified")
+	}
+	if f.Remove {
+		strategy = DedupeStrategyKeepFirst
+	}
+	if strategy != DedupeStrategyNone && !isDedupeStrategy(strategy) {
+		return nil, fmt.Errorf("unknown dedupe strategy '%s'", strategy)
+	}
+	f.Duplicates = nil
+
+	// index the Resources by G/V/K/NS/N, keeping the order they were read in
+	index := map[mergeKey][]*yaml.RNode{}
+	var keys []mergeKey
+	for i := range input {
+		meta, err := input[i].GetMeta()
+		if err != nil && err != yaml.ErrMissingMetadata {
+			return nil, err
+		}
+		if !kio.IsResource(input[i]) {
+			// package metadata files of different packages aren't duplicates
+			continue
+		}
+		key := mergeKey{
+			apiVersion: meta.ApiVersion,
+			kind:       meta.Kind,
+			namespace:  meta.Namespace,
+			name:       meta.Name,
+		}
+		if _, found := index[key]; !found {
+			keys = append(keys, key)
+		}
+		index[key] = append(index[key], input[i])
+	}
+
+	for _, k := range keys {
+		if len(index[k]) > 1 {
+			f.Duplicates = append(f.Duplicates, Duplicate{
+				ApiVersion: k.apiVersion,
+				Kind:       k.kind,
+				Namespace:  k.namespace,
+				Name:       k.name,
+				Resources:  index[k],
+			})
+		}
+	}
+
+	switch {
+	case f.Rename:
+		return input, f.rename(index)
+	case strategy == DedupeStrategyKeepFirst:
+		return f.remove(input, 0), nil
+	case strategy == DedupeStrategyKeepLast:
+		return f.remove(input, -1), nil
+	case strategy == DedupeStrategyMerge:
+		if err := f.merge(); err != nil {
+			return nil, err
+		}
+		return f.remove(input, 0), nil
+	case strategy == DedupeStrategyError && len(f.Duplicates) > 0:
+		var ids []string
+		for _, d := range f.Duplicates {
+			id := d.Name
+			if d.Namespace != "" {
+				id = d.Namespace + "/" + d.Name
+			}
+			ids = append(ids, fmt.Sprintf("%s %s %s", d.ApiVersion, d.Kind, id))
+		}
+		return nil, fmt.Errorf("found %d duplicate resources: %s",
+			len(f.Duplicates), strings.Join(ids, ", "))
+	}
+	return input, nil
+}
+
+func isDedupeStrategy(strategy DedupeStrategy) bool {
+	for _, s := range DedupeStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// remove returns input with only the instance of each duplicate at index keep --
+// counted from the end if negative
+func (f *DedupeFilter) remove(input []*yaml.RNode, keep int) []*yaml.RNode {
+	removed := map[*yaml.RNode]bool{}
+	for _, d := range f.Duplicates {
+		k := keep
+		if k < 0 {
+			k += len(d.Resources)
+		}
+		for i, n := range d.Resources {
+			removed[n] = i != k
+		}
+	}
+	var output []*yaml.RNode
+	for i := range input {
+		if !removed[input[i]] {
+			output = append(output, input[i])
+		}
+	}
+	return output
+}
+
+// rename sets a unique name on the second and subsequent instance of each duplicate
+func (f *DedupeFilter) rename(index map[mergeKey][]*yaml.RNode) error {
+	for _, d := range f.Duplicates {
+		for _, n := range d.Resources[1:] {
+			key := mergeKey{apiVersion: d.ApiVersion, kind: d.Kind, namespace: d.Namespace}
+			for i := 2; ; i++ {
+				key.name = fmt.Sprintf("%s-%d", d.Name, i)
+				if _, found := index[key]; !found {
+					break
+				}
+			}
+			index[key] = []*yaml.RNode{n}
+			if err := n.PipeE(
+				yaml.Lookup("metadata"), yaml.SetField("name", yaml.NewScalarRNode(key.name))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// merge merges the second and subsequent instance of each duplicate into the first.  The
+// first instance keeps the annotations of the reader, so that it is written back where it
+// was read from.
+func (f *DedupeFilter) merge() error {
+	for _, d := range f.Duplicates {
+		merged := d.Resources[0]
+		for _, n := range d.Resources[1:] {
+			patch := n.Copy()
+			for _, a := range []string{kioutil.IndexAnnotation, kioutil.PathAnnotation} {
+				if err := patch.PipeE(yaml.ClearAnnotation(a)); err != nil {
+					return err
+				}
+			}
+			result, err := merge2.Merge(patch, merged)
+			if err != nil {
+				return err
+			}
+			merged.SetYNode(result.YNode())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+var dedupeInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: bar
+`
+
+func TestDedupeFilter_Filter(t *testing.T) {
+	var tests = []struct {
+		name     string
+		filter   DedupeFilter
+		expected string
+	}{
+		{
+			name:     "report",
+			expected: dedupeInput,
+		},
+		{
+			name:   "remove",
+			filter: DedupeFilter{Remove: true},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: bar
+`,
+		},
+		{
+			name:   "keep-last",
+			filter: DedupeFilter{Strategy: DedupeStrategyKeepLast},
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: bar
+`,
+		},
+		{
+			name:   "merge",
+			filter: DedupeFilter{Strategy: DedupeStrategyMerge},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: bar
+`,
+		},
+		{
+			name:   "rename",
+			filter: DedupeFilter{Rename: true},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-3
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: bar
+`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := kio.Pipeline{
+				Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(dedupeInput)}},
+				Filters: []kio.Filter{&test.filter},
+				Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+			}.Execute()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, out.String())
+
+			if !assert.Len(t, test.filter.Duplicates, 1) {
+				t.FailNow()
+			}
+			d := test.filter.Duplicates[0]
+			assert.Equal(t, "apps/v1", d.ApiVersion)
+			assert.Equal(t, "Deployment", d.Kind)
+			assert.Equal(t, "", d.Namespace)
+			assert.Equal(t, "foo", d.Name)
+			assert.Len(t, d.Resources, 2)
+		})
+	}
+}
+
+func TestDedupeFilter_Filter_removeAndRename(t *testing.T) {
+	_, err := (&DedupeFilter{Remove: true, Rename: true}).Filter(nil)
+	assert.Error(t, err)
+
+	_, err = (&DedupeFilter{Rename: true, Strategy: DedupeStrategyMerge}).Filter(nil)
+	assert.EqualError(t, err, "only one of remove, rename and strategy may be specified")
+
+	_, err = (&DedupeFilter{Strategy: "keep-all"}).Filter(nil)
+	assert.EqualError(t, err, "unknown dedupe strategy 'keep-all'")
+}
+
+func TestDedupeFilter_Filter_error(t *testing.T) {
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(dedupeInput)}},
+		Filters: []kio.Filter{&DedupeFilter{Strategy: DedupeStrategyError}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: &bytes.Buffer{}}},
+	}.Execute()
+	assert.EqualError(t, err, "found 1 duplicate resources: apps/v1 Deployment foo")
+
+	// Resources in other namespaces aren't duplicates
+	err = kio.Pipeline{
+		Inputs: []kio.Reader{
+			&kio.ByteReader{Reader: bytes.NewBufferString("apiVersion: v1\nkind: Service\nmetadata:\n  name: foo\n")},
+			&kio.ByteReader{Reader: bytes.NewBufferString(
+				"apiVersion: v1\nkind: Service\nmetadata:\n  name: foo\n  namespace: bar\n")},
+		},
+		Filters: []kio.Filter{&DedupeFilter{Strategy: DedupeStrategyError}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: &bytes.Buffer{}}},
+	}.Execute()
+	assert.NoError(t, err)
+}
+
+func TestDedupeFilter_Filter_nonResources(t *testing.T) {
+	f := &DedupeFilter{Strategy: DedupeStrategyKeepFirst}
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(
+			"resources:\n- a.yaml\n---\nresources:\n- b.yaml\n")}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, f.Duplicates)
+	assert.Equal(t, "resources:\n- a.yaml\n---\nresources:\n- b.yaml\n", out.String())
+}
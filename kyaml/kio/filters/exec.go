@@ -0,0 +1,63 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ExecFilter filters Resources using a local executable.
+// The executable reads a ResourceList containing the input Resources and the
+// function config from stdin, and writes a ResourceList containing the filtered
+// Resources to stdout.
+// If there is a error or validation failure, the process must exit non-zero.
+type ExecFilter struct {
+	// Path is the path to the executable.
+	Path string `yaml:"path,omitempty"`
+
+	// Args are the arguments passed to the executable.
+	Args []string `yaml:"args,omitempty"`
+
+	// Dir is the working directory of the executable.  Defaults to the current
+	// working directory.
+	Dir string `yaml:"dir,omitempty"`
+
+	// Config is the function config put in the ResourceList functionConfig field.
+	// Typically a Kubernetes style Resource Config.
+	Config *yaml.RNode `yaml:"config,omitempty"`
+}
+
+var _ kio.Filter = &ExecFilter{}
+
+// Filter implements kio.Filter
+func (c *ExecFilter) Filter(input []*yaml.RNode) ([]*yaml.RNode, error) {
+	in := &bytes.Buffer{}
+	out := &bytes.Buffer{}
+
+	// write the input
+	err := kio.ByteWriter{
+		WrappingApiVersion: kio.ResourceListApiVersion,
+		WrappingKind:       kio.ResourceListKind,
+		Writer:             in, KeepReaderAnnotations: true, FunctionConfig: c.Config}.Write(input)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Stdin = in
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.WrapPrefixf(err, "function %s failed", c.Path)
+	}
+
+	return (&kio.ByteReader{Reader: out}).Read()
+}
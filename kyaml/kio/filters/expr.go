@@ -0,0 +1,757 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ExprFilter selects the Resources for which Expr is true, and optionally projects them to
+// a subset of their fields.
+//
+// Expr is written in a small expression language:
+//
+//   - fields are referenced by their paths -- e.g. spec.replicas, or
+//     metadata.labels["app.kubernetes.io/name"] for keys which are not identifiers.
+//     List elements are referenced by index -- e.g. spec.containers[0].image.
+//     Fields which are not set are null.
+//   - literals are strings ("nginx" or 'nginx'), numbers, true, false, null and lists
+//     (["a", "b"])
+//   - comparisons are ==, !=, <, <=, >, >=, =~ and !~ (regular expression match) and in
+//     (list membership).  Values of different types are not equal and not ordered.
+//   - conditions are combined with &&, || and !, and grouped with parentheses
+//   - has(PATH) is true if the field is set, and len(VALUE) is the length of a string,
+//     list or map
+//
+// e.g. 'kind == "Deployment" && spec.replicas > 3'.  Values other than booleans are
+// true unless they are null.
+type ExprFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Expr is the expression Resources are selected by.
+	Expr string `yaml:"expr,omitempty"`
+
+	// Project are the paths of the fields to keep in the selected Resources, in addition
+	// to their apiVersion, kind, name and namespace.  Paths must not contain list indexes.
+	// If unset the Resources are not modified.
+	Project []string `yaml:"project,omitempty"`
+}
+
+var _ kio.Filter = &ExprFilter{}
+
+func (f *ExprFilter) Filter(input []*yaml.RNode) ([]*yaml.RNode, error) {
+	e, err := ParseExpr(f.Expr)
+	if err != nil {
+		return nil, err
+	}
+	var project [][]exprPathElement
+	for _, p := range f.Project {
+		path, err := parseExprPath(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, elem := range path {
+			if elem.index >= 0 {
+				return nil, fmt.Errorf("cannot project %s: list indexes are not supported", p)
+			}
+		}
+		project = append(project, path)
+	}
+
+	var output []*yaml.RNode
+	for i := range input {
+		matched, err := e.Matches(input[i])
+		if err != nil {
+			meta, _ := input[i].GetMeta()
+			return nil, fmt.Errorf("%s %s: %v", meta.Kind, meta.Name, err)
+		}
+		if !matched {
+			continue
+		}
+		if project == nil {
+			output = append(output, input[i])
+			continue
+		}
+		projected, err := projectFields(input[i], project)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, projected)
+	}
+	return output, nil
+}
+
+// projectFields returns a copy of node with only its identity, reader annotations and the
+// fields at paths.
+func projectFields(node *yaml.RNode, paths [][]exprPathElement) (*yaml.RNode, error) {
+	keep := [][]exprPathElement{
+		{{key: "apiVersion", index: -1}},
+		{{key: "kind", index: -1}},
+		{{key: "metadata", index: -1}, {key: "name", index: -1}},
+		{{key: "metadata", index: -1}, {key: "namespace", index: -1}},
+	}
+	for _, a := range []string{
+		kioutil.PathAnnotation, kioutil.IndexAnnotation, kioutil.PackageAnnotation} {
+		keep = append(keep, []exprPathElement{
+			{key: "metadata", index: -1}, {key: "annotations", index: -1}, {key: a, index: -1}})
+	}
+	keep = append(keep, paths...)
+
+	out := yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+	for _, path := range keep {
+		value := lookupExprPath(node.YNode(), path)
+		if value == nil {
+			continue
+		}
+		var keys []string
+		for _, elem := range path {
+			keys = append(keys, elem.key)
+		}
+		parent, err := out.Pipe(yaml.LookupCreate(yaml.MappingNode, keys[:len(keys)-1]...))
+		if err != nil {
+			return nil, err
+		}
+		if err := parent.PipeE(
+			yaml.SetField(keys[len(keys)-1], yaml.NewRNode(yaml.CopyYNode(value)))); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Expr is a parsed ExprFilter expression
+type Expr struct {
+	root exprNode
+}
+
+// ParseExpr parses an expression of the ExprFilter expression language.
+func ParseExpr(s string) (*Expr, error) {
+	p := &exprParser{}
+	if err := p.tokenize(s); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s at offset %d", p.tokens[p.pos].text, p.tokens[p.pos].offset)
+	}
+	return &Expr{root: root}, nil
+}
+
+// Matches returns true if the expression is true for the Resource.
+func (e *Expr) Matches(node *yaml.RNode) (bool, error) {
+	v, err := e.root.eval(node.YNode())
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// exprNode is a node of a parsed expression.  Values are strings, float64s, bools, nil,
+// []interface{}s for lists and *yaml.Nodes for maps.
+type exprNode interface {
+	eval(node *yaml.Node) (interface{}, error)
+}
+
+type exprLiteral struct{ value interface{} }
+
+func (l exprLiteral) eval(*yaml.Node) (interface{}, error) { return l.value, nil }
+
+type exprList []exprNode
+
+func (l exprList) eval(node *yaml.Node) (interface{}, error) {
+	var values []interface{}
+	for _, elem := range l {
+		v, err := elem.eval(node)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// exprPathElement is a map key, or a list index if index is not -1
+type exprPathElement struct {
+	key   string
+	index int
+}
+
+type exprPath []exprPathElement
+
+func (p exprPath) eval(node *yaml.Node) (interface{}, error) {
+	return nodeValue(lookupExprPath(node, p)), nil
+}
+
+type exprNot struct{ operand exprNode }
+
+func (n exprNot) eval(node *yaml.Node) (interface{}, error) {
+	v, err := n.operand.eval(node)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+type exprLogical struct {
+	op          string
+	left, right exprNode
+}
+
+func (l exprLogical) eval(node *yaml.Node) (interface{}, error) {
+	v, err := l.left.eval(node)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit
+	if truthy(v) == (l.op == "||") {
+		return truthy(v), nil
+	}
+	v, err = l.right.eval(node)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(v), nil
+}
+
+type exprCompare struct {
+	op          string
+	left, right exprNode
+	// regexp is the compiled right operand of =~ and !~ if it is a literal
+	regexp *regexp.Regexp
+}
+
+func (c exprCompare) eval(node *yaml.Node) (interface{}, error) {
+	left, err := c.left.eval(node)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.right.eval(node)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "in":
+		list, ok := right.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("right operand of in must be a list")
+		}
+		for _, elem := range list {
+			if valuesEqual(left, elem) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "=~", "!~":
+		s, ok := left.(string)
+		if !ok {
+			return c.op == "!~", nil
+		}
+		r := c.regexp
+		if r == nil {
+			pattern, ok := right.(string)
+			if !ok {
+				return nil, fmt.Errorf("right operand of %s must be a string", c.op)
+			}
+			if r, err = regexp.Compile(pattern); err != nil {
+				return nil, err
+			}
+		}
+		return r.MatchString(s) == (c.op == "=~"), nil
+	}
+
+	// ordered comparisons of numbers or strings
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, nil
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, nil
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false, nil
+	}
+	switch c.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type exprCall struct {
+	name string
+	arg  exprNode
+}
+
+func (c exprCall) eval(node *yaml.Node) (interface{}, error) {
+	if c.name == "has" {
+		return lookupExprPath(node, c.arg.(exprPath)) != nil, nil
+	}
+	v, err := c.arg.eval(node)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case string:
+		return float64(len(v)), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case *yaml.Node:
+		return float64(len(v.Content) / 2), nil
+	case nil:
+		return float64(0), nil
+	}
+	return nil, fmt.Errorf("len of %v is not defined", v)
+}
+
+// lookupExprPath returns the node at path, or nil if it does not exist
+func lookupExprPath(node *yaml.Node, path []exprPathElement) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, elem := range path {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		switch {
+		case elem.index >= 0 && node.Kind == yaml.SequenceNode:
+			if elem.index >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[elem.index]
+		case elem.index < 0 && node.Kind == yaml.MappingNode:
+			var found *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == elem.key {
+					found = node.Content[i+1]
+				}
+			}
+			if found == nil {
+				return nil
+			}
+			node = found
+		default:
+			return nil
+		}
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// nodeValue returns the expression value of a yaml node
+func nodeValue(node *yaml.Node) interface{} {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var values []interface{}
+		for _, elem := range node.Content {
+			values = append(values, nodeValue(elem))
+		}
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values
+	case yaml.MappingNode:
+		return node
+	}
+	switch node.ShortTag() {
+	case yaml.NullNodeTag:
+		return nil
+	case "!!bool":
+		b, _ := strconv.ParseBool(node.Value)
+		return b
+	case "!!int", "!!float":
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			return f
+		}
+		if i, err := strconv.ParseInt(node.Value, 0, 64); err == nil {
+			return float64(i)
+		}
+	}
+	return node.Value
+}
+
+// valuesEqual returns true if the values have the same type and value
+func valuesEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !valuesEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case *yaml.Node:
+		b, ok := b.(*yaml.Node)
+		if !ok {
+			return false
+		}
+		as, err1 := yaml.String(a)
+		bs, err2 := yaml.String(b)
+		return err1 == nil && err2 == nil && as == bs
+	}
+	return a == b
+}
+
+// truthy returns the boolean value of v
+func truthy(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return v != nil
+}
+
+// exprToken is a token of an expression
+type exprToken struct {
+	// kind is "ident", "string", "number", or the operator or punctuation
+	kind   string
+	text   string
+	offset int
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+// exprOperators are the operators and punctuation, longest first
+var exprOperators = []string{
+	"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ".", ","}
+
+func (p *exprParser) tokenize(s string) error {
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != s[i] {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return fmt.Errorf("unterminated string at offset %d", i)
+			}
+			text := s[i+1 : j]
+			if c == '"' {
+				var err error
+				if text, err = strconv.Unquote(s[i : j+1]); err != nil {
+					return fmt.Errorf("invalid string at offset %d: %v", i, err)
+				}
+			}
+			p.tokens = append(p.tokens, exprToken{kind: "string", text: text, offset: i})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(s) && unicode.IsDigit(rune(s[i+1]))):
+			j := i + 1
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			p.tokens = append(p.tokens, exprToken{kind: "number", text: s[i:j], offset: i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i + 1
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) ||
+				s[j] == '_' || s[j] == '-') {
+				j++
+			}
+			p.tokens = append(p.tokens, exprToken{kind: "ident", text: s[i:j], offset: i})
+			i = j
+		default:
+			found := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(s[i:], op) {
+					p.tokens = append(p.tokens, exprToken{kind: op, text: op, offset: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+		}
+	}
+	return nil
+}
+
+// peek returns the kind of the next token, or "" at the end of the expression
+func (p *exprParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	t := p.tokens[p.pos]
+	if t.kind == "ident" && (t.text == "in") {
+		return t.text
+	}
+	return t.kind
+}
+
+// expect consumes the next token, which must be of kind
+func (p *exprParser) expect(kind string) (exprToken, error) {
+	if p.pos >= len(p.tokens) {
+		return exprToken{}, fmt.Errorf("expected %s at end of expression", kind)
+	}
+	t := p.tokens[p.pos]
+	if t.kind != kind {
+		return exprToken{}, fmt.Errorf("expected %s at offset %d, found %s", kind, t.offset, t.text)
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogical{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogical{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.peek() == "!" {
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return exprNot{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~", "in":
+	default:
+		return left, nil
+	}
+	p.pos++
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	c := exprCompare{op: op, left: left, right: right}
+	if l, ok := right.(exprLiteral); ok && (op == "=~" || op == "!~") {
+		pattern, ok := l.value.(string)
+		if !ok {
+			return nil, fmt.Errorf("right operand of %s must be a string", op)
+		}
+		if c.regexp, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	switch t.kind {
+	case "string":
+		p.pos++
+		return exprLiteral{value: t.text}, nil
+	case "number":
+		p.pos++
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at offset %d", t.text, t.offset)
+		}
+		return exprLiteral{value: f}, nil
+	case "(":
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case "[":
+		p.pos++
+		var list exprList
+		for p.peek() != "]" {
+			if len(list) > 0 {
+				if _, err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			elem, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, elem)
+		}
+		p.pos++
+		return list, nil
+	case "ident":
+		switch t.text {
+		case "true", "false":
+			p.pos++
+			return exprLiteral{value: t.text == "true"}, nil
+		case "null":
+			p.pos++
+			return exprLiteral{}, nil
+		case "has", "len":
+			if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == "(" {
+				return p.parseCall()
+			}
+		}
+		return p.parsePath()
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t.text, t.offset)
+}
+
+func (p *exprParser) parseCall() (exprNode, error) {
+	name := p.tokens[p.pos].text
+	p.pos += 2
+	var arg exprNode
+	var err error
+	if name == "has" {
+		arg, err = p.parsePath()
+	} else {
+		arg, err = p.parseOr()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return exprCall{name: name, arg: arg}, nil
+}
+
+func (p *exprParser) parsePath() (exprNode, error) {
+	t, err := p.expect("ident")
+	if err != nil {
+		return nil, err
+	}
+	path := exprPath{{key: t.text, index: -1}}
+	for {
+		switch p.peek() {
+		case ".":
+			p.pos++
+			t, err := p.expect("ident")
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, exprPathElement{key: t.text, index: -1})
+		case "[":
+			p.pos++
+			if p.peek() == "number" {
+				t := p.tokens[p.pos]
+				i, err := strconv.Atoi(t.text)
+				if err != nil || i < 0 {
+					return nil, fmt.Errorf("invalid index %s at offset %d", t.text, t.offset)
+				}
+				path = append(path, exprPathElement{index: i})
+				p.pos++
+			} else {
+				t, err := p.expect("string")
+				if err != nil {
+					return nil, err
+				}
+				path = append(path, exprPathElement{key: t.text, index: -1})
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return path, nil
+		}
+	}
+}
+
+// parseExprPath parses a field path of the expression language
+func parseExprPath(s string) (exprPath, error) {
+	p := &exprParser{}
+	if err := p.tokenize(s); err != nil {
+		return nil, err
+	}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid path %s", s)
+	}
+	return path.(exprPath), nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const exprInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app.kubernetes.io/name: web
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.17
+      - name: sidecar
+        image: gcr.io/example/sidecar:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+  namespace: data
+spec:
+  replicas: 1
+  paused: true
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+func TestExprFilter_Filter(t *testing.T) {
+	var testCases = []struct {
+		expr     string
+		expected []string
+	}{
+		{`kind == "Deployment" && spec.replicas > 3`, []string{"web"}},
+		{`kind == 'Deployment'`, []string{"web", "db"}},
+		{`spec.replicas <= 1 || kind != "Deployment"`, []string{"db", "web"}},
+		{`!(kind == "Deployment")`, []string{"web"}},
+		{`spec.paused`, []string{"db"}},
+		{`!spec.paused`, []string{"web", "web"}},
+		{`has(metadata.namespace)`, []string{"db"}},
+		{`metadata.namespace == null`, []string{"web", "web"}},
+		{`metadata.labels["app.kubernetes.io/name"] == "web"`, []string{"web"}},
+		{`spec.template.spec.containers[1].image =~ "^gcr.io/"`, []string{"web"}},
+		{`spec.template.spec.containers[2].image =~ "^gcr.io/"`, nil},
+		{`len(spec.template.spec.containers) == 2`, []string{"web"}},
+		{`metadata.name in ["db", "cache"]`, []string{"db"}},
+		{`metadata.name !~ "^w"`, []string{"db"}},
+		{`spec.replicas == "5"`, nil},
+		{`metadata.name > "m"`, []string{"web", "web"}},
+	}
+	for _, tc := range testCases {
+		f := &ExprFilter{Expr: tc.expr}
+		out, err := f.Filter(parseExprInput(t))
+		if !assert.NoError(t, err, tc.expr) {
+			continue
+		}
+		var names []string
+		for i := range out {
+			meta, err := out[i].GetMeta()
+			if assert.NoError(t, err) {
+				names = append(names, meta.Name)
+			}
+		}
+		assert.Equal(t, tc.expected, names, tc.expr)
+	}
+}
+
+func TestExprFilter_Filter_project(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(exprInput)}},
+		Filters: []kio.Filter{&ExprFilter{
+			Expr:    `kind == "Deployment"`,
+			Project: []string{"spec.replicas", `metadata.labels["app.kubernetes.io/name"]`},
+		}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app.kubernetes.io/name: web
+spec:
+  replicas: 5
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+  namespace: data
+spec:
+  replicas: 1
+`, out.String())
+}
+
+func TestExprFilter_Filter_errors(t *testing.T) {
+	var testCases = []struct {
+		expr     string
+		project  []string
+		expected string
+	}{
+		{``, nil, "empty expression"},
+		{`kind ==`, nil, "unexpected end of expression"},
+		{`kind == "Deployment`, nil, "unterminated string at offset 8"},
+		{`(kind == "Deployment"`, nil, "expected ) at end of expression"},
+		{`kind == "Deployment" kind`, nil, "unexpected kind at offset 21"},
+		{`kind @ "Deployment"`, nil, `unexpected '@' at offset 5`},
+		{`kind =~ "("`, nil, "error parsing regexp"},
+		{`kind in "Deployment"`, nil, "Deployment web: right operand of in must be a list"},
+		{`true`, []string{"spec.containers[0]"}, "list indexes are not supported"},
+	}
+	for _, tc := range testCases {
+		f := &ExprFilter{Expr: tc.expr, Project: tc.project}
+		_, err := f.Filter(parseExprInput(t))
+		if assert.Error(t, err, tc.expr) {
+			assert.Contains(t, err.Error(), tc.expected, tc.expr)
+		}
+	}
+}
+
+func parseExprInput(t *testing.T) []*yaml.RNode {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(exprInput)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return nodes
+}
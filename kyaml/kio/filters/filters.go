@@ -16,11 +16,25 @@ import (
 // Filters are the list of known filters for unmarshalling a filter into a concrete
 // implementation.
 var Filters = map[string]func() kio.Filter{
-	"FileSetter":    func() kio.Filter { return &FileSetter{} },
-	"FormatFilter":  func() kio.Filter { return &FormatFilter{} },
-	"GrepFilter":    func() kio.Filter { return GrepFilter{} },
-	"MatchModifier": func() kio.Filter { return &MatchModifyFilter{} },
-	"Modifier":      func() kio.Filter { return &Modifier{} },
+	"DedupeFilter":            func() kio.Filter { return &DedupeFilter{} },
+	"ExprFilter":              func() kio.Filter { return &ExprFilter{} },
+	"FileSetter":              func() kio.Filter { return &FileSetter{} },
+	"FormatFilter":            func() kio.Filter { return &FormatFilter{} },
+	"GrepFilter":              func() kio.Filter { return GrepFilter{} },
+	"HashFilter":              func() kio.Filter { return &HashFilter{} },
+	"LabelSetter":             func() kio.Filter { return &LabelSetter{} },
+	"MatchModifier":           func() kio.Filter { return &MatchModifyFilter{} },
+	"MigrateFilter":           func() kio.Filter { return &MigrateFilter{} },
+	"Modifier":                func() kio.Filter { return &Modifier{} },
+	"NamespaceFilter":         func() kio.Filter { return &NamespaceFilter{} },
+	"OwnershipTransferFilter": func() kio.Filter { return &OwnershipTransferFilter{} },
+	"PatchFilter":             func() kio.Filter { return &PatchFilter{} },
+	"RedactFilter":            func() kio.Filter { return &RedactFilter{} },
+	"RenameFilter":            func() kio.Filter { return &RenameFilter{} },
+	"SetFieldFilter":          func() kio.Filter { return &SetFieldFilter{} },
+	"SetFilter":               func() kio.Filter { return &SetFilter{} },
+	"SortFilter":              func() kio.Filter { return &SortFilter{} },
+	"StripFilter":             func() kio.Filter { return &StripFilter{} },
 }
 
 // filter wraps a kio.filter so that it can be unmarshalled from yaml.
@@ -133,6 +147,15 @@ const (
 
 	// NamespaceFmt substitutes metdata.namespace
 	NamespaceFmt FilenameFmtVerb = "%s"
+
+	// KindTemplate substitutes kind
+	KindTemplate FilenameFmtVerb = "{kind}"
+
+	// NameTemplate substitutes metadata.name
+	NameTemplate FilenameFmtVerb = "{name}"
+
+	// NamespaceTemplate substitutes metadata.namespace
+	NamespaceTemplate FilenameFmtVerb = "{namespace}"
 )
 
 // FileSetter sets the file name and mode annotations on Resources.
@@ -173,6 +196,9 @@ func (f *FileSetter) Filter(input []*yaml.RNode) ([]*yaml.RNode, error) {
 		file = strings.Replace(file, string(KindFmt), strings.ToLower(m.Kind), -1)
 		file = strings.Replace(file, string(NameFmt), strings.ToLower(m.Name), -1)
 		file = strings.Replace(file, string(NamespaceFmt), strings.ToLower(m.Namespace), -1)
+		file = strings.Replace(file, string(KindTemplate), strings.ToLower(m.Kind), -1)
+		file = strings.Replace(file, string(NameTemplate), strings.ToLower(m.Name), -1)
+		file = strings.Replace(file, string(NamespaceTemplate), strings.ToLower(m.Namespace), -1)
 
 		if _, found := m.Annotations[kioutil.PathAnnotation]; !found || f.Override {
 			if _, err := input[i].Pipe(yaml.SetAnnotation(kioutil.PathAnnotation, file)); err != nil {
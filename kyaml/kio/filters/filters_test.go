@@ -124,6 +124,51 @@ metadata:
 `, out.String())
 }
 
+func TestFileSetter_Filter_template(t *testing.T) {
+	in := bytes.NewBufferString(r)
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: in}},
+		Filters: []Filter{&FileSetter{
+			FilenamePattern: "{kind}-{name}.yaml",
+		}},
+		Outputs: []Writer{ByteWriter{Sort: true, Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo1
+  namespace: bar
+  annotations:
+    config.kubernetes.io/path: deployment-foo1.yaml
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo2
+  annotations:
+    config.kubernetes.io/path: deployment-foo2.yaml
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo1
+  annotations:
+    config.kubernetes.io/path: service-foo1.yaml
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo2
+  namespace: bar
+  annotations:
+    config.kubernetes.io/path: service-foo2.yaml
+`, out.String())
+}
+
 func TestFileSetter_Filter_empty(t *testing.T) {
 	in := bytes.NewBufferString(r)
 	out := &bytes.Buffer{}
@@ -56,7 +56,12 @@ func FormatFileOrDirectory(path string) error {
 
 type FormatFilter struct{}
 
-var _ kio.Filter = FormatFilter{}
+var _ kio.ResourceLocalFilter = FormatFilter{}
+
+// ResourceLocal returns true -- each Resource is formatted independently.
+func (f FormatFilter) ResourceLocal() bool {
+	return true
+}
 
 func (f FormatFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
 	for i := range slice {
@@ -110,6 +115,14 @@ func (f *formatter) fmtNode(n *yaml.Node, path string) error {
 		if n.Kind == yaml.MappingNode && i%2 == 1 {
 			p = fmt.Sprintf("%s.%s", path, n.Content[i-1].Value)
 		}
+		if n.Kind == yaml.SequenceNode {
+			// the comments for a list element are on its first field, keep them there
+			// when the element fields are sorted
+			if err := f.fmtElement(n.Content[i], p); err != nil {
+				return err
+			}
+			continue
+		}
 		err := f.fmtNode(n.Content[i], p)
 		if err != nil {
 			return err
@@ -118,6 +131,28 @@ func (f *formatter) fmtNode(n *yaml.Node, path string) error {
 	return nil
 }
 
+// fmtElement formats a list element, keeping the head comment of the element
+// above the element if its first field changes.
+func (f *formatter) fmtElement(n *yaml.Node, path string) error {
+	if n.Kind != yaml.MappingNode || len(n.Content) == 0 {
+		return f.fmtNode(n, path)
+	}
+	first := n.Content[0]
+	comment := first.HeadComment
+	if err := f.fmtNode(n, path); err != nil {
+		return err
+	}
+	if comment == "" || n.Content[0] == first {
+		return nil
+	}
+	first.HeadComment = ""
+	if n.Content[0].HeadComment != "" {
+		comment = comment + "\n" + n.Content[0].HeadComment
+	}
+	n.Content[0].HeadComment = comment
+	return nil
+}
+
 // sortedMapContents sorts the Contents field of a MappingNode by the field names using a statically
 // defined field precedence, and falling back on lexicographical sorting
 type sortedMapContents yaml.Node
@@ -0,0 +1,79 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// FunctionAnnotation is the annotation identifying a Resource as the config for a
+// function, and specifying how the function is run.
+//
+// Example -- function run as a container:
+//
+//	metadata:
+//	  annotations:
+//	    config.kubernetes.io/function: |
+//	      container:
+//	        image: gcr.io/example/examplefunction:v1.0.1
+//
+// Example -- function run as a local executable, relative to the function config:
+//
+//	metadata:
+//	  annotations:
+//	    config.kubernetes.io/function: |
+//	      exec:
+//	        path: ./bin/examplefunction
+const FunctionAnnotation = "config.kubernetes.io/function"
+
+// FunctionSpec defines how a function is run.  Exactly one of Container or Exec is set.
+type FunctionSpec struct {
+	// Container is the spec for running a function as a container
+	Container ContainerSpec `yaml:"container,omitempty"`
+
+	// Exec is the spec for running a function as a local executable
+	Exec ExecSpec `yaml:"exec,omitempty"`
+}
+
+// ContainerSpec defines a function run as a container
+type ContainerSpec struct {
+	// Image is the container image to run
+	Image string `yaml:"image,omitempty"`
+}
+
+// ExecSpec defines a function run as a local executable
+type ExecSpec struct {
+	// Path is the path to the executable.  Relative paths are relative to the
+	// directory containing the function config.
+	Path string `yaml:"path,omitempty"`
+
+	// Args are the arguments passed to the executable
+	Args []string `yaml:"args,omitempty"`
+}
+
+// GetFunctionSpec returns the FunctionSpec from the FunctionAnnotation on n, or nil if n
+// does not have the annotation.
+func GetFunctionSpec(n *yaml.RNode) (*FunctionSpec, error) {
+	meta, err := n.GetMeta()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	value, found := meta.Annotations[FunctionAnnotation]
+	if !found {
+		return nil, nil
+	}
+
+	fs := &FunctionSpec{}
+	if err := yaml.Unmarshal([]byte(value), fs); err != nil {
+		return nil, errors.WrapPrefixf(err, "invalid %s annotation on %s %s",
+			FunctionAnnotation, meta.Kind, meta.Name)
+	}
+	if (fs.Container.Image == "") == (fs.Exec.Path == "") {
+		return nil, errors.Errorf(
+			"%s annotation on %s %s must specify exactly one of container.image or exec.path",
+			FunctionAnnotation, meta.Kind, meta.Name)
+	}
+	return fs, nil
+}
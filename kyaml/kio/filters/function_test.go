@@ -0,0 +1,81 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestGetFunctionSpec(t *testing.T) {
+	n, err := yaml.Parse(`apiVersion: v1
+kind: Example
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: ./fn
+        args: [a, b]
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	spec, err := GetFunctionSpec(n)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, &FunctionSpec{Exec: ExecSpec{Path: "./fn", Args: []string{"a", "b"}}}, spec)
+
+	n, err = yaml.Parse(`apiVersion: v1
+kind: Example
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: gcr.io/example/fn:v1
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	spec, err = GetFunctionSpec(n)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, &FunctionSpec{Container: ContainerSpec{Image: "gcr.io/example/fn:v1"}}, spec)
+}
+
+func TestGetFunctionSpec_missing(t *testing.T) {
+	n, err := yaml.Parse(`apiVersion: v1
+kind: Example
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	spec, err := GetFunctionSpec(n)
+	assert.NoError(t, err)
+	assert.Nil(t, spec)
+}
+
+func TestGetFunctionSpec_invalid(t *testing.T) {
+	n, err := yaml.Parse(`apiVersion: v1
+kind: Example
+metadata:
+  name: foo
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: gcr.io/example/fn:v1
+      exec:
+        path: ./fn
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = GetFunctionSpec(n)
+	assert.EqualError(t, err, "config.kubernetes.io/function annotation on Example foo "+
+		"must specify exactly one of container.image or exec.path")
+}
@@ -0,0 +1,265 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ResourceID identifies a Resource in a graph
+type ResourceID struct {
+	ApiVersion string `yaml:"apiVersion,omitempty" json:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Namespace  string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Name       string `yaml:"name,omitempty" json:"name,omitempty"`
+}
+
+// String returns the Resource as 'Kind namespace/name', or 'Kind name' if the
+// Resource doesn't have a namespace.
+func (id ResourceID) String() string {
+	if id.Namespace == "" {
+		return fmt.Sprintf("%s %s", id.Kind, id.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", id.Kind, id.Namespace, id.Name)
+}
+
+// key identifies the Resource independently of its apiVersion
+func (id ResourceID) key() string {
+	return ResourceID{Kind: id.Kind, Namespace: id.Namespace, Name: id.Name}.String()
+}
+
+// EdgeType is the type of dependency between two Resources
+type EdgeType string
+
+const (
+	// EdgeOwner is the dependency of a Resource on an owner in its ownerReferences
+	EdgeOwner EdgeType = "owner"
+
+	// EdgeReference is the dependency of a Resource on a Resource it references by
+	// name -- e.g. a Deployment mounting a ConfigMap as a volume
+	EdgeReference EdgeType = "reference"
+
+	// EdgeSelector is the dependency of a Service on the workloads its selector
+	// matches the pods of
+	EdgeSelector EdgeType = "selector"
+)
+
+// Edge is a dependency of one Resource on another
+type Edge struct {
+	// From is the dependent Resource
+	From ResourceID `yaml:"from" json:"from"`
+
+	// To is the Resource depended on
+	To ResourceID `yaml:"to" json:"to"`
+
+	// Type is the type of the dependency
+	Type EdgeType `yaml:"type" json:"type"`
+
+	// Path is the path to the field of From which declares the dependency
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// Line is the line of the field of From which declares the dependency, if known
+	Line int `yaml:"line,omitempty" json:"line,omitempty"`
+}
+
+// podLabelPaths are the paths to the pod labels of the workload kinds
+var podLabelPaths = map[string][]string{
+	"Pod":                   {"metadata", "labels"},
+	"Deployment":            {"spec", "template", "metadata", "labels"},
+	"StatefulSet":           {"spec", "template", "metadata", "labels"},
+	"DaemonSet":             {"spec", "template", "metadata", "labels"},
+	"ReplicaSet":            {"spec", "template", "metadata", "labels"},
+	"ReplicationController": {"spec", "template", "metadata", "labels"},
+	"Job":                   {"spec", "template", "metadata", "labels"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "metadata", "labels"},
+}
+
+// referencedKinds are the kinds of nameReferences, sorted so that the edges are
+// added in a consistent order
+var referencedKinds = func() []string {
+	var kinds []string
+	for k := range nameReferences {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}()
+
+// GraphFilter builds the dependency graph of the Resources.  The Resources are not
+// modified.
+//
+// Resources depend on the owners in their ownerReferences, on the Resources they
+// reference by name (as updated by RenameFilter), and Services depend on the workloads
+// their selectors match.  Only dependencies on Resources in the input are included.
+// Documents which aren't Resources, such as kustomization files, aren't in the graph.
+type GraphFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Nodes is populated by Filter with the Resources, in the order of the input
+	Nodes []ResourceID `yaml:"nodes,omitempty"`
+
+	// Edges is populated by Filter with the dependencies between the Resources, sorted
+	// by the order of From and To in Nodes.
+	Edges []Edge `yaml:"edges,omitempty"`
+
+	// Unresolved is populated by Filter with the owners and references to Resources
+	// which are not in the input, in the order of From in Nodes.  The namespace of To
+	// is the namespace of From, unless the kind of To is cluster scoped.
+	Unresolved []Edge `yaml:"unresolved,omitempty"`
+}
+
+var _ kio.Filter = &GraphFilter{}
+
+func (f *GraphFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Nodes = nil
+	f.Edges = nil
+	f.Unresolved = nil
+
+	var resources []*yaml.RNode
+	var metas []yaml.ResourceMeta
+	index := map[string]int{}
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil && err != yaml.ErrMissingMetadata {
+			return nil, err
+		}
+		if !kio.IsResource(slice[i]) {
+			continue
+		}
+		id := ResourceID{
+			ApiVersion: meta.ApiVersion, Kind: meta.Kind, Namespace: meta.Namespace, Name: meta.Name}
+		index[id.key()] = len(resources)
+		resources = append(resources, slice[i])
+		metas = append(metas, meta)
+		f.Nodes = append(f.Nodes, id)
+	}
+
+	type edge struct {
+		from, to int
+		t        EdgeType
+		path     string
+		line     int
+	}
+	var edges []edge
+	seen := map[edge]bool{}
+	add := func(from int, to ResourceID, t EdgeType, path string, line int) bool {
+		j, found := index[to.key()]
+		if !found || j == from {
+			return found
+		}
+		e := edge{from: from, to: j, t: t, path: path, line: line}
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+		return true
+	}
+
+	for i := range resources {
+		meta := metas[i]
+
+		// owners
+		owners, err := resources[i].Pipe(yaml.Lookup("metadata", "ownerReferences"))
+		if err != nil {
+			return nil, err
+		}
+		if owners != nil {
+			elements, err := owners.Elements()
+			if err != nil {
+				return nil, err
+			}
+			for j := range elements {
+				owner := ResourceID{
+					ApiVersion: fieldValue(elements[j], "apiVersion"),
+					Kind:       fieldValue(elements[j], "kind"),
+					Namespace:  meta.Namespace,
+					Name:       fieldValue(elements[j], "name"),
+				}
+				path := fmt.Sprintf("metadata.ownerReferences[%d]", j)
+				line := elements[j].YNode().Line
+				// owners are either in the same namespace, or cluster scoped
+				found := add(i, owner, EdgeOwner, path, line)
+				clusterOwner := owner
+				clusterOwner.Namespace = ""
+				if !add(i, clusterOwner, EdgeOwner, path, line) && !found {
+					f.Unresolved = append(f.Unresolved, Edge{
+						From: f.Nodes[i], To: owner, Type: EdgeOwner, Path: path, Line: line})
+				}
+			}
+		}
+
+		// references by name
+		for _, kind := range referencedKinds {
+			visitReferences(resources[i], meta, kind, func(node *yaml.Node, namespace string, p []string) {
+				to := ResourceID{Kind: kind, Namespace: namespace, Name: node.Value}
+				if !add(i, to, EdgeReference, joinSearchPath(p), node.Line) {
+					f.Unresolved = append(f.Unresolved, Edge{
+						From: f.Nodes[i], To: to, Type: EdgeReference, Path: joinSearchPath(p),
+						Line: node.Line})
+				}
+			})
+		}
+
+		// service selectors
+		if meta.Kind != "Service" {
+			continue
+		}
+		selector, err := resources[i].Pipe(yaml.Lookup("spec", "selector"))
+		if err != nil {
+			return nil, err
+		}
+		if yaml.IsMissingOrNull(selector) || len(selector.Content()) == 0 {
+			continue
+		}
+		for j := range resources {
+			path, found := podLabelPaths[metas[j].Kind]
+			if !found || metas[j].Namespace != meta.Namespace {
+				continue
+			}
+			labels, err := resources[j].Pipe(yaml.Lookup(path...))
+			if err != nil {
+				return nil, err
+			}
+			if labels != nil && selects(selector, labels) {
+				add(i, f.Nodes[j], EdgeSelector, "spec.selector", selector.YNode().Line)
+			}
+		}
+	}
+
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].from != edges[j].from {
+			return edges[i].from < edges[j].from
+		}
+		return edges[i].to < edges[j].to
+	})
+	for _, e := range edges {
+		f.Edges = append(f.Edges, Edge{
+			From: f.Nodes[e.from], To: f.Nodes[e.to], Type: e.t, Path: e.path, Line: e.line})
+	}
+	return slice, nil
+}
+
+// fieldValue returns the value of the scalar field, or "" if it isn't set
+func fieldValue(node *yaml.RNode, field string) string {
+	if v := node.Field(field); !yaml.IsFieldEmpty(v) {
+		return v.Value.YNode().Value
+	}
+	return ""
+}
+
+// selects returns true if the labels match every key and value of the selector
+func selects(selector, labels *yaml.RNode) bool {
+	matches := true
+	_ = selector.VisitFields(func(f *yaml.MapNode) error {
+		if fieldValue(labels, f.Key.YNode().Value) != f.Value.YNode().Value {
+			matches = false
+		}
+		return nil
+	})
+	return matches
+}
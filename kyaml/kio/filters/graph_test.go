@@ -0,0 +1,161 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const graphInput = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      serviceAccountName: nginx
+      containers:
+      - name: nginx
+        envFrom:
+        - configMapRef:
+            name: config
+        - secretRef:
+            name: missing
+---
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: nginx-1234
+  namespace: default
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: Deployment
+    name: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  selector:
+    app: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: other
+  namespace: default
+spec:
+  selector:
+    app: other
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: nginx
+  namespace: default
+`
+
+func TestGraphFilter_Filter(t *testing.T) {
+	f := &GraphFilter{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(graphInput)}},
+		Filters: []kio.Filter{f},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var nodes []string
+	for _, n := range f.Nodes {
+		nodes = append(nodes, n.String())
+	}
+	assert.Equal(t, []string{
+		"ConfigMap default/config",
+		"Deployment default/nginx",
+		"ReplicaSet default/nginx-1234",
+		"Service default/nginx",
+		"Service default/other",
+		"ServiceAccount default/nginx",
+	}, nodes)
+
+	var edges []string
+	for _, e := range f.Edges {
+		edges = append(edges, e.From.String()+" -> "+e.To.String()+" "+string(e.Type)+" "+e.Path)
+	}
+	assert.Equal(t, []string{
+		"Deployment default/nginx -> ConfigMap default/config reference " +
+			"spec.template.spec.containers[name=nginx].envFrom[0].configMapRef.name",
+		"Deployment default/nginx -> ServiceAccount default/nginx reference " +
+			"spec.template.spec.serviceAccountName",
+		"ReplicaSet default/nginx-1234 -> Deployment default/nginx owner metadata.ownerReferences[0]",
+		"Service default/nginx -> Deployment default/nginx selector spec.selector",
+	}, edges)
+
+	var unresolved []string
+	for _, e := range f.Unresolved {
+		unresolved = append(unresolved, fmt.Sprintf("%s -> %s %s %s:%d",
+			e.From, e.To, e.Type, e.Path, e.Line))
+	}
+	assert.Equal(t, []string{
+		"Deployment default/nginx -> Secret default/missing reference " +
+			"spec.template.spec.containers[name=nginx].envFrom[1].secretRef.name:25",
+	}, unresolved)
+}
+
+func TestGraphFilter_Filter_nonResources(t *testing.T) {
+	in := `resources:
+- service.yaml
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  selector:
+    app: nginx
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  namespace: default
+  labels:
+    app: nginx
+`
+	f := &GraphFilter{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(in)}},
+		Filters: []kio.Filter{f},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, []ResourceID{
+		{ApiVersion: "v1", Kind: "Service", Namespace: "default", Name: "nginx"},
+		{ApiVersion: "v1", Kind: "Pod", Namespace: "default", Name: "nginx"},
+	}, f.Nodes)
+	assert.Equal(t, []Edge{{
+		From: f.Nodes[0], To: f.Nodes[1], Type: EdgeSelector, Path: "spec.selector", Line: 11},
+	}, f.Edges)
+}
@@ -0,0 +1,163 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// hashSuffix matches the name suffix hashes appended by kustomize and HashFilter
+var hashSuffix = regexp.MustCompile(`-[2456789bcdfghkmt]{10}$`)
+
+// HashFilter appends a hash of their contents to the names of the ConfigMaps and
+// Secrets selected by Target, and updates the references to them -- as kustomize does
+// for generated ConfigMaps and Secrets -- so that workloads are rolled out when they
+// change.
+//
+// The hashes are computed as kustomize computes them: from the kind, name and data of
+// ConfigMaps, and from the kind, name, type and data of Secrets.  A previous hash
+// suffix of the name is replaced, so that names are only changed when the contents
+// change.  References are updated as by RenameFilter.
+type HashFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Target selects the ConfigMaps and Secrets to hash.  If empty, all ConfigMaps and
+	// Secrets are hashed.
+	Target PatchTarget `yaml:"target,omitempty"`
+
+	// Renamed is populated by Filter with the renames of the Resources whose names were
+	// changed, and the references they updated.
+	Renamed []*RenameFilter `yaml:"renamed,omitempty"`
+}
+
+var _ kio.Filter = &HashFilter{}
+
+func (f *HashFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Renamed = nil
+
+	// hash all of the Resources before renaming any of them
+	var renames []*RenameFilter
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		if meta.ApiVersion != "v1" || (meta.Kind != "ConfigMap" && meta.Kind != "Secret") {
+			continue
+		}
+		matched, err := f.Target.Matches(meta)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		name := hashSuffix.ReplaceAllString(meta.Name, "")
+		hash, err := contentHash(slice[i], meta.Kind, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %v", meta.Kind, meta.Name, err)
+		}
+		if newName := name + "-" + hash; newName != meta.Name {
+			renames = append(renames, &RenameFilter{ResourceKind: meta.Kind,
+				Namespace: meta.Namespace, Name: meta.Name, NewName: newName})
+		}
+	}
+
+	for _, r := range renames {
+		if _, err := r.Filter(slice); err != nil {
+			return nil, err
+		}
+	}
+	f.Renamed = renames
+	return slice, nil
+}
+
+// contentHash returns the name suffix hash kustomize computes for the ConfigMap or
+// Secret with the name
+func contentHash(rn *yaml.RNode, kind, name string) (string, error) {
+	// the data fields, with the values of binary fields decoded
+	fields := func(field string, binary bool) (map[string]interface{}, error) {
+		node, err := rn.Pipe(yaml.Lookup(field))
+		if err != nil || node == nil || node.YNode().Kind != yaml.MappingNode {
+			return nil, err
+		}
+		data := map[string]interface{}{}
+		content := node.YNode().Content
+		for i := 0; i+1 < len(content); i += 2 {
+			if !binary {
+				data[content[i].Value] = content[i+1].Value
+				continue
+			}
+			b, err := base64.StdEncoding.DecodeString(content[i+1].Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base64 value of %s.%s: %v",
+					field, content[i].Value, err)
+			}
+			data[content[i].Value] = b
+		}
+		return data, nil
+	}
+
+	// json.Marshal sorts the keys, so that the encoding is stable
+	encoded := map[string]interface{}{"kind": kind, "name": name}
+	switch kind {
+	case "ConfigMap":
+		data, err := fields("data", false)
+		if err != nil {
+			return "", err
+		}
+		encoded["data"] = data
+		binaryData, err := fields("binaryData", true)
+		if err != nil {
+			return "", err
+		}
+		if len(binaryData) > 0 {
+			encoded["binaryData"] = binaryData
+		}
+	case "Secret":
+		data, err := fields("data", true)
+		if err != nil {
+			return "", err
+		}
+		encoded["data"] = data
+		t, err := rn.Pipe(yaml.Lookup("type"))
+		if err != nil {
+			return "", err
+		}
+		encoded["type"] = ""
+		if t != nil {
+			encoded["type"] = t.YNode().Value
+		}
+	}
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	// encode the first 10 characters of the hex sha256, replacing characters to avoid
+	// generating words
+	hex := []rune(fmt.Sprintf("%x", sha256.Sum256(b))[:10])
+	for i := range hex {
+		switch hex[i] {
+		case '0':
+			hex[i] = 'g'
+		case '1':
+			hex[i] = 'h'
+		case '3':
+			hex[i] = 'k'
+		case 'a':
+			hex[i] = 'm'
+		case 'e':
+			hex[i] = 't'
+		}
+	}
+	return string(hex), nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const hashInput = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  PORT: "8080"
+  MODE: prod
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-secret
+type: Opaque
+data:
+  password: aHVudGVyMg==
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        envFrom:
+        - configMapRef:
+            name: web-config
+      volumes:
+      - name: secret
+        secret:
+          secretName: web-secret
+`
+
+func runHashFilter(t *testing.T, input string, f *HashFilter) string {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(input)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return out.String()
+}
+
+func TestHashFilter_Filter(t *testing.T) {
+	f := &HashFilter{}
+	out := runHashFilter(t, hashInput, f)
+	if assert.Len(t, f.Renamed, 2) {
+		assert.Equal(t, "web-config-tgmmtkfdcd", f.Renamed[0].NewName)
+		assert.Len(t, f.Renamed[0].References, 1)
+		assert.Equal(t, "web-secret-9gbkd8c8ff", f.Renamed[1].NewName)
+		assert.Len(t, f.Renamed[1].References, 1)
+	}
+	assert.Contains(t, out, `metadata:
+  name: web-config-tgmmtkfdcd
+`)
+	assert.Contains(t, out, `metadata:
+  name: web-secret-9gbkd8c8ff
+`)
+	assert.Contains(t, out, `        - configMapRef:
+            name: web-config-tgmmtkfdcd
+`)
+	assert.Contains(t, out, `          secretName: web-secret-9gbkd8c8ff
+`)
+
+	// hashing again does not change the names
+	f = &HashFilter{}
+	assert.Equal(t, out, runHashFilter(t, out, f))
+	assert.Empty(t, f.Renamed)
+}
+
+func TestHashFilter_Filter_changed(t *testing.T) {
+	input := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config-tgmmtkfdcd
+data:
+  PORT: "9090"
+  MODE: prod
+`
+	// the previous hash is replaced
+	assert.Contains(t, runHashFilter(t, input, &HashFilter{}), `  name: web-config-tkdc28g244
+`)
+}
+
+func TestHashFilter_Filter_target(t *testing.T) {
+	f := &HashFilter{Target: PatchTarget{Kind: "Secret"}}
+	out := runHashFilter(t, hashInput, f)
+	assert.Len(t, f.Renamed, 1)
+	assert.Contains(t, out, `metadata:
+  name: web-config
+`)
+	assert.Contains(t, out, `          secretName: web-secret-9gbkd8c8ff
+`)
+}
+
+func TestHashFilter_Filter_invalidSecret(t *testing.T) {
+	input := `apiVersion: v1
+kind: Secret
+metadata:
+  name: web-secret
+data:
+  password: hunter2
+`
+	_, err := (&HashFilter{}).Filter([]*yaml.RNode{yaml.MustParse(input)})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Secret web-secret: invalid base64 value of data.password")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// containerListFields are the fields of Pod specs listing containers
+var containerListFields = map[string]bool{
+	"containers":          true,
+	"initContainers":      true,
+	"ephemeralContainers": true,
+}
+
+// ImageDigestFilter pins the container images of the Resources to the digests of their
+// tags -- e.g. nginx:1.19 is replaced with nginx@sha256:... -- so that the Resources
+// keep running the same images when the tags are pushed again.  The containers of Pod
+// specs are found anywhere in the Resources, so the images of workloads, CronJobs and
+// custom Resources embedding Pod templates are all pinned.  Images already pinned to a
+// digest are not modified.
+type ImageDigestFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Resolve returns the digest of the image tag -- e.g. sha256:...
+	Resolve func(image string) (string, error) `yaml:"-"`
+
+	// TagComment records the image tag in a line comment on the pinned image --
+	// e.g. 'image: nginx@sha256:... # nginx:1.19'
+	TagComment bool `yaml:"tagComment,omitempty"`
+
+	// Pinned is populated by Filter with the images pinned, with their pinned values.
+	Pinned []SearchMatch `yaml:"pinned,omitempty"`
+}
+
+var _ kio.Filter = &ImageDigestFilter{}
+
+func (f *ImageDigestFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Pinned = nil
+	if f.Resolve == nil {
+		return nil, fmt.Errorf("must specify a digest resolver")
+	}
+
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		var resolveErr error
+		searchNode(slice[i].YNode(), nil, nil, func(node, _ *yaml.Node, p []string) {
+			if resolveErr != nil || !isContainerImage(p) || strings.Contains(node.Value, "@") {
+				return
+			}
+			name, tag := splitImageTag(node.Value)
+			digest, err := f.Resolve(name + ":" + tag)
+			if err != nil {
+				resolveErr = fmt.Errorf("%s %s: %s: %v", meta.Kind, meta.Name, joinSearchPath(p), err)
+				return
+			}
+			if f.TagComment {
+				node.LineComment = "# " + name + ":" + tag
+			}
+			node.Value = name + "@" + digest
+			f.Pinned = append(f.Pinned, SearchMatch{
+				ApiVersion: meta.ApiVersion,
+				Kind:       meta.Kind,
+				Namespace:  meta.Namespace,
+				Name:       meta.Name,
+				File:       meta.Annotations[kioutil.PathAnnotation],
+				Line:       node.Line,
+				Path:       joinSearchPath(p),
+				Value:      node.Value,
+			})
+		})
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+	}
+	return slice, nil
+}
+
+// isContainerImage returns true if the path is the image of a container
+func isContainerImage(p []string) bool {
+	n := len(p)
+	return n >= 3 && p[n-1] == "image" && strings.HasPrefix(p[n-2], "[") &&
+		containerListFields[p[n-3]]
+}
+
+// splitImageTag returns the name and tag of an image -- the tag is latest if it is not
+// set
+func splitImageTag(image string) (string, string) {
+	// the registry host may contain a port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+func TestImageDigestFilter_Filter(t *testing.T) {
+	digests := map[string]string{
+		"nginx:1.19":                "sha256:aaaa",
+		"nginx:latest":              "sha256:bbbb",
+		"localhost:5000/init:v1":    "sha256:cccc",
+		"gcr.io/example/debug:v2.0": "sha256:dddd",
+	}
+	var resolved []string
+	f := &ImageDigestFilter{TagComment: true, Resolve: func(image string) (string, error) {
+		resolved = append(resolved, image)
+		return digests[image], nil
+	}}
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: localhost:5000/init:v1
+      containers:
+      - name: nginx
+        image: nginx:1.19
+      - name: sidecar
+        image: nginx
+      - name: pinned
+        image: nginx@sha256:eeee
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: job
+            image: nginx:1.19
+          ephemeralContainers:
+          - name: debug
+            image: gcr.io/example/debug:v2.0
+  image: not-a-container:v1
+`)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: localhost:5000/init@sha256:cccc # localhost:5000/init:v1
+      containers:
+      - name: nginx
+        image: nginx@sha256:aaaa # nginx:1.19
+      - name: sidecar
+        image: nginx@sha256:bbbb # nginx:latest
+      - name: pinned
+        image: nginx@sha256:eeee
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: job
+            image: nginx@sha256:aaaa # nginx:1.19
+          ephemeralContainers:
+          - name: debug
+            image: gcr.io/example/debug@sha256:dddd # gcr.io/example/debug:v2.0
+  image: not-a-container:v1
+`, out.String())
+	assert.Equal(t, []string{"localhost:5000/init:v1", "nginx:1.19", "nginx:latest",
+		"nginx:1.19", "gcr.io/example/debug:v2.0"}, resolved)
+
+	if assert.Len(t, f.Pinned, 5) {
+		assert.Equal(t, SearchMatch{
+			ApiVersion: "batch/v1beta1",
+			Kind:       "CronJob",
+			Name:       "job",
+			Line:       33,
+			Path:       "spec.jobTemplate.spec.template.spec.ephemeralContainers[name=debug].image",
+			Value:      "gcr.io/example/debug@sha256:dddd",
+		}, f.Pinned[4])
+	}
+}
+
+func TestImageDigestFilter_Filter_error(t *testing.T) {
+	f := &ImageDigestFilter{Resolve: func(image string) (string, error) {
+		return "", fmt.Errorf("manifest unknown")
+	}}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(`kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.19
+`)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: &bytes.Buffer{}}},
+	}.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"Pod nginx: spec.containers[name=nginx].image: manifest unknown")
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// LabelSetter sets labels on Resources.  Documents which aren't Resources, such as
+// kustomization files, are left unchanged -- see kio.IsResource.
+//
+// If IncludeSelectors is set, LabelSetter will also set the labels on the selectors
+// and pod templates of the Resources, matching the kustomize commonLabels transformer.
+type LabelSetter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Labels are the labels to set.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// IncludeSelectors will also set the labels on selectors and templates.
+	IncludeSelectors bool `yaml:"includeSelectors,omitempty"`
+}
+
+var _ kio.ResourceLocalFilter = LabelSetter{}
+
+// ResourceLocal returns true -- the labels of each Resource are set independently.
+func (s LabelSetter) ResourceLocal() bool {
+	return true
+}
+
+// labelFieldSpec identifies a field containing labels or a label selector.
+type labelFieldSpec struct {
+	// group is the apiVersion group of the Resource -- matches any group if unset
+	group string
+	// version is the apiVersion version of the Resource -- matches any version if unset
+	version string
+	// kind is the kind of the Resource
+	kind string
+	// path is the path to the labels field.  Lists in the path are traversed, setting
+	// the labels on each element.
+	path []string
+	// create will create the field if it is missing
+	create bool
+}
+
+func (s labelFieldSpec) matches(meta yaml.ResourceMeta) bool {
+	group, version := splitApiVersion(meta.ApiVersion)
+	return s.kind == meta.Kind &&
+		(s.group == "" || s.group == group) &&
+		(s.version == "" || s.version == version)
+}
+
+// splitApiVersion splits the apiVersion into its group and version
+func splitApiVersion(apiVersion string) (string, string) {
+	parts := strings.Split(apiVersion, "/")
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[0], parts[len(parts)-1]
+}
+
+const (
+	podAffinityPreferred     = "spec.template.spec.affinity.podAffinity.preferredDuringSchedulingIgnoredDuringExecution.podAffinityTerm.labelSelector.matchLabels"
+	podAffinityRequired      = "spec.template.spec.affinity.podAffinity.requiredDuringSchedulingIgnoredDuringExecution.labelSelector.matchLabels"
+	podAntiAffinityPreferred = "spec.template.spec.affinity.podAntiAffinity.preferredDuringSchedulingIgnoredDuringExecution.podAffinityTerm.labelSelector.matchLabels"
+	podAntiAffinityRequired  = "spec.template.spec.affinity.podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution.labelSelector.matchLabels"
+)
+
+func newLabelFieldSpec(group, version, kind, path string, create bool) labelFieldSpec {
+	return labelFieldSpec{
+		group: group, version: version, kind: kind, path: strings.Split(path, "."), create: create}
+}
+
+// selectorLabelFields are the fields the kustomize commonLabels transformer sets in addition
+// to metadata.labels.
+var selectorLabelFields = []labelFieldSpec{
+	newLabelFieldSpec("", "v1", "Service", "spec.selector", true),
+	newLabelFieldSpec("", "v1", "ReplicationController", "spec.selector", true),
+	newLabelFieldSpec("", "v1", "ReplicationController", "spec.template.metadata.labels", true),
+
+	newLabelFieldSpec("", "", "Deployment", "spec.selector.matchLabels", true),
+	newLabelFieldSpec("", "", "Deployment", "spec.template.metadata.labels", true),
+	newLabelFieldSpec("apps", "", "Deployment", podAffinityPreferred, false),
+	newLabelFieldSpec("apps", "", "Deployment", podAffinityRequired, false),
+	newLabelFieldSpec("apps", "", "Deployment", podAntiAffinityPreferred, false),
+	newLabelFieldSpec("apps", "", "Deployment", podAntiAffinityRequired, false),
+
+	newLabelFieldSpec("", "", "ReplicaSet", "spec.selector.matchLabels", true),
+	newLabelFieldSpec("", "", "ReplicaSet", "spec.template.metadata.labels", true),
+
+	newLabelFieldSpec("", "", "DaemonSet", "spec.selector.matchLabels", true),
+	newLabelFieldSpec("", "", "DaemonSet", "spec.template.metadata.labels", true),
+
+	newLabelFieldSpec("apps", "", "StatefulSet", "spec.selector.matchLabels", true),
+	newLabelFieldSpec("apps", "", "StatefulSet", "spec.template.metadata.labels", true),
+	newLabelFieldSpec("apps", "", "StatefulSet", podAffinityPreferred, false),
+	newLabelFieldSpec("apps", "", "StatefulSet", podAffinityRequired, false),
+	newLabelFieldSpec("apps", "", "StatefulSet", podAntiAffinityPreferred, false),
+	newLabelFieldSpec("apps", "", "StatefulSet", podAntiAffinityRequired, false),
+	newLabelFieldSpec("apps", "", "StatefulSet", "spec.volumeClaimTemplates.metadata.labels", true),
+
+	newLabelFieldSpec("batch", "", "Job", "spec.selector.matchLabels", false),
+	newLabelFieldSpec("batch", "", "Job", "spec.template.metadata.labels", true),
+
+	newLabelFieldSpec("batch", "", "CronJob", "spec.jobTemplate.spec.selector.matchLabels", false),
+	newLabelFieldSpec("batch", "", "CronJob", "spec.jobTemplate.metadata.labels", true),
+	newLabelFieldSpec("batch", "", "CronJob", "spec.jobTemplate.spec.template.metadata.labels", true),
+
+	newLabelFieldSpec("policy", "", "PodDisruptionBudget", "spec.selector.matchLabels", false),
+
+	newLabelFieldSpec("networking.k8s.io", "", "NetworkPolicy", "spec.podSelector.matchLabels", false),
+	newLabelFieldSpec("networking.k8s.io", "", "NetworkPolicy", "spec.ingress.from.podSelector.matchLabels", false),
+	newLabelFieldSpec("networking.k8s.io", "", "NetworkPolicy", "spec.egress.to.podSelector.matchLabels", false),
+}
+
+func (s LabelSetter) Filter(input []*yaml.RNode) ([]*yaml.RNode, error) {
+	// sort the keys so the labels are added in a consistent order
+	var keys []string
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i := range input {
+		if !kio.IsResource(input[i]) {
+			continue
+		}
+		for _, k := range keys {
+			if err := input[i].PipeE(yaml.SetLabel(k, s.Labels[k])); err != nil {
+				return nil, err
+			}
+		}
+		if !s.IncludeSelectors {
+			continue
+		}
+
+		meta, err := input[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range selectorLabelFields {
+			if !spec.matches(meta) {
+				continue
+			}
+			if err := s.setLabels(input[i], spec.path, spec.create, keys); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return input, nil
+}
+
+// setLabels sets the labels on the field at path, traversing any lists in the path.
+func (s LabelSetter) setLabels(rn *yaml.RNode, path []string, create bool, keys []string) error {
+	if len(path) == 0 {
+		for _, k := range keys {
+			if err := rn.PipeE(yaml.SetField(k, yaml.NewStringRNode(s.Labels[k]))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	field, err := rn.Pipe(yaml.Get(path[0]))
+	if err != nil {
+		return err
+	}
+	if field == nil {
+		if !create {
+			return nil
+		}
+		field, err = rn.Pipe(yaml.LookupCreate(yaml.MappingNode, path[0]))
+		if err != nil {
+			return err
+		}
+	}
+
+	if field.YNode().Kind != yaml.SequenceNode {
+		return s.setLabels(field, path[1:], create, keys)
+	}
+	// set the labels on each element of the list
+	return field.VisitElements(func(elem *yaml.RNode) error {
+		return s.setLabels(elem, path[1:], create, keys)
+	})
+}
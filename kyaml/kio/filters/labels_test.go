@@ -0,0 +1,201 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestLabelSetter_Filter(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  labels:
+    app: nginx
+spec:
+  selector:
+    app: nginx
+`
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(in)}},
+		Filters: []kio.Filter{LabelSetter{Labels: map[string]string{"tier": "web", "enabled": "true"}}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  labels:
+    enabled: "true"
+    tier: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  labels:
+    app: nginx
+    enabled: "true"
+    tier: web
+spec:
+  selector:
+    app: nginx
+`, out.String()) {
+		t.FailNow()
+	}
+}
+
+func TestLabelSetter_Filter_includeSelectors(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      affinity:
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+          - labelSelector:
+              matchLabels:
+                app: nginx
+            topologyKey: kubernetes.io/hostname
+      containers:
+      - name: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+spec:
+  selector:
+    app: nginx
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - name: job
+`
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(in)}},
+		Filters: []kio.Filter{LabelSetter{
+			Labels:           map[string]string{"tier": "web"},
+			IncludeSelectors: true,
+		}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  labels:
+    tier: web
+spec:
+  template:
+    spec:
+      affinity:
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+          - labelSelector:
+              matchLabels:
+                app: nginx
+                tier: web
+            topologyKey: kubernetes.io/hostname
+      containers:
+      - name: nginx
+    metadata:
+      labels:
+        tier: web
+  selector:
+    matchLabels:
+      tier: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  labels:
+    tier: web
+spec:
+  selector:
+    app: nginx
+    tier: web
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: foo
+  labels:
+    tier: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: job
+    metadata:
+      labels:
+        tier: web
+`, out.String()) {
+		t.FailNow()
+	}
+}
+
+func TestLabelSetter_Filter_nonResources(t *testing.T) {
+	kustomization := yaml.MustParse(`resources:
+- deployment.yaml
+metadata:
+  annotations:
+    config.kubernetes.io/path: kustomization.yaml
+`)
+	values := yaml.MustParse("replicas: 3\n")
+	_, err := LabelSetter{Labels: map[string]string{"tier": "web"}}.Filter(
+		[]*yaml.RNode{kustomization, values})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `resources:
+- deployment.yaml
+metadata:
+  annotations:
+    config.kubernetes.io/path: kustomization.yaml
+`, kustomization.MustString())
+	assert.Equal(t, "replicas: 3\n", values.MustString())
+}
@@ -0,0 +1,437 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// apiMigration migrates the Resources of a deprecated apiVersion to its replacement
+type apiMigration struct {
+	// apiVersion is the deprecated apiVersion
+	apiVersion string
+
+	// kinds are the kinds to migrate.  Matches any kind if empty.
+	kinds []string
+
+	// replacement is the apiVersion to migrate to
+	replacement string
+
+	// migrate, if set, makes the structural changes required by the replacement, and
+	// returns the changes which must be made or checked manually
+	migrate func(rn *yaml.RNode, meta yaml.ResourceMeta) ([]string, error)
+}
+
+// workloadKinds are the kinds of the apps/v1 workloads
+var workloadKinds = []string{"Deployment", "DaemonSet", "ReplicaSet", "StatefulSet"}
+
+// apiMigrations are the migrations of the well-known deprecated apiVersions.  See
+// conformance.DeprecatedAPIs.
+var apiMigrations = []apiMigration{
+	{apiVersion: "extensions/v1beta1", kinds: []string{"Deployment", "DaemonSet", "ReplicaSet"},
+		replacement: "apps/v1", migrate: migrateWorkload},
+	{apiVersion: "extensions/v1beta1", kinds: []string{"NetworkPolicy"},
+		replacement: "networking.k8s.io/v1"},
+	{apiVersion: "extensions/v1beta1", kinds: []string{"PodSecurityPolicy"},
+		replacement: "policy/v1beta1"},
+	{apiVersion: "extensions/v1beta1", kinds: []string{"Ingress"},
+		replacement: "networking.k8s.io/v1beta1"},
+	{apiVersion: "apps/v1beta1", replacement: "apps/v1", migrate: migrateWorkload},
+	{apiVersion: "apps/v1beta2", replacement: "apps/v1", migrate: migrateWorkload},
+	{apiVersion: "batch/v2alpha1", kinds: []string{"CronJob"}, replacement: "batch/v1beta1"},
+	{apiVersion: "rbac.authorization.k8s.io/v1alpha1", replacement: "rbac.authorization.k8s.io/v1"},
+	{apiVersion: "rbac.authorization.k8s.io/v1beta1", replacement: "rbac.authorization.k8s.io/v1"},
+	{apiVersion: "apiextensions.k8s.io/v1beta1", kinds: []string{"CustomResourceDefinition"},
+		replacement: "apiextensions.k8s.io/v1", migrate: migrateCRD},
+	{apiVersion: "admissionregistration.k8s.io/v1beta1",
+		kinds:       []string{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"},
+		replacement: "admissionregistration.k8s.io/v1", migrate: migrateWebhooks},
+	{apiVersion: "scheduling.k8s.io/v1beta1", replacement: "scheduling.k8s.io/v1"},
+	{apiVersion: "storage.k8s.io/v1beta1", kinds: []string{"StorageClass"},
+		replacement: "storage.k8s.io/v1"},
+}
+
+// lookupMigration returns the migration of the apiVersion and kind, or nil if there is
+// none
+func lookupMigration(apiVersion, kind string) *apiMigration {
+	for i := range apiMigrations {
+		m := &apiMigrations[i]
+		if m.apiVersion == apiVersion && (len(m.kinds) == 0 || containsString(m.kinds, kind)) {
+			return m
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Migration is a Resource migrated by MigrateFilter
+type Migration struct {
+	// ApiVersion is the previous apiVersion of the Resource
+	ApiVersion string `yaml:"apiVersion,omitempty"`
+
+	// NewApiVersion is the apiVersion the Resource was migrated to
+	NewApiVersion string `yaml:"newApiVersion,omitempty"`
+
+	Kind      string `yaml:"kind,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Name      string `yaml:"name,omitempty"`
+	File      string `yaml:"file,omitempty"`
+
+	// FollowUps are the changes which must be made or checked manually -- e.g. fields
+	// which have no replacement, or defaults which differ in the new apiVersion
+	FollowUps []string `yaml:"followUps,omitempty"`
+}
+
+// MigrateFilter migrates Resources using deprecated or removed apiVersions to their
+// replacements -- e.g. extensions/v1beta1 Deployments to apps/v1 -- making the
+// structural changes the replacements require:
+//
+//   - apps/v1 workloads: spec.selector is set from the pod template labels if it is not
+//     set, the update strategy of DaemonSets and StatefulSets is set to the previous
+//     default, and spec.rollbackTo of Deployments is removed
+//   - apiextensions.k8s.io/v1 CustomResourceDefinitions: spec.version, spec.validation,
+//     spec.subresources and spec.additionalPrinterColumns are moved to spec.versions,
+//     and the conversion webhook is moved to spec.conversion.webhook
+//   - admissionregistration.k8s.io/v1 webhooks: admissionReviewVersions, failurePolicy,
+//     matchPolicy and timeoutSeconds are set to the previous defaults if they are not
+//     set
+//
+// Changes which cannot be made automatically are reported as FollowUps.
+type MigrateFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Migrated is populated by Filter with the Resources which were migrated
+	Migrated []Migration `yaml:"migrated,omitempty"`
+}
+
+var _ kio.Filter = &MigrateFilter{}
+
+func (f *MigrateFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Migrated = nil
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		m := lookupMigration(meta.ApiVersion, meta.Kind)
+		if m == nil {
+			continue
+		}
+		var followUps []string
+		if m.migrate != nil {
+			if followUps, err = m.migrate(slice[i], meta); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", meta.Kind, meta.Name, err)
+			}
+		}
+		apiVersion, err := slice[i].Pipe(yaml.Lookup("apiVersion"))
+		if err != nil {
+			return nil, err
+		}
+		// set the value rather than the field to keep its comments
+		apiVersion.YNode().Value = m.replacement
+		f.Migrated = append(f.Migrated, Migration{
+			ApiVersion:    meta.ApiVersion,
+			NewApiVersion: m.replacement,
+			Kind:          meta.Kind,
+			Namespace:     meta.Namespace,
+			Name:          meta.Name,
+			File:          meta.Annotations[kioutil.PathAnnotation],
+			FollowUps:     followUps,
+		})
+	}
+	return slice, nil
+}
+
+// lookupField returns the field of rn at the path, or nil if it is not set
+func lookupField(rn *yaml.RNode, path ...string) (*yaml.RNode, error) {
+	field, err := rn.Pipe(yaml.Lookup(path...))
+	if err != nil || field == nil || yaml.IsNull(field) {
+		return nil, err
+	}
+	return field, nil
+}
+
+// setDefault sets the field of rn to value if it is not set, and returns true if it
+// was set
+func setDefault(rn *yaml.RNode, name string, value *yaml.RNode) (bool, error) {
+	field, err := lookupField(rn, name)
+	if err != nil || field != nil {
+		return false, err
+	}
+	return true, rn.PipeE(yaml.SetField(name, value))
+}
+
+// migrateWorkload migrates Deployments, DaemonSets, ReplicaSets and StatefulSets to
+// apps/v1
+func migrateWorkload(rn *yaml.RNode, meta yaml.ResourceMeta) ([]string, error) {
+	if !containsString(workloadKinds, meta.Kind) {
+		return nil, nil
+	}
+	var followUps []string
+	spec, err := rn.Pipe(yaml.LookupCreate(yaml.MappingNode, "spec"))
+	if err != nil {
+		return nil, err
+	}
+
+	// the selector was defaulted to the pod template labels, and is required by apps/v1
+	selector, err := lookupField(spec, "selector")
+	if err != nil {
+		return nil, err
+	}
+	if selector == nil {
+		labels, err := lookupField(spec, "template", "metadata", "labels")
+		if err != nil {
+			return nil, err
+		}
+		if labels == nil {
+			followUps = append(followUps,
+				"spec.selector is required, and the pod template has no labels to select")
+		} else {
+			selector = yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+			err = selector.PipeE(yaml.SetField("matchLabels", labels.Copy()))
+			if err != nil {
+				return nil, err
+			}
+			if err := spec.PipeE(yaml.SetField("selector", selector)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch {
+	case meta.Kind == "Deployment":
+		rollbackTo, err := spec.Pipe(yaml.Clear("rollbackTo"))
+		if err != nil {
+			return nil, err
+		}
+		if rollbackTo != nil {
+			followUps = append(followUps,
+				"spec.rollbackTo was removed -- use 'kubectl rollout undo' instead")
+		}
+		if meta.ApiVersion != "extensions/v1beta1" {
+			break
+		}
+		for _, field := range []struct{ name, value string }{
+			{"revisionHistoryLimit", "10"}, {"progressDeadlineSeconds", "600"}} {
+			if f, err := lookupField(spec, field.name); err != nil {
+				return nil, err
+			} else if f == nil {
+				followUps = append(followUps, fmt.Sprintf(
+					"spec.%s is not set, and defaults to %s in apps/v1", field.name, field.value))
+			}
+		}
+	case meta.Kind == "DaemonSet" && meta.ApiVersion == "extensions/v1beta1",
+		meta.Kind == "StatefulSet" && meta.ApiVersion == "apps/v1beta1":
+		// the update strategy defaulted to OnDelete, and defaults to RollingUpdate in
+		// apps/v1
+		strategy := yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+		if err := strategy.PipeE(yaml.SetField("type", yaml.NewScalarRNode("OnDelete"))); err != nil {
+			return nil, err
+		}
+		set, err := setDefault(spec, "updateStrategy", strategy)
+		if err != nil {
+			return nil, err
+		}
+		if set {
+			followUps = append(followUps, fmt.Sprintf("spec.updateStrategy was set to OnDelete, "+
+				"the default of %s -- remove it to use RollingUpdate", meta.ApiVersion))
+		}
+	}
+	return followUps, nil
+}
+
+// migrateCRD migrates CustomResourceDefinitions to apiextensions.k8s.io/v1
+func migrateCRD(rn *yaml.RNode, _ yaml.ResourceMeta) ([]string, error) {
+	var followUps []string
+	spec, err := rn.Pipe(yaml.LookupCreate(yaml.MappingNode, "spec"))
+	if err != nil {
+		return nil, err
+	}
+
+	// spec.version is replaced by spec.versions
+	version, err := spec.Pipe(yaml.Clear("version"))
+	if err != nil {
+		return nil, err
+	}
+	versions, err := lookupField(spec, "versions")
+	if err != nil {
+		return nil, err
+	}
+	if versions == nil {
+		if version == nil {
+			return nil, fmt.Errorf("spec.version or spec.versions must be set")
+		}
+		v := yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+		for _, field := range []struct {
+			name  string
+			value *yaml.RNode
+		}{{"name", yaml.NewScalarRNode(version.YNode().Value)},
+			{"served", yaml.NewScalarRNode("true")},
+			{"storage", yaml.NewScalarRNode("true")}} {
+			if err := v.PipeE(yaml.SetField(field.name, field.value)); err != nil {
+				return nil, err
+			}
+		}
+		versions = yaml.NewRNode(&yaml.Node{Kind: yaml.SequenceNode})
+		if err := versions.PipeE(yaml.Append(v.YNode())); err != nil {
+			return nil, err
+		}
+		if err := spec.PipeE(yaml.SetField("versions", versions)); err != nil {
+			return nil, err
+		}
+	}
+	elements, err := versions.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	// the top-level fields are replaced by the fields of each version
+	for _, field := range []struct{ name, versionName string }{
+		{"validation", "schema"}, {"subresources", "subresources"},
+		{"additionalPrinterColumns", "additionalPrinterColumns"}} {
+		value, err := spec.Pipe(yaml.Clear(field.name))
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		for _, v := range elements {
+			if _, err := setDefault(v, field.versionName, value.Copy()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, v := range elements {
+		name := ""
+		if n, err := lookupField(v, "name"); err != nil {
+			return nil, err
+		} else if n != nil {
+			name = n.YNode().Value
+		}
+		// the JSONPath of printer columns is renamed to jsonPath
+		columns, err := lookupField(v, "additionalPrinterColumns")
+		if err != nil {
+			return nil, err
+		}
+		if columns != nil {
+			for _, c := range columns.YNode().Content {
+				for i := 0; i+1 < len(c.Content); i += 2 {
+					if c.Content[i].Value == "JSONPath" {
+						c.Content[i].Value = "jsonPath"
+					}
+				}
+			}
+		}
+		schema, err := lookupField(v, "schema", "openAPIV3Schema")
+		if err != nil {
+			return nil, err
+		}
+		if schema == nil {
+			followUps = append(followUps, fmt.Sprintf(
+				"spec.versions[name=%s].schema.openAPIV3Schema is required", name))
+		}
+	}
+
+	// unknown fields are pruned unless the schemas preserve them
+	preserve, err := spec.Pipe(yaml.Clear("preserveUnknownFields"))
+	if err != nil {
+		return nil, err
+	}
+	if preserve != nil && preserve.YNode().Value == "true" {
+		followUps = append(followUps, "spec.preserveUnknownFields was removed -- set "+
+			"x-kubernetes-preserve-unknown-fields in the schemas to keep unknown fields")
+	}
+	followUps = append(followUps,
+		"the schemas must be structural -- see 'kubectl explain customresourcedefinition.spec'")
+
+	// the conversion webhook is moved to spec.conversion.webhook
+	conversion, err := lookupField(spec, "conversion")
+	if err != nil || conversion == nil {
+		return followUps, err
+	}
+	clientConfig, err := conversion.Pipe(yaml.Clear("webhookClientConfig"))
+	if err != nil {
+		return nil, err
+	}
+	reviewVersions, err := conversion.Pipe(yaml.Clear("conversionReviewVersions"))
+	if err != nil {
+		return nil, err
+	}
+	if clientConfig == nil {
+		return followUps, nil
+	}
+	webhook, err := conversion.Pipe(yaml.LookupCreate(yaml.MappingNode, "webhook"))
+	if err != nil {
+		return nil, err
+	}
+	if err := webhook.PipeE(yaml.SetField("clientConfig", clientConfig)); err != nil {
+		return nil, err
+	}
+	if reviewVersions == nil {
+		reviewVersions = yaml.NewListRNode("v1beta1")
+	}
+	if _, err := setDefault(webhook, "conversionReviewVersions", reviewVersions); err != nil {
+		return nil, err
+	}
+	return followUps, nil
+}
+
+// migrateWebhooks migrates MutatingWebhookConfigurations and
+// ValidatingWebhookConfigurations to admissionregistration.k8s.io/v1
+func migrateWebhooks(rn *yaml.RNode, _ yaml.ResourceMeta) ([]string, error) {
+	var followUps []string
+	webhooks, err := lookupField(rn, "webhooks")
+	if err != nil || webhooks == nil {
+		return nil, err
+	}
+	elements, err := webhooks.Elements()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range elements {
+		name := ""
+		if n, err := lookupField(w, "name"); err != nil {
+			return nil, err
+		} else if n != nil {
+			name = n.YNode().Value
+		}
+
+		// keep the defaults of v1beta1, which differ in v1
+		for _, field := range []struct {
+			name  string
+			value *yaml.RNode
+		}{{"admissionReviewVersions", yaml.NewListRNode("v1beta1")},
+			{"failurePolicy", yaml.NewScalarRNode("Ignore")},
+			{"matchPolicy", yaml.NewScalarRNode("Exact")},
+			{"timeoutSeconds", yaml.NewScalarRNode("30")}} {
+			if _, err := setDefault(w, field.name, field.value); err != nil {
+				return nil, err
+			}
+		}
+
+		sideEffects, err := lookupField(w, "sideEffects")
+		if err != nil {
+			return nil, err
+		}
+		if sideEffects == nil || (sideEffects.YNode().Value != "None" &&
+			sideEffects.YNode().Value != "NoneOnDryRun") {
+			followUps = append(followUps, fmt.Sprintf(
+				"webhooks[name=%s].sideEffects must be set to None or NoneOnDryRun", name))
+		}
+	}
+	return followUps, nil
+}
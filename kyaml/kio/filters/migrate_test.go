@@ -0,0 +1,238 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+func runMigrateFilter(t *testing.T, input string) (string, *MigrateFilter) {
+	f := &MigrateFilter{}
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(input)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return out.String(), f
+}
+
+func TestMigrateFilter_Filter_workloads(t *testing.T) {
+	out, f := runMigrateFilter(t, `apiVersion: extensions/v1beta1 # old
+kind: Deployment
+metadata:
+  name: web
+spec:
+  rollbackTo:
+    revision: 2
+  revisionHistoryLimit: 5
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  selector:
+    matchLabels:
+      app: agent
+  template:
+    metadata:
+      labels:
+        app: agent
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: current
+`)
+	assert.Equal(t, `apiVersion: apps/v1 # old
+kind: Deployment
+metadata:
+  name: web
+spec:
+  revisionHistoryLimit: 5
+  template:
+    metadata:
+      labels:
+        app: web
+  selector:
+    matchLabels:
+      app: web
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  selector:
+    matchLabels:
+      app: agent
+  template:
+    metadata:
+      labels:
+        app: agent
+  updateStrategy:
+    type: OnDelete
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: current
+`, out)
+	if assert.Len(t, f.Migrated, 2) {
+		assert.Equal(t, Migration{
+			ApiVersion:    "extensions/v1beta1",
+			NewApiVersion: "apps/v1",
+			Kind:          "Deployment",
+			Name:          "web",
+			FollowUps: []string{
+				"spec.rollbackTo was removed -- use 'kubectl rollout undo' instead",
+				"spec.progressDeadlineSeconds is not set, and defaults to 600 in apps/v1",
+			},
+		}, f.Migrated[0])
+		assert.Equal(t, []string{"spec.updateStrategy was set to OnDelete, the default of " +
+			"extensions/v1beta1 -- remove it to use RollingUpdate"}, f.Migrated[1].FollowUps)
+	}
+}
+
+func TestMigrateFilter_Filter_apiVersionOnly(t *testing.T) {
+	out, f := runMigrateFilter(t, `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  backend:
+    serviceName: web
+    servicePort: 80
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: Role
+metadata:
+  name: web
+`)
+	assert.Contains(t, out, `apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  backend:
+    serviceName: web
+    servicePort: 80
+`)
+	assert.Contains(t, out, `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+`)
+	if assert.Len(t, f.Migrated, 2) {
+		assert.Empty(t, f.Migrated[0].FollowUps)
+		assert.Empty(t, f.Migrated[1].FollowUps)
+	}
+}
+
+func TestMigrateFilter_Filter_crd(t *testing.T) {
+	out, f := runMigrateFilter(t, `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  version: v1
+  names:
+    kind: Widget
+  validation:
+    openAPIV3Schema:
+      type: object
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+  - name: Replicas
+    type: integer
+    JSONPath: .spec.replicas
+  conversion:
+    strategy: Webhook
+    webhookClientConfig:
+      url: https://example.com/convert
+`)
+	assert.Equal(t, `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  conversion:
+    strategy: Webhook
+    webhook:
+      clientConfig:
+        url: https://example.com/convert
+      conversionReviewVersions:
+      - v1beta1
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+    subresources:
+      status: {}
+    additionalPrinterColumns:
+    - name: Replicas
+      type: integer
+      jsonPath: .spec.replicas
+`, out)
+	if assert.Len(t, f.Migrated, 1) {
+		assert.Equal(t, []string{"the schemas must be structural -- " +
+			"see 'kubectl explain customresourcedefinition.spec'"}, f.Migrated[0].FollowUps)
+	}
+}
+
+func TestMigrateFilter_Filter_webhooks(t *testing.T) {
+	out, f := runMigrateFilter(t, `apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: policy
+webhooks:
+- name: policy.example.com
+  failurePolicy: Fail
+- name: audit.example.com
+  sideEffects: None
+`)
+	assert.Equal(t, `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: policy
+webhooks:
+- name: policy.example.com
+  failurePolicy: Fail
+  admissionReviewVersions:
+  - v1beta1
+  matchPolicy: Exact
+  timeoutSeconds: 30
+- name: audit.example.com
+  sideEffects: None
+  admissionReviewVersions:
+  - v1beta1
+  failurePolicy: Ignore
+  matchPolicy: Exact
+  timeoutSeconds: 30
+`, out)
+	if assert.Len(t, f.Migrated, 1) {
+		assert.Equal(t, []string{
+			"webhooks[name=policy.example.com].sideEffects must be set to None or NoneOnDryRun",
+		}, f.Migrated[0].FollowUps)
+	}
+}
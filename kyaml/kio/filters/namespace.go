@@ -0,0 +1,206 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// defaultClusterScopedKinds are the kinds which are not namespaced if
+// NamespaceFilter.IsNamespaced is not set
+var defaultClusterScopedKinds = map[string]bool{
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"CustomResourceDefinition": true,
+	"Namespace":                true,
+	"PersistentVolume":         true,
+	"StorageClass":             true,
+}
+
+// NamespaceFilter sets metadata.namespace of the namespaced Resources.  Cluster-scoped
+// Resources, including Namespaces, and documents which aren't Resources, such as
+// kustomization files, are not modified.
+//
+// If UpdateReferences is set, references to the previous namespaces of the Resources are
+// also updated:
+//
+//   - the namespace of the ServiceAccount subjects of RoleBindings and ClusterRoleBindings
+//   - the DNS names of Services in string values -- e.g. web.prod.svc.cluster.local
+//
+// Only references to Resources in the package are updated.
+type NamespaceFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Namespace is the namespace to set
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// UpdateReferences updates references to the previous namespaces of the Resources
+	UpdateReferences bool `yaml:"updateReferences,omitempty"`
+
+	// IsNamespaced returns false for the Resources of cluster-scoped kinds -- e.g.
+	// openapi.Schemas.IsNamespaced.  Defaults to a list of common cluster-scoped kinds.
+	IsNamespaced func(yaml.ResourceMeta) bool `yaml:"-"`
+
+	// Count is populated by Filter with the number of Resources whose namespace was set
+	Count int `yaml:"count,omitempty"`
+
+	// References is populated by Filter with the number of references updated
+	References int `yaml:"references,omitempty"`
+}
+
+var _ kio.Filter = &NamespaceFilter{}
+
+func (f *NamespaceFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Count, f.References = 0, 0
+	if f.Namespace == "" {
+		return nil, fmt.Errorf("must specify namespace")
+	}
+	isNamespaced := f.IsNamespaced
+	if isNamespaced == nil {
+		isNamespaced = func(meta yaml.ResourceMeta) bool {
+			return !defaultClusterScopedKinds[meta.Kind]
+		}
+	}
+
+	// previous are the previous namespaces of the Resources
+	previous := map[string]bool{}
+	// services are the names of the Services in the package, by previous namespace
+	services := map[string][]string{}
+	for i := range slice {
+		if !kio.IsResource(slice[i]) {
+			continue
+		}
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		if !isNamespaced(meta) {
+			continue
+		}
+		if meta.Namespace != "" && meta.Namespace != f.Namespace {
+			previous[meta.Namespace] = true
+			if meta.Kind == "Service" {
+				services[meta.Namespace] = append(services[meta.Namespace], meta.Name)
+			}
+		}
+		if meta.Namespace == f.Namespace {
+			continue
+		}
+		if err := slice[i].PipeE(yaml.LookupCreate(yaml.MappingNode, "metadata"),
+			yaml.SetField("namespace", yaml.NewScalarRNode(f.Namespace))); err != nil {
+			return nil, err
+		}
+		f.Count++
+	}
+	if !f.UpdateReferences || len(previous) == 0 {
+		return slice, nil
+	}
+
+	dnsNames := f.serviceDNSNames(services)
+	for i := range slice {
+		if !kio.IsResource(slice[i]) {
+			continue
+		}
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		if meta.Kind == "RoleBinding" || meta.Kind == "ClusterRoleBinding" {
+			if err := f.updateSubjects(slice[i], previous); err != nil {
+				return nil, err
+			}
+		}
+		if dnsNames != nil {
+			f.updateDNSNames(slice[i].YNode(), dnsNames)
+		}
+	}
+	return slice, nil
+}
+
+// updateSubjects sets the namespace of the ServiceAccount subjects in the previous
+// namespaces
+func (f *NamespaceFilter) updateSubjects(rn *yaml.RNode, previous map[string]bool) error {
+	subjects, err := rn.Pipe(yaml.Lookup("subjects"))
+	if err != nil || subjects == nil || subjects.YNode().Kind != yaml.SequenceNode {
+		return err
+	}
+	for _, s := range subjects.YNode().Content {
+		subject := yaml.NewRNode(s)
+		kind, err := subject.Pipe(yaml.Lookup("kind"))
+		if err != nil {
+			return err
+		}
+		if kind == nil || kind.YNode().Value != "ServiceAccount" {
+			continue
+		}
+		ns, err := subject.Pipe(yaml.Lookup("namespace"))
+		if err != nil {
+			return err
+		}
+		if ns == nil || !previous[ns.YNode().Value] {
+			continue
+		}
+		ns.YNode().Value = f.Namespace
+		f.References++
+	}
+	return nil
+}
+
+// serviceDNSNames returns a pattern matching the namespaced DNS names of the services --
+// e.g. web.prod.svc -- or nil if there are none.  The first group of the pattern is the
+// character preceding the name, and the second group is the service name.
+func (f *NamespaceFilter) serviceDNSNames(services map[string][]string) *regexp.Regexp {
+	var names []string
+	for ns, svcs := range services {
+		for _, svc := range svcs {
+			names = append(names, regexp.QuoteMeta(svc)+`\.`+regexp.QuoteMeta(ns))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	pattern := `(^|[^a-z0-9.-])(`
+	for i, name := range names {
+		if i > 0 {
+			pattern += "|"
+		}
+		pattern += name
+	}
+	return regexp.MustCompile(pattern + `)\.svc\b`)
+}
+
+// updateDNSNames replaces the namespace of the service DNS names in the string values of
+// node
+func (f *NamespaceFilter) updateDNSNames(node *yaml.Node, dnsNames *regexp.Regexp) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.ShortTag() != "!!str" {
+			return
+		}
+		value := dnsNames.ReplaceAllStringFunc(node.Value, func(match string) string {
+			m := dnsNames.FindStringSubmatch(match)
+			// service names and namespaces do not contain dots
+			svc := m[2][:strings.Index(m[2], ".")]
+			return m[1] + svc + "." + f.Namespace + ".svc"
+		})
+		if value != node.Value {
+			node.Value = value
+			f.References++
+		}
+	case yaml.MappingNode:
+		// only values may reference services
+		for i := 1; i < len(node.Content); i += 2 {
+			f.updateDNSNames(node.Content[i], dnsNames)
+		}
+	default:
+		for i := range node.Content {
+			f.updateDNSNames(node.Content[i], dnsNames)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const namespaceInput = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: staging
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: staging
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+data:
+  DB_HOST: db.staging.svc.cluster.local
+  CACHE_HOST: cache.staging.svc.cluster.local
+  OTHER_HOST: my-db.staging.svc
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: web
+  namespace: staging
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: staging
+- kind: ServiceAccount
+  name: monitor
+  namespace: monitoring
+roleRef:
+  kind: Role
+  name: web
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: gadget
+`
+
+func runNamespaceFilter(t *testing.T, f *NamespaceFilter) string {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(namespaceInput)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return out.String()
+}
+
+func TestNamespaceFilter_Filter(t *testing.T) {
+	f := &NamespaceFilter{Namespace: "prod"}
+	out := runNamespaceFilter(t, f)
+	assert.Equal(t, 4, f.Count)
+	assert.Equal(t, 0, f.References)
+	assert.Contains(t, out, `kind: Namespace
+metadata:
+  name: staging
+`)
+	assert.Contains(t, out, `  name: db
+  namespace: prod
+`)
+	assert.Contains(t, out, `  name: gadget
+  namespace: prod
+`)
+	assert.Contains(t, out, `  DB_HOST: db.staging.svc.cluster.local
+`)
+	assert.Contains(t, out, `  namespace: staging
+- kind: ServiceAccount`)
+}
+
+func TestNamespaceFilter_Filter_isNamespaced(t *testing.T) {
+	f := &NamespaceFilter{Namespace: "prod", IsNamespaced: func(meta yaml.ResourceMeta) bool {
+		return meta.Kind != "Namespace" && meta.Kind != "Gadget"
+	}}
+	out := runNamespaceFilter(t, f)
+	assert.Equal(t, 3, f.Count)
+	assert.Contains(t, out, `metadata:
+  name: gadget
+`)
+}
+
+func TestNamespaceFilter_Filter_updateReferences(t *testing.T) {
+	f := &NamespaceFilter{Namespace: "prod", UpdateReferences: true}
+	out := runNamespaceFilter(t, f)
+	assert.Equal(t, 4, f.Count)
+	assert.Equal(t, 2, f.References)
+	// only the services in the package are updated
+	assert.Contains(t, out, `data:
+  DB_HOST: db.prod.svc.cluster.local
+  CACHE_HOST: cache.staging.svc.cluster.local
+  OTHER_HOST: my-db.staging.svc
+`)
+	// only subjects in the previous namespaces are updated
+	assert.Contains(t, out, `subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: prod
+- kind: ServiceAccount
+  name: monitor
+  namespace: monitoring
+`)
+}
+
+func TestNamespaceFilter_Filter_nonResources(t *testing.T) {
+	kustomization := yaml.MustParse(`resources:
+- deployment.yaml
+metadata:
+  annotations:
+    config.kubernetes.io/path: kustomization.yaml
+`)
+	deployment := yaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	f := &NamespaceFilter{Namespace: "staging", UpdateReferences: true}
+	_, err := f.Filter([]*yaml.RNode{kustomization, deployment})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, f.Count)
+	assert.Equal(t, `resources:
+- deployment.yaml
+metadata:
+  annotations:
+    config.kubernetes.io/path: kustomization.yaml
+`, kustomization.MustString())
+}
+
+func TestNamespaceFilter_Filter_noNamespace(t *testing.T) {
+	_, err := (&NamespaceFilter{}).Filter(nil)
+	assert.EqualError(t, err, "must specify namespace")
+}
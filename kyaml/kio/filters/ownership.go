@@ -0,0 +1,183 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TransferProfile is the set of labels and annotations a tool sets on the Resources it
+// manages, which are removed when transferring ownership of the Resources from the tool.
+//
+// Each label or annotation is matched by its key, a key prefix ending in '*', or a
+// key and value as 'key=value'.
+type TransferProfile struct {
+	// Name is the name of the tool -- e.g. helm
+	Name string `yaml:"name" json:"name"`
+
+	// Labels match the labels to remove
+	Labels []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
+	// Annotations match the annotations to remove
+	Annotations []string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// TransferProfiles are the profiles of well-known tools, indexed by name
+var TransferProfiles = map[string]TransferProfile{
+	"argocd": {
+		Name:   "argocd",
+		Labels: []string{"argocd.argoproj.io/instance"},
+		Annotations: []string{
+			"argocd.argoproj.io/instance",
+			"argocd.argoproj.io/tracking-id",
+			"argocd.argoproj.io/compare-options",
+			"argocd.argoproj.io/sync-options",
+		},
+	},
+	"flux": {
+		Name: "flux",
+		Labels: []string{
+			"kustomize.toolkit.fluxcd.io/*",
+			"helm.toolkit.fluxcd.io/*",
+			"fluxcd.io/sync-gc-mark",
+		},
+		Annotations: []string{
+			"kustomize.toolkit.fluxcd.io/*",
+			"helm.toolkit.fluxcd.io/*",
+			"fluxcd.io/sync-checksum",
+		},
+	},
+	"helm": {
+		Name: "helm",
+		Labels: []string{
+			"app.kubernetes.io/managed-by=Helm",
+			"helm.sh/chart",
+			"heritage=Helm",
+			"heritage=Tiller",
+		},
+		Annotations: []string{"meta.helm.sh/*"},
+	},
+	"kubectl": {
+		Name:        "kubectl",
+		Annotations: []string{"kubectl.kubernetes.io/last-applied-configuration"},
+	},
+}
+
+// matchesTransfer returns true if the key and value match one of the patterns
+func matchesTransfer(patterns []string, key, value string) bool {
+	for _, p := range patterns {
+		switch {
+		case strings.HasSuffix(p, "*"):
+			if strings.HasPrefix(key, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		case strings.Contains(p, "="):
+			if p == key+"="+value {
+				return true
+			}
+		case p == key:
+			return true
+		}
+	}
+	return false
+}
+
+// OwnershipTransferFilter removes the labels and annotations set by the tools in
+// Profiles from the Resources, so that the Resources may be adopted by another tool.
+//
+// Only the labels and annotations of the Resources themselves are removed.  Pod
+// template labels are left untouched, since they may be matched by selectors.
+type OwnershipTransferFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Profiles are the tools to transfer ownership from
+	Profiles []TransferProfile `yaml:"profiles,omitempty"`
+
+	// Removed is populated by Filter with the labels and annotations removed,
+	// with their previous values.
+	Removed []SearchMatch `yaml:"removed,omitempty"`
+}
+
+var _ kio.Filter = &OwnershipTransferFilter{}
+
+func (f *OwnershipTransferFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Removed = nil
+	if len(f.Profiles) == 0 {
+		return nil, fmt.Errorf("must specify at least one profile")
+	}
+	var labels, annotations []string
+	for _, p := range f.Profiles {
+		labels = append(labels, p.Labels...)
+		annotations = append(annotations, p.Annotations...)
+	}
+
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range []struct {
+			name     string
+			patterns []string
+		}{{"labels", labels}, {"annotations", annotations}} {
+			m, err := slice[i].Pipe(yaml.Lookup("metadata", field.name))
+			if err != nil {
+				return nil, err
+			}
+			if m == nil {
+				continue
+			}
+
+			var keys []string
+			err = m.VisitFields(func(n *yaml.MapNode) error {
+				key, value := n.Key.YNode().Value, n.Value.YNode().Value
+				if field.name == "annotations" && isReaderAnnotation(key) {
+					return nil
+				}
+				if !matchesTransfer(field.patterns, key, value) {
+					return nil
+				}
+				keys = append(keys, key)
+				f.Removed = append(f.Removed, SearchMatch{
+					ApiVersion: meta.ApiVersion,
+					Kind:       meta.Kind,
+					Namespace:  meta.Namespace,
+					Name:       meta.Name,
+					File:       meta.Annotations[kioutil.PathAnnotation],
+					Line:       n.Key.YNode().Line,
+					Path:       joinSearchPath([]string{"metadata", field.name, key}),
+					Value:      value,
+				})
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				if _, err := m.Pipe(yaml.Clear(key)); err != nil {
+					return nil, err
+				}
+			}
+			if len(keys) > 0 && len(m.Content()) == 0 {
+				if _, err := slice[i].Pipe(yaml.Lookup("metadata"), yaml.Clear(field.name)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(f.Removed, func(i, j int) bool {
+		if f.Removed[i].File != f.Removed[j].File {
+			return f.Removed[i].File < f.Removed[j].File
+		}
+		return f.Removed[i].Line < f.Removed[j].Line
+	})
+	return slice, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+func TestOwnershipTransferFilter_Filter(t *testing.T) {
+	f := &OwnershipTransferFilter{Profiles: []TransferProfile{
+		TransferProfiles["helm"], TransferProfiles["kubectl"]}}
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  labels:
+    app: nginx
+    app.kubernetes.io/managed-by: Helm
+    helm.sh/chart: nginx-1.0.0
+  annotations:
+    meta.helm.sh/release-name: nginx
+    meta.helm.sh/release-namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        app: nginx
+        helm.sh/chart: nginx-1.0.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{}'
+`)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  labels:
+    app: nginx
+spec:
+  template:
+    metadata:
+      labels:
+        app: nginx
+        helm.sh/chart: nginx-1.0.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+`, out.String())
+
+	var removed []string
+	for _, r := range f.Removed {
+		removed = append(removed, r.Kind+" "+r.Name+" "+r.Path+"="+r.Value)
+	}
+	assert.Equal(t, []string{
+		`Deployment nginx metadata.labels.app\.kubernetes\.io/managed-by=Helm`,
+		`Deployment nginx metadata.labels.helm\.sh/chart=nginx-1.0.0`,
+		`Deployment nginx metadata.annotations.meta\.helm\.sh/release-name=nginx`,
+		`Deployment nginx metadata.annotations.meta\.helm\.sh/release-namespace=default`,
+		`Service nginx metadata.annotations.kubectl\.kubernetes\.io/last-applied-configuration={}`,
+	}, removed)
+}
+
+func TestOwnershipTransferFilter_Filter_noProfiles(t *testing.T) {
+	_, err := (&OwnershipTransferFilter{}).Filter(nil)
+	assert.EqualError(t, err, "must specify at least one profile")
+}
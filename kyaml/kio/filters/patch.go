@@ -0,0 +1,716 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/merge2"
+)
+
+// PatchTarget selects the Resources a patch is applied to.  Empty fields match all
+// Resources.
+type PatchTarget struct {
+	// Group is the API group of the Resources -- e.g. apps, or "" for the core group
+	// which may not be selected individually.
+	Group string `yaml:"group,omitempty"`
+
+	// Version is the API version of the Resources -- e.g. v1
+	Version string `yaml:"version,omitempty"`
+
+	// Kind is the kind of the Resources
+	Kind string `yaml:"kind,omitempty"`
+
+	// Namespace is a glob the namespace of the Resources must match
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Name is a glob the name of the Resources must match -- e.g. 'nginx-*'
+	Name string `yaml:"name,omitempty"`
+
+	// Labels must all be set on the Resources with the same values
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// isEmpty returns true if the target matches all Resources
+func (t PatchTarget) isEmpty() bool {
+	return t.Group == "" && t.Version == "" && t.Kind == "" && t.Namespace == "" &&
+		t.Name == "" && len(t.Labels) == 0
+}
+
+// Matches returns true if the Resource is selected by the target.
+func (t PatchTarget) Matches(meta yaml.ResourceMeta) (bool, error) {
+	group, version := "", meta.ApiVersion
+	if i := strings.Index(meta.ApiVersion, "/"); i >= 0 {
+		group, version = meta.ApiVersion[:i], meta.ApiVersion[i+1:]
+	}
+	if (t.Group != "" && t.Group != group) || (t.Version != "" && t.Version != version) ||
+		(t.Kind != "" && t.Kind != meta.Kind) {
+		return false, nil
+	}
+	for _, glob := range []struct{ pattern, value string }{
+		{t.Namespace, meta.Namespace}, {t.Name, meta.Name}} {
+		if glob.pattern == "" {
+			continue
+		}
+		matched, err := path.Match(glob.pattern, glob.value)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	for k, v := range t.Labels {
+		if value, found := meta.Labels[k]; !found || value != v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// PatchFilter applies a strategic merge patch or a JSON 6902 patch to the Resources
+// selected by Target.
+//
+// Strategic merge patches are merged into the Resources using the merge2 rules -- see
+// merge2.Help.  If Target is empty, the patch is applied to the Resources with the
+// kind, name and namespace of the patch.  The '$patch: delete' and '$patch: replace'
+// directives delete or replace the map or list element they are set on, rather than
+// merging it, and an element '{$patch: replace}' replaces the whole list.  Set on the
+// patch itself, they delete the Resources, or replace them keeping their identity.  The
+// other directives -- e.g. '$retainKeys' -- are not supported.
+//
+// JSON 6902 patches are lists of operations written as yaml or json -- e.g.
+// '[{"op": "replace", "path": "/spec/replicas", "value": 3}]'.  All operations are
+// supported: add, remove, replace, move, copy and test.  Comments of unmodified fields
+// are retained.
+//
+// Patches don't see the annotations recording where the Resources were read from, which
+// are kept.  Documents which aren't Resources, such as kustomization files, aren't patched.
+type PatchFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Patch is a strategic merge patch.
+	Patch string `yaml:"patch,omitempty"`
+
+	// JSONPatch is a JSON 6902 patch.  Only one of Patch and JSONPatch may be set.
+	JSONPatch string `yaml:"jsonPatch,omitempty"`
+
+	// Target selects the Resources to patch.
+	Target PatchTarget `yaml:"target,omitempty"`
+
+	// Patched is populated by Filter with the Resources patched.
+	Patched []yaml.ResourceMeta `yaml:"patched,omitempty"`
+}
+
+var _ kio.Filter = &PatchFilter{}
+
+// jsonPatchOperation is an operation of a JSON 6902 patch
+type jsonPatchOperation struct {
+	Op    string
+	Path  string
+	From  string
+	Value *yaml.Node
+}
+
+// parseJSONPatch returns the operations of the JSON 6902 patch node.  The values are
+// kept as nodes so that they are added with their styles.
+func parseJSONPatch(node *yaml.RNode) ([]jsonPatchOperation, error) {
+	if node.YNode().Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("expected a list of operations")
+	}
+	var ops []jsonPatchOperation
+	for i, elem := range node.YNode().Content {
+		if elem.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("operation %d: expected a mapping", i)
+		}
+		var op jsonPatchOperation
+		for j := 0; j+1 < len(elem.Content); j += 2 {
+			key, value := elem.Content[j].Value, elem.Content[j+1]
+			switch key {
+			case "op":
+				op.Op = value.Value
+			case "path":
+				op.Path = value.Value
+			case "from":
+				op.From = value.Value
+			case "value":
+				op.Value = value
+			default:
+				return nil, fmt.Errorf("operation %d: unknown field %q", i, key)
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func (f *PatchFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Patched = nil
+	if (f.Patch == "") == (f.JSONPatch == "") {
+		return nil, fmt.Errorf("exactly one of patch and jsonPatch must be set")
+	}
+
+	target := f.Target
+	var patch *yaml.RNode
+	var directive string
+	var ops []jsonPatchOperation
+	if f.Patch != "" {
+		var err error
+		if patch, err = yaml.Parse(f.Patch); err != nil {
+			return nil, fmt.Errorf("unable to parse patch: %v", err)
+		}
+		if patch.YNode().Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("unable to parse patch: expected a mapping")
+		}
+		if directive, err = patchDirective(patch.YNode()); err != nil {
+			return nil, err
+		}
+		if target.isEmpty() {
+			meta, err := patch.GetMeta()
+			if err != nil && err != yaml.ErrMissingMetadata {
+				return nil, err
+			}
+			if meta.Kind == "" || meta.Name == "" {
+				return nil, fmt.Errorf("patch must have a kind and name, or a target")
+			}
+			target = PatchTarget{Kind: meta.Kind, Name: meta.Name, Namespace: meta.Namespace}
+		}
+		// don't merge the identity of the patch into the selected Resources
+		for _, field := range []string{"apiVersion", "kind"} {
+			if err := patch.PipeE(yaml.Clear(field)); err != nil {
+				return nil, err
+			}
+		}
+		if err := patch.PipeE(yaml.Lookup("metadata"), yaml.Clear("name")); err != nil {
+			return nil, err
+		}
+		if err := patch.PipeE(yaml.Lookup("metadata"), yaml.Clear("namespace")); err != nil {
+			return nil, err
+		}
+	} else {
+		node, err := yaml.Parse(f.JSONPatch)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse jsonPatch: %v", err)
+		}
+		if ops, err = parseJSONPatch(node); err != nil {
+			return nil, fmt.Errorf("unable to parse jsonPatch: %v", err)
+		}
+	}
+
+	var output []*yaml.RNode
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil && err != yaml.ErrMissingMetadata {
+			return nil, err
+		}
+		matched, err := target.Matches(meta)
+		if err != nil {
+			return nil, err
+		}
+		if !matched || !kio.IsResource(slice[i]) {
+			output = append(output, slice[i])
+			continue
+		}
+		f.Patched = append(f.Patched, meta)
+
+		// the patch only sees the fields of the Resource, not where it was read from
+		annotations, err := clearReaderAnnotations(slice[i])
+		if err != nil {
+			return nil, err
+		}
+		node := slice[i]
+		if patch != nil {
+			if node, err = mergePatch(patch.Copy(), node, directive); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", meta.Kind, meta.Name, err)
+			}
+			if node == nil {
+				// deleted
+				continue
+			}
+		} else {
+			for _, op := range ops {
+				if err := applyJSONPatchOperation(node.YNode(), op); err != nil {
+					return nil, fmt.Errorf("%s %s: %s %s: %v", meta.Kind, meta.Name, op.Op, op.Path, err)
+				}
+			}
+		}
+		for _, k := range readerAnnotations {
+			if v, found := annotations[k]; found {
+				if err := node.PipeE(yaml.SetAnnotation(k, v)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		output = append(output, node)
+	}
+	return output, nil
+}
+
+// clearReaderAnnotations removes the readerAnnotations from the Resource, and the
+// annotations field if only they were set, and returns them
+func clearReaderAnnotations(node *yaml.RNode) (map[string]string, error) {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{}
+	for _, k := range readerAnnotations {
+		if v, found := meta.Annotations[k]; found {
+			annotations[k] = v
+			if err := node.PipeE(yaml.ClearAnnotation(k)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(annotations) == 0 {
+		return annotations, nil
+	}
+	field, err := node.Pipe(yaml.Lookup("metadata", "annotations"))
+	if err != nil {
+		return nil, err
+	}
+	if field != nil && len(field.Content()) == 0 {
+		if err := node.PipeE(yaml.Lookup("metadata"), yaml.Clear("annotations")); err != nil {
+			return nil, err
+		}
+	}
+	return annotations, nil
+}
+
+// mergePatch applies the strategic merge patch to the Resource.  directive is the $patch
+// directive of the patch itself, which has been removed from it.  Returns nil if the
+// Resource is deleted.
+func mergePatch(patch, node *yaml.RNode, directive string) (*yaml.RNode, error) {
+	switch directive {
+	case "delete":
+		return nil, nil
+	case "replace":
+		// keep the identity of the Resource
+		identity := yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+		for _, path := range [][]string{
+			{"apiVersion"}, {"kind"}, {"metadata", "name"}, {"metadata", "namespace"}} {
+			value, err := node.Pipe(yaml.Lookup(path...))
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				continue
+			}
+			if err := identity.PipeE(
+				yaml.LookupCreate(yaml.MappingNode, path[:len(path)-1]...),
+				yaml.SetField(path[len(path)-1], value)); err != nil {
+				return nil, err
+			}
+		}
+		node = identity
+	}
+	if _, err := applyPatchDirectives(patch.YNode(), node.YNode()); err != nil {
+		return nil, err
+	}
+	return merge2.Merge(patch, node)
+}
+
+// patchDirective removes the $patch directive from the mapping node of a strategic merge
+// patch, and returns its value -- "" if the node doesn't have one.
+func patchDirective(node *yaml.Node) (string, error) {
+	var directive string
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !strings.HasPrefix(key.Value, "$") {
+			content = append(content, key, value)
+			continue
+		}
+		if key.Value != "$patch" {
+			return "", fmt.Errorf("%d:%d: unsupported directive %s", key.Line, key.Column, key.Value)
+		}
+		switch value.Value {
+		case "merge", "replace", "delete":
+			directive = value.Value
+		default:
+			return "", fmt.Errorf("%d:%d: unsupported directive $patch: %s",
+				key.Line, key.Column, value.Value)
+		}
+	}
+	if directive == "merge" {
+		directive = ""
+	}
+	node.Content = content
+	return directive, nil
+}
+
+// applyPatchDirectives applies the $patch directives of the strategic merge patch to node,
+// and removes them from the patch so that the rest of the patch is merged into node.
+// node may be nil if the patched field doesn't exist.  Returns true if the patch was
+// emptied by removing fields and elements with directives, and should not be merged.
+func applyPatchDirectives(patch, node *yaml.Node) (bool, error) {
+	switch patch.Kind {
+	case yaml.MappingNode:
+		if node != nil && node.Kind != yaml.MappingNode {
+			node = nil
+		}
+		removed := false
+		var content []*yaml.Node
+		for i := 0; i+1 < len(patch.Content); i += 2 {
+			key, value := patch.Content[i], patch.Content[i+1]
+			var field *yaml.Node
+			j := -1
+			if node != nil {
+				if j = mappingIndex(node, key.Value); j >= 0 {
+					field = node.Content[j+1]
+				}
+			}
+			directive := ""
+			if value.Kind == yaml.MappingNode {
+				var err error
+				if directive, err = patchDirective(value); err != nil {
+					return false, err
+				}
+			}
+			switch {
+			case directive == "delete":
+				if j >= 0 {
+					node.Content = append(node.Content[:j:j], node.Content[j+2:]...)
+				}
+			case directive == "replace" && node != nil:
+				if _, err := applyPatchDirectives(value, nil); err != nil {
+					return false, err
+				}
+				if j >= 0 {
+					node.Content[j+1] = value
+				} else {
+					node.Content = append(node.Content, key, value)
+				}
+			default:
+				empty, err := applyPatchDirectives(value, field)
+				if err != nil {
+					return false, err
+				}
+				if !empty {
+					content = append(content, key, value)
+					continue
+				}
+			}
+			removed = true
+		}
+		patch.Content = content
+		return removed && len(content) == 0, nil
+	case yaml.SequenceNode:
+		return applyListPatchDirectives(patch, node)
+	}
+	return false, nil
+}
+
+// applyListPatchDirectives applies the $patch directives of the elements of the list of a
+// strategic merge patch to the list node.  Elements are paired by their associative key.
+func applyListPatchDirectives(patch, node *yaml.Node) (bool, error) {
+	if node != nil && node.Kind != yaml.SequenceNode {
+		node = nil
+	}
+	replace := false
+	var elems []*yaml.Node
+	directives := map[*yaml.Node]string{}
+	for _, elem := range patch.Content {
+		if elem.Kind == yaml.MappingNode {
+			directive, err := patchDirective(elem)
+			if err != nil {
+				return false, err
+			}
+			if directive == "replace" && len(elem.Content) == 0 {
+				// {$patch: replace} replaces the whole list
+				replace = true
+				continue
+			}
+			directives[elem] = directive
+		}
+		elems = append(elems, elem)
+	}
+	patch.Content = elems
+	if replace {
+		if node == nil {
+			return applyListPatchDirectives(patch, nil)
+		}
+		if _, err := applyListPatchDirectives(patch, nil); err != nil {
+			return false, err
+		}
+		node.Content = patch.Content
+		return true, nil
+	}
+
+	key := yaml.NewRNode(patch).GetAssociativeKey()
+	removed := false
+	var content []*yaml.Node
+	for _, elem := range elems {
+		directive := directives[elem]
+		if directive != "" && key == "" {
+			return false, fmt.Errorf("%d:%d: $patch: %s requires the list elements to have "+
+				"an associative key -- e.g. name", elem.Line, elem.Column, directive)
+		}
+		j := -1
+		if key != "" && node != nil && elem.Kind == yaml.MappingNode {
+			value := yaml.NewRNode(elem).Field(key).Value.YNode().Value
+			for k := range node.Content {
+				f := yaml.NewRNode(node.Content[k]).Field(key)
+				if f != nil && f.Value.YNode().Value == value {
+					j = k
+					break
+				}
+			}
+		}
+		switch {
+		case directive == "delete":
+			if j >= 0 {
+				node.Content = append(node.Content[:j:j], node.Content[j+1:]...)
+			}
+			removed = true
+		case directive == "replace" && j >= 0:
+			if _, err := applyPatchDirectives(elem, nil); err != nil {
+				return false, err
+			}
+			node.Content[j] = elem
+			removed = true
+		default:
+			var match *yaml.Node
+			if j >= 0 {
+				match = node.Content[j]
+			}
+			if _, err := applyPatchDirectives(elem, match); err != nil {
+				return false, err
+			}
+			content = append(content, elem)
+		}
+	}
+	patch.Content = content
+	return removed && len(content) == 0, nil
+}
+
+// applyJSONPatchOperation applies the JSON 6902 operation to the node
+func applyJSONPatchOperation(node *yaml.Node, op jsonPatchOperation) error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("missing value")
+		}
+	case "move", "copy":
+		if op.From == "" {
+			return fmt.Errorf("missing from")
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unsupported operation")
+	}
+
+	tokens, err := jsonPointer(op.Path)
+	if err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add":
+		return jsonPatchAdd(node, tokens, yaml.CopyYNode(op.Value))
+	case "remove":
+		_, err := jsonPatchRemove(node, tokens)
+		return err
+	case "replace":
+		return jsonPatchReplace(node, tokens, yaml.CopyYNode(op.Value))
+	case "test":
+		value, err := jsonPointerNode(node, tokens)
+		if err != nil {
+			return err
+		}
+		var expected, actual interface{}
+		if err := op.Value.Decode(&expected); err != nil {
+			return err
+		}
+		if err := value.Decode(&actual); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Errorf("test failed")
+		}
+		return nil
+	}
+
+	from, err := jsonPointer(op.From)
+	if err != nil {
+		return err
+	}
+	var value *yaml.Node
+	if op.Op == "move" {
+		value, err = jsonPatchRemove(node, from)
+	} else {
+		value, err = jsonPointerNode(node, from)
+		value = yaml.CopyYNode(value)
+	}
+	if err != nil {
+		return err
+	}
+	return jsonPatchAdd(node, tokens, value)
+}
+
+// jsonPointer returns the unescaped tokens of a JSON pointer -- e.g. /metadata/name
+func jsonPointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid path '%s': must start with '/'", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(tokens[i], "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// jsonPointerNode returns the node at the JSON pointer tokens
+func jsonPointerNode(node *yaml.Node, tokens []string) (*yaml.Node, error) {
+	for i, token := range tokens {
+		switch node.Kind {
+		case yaml.MappingNode:
+			j := mappingIndex(node, token)
+			if j < 0 {
+				return nil, fmt.Errorf("%s not found", jsonPointerString(tokens[:i+1]))
+			}
+			node = node.Content[j+1]
+		case yaml.SequenceNode:
+			j, err := sequenceIndex(node, token, false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", jsonPointerString(tokens[:i+1]), err)
+			}
+			node = node.Content[j]
+		default:
+			return nil, fmt.Errorf("%s not found", jsonPointerString(tokens[:i+1]))
+		}
+	}
+	return node, nil
+}
+
+// jsonPatchAdd adds value at the JSON pointer tokens, replacing an existing field or
+// inserting into a list.
+func jsonPatchAdd(node *yaml.Node, tokens []string, value *yaml.Node) error {
+	if len(tokens) == 0 {
+		*node = *value
+		return nil
+	}
+	parent, err := jsonPointerNode(node, tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	token := tokens[len(tokens)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		if j := mappingIndex(parent, token); j >= 0 {
+			parent.Content[j+1] = value
+			return nil
+		}
+		parent.Content = append(parent.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: token, Tag: "!!str"}, value)
+		return nil
+	case yaml.SequenceNode:
+		j, err := sequenceIndex(parent, token, true)
+		if err != nil {
+			return fmt.Errorf("%s: %v", jsonPointerString(tokens), err)
+		}
+		content := append([]*yaml.Node{}, parent.Content[:j]...)
+		content = append(content, value)
+		parent.Content = append(content, parent.Content[j:]...)
+		return nil
+	}
+	return fmt.Errorf("%s is not an object or array", jsonPointerString(tokens[:len(tokens)-1]))
+}
+
+// jsonPatchReplace replaces the existing node at the JSON pointer tokens with value,
+// keeping its line comment
+func jsonPatchReplace(node *yaml.Node, tokens []string, value *yaml.Node) error {
+	old, err := jsonPointerNode(node, tokens)
+	if err != nil {
+		return err
+	}
+	if value.LineComment == "" {
+		value.LineComment = old.LineComment
+	}
+	if len(tokens) == 0 {
+		*node = *value
+		return nil
+	}
+	parent, _ := jsonPointerNode(node, tokens[:len(tokens)-1])
+	token := tokens[len(tokens)-1]
+	if parent.Kind == yaml.MappingNode {
+		parent.Content[mappingIndex(parent, token)+1] = value
+	} else {
+		j, _ := sequenceIndex(parent, token, false)
+		parent.Content[j] = value
+	}
+	return nil
+}
+
+// jsonPatchRemove removes and returns the node at the JSON pointer tokens
+func jsonPatchRemove(node *yaml.Node, tokens []string) (*yaml.Node, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot remove the Resource")
+	}
+	parent, err := jsonPointerNode(node, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := tokens[len(tokens)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		j := mappingIndex(parent, token)
+		if j < 0 {
+			return nil, fmt.Errorf("%s not found", jsonPointerString(tokens))
+		}
+		value := parent.Content[j+1]
+		parent.Content = append(parent.Content[:j:j], parent.Content[j+2:]...)
+		return value, nil
+	case yaml.SequenceNode:
+		j, err := sequenceIndex(parent, token, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", jsonPointerString(tokens), err)
+		}
+		value := parent.Content[j]
+		parent.Content = append(parent.Content[:j:j], parent.Content[j+1:]...)
+		return value, nil
+	}
+	return nil, fmt.Errorf("%s not found", jsonPointerString(tokens))
+}
+
+// mappingIndex returns the index of the key in the mapping node, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// sequenceIndex returns the index of the token in the sequence node.  If insert is
+// true the index may be the length of the sequence, which is also written as '-'.
+func sequenceIndex(node *yaml.Node, token string, insert bool) (int, error) {
+	if token == "-" && insert {
+		return len(node.Content), nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid index '%s'", token)
+	}
+	if i > len(node.Content) || (i == len(node.Content) && !insert) {
+		return 0, fmt.Errorf("index %d out of range", i)
+	}
+	return i, nil
+}
+
+// jsonPointerString returns the JSON pointer for the tokens
+func jsonPointerString(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.Replace(strings.Replace(t, "~", "~0", -1), "/", "~1", -1))
+	}
+	return b.String()
+}
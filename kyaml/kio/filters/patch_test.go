@@ -0,0 +1,489 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const patchInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    tier: web
+spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: sidecar:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+  namespace: data
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  labels:
+    tier: web
+`
+
+func runPatchFilter(t *testing.T, f *PatchFilter) (string, error) {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(patchInput)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	return out.String(), err
+}
+
+func TestPatchFilter_Filter_strategicMerge(t *testing.T) {
+	f := &PatchFilter{Patch: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v2
+`}
+	out, err := runPatchFilter(t, f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    tier: web
+spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v2
+      - name: sidecar
+        image: sidecar:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+  namespace: data
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  labels:
+    tier: web
+`, out)
+	if assert.Len(t, f.Patched, 1) {
+		assert.Equal(t, "app", f.Patched[0].Name)
+	}
+}
+
+func TestPatchFilter_Filter_target(t *testing.T) {
+	f := &PatchFilter{
+		Patch: `metadata:
+  annotations:
+    team: payments
+`,
+		Target: PatchTarget{Group: "apps", Kind: "Deployment", Name: "*"},
+	}
+	out, err := runPatchFilter(t, f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, out, `kind: Deployment
+metadata:
+  name: app
+  labels:
+    tier: web
+  annotations:
+    team: payments
+`)
+	assert.Contains(t, out, `kind: Deployment
+metadata:
+  name: db
+  namespace: data
+  annotations:
+    team: payments
+`)
+	assert.Len(t, f.Patched, 2)
+
+	f = &PatchFilter{
+		Patch:  "metadata:\n  annotations:\n    team: payments\n",
+		Target: PatchTarget{Labels: map[string]string{"tier": "web"}},
+	}
+	_, err = runPatchFilter(t, f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if assert.Len(t, f.Patched, 2) {
+		assert.Equal(t, "Deployment", f.Patched[0].Kind)
+		assert.Equal(t, "Service", f.Patched[1].Kind)
+	}
+
+	f = &PatchFilter{
+		Patch:  "metadata:\n  annotations:\n    team: payments\n",
+		Target: PatchTarget{Version: "v1", Namespace: "data"},
+	}
+	_, err = runPatchFilter(t, f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if assert.Len(t, f.Patched, 1) {
+		assert.Equal(t, "db", f.Patched[0].Name)
+	}
+}
+
+func TestPatchFilter_Filter_jsonPatch(t *testing.T) {
+	f := &PatchFilter{
+		JSONPatch: `[
+  {"op": "test", "path": "/spec/replicas", "value": 1},
+  {"op": "replace", "path": "/spec/replicas", "value": 3},
+  {"op": "add", "path": "/spec/template/spec/containers/0/args", "value": ["--debug"]},
+  {"op": "copy", "from": "/metadata/labels", "path": "/spec/template/metadata"},
+  {"op": "move", "from": "/spec/template/spec/containers/1", "path": "/spec/template/spec/containers/0"},
+  {"op": "remove", "path": "/metadata/labels/tier"},
+  {"op": "add", "path": "/metadata/labels/app.kubernetes.io~1name", "value": "app"}
+]`,
+		Target: PatchTarget{Kind: "Deployment", Name: "app"},
+	}
+	out, err := runPatchFilter(t, f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, out, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app.kubernetes.io/name: "app"
+spec:
+  replicas: 3 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: sidecar:v1
+      - name: app
+        image: app:v1
+        args: ["--debug"]
+    metadata:
+      tier: web
+---
+`)
+}
+
+func TestPatchFilter_Filter_errors(t *testing.T) {
+	_, err := runPatchFilter(t, &PatchFilter{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "exactly one of patch and jsonPatch must be set")
+	}
+
+	_, err = runPatchFilter(t, &PatchFilter{Patch: "spec:\n  replicas: 3\n"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "patch must have a kind and name, or a target")
+	}
+
+	_, err = runPatchFilter(t, &PatchFilter{
+		JSONPatch: `[{"op": "test", "path": "/spec/replicas", "value": 2}]`,
+		Target:    PatchTarget{Name: "app", Kind: "Deployment"},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Deployment app: test /spec/replicas: test failed")
+	}
+
+	_, err = runPatchFilter(t, &PatchFilter{
+		JSONPatch: `[{"op": "remove", "path": "/spec/paused"}]`,
+		Target:    PatchTarget{Name: "db"},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Deployment db: remove /spec/paused:")
+	}
+
+	_, err = runPatchFilter(t, &PatchFilter{
+		Patch:  "spec:\n  $retainKeys: [replicas]\n",
+		Target: PatchTarget{Name: "db"},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Deployment db: 2:3: unsupported directive $retainKeys")
+	}
+
+	_, err = runPatchFilter(t, &PatchFilter{
+		Patch: "kind: Deployment\nmetadata:\n  name: app\n$patch: remove\n"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "4:1: unsupported directive $patch: remove")
+	}
+
+	_, err = runPatchFilter(t, &PatchFilter{
+		Patch:  "spec:\n  args:\n  - $patch: delete\n",
+		Target: PatchTarget{Name: "db"},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"Deployment db: 3:5: $patch: delete requires the list elements to have an associative key")
+	}
+}
+
+func TestPatchFilter_Filter_directives(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{
+			name: "delete list element",
+			patch: `kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        $patch: delete
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    tier: web
+spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+`,
+		},
+		{
+			name: "replace list element",
+			patch: `kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        $patch: replace
+        args:
+        - run
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    tier: web
+spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: app
+        args:
+        - run
+      - name: sidecar
+        image: sidecar:v1
+`,
+		},
+		{
+			name: "replace list",
+			patch: `kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - $patch: replace
+      - name: proxy
+        image: proxy:v1
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    tier: web
+spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: proxy
+        image: proxy:v1
+`,
+		},
+		{
+			name: "delete map",
+			patch: `kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    $patch: delete
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    tier: web
+spec:
+  replicas: 1 # scaled by hpa
+`,
+		},
+		{
+			name: "replace map",
+			patch: `kind: Deployment
+metadata:
+  name: app
+  labels:
+    $patch: replace
+    app: web
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: web
+spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: sidecar:v1
+`,
+		},
+		{
+			name: "replace Resource",
+			patch: `kind: Deployment
+metadata:
+  name: app
+$patch: replace
+spec:
+  replicas: 2
+`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 2
+`,
+		},
+		{
+			name: "delete Resource",
+			patch: `kind: Deployment
+metadata:
+  name: app
+$patch: delete
+`,
+		},
+	}
+	for _, test := range tests {
+		f := &PatchFilter{Patch: test.patch}
+		out, err := runPatchFilter(t, f)
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		if test.expected != "" {
+			test.expected += "---\n"
+		}
+		assert.Equal(t, test.expected+`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+  namespace: data
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  labels:
+    tier: web
+`, out, test.name)
+		assert.Len(t, f.Patched, 1, test.name)
+	}
+}
+
+func TestPatchFilter_Filter_readerAnnotations(t *testing.T) {
+	input := `apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  annotations:
+    a: b
+---
+resources:
+- service.yaml
+`
+	for _, f := range []*PatchFilter{
+		{JSONPatch: `[
+  {"op": "test", "path": "/metadata/annotations", "value": {"a": "b"}},
+  {"op": "replace", "path": "/metadata/annotations", "value": {"c": "d"}}
+]`},
+		{Patch: `kind: Service
+metadata:
+  name: app
+  annotations:
+    $patch: replace
+    c: d
+`},
+	} {
+		nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(input)}).Read()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		kustomization := nodes[1].MustString()
+		nodes, err = f.Filter(nodes)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		// the patch doesn't see or modify the annotations of the reader
+		meta, err := nodes[0].GetMeta()
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[string]string{"c": "d", "config.kubernetes.io/index": "0"},
+				meta.Annotations)
+		}
+		// documents which aren't Resources aren't patched
+		assert.Equal(t, kustomization, nodes[1].MustString())
+		assert.Len(t, f.Patched, 1)
+	}
+}
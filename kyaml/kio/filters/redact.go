@@ -0,0 +1,144 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"path"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DefaultRedactPlaceholder is the value redacted fields are replaced with by default
+const DefaultRedactPlaceholder = "REDACTED"
+
+// lastAppliedAnnotation is set by kubectl apply to the applied config -- for Secrets
+// this contains the Secret values.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// RedactFilter replaces the values of Secrets, and of the fields matching Paths, so that
+// the Resources may be shared without leaking them.
+//
+// The values of Secret data and stringData are replaced, as well as the
+// last-applied-configuration annotation of Secrets, which contains the values.  Secret
+// data values are replaced by the base64 encoded replacement, so that the Secrets are
+// still valid.
+type RedactFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Paths are the path patterns of additional fields to redact in all Resources --
+	// e.g. '**.password'.  See SearchFilter for the path syntax.
+	Paths []string `yaml:"paths,omitempty"`
+
+	// Placeholder is the value to replace the values with.  Defaults to
+	// DefaultRedactPlaceholder.
+	Placeholder string `yaml:"placeholder,omitempty"`
+
+	// Hash replaces the values with the sha256 hash of the values rather than the
+	// Placeholder, so that redacted values may still be compared.  The hash of Secret
+	// data values is computed from the decoded value, so that equal data and stringData
+	// values have the same hash.
+	Hash bool `yaml:"hash,omitempty"`
+
+	// Redacted is populated by Filter with the fields redacted, in the order of the
+	// Resources and fields.  The Values are the replacements.
+	Redacted []SearchMatch `yaml:"redacted,omitempty"`
+}
+
+var _ kio.Filter = &RedactFilter{}
+
+func (f *RedactFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Redacted = nil
+	var patterns [][]string
+	for _, p := range f.Paths {
+		pattern := searchPath(p)
+		for _, s := range pattern {
+			if _, err := path.Match(elementContents(s), ""); err != nil {
+				return nil, fmt.Errorf("invalid path pattern '%s': %v", p, err)
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		secret := meta.Kind == "Secret" && meta.ApiVersion == "v1"
+
+		var redactErr error
+		searchNode(slice[i].YNode(), nil, nil, func(node, _ *yaml.Node, p []string) {
+			if redactErr != nil {
+				return
+			}
+			encoded := false
+			switch {
+			case secret && len(p) == 2 && p[0] == "data":
+				encoded = true
+			case secret && len(p) == 2 && p[0] == "stringData":
+			case secret && len(p) == 3 && p[0] == "metadata" && p[1] == "annotations" &&
+				p[2] == lastAppliedAnnotation:
+			default:
+				matched := false
+				for _, pattern := range patterns {
+					if matchSearchPath(pattern, p) {
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					return
+				}
+			}
+
+			raw := node.Value
+			if encoded {
+				b, err := base64.StdEncoding.DecodeString(node.Value)
+				if err != nil {
+					redactErr = fmt.Errorf("%s %s: data.%s is not base64 encoded: %v",
+						meta.Kind, meta.Name, p[1], err)
+					return
+				}
+				raw = string(b)
+			}
+			value := f.replacement(raw)
+			if encoded {
+				value = base64.StdEncoding.EncodeToString([]byte(value))
+			}
+			node.Value = value
+			node.Tag = "!!str"
+			node.Style = 0
+			f.Redacted = append(f.Redacted, SearchMatch{
+				ApiVersion: meta.ApiVersion,
+				Kind:       meta.Kind,
+				Namespace:  meta.Namespace,
+				Name:       meta.Name,
+				File:       meta.Annotations[kioutil.PathAnnotation],
+				Line:       node.Line,
+				Path:       joinSearchPath(p),
+				Value:      value,
+			})
+		})
+		if redactErr != nil {
+			return nil, redactErr
+		}
+	}
+	return slice, nil
+}
+
+// replacement returns the value to replace value with
+func (f *RedactFilter) replacement(value string) string {
+	if f.Hash {
+		return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(value)))
+	}
+	if f.Placeholder != "" {
+		return f.Placeholder
+	}
+	return DefaultRedactPlaceholder
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const redactInput = `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"v1","kind":"Secret","data":{"password":"YmFy"}}
+data:
+  password: YmFy
+stringData:
+  username: bar
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        env:
+        - name: DB_PASSWORD
+          value: hunter2
+        - name: DB_PORT
+          value: 5432
+`
+
+func TestRedactFilter_Filter(t *testing.T) {
+	f := &RedactFilter{Paths: []string{"**.env[name=*PASSWORD].value"}}
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(redactInput)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: REDACTED
+data:
+  password: UkVEQUNURUQ=
+stringData:
+  username: REDACTED
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        env:
+        - name: DB_PASSWORD
+          value: REDACTED
+        - name: DB_PORT
+          value: 5432
+`, out.String())
+
+	var redacted []string
+	for _, r := range f.Redacted {
+		redacted = append(redacted, r.Kind+" "+r.Name+" "+r.Path+"="+r.Value)
+	}
+	assert.Equal(t, []string{
+		`Secret creds metadata.annotations.kubectl\.kubernetes\.io/last-applied-configuration=REDACTED`,
+		`Secret creds data.password=UkVEQUNURUQ=`,
+		`Secret creds stringData.username=REDACTED`,
+		`Deployment app spec.template.spec.containers[name=app].env[name=DB_PASSWORD].value=REDACTED`,
+	}, redacted)
+}
+
+func TestRedactFilter_Filter_hash(t *testing.T) {
+	f := &RedactFilter{Paths: []string{"**.env[name=DB_PORT].value"}, Hash: true}
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(redactInput)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// data and stringData values are hashed the same
+	assert.Contains(t, out.String(), `data:
+  password: c2hhMjU2OmZjZGUyYjJlZGJhNTZiZjQwODYwMWZiNzIxZmU5YjVjMzM4ZDEwZWU0MjllYTA0ZmFlNTUxMWI2OGZiZjhmYjk=
+stringData:
+  username: sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9
+`)
+	assert.Contains(t, out.String(), `        - name: DB_PASSWORD
+          value: hunter2
+        - name: DB_PORT
+          value: sha256:4aeb7ad6d5d37a041c4c5ce6562bf9e3caf05a42d931cef4d9e2a60ca623194d
+`)
+}
+
+func TestRedactFilter_Filter_placeholder(t *testing.T) {
+	f := &RedactFilter{Placeholder: "xxx"}
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(redactInput)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = f.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "eHh4", f.Redacted[1].Value)
+	assert.Equal(t, "xxx", f.Redacted[2].Value)
+}
+
+func TestRedactFilter_Filter_notEncoded(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+data:
+  password: not base64
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = (&RedactFilter{}).Filter(nodes)
+	assert.EqualError(t, err,
+		"Secret creds: data.password is not base64 encoded: illegal base64 data at input byte 3")
+}
+
+func TestRedactFilter_Filter_invalidPath(t *testing.T) {
+	_, err := (&RedactFilter{Paths: []string{`spec.a\`}}).Filter(nil)
+	assert.EqualError(t, err, `invalid path pattern 'spec.a\': syntax error in pattern`)
+}
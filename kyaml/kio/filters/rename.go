@@ -0,0 +1,227 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// nameReference is a field which references a Resource by name
+type nameReference struct {
+	// kinds are the kinds of the Resources containing the field.  Matches any kind
+	// if empty.
+	kinds []string
+
+	// path is the search path pattern of the field
+	path string
+
+	// kindField, if set, is a sibling field which must be set to the kind of the
+	// referenced Resource -- e.g. roleRef.kind
+	kindField string
+
+	// namespaceField, if set, is a sibling field which overrides the namespace of the
+	// referenced Resource -- e.g. subjects[].namespace
+	namespaceField string
+}
+
+// podSpecPath prefixes the path of a field in a pod spec, so that it matches the pod
+// specs of any workload -- e.g. Pods, Deployments and CronJobs
+func podSpecPath(p string) string {
+	return "**." + p
+}
+
+// nameReferences are the fields referencing Resources by name, by referenced kind
+var nameReferences = map[string][]nameReference{
+	"ConfigMap": {
+		{path: podSpecPath("env[*].valueFrom.configMapKeyRef.name")},
+		{path: podSpecPath("envFrom[*].configMapRef.name")},
+		{path: podSpecPath("volumes[*].configMap.name")},
+		{path: podSpecPath("volumes[*].projected.sources[*].configMap.name")},
+	},
+	"Secret": {
+		{path: podSpecPath("env[*].valueFrom.secretKeyRef.name")},
+		{path: podSpecPath("envFrom[*].secretRef.name")},
+		{path: podSpecPath("volumes[*].secret.secretName")},
+		{path: podSpecPath("volumes[*].projected.sources[*].secret.name")},
+		{path: podSpecPath("imagePullSecrets[*].name")},
+		{kinds: []string{"ServiceAccount"}, path: "secrets[*].name"},
+		{kinds: []string{"Ingress"}, path: "spec.tls[*].secretName"},
+	},
+	"Service": {
+		{kinds: []string{"Ingress"}, path: "spec.backend.serviceName"},
+		{kinds: []string{"Ingress"}, path: "spec.defaultBackend.service.name"},
+		{kinds: []string{"Ingress"}, path: "spec.rules[*].http.paths[*].backend.serviceName"},
+		{kinds: []string{"Ingress"}, path: "spec.rules[*].http.paths[*].backend.service.name"},
+		{kinds: []string{"StatefulSet"}, path: "spec.serviceName"},
+	},
+	"ServiceAccount": {
+		{path: podSpecPath("serviceAccountName")},
+		{path: podSpecPath("serviceAccount")},
+		{kinds: []string{"RoleBinding", "ClusterRoleBinding"}, path: "subjects[*].name",
+			kindField: "kind", namespaceField: "namespace"},
+	},
+	"PersistentVolumeClaim": {
+		{path: podSpecPath("volumes[*].persistentVolumeClaim.claimName")},
+	},
+	"Role": {
+		{kinds: []string{"RoleBinding"}, path: "roleRef.name", kindField: "kind"},
+	},
+	"ClusterRole": {
+		{kinds: []string{"RoleBinding", "ClusterRoleBinding"}, path: "roleRef.name",
+			kindField: "kind"},
+	},
+}
+
+// clusterScopedKinds are the kinds which may be renamed or referenced without a
+// namespace
+var clusterScopedKinds = map[string]bool{
+	"ClusterRole": true,
+}
+
+// RenameFilter renames a Resource, and updates the fields of other Resources which
+// reference it by name -- e.g. the env, envFrom and volumes of workloads referencing a
+// ConfigMap, or the backends of Ingresses referencing a Service.
+//
+// References are only updated in Resources in the same namespace as the renamed
+// Resource, unless the reference sets its own namespace.
+type RenameFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// ResourceKind is the kind of the Resource to rename
+	ResourceKind string `yaml:"resourceKind,omitempty"`
+
+	// Namespace is the namespace of the Resource to rename
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Name is the name of the Resource to rename
+	Name string `yaml:"name,omitempty"`
+
+	// NewName is the new name of the Resource
+	NewName string `yaml:"newName,omitempty"`
+
+	// References is populated by Filter with the references which were updated
+	References []SearchMatch `yaml:"references,omitempty"`
+}
+
+var _ kio.Filter = &RenameFilter{}
+
+func (f *RenameFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.References = nil
+	if f.Name == "" || f.NewName == "" {
+		return nil, fmt.Errorf("must specify the name and new name")
+	}
+
+	found := false
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		if meta.Kind != f.ResourceKind || meta.Name != f.Name ||
+			(meta.Namespace != f.Namespace && !clusterScopedKinds[meta.Kind]) {
+			continue
+		}
+		name, err := slice[i].Pipe(yaml.Lookup("metadata", "name"))
+		if err != nil {
+			return nil, err
+		}
+		// set the value rather than the field to keep its comments
+		name.YNode().Value = f.NewName
+		found = true
+	}
+	if !found {
+		id := f.Name
+		if f.Namespace != "" {
+			id = f.Namespace + "/" + f.Name
+		}
+		return nil, fmt.Errorf("%s %s not found", f.ResourceKind, id)
+	}
+
+	targetNamespace := f.Namespace
+	if clusterScopedKinds[f.ResourceKind] {
+		targetNamespace = ""
+	}
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		visitReferences(slice[i], meta, f.ResourceKind,
+			func(node *yaml.Node, namespace string, p []string) {
+				if node.Value != f.Name || namespace != targetNamespace {
+					return
+				}
+				f.References = append(f.References, SearchMatch{
+					ApiVersion: meta.ApiVersion,
+					Kind:       meta.Kind,
+					Namespace:  meta.Namespace,
+					Name:       meta.Name,
+					File:       meta.Annotations[kioutil.PathAnnotation],
+					Line:       node.Line,
+					Path:       joinSearchPath(p),
+					Value:      f.NewName,
+				})
+				node.Value = f.NewName
+			})
+	}
+	return slice, nil
+}
+
+// matchesKind returns true if the reference may be in a Resource of the kind
+func (ref nameReference) matchesKind(kind string) bool {
+	if len(ref.kinds) == 0 {
+		return true
+	}
+	for _, k := range ref.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// visitReferences calls fn for each field of the Resource which references a Resource
+// of the kind by name, with the namespace of the referenced Resource and the path to
+// the field.  The namespace of cluster scoped Resources is always empty.
+func visitReferences(node *yaml.RNode, meta yaml.ResourceMeta, kind string,
+	fn func(field *yaml.Node, namespace string, p []string)) {
+	for _, ref := range nameReferences[kind] {
+		if !ref.matchesKind(meta.Kind) {
+			continue
+		}
+		pattern := searchPath(ref.path)
+		searchNode(node.YNode(), nil, nil, func(field, parent *yaml.Node, p []string) {
+			if !matchSearchPath(pattern, p) {
+				return
+			}
+			sibling := func(name string) string {
+				if parent == nil {
+					return ""
+				}
+				v := yaml.NewRNode(parent).Field(name)
+				if yaml.IsFieldEmpty(v) {
+					return ""
+				}
+				return v.Value.YNode().Value
+			}
+			if ref.kindField != "" && sibling(ref.kindField) != kind {
+				return
+			}
+			namespace := meta.Namespace
+			if ref.namespaceField != "" {
+				if ns := sibling(ref.namespaceField); ns != "" {
+					namespace = ns
+				}
+			}
+			if clusterScopedKinds[kind] {
+				namespace = ""
+			}
+			fn(field, namespace, p)
+		})
+	}
+}
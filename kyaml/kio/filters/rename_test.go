@@ -0,0 +1,245 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+func rename(t *testing.T, f *RenameFilter, input string) string {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(input)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return out.String()
+}
+
+func TestRenameFilter_Filter_configMap(t *testing.T) {
+	f := &RenameFilter{ResourceKind: "ConfigMap", Namespace: "default", Name: "config", NewName: "settings"}
+	actual := rename(t, f, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config # the config
+  namespace: default
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        env:
+        - name: A
+          valueFrom:
+            configMapKeyRef:
+              name: config
+              key: a
+        envFrom:
+        - configMapRef:
+            name: config
+      volumes:
+      - name: config
+        configMap:
+          name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: other
+spec:
+  template:
+    spec:
+      volumes:
+      - name: config
+        configMap:
+          name: config
+`)
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings # the config
+  namespace: default
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        env:
+        - name: A
+          valueFrom:
+            configMapKeyRef:
+              name: settings
+              key: a
+        envFrom:
+        - configMapRef:
+            name: settings
+      volumes:
+      - name: config
+        configMap:
+          name: settings
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: other
+spec:
+  template:
+    spec:
+      volumes:
+      - name: config
+        configMap:
+          name: config
+`, actual)
+
+	var paths []string
+	for _, r := range f.References {
+		paths = append(paths, r.Path)
+	}
+	assert.Equal(t, []string{
+		"spec.template.spec.containers[name=nginx].env[name=A].valueFrom.configMapKeyRef.name",
+		"spec.template.spec.containers[name=nginx].envFrom[0].configMapRef.name",
+		"spec.template.spec.volumes[name=config].configMap.name",
+	}, paths)
+}
+
+func TestRenameFilter_Filter_service(t *testing.T) {
+	f := &RenameFilter{ResourceKind: "Service", Name: "web", NewName: "frontend"}
+	actual := rename(t, f, `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  rules:
+  - http:
+      paths:
+      - path: /
+        backend:
+          serviceName: web
+          servicePort: 80
+`)
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+---
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  rules:
+  - http:
+      paths:
+      - path: /
+        backend:
+          serviceName: frontend
+          servicePort: 80
+`, actual)
+}
+
+func TestRenameFilter_Filter_serviceAccount(t *testing.T) {
+	f := &RenameFilter{ResourceKind: "ServiceAccount", Namespace: "app", Name: "runner", NewName: "worker"}
+	actual := rename(t, f, `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: runner
+  namespace: app
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: job
+  namespace: app
+spec:
+  serviceAccountName: runner
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: runner
+roleRef:
+  kind: ClusterRole
+  name: runner
+subjects:
+- kind: ServiceAccount
+  name: runner
+  namespace: app
+- kind: ServiceAccount
+  name: runner
+  namespace: other
+- kind: User
+  name: runner
+`)
+	assert.Equal(t, `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: worker
+  namespace: app
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: job
+  namespace: app
+spec:
+  serviceAccountName: worker
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: runner
+roleRef:
+  kind: ClusterRole
+  name: runner
+subjects:
+- kind: ServiceAccount
+  name: worker
+  namespace: app
+- kind: ServiceAccount
+  name: runner
+  namespace: other
+- kind: User
+  name: runner
+`, actual)
+}
+
+func TestRenameFilter_Filter_notFound(t *testing.T) {
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`)}},
+		Filters: []kio.Filter{&RenameFilter{
+			ResourceKind: "ConfigMap", Namespace: "default", Name: "config", NewName: "settings"}},
+	}.Execute()
+	assert.EqualError(t, err, "ConfigMap default/config not found")
+}
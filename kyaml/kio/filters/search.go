@@ -0,0 +1,230 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SearchMatch is a field matched by a SearchFilter
+type SearchMatch struct {
+	ApiVersion string `yaml:"apiVersion,omitempty" json:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Namespace  string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Name       string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// File is the file the Resource was read from, if known
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// Line is the line of the field in File
+	Line int `yaml:"line,omitempty" json:"line,omitempty"`
+
+	// Path is the path to the field -- e.g. spec.template.spec.containers[name=nginx].image
+	Path string `yaml:"path" json:"path"`
+
+	// Value is the value of the field
+	Value string `yaml:"value" json:"value"`
+}
+
+// SearchFilter finds the scalar fields of Resources matching a path pattern and
+// value regular expression.  The Resources are not modified.
+//
+// Paths are written as 'field.field', with list elements written as '[name=value]'
+// if the element has a name, and as '[index]' otherwise.  '.' as part of a field name
+// is escaped as '\.'.  Each path segment of the pattern is matched as a glob against
+// the path segment of the field -- e.g. 'spec.*.spec.containers[*].image' -- and
+// '**' matches any number of segments -- e.g. '**.image'.
+type SearchFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Path is the path pattern fields must match.  Matches all fields if empty.
+	Path string `yaml:"path,omitempty"`
+
+	// Value is the regular expression field values must match.  Matches all values
+	// if empty.
+	Value string `yaml:"value,omitempty"`
+
+	// Matches are populated by Filter, in the order of the Resources and fields.
+	Matches []SearchMatch `yaml:"matches,omitempty"`
+}
+
+var _ kio.Filter = &SearchFilter{}
+
+func (f *SearchFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Matches = nil
+	var pattern []string
+	if f.Path != "" {
+		pattern = searchPath(f.Path)
+		for _, p := range pattern {
+			if _, err := path.Match(elementContents(p), ""); err != nil {
+				return nil, fmt.Errorf("invalid path pattern '%s': %v", f.Path, err)
+			}
+		}
+	}
+	var reg *regexp.Regexp
+	if f.Value != "" {
+		var err error
+		if reg, err = regexp.Compile(f.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range slice {
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		searchNode(slice[i].YNode(), nil, nil, func(node, _ *yaml.Node, p []string) {
+			if pattern != nil && !matchSearchPath(pattern, p) {
+				return
+			}
+			if reg != nil && !reg.MatchString(node.Value) {
+				return
+			}
+			f.Matches = append(f.Matches, SearchMatch{
+				ApiVersion: meta.ApiVersion,
+				Kind:       meta.Kind,
+				Namespace:  meta.Namespace,
+				Name:       meta.Name,
+				File:       meta.Annotations[kioutil.PathAnnotation],
+				Line:       node.Line,
+				Path:       joinSearchPath(p),
+				Value:      node.Value,
+			})
+		})
+	}
+	return slice, nil
+}
+
+// searchNode calls fn with each scalar field under node, the map containing the field
+// and the path to the field.  The map is nil for list elements.
+func searchNode(node, parent *yaml.Node, p []string, fn func(*yaml.Node, *yaml.Node, []string)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for i := range node.Content {
+			searchNode(node.Content[i], nil, p, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			// skip the annotations set by the readers
+			if len(p) == 2 && p[0] == "metadata" && p[1] == "annotations" && isReaderAnnotation(key) {
+				continue
+			}
+			searchNode(node.Content[i+1], node, append(p[:len(p):len(p)], key), fn)
+		}
+	case yaml.SequenceNode:
+		for i := range node.Content {
+			elem := fmt.Sprintf("[%d]", i)
+			if n := yaml.NewRNode(node.Content[i]).Field("name"); !yaml.IsFieldEmpty(n) &&
+				n.Value.YNode().Kind == yaml.ScalarNode {
+				elem = "[name=" + n.Value.YNode().Value + "]"
+			}
+			searchNode(node.Content[i], nil, append(p[:len(p):len(p)], elem), fn)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != yaml.NullNodeTag {
+			fn(node, parent, p)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			searchNode(node.Alias, parent, p, fn)
+		}
+	}
+}
+
+// searchPath splits a path into its segments
+func searchPath(p string) []string {
+	var segments []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == '\\' && i+1 < len(p) && p[i+1] == '.':
+			current.WriteByte('.')
+			i++
+		case p[i] == '.':
+			flush()
+		case p[i] == '[':
+			flush()
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				end = len(p) - i - 1
+			}
+			segments = append(segments, p[i:i+end+1])
+			i += end
+		default:
+			current.WriteByte(p[i])
+		}
+	}
+	flush()
+	return segments
+}
+
+// joinSearchPath joins the segments of a path
+func joinSearchPath(p []string) string {
+	var b strings.Builder
+	for i := range p {
+		if strings.HasPrefix(p[i], "[") {
+			b.WriteString(p[i])
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(strings.Replace(p[i], ".", `\.`, -1))
+	}
+	return b.String()
+}
+
+// matchSearchPath returns true if the path segments match the pattern segments
+func matchSearchPath(pattern, p []string) bool {
+	if len(pattern) == 0 {
+		return len(p) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(p); i++ {
+			if matchSearchPath(pattern[1:], p[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(p) == 0 {
+		return false
+	}
+	if strings.HasPrefix(pattern[0], "[") != strings.HasPrefix(p[0], "[") {
+		return false
+	}
+	// match the contents of list elements, so '[' isn't read as a character class
+	matched, _ := path.Match(elementContents(pattern[0]), elementContents(p[0]))
+	return matched && matchSearchPath(pattern[1:], p[1:])
+}
+
+// elementContents returns the contents of a list element path segment, or the segment
+// if it isn't a list element
+func elementContents(segment string) string {
+	if strings.HasPrefix(segment, "[") {
+		return strings.TrimSuffix(strings.TrimPrefix(segment, "["), "]")
+	}
+	return segment
+}
+
+// isReaderAnnotation returns true for the annotations set by kio readers
+func isReaderAnnotation(key string) bool {
+	return key == kioutil.IndexAnnotation || key == kioutil.PathAnnotation ||
+		key == kioutil.PackageAnnotation
+}
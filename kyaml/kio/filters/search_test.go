@@ -0,0 +1,135 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const searchInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  annotations:
+    example.com/registry: gcr.io
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: gcr.io/nginx:1.7.9
+        args: [--port, "80"]
+      - name: sidecar
+        image: docker.io/sidecar:1.0
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: backup
+            image: gcr.io/backup:2.0
+`
+
+func search(t *testing.T, f *SearchFilter) []string {
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(searchInput)}},
+		Filters: []kio.Filter{f},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var actual []string
+	for _, m := range f.Matches {
+		actual = append(actual, m.Kind+" "+m.Name+" "+m.Path+"="+m.Value)
+	}
+	return actual
+}
+
+func TestSearchFilter_Filter(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filter   *SearchFilter
+		expected []string
+	}{
+		"path": {
+			filter: &SearchFilter{Path: "spec.template.spec.containers[*].image"},
+			expected: []string{
+				"Deployment nginx spec.template.spec.containers[name=nginx].image=gcr.io/nginx:1.7.9",
+				"Deployment nginx spec.template.spec.containers[name=sidecar].image=docker.io/sidecar:1.0",
+			},
+		},
+		"recursive path": {
+			filter: &SearchFilter{Path: "**.containers[*].image"},
+			expected: []string{
+				"Deployment nginx spec.template.spec.containers[name=nginx].image=gcr.io/nginx:1.7.9",
+				"Deployment nginx spec.template.spec.containers[name=sidecar].image=docker.io/sidecar:1.0",
+				"CronJob backup spec.jobTemplate.spec.template.spec.containers[name=backup].image=gcr.io/backup:2.0",
+			},
+		},
+		"path and value": {
+			filter: &SearchFilter{Path: "**.image", Value: "^gcr\\.io/"},
+			expected: []string{
+				"Deployment nginx spec.template.spec.containers[name=nginx].image=gcr.io/nginx:1.7.9",
+				"CronJob backup spec.jobTemplate.spec.template.spec.containers[name=backup].image=gcr.io/backup:2.0",
+			},
+		},
+		"value": {
+			filter: &SearchFilter{Value: "^gcr\\.io"},
+			expected: []string{
+				"Deployment nginx metadata.annotations.example\\.com/registry=gcr.io",
+				"Deployment nginx spec.template.spec.containers[name=nginx].image=gcr.io/nginx:1.7.9",
+				"CronJob backup spec.jobTemplate.spec.template.spec.containers[name=backup].image=gcr.io/backup:2.0",
+			},
+		},
+		"escaped path": {
+			filter: &SearchFilter{Path: "metadata.annotations.example\\.com/*"},
+			expected: []string{
+				"Deployment nginx metadata.annotations.example\\.com/registry=gcr.io",
+			},
+		},
+		"list index": {
+			filter: &SearchFilter{Path: "**.args[1]"},
+			expected: []string{
+				"Deployment nginx spec.template.spec.containers[name=nginx].args[1]=80",
+			},
+		},
+	} {
+		assert.Equal(t, tc.expected, search(t, tc.filter), name)
+	}
+}
+
+func TestSearchFilter_Filter_location(t *testing.T) {
+	f := &SearchFilter{Path: "**.image", Value: "backup"}
+	search(t, f)
+	if !assert.Len(t, f.Matches, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, SearchMatch{
+		ApiVersion: "batch/v1beta1",
+		Kind:       "CronJob",
+		Name:       "backup",
+		Line:       29,
+		Path:       "spec.jobTemplate.spec.template.spec.containers[name=backup].image",
+		Value:      "gcr.io/backup:2.0",
+	}, f.Matches[0])
+}
+
+func TestSearchFilter_Filter_invalid(t *testing.T) {
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(searchInput)}},
+		Filters: []kio.Filter{&SearchFilter{Path: `spec.a\`}},
+	}.Execute()
+	assert.EqualError(t, err, `invalid path pattern 'spec.a\': syntax error in pattern`)
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SetFieldFilter sets or deletes a field of the Resources selected by Target.
+//
+// The type of Value is inferred as it would be read from yaml -- e.g. 3 is an int, true
+// is a bool and nginx is a string -- unless Tag is set.  Value may also be a flow style
+// list or map -- e.g. [a, b].  Missing parent fields are created.
+//
+// The comments of the field are retained when it is set.  Documents which aren't Resources,
+// such as kustomization files, aren't modified.
+type SetFieldFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Target selects the Resources to modify.  If empty, all Resources are modified.
+	Target PatchTarget `yaml:"target,omitempty"`
+
+	// Path is the path to the field -- e.g. [spec, revisionHistoryLimit], or
+	// [spec, template, spec, containers, "[name=nginx]", image].  See yaml.PathGetter.
+	Path []string `yaml:"path,omitempty"`
+
+	// Value is the value to set.
+	Value string `yaml:"value,omitempty"`
+
+	// Tag is the tag of the value -- e.g. !!str to set a string which would be read as
+	// another type.  Defaults to the inferred tag.
+	Tag string `yaml:"tag,omitempty"`
+
+	// Delete deletes the field rather than setting it.  Path may end with a list element
+	// to delete -- e.g. [spec, containers, "[name=sidecar]"].
+	Delete bool `yaml:"delete,omitempty"`
+
+	// Modified is populated by Filter with the Resources modified.
+	Modified []yaml.ResourceMeta `yaml:"modified,omitempty"`
+}
+
+var _ kio.Filter = &SetFieldFilter{}
+
+func (f *SetFieldFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Modified = nil
+	if len(f.Path) == 0 {
+		return nil, fmt.Errorf("must specify the path of the field")
+	}
+	field := f.Path[len(f.Path)-1]
+	if !f.Delete && yaml.IsListIndex(field) {
+		return nil, fmt.Errorf("cannot set list element %s -- set its fields instead", field)
+	}
+	var value *yaml.Node
+	if !f.Delete {
+		var err error
+		if value, err = f.value(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range slice {
+		if !kio.IsResource(slice[i]) {
+			continue
+		}
+		meta, err := slice[i].GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		matched, err := f.Target.Matches(meta)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		var modified bool
+		if f.Delete {
+			modified, err = f.delete(slice[i])
+		} else {
+			modified, err = f.set(slice[i], yaml.CopyYNode(value))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %v", meta.Kind, meta.Name, err)
+		}
+		if modified {
+			f.Modified = append(f.Modified, meta)
+		}
+	}
+	return slice, nil
+}
+
+// value returns the node for Value
+func (f *SetFieldFilter) value() (*yaml.Node, error) {
+	if f.Value == "" || f.Tag != "" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: f.Value, Tag: f.Tag}, nil
+	}
+	rn, err := yaml.Parse(f.Value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse value '%s': %v", f.Value, err)
+	}
+	node := rn.YNode()
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	return node, nil
+}
+
+// set sets the field to value, and returns true if it was changed
+func (f *SetFieldFilter) set(rn *yaml.RNode, value *yaml.Node) (bool, error) {
+	parent, err := rn.Pipe(yaml.LookupCreate(yaml.MappingNode, f.Path[:len(f.Path)-1]...))
+	if err != nil {
+		return false, err
+	}
+	if parent.YNode().Kind != yaml.MappingNode {
+		return false, fmt.Errorf("%s is not a map", strings.Join(f.Path[:len(f.Path)-1], "."))
+	}
+	name := f.Path[len(f.Path)-1]
+	// don't use RNode.Field, which clears the style of the value
+	var old *yaml.Node
+	for i := 0; i+1 < len(parent.YNode().Content); i += 2 {
+		if parent.YNode().Content[i].Value == name {
+			old = parent.YNode().Content[i+1]
+		}
+	}
+	if old == nil {
+		return true, parent.PipeE(yaml.SetField(name, yaml.NewRNode(value)))
+	}
+
+	if old.Kind == value.Kind && old.Kind == yaml.ScalarNode &&
+		old.Value == value.Value && old.ShortTag() == value.ShortTag() {
+		return false, nil
+	}
+	// keep the comments of the field
+	value.HeadComment, value.LineComment, value.FootComment =
+		old.HeadComment, old.LineComment, old.FootComment
+	// keep the quoting of strings
+	if old.Kind == yaml.ScalarNode && value.Kind == yaml.ScalarNode && value.Style == 0 &&
+		old.ShortTag() == "!!str" && value.ShortTag() == "!!str" {
+		value.Style = old.Style
+	}
+	*old = *value
+	return true, nil
+}
+
+// delete deletes the field, and returns true if it was set
+func (f *SetFieldFilter) delete(rn *yaml.RNode) (bool, error) {
+	parent, err := rn.Pipe(yaml.Lookup(f.Path[:len(f.Path)-1]...))
+	if err != nil || parent == nil {
+		return false, err
+	}
+	name := f.Path[len(f.Path)-1]
+	if !yaml.IsListIndex(name) {
+		if parent.YNode().Kind != yaml.MappingNode {
+			return false, nil
+		}
+		removed, err := parent.Pipe(yaml.Clear(name))
+		return removed != nil, err
+	}
+
+	if parent.YNode().Kind != yaml.SequenceNode {
+		return false, nil
+	}
+	key, value, err := yaml.SplitIndexNameValue(name)
+	if err != nil {
+		return false, err
+	}
+	count := len(parent.YNode().Content)
+	if err := parent.PipeE(yaml.ElementSetter{Key: key, Value: value}); err != nil {
+		return false, err
+	}
+	return len(parent.YNode().Content) < count, nil
+}
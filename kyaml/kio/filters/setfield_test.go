@@ -0,0 +1,125 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const setFieldInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: "nginx:1.16"
+      - name: sidecar
+        image: sidecar
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+func runSetFieldFilter(t *testing.T, f *SetFieldFilter) string {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(setFieldInput)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return out.String()
+}
+
+func TestSetFieldFilter_Filter(t *testing.T) {
+	f := &SetFieldFilter{
+		Target: PatchTarget{Kind: "Deployment"}, Path: []string{"spec", "replicas"}, Value: "3"}
+	assert.Contains(t, runSetFieldFilter(t, f), "  replicas: 3 # scaled by hpa\n")
+	assert.Len(t, f.Modified, 1)
+
+	// the type is inferred
+	f = &SetFieldFilter{Path: []string{"spec", "paused"}, Value: "true",
+		Target: PatchTarget{Kind: "Deployment"}}
+	assert.Contains(t, runSetFieldFilter(t, f), `spec:
+  replicas: 1 # scaled by hpa
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: "nginx:1.16"
+      - name: sidecar
+        image: sidecar
+  paused: true
+`)
+
+	// strings keep their quoting, and may be tagged
+	f = &SetFieldFilter{Value: "nginx:1.17",
+		Path: []string{"spec", "template", "spec", "containers", "[name=nginx]", "image"}}
+	assert.Contains(t, runSetFieldFilter(t, f), `        image: "nginx:1.17"`)
+	f = &SetFieldFilter{Value: "3", Tag: "!!str",
+		Path: []string{"metadata", "annotations", "revision"}}
+	out := runSetFieldFilter(t, f)
+	assert.Contains(t, out, `  name: web
+  annotations:
+    revision: "3"
+spec:`)
+	assert.Len(t, f.Modified, 2)
+
+	// flow style values
+	f = &SetFieldFilter{Value: "[--debug, --verbose]", Target: PatchTarget{Kind: "Deployment"},
+		Path: []string{"spec", "template", "spec", "containers", "[name=sidecar]", "args"}}
+	assert.Contains(t, runSetFieldFilter(t, f), `      - name: sidecar
+        image: sidecar
+        args:
+        - --debug
+        - --verbose
+---`)
+
+	// unchanged values are not modified
+	f = &SetFieldFilter{Value: "1", Path: []string{"spec", "replicas"},
+		Target: PatchTarget{Kind: "Deployment"}}
+	runSetFieldFilter(t, f)
+	assert.Len(t, f.Modified, 0)
+}
+
+func TestSetFieldFilter_Filter_delete(t *testing.T) {
+	f := &SetFieldFilter{Delete: true, Path: []string{"spec", "replicas"}}
+	out := runSetFieldFilter(t, f)
+	assert.NotContains(t, out, "replicas")
+	assert.Len(t, f.Modified, 1)
+
+	f = &SetFieldFilter{Delete: true,
+		Path: []string{"spec", "template", "spec", "containers", "[name=sidecar]"}}
+	out = runSetFieldFilter(t, f)
+	assert.NotContains(t, out, "sidecar")
+	assert.Len(t, f.Modified, 1)
+
+	f = &SetFieldFilter{Delete: true, Path: []string{"spec", "paused"}}
+	runSetFieldFilter(t, f)
+	assert.Len(t, f.Modified, 0)
+}
+
+func TestSetFieldFilter_Filter_errors(t *testing.T) {
+	_, err := (&SetFieldFilter{}).Filter(nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, "must specify the path of the field", err.Error())
+	}
+	_, err = (&SetFieldFilter{Path: []string{"spec", "containers", "[name=a]"}, Value: "a"}).Filter(nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "cannot set list element [name=a]")
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Setters are declared on fields with a line comment containing the OpenAPI for
+// the field.  A setter sets the whole field value:
+//
+//	replicas: 3 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"3"}}}
+//
+// While partial setters each set a substring of the field value:
+//
+//	image: nginx:1.7.9 # {"x-kustomize":{"partialSetters":[{"name":"image","value":"nginx"},{"name":"tag","value":"1.7.9"}]}}
+const (
+	kustomizeExtension = "x-kustomize"
+	setterKey          = "setter"
+	partialSettersKey  = "partialSetters"
+)
+
+// Setter is a setter declared in the Resources
+type Setter struct {
+	// Name is the name of the setter
+	Name string `yaml:"name" json:"name"`
+
+	// Value is the current value of the setter
+	Value string `yaml:"value" json:"value"`
+
+	// Type is the OpenAPI type of the fields set by the setter, if any
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// Partial is true if the setter sets part of the field values
+	Partial bool `yaml:"partial,omitempty" json:"partial,omitempty"`
+
+	// Count is the number of fields set by the setter
+	Count int `yaml:"count" json:"count"`
+}
+
+// ListSettersFilter lists the setters declared in the Resources.  The Resources are
+// not modified.
+type ListSettersFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Name, if set, only lists the setter with this name
+	Name string `yaml:"name,omitempty"`
+
+	// Setters are populated by Filter, sorted by name and value.  Setters which have
+	// different values on different fields are listed once for each value.
+	Setters []Setter `yaml:"setters,omitempty"`
+}
+
+var _ kio.Filter = &ListSettersFilter{}
+
+func (f *ListSettersFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Setters = nil
+	index := map[string]int{}
+	err := visitSetters(slice, func(node *yaml.Node, s declaredSetter, _ map[string]interface{}) error {
+		if f.Name != "" && s.Name != f.Name {
+			return nil
+		}
+		key := s.Name + "=" + s.Value
+		if i, found := index[key]; found {
+			f.Setters[i].Count++
+			return nil
+		}
+		index[key] = len(f.Setters)
+		f.Setters = append(f.Setters, Setter{
+			Name: s.Name, Value: s.Value, Type: s.Type, Partial: s.Partial, Count: 1})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(f.Setters, func(i, j int) bool {
+		if f.Setters[i].Name != f.Setters[j].Name {
+			return f.Setters[i].Name < f.Setters[j].Name
+		}
+		return f.Setters[i].Value < f.Setters[j].Value
+	})
+	return slice, nil
+}
+
+// SetFilter sets the value of a setter, substituting the value into every field the
+// setter is declared on.
+type SetFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Name is the name of the setter to set
+	Name string `yaml:"name,omitempty"`
+
+	// Value is the new value of the setter
+	Value string `yaml:"value,omitempty"`
+
+	// Count is populated by Filter with the number of fields set
+	Count int `yaml:"count,omitempty"`
+}
+
+var _ kio.Filter = &SetFilter{}
+
+func (f *SetFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Count = 0
+	err := visitSetters(slice, func(node *yaml.Node, s declaredSetter, meta map[string]interface{}) error {
+		if s.Name != f.Name {
+			return nil
+		}
+		if s.Partial {
+			if s.Value == "" || !strings.Contains(node.Value, s.Value) {
+				return fmt.Errorf("field value '%s' does not contain the value '%s' of setter %s",
+					node.Value, s.Value, s.Name)
+			}
+			node.Value = strings.Replace(node.Value, s.Value, f.Value, 1)
+		} else {
+			tag, err := setterTag(s.Type, f.Value)
+			if err != nil {
+				return fmt.Errorf("setter %s: %v", s.Name, err)
+			}
+			node.Value = f.Value
+			node.Tag = tag
+		}
+		s.def["value"] = f.Value
+		b, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+		node.LineComment = "# " + string(b)
+		f.Count++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.Count == 0 {
+		return nil, fmt.Errorf("setter %s not found", f.Name)
+	}
+	return slice, nil
+}
+
+// setterTag returns the tag for a field with the OpenAPI type set to value
+func setterTag(t, value string) (string, error) {
+	var err error
+	var tag string
+	switch t {
+	case "integer":
+		_, err = strconv.ParseInt(value, 0, 64)
+		tag = "!!int"
+	case "number":
+		_, err = strconv.ParseFloat(value, 64)
+		tag = "!!float"
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+		tag = "!!bool"
+	case "string":
+		tag = "!!str"
+	default:
+		// untyped fields are resolved from the value
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("value '%s' is not of type %s", value, t)
+	}
+	return tag, nil
+}
+
+// declaredSetter is a setter declared on a field
+type declaredSetter struct {
+	Name    string
+	Value   string
+	Type    string
+	Partial bool
+	// def is the setter definition parsed from the field comment
+	def map[string]interface{}
+}
+
+// visitSetters calls fn for each setter declared on a scalar field of the Resources,
+// with the parsed field comment.  Changes fn makes to the setter definition are
+// reflected in the field comment.
+func visitSetters(slice []*yaml.RNode,
+	fn func(*yaml.Node, declaredSetter, map[string]interface{}) error) error {
+	for i := range slice {
+		if err := visitSetterNodes(slice[i].YNode(), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func visitSetterNodes(node *yaml.Node,
+	fn func(*yaml.Node, declaredSetter, map[string]interface{}) error) error {
+	if node.Kind != yaml.ScalarNode {
+		for i := range node.Content {
+			if err := visitSetterNodes(node.Content[i], fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	c := strings.TrimSpace(strings.TrimPrefix(node.LineComment, "#"))
+	if !strings.HasPrefix(c, "{") {
+		return nil
+	}
+	meta := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(c), &meta); err != nil {
+		// not a field definition
+		return nil
+	}
+	ext, ok := meta[kustomizeExtension].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	t, _ := meta["type"].(string)
+
+	if def, ok := ext[setterKey].(map[string]interface{}); ok {
+		if err := fn(node, newDeclaredSetter(def, t, false), meta); err != nil {
+			return err
+		}
+	}
+	partials, _ := ext[partialSettersKey].([]interface{})
+	for i := range partials {
+		if def, ok := partials[i].(map[string]interface{}); ok {
+			if err := fn(node, newDeclaredSetter(def, t, true), meta); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newDeclaredSetter(def map[string]interface{}, t string, partial bool) declaredSetter {
+	s := declaredSetter{Type: t, Partial: partial, def: def}
+	s.Name, _ = def["name"].(string)
+	s.Value, _ = def["value"].(string)
+	return s
+}
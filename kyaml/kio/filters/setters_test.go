@@ -0,0 +1,135 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const settersInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx # {"x-kustomize":{"partialSetters":[{"name":"name","value":"nginx"}]}}
+spec:
+  replicas: 3 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"3"}}}
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7.9 # {"x-kustomize":{"partialSetters":[{"name":"image","value":"nginx"},{"name":"tag","value":"1.7.9"}]}}
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: nginx-db # {"x-kustomize":{"partialSetters":[{"name":"name","value":"nginx"}]}}
+spec:
+  replicas: 1 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"1"}}}
+`
+
+func TestListSettersFilter_Filter(t *testing.T) {
+	f := &ListSettersFilter{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(settersInput)}},
+		Filters: []kio.Filter{f},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []Setter{
+		{Name: "image", Value: "nginx", Partial: true, Count: 1},
+		{Name: "name", Value: "nginx", Partial: true, Count: 2},
+		{Name: "replicas", Value: "1", Type: "integer", Count: 1},
+		{Name: "replicas", Value: "3", Type: "integer", Count: 1},
+		{Name: "tag", Value: "1.7.9", Partial: true, Count: 1},
+	}, f.Setters)
+}
+
+func TestSetFilter_Filter(t *testing.T) {
+	out := &bytes.Buffer{}
+	replicas := &SetFilter{Name: "replicas", Value: "5"}
+	name := &SetFilter{Name: "name", Value: "web"}
+	tag := &SetFilter{Name: "tag", Value: "1.17"}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(settersInput)}},
+		Filters: []kio.Filter{replicas, name, tag},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, replicas.Count)
+	assert.Equal(t, 2, name.Count)
+	assert.Equal(t, 1, tag.Count)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web # {"x-kustomize":{"partialSetters":[{"name":"name","value":"web"}]}}
+spec:
+  replicas: 5 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"5"}}}
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.17 # {"x-kustomize":{"partialSetters":[{"name":"image","value":"nginx"},{"name":"tag","value":"1.17"}]}}
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: web-db # {"x-kustomize":{"partialSetters":[{"name":"name","value":"web"}]}}
+spec:
+  replicas: 5 # {"type":"integer","x-kustomize":{"setter":{"name":"replicas","value":"5"}}}
+`, out.String())
+}
+
+func TestSetFilter_Filter_string(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+data:
+  port: bar # {"type":"string","x-kustomize":{"setter":{"name":"port","value":"bar"}}}
+`)}},
+		Filters: []kio.Filter{&SetFilter{Name: "port", Value: "8080"}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+data:
+  port: "8080" # {"type":"string","x-kustomize":{"setter":{"name":"port","value":"8080"}}}
+`, out.String())
+}
+
+func TestSetFilter_Filter_errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filter   *SetFilter
+		expected string
+	}{
+		"not found": {
+			filter:   &SetFilter{Name: "foo", Value: "bar"},
+			expected: "setter foo not found",
+		},
+		"wrong type": {
+			filter:   &SetFilter{Name: "replicas", Value: "many"},
+			expected: "setter replicas: value 'many' is not of type integer",
+		},
+	} {
+		err := kio.Pipeline{
+			Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(settersInput)}},
+			Filters: []kio.Filter{tc.filter},
+		}.Execute()
+		assert.EqualError(t, err, tc.expected, name)
+	}
+}
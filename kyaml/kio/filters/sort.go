@@ -0,0 +1,204 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SortFilter orders Resources so that they may be safely applied in order --
+// e.g. Namespaces and CustomResourceDefinitions are first, and webhooks are last.
+// Resources of the same kind are ordered by apiVersion, namespace and name.
+//
+// Documents which aren't Resources, such as kustomization files, keep their position,
+// and the Resources are ordered around them.
+type SortFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// OwnerReferences will additionally order Resources after the owners listed in
+	// their metadata.ownerReferences.
+	OwnerReferences bool `yaml:"ownerReferences,omitempty"`
+}
+
+var _ kio.Filter = SortFilter{}
+
+// orderFirst are the kinds which are applied first, in order.
+// Mirrors the kustomize resource ordering.
+var orderFirst = []string{
+	"Namespace",
+	"ResourceQuota",
+	"StorageClass",
+	"CustomResourceDefinition",
+	"MutatingWebhookConfiguration",
+	"ServiceAccount",
+	"PodSecurityPolicy",
+	"Role",
+	"ClusterRole",
+	"RoleBinding",
+	"ClusterRoleBinding",
+	"ConfigMap",
+	"Secret",
+	"Service",
+	"LimitRange",
+	"PriorityClass",
+	"Deployment",
+	"StatefulSet",
+	"CronJob",
+	"PodDisruptionBudget",
+}
+
+// orderLast are the kinds which are applied last, in order.
+var orderLast = []string{
+	"ValidatingWebhookConfiguration",
+}
+
+var kindOrder = func() map[string]int {
+	m := map[string]int{}
+	for i, n := range orderFirst {
+		m[n] = -len(orderFirst) + i
+	}
+	for i, n := range orderLast {
+		m[n] = 1 + i
+	}
+	return m
+}()
+
+func (s SortFilter) Filter(input []*yaml.RNode) ([]*yaml.RNode, error) {
+	keys := map[*yaml.RNode]mergeKey{}
+	var resources []*yaml.RNode
+	for i := range input {
+		meta, err := input[i].GetMeta()
+		if err != nil && err != yaml.ErrMissingMetadata {
+			return nil, err
+		}
+		if !kio.IsResource(input[i]) {
+			continue
+		}
+		keys[input[i]] = mergeKey{
+			apiVersion: meta.ApiVersion,
+			kind:       meta.Kind,
+			namespace:  meta.Namespace,
+			name:       meta.Name,
+		}
+		resources = append(resources, input[i])
+	}
+
+	sorted := append([]*yaml.RNode{}, resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, kj := keys[sorted[i]], keys[sorted[j]]
+		if kindOrder[ki.kind] != kindOrder[kj.kind] {
+			return kindOrder[ki.kind] < kindOrder[kj.kind]
+		}
+		if ki.kind != kj.kind {
+			return ki.kind < kj.kind
+		}
+		if ki.apiVersion != kj.apiVersion {
+			return ki.apiVersion < kj.apiVersion
+		}
+		if ki.namespace != kj.namespace {
+			return ki.namespace < kj.namespace
+		}
+		return ki.name < kj.name
+	})
+
+	if s.OwnerReferences {
+		var err error
+		if sorted, err = sortOwners(sorted, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	// put the sorted Resources in the positions of the Resources
+	output := append([]*yaml.RNode{}, input...)
+	next := 0
+	for i := range output {
+		if _, found := keys[output[i]]; found {
+			output[i] = sorted[next]
+			next++
+		}
+	}
+	return output, nil
+}
+
+// ownerReference is the subset of a metadata.ownerReferences element used for ordering
+type ownerReference struct {
+	ApiVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+}
+
+// sortOwners moves Resources after their owners, otherwise keeping the nodes in order.
+// Resources which are part of an ownership cycle are kept in order.
+func sortOwners(nodes []*yaml.RNode, keys map[*yaml.RNode]mergeKey) ([]*yaml.RNode, error) {
+	index := map[mergeKey]*yaml.RNode{}
+	for _, n := range nodes {
+		index[keys[n]] = n
+	}
+
+	// find the owners of each Resource which are present in the input
+	owners := map[*yaml.RNode][]*yaml.RNode{}
+	for _, n := range nodes {
+		refs, err := n.Pipe(yaml.Lookup("metadata", "ownerReferences"))
+		if err != nil {
+			return nil, err
+		}
+		if refs == nil {
+			continue
+		}
+		var o []ownerReference
+		if err := refs.YNode().Decode(&o); err != nil {
+			return nil, err
+		}
+		for _, ref := range o {
+			key := mergeKey{apiVersion: ref.ApiVersion, kind: ref.Kind, name: ref.Name}
+			// owners are either cluster scoped, or in the same namespace as the Resource
+			owner, found := index[key]
+			if !found {
+				key.namespace = keys[n].namespace
+				owner, found = index[key]
+			}
+			if found && owner != n {
+				owners[n] = append(owners[n], owner)
+			}
+		}
+	}
+
+	// repeatedly emit the first Resource whose owners have all been emitted
+	done := map[*yaml.RNode]bool{}
+	var output []*yaml.RNode
+	for len(output) < len(nodes) {
+		next := -1
+		for i, n := range nodes {
+			if done[n] {
+				continue
+			}
+			ready := true
+			for _, o := range owners[n] {
+				if !done[o] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// cycle -- emit the first remaining Resource
+			for i, n := range nodes {
+				if !done[n] {
+					next = i
+					break
+				}
+			}
+		}
+		done[nodes[next]] = true
+		output = append(output, nodes[next])
+	}
+	return output, nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+func TestSortFilter_Filter(t *testing.T) {
+	in := `apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: webhook
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+  namespace: foo
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: a
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: a
+  namespace: foo
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: foo
+`
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(in)}},
+		Filters: []kio.Filter{SortFilter{}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, `apiVersion: v1
+kind: Namespace
+metadata:
+  name: foo
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: a
+  namespace: foo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+  namespace: foo
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: a
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: webhook
+`, out.String())
+}
+
+func TestSortFilter_Filter_ownerReferences(t *testing.T) {
+	in := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: owned
+  namespace: foo
+  ownerReferences:
+  - apiVersion: example.com/v1
+    kind: Widget
+    name: owner
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: other
+  namespace: foo
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: owner
+  namespace: foo
+`
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(in)}},
+		Filters: []kio.Filter{SortFilter{OwnerReferences: true}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: other
+  namespace: foo
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: owner
+  namespace: foo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: owned
+  namespace: foo
+  ownerReferences:
+  - apiVersion: example.com/v1
+    kind: Widget
+    name: owner
+`, out.String())
+}
+
+func TestSortFilter_Filter_nonResources(t *testing.T) {
+	in := `apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+resources:
+- service.yaml
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: foo
+`
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(in)}},
+		Filters: []kio.Filter{SortFilter{}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the kustomization keeps its position, the Resources are sorted around it
+	assert.Equal(t, `apiVersion: v1
+kind: Namespace
+metadata:
+  name: foo
+---
+resources:
+- service.yaml
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`, out.String())
+}
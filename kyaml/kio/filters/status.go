@@ -0,0 +1,205 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Condition is an element of a Resource's status.conditions.
+type Condition struct {
+	Type               string `yaml:"type,omitempty"`
+	Status             string `yaml:"status,omitempty"`
+	Reason             string `yaml:"reason,omitempty"`
+	Message            string `yaml:"message,omitempty"`
+	LastTransitionTime string `yaml:"lastTransitionTime,omitempty"`
+}
+
+const (
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+)
+
+// Readiness is the readiness of a Resource, evaluated from its Conditions.
+type Readiness string
+
+const (
+	// Ready Resources have reconciled successfully -- e.g. an Available Deployment.
+	Ready Readiness = "Ready"
+	// NotReady Resources are still reconciling.
+	NotReady Readiness = "NotReady"
+	// Failed Resources will not become ready without intervention -- e.g. a Failed Job.
+	Failed Readiness = "Failed"
+	// UnknownReadiness Resources have no Conditions from which to evaluate readiness.
+	UnknownReadiness Readiness = "Unknown"
+)
+
+// ReadinessRule evaluates the Readiness of a Resource from its Conditions.
+type ReadinessRule func(rn *yaml.RNode, conditions []Condition) Readiness
+
+// ReadinessRules are the ReadinessRules for specific types, indexed by kind and group --
+// e.g. 'Deployment.apps', or 'Pod' for the core group.  Types without a rule use
+// DefaultReadinessRule.  Rules may be added or replaced to support additional types.
+var ReadinessRules = map[string]ReadinessRule{
+	"Deployment.apps":       deploymentReadiness,
+	"Deployment.extensions": deploymentReadiness,
+	"Job.batch":             jobReadiness,
+}
+
+// ResourceStatus is the status of a Resource.
+type ResourceStatus struct {
+	ApiVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+
+	// Conditions are the Conditions read from status.conditions.
+	Conditions []Condition
+
+	// Readiness is the Readiness evaluated from Conditions.
+	Readiness Readiness
+
+	// Resource is the Resource the status was read from.
+	Resource *yaml.RNode
+}
+
+// StatusFilter reads the status Conditions of Resources and evaluates their Readiness.
+// Resources are not modified.
+type StatusFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Rules override ReadinessRules for this filter.
+	Rules map[string]ReadinessRule `yaml:"-"`
+
+	// Statuses is populated by Filter with the status of each Resource in input order.
+	Statuses []ResourceStatus `yaml:"-"`
+}
+
+var _ kio.Filter = &StatusFilter{}
+
+func (f *StatusFilter) Filter(input []*yaml.RNode) ([]*yaml.RNode, error) {
+	f.Statuses = nil
+	for i := range input {
+		s, err := f.GetStatus(input[i])
+		if err != nil {
+			return nil, err
+		}
+		f.Statuses = append(f.Statuses, s)
+	}
+	return input, nil
+}
+
+// GetStatus returns the status of a single Resource.
+func (f *StatusFilter) GetStatus(rn *yaml.RNode) (ResourceStatus, error) {
+	meta, err := rn.GetMeta()
+	if err != nil {
+		return ResourceStatus{}, err
+	}
+	conditions, err := GetConditions(rn)
+	if err != nil {
+		return ResourceStatus{}, err
+	}
+
+	rule, found := f.Rules[groupKind(meta)]
+	if !found {
+		rule, found = ReadinessRules[groupKind(meta)]
+	}
+	if !found {
+		rule = DefaultReadinessRule
+	}
+
+	return ResourceStatus{
+		ApiVersion: meta.ApiVersion,
+		Kind:       meta.Kind,
+		Namespace:  meta.Namespace,
+		Name:       meta.Name,
+		Conditions: conditions,
+		Readiness:  rule(rn, conditions),
+		Resource:   rn,
+	}, nil
+}
+
+// GetConditions returns the Conditions from the Resource's status.conditions.
+func GetConditions(rn *yaml.RNode) ([]Condition, error) {
+	c, err := rn.Pipe(yaml.Lookup("status", "conditions"))
+	if err != nil || c == nil {
+		return nil, err
+	}
+	var conditions []Condition
+	if err := c.YNode().Decode(&conditions); err != nil {
+		return nil, err
+	}
+	return conditions, nil
+}
+
+// GetCondition returns the Condition with the type, or nil if it is not present.
+func GetCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// DefaultReadinessRule evaluates Readiness using the Ready condition, falling back on the
+// Available condition.  Resources with neither condition have UnknownReadiness.
+func DefaultReadinessRule(_ *yaml.RNode, conditions []Condition) Readiness {
+	c := GetCondition(conditions, "Ready")
+	if c == nil {
+		c = GetCondition(conditions, "Available")
+	}
+	if c == nil {
+		return UnknownReadiness
+	}
+	return conditionReadiness(c)
+}
+
+// conditionReadiness maps a True condition to Ready, and any other status to NotReady
+func conditionReadiness(c *Condition) Readiness {
+	if c.Status == ConditionTrue {
+		return Ready
+	}
+	return NotReady
+}
+
+// deploymentReadiness is Failed if the Deployment has exceeded its progress deadline,
+// otherwise it is Ready if it is Available.
+func deploymentReadiness(_ *yaml.RNode, conditions []Condition) Readiness {
+	if c := GetCondition(conditions, "Progressing"); c != nil &&
+		c.Status == ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+		return Failed
+	}
+	c := GetCondition(conditions, "Available")
+	if c == nil {
+		return UnknownReadiness
+	}
+	return conditionReadiness(c)
+}
+
+// jobReadiness is Ready if the Job is Complete and Failed if it has Failed.  Jobs only
+// have conditions once they finish, so running Jobs with a status are NotReady.
+func jobReadiness(rn *yaml.RNode, conditions []Condition) Readiness {
+	if c := GetCondition(conditions, "Failed"); c != nil && c.Status == ConditionTrue {
+		return Failed
+	}
+	if c := GetCondition(conditions, "Complete"); c != nil && c.Status == ConditionTrue {
+		return Ready
+	}
+	if rn.Field("status") == nil {
+		return UnknownReadiness
+	}
+	return NotReady
+}
+
+// groupKind returns the kind and group of the Resource -- e.g. 'Deployment.apps'
+func groupKind(meta yaml.ResourceMeta) string {
+	group, _ := splitApiVersion(meta.ApiVersion)
+	if group == "" {
+		return meta.Kind
+	}
+	return meta.Kind + "." + group
+}
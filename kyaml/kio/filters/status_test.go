@@ -0,0 +1,133 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestStatusFilter_Filter(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: available
+status:
+  conditions:
+  - type: Available
+    status: "True"
+  - type: Progressing
+    status: "True"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deadline
+status:
+  conditions:
+  - type: Available
+    status: "False"
+  - type: Progressing
+    status: "False"
+    reason: ProgressDeadlineExceeded
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: running
+status:
+  active: 1
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: failed
+status:
+  conditions:
+  - type: Failed
+    status: "True"
+    reason: BackoffLimitExceeded
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+    message: containers with unready status
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+`
+	f := &StatusFilter{}
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(in)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// the Resources are not modified
+	assert.Equal(t, in, out.String())
+
+	var readiness []Readiness
+	for _, s := range f.Statuses {
+		readiness = append(readiness, s.Readiness)
+	}
+	assert.Equal(t, []Readiness{
+		Ready, Failed, NotReady, Failed, NotReady, UnknownReadiness, Ready}, readiness)
+
+	assert.Equal(t, "pod", f.Statuses[4].Name)
+	assert.Equal(t, []Condition{{
+		Type:    "Ready",
+		Status:  ConditionFalse,
+		Message: "containers with unready status",
+	}}, f.Statuses[4].Conditions)
+}
+
+func TestStatusFilter_Filter_rules(t *testing.T) {
+	f := &StatusFilter{Rules: map[string]ReadinessRule{
+		"Widget.example.com": func(_ *yaml.RNode, conditions []Condition) Readiness {
+			if c := GetCondition(conditions, "Synced"); c != nil && c.Status == ConditionTrue {
+				return Ready
+			}
+			return NotReady
+		},
+	}}
+	s, err := f.GetStatus(yaml.MustParse(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+  - type: Synced
+    status: "False"
+`))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, NotReady, s.Readiness)
+}
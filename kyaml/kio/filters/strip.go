@@ -0,0 +1,76 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// StripFilter removes the fields populated by the apiserver from Resources, so that
+// Resource Config read from a cluster can be written back to a package.
+type StripFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Status removes the status field.
+	Status bool `yaml:"status,omitempty"`
+
+	// ManagedFields removes the metadata.managedFields field.
+	ManagedFields bool `yaml:"managedFields,omitempty"`
+
+	// CreationTimestamp removes the metadata.creationTimestamp field, and the
+	// creationTimestamp field from the metadata of pod templates.
+	CreationTimestamp bool `yaml:"creationTimestamp,omitempty"`
+
+	// ResourceVersion removes the metadata.resourceVersion field.
+	ResourceVersion bool `yaml:"resourceVersion,omitempty"`
+
+	// UID removes the metadata.uid field.
+	UID bool `yaml:"uid,omitempty"`
+}
+
+var _ kio.ResourceLocalFilter = StripFilter{}
+
+// ResourceLocal returns true -- the fields of each Resource are removed independently.
+func (f StripFilter) ResourceLocal() bool {
+	return true
+}
+
+func (f StripFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	var metadataFields []string
+	if f.ManagedFields {
+		metadataFields = append(metadataFields, "managedFields")
+	}
+	if f.CreationTimestamp {
+		metadataFields = append(metadataFields, "creationTimestamp")
+	}
+	if f.ResourceVersion {
+		metadataFields = append(metadataFields, "resourceVersion")
+	}
+	if f.UID {
+		metadataFields = append(metadataFields, "uid")
+	}
+
+	for i := range slice {
+		if f.Status {
+			if _, err := slice[i].Pipe(yaml.Clear("status")); err != nil {
+				return nil, err
+			}
+		}
+		for _, field := range metadataFields {
+			if _, err := slice[i].Pipe(yaml.Lookup("metadata"), yaml.Clear(field)); err != nil {
+				return nil, err
+			}
+		}
+		if f.CreationTimestamp {
+			// pod templates are serialized with a null creationTimestamp
+			_, err := slice[i].Pipe(
+				yaml.Lookup("spec", "template", "metadata"), yaml.Clear("creationTimestamp"))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return slice, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+)
+
+const stripInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+  uid: 6c3b1a5e-1f0e-4d8e-9a3b-2a1c5e6f7d8e
+  resourceVersion: "1234"
+  creationTimestamp: "2019-10-26T11:06:19Z"
+  managedFields:
+  - manager: kubectl
+    operation: Apply
+spec:
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app: nginx
+status:
+  replicas: 1
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  uid: 2a1c5e6f-1f0e-4d8e-9a3b-6c3b1a5e7d8e
+`
+
+func TestStripFilter_Filter(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(stripInput)}},
+		Filters: []kio.Filter{StripFilter{
+			Status:            true,
+			ManagedFields:     true,
+			CreationTimestamp: true,
+			ResourceVersion:   true,
+			UID:               true,
+		}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        app: nginx
+---
+apiVersion: v1
+kind: Namespace
+`, out.String())
+}
+
+func TestStripFilter_Filter_some(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(stripInput)}},
+		Filters: []kio.Filter{StripFilter{Status: true, UID: true}},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+  resourceVersion: "1234"
+  creationTimestamp: "2019-10-26T11:06:19Z"
+  managedFields:
+  - manager: kubectl
+    operation: Apply
+spec:
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app: nginx
+---
+apiVersion: v1
+kind: Namespace
+`, out.String())
+}
@@ -10,7 +10,12 @@ import (
 
 type StripCommentsFilter struct{}
 
-var _ kio.Filter = StripCommentsFilter{}
+var _ kio.ResourceLocalFilter = StripCommentsFilter{}
+
+// ResourceLocal returns true -- the comments of each Resource are stripped independently.
+func (f StripCommentsFilter) ResourceLocal() bool {
+	return true
+}
 
 func (f StripCommentsFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
 	for i := range slice {
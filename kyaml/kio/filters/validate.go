@@ -0,0 +1,139 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ValidationAnnotation is set by ValidateFilter to the validation errors of the
+// Resources, one per line, when annotating the Resources.
+const ValidationAnnotation = "config.kubernetes.io/validation"
+
+// ValidateMode configures how ValidateFilter reports the validation errors
+type ValidateMode string
+
+const (
+	// ValidateModeFail fails the Filter for the first invalid Resource, with all of its
+	// errors.  This is the default.
+	ValidateModeFail ValidateMode = ""
+
+	// ValidateModeAnnotate sets the ValidationAnnotation of the invalid Resources to their
+	// errors, and clears it from the valid Resources, so that the Resources are written
+	// with their results.
+	ValidateModeAnnotate ValidateMode = "annotate"
+)
+
+// readerAnnotations are the annotations set by kio Readers -- they aren't part of the
+// Resources, so they aren't validated.
+var readerAnnotations = []string{
+	kioutil.IndexAnnotation,
+	kioutil.PathAnnotation,
+	kioutil.PackageAnnotation,
+	kioutil.URLAnnotation,
+}
+
+// ValidateFilter validates each Resource against the Schema of its apiVersion and kind,
+// so that the input of a Pipeline may be validated as it is read.  Resources without a
+// Schema aren't validated.  See openapi.Validate for the errors reported.
+type ValidateFilter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Mode configures how the validation errors are reported.  Defaults to
+	// ValidateModeFail.
+	Mode ValidateMode `yaml:"mode,omitempty"`
+
+	// Schemas are the Schemas to validate the Resources against -- e.g. the built-in
+	// Kubernetes Schemas with the Schemas of the CustomResourceDefinitions added.
+	Schemas openapi.Schemas `yaml:"-"`
+}
+
+var _ kio.ResourceLocalFilter = ValidateFilter{}
+
+// ResourceLocal returns true -- each Resource is validated independently.
+func (f ValidateFilter) ResourceLocal() bool {
+	return true
+}
+
+func (f ValidateFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode, error) {
+	if f.Mode != ValidateModeFail && f.Mode != ValidateModeAnnotate {
+		return nil, errors.Errorf("unknown validate mode %q", f.Mode)
+	}
+	for i := range slice {
+		errs, err := f.validate(slice[i])
+		if err != nil {
+			return nil, err
+		}
+		if f.Mode == ValidateModeAnnotate {
+			if err := annotateValidation(slice[i], errs); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			return nil, validationError(slice[i], errs)
+		}
+	}
+	return slice, nil
+}
+
+// validate returns the sorted validation errors of the Resource
+func (f ValidateFilter) validate(rn *yaml.RNode) ([]openapi.ValidationError, error) {
+	meta, err := rn.GetMeta()
+	if err != nil {
+		// not a Resource
+		return nil, nil
+	}
+	s := f.Schemas.Lookup(meta)
+	if s == nil {
+		return nil, nil
+	}
+	// validate a copy without the annotations which aren't part of the Resource, since
+	// the Resource itself is written
+	rn = rn.Copy()
+	for _, a := range append(readerAnnotations, ValidationAnnotation) {
+		if err := rn.PipeE(yaml.ClearAnnotation(a)); err != nil {
+			return nil, err
+		}
+	}
+	errs := openapi.Validate(rn, s)
+	openapi.SortErrors(errs)
+	return errs, nil
+}
+
+// annotateValidation sets the ValidationAnnotation of the Resource to the errors, or
+// clears it if there are none
+func annotateValidation(rn *yaml.RNode, errs []openapi.ValidationError) error {
+	if len(errs) == 0 {
+		return rn.PipeE(yaml.ClearAnnotation(ValidationAnnotation))
+	}
+	lines := make([]string, len(errs))
+	for i := range errs {
+		lines[i] = errs[i].Error()
+	}
+	return rn.PipeE(yaml.SetAnnotation(ValidationAnnotation, strings.Join(lines, "\n")))
+}
+
+// validationError returns an error for the validation errors of the Resource,
+// formatted as the validate command prints them.
+func validationError(rn *yaml.RNode, errs []openapi.ValidationError) error {
+	meta, _ := rn.GetMeta()
+	path := meta.Annotations[kioutil.PathAnnotation]
+	if path == "" {
+		path = "stdin"
+	}
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = fmt.Sprintf("%s:%d:%d: %s %s: %s: %s",
+			path, e.Line, e.Column, meta.Kind, meta.Name, e.Path, e.Message)
+	}
+	return errors.Errorf("%s %s is invalid:\n%s", meta.Kind, meta.Name, strings.Join(lines, "\n"))
+}
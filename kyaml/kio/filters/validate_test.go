@@ -0,0 +1,122 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	. "sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const validateCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  version: v1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            replicas:
+              type: integer
+`
+
+const validateInput = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: valid
+spec:
+  replicas: 1
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: invalid
+  annotations:
+    config.kubernetes.io/validation: stale
+spec:
+  replicas: "two"
+  size: large
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: unknown
+spec:
+  anything: goes
+`
+
+func runValidateFilter(t *testing.T, f ValidateFilter) (string, error) {
+	s := openapi.Schemas{}
+	if !assert.NoError(t, s.AddCRDs(yaml.MustParse(validateCRD))) {
+		t.FailNow()
+	}
+	f.Schemas = s
+	out := &bytes.Buffer{}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.ByteReader{Reader: bytes.NewBufferString(validateInput)}},
+		Filters: []kio.Filter{f},
+		Outputs: []kio.Writer{kio.ByteWriter{Writer: out}},
+	}.Execute()
+	return out.String(), err
+}
+
+func TestValidateFilter_fail(t *testing.T) {
+	_, err := runValidateFilter(t, ValidateFilter{})
+	assert.EqualError(t, err, `Widget invalid is invalid:
+stdin:15:13: Widget invalid: .spec.replicas: expected integer, found string
+stdin:16:3: Widget invalid: .spec.size: unknown field`)
+}
+
+func TestValidateFilter_annotate(t *testing.T) {
+	out, err := runValidateFilter(t, ValidateFilter{Mode: ValidateModeAnnotate})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the reader annotations and the previous results aren't validated, and the
+	// Resources without schemas aren't annotated
+	assert.Equal(t, `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: valid
+spec:
+  replicas: 1
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: invalid
+  annotations:
+    config.kubernetes.io/validation: |-
+      15:13: .spec.replicas: expected integer, found string
+      16:3: .spec.size: unknown field
+spec:
+  replicas: "two"
+  size: large
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: unknown
+spec:
+  anything: goes
+`, out)
+}
+
+func TestValidateFilter_mode(t *testing.T) {
+	_, err := runValidateFilter(t, ValidateFilter{Mode: "warn"})
+	assert.EqualError(t, err, `unknown validate mode "warn"`)
+}
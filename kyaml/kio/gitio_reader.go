@@ -0,0 +1,291 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// gitURLPrefixes are the prefixes of the URLs read by GitReader
+var gitURLPrefixes = []string{"https://", "http://", "ssh://", "git://", "file://", "git@"}
+
+// IsGitURL returns true if the package path is the URL of a git repository, rather than
+// a local directory -- e.g. https://github.com/org/repo//manifests?ref=v1.2.3
+func IsGitURL(path string) bool {
+	for _, p := range gitURLPrefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGitURL parses a git URL into the URL of the repository, the package directory
+// within the repository and the ref to read.  The URLs are written as they are for
+// kustomize remote bases -- the directory follows the repository after a "//", or after
+// ".git/", and the ref is the ref query parameter:
+//
+//	https://github.com/org/repo//manifests?ref=v1.2.3
+//	git@github.com:org/repo.git/manifests?ref=main
+func ParseGitURL(gitURL string) (repo, dir, ref string, err error) {
+	if !IsGitURL(gitURL) {
+		return "", "", "", errors.Errorf("not a git URL: %s", gitURL)
+	}
+	repo = gitURL
+	if i := strings.Index(repo, "?"); i >= 0 {
+		query, err := url.ParseQuery(repo[i+1:])
+		if err != nil {
+			return "", "", "", errors.WrapPrefixf(err, "invalid git URL %s", gitURL)
+		}
+		repo, ref = repo[:i], query.Get("ref")
+	}
+
+	// the "//" of the scheme doesn't separate the directory
+	scheme := ""
+	if i := strings.Index(repo, "://"); i >= 0 {
+		scheme, repo = repo[:i+3], repo[i+3:]
+	}
+	if i := strings.Index(repo, "//"); i >= 0 {
+		repo, dir = repo[:i], repo[i+2:]
+	} else if i := strings.Index(repo, ".git/"); i >= 0 {
+		repo, dir = repo[:i+4], repo[i+5:]
+	}
+	dir = strings.Trim(dir, "/")
+	if strings.Contains(filepath.Clean(dir), "..") {
+		return "", "", "", errors.Errorf("git URL directory must be within the repository: %s", gitURL)
+	}
+	repo = scheme + strings.TrimSuffix(repo, "/")
+	if repo == scheme {
+		return "", "", "", errors.Errorf("git URL must specify a repository: %s", gitURL)
+	}
+	if err := checkGitArgs(repo, ref); err != nil {
+		return "", "", "", errors.WrapPrefixf(err, "invalid git URL %s", gitURL)
+	}
+	return repo, dir, ref, nil
+}
+
+// checkGitArgs returns an error if the repository or ref would be read as an option by
+// the git command -- e.g. a ref '--upload-pack=...' running a command
+func checkGitArgs(repo, ref string) error {
+	if strings.HasPrefix(repo, "-") {
+		return errors.Errorf("repository must not start with '-': %s", repo)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return errors.Errorf("ref must not start with '-': %s", ref)
+	}
+	return nil
+}
+
+// GitReader reads the Resources of a package from a git repository.  The repository is
+// cloned at the ref using the git command, and the Resources are read from the package
+// directory as LocalPackageReader reads them -- annotated with their path relative to the
+// package directory.
+type GitReader struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// URL is the git URL of the package, as parsed by ParseGitURL.
+	URL string `yaml:"url,omitempty"`
+
+	// Ref is the branch, tag or commit to read.  Overrides the ref of the URL.  Defaults
+	// to the default branch of the repository.
+	Ref string `yaml:"ref,omitempty"`
+
+	// CacheDir is a directory where the clones of the repositories are kept, and reused
+	// the next time the same repository and ref are read.  A cached clone isn't updated --
+	// e.g. if read at a branch -- delete it to read the repository again.  If unset, the
+	// repository is cloned into a temporary directory which is deleted after reading.
+	CacheDir string `yaml:"cacheDir,omitempty"`
+
+	// PackageFileName is the name of file containing package metadata.
+	// It will be used to identify package.
+	PackageFileName string `yaml:"packageFileName,omitempty"`
+
+	// MatchFilesGlob configures Read to only read Resources from files matching any of the
+	// provided patterns.
+	// Defaults to ["*.yaml", "*.yml"] if empty.  To match all files specify ["*"].
+	MatchFilesGlob []string `yaml:"matchFilesGlob,omitempty"`
+
+	// IncludeSubpackages will configure Read to read Resources from subpackages.
+	// Subpackages are identified by presence of PackageFileName.
+	IncludeSubpackages bool `yaml:"includeSubpackages,omitempty"`
+
+	// OmitReaderAnnotations will cause the reader to skip annotating Resources with the file
+	// path and mode.
+	OmitReaderAnnotations bool `yaml:"omitReaderAnnotations,omitempty"`
+
+	// SetAnnotations are annotations to set on the Resources as they are read.
+	SetAnnotations map[string]string `yaml:"setAnnotations,omitempty"`
+
+	// ParseMode configures how the Resources are parsed.  Defaults to ParseModePreserve.
+	ParseMode ParseMode `yaml:"parseMode,omitempty"`
+
+	// AliasMode configures how anchors and aliases are handled.  Defaults to
+	// AliasModePreserve.
+	AliasMode AliasMode `yaml:"aliasMode,omitempty"`
+
+	// DuplicateKeyMode configures how duplicate keys are handled.  Defaults to
+	// DuplicateKeyModeError.
+	DuplicateKeyMode DuplicateKeyMode `yaml:"duplicateKeyMode,omitempty"`
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer `yaml:"-"`
+
+	// Limits guard reading against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits `yaml:"limits,omitempty"`
+}
+
+var _ Reader = GitReader{}
+
+// withParseMode returns a copy of the GitReader configured to use the ParseMode
+func (r GitReader) withParseMode(mode ParseMode) Reader {
+	r.ParseMode = mode
+	return r
+}
+
+// withReadPolicy returns a copy of the GitReader configured to use the AliasMode and
+// DuplicateKeyMode
+func (r GitReader) withReadPolicy(aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns a copy of the GitReader configured to write warnings to w, unless
+// it sets its own
+func (r GitReader) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// withLimits returns a copy of the GitReader configured to use the Limits, unless it sets
+// its own
+func (r GitReader) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
+
+// Read reads the Resources.
+func (r GitReader) Read() ([]*yaml.RNode, error) {
+	if r.URL == "" {
+		return nil, fmt.Errorf("must specify git URL")
+	}
+	repo, dir, ref, err := ParseGitURL(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	if r.Ref != "" {
+		ref = r.Ref
+	}
+
+	clone, cleanup, err := r.clone(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	path := filepath.Join(clone, filepath.FromSlash(dir))
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.Errorf("directory %s not found in %s", dir, repo)
+	}
+	return LocalPackageReader{
+		PackagePath:           path,
+		PackageFileName:       r.PackageFileName,
+		MatchFilesGlob:        r.MatchFilesGlob,
+		IncludeSubpackages:    r.IncludeSubpackages,
+		OmitReaderAnnotations: r.OmitReaderAnnotations,
+		SetAnnotations:        r.SetAnnotations,
+		ParseMode:             r.ParseMode,
+		AliasMode:             r.AliasMode,
+		DuplicateKeyMode:      r.DuplicateKeyMode,
+		Warnings:              r.Warnings,
+		Limits:                r.Limits,
+	}.Read()
+}
+
+// clone returns a directory containing the repository checked out at the ref, and a func
+// deleting it unless it is cached
+func (r GitReader) clone(repo, ref string) (string, func(), error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	cleanup := func() {}
+	if err := checkGitArgs(repo, ref); err != nil {
+		return "", cleanup, err
+	}
+
+	var dir string
+	if r.CacheDir != "" {
+		sum := sha256.Sum256([]byte(repo + "@" + ref))
+		dir = filepath.Join(r.CacheDir, hex.EncodeToString(sum[:8]))
+		if _, err := os.Stat(dir); err == nil {
+			return dir, cleanup, nil
+		}
+		if err := os.MkdirAll(r.CacheDir, 0700); err != nil {
+			return "", cleanup, errors.Wrap(err)
+		}
+	}
+
+	// clone into a temporary directory, which is moved to the cache once checked out so
+	// that a failed clone isn't cached
+	tmp, err := ioutil.TempDir(r.CacheDir, "kyaml-git")
+	if err != nil {
+		return "", cleanup, errors.Wrap(err)
+	}
+	cleanup = func() { _ = os.RemoveAll(tmp) }
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", "--", repo, ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	} {
+		if err := runGit(tmp, args...); err != nil {
+			cleanup()
+			return "", func() {}, errors.WrapPrefixf(err, "could not clone %s at %s", repo, ref)
+		}
+	}
+	if dir == "" {
+		return tmp, cleanup, nil
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		cleanup()
+		if _, statErr := os.Stat(dir); statErr == nil {
+			// cloned concurrently
+			return dir, func() {}, nil
+		}
+		return "", func() {}, errors.Wrap(err)
+	}
+	return dir, func() {}, nil
+}
+
+// runGit runs the git command in the directory
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	// never prompt for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		return errors.Errorf("git %s: %v: %s",
+			strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
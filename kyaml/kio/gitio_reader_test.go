@@ -0,0 +1,160 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"io/ioutil"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestParseGitURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		repo string
+		dir  string
+		ref  string
+		err  string
+	}{
+		{url: "https://github.com/org/repo//manifests?ref=v1.2.3",
+			repo: "https://github.com/org/repo", dir: "manifests", ref: "v1.2.3"},
+		{url: "https://github.com/org/repo",
+			repo: "https://github.com/org/repo"},
+		{url: "https://github.com/org/repo.git/manifests/base/?ref=main",
+			repo: "https://github.com/org/repo.git", dir: "manifests/base", ref: "main"},
+		{url: "git@github.com:org/repo.git//manifests",
+			repo: "git@github.com:org/repo.git", dir: "manifests"},
+		{url: "file:///tmp/repo//pkg?ref=abc123",
+			repo: "file:///tmp/repo", dir: "pkg", ref: "abc123"},
+		{url: "ssh://git@example.com/repo/",
+			repo: "ssh://git@example.com/repo"},
+		{url: "https://github.com/org/repo//../other",
+			err: "git URL directory must be within the repository"},
+		{url: "https://",
+			err: "git URL must specify a repository"},
+		{url: "my-dir/",
+			err: "not a git URL: my-dir/"},
+		{url: "file:///tmp/repo?ref=--upload-pack=touch%20/tmp/pwned",
+			err: "ref must not start with '-': --upload-pack=touch /tmp/pwned"},
+	}
+	for _, test := range tests {
+		repo, dir, ref, err := ParseGitURL(test.url)
+		if test.err != "" {
+			if assert.Error(t, err, test.url) {
+				assert.Contains(t, err.Error(), test.err, test.url)
+			}
+			continue
+		}
+		if !assert.NoError(t, err, test.url) {
+			continue
+		}
+		assert.Equal(t, test.repo, repo, test.url)
+		assert.Equal(t, test.dir, dir, test.url)
+		assert.Equal(t, test.ref, ref, test.url)
+	}
+}
+
+func TestGitReader_Read(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	s := setupDirectories(t, "repo/pkg/a")
+	defer s.clean()
+	s.writeFile(t, filepath.Join("repo", "pkg", "a_test.yaml"), readFileA)
+	s.writeFile(t, filepath.Join("repo", "pkg", "a", "b_test.yaml"), readFileB)
+	s.writeFile(t, filepath.Join("repo", "other.yaml"), readFileB)
+	repo := filepath.Join(s.root, "repo")
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{
+			"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if !assert.NoError(t, err, string(out)) {
+			t.FailNow()
+		}
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	// the tag is read rather than the latest commit
+	s.writeFile(t, filepath.Join("repo", "pkg", "a_test.yaml"), readFileB)
+	git("commit", "--quiet", "-am", "v2")
+
+	expected, err := LocalPackageReader{PackagePath: filepath.Join(repo, "pkg")}.Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	url := "file://" + filepath.ToSlash(repo) + "//pkg"
+
+	nodes, err := GitReader{URL: url}.Read()
+	if assert.NoError(t, err) {
+		assert.Equal(t, stringNodes(t, expected), stringNodes(t, nodes))
+	}
+
+	// read the tag, and cache the clone
+	s.writeFile(t, filepath.Join("v1", "pkg", "a_test.yaml"), readFileA)
+	s.writeFile(t, filepath.Join("v1", "pkg", "a", "b_test.yaml"), readFileB)
+	expected, err = LocalPackageReader{PackagePath: filepath.Join(s.root, "v1", "pkg")}.Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cache := filepath.Join(s.root, "cache")
+	for _, r := range []GitReader{
+		{URL: url + "?ref=v1", CacheDir: cache},
+		{URL: url, Ref: "v1", CacheDir: cache},
+	} {
+		nodes, err = r.Read()
+		if assert.NoError(t, err) {
+			assert.Equal(t, stringNodes(t, expected), stringNodes(t, nodes))
+		}
+		entries, err := ioutil.ReadDir(cache)
+		if assert.NoError(t, err) {
+			assert.Len(t, entries, 1)
+		}
+	}
+
+	// the cached clone is read after the repository is deleted
+	if !assert.NoError(t, os.RemoveAll(repo)) {
+		t.FailNow()
+	}
+	nodes, err = GitReader{URL: url + "?ref=v1", CacheDir: cache}.Read()
+	if assert.NoError(t, err) {
+		assert.Equal(t, stringNodes(t, expected), stringNodes(t, nodes))
+	}
+	_, err = GitReader{URL: url + "?ref=v2", CacheDir: cache}.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "could not clone")
+	}
+	// failed clones aren't cached
+	entries, err := ioutil.ReadDir(cache)
+	if assert.NoError(t, err) {
+		assert.Len(t, entries, 1)
+	}
+}
+
+func TestGitReader_Read_optionRef(t *testing.T) {
+	s := setupDirectories(t, "repo")
+	defer s.clean()
+	pwned := filepath.Join(s.root, "pwned")
+	ref := "--upload-pack=touch " + pwned + " && git-upload-pack"
+	url := "file://" + filepath.ToSlash(filepath.Join(s.root, "repo"))
+	for _, r := range []GitReader{
+		{URL: url + "?ref=" + neturl.QueryEscape(ref)},
+		{URL: url, Ref: ref},
+	} {
+		_, err := r.Read()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "ref must not start with '-'")
+		}
+		_, err = os.Stat(pwned)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
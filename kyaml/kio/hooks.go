@@ -0,0 +1,52 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import "time"
+
+// PipelineHooks are called by Pipeline.Execute as it runs, so that long running Pipelines
+// may report their progress -- e.g. a progress bar -- and services may export metrics.
+// Each hook is optional.  The hooks are called from the goroutine calling Execute.
+type PipelineHooks struct {
+	// OnRead is called after each Input is read, with the index of the Input, the number of
+	// Resources it read, and the time taken to read them.
+	OnRead func(input, resources int, elapsed time.Duration)
+
+	// OnFilter is called after each Filter is applied, with the index of the Filter, the
+	// number of Resources it returned, and the time taken to apply it.  Consecutive
+	// Filters run concurrently with Parallelism are applied together, so OnFilter is
+	// called for each of them with the time taken to apply them all.
+	OnFilter func(filter, resources int, elapsed time.Duration)
+
+	// OnWrite is called after each Output is written, with the index of the Output, the
+	// number of Resources written, and the time taken to write them.
+	OnWrite func(output, resources int, elapsed time.Duration)
+
+	// OnError is called with the error Execute returns, if any.
+	OnError func(err error)
+}
+
+func (h *PipelineHooks) read(input, resources int, start time.Time) {
+	if h != nil && h.OnRead != nil {
+		h.OnRead(input, resources, time.Since(start))
+	}
+}
+
+func (h *PipelineHooks) filter(filter, resources int, start time.Time) {
+	if h != nil && h.OnFilter != nil {
+		h.OnFilter(filter, resources, time.Since(start))
+	}
+}
+
+func (h *PipelineHooks) write(output, resources int, start time.Time) {
+	if h != nil && h.OnWrite != nil {
+		h.OnWrite(output, resources, time.Since(start))
+	}
+}
+
+func (h *PipelineHooks) error(err error) {
+	if h != nil && h.OnError != nil && err != nil {
+		h.OnError(err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// recordHooks returns hooks recording the calls in events
+func recordHooks(events *[]string) *PipelineHooks {
+	return &PipelineHooks{
+		OnRead: func(input, resources int, elapsed time.Duration) {
+			*events = append(*events, fmt.Sprintf("read %d: %d", input, resources))
+		},
+		OnFilter: func(filter, resources int, elapsed time.Duration) {
+			*events = append(*events, fmt.Sprintf("filter %d: %d", filter, resources))
+		},
+		OnWrite: func(output, resources int, elapsed time.Duration) {
+			*events = append(*events, fmt.Sprintf("write %d: %d", output, resources))
+		},
+		OnError: func(err error) {
+			*events = append(*events, fmt.Sprintf("error: %v", err))
+		},
+	}
+}
+
+func TestPipeline_Execute_hooks(t *testing.T) {
+	drop := FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+		return nodes[1:], nil
+	})
+	var events []string
+	err := Pipeline{
+		Inputs: []Reader{
+			&ByteReader{Reader: bytes.NewBufferString("a: b\n---\nc: d\n")},
+			&ByteReader{Reader: bytes.NewBufferString("e: f\n")},
+		},
+		Filters: []Filter{drop, filters.FormatFilter{}, filters.FormatFilter{}, drop},
+		Outputs: []Writer{
+			WriterFunc(func([]*yaml.RNode) error { return nil }),
+			WriterFunc(func([]*yaml.RNode) error { return nil }),
+		},
+		Parallelism: 2,
+		Hooks:       recordHooks(&events),
+	}.Execute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the resource-local filters run concurrently are reported once both have run
+	assert.Equal(t, []string{
+		"read 0: 2",
+		"read 1: 1",
+		"filter 0: 2",
+		"filter 1: 2",
+		"filter 2: 2",
+		"filter 3: 1",
+		"write 0: 1",
+		"write 1: 1",
+	}, events)
+
+	// errors are reported
+	events = nil
+	err = Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString("a: b\n")}},
+		Filters: []Filter{FilterFunc(func([]*yaml.RNode) ([]*yaml.RNode, error) {
+			return nil, fmt.Errorf("failed")
+		})},
+		Hooks: recordHooks(&events),
+	}.Execute()
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, []string{"read 0: 1", "error: failed"}, events)
+}
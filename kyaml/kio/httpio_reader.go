@@ -0,0 +1,227 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// DefaultHTTPTimeout is the default timeout of the requests of HTTPReader
+	DefaultHTTPTimeout = 30 * time.Second
+
+	// DefaultHTTPMaxBytes is the default limit of the size of the responses read by
+	// HTTPReader
+	DefaultHTTPMaxBytes = 10 << 20
+)
+
+// HTTPReader reads Resources from HTTP(S) URLs.  Each response may contain a single
+// Resource, a stream of YAML documents or JSON values, or a List wrapping Resources --
+// e.g. the manifests of a release published on the web.  The URLs are fetched in order,
+// and their Resources are annotated with the config.kubernetes.io/url they were read from,
+// and their config.kubernetes.io/index in the response.
+type HTTPReader struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// URLs are the http or https URLs to read.
+	URLs []string `yaml:"urls,omitempty"`
+
+	// Client is the client sending the requests.  Defaults to a client with the Timeout.
+	Client *http.Client `yaml:"-"`
+
+	// Timeout is the time limit of each request, including reading the response.  Defaults
+	// to DefaultHTTPTimeout.  Ignored if Client is set.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// MaxBytes is the limit of the size of each response -- larger responses fail reading
+	// rather than being truncated.  Defaults to DefaultHTTPMaxBytes.
+	MaxBytes int64 `yaml:"maxBytes,omitempty"`
+
+	// OmitReaderAnnotations will cause the reader to skip annotating Resources with the URL
+	// and index.
+	OmitReaderAnnotations bool `yaml:"omitReaderAnnotations,omitempty"`
+
+	// SetAnnotations are annotations to set on the Resources as they are read.
+	SetAnnotations map[string]string `yaml:"setAnnotations,omitempty"`
+
+	// ParseMode configures how the YAML Resources are parsed.  Defaults to
+	// ParseModePreserve.
+	ParseMode ParseMode `yaml:"parseMode,omitempty"`
+
+	// AliasMode configures how anchors and aliases are handled.  Defaults to
+	// AliasModePreserve.
+	AliasMode AliasMode `yaml:"aliasMode,omitempty"`
+
+	// DuplicateKeyMode configures how duplicate keys are handled.  Defaults to
+	// DuplicateKeyModeError.
+	DuplicateKeyMode DuplicateKeyMode `yaml:"duplicateKeyMode,omitempty"`
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer `yaml:"-"`
+
+	// Limits guard reading against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits `yaml:"limits,omitempty"`
+}
+
+var _ Reader = HTTPReader{}
+
+// withParseMode returns a copy of the HTTPReader configured to use the ParseMode
+func (r HTTPReader) withParseMode(mode ParseMode) Reader {
+	r.ParseMode = mode
+	return r
+}
+
+// withReadPolicy returns a copy of the HTTPReader configured to use the AliasMode and
+// DuplicateKeyMode
+func (r HTTPReader) withReadPolicy(aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns a copy of the HTTPReader configured to write warnings to w, unless
+// it sets its own
+func (r HTTPReader) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// withLimits returns a copy of the HTTPReader configured to use the Limits, unless it sets
+// its own
+func (r HTTPReader) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
+
+// Read reads the Resources.
+func (r HTTPReader) Read() ([]*yaml.RNode, error) {
+	if len(r.URLs) == 0 {
+		return nil, fmt.Errorf("must specify at least one URL")
+	}
+	if r.Client == nil {
+		timeout := r.Timeout
+		if timeout == 0 {
+			timeout = DefaultHTTPTimeout
+		}
+		r.Client = &http.Client{Timeout: timeout}
+	}
+	if r.MaxBytes == 0 {
+		r.MaxBytes = DefaultHTTPMaxBytes
+	}
+
+	var operand ResourceNodeSlice
+	for _, u := range r.URLs {
+		nodes, err := r.readURL(u)
+		if err != nil {
+			return nil, errors.WrapPrefixf(err, "%s", u)
+		}
+		operand = append(operand, nodes...)
+	}
+	return operand, nil
+}
+
+// readURL reads the Resources of a single URL
+func (r HTTPReader) readURL(u string) ([]*yaml.RNode, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, errors.Errorf("URL scheme must be http or https")
+	}
+
+	resp, err := r.Client.Get(u)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	// read one more byte than the limit to tell a response of exactly MaxBytes from a
+	// larger one
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, r.MaxBytes+1))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if int64(len(b)) > r.MaxBytes {
+		return nil, errors.Errorf("response exceeds the limit of %d bytes", r.MaxBytes)
+	}
+
+	// the readers don't annotate the Resources unwrapped from Lists, so the Resources are
+	// annotated once read
+	var nodes []*yaml.RNode
+	if isJSONResponse(parsed, resp, b) {
+		nodes, err = (&JSONReader{
+			Reader:                bytes.NewReader(b),
+			OmitReaderAnnotations: true,
+		}).Read()
+	} else {
+		nodes, err = (&ByteReader{
+			Reader:                bytes.NewReader(b),
+			OmitReaderAnnotations: true,
+			ParseMode:             r.ParseMode,
+			AliasMode:             r.AliasMode,
+			DuplicateKeyMode:      r.DuplicateKeyMode,
+			Warnings:              r.Warnings,
+			Limits:                r.Limits,
+		}).Read()
+	}
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{}
+	for k, v := range r.SetAnnotations {
+		annotations[k] = v
+	}
+	for i := range nodes {
+		if !r.OmitReaderAnnotations {
+			annotations[kioutil.IndexAnnotation] = strconv.Itoa(i)
+			annotations[kioutil.URLAnnotation] = u
+		}
+		// sort the annotations by key so the output Resources are consistent
+		var keys []string
+		for k := range annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, err := nodes[i].Pipe(yaml.SetAnnotation(k, annotations[k])); err != nil {
+				return nil, errors.Wrap(err)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// isJSONResponse returns true if the response should be decoded as a stream of JSON
+// values -- it has a JSON media type or file extension, or is an array.  Other JSON
+// values are decoded as YAML.
+func isJSONResponse(u *url.URL, resp *http.Response, b []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		mediaType == "application/json" {
+		return true
+	}
+	return path.Ext(u.Path) == ".json" || bytes.HasPrefix(bytes.TrimSpace(b), []byte("["))
+}
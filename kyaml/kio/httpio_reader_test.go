@@ -0,0 +1,178 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestHTTPReader_Read(t *testing.T) {
+	responses := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"/stream.yaml": {body: `kind: A
+metadata:
+  name: a1
+---
+kind: A
+metadata:
+  name: a2
+`},
+		"/list": {body: `apiVersion: v1
+kind: List
+items:
+- kind: B
+  metadata:
+    name: b1
+- kind: B
+  metadata:
+    name: b2
+`},
+		"/array.json": {body: `[{"kind": "C", "metadata": {"name": "c1"}}, {"kind": "C", "metadata": {"name": "c2"}}]`},
+		"/list.json": {contentType: "application/json; charset=utf-8",
+			body: `{"apiVersion": "v1", "kind": "List", "items": [{"kind": "D", "metadata": {"name": "d"}}]}`},
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if resp.contentType != "" {
+			w.Header().Set("Content-Type", resp.contentType)
+		}
+		_, _ = w.Write([]byte(resp.body))
+	}))
+	defer s.Close()
+
+	nodes, err := HTTPReader{URLs: []string{
+		s.URL + "/stream.yaml", s.URL + "/list", s.URL + "/array.json", s.URL + "/list.json",
+	}}.Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	out := &bytes.Buffer{}
+	if !assert.NoError(t, ByteWriter{Writer: out, KeepReaderAnnotations: true}.Write(nodes)) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.Replace(`kind: A
+metadata:
+  name: a1
+  annotations:
+    config.kubernetes.io/index: 0
+    config.kubernetes.io/url: URL/stream.yaml
+---
+kind: A
+metadata:
+  name: a2
+  annotations:
+    config.kubernetes.io/index: 1
+    config.kubernetes.io/url: URL/stream.yaml
+---
+kind: B
+metadata:
+  name: b1
+  annotations:
+    config.kubernetes.io/index: 0
+    config.kubernetes.io/url: URL/list
+---
+kind: B
+metadata:
+  name: b2
+  annotations:
+    config.kubernetes.io/index: 1
+    config.kubernetes.io/url: URL/list
+---
+kind: C
+metadata:
+  name: c1
+  annotations:
+    config.kubernetes.io/index: 0
+    config.kubernetes.io/url: URL/array.json
+---
+kind: C
+metadata:
+  name: c2
+  annotations:
+    config.kubernetes.io/index: 1
+    config.kubernetes.io/url: URL/array.json
+---
+kind: D
+metadata:
+  name: d
+  annotations:
+    config.kubernetes.io/index: 0
+    config.kubernetes.io/url: URL/list.json
+`, "URL", s.URL, -1), out.String())
+
+	nodes, err = HTTPReader{URLs: []string{s.URL + "/stream.yaml"}, OmitReaderAnnotations: true,
+		SetAnnotations: map[string]string{"foo": "bar"}}.Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{`kind: A
+metadata:
+  name: a1
+  annotations:
+    foo: bar
+`, `kind: A
+metadata:
+  name: a2
+  annotations:
+    foo: bar
+`}, stringNodes(t, nodes))
+}
+
+func TestHTTPReader_Read_errors(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("kind: A\nmetadata:\n  name: a\n"))
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name   string
+		reader HTTPReader
+		err    string
+	}{
+		{name: "no URLs", reader: HTTPReader{},
+			err: "must specify at least one URL"},
+		{name: "scheme", reader: HTTPReader{URLs: []string{"file:///tmp/a.yaml"}},
+			err: "file:///tmp/a.yaml: URL scheme must be http or https"},
+		{name: "status", reader: HTTPReader{URLs: []string{s.URL + "/missing"}},
+			err: s.URL + "/missing: unexpected response status 404 Not Found"},
+		{name: "size limit", reader: HTTPReader{URLs: []string{s.URL}, MaxBytes: 10},
+			err: "response exceeds the limit of 10 bytes"},
+		{name: "timeout", reader: HTTPReader{URLs: []string{s.URL + "/slow"},
+			Timeout: 50 * time.Millisecond},
+			err: "Client.Timeout exceeded"},
+	}
+	for _, test := range tests {
+		_, err := test.reader.Read()
+		if assert.Error(t, err, test.name) {
+			assert.Contains(t, err.Error(), test.err, test.name)
+		}
+	}
+
+	// a response of exactly MaxBytes is read
+	nodes, err := HTTPReader{URLs: []string{s.URL}, MaxBytes: 28}.Read()
+	if assert.NoError(t, err) {
+		assert.Len(t, nodes, 1)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// JSONReader decodes ResourceNodes from a stream of JSON values.  Each value may be
+// a Resource, a List or ResourceList wrapping Resources, or an array of Resources.
+//
+// Since JSON is a subset of YAML, the values are decoded in the same way as ByteReader
+// decodes YAML documents, and the order of the fields is preserved.  The values are read
+// as block style YAML, as ByteReader reads JSON input.
+type JSONReader struct {
+	// Reader is where ResourceNodes are decoded from.
+	Reader io.Reader
+
+	// OmitReaderAnnotations will configures Read to skip setting the config.kubernetes.io/index
+	// annotation on Resources as they are Read.
+	OmitReaderAnnotations bool
+
+	// SetAnnotations is a map of caller specified annotations to set on resources as they are read
+	// These are independent of the annotations controlled by OmitReaderAnnotations
+	SetAnnotations map[string]string
+
+	FunctionConfig *yaml.RNode
+
+	// DisableUnwrapping prevents Resources in Lists and ResourceLists from being unwrapped
+	DisableUnwrapping bool
+
+	// WrappingApiVersion is set by Read(), and is the apiVersion of the object that
+	// the read objects were originally wrapped in.
+	WrappingApiVersion string
+
+	// WrappingKind is set by Read(), and is the kind of the object that
+	// the read objects were originally wrapped in.
+	WrappingKind string
+}
+
+var _ Reader = &JSONReader{}
+
+func (r *JSONReader) Read() ([]*yaml.RNode, error) {
+	values, err := decodeJSON(r.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &ByteReader{
+		OmitReaderAnnotations: r.OmitReaderAnnotations,
+		SetAnnotations:        r.SetAnnotations,
+		DisableUnwrapping:     r.DisableUnwrapping,
+	}
+	nodes, err := b.readJSON(values)
+	r.FunctionConfig = b.FunctionConfig
+	r.WrappingApiVersion = b.WrappingApiVersion
+	r.WrappingKind = b.WrappingKind
+	return nodes, errors.Wrap(err)
+}
+
+// decodeJSON decodes the stream of JSON values from r, unwrapping arrays of Resources
+func decodeJSON(r io.Reader) ([]string, error) {
+	var values []string
+	decoder := json.NewDecoder(r)
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		// unwrap arrays of Resources
+		if bytes.HasPrefix(bytes.TrimSpace(value), []byte("[")) {
+			var items []json.RawMessage
+			if err := json.Unmarshal(value, &items); err != nil {
+				return nil, errors.Wrap(err)
+			}
+			for i := range items {
+				values = append(values, string(items[i]))
+			}
+			continue
+		}
+		values = append(values, string(value))
+	}
+	return values, nil
+}
+
+// isJSONStart returns true if c starts a JSON object or array
+func isJSONStart(c byte) bool {
+	return c == '{' || c == '['
+}
+
+// jsonValues returns the values of input if it is a stream of JSON values.  Returns false
+// if it is YAML -- including YAML documents starting with flow mappings or sequences which
+// aren't valid JSON.
+func jsonValues(input []byte) ([]string, bool) {
+	trimmed := bytes.TrimSpace(input)
+	if len(trimmed) == 0 || !isJSONStart(trimmed[0]) {
+		return nil, false
+	}
+	values, err := decodeJSON(bytes.NewReader(trimmed))
+	return values, err == nil
+}
+
+// peekJSON returns true if the first non-whitespace byte of b starts a JSON value, without
+// reading from b
+func peekJSON(b *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		p, err := b.Peek(n)
+		if err != nil {
+			return false
+		}
+		switch c := p[n-1]; c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		default:
+			return isJSONStart(c)
+		}
+	}
+}
+
+// blockStyle clears the flow and quoting styles of nodes read from JSON, so that they are
+// written as block style YAML.  Strings which would be read as other types are still
+// quoted when written.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for i := range node.Content {
+		blockStyle(node.Content[i])
+	}
+}
+
+// JSONFormat configures how JSONWriter writes Resources
+type JSONFormat string
+
+const (
+	// JSONFormatStream writes the Resources as a stream of indented JSON objects.  This is
+	// the default.
+	JSONFormatStream JSONFormat = ""
+
+	// JSONFormatArray writes the Resources as an indented JSON array.
+	JSONFormatArray JSONFormat = "array"
+
+	// JSONFormatNDJSON writes the Resources as newline-delimited JSON -- each Resource is
+	// written as compact JSON on its own line -- as read by jq and log-processing tools.
+	JSONFormatNDJSON JSONFormat = "ndjson"
+)
+
+// JSONWriter writes ResourceNodes as JSON, formatted by Format.  The order of the fields is
+// preserved.
+type JSONWriter struct {
+	// Writer is where ResourceNodes are encoded.
+	Writer io.Writer
+
+	// KeepReaderAnnotations if set will keep the Reader specific annotations when writing
+	// the Resources, otherwise they will be cleared.
+	KeepReaderAnnotations bool
+
+	// ClearAnnotations is a list of annotations to clear when writing the Resources.
+	ClearAnnotations []string
+
+	// FunctionConfig is the function config for an ResourceList.  If non-nil
+	// wrap the results in an ResourceList.
+	FunctionConfig *yaml.RNode
+
+	// WrappingKind if set will cause JSONWriter to wrap the Resources in
+	// an 'items' field in this kind.  e.g. if WrappingKind is 'List',
+	// JSONWriter will wrap the Resources in a List .items field.
+	// Otherwise the Resources are written as a stream of JSON objects.
+	WrappingKind string
+
+	// WrappingApiVersion is the apiVersion for WrappingKind
+	WrappingApiVersion string
+
+	// Sort if set, will cause JSONWriter to sort the the nodes before writing them.
+	Sort bool
+
+	// Format configures how the Resources are written.  Defaults to JSONFormatStream.
+	// The List of WrappingKind is written as a single object, so may not be written as
+	// an array.
+	Format JSONFormat
+}
+
+var _ Writer = JSONWriter{}
+
+func (w JSONWriter) Write(nodes []*yaml.RNode) error {
+	if err := w.validate(); err != nil {
+		return err
+	}
+	if w.Sort {
+		if err := kioutil.SortNodes(nodes); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+
+	for i := range nodes {
+		if err := cleanNode(nodes[i], w.KeepReaderAnnotations, w.ClearAnnotations); err != nil {
+			return err
+		}
+	}
+
+	// don't wrap the elements
+	if w.WrappingKind == "" {
+		for i := range nodes {
+			if err := w.encode(nodes[i], i); err != nil {
+				return err
+			}
+		}
+		return w.end(len(nodes))
+	}
+
+	// wrap the elements in a list
+	items := &yaml.Node{Kind: yaml.SequenceNode}
+	list := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "apiVersion"},
+			{Kind: yaml.ScalarNode, Value: w.WrappingApiVersion},
+			{Kind: yaml.ScalarNode, Value: "kind"},
+			{Kind: yaml.ScalarNode, Value: w.WrappingKind},
+			{Kind: yaml.ScalarNode, Value: "items"}, items,
+		}}
+	if w.FunctionConfig != nil {
+		list.Content = append(list.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "functionConfig"},
+			w.FunctionConfig.YNode())
+	}
+	for i := range nodes {
+		items.Content = append(items.Content, nodes[i].YNode())
+	}
+	return w.encode(yaml.NewRNode(list), 0)
+}
+
+var _ StreamWriter = JSONWriter{}
+
+// WriteStream writes the Resources received from nodes one at a time, until nodes is
+// closed.  WrappingKind and Sort aren't supported, as they require all the Resources.
+func (w JSONWriter) WriteStream(nodes <-chan *yaml.RNode) error {
+	if err := w.validate(); err != nil {
+		return err
+	}
+	if w.WrappingKind != "" || w.Sort {
+		return errors.Errorf("streaming JSONWriter doesn't support WrappingKind or Sort")
+	}
+	i := 0
+	for node := range nodes {
+		if err := cleanNode(node, w.KeepReaderAnnotations, w.ClearAnnotations); err != nil {
+			return err
+		}
+		if err := w.encode(node, i); err != nil {
+			return err
+		}
+		i++
+	}
+	return w.end(i)
+}
+
+// validate returns an error if the Format is invalid
+func (w JSONWriter) validate() error {
+	switch w.Format {
+	case JSONFormatStream, JSONFormatNDJSON:
+		return nil
+	case JSONFormatArray:
+		if w.WrappingKind != "" {
+			return errors.Errorf("wrapped Resources cannot be written as a JSON array")
+		}
+		return nil
+	default:
+		return errors.Errorf("unknown JSON format %q", w.Format)
+	}
+}
+
+// encode writes node to the Writer as the index-th value of the Format.  Stream values are
+// written as indented JSON followed by a newline, array elements are indented within the
+// array and preceded by the array start or a comma, and NDJSON values are written as
+// compact JSON followed by a newline.
+func (w JSONWriter) encode(node *yaml.RNode, index int) error {
+	b, err := node.MarshalJSON()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	out := &bytes.Buffer{}
+	switch w.Format {
+	case JSONFormatNDJSON:
+		err = json.Compact(out, b)
+		out.WriteByte('\n')
+	case JSONFormatArray:
+		if index == 0 {
+			out.WriteString("[\n  ")
+		} else {
+			out.WriteString(",\n  ")
+		}
+		err = json.Indent(out, b, "  ", "  ")
+	default:
+		err = json.Indent(out, b, "", "  ")
+		out.WriteByte('\n')
+	}
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	_, err = w.Writer.Write(out.Bytes())
+	return errors.Wrap(err)
+}
+
+// end writes the end of the Format after count values
+func (w JSONWriter) end(count int) error {
+	if w.Format != JSONFormatArray {
+		return nil
+	}
+	end := "\n]\n"
+	if count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(w.Writer, end)
+	return errors.Wrap(err)
+}
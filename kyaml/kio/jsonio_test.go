@@ -0,0 +1,202 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestJSONReader_Read(t *testing.T) {
+	input := `{"kind": "Deployment", "apiVersion": "apps/v1", "metadata": {"name": "a"}}
+{
+  "kind": "Service",
+  "apiVersion": "v1",
+  "metadata": {"name": "b"}
+}
+[{"kind": "ConfigMap", "metadata": {"name": "c"}}, {"kind": "Secret", "metadata": {"name": "d"}}]
+`
+	nodes, err := (&JSONReader{Reader: strings.NewReader(input),
+		OmitReaderAnnotations: true}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, nodes, 4) {
+		return
+	}
+	var names []string
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if !assert.NoError(t, err) {
+			return
+		}
+		names = append(names, meta.Kind+"/"+meta.Name)
+	}
+	assert.Equal(t, []string{"Deployment/a", "Service/b", "ConfigMap/c", "Secret/d"}, names)
+
+	// field order is preserved
+	b, err := nodes[0].MarshalJSON()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"a"}}`,
+		string(b))
+}
+
+func TestJSONReader_Read_list(t *testing.T) {
+	input := `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"kind": "Deployment", "metadata": {"name": "a"}},
+    {"kind": "Service", "metadata": {"name": "b"}}
+  ]
+}`
+	r := &JSONReader{Reader: strings.NewReader(input), OmitReaderAnnotations: true}
+	nodes, err := r.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, "List", r.WrappingKind)
+	assert.Equal(t, "v1", r.WrappingApiVersion)
+}
+
+func TestJSONReader_Read_error(t *testing.T) {
+	_, err := (&JSONReader{Reader: strings.NewReader(`{"kind": `)}).Read()
+	assert.Error(t, err)
+}
+
+func TestJSONWriter_Write(t *testing.T) {
+	node1, err := yaml.Parse(`kind: Deployment
+metadata:
+  name: a
+  annotations:
+    config.kubernetes.io/index: 0
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	node2, err := yaml.Parse(`kind: Service
+metadata:
+  name: b
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	buff := &bytes.Buffer{}
+	err = JSONWriter{Writer: buff}.Write([]*yaml.RNode{node1, node2})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `{
+  "kind": "Deployment",
+  "metadata": {
+    "name": "a"
+  }
+}
+{
+  "kind": "Service",
+  "metadata": {
+    "name": "b"
+  }
+}
+`, buff.String())
+}
+
+func TestJSONWriter_Write_wrapped(t *testing.T) {
+	node1, err := yaml.Parse(`kind: Deployment
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	buff := &bytes.Buffer{}
+	err = JSONWriter{
+		Writer:             buff,
+		WrappingKind:       "List",
+		WrappingApiVersion: "v1",
+	}.Write([]*yaml.RNode{node1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "kind": "Deployment"
+    }
+  ]
+}
+`, buff.String())
+}
+
+func TestJSONWriter_Write_format(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   JSONFormat
+		input    string
+		expected string
+	}{
+		{name: "array", format: JSONFormatArray,
+			input: "kind: Deployment\nmetadata:\n  name: a\n---\nkind: Service\n",
+			expected: `[
+  {
+    "kind": "Deployment",
+    "metadata": {
+      "name": "a"
+    }
+  },
+  {
+    "kind": "Service"
+  }
+]
+`},
+		{name: "empty array", format: JSONFormatArray, expected: "[]\n"},
+		{name: "ndjson", format: JSONFormatNDJSON,
+			input: "kind: Deployment\nmetadata:\n  name: a\n---\nkind: Service\n",
+			expected: `{"kind":"Deployment","metadata":{"name":"a"}}
+{"kind":"Service"}
+`},
+	}
+	for _, test := range tests {
+		nodes, err := (&ByteReader{Reader: bytes.NewBufferString(test.input)}).Read()
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		buff := &bytes.Buffer{}
+		if !assert.NoError(t, JSONWriter{Writer: buff, Format: test.format}.Write(nodes), test.name) {
+			continue
+		}
+		assert.Equal(t, test.expected, buff.String(), test.name)
+
+		// the Resources are written the same when streamed
+		buff.Reset()
+		err = StreamPipeline{
+			Inputs: []StreamReader{&ByteReader{Reader: bytes.NewBufferString(test.input)}},
+			Output: JSONWriter{Writer: buff, Format: test.format},
+		}.Execute()
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		assert.Equal(t, test.expected, buff.String(), test.name)
+	}
+}
+
+func TestJSONWriter_Write_formatError(t *testing.T) {
+	err := JSONWriter{Writer: &bytes.Buffer{}, Format: JSONFormatArray, WrappingKind: "List"}.Write(nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "wrapped Resources cannot be written as a JSON array")
+	}
+	err = JSONWriter{Writer: &bytes.Buffer{}, Format: "xml"}.Write(nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `unknown JSON format "xml"`)
+	}
+}
@@ -6,6 +6,9 @@
 package kio
 
 import (
+	"io"
+	"time"
+
 	"sigs.k8s.io/kustomize/kyaml/errors"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
@@ -75,43 +78,194 @@ type Pipeline struct {
 
 	// Outputs are where the transformed Resource Configuration is written.
 	Outputs []Writer `yaml:"outputs,omitempty"`
+
+	// ParseMode if set configures the Inputs which support parse modes -- ByteReader and
+	// LocalPackageReader -- to parse the Resource Configuration using the mode.
+	ParseMode ParseMode `yaml:"parseMode,omitempty"`
+
+	// AliasMode and DuplicateKeyMode if set configure the Inputs which support them --
+	// ByteReader, LocalPackageReader and their ReadWriters -- to handle anchors, aliases and
+	// duplicate keys using the modes, so that all Inputs read the Resources the same way.
+	AliasMode        AliasMode        `yaml:"aliasMode,omitempty"`
+	DuplicateKeyMode DuplicateKeyMode `yaml:"duplicateKeyMode,omitempty"`
+
+	// Warnings if set configures the Inputs which support it to write their warnings to it
+	// -- e.g. the values dropped with DuplicateKeyModeLastWins.  Inputs which set their own
+	// Warnings keep them.  Warnings are discarded by default.
+	Warnings io.Writer `yaml:"-"`
+
+	// Limits if set configure the Inputs which support them to fail once they read a document
+	// larger than MaxResourceBytes, and the Pipeline to fail once its Inputs have read more
+	// than MaxResources Resources.  Inputs which set their own Limits keep them.
+	Limits *Limits `yaml:"limits,omitempty"`
+
+	// Metadata if set is passed to the Filters and Outputs which implement MetadataFilter
+	// and MetadataWriter, so that they may share values about the Resources.  Defaults to
+	// an empty Metadata for each execution.
+	Metadata *Metadata `yaml:"-"`
+
+	// Parallelism if greater than 1 runs the Filters which implement ResourceLocalFilter
+	// over up to Parallelism shards of the Resources concurrently.  Consecutive
+	// resource-local Filters are run over each shard in sequence, and the other Filters
+	// over all the Resources, so that the Resources are filtered in the same order as
+	// they are without Parallelism.
+	Parallelism int `yaml:"parallelism,omitempty"`
+
+	// Hooks if set are called as the Pipeline reads, filters and writes the Resources, and
+	// when it fails.
+	Hooks *PipelineHooks `yaml:"-"`
+
+	// ContinueOnError if set configures the Inputs which support it -- ByteReader and
+	// LocalPackageReader -- to skip the documents they can't parse, so that one broken
+	// file doesn't fail the Pipeline.  The Resources of the other documents are filtered
+	// and written, and the ReadErrors of the skipped documents are returned once they have
+	// been.  Once any documents are skipped, the Pipeline refuses to run Outputs which may
+	// delete files -- e.g. a LocalPackageReadWriter without NoDeleteFiles -- since the files
+	// of the skipped documents could be deleted.  Pipelines writing the Resources back in
+	// place shouldn't continue on error, since the skipped documents would be dropped from
+	// their files.
+	ContinueOnError bool `yaml:"continueOnError,omitempty"`
+
+	// Timings if set records the wall time, Resources and allocations of each Input, Filter
+	// and Output.
+	Timings *PipelineTimings `yaml:"-"`
+}
+
+// ParseMode configures how Readers parse Resource Configuration
+type ParseMode string
+
+const (
+	// ParseModePreserve parses Resources retaining their comments, so that they may be
+	// written back.  This is the default.
+	ParseModePreserve ParseMode = ""
+
+	// ParseModeFast parses Resources skipping the work to attribute comments to the
+	// right Resources, and to parse the metadata of each Resource as it is read.  Comments
+	// between Resources may be attached to the preceding Resource.  Should only be used by
+	// Pipelines which don't write the Resources back, such as count, grep or validate.
+	ParseModeFast ParseMode = "fast"
+)
+
+// parseModeReader is implemented by the Readers which support parse modes
+type parseModeReader interface {
+	withParseMode(ParseMode) Reader
 }
 
 // Execute executes each step in the sequence, returning immediately after encountering
 // any error as part of the Pipeline.
 func (p Pipeline) Execute() error {
+	err := p.execute()
+	p.Hooks.error(err)
+	return err
+}
+
+func (p Pipeline) execute() error {
 	var result []*yaml.RNode
+	var skipped ReadErrors
+	var limits Limits
+	if p.Limits != nil {
+		limits = *p.Limits
+	}
+	pipeline := p.Timings.pipeline()
 
 	// read from the inputs
-	for _, i := range p.Inputs {
+	for index, i := range p.Inputs {
+		start, stage := time.Now(), p.Timings.start()
+		if r, ok := i.(parseModeReader); ok && p.ParseMode != ParseModePreserve {
+			i = r.withParseMode(p.ParseMode)
+		}
+		if r, ok := i.(readPolicyReader); ok && (p.AliasMode != AliasModePreserve ||
+			p.DuplicateKeyMode != DuplicateKeyModeError) {
+			i = r.withReadPolicy(p.AliasMode, p.DuplicateKeyMode)
+		}
+		if r, ok := i.(warningsReader); ok && p.Warnings != nil {
+			i = r.withWarnings(p.Warnings)
+		}
+		if r, ok := i.(limitsReader); ok && limits != (Limits{}) {
+			i = r.withLimits(limits)
+		}
+		if r, ok := i.(continueOnErrorReader); ok && p.ContinueOnError {
+			i = r.withContinueOnError()
+		}
 		nodes, err := i.Read()
-		if err != nil {
+		if errs, ok := err.(ReadErrors); ok {
+			// report the skipped documents once the other Resources are written
+			skipped = append(skipped, errs...)
+		} else if err != nil {
 			return errors.Wrap(err)
 		}
+		p.Hooks.read(index, len(nodes), start)
+		p.Timings.record(pipeline, StageRead, index, p.Inputs[index], len(nodes), stage)
 		result = append(result, nodes...)
+		if err := limits.checkCount(len(result)); err != nil {
+			return errors.Wrap(err)
+		}
 	}
 	if len(result) == 0 {
 		// no inputs to operate on
-		return nil
+		return skipped.err()
+	}
+
+	metadata := p.Metadata
+	if metadata == nil {
+		metadata = NewMetadata()
 	}
 
 	// apply operations
 	var err error
-	for i := range p.Filters {
+	for i := 0; i < len(p.Filters); i++ {
 		op := p.Filters[i]
-		result, err = op.Filter(result)
-		if len(result) == 0 || err != nil {
+		start, stage, first := time.Now(), p.Timings.start(), i
+		if p.Parallelism > 1 && len(result) > 1 && isResourceLocal(op) {
+			j := i + 1
+			for j < len(p.Filters) && isResourceLocal(p.Filters[j]) {
+				j++
+			}
+			result, err = filterShards(result, p.Filters[i:j], p.Parallelism)
+			i = j - 1
+		} else if f, ok := op.(MetadataFilter); ok {
+			result, err = f.FilterWithMetadata(result, metadata)
+		} else {
+			result, err = op.Filter(result)
+		}
+		if err == nil {
+			for k := first; k <= i; k++ {
+				p.Hooks.filter(k, len(result), start)
+				p.Timings.record(pipeline, StageFilter, k, p.Filters[k], len(result), stage)
+			}
+		}
+		if err != nil {
 			return errors.Wrap(err)
 		}
+		if len(result) == 0 {
+			return skipped.err()
+		}
+	}
+
+	// don't delete the files of the skipped documents
+	if len(skipped) > 0 {
+		for _, o := range p.Outputs {
+			if w, ok := o.(fileDeleter); ok && w.deletesFiles() {
+				return errors.Errorf("not writing to %T, which may delete files, since %v", o, skipped)
+			}
+		}
 	}
 
 	// write to the outputs
-	for _, o := range p.Outputs {
-		if err := o.Write(result); err != nil {
+	for index, o := range p.Outputs {
+		start, stage := time.Now(), p.Timings.start()
+		if w, ok := o.(MetadataWriter); ok {
+			err = w.WriteWithMetadata(result, metadata)
+		} else {
+			err = o.Write(result)
+		}
+		if err != nil {
 			return errors.Wrap(err)
 		}
+		p.Hooks.write(index, len(result), start)
+		p.Timings.record(pipeline, StageWrite, index, o, len(result), stage)
 	}
-	return nil
+	return skipped.err()
 }
 
 // FilterAll runs the yaml.Filter against all inputs
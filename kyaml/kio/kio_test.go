@@ -4,10 +4,12 @@
 package kio_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 func TestPipe(t *testing.T) {
@@ -26,3 +28,62 @@ func TestPipe(t *testing.T) {
 func TestSlice_Write(t *testing.T) {
 
 }
+
+func TestPipeline_Execute_parseMode(t *testing.T) {
+	r := &ByteReader{Reader: bytes.NewBufferString("a: b\n")}
+	err := Pipeline{
+		Inputs:    []Reader{r},
+		Outputs:   []Writer{WriterFunc(func([]*yaml.RNode) error { return nil })},
+		ParseMode: ParseModeFast,
+	}.Execute()
+	if assert.NoError(t, err) {
+		assert.Equal(t, ParseModeFast, r.ParseMode)
+	}
+}
+
+func TestPipeline_Execute_readPolicy(t *testing.T) {
+	r := &ByteReader{Reader: bytes.NewBufferString("a: &b c\nd: *b\n")}
+	var nodes []*yaml.RNode
+	err := Pipeline{
+		Inputs: []Reader{r},
+		Outputs: []Writer{WriterFunc(func(n []*yaml.RNode) error {
+			nodes = n
+			return nil
+		})},
+		AliasMode: AliasModeExpand,
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, AliasModeExpand, r.AliasMode)
+	assert.Equal(t, "c", nodes[0].Field("d").Value.YNode().Value)
+}
+
+func TestPipeline_Execute_warnings(t *testing.T) {
+	in := "a: b\na: c\n"
+	warnings := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs:           []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		DuplicateKeyMode: DuplicateKeyModeLastWins,
+		Warnings:         warnings,
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "1:1: duplicate key \"a\", using the value at line 2\n", warnings.String())
+
+	// Inputs which set their own Warnings keep them
+	own := &bytes.Buffer{}
+	warnings.Reset()
+	err = Pipeline{
+		Inputs: []Reader{&ByteReader{
+			Reader: bytes.NewBufferString(in), DuplicateKeyMode: DuplicateKeyModeLastWins,
+			Warnings: own}},
+		Warnings: warnings,
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "", warnings.String())
+	assert.Equal(t, "1:1: duplicate key \"a\", using the value at line 2\n", own.String())
+}
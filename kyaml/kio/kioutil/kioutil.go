@@ -23,6 +23,9 @@ const (
 
 	// PackageAnnotation records the name of the package the Resource was read from
 	PackageAnnotation AnnotationKey = "config.kubernetes.io/package"
+
+	// URLAnnotation records the URL the Resource was read from
+	URLAnnotation AnnotationKey = "config.kubernetes.io/url"
 )
 
 func GetFileAnnotations(rn *yaml.RNode) (string, string, error) {
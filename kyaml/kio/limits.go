@@ -0,0 +1,138 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bytes"
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// Limits guard Readers against inputs too large to be read into memory -- e.g. an
+// enormous or malformed stream piped into a command -- so that they fail as soon as the
+// input exceeds them rather than running out of memory.  Zero values are unlimited.
+type Limits struct {
+	// MaxResourceBytes is the maximum size of each document read, in bytes.
+	MaxResourceBytes int `yaml:"maxResourceBytes,omitempty"`
+
+	// MaxResources is the maximum number of Resources read.  The documents of each input
+	// are counted as they are read, and the Resources of all the inputs of a Pipeline once
+	// they are read -- including the items of Lists.
+	MaxResources int `yaml:"maxResources,omitempty"`
+}
+
+// limitsReader is implemented by the Readers which support Limits
+type limitsReader interface {
+	withLimits(Limits) Reader
+}
+
+// checkCount returns an error if the number of Resources read exceeds MaxResources
+func (l Limits) checkCount(count int) error {
+	if l.MaxResources > 0 && count > l.MaxResources {
+		return errors.Errorf("input exceeds the maximum of %d resources", l.MaxResources)
+	}
+	return nil
+}
+
+// reader returns r guarded by the Limits
+func (l Limits) reader(r io.Reader) io.Reader {
+	if l == (Limits{}) {
+		return r
+	}
+	return &limitedReader{reader: r, limits: l, line: 1, docLine: 1}
+}
+
+// limitedReader reads from reader until it exceeds the limits.  Documents are separated
+// by "---" lines, which aren't counted in their size, and only the documents with any
+// content are counted.
+type limitedReader struct {
+	reader io.Reader
+	limits Limits
+
+	// line is the number of the line being read, lineBytes its size, current the bytes of
+	// the line read up to the length of a separator, and lineContent set once the line has
+	// non-whitespace
+	line        int
+	lineBytes   int
+	current     []byte
+	lineContent bool
+
+	// docs are the documents with content read, docBytes the size of the lines of the
+	// document read, docLine its first line, and docContent set once it has content
+	docs       int
+	docBytes   int
+	docLine    int
+	docContent bool
+}
+
+var separatorLine = []byte("---")
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	for _, c := range p[:n] {
+		r.lineBytes++
+		if c == '\n' {
+			if e := r.endLine(); e != nil {
+				return 0, e
+			}
+			continue
+		}
+		if len(r.current) <= len(separatorLine) {
+			r.current = append(r.current, c)
+		}
+		if c != ' ' && c != '\t' && c != '\r' {
+			r.lineContent = true
+		}
+		if r.tooLarge() && !r.maybeSeparator() {
+			return 0, r.sizeError()
+		}
+	}
+	if err == io.EOF {
+		if e := r.endLine(); e != nil {
+			return 0, e
+		}
+	}
+	return n, err
+}
+
+// tooLarge returns true if the document read, including the line being read, exceeds
+// MaxResourceBytes
+func (r *limitedReader) tooLarge() bool {
+	return r.limits.MaxResourceBytes > 0 && r.docBytes+r.lineBytes > r.limits.MaxResourceBytes
+}
+
+// maybeSeparator returns true if the line read so far may be a separator
+func (r *limitedReader) maybeSeparator() bool {
+	return r.lineBytes <= len(separatorLine) && bytes.HasPrefix(separatorLine, r.current)
+}
+
+func (r *limitedReader) sizeError() error {
+	return errors.Errorf("document at line %d exceeds the maximum size of %d bytes",
+		r.docLine, r.limits.MaxResourceBytes)
+}
+
+// endLine counts the line read, starting a new document if it is a separator
+func (r *limitedReader) endLine() error {
+	separator, content := bytes.Equal(r.current, separatorLine), r.lineContent
+	r.current, r.lineContent = r.current[:0], false
+	r.line++
+	if separator {
+		r.lineBytes, r.docBytes, r.docLine, r.docContent = 0, 0, r.line, false
+		return nil
+	}
+	if r.tooLarge() {
+		return r.sizeError()
+	}
+	r.docBytes, r.lineBytes = r.docBytes+r.lineBytes, 0
+	if r.docContent || !content {
+		return nil
+	}
+	r.docContent = true
+	r.docs++
+	if r.limits.MaxResources > 0 && r.docs > r.limits.MaxResources {
+		return errors.Errorf("input exceeds the maximum of %d documents", r.limits.MaxResources)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const limitsInput = `---
+a: b
+---
+
+---
+c: d
+---
+e: |
+  a long value which makes the document larger than the others
+`
+
+func TestByteReader_Read_maxResourceBytes(t *testing.T) {
+	for _, mode := range []ParseMode{ParseModePreserve, ParseModeFast} {
+		_, err := (&ByteReader{
+			Reader:    bytes.NewBufferString(limitsInput),
+			ParseMode: mode,
+			Limits:    Limits{MaxResourceBytes: 32},
+		}).Read()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "document at line 8 exceeds the maximum size of 32 bytes")
+		}
+	}
+
+	nodes, err := (&ByteReader{
+		Reader: bytes.NewBufferString(limitsInput),
+		Limits: Limits{MaxResourceBytes: 80},
+	}).Read()
+	if assert.NoError(t, err) {
+		assert.Len(t, nodes, 3)
+	}
+}
+
+func TestByteReader_Read_maxResources(t *testing.T) {
+	// empty documents aren't counted
+	nodes, err := (&ByteReader{
+		Reader: bytes.NewBufferString(limitsInput),
+		Limits: Limits{MaxResources: 3},
+	}).Read()
+	if assert.NoError(t, err) {
+		assert.Len(t, nodes, 3)
+	}
+
+	_, err = (&ByteReader{
+		Reader: bytes.NewBufferString(limitsInput),
+		Limits: Limits{MaxResources: 2},
+	}).Read()
+	assert.EqualError(t, err, "input exceeds the maximum of 2 documents")
+
+	// the items of Lists are counted once they are read
+	_, err = (&ByteReader{
+		Reader: bytes.NewBufferString(`apiVersion: v1
+kind: List
+items:
+- a: b
+- c: d
+- e: f
+`),
+		Limits: Limits{MaxResources: 2},
+	}).Read()
+	assert.EqualError(t, err, "input exceeds the maximum of 2 resources")
+}
+
+func TestPipeline_Execute_limits(t *testing.T) {
+	input := func() []Reader {
+		return []Reader{
+			&ByteReader{Reader: bytes.NewBufferString("a: b\n---\nc: d\n")},
+			&ByteReader{Reader: bytes.NewBufferString("e: f\n")},
+		}
+	}
+	output := []Writer{WriterFunc(func([]*yaml.RNode) error { return nil })}
+
+	// the Resources of all the Inputs are counted
+	err := Pipeline{Inputs: input(), Outputs: output, Limits: &Limits{MaxResources: 2}}.Execute()
+	assert.EqualError(t, err, "input exceeds the maximum of 2 resources")
+
+	err = Pipeline{Inputs: input(), Outputs: output, Limits: &Limits{MaxResources: 3}}.Execute()
+	assert.NoError(t, err)
+
+	// the Inputs which set Limits keep them
+	inputs := input()
+	inputs[0].(*ByteReader).Limits = Limits{MaxResourceBytes: 8}
+	inputs[1].(*ByteReader).Limits = Limits{MaxResourceBytes: 8}
+	err = Pipeline{Inputs: inputs, Outputs: output, Limits: &Limits{MaxResourceBytes: 4}}.Execute()
+	assert.NoError(t, err)
+}
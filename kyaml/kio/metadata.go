@@ -0,0 +1,160 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// MetadataAnnotationPrefix is the prefix of the annotations Metadata is rendered to
+const MetadataAnnotationPrefix = "metadata.config.kubernetes.io/"
+
+// MetadataKey is the key of a Metadata value -- e.g. "validation-errors".  Keys should be
+// unique to the Filters which set them, and must be valid annotation names to be
+// rendered.
+type MetadataKey string
+
+// Metadata holds values about Resources which are passed between the Filters and Writers
+// of a Pipeline, but are not part of the Resources -- e.g. validation results, computed
+// hashes or source provenance -- so that they don't need to be written to annotations.
+//
+// Values are keyed by the identity of the Resources -- their API group, kind, namespace
+// and name -- so Filters changing the identity of a Resource must move its values with
+// Move.
+type Metadata struct {
+	values map[string]map[MetadataKey]interface{}
+}
+
+// NewMetadata returns an empty Metadata.
+func NewMetadata() *Metadata {
+	return &Metadata{values: map[string]map[MetadataKey]interface{}{}}
+}
+
+// metadataID returns the identity of the Resource values are keyed by
+func metadataID(node *yaml.RNode) (string, error) {
+	meta, err := node.GetMeta()
+	if err != nil && err != yaml.ErrMissingMetadata {
+		return "", errors.Wrap(err)
+	}
+	group := ""
+	if i := strings.Index(meta.ApiVersion, "/"); i >= 0 {
+		group = meta.ApiVersion[:i]
+	}
+	return strings.Join([]string{group, meta.Kind, meta.Namespace, meta.Name}, "/"), nil
+}
+
+// Set sets the value of key for the Resource.
+func (m *Metadata) Set(node *yaml.RNode, key MetadataKey, value interface{}) error {
+	id, err := metadataID(node)
+	if err != nil {
+		return err
+	}
+	if m.values[id] == nil {
+		m.values[id] = map[MetadataKey]interface{}{}
+	}
+	m.values[id][key] = value
+	return nil
+}
+
+// Get returns the value of key for the Resource, and whether it is set.
+func (m *Metadata) Get(node *yaml.RNode, key MetadataKey) (interface{}, bool) {
+	id, err := metadataID(node)
+	if err != nil {
+		return nil, false
+	}
+	value, found := m.values[id][key]
+	return value, found
+}
+
+// Delete deletes the value of key for the Resource.
+func (m *Metadata) Delete(node *yaml.RNode, key MetadataKey) {
+	if id, err := metadataID(node); err == nil {
+		delete(m.values[id], key)
+	}
+}
+
+// Keys returns the sorted keys of the values set for the Resource.
+func (m *Metadata) Keys(node *yaml.RNode) []MetadataKey {
+	id, err := metadataID(node)
+	if err != nil {
+		return nil
+	}
+	var keys []MetadataKey
+	for k := range m.values[id] {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// Move moves the values of the Resource from to the Resource to, replacing its values --
+// e.g. after renaming a Resource.  from and to may be copies of the Resource before and
+// after its identity was changed.
+func (m *Metadata) Move(from, to *yaml.RNode) error {
+	fromID, err := metadataID(from)
+	if err != nil {
+		return err
+	}
+	toID, err := metadataID(to)
+	if err != nil {
+		return err
+	}
+	if fromID == toID {
+		return nil
+	}
+	m.values[toID] = m.values[fromID]
+	delete(m.values, fromID)
+	return nil
+}
+
+// Render sets the values of the Resource as annotations named MetadataAnnotationPrefix plus
+// their keys.  String values are set as they are, and other values are encoded as json.
+func (m *Metadata) Render(node *yaml.RNode) error {
+	for _, k := range m.Keys(node) {
+		value, _ := m.Get(node, k)
+		s, ok := value.(string)
+		if !ok {
+			b, err := json.Marshal(value)
+			if err != nil {
+				return errors.Wrap(err)
+			}
+			s = string(b)
+		}
+		if err := node.PipeE(yaml.SetAnnotation(MetadataAnnotationPrefix+string(k), s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MetadataFilter is implemented by Filters which read or write Metadata.  Pipelines call
+// FilterWithMetadata rather than Filter.
+type MetadataFilter interface {
+	Filter
+	FilterWithMetadata([]*yaml.RNode, *Metadata) ([]*yaml.RNode, error)
+}
+
+// MetadataFilterFunc implements a MetadataFilter as a function.  Filter is called with
+// an empty Metadata.
+type MetadataFilterFunc func([]*yaml.RNode, *Metadata) ([]*yaml.RNode, error)
+
+func (fn MetadataFilterFunc) Filter(o []*yaml.RNode) ([]*yaml.RNode, error) {
+	return fn(o, NewMetadata())
+}
+
+func (fn MetadataFilterFunc) FilterWithMetadata(o []*yaml.RNode, m *Metadata) ([]*yaml.RNode, error) {
+	return fn(o, m)
+}
+
+// MetadataWriter is implemented by Writers which read Metadata.  Pipelines call
+// WriteWithMetadata rather than Write.
+type MetadataWriter interface {
+	Writer
+	WriteWithMetadata([]*yaml.RNode, *Metadata) error
+}
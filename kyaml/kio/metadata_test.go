@@ -0,0 +1,131 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const metadataInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+func TestMetadata(t *testing.T) {
+	nodes, err := (&ByteReader{
+		Reader: bytes.NewBufferString(metadataInput), OmitReaderAnnotations: true}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	m := NewMetadata()
+	assert.NoError(t, m.Set(nodes[0], "hash", "abc"))
+	assert.NoError(t, m.Set(nodes[0], "errors", 2))
+
+	v, found := m.Get(nodes[0], "hash")
+	assert.True(t, found)
+	assert.Equal(t, "abc", v)
+	// values are keyed by identity, not by node
+	_, found = m.Get(nodes[1], "hash")
+	assert.False(t, found)
+	v, found = m.Get(nodes[0].Copy(), "errors")
+	assert.True(t, found)
+	assert.Equal(t, 2, v)
+	assert.Equal(t, []MetadataKey{"errors", "hash"}, m.Keys(nodes[0]))
+
+	m.Delete(nodes[0], "errors")
+	assert.Equal(t, []MetadataKey{"hash"}, m.Keys(nodes[0]))
+
+	// values are moved with renamed Resources
+	renamed := nodes[0].Copy()
+	assert.NoError(t, renamed.PipeE(
+		yaml.Lookup("metadata"), yaml.SetField("name", yaml.NewScalarRNode("frontend"))))
+	assert.NoError(t, m.Move(nodes[0], renamed))
+	assert.Equal(t, []MetadataKey{"hash"}, m.Keys(renamed))
+	assert.Empty(t, m.Keys(nodes[0]))
+}
+
+func TestPipeline_Metadata(t *testing.T) {
+	validate := MetadataFilterFunc(func(nodes []*yaml.RNode, m *Metadata) ([]*yaml.RNode, error) {
+		for i := range nodes {
+			meta, err := nodes[i].GetMeta()
+			if err != nil {
+				return nil, err
+			}
+			if meta.Kind == "Deployment" {
+				err = m.Set(nodes[i], "validation", []string{"missing replicas"})
+			} else {
+				err = m.Set(nodes[i], "validation", "ok")
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	})
+	var seen []interface{}
+	report := MetadataFilterFunc(func(nodes []*yaml.RNode, m *Metadata) ([]*yaml.RNode, error) {
+		for i := range nodes {
+			v, _ := m.Get(nodes[i], "validation")
+			seen = append(seen, v)
+		}
+		return nodes, nil
+	})
+
+	rendered, dropped := &bytes.Buffer{}, &bytes.Buffer{}
+	err := Pipeline{
+		Inputs:  []Reader{&ByteReader{Reader: bytes.NewBufferString(metadataInput)}},
+		Filters: []Filter{validate, report},
+		Outputs: []Writer{
+			ByteWriter{Writer: rendered, RenderMetadata: true},
+			ByteWriter{Writer: dropped},
+		},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []interface{}{[]string{"missing replicas"}, "ok"}, seen)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    metadata.config.kubernetes.io/validation: '["missing replicas"]'
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  annotations:
+    metadata.config.kubernetes.io/validation: ok
+`, rendered.String())
+	assert.Equal(t, metadataInput, dropped.String())
+
+	// Metadata may be shared with the caller
+	m := NewMetadata()
+	err = Pipeline{
+		Inputs:   []Reader{&ByteReader{Reader: bytes.NewBufferString(metadataInput)}},
+		Filters:  []Filter{validate},
+		Metadata: m,
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(metadataInput)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	v, _ := m.Get(nodes[1], "validation")
+	assert.Equal(t, "ok", v)
+}
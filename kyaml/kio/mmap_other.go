@@ -0,0 +1,22 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package kio
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// mmapFile reads the file into memory on platforms without mmap, and returns its bytes
+// and a no-op func
+func mmapFile(f *os.File, _ int64) ([]byte, func() error, error) {
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, func() error { return nil }, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"io"
+	"os"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// MmapReader reads the Resources of a single file by memory-mapping it read-only, rather
+// than reading it into memory before parsing it as ByteReader does -- e.g. for
+// multi-hundred-MB dumps of a cluster.  The Resources are decoded one at a time from the
+// mapped file, so that the peak memory is roughly that of the decoded Resources.
+//
+// The Resources are read as they are by ByteReader.  On platforms without mmap the file
+// is read into memory.
+type MmapReader struct {
+	// Path is the path of the file to read.
+	Path string
+
+	// OmitReaderAnnotations will configures Read to skip setting the config.kubernetes.io/index
+	// annotation on Resources as they are Read.
+	OmitReaderAnnotations bool
+
+	// SetAnnotations is a map of caller specified annotations to set on resources as they are read
+	// These are independent of the annotations controlled by OmitReaderAnnotations
+	SetAnnotations map[string]string
+
+	FunctionConfig *yaml.RNode
+
+	// DisableUnwrapping prevents Resources in Lists and ResourceLists from being unwrapped
+	DisableUnwrapping bool
+
+	// ParseMode configures how the Resources are parsed.  Defaults to ParseModePreserve.
+	ParseMode ParseMode
+
+	// AliasMode configures how anchors and aliases are handled.  Defaults to
+	// AliasModePreserve.
+	AliasMode AliasMode
+
+	// DuplicateKeyMode configures how duplicate keys are handled.  Defaults to
+	// DuplicateKeyModeError.
+	DuplicateKeyMode DuplicateKeyMode
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer
+
+	// Limits guard reading against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits
+
+	// WrappingApiVersion is set by Read(), and is the apiVersion of the object that
+	// the read objects were originally wrapped in.
+	WrappingApiVersion string
+
+	// WrappingKind is set by Read(), and is the kind of the object that
+	// the read objects were originally wrapped in.
+	WrappingKind string
+}
+
+var _ Reader = &MmapReader{}
+
+func (r *MmapReader) Read() ([]*yaml.RNode, error) {
+	f, err := os.Open(r.Path)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	// the decoded Resources don't reference the input, so it may be unmapped after
+	// they are read
+	input, unmap, err := mmapFile(f, info.Size())
+	if err != nil {
+		return nil, errors.WrapPrefixf(err, "%s", r.Path)
+	}
+	defer unmap()
+
+	b := &ByteReader{
+		OmitReaderAnnotations: r.OmitReaderAnnotations,
+		SetAnnotations:        r.SetAnnotations,
+		DisableUnwrapping:     r.DisableUnwrapping,
+		AliasMode:             r.AliasMode,
+		DuplicateKeyMode:      r.DuplicateKeyMode,
+		Warnings:              r.Warnings,
+		Limits:                r.Limits,
+		ParseMode:             r.ParseMode,
+	}
+	nodes, err := b.readInput(input)
+	if err != nil {
+		return nil, errors.WrapPrefixf(err, "%s", r.Path)
+	}
+	r.FunctionConfig = b.FunctionConfig
+	r.WrappingApiVersion = b.WrappingApiVersion
+	r.WrappingKind = b.WrappingKind
+	return nodes, nil
+}
+
+// withParseMode returns the MmapReader configured to use the ParseMode
+func (r *MmapReader) withParseMode(mode ParseMode) Reader {
+	r.ParseMode = mode
+	return r
+}
+
+// withReadPolicy returns the MmapReader configured to use the AliasMode and
+// DuplicateKeyMode
+func (r *MmapReader) withReadPolicy(aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns the MmapReader configured to write warnings to w, unless it sets
+// its own
+func (r *MmapReader) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// withLimits returns the MmapReader configured to use the Limits, unless it sets its own
+func (r *MmapReader) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
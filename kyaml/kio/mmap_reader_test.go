@@ -0,0 +1,123 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestMmapReader_Read(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+
+	tests := map[string]string{
+		"multiple": getByteReaderTestInput(t).String(),
+		"list": `apiVersion: config.kubernetes.io/v1alpha1
+kind: ResourceList
+functionConfig:
+  foo: bar
+items:
+- kind: Deployment
+  spec:
+    replicas: 1
+- kind: Service
+`,
+		"comments": `# header comment
+kind: Deployment # line comment
+---
+kind: Service
+spec:
+  # field comment
+  type: ClusterIP
+`,
+		"empty": ``,
+	}
+	for name, input := range tests {
+		path := filepath.Join(d, name+".yaml")
+		if !assert.NoError(t, ioutil.WriteFile(path, []byte(input), 0600)) {
+			t.FailNow()
+		}
+		for _, mode := range []ParseMode{ParseModePreserve, ParseModeFast} {
+			// the Resources are read as they are by ByteReader
+			b := &ByteReader{Reader: bytes.NewBufferString(input), ParseMode: mode,
+				SetAnnotations: map[string]string{"foo": "bar"}}
+			expected, err := b.Read()
+			if !assert.NoError(t, err, name) {
+				continue
+			}
+			m := &MmapReader{Path: path, ParseMode: mode,
+				SetAnnotations: map[string]string{"foo": "bar"}}
+			actual, err := m.Read()
+			if !assert.NoError(t, err, name) {
+				continue
+			}
+
+			if !assert.Len(t, actual, len(expected), name) {
+				continue
+			}
+			for i := range expected {
+				e, err := expected[i].String()
+				if !assert.NoError(t, err, name) {
+					continue
+				}
+				a, err := actual[i].String()
+				if !assert.NoError(t, err, name) {
+					continue
+				}
+				assert.Equal(t, e, a, name)
+				assert.Equal(t, expected[i].YNode().Line, actual[i].YNode().Line, name)
+			}
+			assert.Equal(t, b.WrappingKind, m.WrappingKind, name)
+			assert.Equal(t, b.WrappingApiVersion, m.WrappingApiVersion, name)
+			if b.FunctionConfig != nil && assert.NotNil(t, m.FunctionConfig, name) {
+				assert.Equal(t, b.FunctionConfig.MustString(), m.FunctionConfig.MustString(), name)
+			}
+		}
+	}
+}
+
+func TestMmapReader_Read_pipeline(t *testing.T) {
+	f, err := ioutil.TempFile("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`a: &a b
+c: *a
+---
+d: e
+`)
+	if !assert.NoError(t, err) || !assert.NoError(t, f.Close()) {
+		t.FailNow()
+	}
+
+	// the parse mode and read policy of the Pipeline are used
+	var nodes []*yaml.RNode
+	err = Pipeline{
+		Inputs:    []Reader{&MmapReader{Path: f.Name(), OmitReaderAnnotations: true}},
+		Outputs:   []Writer{WriterFunc(func(n []*yaml.RNode) error { nodes = n; return nil })},
+		AliasMode: AliasModeExpand,
+	}.Execute()
+	if !assert.NoError(t, err) || !assert.Len(t, nodes, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "a: b\nc: b\n", nodes[0].MustString())
+	assert.Equal(t, "d: e\n", nodes[1].MustString())
+}
+
+func TestMmapReader_Read_missing(t *testing.T) {
+	_, err := (&MmapReader{Path: filepath.Join("not", "found.yaml")}).Read()
+	assert.Error(t, err)
+}
@@ -0,0 +1,308 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/registry"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// OCIManifestMediaType is the media type of the manifests of OCI artifacts
+	OCIManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// OCIConfigMediaType is the media type of the config of the package artifacts written
+	// by OCIWriter -- the type of the artifacts
+	OCIConfigMediaType = "application/vnd.kyaml.package.config.v1+json"
+
+	// OCILayerMediaType is the media type of the files of the package artifacts written
+	// by OCIWriter
+	OCILayerMediaType = "application/vnd.kyaml.package.file.v1+yaml"
+
+	// ociTitleAnnotation is the annotation of the layers with the path of their file
+	ociTitleAnnotation = "org.opencontainers.image.title"
+
+	// ociScheme is the optional scheme of the references of OCI artifacts
+	ociScheme = "oci://"
+)
+
+// ociManifest is the manifest of an OCI artifact
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ociDescriptor describes a blob of an OCI artifact
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// parseOCIReference parses the reference of an OCI artifact, which may be prefixed with
+// oci://
+func parseOCIReference(reference string) (registry.Reference, error) {
+	if reference == "" {
+		return registry.Reference{}, fmt.Errorf("must specify artifact reference")
+	}
+	return registry.ParseReference(strings.TrimPrefix(reference, ociScheme))
+}
+
+// OCIReader reads the Resources of a package from an OCI artifact in a container
+// registry.  The artifacts are stored as ORAS stores files -- each file of the package is
+// a layer of the artifact, with its path in the org.opencontainers.image.title
+// annotation -- so packages pushed with OCIWriter or the oras CLI may be read.  The
+// Resources are read as LocalPackageReader reads them from the package -- annotated with
+// the config.kubernetes.io/path and config.kubernetes.io/package of their file, and read
+// in the same order.
+type OCIReader struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Reference is the reference of the artifact -- e.g. ghcr.io/org/pkg:v1 or
+	// oci://ghcr.io/org/pkg@sha256:...
+	Reference string `yaml:"reference,omitempty"`
+
+	// Client sends the requests to the registry.  Defaults to an anonymous client.
+	Client *registry.Client `yaml:"-"`
+
+	// PackageFileName is the name of file containing package metadata.
+	// It will be used to identify package.
+	PackageFileName string `yaml:"packageFileName,omitempty"`
+
+	// MatchFilesGlob configures Read to only read Resources from files matching any of the
+	// provided patterns.
+	// Defaults to ["*.yaml", "*.yml"] if empty.  To match all files specify ["*"].
+	MatchFilesGlob []string `yaml:"matchFilesGlob,omitempty"`
+
+	// IncludeSubpackages will configure Read to read Resources from subpackages.
+	// Subpackages are identified by presence of PackageFileName.
+	IncludeSubpackages bool `yaml:"includeSubpackages,omitempty"`
+
+	// OmitReaderAnnotations will cause the reader to skip annotating Resources with the file
+	// path and mode.
+	OmitReaderAnnotations bool `yaml:"omitReaderAnnotations,omitempty"`
+
+	// SetAnnotations are annotations to set on the Resources as they are read.
+	SetAnnotations map[string]string `yaml:"setAnnotations,omitempty"`
+
+	// ParseMode configures how the Resources are parsed.  Defaults to ParseModePreserve.
+	ParseMode ParseMode `yaml:"parseMode,omitempty"`
+
+	// AliasMode configures how anchors and aliases are handled.  Defaults to
+	// AliasModePreserve.
+	AliasMode AliasMode `yaml:"aliasMode,omitempty"`
+
+	// DuplicateKeyMode configures how duplicate keys are handled.  Defaults to
+	// DuplicateKeyModeError.
+	DuplicateKeyMode DuplicateKeyMode `yaml:"duplicateKeyMode,omitempty"`
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer `yaml:"-"`
+
+	// Limits guard reading against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits `yaml:"limits,omitempty"`
+}
+
+var _ Reader = OCIReader{}
+
+// withParseMode returns a copy of the OCIReader configured to use the ParseMode
+func (r OCIReader) withParseMode(mode ParseMode) Reader {
+	r.ParseMode = mode
+	return r
+}
+
+// withReadPolicy returns a copy of the OCIReader configured to use the AliasMode and
+// DuplicateKeyMode
+func (r OCIReader) withReadPolicy(aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns a copy of the OCIReader configured to write warnings to w, unless
+// it sets its own
+func (r OCIReader) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// withLimits returns a copy of the OCIReader configured to use the Limits, unless it sets
+// its own
+func (r OCIReader) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
+
+// Read reads the Resources.
+func (r OCIReader) Read() ([]*yaml.RNode, error) {
+	ref, err := parseOCIReference(r.Reference)
+	if err != nil {
+		return nil, err
+	}
+	client := r.Client
+	if client == nil {
+		client = &registry.Client{}
+	}
+
+	b, mediaType, err := client.GetManifest(ref, OCIManifestMediaType)
+	if err != nil {
+		return nil, errors.WrapPrefixf(err, "%s", r.Reference)
+	}
+	m := ociManifest{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.WrapPrefixf(err, "%s: invalid manifest", r.Reference)
+	}
+	if m.MediaType != "" {
+		mediaType = m.MediaType
+	}
+	if mediaType != OCIManifestMediaType {
+		return nil, errors.Errorf("%s: not an OCI artifact: manifest media type is %s",
+			r.Reference, mediaType)
+	}
+
+	// the files are read as TarReader reads the files of an archive
+	tr := TarReader{
+		PackageFileName:       r.PackageFileName,
+		MatchFilesGlob:        r.MatchFilesGlob,
+		IncludeSubpackages:    r.IncludeSubpackages,
+		OmitReaderAnnotations: r.OmitReaderAnnotations,
+		SetAnnotations:        r.SetAnnotations,
+		ParseMode:             r.ParseMode,
+		AliasMode:             r.AliasMode,
+		DuplicateKeyMode:      r.DuplicateKeyMode,
+		Warnings:              r.Warnings,
+		Limits:                r.Limits,
+	}
+	if len(tr.MatchFilesGlob) == 0 {
+		tr.MatchFilesGlob = defaultMatch
+	}
+	files := map[string][]byte{}
+	packages := map[string]bool{}
+	for _, l := range m.Layers {
+		// layers without a title aren't files
+		title := l.Annotations[ociTitleAnnotation]
+		if title == "" {
+			continue
+		}
+		name := path.Clean(title)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, errors.Errorf("%s: layer title must be within the package: %s",
+				r.Reference, title)
+		}
+		if r.PackageFileName != "" && path.Base(name) == r.PackageFileName {
+			packages[path.Dir(name)] = true
+		}
+		if match, err := tr.shouldReadFile(name); err != nil {
+			return nil, err
+		} else if !match {
+			continue
+		}
+		if files[name], err = client.GetBlob(ref, l.Digest, l.Size); err != nil {
+			return nil, errors.WrapPrefixf(err, "%s: %s", r.Reference, title)
+		}
+	}
+	return tr.readFiles(files, packages)
+}
+
+// OCIWriter writes the Resources of a package to an OCI artifact in a container
+// registry.  The Resources are written to the files of their config.kubernetes.io/path
+// annotation as LocalPackageWriter writes them to a directory, and each file is pushed as
+// a layer of the artifact, as ORAS pushes files.
+type OCIWriter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Reference is the reference of the artifact to push -- e.g. ghcr.io/org/pkg:v1 or
+	// oci://ghcr.io/org/pkg:v1.  The reference must have a tag.
+	Reference string `yaml:"reference,omitempty"`
+
+	// Client sends the requests to the registry.  Defaults to an anonymous client.
+	Client *registry.Client `yaml:"-"`
+
+	// Annotations are the annotations of the manifest of the artifact -- e.g.
+	// org.opencontainers.image.source.
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	// KeepReaderAnnotations if set will retain the annotations set by OCIReader
+	KeepReaderAnnotations bool `yaml:"keepReaderAnnotations,omitempty"`
+
+	// ClearAnnotations will clear annotations before writing the resources
+	ClearAnnotations []string `yaml:"clearAnnotations,omitempty"`
+
+	// Digest is populated by Write with the digest of the manifest of the artifact, so
+	// that it may be read by digest.
+	Digest string `yaml:"digest,omitempty"`
+}
+
+var _ Writer = &OCIWriter{}
+
+func (w *OCIWriter) Write(nodes []*yaml.RNode) error {
+	w.Digest = ""
+	ref, err := parseOCIReference(w.Reference)
+	if err != nil {
+		return err
+	}
+	if ref.Tag == "" || ref.Digest != "" {
+		return errors.Errorf("artifact reference must have a tag and no digest: %s", w.Reference)
+	}
+	client := w.Client
+	if client == nil {
+		client = &registry.Client{}
+	}
+
+	paths, files, err := TarWriter{
+		KeepReaderAnnotations: w.KeepReaderAnnotations,
+		ClearAnnotations:      w.ClearAnnotations,
+	}.files(nodes)
+	if err != nil {
+		return err
+	}
+
+	m := ociManifest{
+		SchemaVersion: 2,
+		MediaType:     OCIManifestMediaType,
+		Annotations:   w.Annotations,
+	}
+	config := []byte("{}")
+	if m.Config.Digest, err = client.PushBlob(ref, config); err != nil {
+		return errors.WrapPrefixf(err, "%s: config", w.Reference)
+	}
+	m.Config.MediaType, m.Config.Size = OCIConfigMediaType, int64(len(config))
+	for _, p := range paths {
+		digest, err := client.PushBlob(ref, files[p])
+		if err != nil {
+			return errors.WrapPrefixf(err, "%s: %s", w.Reference, p)
+		}
+		m.Layers = append(m.Layers, ociDescriptor{
+			MediaType:   OCILayerMediaType,
+			Digest:      digest,
+			Size:        int64(len(files[p])),
+			Annotations: map[string]string{ociTitleAnnotation: p},
+		})
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if w.Digest, err = client.PutManifest(ref, OCIManifestMediaType, b); err != nil {
+		return errors.WrapPrefixf(err, "%s", w.Reference)
+	}
+	return nil
+}
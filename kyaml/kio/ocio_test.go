@@ -0,0 +1,287 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/registry"
+)
+
+// fakeOCIRegistry is an in-memory registry serving the blobs and manifests of the pkg
+// repository
+type fakeOCIRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	types     map[string]string
+}
+
+func newFakeOCIRegistry() (*fakeOCIRegistry, *httptest.Server) {
+	f := &fakeOCIRegistry{
+		blobs: map[string][]byte{}, manifests: map[string][]byte{}, types: map[string]string{}}
+	return f, httptest.NewTLSServer(f)
+}
+
+func (f *fakeOCIRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := strings.TrimPrefix(r.URL.Path, "/v2/pkg/")
+	switch {
+	case p == "blobs/uploads/" && r.Method == http.MethodPost:
+		w.Header().Set("Location", "/v2/pkg/blobs/uploads/1")
+		w.WriteHeader(http.StatusAccepted)
+	case p == "blobs/uploads/1" && r.Method == http.MethodPut:
+		b, _ := ioutil.ReadAll(r.Body)
+		f.blobs[r.URL.Query().Get("digest")] = b
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(p, "blobs/"):
+		b, found := f.blobs[strings.TrimPrefix(p, "blobs/")]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(b)
+	case strings.HasPrefix(p, "manifests/") && r.Method == http.MethodPut:
+		b, _ := ioutil.ReadAll(r.Body)
+		f.putManifest(strings.TrimPrefix(p, "manifests/"), r.Header.Get("Content-Type"), b)
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(p, "manifests/"):
+		b, found := f.manifests[strings.TrimPrefix(p, "manifests/")]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[strings.TrimPrefix(p, "manifests/")])
+		_, _ = w.Write(b)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// putManifest stores the manifest by tag and digest
+func (f *fakeOCIRegistry) putManifest(tag, mediaType string, b []byte) {
+	for _, k := range []string{tag, registry.Digest(b)} {
+		f.manifests[k], f.types[k] = b, mediaType
+	}
+}
+
+// putFiles stores the files as the layers of an artifact, as the oras CLI pushes them
+func (f *fakeOCIRegistry) putFiles(tag string, files map[string][]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	f.blobs[registry.Digest([]byte("{}"))] = []byte("{}")
+	var layers []string
+	for _, name := range names {
+		d := registry.Digest(files[name])
+		f.blobs[d] = files[name]
+		layers = append(layers, fmt.Sprintf(`{"mediaType": "application/vnd.oci.image.layer.v1.tar",
+"digest": %q, "size": %d, "annotations": {"org.opencontainers.image.title": %q}}`,
+			d, len(files[name]), name))
+	}
+	// layers without a title are skipped
+	f.blobs[registry.Digest([]byte("untitled"))] = []byte("untitled")
+	layers = append(layers, fmt.Sprintf(`{"mediaType": "application/octet-stream",
+"digest": %q, "size": 8}`, registry.Digest([]byte("untitled"))))
+	f.putManifest(tag, OCIManifestMediaType, []byte(fmt.Sprintf(`{"schemaVersion": 2,
+"config": {"mediaType": "application/vnd.unknown.config.v1+json", "digest": %q, "size": 2},
+"layers": [%s]}`, registry.Digest([]byte("{}")), strings.Join(layers, ", "))))
+}
+
+// layer returns the blob of the layer of the v1 manifest with the title
+func (f *fakeOCIRegistry) layer(t *testing.T, title string) []byte {
+	m := struct {
+		Layers []struct {
+			Digest      string            `json:"digest"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"layers"`
+	}{}
+	if !assert.NoError(t, json.Unmarshal(f.manifests["v1"], &m)) {
+		t.FailNow()
+	}
+	for _, l := range m.Layers {
+		if l.Annotations["org.opencontainers.image.title"] == title {
+			return f.blobs[l.Digest]
+		}
+	}
+	t.Fatalf("no layer %s", title)
+	return nil
+}
+
+func TestOCIReader_Read(t *testing.T) {
+	files := map[string][]byte{
+		"a_test.yaml":     readFileA,
+		"a/b_test.yaml":   readFileB,
+		"a-c_test.yaml":   readFileB,
+		"a/c/c_test.yaml": readFileA,
+		"a/c/pkgFile":     pkgFile,
+		"a/d.txt":         readFileB,
+	}
+	s := setupDirectories(t)
+	defer s.clean()
+	for name, value := range files {
+		s.writeFile(t, "pkg/"+name, value)
+	}
+	f, server := newFakeOCIRegistry()
+	defer server.Close()
+	f.putFiles("v1", files)
+	reference := "oci://" + strings.TrimPrefix(server.URL, "https://") + "/pkg:v1"
+	client := &registry.Client{HTTP: server.Client()}
+
+	tests := []struct {
+		name string
+		lr   LocalPackageReader
+		or   OCIReader
+	}{
+		{name: "package",
+			lr: LocalPackageReader{PackagePath: "pkg"},
+			or: OCIReader{}},
+		{name: "skip subpackages",
+			lr: LocalPackageReader{PackagePath: "pkg", PackageFileName: "pkgFile"},
+			or: OCIReader{PackageFileName: "pkgFile"}},
+		{name: "include subpackages",
+			lr: LocalPackageReader{PackagePath: "pkg", PackageFileName: "pkgFile",
+				IncludeSubpackages: true},
+			or: OCIReader{PackageFileName: "pkgFile", IncludeSubpackages: true}},
+		{name: "match files",
+			lr: LocalPackageReader{PackagePath: "pkg", MatchFilesGlob: []string{"*.txt"}},
+			or: OCIReader{MatchFilesGlob: []string{"*.txt"}}},
+		{name: "annotations",
+			lr: LocalPackageReader{PackagePath: "pkg", OmitReaderAnnotations: true,
+				SetAnnotations: map[string]string{"foo": "bar"}},
+			or: OCIReader{OmitReaderAnnotations: true,
+				SetAnnotations: map[string]string{"foo": "bar"}}},
+	}
+	for _, test := range tests {
+		expected, err := test.lr.Read()
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		test.or.Reference, test.or.Client = reference, client
+		actual, err := test.or.Read()
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		assert.Equal(t, stringNodes(t, expected), stringNodes(t, actual), test.name)
+	}
+}
+
+func TestOCIWriter_Write(t *testing.T) {
+	s := setupDirectories(t)
+	defer s.clean()
+	s.writeFile(t, "pkg/a_test.yaml", readFileA)
+	s.writeFile(t, "pkg/a/b_test.yaml", readFileB)
+	expected, err := LocalPackageReader{PackagePath: "pkg"}.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, server := newFakeOCIRegistry()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := &registry.Client{HTTP: server.Client()}
+
+	nodes, err := LocalPackageReader{PackagePath: "pkg"}.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	w := &OCIWriter{Reference: "oci://" + host + "/pkg:v1", Client: client,
+		Annotations: map[string]string{"org.opencontainers.image.source": "https://github.com/org/pkg"}}
+	if !assert.NoError(t, w.Write(nodes)) {
+		return
+	}
+	assert.Equal(t, registry.Digest(f.manifests["v1"]), w.Digest)
+	layer := func(title string) string {
+		b := f.layer(t, title)
+		return fmt.Sprintf(`{"mediaType":"application/vnd.kyaml.package.file.v1+yaml",`+
+			`"digest":%q,"size":%d,"annotations":{"org.opencontainers.image.title":%q}}`,
+			registry.Digest(b), len(b), title)
+	}
+	assert.Equal(t, `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",`+
+		`"config":{"mediaType":"application/vnd.kyaml.package.config.v1+json",`+
+		`"digest":"`+registry.Digest([]byte("{}"))+`","size":2},`+
+		`"layers":[`+layer("a/b_test.yaml")+`,`+layer("a_test.yaml")+`],`+
+		`"annotations":{"org.opencontainers.image.source":"https://github.com/org/pkg"}}`,
+		string(f.manifests["v1"]))
+	assert.Equal(t, string(readFileB), string(f.layer(t, "a/b_test.yaml")))
+
+	// the artifact is read by tag and digest
+	for _, reference := range []string{host + "/pkg:v1", host + "/pkg@" + w.Digest} {
+		actual, err := OCIReader{Reference: reference, Client: client}.Read()
+		if !assert.NoError(t, err, reference) {
+			continue
+		}
+		assert.Equal(t, stringNodes(t, expected), stringNodes(t, actual), reference)
+	}
+}
+
+func TestOCIReaderWriter_errors(t *testing.T) {
+	f, server := newFakeOCIRegistry()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := &registry.Client{HTTP: server.Client()}
+	f.putFiles("outside", map[string][]byte{"../a.yaml": readFileA})
+	f.putManifest("image", "application/vnd.docker.distribution.manifest.v2+json",
+		[]byte(`{"schemaVersion": 2, "layers": []}`))
+	// the layer declares a smaller size than its blob
+	f.putManifest("size", OCIManifestMediaType, []byte(fmt.Sprintf(`{"schemaVersion": 2,
+"layers": [{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": %q, "size": 8,
+"annotations": {"org.opencontainers.image.title": "a.yaml"}}]}`, registry.Digest(readFileA))))
+	// the layer declares a larger size than the client reads
+	f.putManifest("large", OCIManifestMediaType, []byte(fmt.Sprintf(`{"schemaVersion": 2,
+"layers": [{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": %q, "size": %d,
+"annotations": {"org.opencontainers.image.title": "a.yaml"}}]}`,
+		registry.Digest(readFileA), registry.DefaultMaxBlobBytes+1)))
+
+	tests := []struct {
+		name      string
+		reference string
+		expected  string
+	}{
+		{name: "no reference", expected: "must specify artifact reference"},
+		{name: "missing", reference: host + "/pkg:v2",
+			expected: host + "/pkg:v2: unexpected response status 404 Not Found"},
+		{name: "image", reference: host + "/pkg:image",
+			expected: "not an OCI artifact: manifest media type is " +
+				"application/vnd.docker.distribution.manifest.v2+json"},
+		{name: "outside", reference: host + "/pkg:outside",
+			expected: "layer title must be within the package: ../a.yaml"},
+		{name: "size", reference: host + "/pkg:size",
+			expected: "blob " + registry.Digest(readFileA) + " doesn't match its size of 8 bytes"},
+		{name: "large", reference: host + "/pkg:large",
+			expected: fmt.Sprintf("blob size %d exceeds the maximum size of %d bytes",
+				registry.DefaultMaxBlobBytes+1, registry.DefaultMaxBlobBytes)},
+	}
+	for _, test := range tests {
+		_, err := OCIReader{Reference: test.reference, Client: client}.Read()
+		if assert.Error(t, err, test.name) {
+			assert.Contains(t, err.Error(), test.expected, test.name)
+		}
+	}
+
+	_, err := OCIReader{Reference: host + "/pkg:outside",
+		Client: &registry.Client{HTTP: server.Client(), MaxManifestBytes: 16}}.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "manifest exceeds the maximum size of 16 bytes")
+	}
+
+	err = (&OCIWriter{Reference: host + "/pkg@" + registry.Digest(nil), Client: client}).Write(nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "artifact reference must have a tag and no digest")
+	}
+}
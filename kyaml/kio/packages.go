@@ -0,0 +1,158 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"path/filepath"
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// PackageMetadataFileNames are the names of the files identifying package directories, in
+// order of precedence when a directory contains several.
+var PackageMetadataFileNames = append([]string{"Kptfile"}, kustomizationFileNames...)
+
+// Package is a directory containing a package metadata file -- a Kptfile or a
+// kustomization file.
+type Package struct {
+	// Path is the path of the package directory relative to the directory the packages
+	// were read from -- "." for the root package.
+	Path string
+
+	// File is the name of the package metadata file -- one of PackageMetadataFileNames.
+	File string
+
+	// Metadata is the package metadata file, annotated with its path as the Resources are.
+	Metadata *yaml.RNode
+}
+
+// Packages are the packages of a directory, sorted by path.  The subpackages of a
+// package are the packages in the directories beneath it.
+type Packages []Package
+
+// PackagesOf returns the packages of the package metadata files in nodes, identified by
+// the file names of their config.kubernetes.io/path annotations -- e.g. the Resources read
+// by a LocalPackageReader with PackageMetadata set.
+func PackagesOf(nodes []*yaml.RNode) Packages {
+	byPath := map[string]Package{}
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil && err != yaml.ErrMissingMetadata {
+			continue
+		}
+		path := meta.Annotations[kioutil.PathAnnotation]
+		p := Package{Path: filepath.Dir(path), File: filepath.Base(path), Metadata: nodes[i]}
+		rank := packageFileRank(p.File)
+		if path == "" || rank < 0 {
+			continue
+		}
+		// keep the file with the highest precedence in the directory
+		if existing, found := byPath[p.Path]; found && packageFileRank(existing.File) <= rank {
+			continue
+		}
+		byPath[p.Path] = p
+	}
+	var packages Packages
+	for _, p := range byPath {
+		packages = append(packages, p)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Path < packages[j].Path })
+	return packages
+}
+
+// ReadPackages returns the packages of the directory dir and its subdirectories, without
+// reading their Resources.
+func ReadPackages(dir string) (Packages, error) {
+	nodes, err := LocalPackageReader{
+		PackagePath:     dir,
+		MatchFilesGlob:  PackageMetadataFileNames,
+		PackageMetadata: true,
+	}.Read()
+	if err != nil {
+		return nil, err
+	}
+	return PackagesOf(nodes), nil
+}
+
+// Get returns the package in the directory dir, or nil if dir isn't a package.
+func (p Packages) Get(dir string) *Package {
+	dir = filepath.Clean(dir)
+	for i := range p {
+		if p[i].Path == dir {
+			return &p[i]
+		}
+	}
+	return nil
+}
+
+// Of returns the innermost package containing the file at path -- e.g. the
+// config.kubernetes.io/path annotation of a Resource -- or nil if the file isn't in a
+// package.
+func (p Packages) Of(path string) *Package {
+	for dir := filepath.Dir(filepath.Clean(path)); ; dir = filepath.Dir(dir) {
+		if pkg := p.Get(dir); pkg != nil {
+			return pkg
+		}
+		if dir == "." || dir == filepath.Dir(dir) {
+			return nil
+		}
+	}
+}
+
+// Parent returns the package enclosing the package in the directory dir, or nil if it is
+// a root package.
+func (p Packages) Parent(dir string) *Package {
+	dir = filepath.Clean(dir)
+	if dir == "." || dir == filepath.Dir(dir) {
+		return nil
+	}
+	return p.Of(dir)
+}
+
+// Subpackages returns the packages directly beneath the package in the directory dir --
+// the packages whose parent it is.
+func (p Packages) Subpackages(dir string) Packages {
+	dir = filepath.Clean(dir)
+	var subpackages Packages
+	for i := range p {
+		if p[i].Path == dir {
+			continue
+		}
+		if parent := p.Parent(p[i].Path); parent != nil && parent.Path == dir {
+			subpackages = append(subpackages, p[i])
+		}
+	}
+	return subpackages
+}
+
+// IsResource returns true if the node is a Resource -- it has an apiVersion and a kind,
+// and isn't a package metadata file, as identified by the file name of its
+// config.kubernetes.io/path annotation.  Packages read with LocalPackageReader contain
+// their kustomization files, which Filters modifying Resources should leave unchanged.
+func IsResource(node *yaml.RNode) bool {
+	meta, err := node.GetMeta()
+	if err != nil || meta.ApiVersion == "" || meta.Kind == "" {
+		return false
+	}
+	path := meta.Annotations[kioutil.PathAnnotation]
+	return path == "" || !IsPackageMetadataFile(filepath.Base(path))
+}
+
+// IsPackageMetadataFile returns true if the file name is one of PackageMetadataFileNames.
+func IsPackageMetadataFile(name string) bool {
+	return packageFileRank(name) >= 0
+}
+
+// packageFileRank returns the precedence of the package metadata file name -- lower is
+// higher -- or -1 if it isn't a package metadata file.
+func packageFileRank(name string) int {
+	for i := range PackageMetadataFileNames {
+		if PackageMetadataFileNames[i] == name {
+			return i
+		}
+	}
+	return -1
+}
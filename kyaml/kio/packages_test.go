@@ -0,0 +1,116 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// packagePaths returns the paths of the packages
+func packagePaths(packages Packages) []string {
+	var paths []string
+	for i := range packages {
+		paths = append(paths, packages[i].Path)
+	}
+	return paths
+}
+
+func TestReadPackages(t *testing.T) {
+	s := setupDirectories(t, "app", filepath.Join("app", "db"), filepath.Join("app", "db", "config"), "docs")
+	defer s.clean()
+	s.writeFile(t, "Kptfile", []byte("apiVersion: kpt.dev/v1alpha1\nkind: Kptfile\nmetadata:\n  name: root\n"))
+	s.writeFile(t, "kustomization.yaml", []byte("resources:\n- app\n"))
+	s.writeFile(t, filepath.Join("app", "kustomization.yaml"), []byte("resources:\n- deployment.yaml\n"))
+	s.writeFile(t, filepath.Join("app", "deployment.yaml"), []byte("kind: Deployment\n"))
+	s.writeFile(t, filepath.Join("app", "db", "Kptfile"), []byte("kind: Kptfile\nmetadata:\n  name: db\n"))
+	s.writeFile(t, filepath.Join("app", "db", "config", "kustomization.yml"), []byte("resources: []\n"))
+	s.writeFile(t, filepath.Join("docs", "service.yaml"), []byte("kind: Service\n"))
+
+	packages, err := ReadPackages(s.root)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{".", "app", filepath.Join("app", "db"),
+		filepath.Join("app", "db", "config")}, packagePaths(packages))
+	// the Kptfile takes precedence over the kustomization file
+	assert.Equal(t, "Kptfile", packages.Get(".").File)
+	assert.Equal(t, "kustomization.yml", packages.Get(filepath.Join("app", "db", "config")).File)
+
+	assert.Equal(t, "app", packages.Of(filepath.Join("app", "deployment.yaml")).Path)
+	assert.Equal(t, ".", packages.Of(filepath.Join("docs", "service.yaml")).Path)
+	assert.Equal(t, "app", packages.Parent(filepath.Join("app", "db")).Path)
+	assert.Nil(t, packages.Parent("."))
+	assert.Nil(t, packages.Get("docs"))
+	assert.Equal(t, []string{"app"}, packagePaths(packages.Subpackages(".")))
+	assert.Equal(t, []string{filepath.Join("app", "db")}, packagePaths(packages.Subpackages("app")))
+
+	// the package metadata is read alongside the Resources
+	nodes, err := LocalPackageReader{PackagePath: s.root, PackageMetadata: true}.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var paths []string
+	for i := range nodes {
+		path, _, err := kioutil.GetFileAnnotations(nodes[i])
+		if !assert.NoError(t, err) {
+			return
+		}
+		paths = append(paths, path)
+	}
+	assert.Contains(t, paths, filepath.Join("app", "db", "Kptfile"))
+	assert.Contains(t, paths, filepath.Join("app", "deployment.yaml"))
+	assert.Equal(t, packagePaths(packages), packagePaths(PackagesOf(nodes)))
+	meta, err := PackagesOf(nodes).Get(filepath.Join("app", "db")).Metadata.GetMeta()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "db", meta.Name)
+	}
+}
+
+func TestIsResource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n", expected: true},
+		{input: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  annotations:
+    config.kubernetes.io/path: app/service.yaml
+`, expected: true},
+		// package metadata files
+		{input: `resources:
+- service.yaml
+metadata:
+  annotations:
+    config.kubernetes.io/path: app/kustomization.yaml
+`},
+		{input: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+metadata:
+  annotations:
+    config.kubernetes.io/path: kustomization.yaml
+`},
+		{input: `apiVersion: kpt.dev/v1alpha1
+kind: Kptfile
+metadata:
+  name: app
+  annotations:
+    config.kubernetes.io/path: app/Kptfile
+`},
+		// documents without an apiVersion or kind
+		{input: "kind: Service\nmetadata:\n  name: web\n"},
+		{input: "a: b\n"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, IsResource(yaml.MustParse(test.input)), test.input)
+	}
+}
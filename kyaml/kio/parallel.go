@@ -0,0 +1,88 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"sync"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ResourceLocalFilter is implemented by Filters which filter each Resource independently
+// of the other Resources -- e.g. setting a field or formatting the Resources -- so that
+// Pipelines with Parallelism may run them over shards of the Resources concurrently.
+//
+// Filters should only be resource-local if filtering each Resource neither reads nor
+// writes state shared with other Resources, including the fields of the Filter.
+type ResourceLocalFilter interface {
+	Filter
+	ResourceLocal() bool
+}
+
+// ResourceLocal declares the Filter resource-local, so that Pipelines with Parallelism may
+// run it concurrently -- e.g. ResourceLocal(FilterAll(yaml.SetLabel("app", "foo"))).
+func ResourceLocal(f Filter) Filter {
+	return resourceLocal{filter: f}
+}
+
+type resourceLocal struct {
+	filter Filter
+}
+
+func (f resourceLocal) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	return f.filter.Filter(nodes)
+}
+
+func (f resourceLocal) ResourceLocal() bool {
+	return true
+}
+
+// isResourceLocal returns true if the Filter may be run over shards of the Resources
+func isResourceLocal(f Filter) bool {
+	if _, ok := f.(MetadataFilter); ok {
+		// Metadata is shared between all the Resources
+		return false
+	}
+	l, ok := f.(ResourceLocalFilter)
+	return ok && l.ResourceLocal()
+}
+
+// filterShards runs the resource-local filters over up to parallelism shards of nodes
+// concurrently, and returns the nodes of the shards in order.  Each shard is filtered by
+// the filters in sequence.
+func filterShards(nodes []*yaml.RNode, filters []Filter, parallelism int) ([]*yaml.RNode, error) {
+	if parallelism > len(nodes) {
+		parallelism = len(nodes)
+	}
+	results := make([][]*yaml.RNode, parallelism)
+	errs := make([]error, parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		// the shards are contiguous, so that the nodes are returned in order, and capped, so
+		// that Filters appending to a shard don't overwrite the next one
+		lo, hi := i*len(nodes)/parallelism, (i+1)*len(nodes)/parallelism
+		shard := nodes[lo:hi:hi]
+		wg.Add(1)
+		go func(i int, shard []*yaml.RNode) {
+			defer wg.Done()
+			for _, f := range filters {
+				if shard, errs[i] = f.Filter(shard); len(shard) == 0 || errs[i] != nil {
+					break
+				}
+			}
+			results[i] = shard
+		}(i, shard)
+	}
+	wg.Wait()
+
+	var result []*yaml.RNode
+	for i := range results {
+		if errs[i] != nil {
+			return nil, errors.Wrap(errs[i])
+		}
+		result = append(result, results[i]...)
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// parallelInput returns n ConfigMaps
+func parallelInput(n int) string {
+	var s []string
+	for i := 0; i < n; i++ {
+		s = append(s, fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c%d\n", i))
+	}
+	return strings.Join(s, "---\n")
+}
+
+func TestPipeline_Execute_parallelism(t *testing.T) {
+	var localCalls, calls int32
+	// dropOdd removes every other ConfigMap, and counts the calls
+	dropOdd := ResourceLocal(FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+		atomic.AddInt32(&localCalls, 1)
+		var result []*yaml.RNode
+		for i := range nodes {
+			meta, err := nodes[i].GetMeta()
+			if err != nil {
+				return nil, err
+			}
+			var n int
+			fmt.Sscanf(meta.Name, "c%d", &n)
+			if n%2 == 0 {
+				result = append(result, nodes[i])
+			}
+		}
+		return result, nil
+	}))
+	// count is not resource-local, so it is called with all the Resources
+	var counted []int
+	count := FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+		calls++
+		counted = append(counted, len(nodes))
+		return nodes, nil
+	})
+	filters := []Filter{
+		ResourceLocal(FilterAll(yaml.SetAnnotation("a", "b"))),
+		dropOdd,
+		count,
+		ResourceLocal(FilterAll(yaml.SetLabel("c", "d"))),
+	}
+
+	input := parallelInput(1000)
+	expected := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs:  []Reader{&ByteReader{Reader: strings.NewReader(input)}},
+		Filters: filters,
+		Outputs: []Writer{ByteWriter{Writer: expected}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int32(1), localCalls)
+
+	for _, parallelism := range []int{2, 7, 2000} {
+		localCalls, calls, counted = 0, 0, nil
+		actual := &bytes.Buffer{}
+		err := Pipeline{
+			Inputs:      []Reader{&ByteReader{Reader: strings.NewReader(input)}},
+			Filters:     filters,
+			Outputs:     []Writer{ByteWriter{Writer: actual}},
+			Parallelism: parallelism,
+		}.Execute()
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, expected.String(), actual.String(), parallelism)
+		if parallelism > 1000 {
+			parallelism = 1000
+		}
+		assert.Equal(t, int32(parallelism), localCalls, parallelism)
+		assert.Equal(t, int32(1), calls, parallelism)
+		assert.Equal(t, []int{500}, counted, parallelism)
+	}
+}
+
+func TestPipeline_Execute_parallelismError(t *testing.T) {
+	fail := ResourceLocal(FilterAll(yaml.FilterFunc(func(node *yaml.RNode) (*yaml.RNode, error) {
+		meta, err := node.GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		if meta.Name == "c42" {
+			return nil, fmt.Errorf("invalid ConfigMap %s", meta.Name)
+		}
+		return node, nil
+	})))
+	err := Pipeline{
+		Inputs:      []Reader{&ByteReader{Reader: strings.NewReader(parallelInput(100))}},
+		Filters:     []Filter{fail},
+		Outputs:     []Writer{ByteWriter{Writer: &bytes.Buffer{}}},
+		Parallelism: 4,
+	}.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid ConfigMap c42")
+	}
+}
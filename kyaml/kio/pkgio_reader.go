@@ -5,8 +5,12 @@ package kio
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"sigs.k8s.io/kustomize/kyaml/errors"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
@@ -52,6 +56,22 @@ type LocalPackageReadWriter struct {
 	// Defaults to ["*.yaml", "*.yml"] if empty.  To match all files specify ["*"].
 	MatchFilesGlob []string `yaml:"matchFilesGlob,omitempty"`
 
+	// Include configures Read to only read Resources from the files whose path relative
+	// to PackagePath matches any of the patterns, in addition to MatchFilesGlob.  ** in a
+	// pattern matches any number of directories, and patterns without a / match the file
+	// name in any directory -- e.g. ["*.yaml"] or ["manifests/**"].
+	Include []string `yaml:"include,omitempty"`
+
+	// Exclude configures Read to skip the files and directories whose path relative to
+	// PackagePath matches any of the patterns, as for Include -- e.g. ["**/testdata/**"].
+	// Excluded directories aren't traversed.
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	// FollowSymlinks and SymlinkRoot configure Read to traverse symlinked directories, as
+	// for LocalPackageReader.
+	FollowSymlinks bool   `yaml:"followSymlinks,omitempty"`
+	SymlinkRoot    string `yaml:"symlinkRoot,omitempty"`
+
 	// IncludeSubpackages will configure Read to read Resources from subpackages.
 	// Subpackages are identified by presence of PackageFileName.
 	IncludeSubpackages bool `yaml:"includeSubpackages,omitempty"`
@@ -70,6 +90,27 @@ type LocalPackageReadWriter struct {
 	// NoDeleteFiles if set to true, LocalPackageReadWriter won't delete any files
 	NoDeleteFiles bool `yaml:"noDeleteFiles,omitempty"`
 
+	// Backup if set will keep a copy of each file replaced or deleted by Write, suffixed
+	// with BackupSuffix.
+	Backup bool `yaml:"backup,omitempty"`
+
+	// Manifest if set is populated by Write with the files it created, updated and deleted.
+	Manifest *WriteManifest `yaml:"-"`
+
+	// AliasMode configures how anchors and aliases are handled when reading.
+	AliasMode AliasMode `yaml:"aliasMode,omitempty"`
+
+	// DuplicateKeyMode configures how duplicate keys are handled when reading.
+	DuplicateKeyMode DuplicateKeyMode `yaml:"duplicateKeyMode,omitempty"`
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer `yaml:"-"`
+
+	// Limits guard reading against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits `yaml:"limits,omitempty"`
+
 	files sets.String
 }
 
@@ -77,9 +118,17 @@ func (r *LocalPackageReadWriter) Read() ([]*yaml.RNode, error) {
 	nodes, err := LocalPackageReader{
 		PackagePath:         r.PackagePath,
 		MatchFilesGlob:      r.MatchFilesGlob,
+		Include:             r.Include,
+		Exclude:             r.Exclude,
+		FollowSymlinks:      r.FollowSymlinks,
+		SymlinkRoot:         r.SymlinkRoot,
 		IncludeSubpackages:  r.IncludeSubpackages,
 		ErrorIfNonResources: r.ErrorIfNonResources,
 		SetAnnotations:      r.SetAnnotations,
+		AliasMode:           r.AliasMode,
+		DuplicateKeyMode:    r.DuplicateKeyMode,
+		Warnings:            r.Warnings,
+		Limits:              r.Limits,
 	}.Read()
 	if err != nil {
 		return nil, errors.Wrap(err)
@@ -94,6 +143,38 @@ func (r *LocalPackageReadWriter) Read() ([]*yaml.RNode, error) {
 	return nodes, nil
 }
 
+// withReadPolicy returns the LocalPackageReadWriter configured to use the AliasMode and
+// DuplicateKeyMode
+func (r *LocalPackageReadWriter) withReadPolicy(
+	aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns the LocalPackageReadWriter configured to write warnings to w,
+// unless it sets its own
+func (r *LocalPackageReadWriter) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// withLimits returns the LocalPackageReadWriter configured to use the Limits, unless it
+// sets its own
+func (r *LocalPackageReadWriter) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
+
+// deletesFiles returns true unless the LocalPackageReadWriter is configured not to delete
+// the files of the Resources it doesn't write
+func (r *LocalPackageReadWriter) deletesFiles() bool {
+	return !r.NoDeleteFiles
+}
+
 func (r *LocalPackageReadWriter) Write(nodes []*yaml.RNode) error {
 	newFiles, err := r.getFiles(nodes)
 	if err != nil {
@@ -103,19 +184,33 @@ func (r *LocalPackageReadWriter) Write(nodes []*yaml.RNode) error {
 	for k := range r.SetAnnotations {
 		clear = append(clear, k)
 	}
+	manifest := WriteManifest{}
 	err = LocalPackageWriter{
 		PackagePath:           r.PackagePath,
 		ClearAnnotations:      clear,
 		KeepReaderAnnotations: r.KeepReaderAnnotations,
+		Backup:                r.Backup,
+		Manifest:              &manifest,
 	}.Write(nodes)
 	if err != nil {
 		return errors.Wrap(err)
 	}
-	deleteFiles := r.files.Difference(newFiles)
-	for f := range deleteFiles {
-		if err = os.Remove(filepath.Join(r.PackagePath, f)); err != nil {
+	deleteFiles := r.files.Difference(newFiles).List()
+	sort.Strings(deleteFiles)
+	for _, f := range deleteFiles {
+		path := filepath.Join(r.PackagePath, f)
+		if r.Backup {
+			err = os.Rename(path, path+BackupSuffix)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
 			return errors.Wrap(err)
 		}
+		manifest.Deleted = append(manifest.Deleted, f)
+	}
+	if r.Manifest != nil {
+		*r.Manifest = manifest
 	}
 	return nil
 }
@@ -148,6 +243,28 @@ type LocalPackageReader struct {
 	// Defaults to ["*.yaml", "*.yml"] if empty.  To match all files specify ["*"].
 	MatchFilesGlob []string `yaml:"matchFilesGlob,omitempty"`
 
+	// Include configures Read to only read Resources from the files whose path relative
+	// to PackagePath matches any of the patterns, in addition to MatchFilesGlob.  ** in a
+	// pattern matches any number of directories, and patterns without a / match the file
+	// name in any directory -- e.g. ["*.yaml"] or ["manifests/**"].
+	Include []string `yaml:"include,omitempty"`
+
+	// Exclude configures Read to skip the files and directories whose path relative to
+	// PackagePath matches any of the patterns, as for Include -- e.g. ["**/testdata/**"].
+	// Excluded directories aren't traversed.
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	// FollowSymlinks configures Read to traverse symlinked directories -- e.g. the shared
+	// bases of monorepos -- reading their files at their paths through the symlinks.
+	// Symlinks must resolve within SymlinkRoot, and symlinks to a directory containing
+	// them are skipped rather than followed in a cycle.  Symlinked files are read whether
+	// or not FollowSymlinks is set.
+	FollowSymlinks bool `yaml:"followSymlinks,omitempty"`
+
+	// SymlinkRoot is the directory the symlinks followed by FollowSymlinks must resolve
+	// within -- e.g. the root of the repository.  Defaults to PackagePath.
+	SymlinkRoot string `yaml:"symlinkRoot,omitempty"`
+
 	// IncludeSubpackages will configure Read to read Resources from subpackages.
 	// Subpackages are identified by presence of PackageFileName.
 	IncludeSubpackages bool `yaml:"includeSubpackages,omitempty"`
@@ -162,10 +279,79 @@ type LocalPackageReader struct {
 
 	// SetAnnotations are annotations to set on the Resources as they are read.
 	SetAnnotations map[string]string `yaml:"setAnnotations,omitempty"`
+
+	// ParseMode configures how the Resources are parsed.  Defaults to ParseModePreserve.
+	ParseMode ParseMode `yaml:"parseMode,omitempty"`
+
+	// AliasMode configures how anchors and aliases are handled.  Defaults to
+	// AliasModePreserve.
+	AliasMode AliasMode `yaml:"aliasMode,omitempty"`
+
+	// DuplicateKeyMode configures how duplicate keys are handled.  Defaults to
+	// DuplicateKeyModeError.
+	DuplicateKeyMode DuplicateKeyMode `yaml:"duplicateKeyMode,omitempty"`
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer `yaml:"-"`
+
+	// Limits guard reading against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits `yaml:"limits,omitempty"`
+
+	// ContinueOnError configures Read to skip the documents which can't be parsed rather
+	// than failing, and to return the Resources of the rest of the package together with a
+	// ReadErrors listing the skipped documents by file and line.
+	ContinueOnError bool `yaml:"continueOnError,omitempty"`
+
+	// PackageMetadata configures Read to also read the package metadata files --
+	// Kptfiles and kustomization files -- whether or not they match MatchFilesGlob and
+	// Include, so that the package structure may be read with PackagesOf alongside the
+	// Resources.  Excluded files are still skipped.
+	PackageMetadata bool `yaml:"packageMetadata,omitempty"`
 }
 
 var _ Reader = LocalPackageReader{}
 
+// withParseMode returns a copy of the LocalPackageReader configured to use the ParseMode
+func (r LocalPackageReader) withParseMode(mode ParseMode) Reader {
+	r.ParseMode = mode
+	return r
+}
+
+// withReadPolicy returns a copy of the LocalPackageReader configured to use the AliasMode
+// and DuplicateKeyMode
+func (r LocalPackageReader) withReadPolicy(
+	aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns a copy of the LocalPackageReader configured to write warnings to w,
+// unless it sets its own
+func (r LocalPackageReader) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// withContinueOnError returns a copy of the LocalPackageReader configured to skip the
+// documents which can't be parsed
+func (r LocalPackageReader) withContinueOnError() Reader {
+	r.ContinueOnError = true
+	return r
+}
+
+// withLimits returns a copy of the LocalPackageReader configured to use the Limits, unless
+// it sets its own
+func (r LocalPackageReader) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
+
 var defaultMatch = []string{"*.yaml", "*.yml"}
 
 // Read reads the Resources.
@@ -176,11 +362,22 @@ func (r LocalPackageReader) Read() ([]*yaml.RNode, error) {
 	if len(r.MatchFilesGlob) == 0 {
 		r.MatchFilesGlob = defaultMatch
 	}
+	// fail before reading any files if a pattern is invalid
+	for _, p := range append(append([]string{}, r.Include...), r.Exclude...) {
+		if err := validatePattern(p); err != nil {
+			return nil, errors.WrapPrefixf(err, "invalid pattern %s", p)
+		}
+	}
 
 	var operand ResourceNodeSlice
+	var skipped ReadErrors
 	var pathRelativeTo string
 	r.PackagePath = filepath.Clean(r.PackagePath)
-	err := filepath.Walk(r.PackagePath, func(
+	walk := filepath.Walk
+	if r.FollowSymlinks {
+		walk = r.walkSymlinks
+	}
+	err := walk(r.PackagePath, func(
 		path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return errors.Wrap(err)
@@ -199,33 +396,46 @@ func (r LocalPackageReader) Read() ([]*yaml.RNode, error) {
 			pathRelativeTo = filepath.Dir(r.PackagePath)
 		}
 
+		// get the relative path to file within the package so we can write the files back out
+		// to another location.
+		rel, err := filepath.Rel(pathRelativeTo, path)
+		if err != nil {
+			return errors.WrapPrefixf(err, pathRelativeTo)
+		}
+
 		// check if we should skip the directory or file
 		if info.IsDir() {
+			if r.isExcluded(rel, true) {
+				return filepath.SkipDir
+			}
 			return r.shouldSkipDir(path)
 		}
-		if match, err := r.shouldSkipFile(info); err != nil {
+		if match, err := r.shouldSkipFile(rel, info); err != nil {
 			return err
 		} else if !match {
 			// skip this file
 			return nil
 		}
-
-		// get the relative path to file within the package so we can write the files back out
-		// to another location.
-		path, err = filepath.Rel(pathRelativeTo, path)
-		if err != nil {
-			return errors.WrapPrefixf(err, pathRelativeTo)
-		}
+		path = rel
 
 		r.initReaderAnnotations(path, info)
 		nodes, err := r.readFile(filepath.Join(pathRelativeTo, path), info)
-		if err != nil {
+		if errs, ok := err.(ReadErrors); ok {
+			for i := range errs {
+				errs[i].File = filepath.Join(pathRelativeTo, path)
+			}
+			skipped = append(skipped, errs...)
+		} else if err != nil {
 			return errors.WrapPrefixf(err, filepath.Join(pathRelativeTo, path))
 		}
 		operand = append(operand, nodes...)
-		return nil
+		// fail before reading the rest of the package once it has too many Resources
+		return r.Limits.checkCount(len(operand))
 	})
-	return operand, err
+	if err != nil {
+		return operand, err
+	}
+	return operand, skipped.err()
 }
 
 // readFile reads the ResourceNodes from a file
@@ -240,12 +450,28 @@ func (r *LocalPackageReader) readFile(path string, info os.FileInfo) ([]*yaml.RN
 		Reader:                f,
 		OmitReaderAnnotations: r.OmitReaderAnnotations,
 		SetAnnotations:        r.SetAnnotations,
+		ParseMode:             r.ParseMode,
+		AliasMode:             r.AliasMode,
+		DuplicateKeyMode:      r.DuplicateKeyMode,
+		Warnings:              r.Warnings,
+		Limits:                r.Limits,
+		ContinueOnError:       r.ContinueOnError,
 	}
 	return rr.Read()
 }
 
-// shouldSkipFile returns true if the file should be skipped
-func (r *LocalPackageReader) shouldSkipFile(info os.FileInfo) (bool, error) {
+// shouldSkipFile returns true if the file, at the path relative to the package, should be
+// read
+func (r *LocalPackageReader) shouldSkipFile(rel string, info os.FileInfo) (bool, error) {
+	if r.isExcluded(rel, false) {
+		return false, nil
+	}
+	if r.PackageMetadata && IsPackageMetadataFile(info.Name()) {
+		return true, nil
+	}
+	if len(r.Include) > 0 && !matchAny(r.Include, rel) {
+		return false, nil
+	}
 	// check if the files are in scope
 	for _, g := range r.MatchFilesGlob {
 		if match, err := filepath.Match(g, info.Name()); err != nil {
@@ -257,6 +483,83 @@ func (r *LocalPackageReader) shouldSkipFile(info os.FileInfo) (bool, error) {
 	return false, nil
 }
 
+// isExcluded returns true if the file or directory at the path relative to the package
+// matches an Exclude pattern.  A directory also matches the patterns matching all of its
+// contents -- e.g. testdata/** -- so that it isn't traversed.
+func (r *LocalPackageReader) isExcluded(rel string, dir bool) bool {
+	if rel == "." {
+		return false
+	}
+	if matchAny(r.Exclude, rel) {
+		return true
+	}
+	if !dir {
+		return false
+	}
+	for _, p := range r.Exclude {
+		if strings.HasSuffix(p, "/**") && matchAny([]string{strings.TrimSuffix(p, "/**")}, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny returns true if the slash-separated path matches any of the patterns.  The
+// patterns are validated by Read.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if match, _ := matchPath(p, rel); match {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath returns true if the path relative to the package matches the pattern.  **
+// matches any number of directories, and patterns without a / match the last element of
+// the path.
+func matchPath(pattern, rel string) (bool, error) {
+	names := strings.Split(filepath.ToSlash(rel), "/")
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		return path.Match(pattern, names[len(names)-1])
+	}
+	return matchNames(strings.Split(pattern, "/"), names)
+}
+
+// validatePattern returns an error if an element of the pattern is malformed
+func validatePattern(pattern string) error {
+	for _, p := range strings.Split(pattern, "/") {
+		if _, err := path.Match(p, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchNames matches the elements of a path against the elements of a pattern
+func matchNames(pattern, names []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// match the rest of the pattern against each suffix of the names
+			for i := 0; i <= len(names); i++ {
+				if match, err := matchNames(pattern[1:], names[i:]); match || err != nil {
+					return match, err
+				}
+			}
+			return false, nil
+		}
+		if len(names) == 0 {
+			return false, nil
+		}
+		match, err := path.Match(pattern[0], names[0])
+		if !match || err != nil {
+			return false, err
+		}
+		pattern, names = pattern[1:], names[1:]
+	}
+	return len(names) == 0, nil
+}
+
 // initReaderAnnotations adds the LocalPackageReader Annotations to r.SetAnnotations
 func (r *LocalPackageReader) initReaderAnnotations(path string, info os.FileInfo) {
 	if r.SetAnnotations == nil {
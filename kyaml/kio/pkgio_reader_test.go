@@ -436,6 +436,109 @@ metadata:
 `, val)
 }
 
+func TestLocalPackageReader_Read_includeExclude(t *testing.T) {
+	s := setupDirectories(t, filepath.Join("a", "b"), filepath.Join("a", "c"))
+	defer s.clean()
+	s.writeFile(t, filepath.Join("a", "b", "a_test.yaml"), readFileA)
+	s.writeFile(t, filepath.Join("a", "b", "b_test.yml"), readFileB)
+	s.writeFile(t, filepath.Join("a", "c", "c_test.yaml"), readFileB)
+	// invalid yaml is never read, as excluded directories aren't traversed
+	s.writeFile(t, filepath.Join("a", "testdata", "bad.yaml"), []byte("a: [b"))
+	s.writeFile(t, filepath.Join("testdata", "bad.yaml"), []byte("a: [b"))
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{name: "include file names",
+			include:  []string{"*.yaml"},
+			exclude:  []string{"**/testdata/**"},
+			expected: []string{"a/b/a_test.yaml", "a/b/a_test.yaml", "a/c/c_test.yaml"}},
+		{name: "include directory",
+			include:  []string{"a/b/**"},
+			expected: []string{"a/b/a_test.yaml", "a/b/a_test.yaml", "a/b/b_test.yml"}},
+		{name: "exclude files",
+			exclude:  []string{"testdata", "a_*", "a/c/*.yaml"},
+			expected: []string{"a/b/b_test.yml"}},
+	}
+	for _, test := range tests {
+		nodes, err := LocalPackageReader{PackagePath: s.root,
+			Include: test.include, Exclude: test.exclude}.Read()
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		var paths []string
+		for i := range nodes {
+			meta, err := nodes[i].GetMeta()
+			if !assert.NoError(t, err, test.name) {
+				break
+			}
+			paths = append(paths, meta.Annotations["config.kubernetes.io/path"])
+		}
+		assert.Equal(t, test.expected, paths, test.name)
+	}
+
+	// the patterns are validated before reading
+	_, err := LocalPackageReader{PackagePath: s.root, Exclude: []string{"a/[b"}}.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid pattern a/[b")
+	}
+}
+
+func TestLocalPackageReader_Read_followSymlinks(t *testing.T) {
+	s := setupDirectories(t, filepath.Join("pkg", "app"), filepath.Join("bases", "base"))
+	defer s.clean()
+	s.writeFile(t, filepath.Join("pkg", "app", "a_test.yaml"), readFileA)
+	s.writeFile(t, filepath.Join("bases", "base", "b_test.yaml"), readFileB)
+	// the shared base is symlinked into the package, and the package into itself
+	err := os.Symlink(filepath.Join("..", "..", "bases", "base"), filepath.Join(s.root, "pkg", "app", "base"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = os.Symlink("..", filepath.Join(s.root, "pkg", "app", "loop"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	paths := func(r LocalPackageReader) ([]string, error) {
+		nodes, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		var paths []string
+		for i := range nodes {
+			meta, err := nodes[i].GetMeta()
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, meta.Annotations["config.kubernetes.io/path"])
+		}
+		return paths, nil
+	}
+
+	// symlinked directories aren't traversed by default
+	actual, err := paths(LocalPackageReader{PackagePath: filepath.Join(s.root, "pkg")})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"app/a_test.yaml", "app/a_test.yaml"}, actual)
+	}
+
+	// the files of symlinked directories are read through the symlinks
+	actual, err = paths(LocalPackageReader{PackagePath: filepath.Join(s.root, "pkg"),
+		FollowSymlinks: true, SymlinkRoot: s.root})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"app/a_test.yaml", "app/a_test.yaml", "app/base/b_test.yaml"}, actual)
+	}
+
+	// symlinks must resolve within the package by default
+	_, err = paths(LocalPackageReader{PackagePath: filepath.Join(s.root, "pkg"), FollowSymlinks: true})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), filepath.Join("app", "base")+" resolves to ")
+		assert.Contains(t, err.Error(), filepath.Join("bases", "base")+" outside of ")
+	}
+}
+
 // func TestLocalPackageReaderWriter_DeleteFiles(t *testing.T) {
 // 	g, _, clean := testutil.SetupDefaultRepoAndWorkspace(t)
 // 	defer clean()
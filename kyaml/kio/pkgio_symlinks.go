@@ -0,0 +1,97 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// walkSymlinks walks the files and directories of root as filepath.Walk does, but following
+// the symlinks -- which must resolve within SymlinkRoot.  The files are walked at their
+// paths through the symlinks, so that they are written back through them.
+func (r *LocalPackageReader) walkSymlinks(root string, fn filepath.WalkFunc) error {
+	limit := r.SymlinkRoot
+	if limit == "" {
+		limit = root
+	}
+	limit, err := realPath(limit)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	return walkPath(root, info, limit, map[string]bool{}, fn)
+}
+
+// walkPath walks path, following the symlinks within limit.  ancestors are the resolved
+// paths of the directories containing path, so that cycles are skipped.
+func walkPath(path string, info os.FileInfo, limit string, ancestors map[string]bool,
+	fn filepath.WalkFunc) error {
+	if err := fn(path, info, nil); err != nil {
+		if info.IsDir() && err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+
+	real, err := realPath(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	ancestors[real] = true
+	defer delete(ancestors, real)
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	for _, e := range entries {
+		p := filepath.Join(path, e.Name())
+		if e.Mode()&os.ModeSymlink != 0 {
+			target, err := realPath(p)
+			if err != nil {
+				return fn(p, e, err)
+			}
+			if !withinDir(limit, target) {
+				return errors.Errorf("symlink %s resolves to %s outside of %s", p, target, limit)
+			}
+			if ancestors[target] {
+				// the symlink is to a directory containing it
+				continue
+			}
+			if e, err = os.Stat(p); err != nil {
+				return fn(p, e, err)
+			}
+		}
+		if err := walkPath(p, e, limit, ancestors, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// realPath returns the absolute path of path with its symlinks resolved
+func realPath(path string) (string, error) {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// withinDir returns true if path is dir or within it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -4,9 +4,12 @@
 package kio
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/kustomize/kyaml/errors"
@@ -26,11 +29,54 @@ type LocalPackageWriter struct {
 
 	// ClearAnnotations will clear annotations before writing the resources
 	ClearAnnotations []string `yaml:"clearAnnotations,omitempty"`
+
+	// ApplySet if set will write the Resources as the members of an apply set, and write
+	// the parent of the set to its Path -- defaulting to DefaultApplySetPath -- if they
+	// don't contain it.  See ApplySet.
+	ApplySet *ApplySet `yaml:"applySet,omitempty"`
+
+	// Backup if set will keep a copy of each file replaced by Write, suffixed with
+	// BackupSuffix.
+	Backup bool `yaml:"backup,omitempty"`
+
+	// Manifest if set is populated by Write with the files it created and updated.
+	Manifest *WriteManifest `yaml:"-"`
+}
+
+// BackupSuffix is the suffix of the copies of the files replaced or deleted by the package
+// writers with Backup
+const BackupSuffix = ".bak"
+
+// WriteManifest lists the files written to a package, relative to the package
+type WriteManifest struct {
+	// Created are the files which didn't exist
+	Created []string `yaml:"created,omitempty"`
+
+	// Updated are the files which were replaced with different contents
+	Updated []string `yaml:"updated,omitempty"`
+
+	// Unchanged are the files whose contents were already those written, which aren't
+	// rewritten
+	Unchanged []string `yaml:"unchanged,omitempty"`
+
+	// Deleted are the files deleted by LocalPackageReadWriter
+	Deleted []string `yaml:"deleted,omitempty"`
 }
 
 var _ Writer = LocalPackageWriter{}
 
 func (r LocalPackageWriter) Write(nodes []*yaml.RNode) error {
+	if r.ApplySet != nil {
+		a := *r.ApplySet
+		if a.Path == "" {
+			a.Path = DefaultApplySetPath
+		}
+		var err error
+		if nodes, err = a.Filter(nodes); err != nil {
+			return err
+		}
+	}
+
 	if err := kioutil.ErrorIfMissingAnnotation(nodes, requiredResourcePackageAnnotations...); err != nil {
 		return err
 	}
@@ -80,34 +126,116 @@ func (r LocalPackageWriter) Write(nodes []*yaml.RNode) error {
 		}
 	}
 
-	// write files
+	// write each file to a temporary file before replacing any by renaming it, so that an
+	// interrupted write doesn't leave partially written files
+	var paths []string
 	for path := range outputFiles {
-		outputPath := filepath.Join(r.PackagePath, path)
-		err = os.MkdirAll(filepath.Dir(filepath.Join(r.PackagePath, path)), 0700)
-		if err != nil {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	files := make([]*packageFile, len(paths))
+	defer func() {
+		for _, f := range files {
+			if f != nil && f.temp != "" {
+				os.Remove(f.temp)
+			}
+		}
+	}()
+	for i, path := range paths {
+		buff := &bytes.Buffer{}
+		w := ByteWriter{
+			Writer:                buff,
+			KeepReaderAnnotations: r.KeepReaderAnnotations,
+			ClearAnnotations:      r.ClearAnnotations,
+		}
+		if err = w.Write(outputFiles[path]); err != nil {
 			return errors.Wrap(err)
 		}
-
-		f, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0600))
-		if err != nil {
+		if files[i], err = stageFile(filepath.Join(r.PackagePath, path), buff.Bytes()); err != nil {
 			return errors.Wrap(err)
 		}
-		if err := func() error {
-			defer f.Close()
-			w := ByteWriter{
-				Writer:                f,
-				KeepReaderAnnotations: r.KeepReaderAnnotations,
-				ClearAnnotations:      r.ClearAnnotations,
-			}
-			if err = w.Write(outputFiles[path]); err != nil {
-				return errors.Wrap(err)
-			}
-			return nil
-		}(); err != nil {
+	}
+
+	manifest := WriteManifest{}
+	for i, f := range files {
+		switch {
+		case f.temp == "":
+			manifest.Unchanged = append(manifest.Unchanged, paths[i])
+			continue
+		case f.previous == nil:
+			manifest.Created = append(manifest.Created, paths[i])
+		default:
+			manifest.Updated = append(manifest.Updated, paths[i])
+		}
+		if err := f.replace(r.Backup); err != nil {
 			return errors.Wrap(err)
 		}
 	}
+	if r.Manifest != nil {
+		*r.Manifest = manifest
+	}
+	return nil
+}
+
+// packageFile is a file staged to be written to a package
+type packageFile struct {
+	// path is the path of the file, and temp the path of the temporary file written with
+	// its new contents -- empty if the contents are unchanged
+	path, temp string
+
+	// previous are the contents of the file before it is replaced, if it exists, and mode
+	// its mode
+	previous []byte
+	mode     os.FileMode
+}
+
+// stageFile writes the contents of the file at path to a temporary file in the same
+// directory, unless they are unchanged.  Symlinked files are written to their targets.
+func stageFile(path string, contents []byte) (*packageFile, error) {
+	f := &packageFile{path: path, mode: 0600}
+	if info, err := os.Stat(path); err == nil {
+		if f.path, err = filepath.EvalSymlinks(path); err != nil {
+			return nil, err
+		}
+		f.mode = info.Mode().Perm()
+		if f.previous, err = ioutil.ReadFile(f.path); err != nil {
+			return nil, err
+		}
+		if bytes.Equal(f.previous, contents) {
+			return f, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(f.path), "."+filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	f.temp = temp.Name()
+	if _, err := temp.Write(contents); err != nil {
+		temp.Close()
+		return nil, err
+	}
+	if err := temp.Chmod(f.mode); err != nil {
+		temp.Close()
+		return nil, err
+	}
+	return f, temp.Close()
+}
 
+// replace renames the temporary file to the file, first copying the previous contents to
+// the backup file if backup is set
+func (f *packageFile) replace(backup bool) error {
+	if backup && f.previous != nil {
+		if err := ioutil.WriteFile(f.path+BackupSuffix, f.previous, f.mode); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(f.temp, f.path); err != nil {
+		return err
+	}
+	f.temp = ""
 	return nil
 }
 
@@ -286,6 +286,108 @@ metadata:
 	}
 }
 
+// TestLocalPackageWriter_Write_manifest tests:
+// - the files created, updated and unchanged are listed in the Manifest
+// - the replaced files are backed up
+// - symlinked files are written through the symlinks
+func TestLocalPackageWriter_Write_manifest(t *testing.T) {
+	d, node1, node2, node3 := getWriterInputs(t)
+	defer os.RemoveAll(d)
+
+	manifest := &WriteManifest{}
+	w := LocalPackageWriter{PackagePath: d, Manifest: manifest, Backup: true}
+	if !assert.NoError(t, w.Write([]*yaml.RNode{node2, node1, node3})) {
+		return
+	}
+	assert.Equal(t, &WriteManifest{
+		Created: []string{"a/b/a_test.yaml", "a/b/b_test.yaml"}}, manifest)
+
+	// b_test.yaml is symlinked
+	target := filepath.Join(d, "a", "b", "b_test.target")
+	if !assert.NoError(t, os.Rename(filepath.Join(d, "a", "b", "b_test.yaml"), target)) {
+		return
+	}
+	if !assert.NoError(t, os.Symlink("b_test.target", filepath.Join(d, "a", "b", "b_test.yaml"))) {
+		return
+	}
+
+	// the Resources are read back, as writing them clears their annotations
+	nodes, err := LocalPackageReader{PackagePath: d}.Read()
+	if !assert.NoError(t, err) || !assert.Len(t, nodes, 3) {
+		return
+	}
+	if !assert.NoError(t, nodes[2].PipeE(yaml.SetField("e", yaml.NewScalarRNode("updated")))) {
+		return
+	}
+	if !assert.NoError(t, w.Write(nodes)) {
+		return
+	}
+	assert.Equal(t, &WriteManifest{
+		Updated:   []string{"a/b/b_test.yaml"},
+		Unchanged: []string{"a/b/a_test.yaml"}}, manifest)
+
+	b, err := ioutil.ReadFile(target)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `e: updated
+g:
+  h:
+  - i # has a list
+  - j
+`, string(b))
+	b, err = ioutil.ReadFile(target + BackupSuffix)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `e: f
+g:
+  h:
+  - i # has a list
+  - j
+`, string(b))
+
+	// the temporary files are renamed, and the unchanged files aren't backed up
+	files, err := ioutil.ReadDir(filepath.Join(d, "a", "b"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{"a_test.yaml", "b_test.target", "b_test.target.bak", "b_test.yaml"}, names)
+}
+
+// TestLocalPackageReadWriter_Write_manifest tests:
+// - the deleted files are listed in the Manifest, and backed up
+func TestLocalPackageReadWriter_Write_manifest(t *testing.T) {
+	d, node1, node2, node3 := getWriterInputs(t)
+	defer os.RemoveAll(d)
+	if !assert.NoError(t, LocalPackageWriter{PackagePath: d}.Write(
+		[]*yaml.RNode{node1, node2, node3})) {
+		return
+	}
+
+	manifest := &WriteManifest{}
+	rw := &LocalPackageReadWriter{PackagePath: d, Manifest: manifest, Backup: true}
+	nodes, err := rw.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, rw.Write(nodes[:2])) {
+		return
+	}
+	assert.Equal(t, &WriteManifest{
+		Unchanged: []string{"a/b/a_test.yaml"},
+		Deleted:   []string{"a/b/b_test.yaml"}}, manifest)
+
+	_, err = os.Stat(filepath.Join(d, "a", "b", "b_test.yaml"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(d, "a", "b", "b_test.yaml"+BackupSuffix))
+	assert.NoError(t, err)
+}
+
 func getWriterInputs(t *testing.T) (string, *yaml.RNode, *yaml.RNode, *yaml.RNode) {
 	node1, err := yaml.Parse(`a: b #first
 metadata:
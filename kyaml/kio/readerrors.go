@@ -0,0 +1,87 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReadError is an error reading a document, which was skipped by a Reader configured to
+// continue on error.
+type ReadError struct {
+	// File is the path of the file the document was read from, if any.
+	File string
+
+	// Line is the line of the input the error was found on if known, otherwise the line
+	// the document starts on.
+	Line int
+
+	// Err is the cause of the error.
+	Err error
+}
+
+func (e ReadError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+// ReadErrors are the errors of the documents skipped by Readers configured to continue on
+// error.  Readers return them together with the Resources of the other documents.
+type ReadErrors []ReadError
+
+func (e ReadErrors) Error() string {
+	var lines []string
+	for i := range e {
+		lines = append(lines, "  "+e[i].Error())
+	}
+	return fmt.Sprintf("%d documents could not be read:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// err returns the ReadErrors as an error, or nil if there are none
+func (e ReadErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// continueOnErrorReader is implemented by the Readers which support continuing on error
+type continueOnErrorReader interface {
+	withContinueOnError() Reader
+}
+
+// fileDeleter is implemented by the Writers which may delete files -- e.g. those of
+// the Resources which are no longer written
+type fileDeleter interface {
+	deletesFiles() bool
+}
+
+// yamlErrorLine matches the line of the YAML parser errors
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+):`)
+
+// newReadError returns the ReadError for the document following offset lines of the
+// input, with the line of err if it has one.  The line of err is relative to the document,
+// so it is removed from the cause.
+func newReadError(offset int, err error) ReadError {
+	line := offset + 1
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		if n, convErr := strconv.Atoi(m[1]); convErr == nil {
+			line = offset + n
+			err = fmt.Errorf("yaml:%s", strings.TrimPrefix(err.Error(), m[0]))
+		}
+	}
+	return ReadError{Line: line, Err: err}
+}
+
+// partial returns true if err only reports the documents skipped by a Reader continuing
+// on error, so that the Resources it read may be used.
+func partial(err error) bool {
+	_, ok := err.(ReadErrors)
+	return ok
+}
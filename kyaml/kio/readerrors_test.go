@@ -0,0 +1,113 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestByteReader_Read_continueOnError(t *testing.T) {
+	in := "a: b\n---\nc: [d\n---\ne: f\ne: g\n---\nh: i\n"
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(in)}).Read()
+	assert.Error(t, err)
+	assert.Nil(t, nodes)
+
+	nodes, err = (&ByteReader{Reader: bytes.NewBufferString(in), ContinueOnError: true}).Read()
+	if !assert.IsType(t, ReadErrors{}, err) {
+		t.FailNow()
+	}
+	errs := err.(ReadErrors)
+	if assert.Len(t, errs, 2) {
+		assert.Equal(t, 3, errs[0].Line)
+		assert.Equal(t, 5, errs[1].Line)
+	}
+	if assert.Len(t, nodes, 2) {
+		assert.Equal(t, "b", nodes[0].Field("a").Value.YNode().Value)
+		assert.Equal(t, "i", nodes[1].Field("h").Value.YNode().Value)
+	}
+}
+
+func TestLocalPackageReader_Read_continueOnError(t *testing.T) {
+	s := setupDirectories(t, "a")
+	defer s.clean()
+	s.writeFile(t, filepath.Join("a", "broken.yaml"), []byte("a: b\n---\nc: [d\n"))
+	s.writeFile(t, filepath.Join("a", "ok.yaml"), []byte("e: f\n"))
+
+	_, err := LocalPackageReader{PackagePath: s.root}.Read()
+	assert.Error(t, err)
+
+	nodes, err := LocalPackageReader{PackagePath: s.root, ContinueOnError: true}.Read()
+	if !assert.IsType(t, ReadErrors{}, err) {
+		t.FailNow()
+	}
+	errs := err.(ReadErrors)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, filepath.Join(s.root, "a", "broken.yaml"), errs[0].File)
+		assert.Equal(t, 3, errs[0].Line)
+		assert.Contains(t, errs[0].Error(), filepath.Join("a", "broken.yaml")+":3: yaml: did not find")
+	}
+	assert.Len(t, nodes, 2)
+}
+
+func TestPipeline_Execute_continueOnError(t *testing.T) {
+	var written []*yaml.RNode
+	err := Pipeline{
+		Inputs: []Reader{
+			&ByteReader{Reader: bytes.NewBufferString("a: b\n---\nc: [d\n")},
+			&ByteReader{Reader: bytes.NewBufferString("e: f\n")},
+		},
+		Outputs: []Writer{WriterFunc(func(nodes []*yaml.RNode) error {
+			written = nodes
+			return nil
+		})},
+		ContinueOnError: true,
+	}.Execute()
+	assert.EqualError(t, err, "1 documents could not be read:\n  line 3: yaml: did not find expected ',' or ']'")
+	// the Resources of the other documents are still written
+	assert.Len(t, written, 2)
+}
+
+func TestPipeline_Execute_continueOnErrorDeleteFiles(t *testing.T) {
+	s := setupDirectories(t, "a")
+	defer s.clean()
+	s.writeFile(t, filepath.Join("a", "ok.yaml"), []byte("e: f\n"))
+	s.writeFile(t, filepath.Join("a", "other.yaml"), []byte("g: h\n"))
+	// keep only the Resources of ok.yaml, so that other.yaml would be deleted
+	keep := FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+		var result []*yaml.RNode
+		for i := range nodes {
+			meta, _ := nodes[i].GetMeta()
+			if meta.Annotations[kioutil.PathAnnotation] == filepath.Join("a", "ok.yaml") {
+				result = append(result, nodes[i])
+			}
+		}
+		return result, nil
+	})
+	execute := func(rw *LocalPackageReadWriter) error {
+		return Pipeline{
+			Inputs:          []Reader{rw, &ByteReader{Reader: bytes.NewBufferString("a: b\n---\nc: [d\n")}},
+			Filters:         []Filter{keep},
+			Outputs:         []Writer{rw},
+			ContinueOnError: true,
+		}.Execute()
+	}
+
+	// Outputs which may delete files aren't run once documents are skipped
+	err := execute(&LocalPackageReadWriter{PackagePath: s.root})
+	assert.EqualError(t, err, "not writing to *kio.LocalPackageReadWriter, which may delete files, "+
+		"since 1 documents could not be read:\n  line 3: yaml: did not find expected ',' or ']'")
+	assert.FileExists(t, filepath.Join(s.root, "a", "other.yaml"))
+
+	// unless they don't delete files
+	err = execute(&LocalPackageReadWriter{PackagePath: s.root, NoDeleteFiles: true})
+	assert.EqualError(t, err, "1 documents could not be read:\n  line 3: yaml: did not find expected ',' or ']'")
+	assert.FileExists(t, filepath.Join(s.root, "a", "other.yaml"))
+}
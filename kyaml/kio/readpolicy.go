@@ -0,0 +1,179 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// AliasMode configures how Readers handle YAML anchors and aliases
+type AliasMode string
+
+const (
+	// AliasModePreserve keeps anchors and aliases as they are read, so that they are written
+	// back.  Filters see the aliases rather than the anchored values.  This is the default.
+	AliasModePreserve AliasMode = ""
+
+	// AliasModeExpand replaces aliases with copies of the anchored values, and merges the
+	// values of merge keys ('<<') into their mappings, so that Filters see the values.
+	// Anchors are removed.
+	AliasModeExpand AliasMode = "expand"
+
+	// AliasModeError fails reading Resources containing anchors or aliases.
+	AliasModeError AliasMode = "error"
+)
+
+// DuplicateKeyMode configures how Readers handle duplicate keys in YAML mappings
+type DuplicateKeyMode string
+
+const (
+	// DuplicateKeyModeError fails reading Resources containing duplicate keys.  This is the
+	// default, for all ParseModes.
+	DuplicateKeyModeError DuplicateKeyMode = ""
+
+	// DuplicateKeyModeLastWins keeps the last value of duplicate keys, as most YAML parsers
+	// do, and writes a warning for each value dropped to the Warnings of the Reader.
+	DuplicateKeyModeLastWins DuplicateKeyMode = "last-wins"
+)
+
+// readPolicyReader is implemented by the Readers which support alias and duplicate key
+// modes
+type readPolicyReader interface {
+	withReadPolicy(AliasMode, DuplicateKeyMode) Reader
+}
+
+// warningsReader is implemented by the Readers which write warnings
+type warningsReader interface {
+	withWarnings(io.Writer) Reader
+}
+
+// readPolicy applies the AliasMode and DuplicateKeyMode to decoded Resources
+type readPolicy struct {
+	aliases       AliasMode
+	duplicateKeys DuplicateKeyMode
+
+	// warnings is where the values dropped with DuplicateKeyModeLastWins are reported, if
+	// set
+	warnings io.Writer
+}
+
+// apply applies the policy to node and its descendants
+func (p readPolicy) apply(node *yaml.Node) error {
+	switch p.aliases {
+	case AliasModePreserve, AliasModeExpand, AliasModeError:
+	default:
+		return fmt.Errorf("unknown alias mode %q", p.aliases)
+	}
+	switch p.duplicateKeys {
+	case DuplicateKeyModeError, DuplicateKeyModeLastWins:
+	default:
+		return fmt.Errorf("unknown duplicate key mode %q", p.duplicateKeys)
+	}
+	return p.walk(node)
+}
+
+func (p readPolicy) walk(node *yaml.Node) error {
+	if p.aliases == AliasModeError && node.Anchor != "" {
+		return fmt.Errorf("%d:%d: anchor &%s is not allowed", node.Line, node.Column, node.Anchor)
+	}
+	if p.aliases == AliasModeExpand {
+		node.Anchor = ""
+	}
+
+	for i := range node.Content {
+		child := node.Content[i]
+		if child.Kind == yaml.AliasNode {
+			if p.aliases == AliasModeError {
+				return fmt.Errorf("%d:%d: alias *%s is not allowed",
+					child.Line, child.Column, child.Value)
+			}
+			if p.aliases == AliasModeExpand {
+				child = yaml.CopyYNode(child.Alias)
+				node.Content[i] = child
+			}
+		}
+		if err := p.walk(child); err != nil {
+			return err
+		}
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	if p.aliases == AliasModeExpand {
+		mergeKeys(node)
+	}
+	return p.duplicates(node)
+}
+
+// duplicates applies the DuplicateKeyMode to the keys of the mapping node
+func (p readPolicy) duplicates(node *yaml.Node) error {
+	// last is the index of the last value of each key
+	last := map[string]int{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if j, found := last[key.Value]; found && p.duplicateKeys == DuplicateKeyModeError {
+			return fmt.Errorf("%d:%d: duplicate key %q, first defined at line %d",
+				key.Line, key.Column, key.Value, node.Content[j].Line)
+		}
+		last[key.Value] = i
+	}
+
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if j := last[key.Value]; j != i {
+			if p.warnings != nil {
+				fmt.Fprintf(p.warnings, "%d:%d: duplicate key %q, using the value at line %d\n",
+					key.Line, key.Column, key.Value, node.Content[j].Line)
+			}
+			continue
+		}
+		content = append(content, key, node.Content[i+1])
+	}
+	node.Content = content
+	return nil
+}
+
+// mergeKeys replaces the merge keys ('<<') of the mapping node with the fields of the
+// merged mappings which are not set by the node.  The merged mappings must already have
+// been expanded.
+func mergeKeys(node *yaml.Node) {
+	var content, merged []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Tag != "!!merge" && !(key.Tag == "" && key.Value == "<<") {
+			content = append(content, key, value)
+			continue
+		}
+		values := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			values = value.Content
+		}
+		for _, v := range values {
+			if v.Kind == yaml.MappingNode {
+				merged = append(merged, v.Content...)
+			}
+		}
+	}
+	if merged == nil {
+		return
+	}
+
+	set := map[string]bool{}
+	for i := 0; i+1 < len(content); i += 2 {
+		set[content[i].Value] = true
+	}
+	// fields of earlier merged mappings take precedence over later ones
+	for i := 0; i+1 < len(merged); i += 2 {
+		if !set[merged[i].Value] {
+			set[merged[i].Value] = true
+			content = append(content, merged[i], merged[i+1])
+		}
+	}
+	node.Content = content
+}
@@ -0,0 +1,400 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DefaultStreamBufferSize is the default number of Resources buffered between the stages
+// of a StreamPipeline
+const DefaultStreamBufferSize = 16
+
+// StreamReader reads Resources one at a time.  Analogous to Reader.
+type StreamReader interface {
+	// ReadStream sends each Resource to nodes as it is read.  It returns once all the
+	// Resources are read, or done is closed.  ReadStream doesn't close nodes.
+	ReadStream(nodes chan<- *yaml.RNode, done <-chan struct{}) error
+}
+
+// StreamWriter writes Resources one at a time.  Analogous to Writer.
+type StreamWriter interface {
+	// WriteStream writes each Resource received from nodes, until nodes is closed.
+	WriteStream(nodes <-chan *yaml.RNode) error
+}
+
+// StreamPipeline reads Resource Configuration from a set of Inputs, applies some
+// transformation filters, and writes the results to an Output, one Resource at a time.
+//
+// Unlike Pipeline, the Resources are passed between the Inputs, Filters and Output
+// through channels as they are read rather than read into a slice, so that the memory
+// used is bounded by the size of the largest Resource rather than the size of the input
+// -- e.g. to process the output of kubectl get -A -o yaml.
+type StreamPipeline struct {
+	// Inputs provide sources for Resource Configuration to be read.  They are read in
+	// sequence.
+	Inputs []StreamReader `yaml:"inputs,omitempty"`
+
+	// Filters are transformations applied to each Resource, in the order they are
+	// specified.  The Filters are called with one Resource at a time, so they must
+	// implement ResourceLocalFilter.
+	Filters []Filter `yaml:"filters,omitempty"`
+
+	// Output is where the transformed Resource Configuration is written.
+	Output StreamWriter `yaml:"output,omitempty"`
+
+	// BufferSize is the number of Resources buffered between the Inputs, Filters and
+	// Output.  Defaults to DefaultStreamBufferSize.
+	BufferSize int `yaml:"bufferSize,omitempty"`
+
+	// Limits if set configure the Inputs which support them to fail once they read a document
+	// larger than MaxResourceBytes.  MaxResources isn't used, since the memory used is
+	// bounded by the size of the largest Resource.
+	Limits *Limits `yaml:"limits,omitempty"`
+}
+
+// Execute executes the Inputs, Filters and Output concurrently, returning the first error
+// encountered.  Once any of them fails, the others are stopped.
+func (p StreamPipeline) Execute() error {
+	for i := range p.Filters {
+		if !isResourceLocal(p.Filters[i]) {
+			return errors.Errorf("stream pipeline filters must be resource-local: %T",
+				p.Filters[i])
+		}
+	}
+	if p.Output == nil {
+		return errors.Errorf("stream pipeline must specify an output")
+	}
+	size := p.BufferSize
+	if size <= 0 {
+		size = DefaultStreamBufferSize
+	}
+	var limits Limits
+	if p.Limits != nil {
+		limits = *p.Limits
+	}
+	limits.MaxResources = 0
+
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() { once.Do(func() { close(done) }) }
+	errs := make(chan error, 2)
+	read := make(chan *yaml.RNode, size)
+	filtered := make(chan *yaml.RNode, size)
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// read from the inputs
+	go func() {
+		defer wg.Done()
+		defer close(read)
+		for _, i := range p.Inputs {
+			if r, ok := i.(limitsReader); ok && limits != (Limits{}) {
+				if s, ok := r.withLimits(limits).(StreamReader); ok {
+					i = s
+				}
+			}
+			if err := i.ReadStream(read, done); err != nil {
+				errs <- errors.Wrap(err)
+				stop()
+				return
+			}
+		}
+	}()
+
+	// apply operations
+	go func() {
+		defer wg.Done()
+		defer close(filtered)
+		for node := range read {
+			result := []*yaml.RNode{node}
+			for _, f := range p.Filters {
+				var err error
+				if result, err = f.Filter(result); err != nil {
+					errs <- errors.Wrap(err)
+					stop()
+					return
+				}
+			}
+			for i := range result {
+				select {
+				case filtered <- result[i]:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	// write to the output
+	err := p.Output.WriteStream(filtered)
+	if err != nil {
+		stop()
+	}
+	// unblock the stages if the output returned before reading all the Resources
+	for range filtered {
+	}
+	wg.Wait()
+	select {
+	case first := <-errs:
+		return first
+	default:
+		return errors.Wrap(err)
+	}
+}
+
+var _ StreamReader = &ByteReader{}
+
+// ReadStream reads the Resources one document at a time, sending each to nodes as it is
+// read.  The items of Lists are read one item at a time, so that the output of
+// kubectl get -o yaml is read with bounded memory -- the block sequence of the top-level
+// items field of a document is read as the items of a List, unless the kind of the
+// document is read before the items field and isn't a List.  The other fields of Lists are
+// dropped.
+//
+// ReadStream doesn't support ParseModeFast, and doesn't record the wrapping kind of
+// Lists.
+func (r *ByteReader) ReadStream(nodes chan<- *yaml.RNode, done <-chan struct{}) error {
+	s := &byteStream{reader: r, nodes: nodes, done: done}
+	in := bufio.NewReader(r.Limits.reader(r.Reader))
+	for {
+		line, err := in.ReadBytes('\n')
+		if len(line) > 0 {
+			if err := s.readLine(bytes.TrimSuffix(line, []byte("\n"))); err != nil {
+				return s.result(err)
+			}
+		}
+		if err == io.EOF {
+			return s.result(s.endDocument())
+		}
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+}
+
+// errStreamDone is returned while streaming once the stream is done
+var errStreamDone = errors.Errorf("stream done")
+
+// byteStream splits a stream of bytes into the documents -- and List items -- decoded by
+// ByteReader.ReadStream
+type byteStream struct {
+	reader *ByteReader
+	nodes  chan<- *yaml.RNode
+	done   <-chan struct{}
+
+	// index is the index of the next Resource, and line the number of lines read
+	index, line int
+
+	// doc are the lines of the document being read, excluding the List items, and docLine
+	// the number of lines preceding it
+	doc     []byte
+	docLine int
+
+	// kind is the kind of the document, once it is read
+	kind string
+
+	// itemsPending is set once the top-level items field is read, until the next line
+	// shows whether it is a block sequence
+	itemsPending bool
+
+	// inItems is set while reading the items of a List.  itemsIndent is the indentation
+	// of the sequence, and streamed is set once the document has items.
+	inItems     bool
+	itemsIndent int
+	streamed    bool
+
+	// item are the lines of the List item being read, and itemLine the number of lines
+	// preceding it
+	item     []byte
+	itemLine int
+}
+
+// result returns the error of ReadStream, which is nil if the stream is done
+func (s *byteStream) result(err error) error {
+	if err == errStreamDone {
+		return nil
+	}
+	return err
+}
+
+// readLine reads the next line of the input, without its newline
+func (s *byteStream) readLine(l []byte) error {
+	s.line++
+	if s.itemsPending {
+		s.itemsPending = false
+		if indent, ok := sequenceItem(l, -1); ok {
+			s.inItems, s.itemsIndent, s.streamed = true, indent, true
+		} else {
+			// the items aren't a block sequence, so the document is read as any other
+			s.appendDoc([]byte("items:"), s.line-1)
+		}
+	}
+	if s.inItems {
+		if _, ok := sequenceItem(l, s.itemsIndent); ok {
+			if err := s.endItem(); err != nil {
+				return err
+			}
+			s.itemLine = s.line - 1
+			// the sequence indicator is indentation of the item
+			start := append([]byte{}, l...)
+			start[s.itemsIndent] = ' '
+			s.item = append(s.item, dedent(start, s.itemsIndent+2)...)
+			s.item = append(s.item, '\n')
+			return nil
+		}
+		if len(bytes.TrimSpace(l)) == 0 || indentation(l) > s.itemsIndent {
+			s.item = append(s.item, dedent(l, s.itemsIndent+2)...)
+			s.item = append(s.item, '\n')
+			return nil
+		}
+		// the line is the next field of the List
+		s.inItems = false
+		if err := s.endItem(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case string(l) == "---":
+		return s.endDocument()
+	case string(l) == "items:" && !s.reader.DisableUnwrapping && (s.kind == "" || isListKind(s.kind)):
+		s.itemsPending = true
+		return nil
+	case bytes.HasPrefix(l, []byte("kind:")):
+		s.kind = strings.Trim(strings.TrimSpace(string(l[len("kind:"):])), `"'`)
+	}
+	s.appendDoc(l, s.line)
+	return nil
+}
+
+// appendDoc appends the line l, numbered n, to the document
+func (s *byteStream) appendDoc(l []byte, n int) {
+	if len(s.doc) == 0 {
+		s.docLine = n - 1
+	}
+	s.doc = append(s.doc, l...)
+	s.doc = append(s.doc, '\n')
+}
+
+// endItem decodes and sends the List item read, if any
+func (s *byteStream) endItem() error {
+	if len(s.item) == 0 {
+		return nil
+	}
+	item := s.item
+	s.item = nil
+	return s.decode(item, s.itemLine, false)
+}
+
+// endDocument decodes and sends the document read, unless its items were sent
+func (s *byteStream) endDocument() error {
+	if s.itemsPending {
+		s.appendDoc([]byte("items:"), s.line)
+	}
+	if err := s.endItem(); err != nil {
+		return err
+	}
+	doc, docLine, kind, streamed := s.doc, s.docLine, s.kind, s.streamed
+	s.doc, s.kind, s.itemsPending, s.inItems, s.streamed = nil, "", false, false, false
+	if streamed {
+		if !isListKind(kind) {
+			return errors.Errorf("document at line %d has a top-level items sequence "+
+				"but is not a List: %s", docLine+1, kind)
+		}
+		return nil
+	}
+	return s.decode(doc, docLine, true)
+}
+
+// decode decodes value and sends it, unwrapping it if it is a List and unwrap is set.
+// offset is the number of lines preceding value in the input.
+func (s *byteStream) decode(value []byte, offset int, unwrap bool) error {
+	node, err := s.reader.decode(s.index, offset, yaml.NewDecoder(bytes.NewReader(value)))
+	if err == io.EOF || (err == nil && yaml.IsMissingOrNull(node)) {
+		return nil
+	}
+	if err != nil {
+		return errors.WrapPrefixf(err, "resource at line %d", offset+1)
+	}
+	output := []*yaml.RNode{node}
+	if unwrap {
+		meta, err := node.GetMeta()
+		if err != yaml.ErrMissingMetadata && err != nil {
+			return errors.Wrap(err)
+		}
+		if items, ok := s.reader.unwrap(node, meta.ApiVersion, meta.Kind); ok {
+			output = items
+		}
+	}
+	for i := range output {
+		select {
+		case s.nodes <- output[i]:
+		case <-s.done:
+			return errStreamDone
+		}
+	}
+	s.index++
+	return nil
+}
+
+// isListKind returns true if the kind is unwrapped by ByteReader
+func isListKind(kind string) bool {
+	return kind == "List" || kind == ResourceListKind
+}
+
+// sequenceItem returns the indentation of l if it starts an item of a block sequence --
+// e.g. "- name: foo" -- indented by indent, or by any indentation if indent is negative
+func sequenceItem(l []byte, indent int) (int, bool) {
+	n := indentation(l)
+	if indent >= 0 && n != indent {
+		return n, false
+	}
+	rest := l[n:]
+	return n, len(rest) > 0 && rest[0] == '-' && (len(rest) == 1 || rest[1] == ' ')
+}
+
+// indentation returns the number of leading spaces of l
+func indentation(l []byte) int {
+	return len(l) - len(bytes.TrimLeft(l, " "))
+}
+
+// dedent removes up to n leading spaces from l
+func dedent(l []byte, n int) []byte {
+	if indent := indentation(l); indent < n {
+		n = indent
+	}
+	return l[n:]
+}
+
+var _ StreamWriter = ByteWriter{}
+
+// WriteStream writes the Resources received from nodes one at a time, until nodes is
+// closed.  WrappingKind and Sort aren't supported, as they require all the Resources.
+func (w ByteWriter) WriteStream(nodes <-chan *yaml.RNode) error {
+	if w.WrappingKind != "" || w.Sort || w.ApplySet != nil {
+		return errors.Errorf("streaming ByteWriter doesn't support WrappingKind, Sort or ApplySet")
+	}
+	encoder := yaml.NewEncoder(w.Writer)
+	defer encoder.Close()
+	for node := range nodes {
+		if err := cleanNode(node, w.KeepReaderAnnotations, w.ClearAnnotations); err != nil {
+			return err
+		}
+		if w.Style != 0 {
+			node.YNode().Style = w.Style
+		}
+		if err := encoder.Encode(node.Document()); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestStreamPipeline_Execute(t *testing.T) {
+	// the items of the List are read one at a time
+	input := `apiVersion: v1
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: app # the app
+    namespace: default
+  spec:
+    template:
+      spec:
+        containers:
+        - name: app
+          image: nginx
+          args:
+          - |
+            multi-line
+
+            value
+-   apiVersion: v1
+    kind: Service
+    metadata:
+      name: app
+kind: List
+metadata:
+  resourceVersion: ""
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: data
+data:
+  items: |
+    a
+---
+apiVersion: example.com/v1
+kind: Queue
+metadata:
+  name: queue
+items:
+- a
+- b
+---
+apiVersion: v1
+kind: List
+items: [{apiVersion: v1, kind: Secret, metadata: {name: secret}}]
+`
+	out := &bytes.Buffer{}
+	err := StreamPipeline{
+		Inputs:  []StreamReader{&ByteReader{Reader: strings.NewReader(input)}},
+		Filters: []Filter{ResourceLocal(FilterAll(yaml.SetLabel("app", "foo")))},
+		Output:  ByteWriter{Writer: out},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app # the app
+  namespace: default
+  labels:
+    app: foo
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx
+        args:
+        - |
+          multi-line
+
+          value
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  labels:
+    app: foo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: data
+  labels:
+    app: foo
+data:
+  items: |
+    a
+---
+apiVersion: example.com/v1
+kind: Queue
+metadata:
+  name: queue
+  labels:
+    app: foo
+items:
+- a
+- b
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: secret
+  labels:
+    app: foo
+`, out.String())
+}
+
+// streamWriterFunc implements a StreamWriter calling the function with each Resource
+type streamWriterFunc func(*yaml.RNode) error
+
+func (fn streamWriterFunc) WriteStream(nodes <-chan *yaml.RNode) error {
+	for node := range nodes {
+		if err := fn(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStreamPipeline_Execute_annotations(t *testing.T) {
+	var indexes []string
+	err := StreamPipeline{
+		Inputs: []StreamReader{&ByteReader{Reader: strings.NewReader(`apiVersion: v1
+items:
+- kind: A
+- kind: B
+kind: List
+---
+kind: C
+`), SetAnnotations: map[string]string{"foo": "bar"}}},
+		Output: streamWriterFunc(func(node *yaml.RNode) error {
+			meta, err := node.GetMeta()
+			if err != nil {
+				return err
+			}
+			indexes = append(indexes, strings.Join([]string{meta.Kind,
+				meta.Annotations["config.kubernetes.io/index"], meta.Annotations["foo"]}, " "))
+			return nil
+		}),
+	}.Execute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"A 0 bar", "B 1 bar", "C 2 bar"}, indexes)
+}
+
+// infiniteReader reads an infinite stream of ConfigMaps
+type infiniteReader struct {
+	buf bytes.Buffer
+	i   int
+}
+
+func (r *infiniteReader) Read(p []byte) (int, error) {
+	if r.buf.Len() == 0 {
+		fmt.Fprintf(&r.buf, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c%d\n---\n", r.i)
+		r.i++
+	}
+	return r.buf.Read(p)
+}
+
+// failingWriter fails writing the Resource with the name
+type failingWriter struct {
+	name    string
+	written int
+}
+
+func (w *failingWriter) WriteStream(nodes <-chan *yaml.RNode) error {
+	for node := range nodes {
+		meta, err := node.GetMeta()
+		if err != nil {
+			return err
+		}
+		if meta.Name == w.name {
+			return fmt.Errorf("cannot write %s", w.name)
+		}
+		w.written++
+	}
+	return nil
+}
+
+func TestStreamPipeline_Execute_errors(t *testing.T) {
+	// the Inputs are stopped once the Output fails
+	w := &failingWriter{name: "c1000"}
+	err := StreamPipeline{
+		Inputs: []StreamReader{&ByteReader{Reader: &infiniteReader{}}},
+		Output: w,
+	}.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "cannot write c1000")
+	}
+	assert.Equal(t, 1000, w.written)
+
+	// the Inputs are stopped once a Filter fails
+	fail := ResourceLocal(FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+		if meta, _ := nodes[0].GetMeta(); meta.Name == "c5" {
+			return nil, fmt.Errorf("invalid %s", meta.Name)
+		}
+		return nodes, nil
+	}))
+	err = StreamPipeline{
+		Inputs:  []StreamReader{&ByteReader{Reader: &infiniteReader{}}},
+		Filters: []Filter{fail},
+		Output:  &failingWriter{},
+	}.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid c5")
+	}
+
+	tests := []struct {
+		name     string
+		p        StreamPipeline
+		expected string
+	}{
+		{name: "filter not resource-local",
+			p: StreamPipeline{Filters: []Filter{FilterAll(yaml.SetLabel("a", "b"))},
+				Output: &failingWriter{}},
+			expected: "stream pipeline filters must be resource-local"},
+		{name: "no output",
+			expected: "stream pipeline must specify an output"},
+		{name: "items of not a List",
+			p: StreamPipeline{Inputs: []StreamReader{&ByteReader{Reader: strings.NewReader(`apiVersion: v1
+items:
+- kind: A
+kind: Queue
+`)}}, Output: &failingWriter{}},
+			expected: "document at line 1 has a top-level items sequence but is not a List: Queue"},
+		{name: "invalid item",
+			p: StreamPipeline{Inputs: []StreamReader{&ByteReader{Reader: strings.NewReader(`apiVersion: v1
+items:
+- kind: A
+- kind: [B
+kind: List
+`)}}, Output: &failingWriter{}},
+			expected: "resource at line 4: yaml: line 1: did not find expected ',' or ']'"},
+		{name: "sort",
+			p: StreamPipeline{Inputs: []StreamReader{&ByteReader{Reader: strings.NewReader("a: b\n")}},
+				Output: ByteWriter{Writer: &bytes.Buffer{}, Sort: true}},
+			expected: "streaming ByteWriter doesn't support WrappingKind, Sort or ApplySet"},
+	}
+	for _, test := range tests {
+		err := test.p.Execute()
+		if assert.Error(t, err, test.name) {
+			assert.Contains(t, err.Error(), test.expected, test.name)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TarReader reads the Resources of a package from a tar archive, which may be compressed
+// with gzip.  The Resources are read as LocalPackageReader reads them from the package
+// extracted to a directory -- annotated with the config.kubernetes.io/path and
+// config.kubernetes.io/package of their file relative to the package, and read in the
+// same order.
+type TarReader struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Reader is where the archive is read from.
+	Reader io.Reader `yaml:"-"`
+
+	// PackagePath is the path of the package directory within the archive -- e.g. when the
+	// archive contains a top-level directory.  Defaults to the root of the archive.
+	PackagePath string `yaml:"path,omitempty"`
+
+	// PackageFileName is the name of file containing package metadata.
+	// It will be used to identify package.
+	PackageFileName string `yaml:"packageFileName,omitempty"`
+
+	// MatchFilesGlob configures Read to only read Resources from files matching any of the
+	// provided patterns.
+	// Defaults to ["*.yaml", "*.yml"] if empty.  To match all files specify ["*"].
+	MatchFilesGlob []string `yaml:"matchFilesGlob,omitempty"`
+
+	// IncludeSubpackages will configure Read to read Resources from subpackages.
+	// Subpackages are identified by presence of PackageFileName.
+	IncludeSubpackages bool `yaml:"includeSubpackages,omitempty"`
+
+	// OmitReaderAnnotations will cause the reader to skip annotating Resources with the file
+	// path and mode.
+	OmitReaderAnnotations bool `yaml:"omitReaderAnnotations,omitempty"`
+
+	// SetAnnotations are annotations to set on the Resources as they are read.
+	SetAnnotations map[string]string `yaml:"setAnnotations,omitempty"`
+
+	// ParseMode configures how the Resources are parsed.  Defaults to ParseModePreserve.
+	ParseMode ParseMode `yaml:"parseMode,omitempty"`
+
+	// AliasMode configures how anchors and aliases are handled.  Defaults to
+	// AliasModePreserve.
+	AliasMode AliasMode `yaml:"aliasMode,omitempty"`
+
+	// DuplicateKeyMode configures how duplicate keys are handled.  Defaults to
+	// DuplicateKeyModeError.
+	DuplicateKeyMode DuplicateKeyMode `yaml:"duplicateKeyMode,omitempty"`
+
+	// Warnings is where warnings about the Resources read are written -- e.g. the values
+	// dropped with DuplicateKeyModeLastWins.  Defaults to discarding them.
+	Warnings io.Writer `yaml:"-"`
+
+	// Limits guard reading against inputs too large to be read into memory.  Defaults to
+	// unlimited.
+	Limits Limits `yaml:"limits,omitempty"`
+}
+
+var _ Reader = TarReader{}
+
+// withParseMode returns a copy of the TarReader configured to use the ParseMode
+func (r TarReader) withParseMode(mode ParseMode) Reader {
+	r.ParseMode = mode
+	return r
+}
+
+// withReadPolicy returns a copy of the TarReader configured to use the AliasMode and
+// DuplicateKeyMode
+func (r TarReader) withReadPolicy(aliases AliasMode, duplicateKeys DuplicateKeyMode) Reader {
+	r.AliasMode, r.DuplicateKeyMode = aliases, duplicateKeys
+	return r
+}
+
+// withWarnings returns a copy of the TarReader configured to write warnings to w, unless
+// it sets its own
+func (r TarReader) withWarnings(w io.Writer) Reader {
+	if r.Warnings == nil {
+		r.Warnings = w
+	}
+	return r
+}
+
+// withLimits returns a copy of the TarReader configured to use the Limits, unless it sets
+// its own
+func (r TarReader) withLimits(l Limits) Reader {
+	if r.Limits == (Limits{}) {
+		r.Limits = l
+	}
+	return r
+}
+
+// gzipMagic are the first bytes of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Read reads the Resources.
+func (r TarReader) Read() ([]*yaml.RNode, error) {
+	if r.Reader == nil {
+		return nil, fmt.Errorf("must specify archive reader")
+	}
+	if len(r.MatchFilesGlob) == 0 {
+		r.MatchFilesGlob = defaultMatch
+	}
+	root := path.Clean("/" + filepath.ToSlash(r.PackagePath))
+
+	br := bufio.NewReader(r.Reader)
+	var in io.Reader = br
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	// the archive is read sequentially, so the files are read before skipping the
+	// subpackages and sorting them
+	files := map[string][]byte{}
+	packages := map[string]bool{}
+	tr := tar.NewReader(in)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(h.Name, "/"))
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return nil, errors.Errorf("archive entry must be within the archive: %s", h.Name)
+		}
+		name = "/" + name
+		if name != root && !strings.HasPrefix(name, strings.TrimSuffix(root, "/")+"/") {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		if rel == "" {
+			// the package path is a file -- make its path relative to its parent as
+			// LocalPackageReader does
+			rel = path.Base(name)
+		}
+		if r.PackageFileName != "" && path.Base(rel) == r.PackageFileName {
+			packages[path.Dir(rel)] = true
+		}
+		if match, err := r.shouldReadFile(rel); err != nil {
+			return nil, err
+		} else if !match {
+			continue
+		}
+		// guard reading the entry, which may be much larger than the compressed archive
+		b, err := ioutil.ReadAll(r.Limits.reader(tr))
+		if err != nil {
+			return nil, errors.WrapPrefixf(err, h.Name)
+		}
+		files[rel] = b
+	}
+	return r.readFiles(files, packages)
+}
+
+// readFiles reads the Resources from the files of the package, by slash separated path
+// relative to the package.  packages are the directories containing a PackageFileName.
+func (r TarReader) readFiles(files map[string][]byte, packages map[string]bool) ([]*yaml.RNode, error) {
+	var paths []string
+	for p := range files {
+		if !r.IncludeSubpackages && r.inSubpackage(p, packages) {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool { return walkLess(paths[i], paths[j]) })
+
+	var operand ResourceNodeSlice
+	for _, p := range paths {
+		nodes, err := r.readFile(filepath.FromSlash(p), files[p])
+		if err != nil {
+			return nil, errors.WrapPrefixf(err, p)
+		}
+		operand = append(operand, nodes...)
+	}
+	return operand, nil
+}
+
+// shouldReadFile returns true if the file matches MatchFilesGlob
+func (r TarReader) shouldReadFile(name string) (bool, error) {
+	for _, g := range r.MatchFilesGlob {
+		if match, err := path.Match(g, path.Base(name)); err != nil {
+			return false, errors.Wrap(err)
+		} else if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inSubpackage returns true if a directory containing the file, other than the package
+// directory, contains a PackageFileName
+func (r TarReader) inSubpackage(name string, packages map[string]bool) bool {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if packages[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// readFile reads the Resources from the contents of a file
+func (r TarReader) readFile(path string, b []byte) ([]*yaml.RNode, error) {
+	annotations := map[string]string{}
+	for k, v := range r.SetAnnotations {
+		annotations[k] = v
+	}
+	if !r.OmitReaderAnnotations {
+		annotations[kioutil.PackageAnnotation] = filepath.Dir(path)
+		annotations[kioutil.PathAnnotation] = path
+	}
+	rr := &ByteReader{
+		DisableUnwrapping:     true,
+		Reader:                bytes.NewReader(b),
+		OmitReaderAnnotations: r.OmitReaderAnnotations,
+		SetAnnotations:        annotations,
+		ParseMode:             r.ParseMode,
+		AliasMode:             r.AliasMode,
+		DuplicateKeyMode:      r.DuplicateKeyMode,
+		Warnings:              r.Warnings,
+		Limits:                r.Limits,
+	}
+	return rr.Read()
+}
+
+// walkLess returns true if filepath.Walk visits the slash separated path a before b --
+// the directories are walked in lexical order of their entries
+func walkLess(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// TarWriter writes the Resources of a package to a tar archive, optionally compressed
+// with gzip.  The Resources are written to the files of their config.kubernetes.io/path
+// annotation as LocalPackageWriter writes them to a directory.
+type TarWriter struct {
+	Kind string `yaml:"kind,omitempty"`
+
+	// Writer is where the archive is written.  It isn't closed by Write.
+	Writer io.Writer `yaml:"-"`
+
+	// PackagePath is the path of the package directory within the archive.  Defaults to
+	// the root of the archive.
+	PackagePath string `yaml:"path,omitempty"`
+
+	// Gzip compresses the archive with gzip.
+	Gzip bool `yaml:"gzip,omitempty"`
+
+	// ModTime is the modification time of the files of the archive.  Defaults to the Unix
+	// epoch, so that writing the same Resources produces the same archive.
+	ModTime time.Time `yaml:"-"`
+
+	// KeepReaderAnnotations if set will retain the annotations set by TarReader
+	KeepReaderAnnotations bool `yaml:"keepReaderAnnotations,omitempty"`
+
+	// ClearAnnotations will clear annotations before writing the resources
+	ClearAnnotations []string `yaml:"clearAnnotations,omitempty"`
+}
+
+var _ Writer = TarWriter{}
+
+func (r TarWriter) Write(nodes []*yaml.RNode) error {
+	if r.Writer == nil {
+		return fmt.Errorf("must specify archive writer")
+	}
+	paths, files, err := r.files(nodes)
+	if err != nil {
+		return err
+	}
+	if r.ModTime.IsZero() {
+		r.ModTime = time.Unix(0, 0)
+	}
+
+	out := r.Writer
+	var gz *gzip.Writer
+	if r.Gzip {
+		gz = gzip.NewWriter(out)
+		gz.ModTime = r.ModTime
+		out = gz
+	}
+	tw := tar.NewWriter(out)
+	for _, p := range paths {
+		b := files[p]
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path.Join(filepath.ToSlash(r.PackagePath), p),
+			Mode:     0600,
+			Size:     int64(len(b)),
+			ModTime:  r.ModTime,
+		})
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if _, err = tw.Write(b); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return errors.Wrap(err)
+	}
+	if gz != nil {
+		return errors.Wrap(gz.Close())
+	}
+	return nil
+}
+
+// files returns the contents of the files of the Resources, by slash separated path
+// relative to the package, and the sorted paths
+func (r TarWriter) files(nodes []*yaml.RNode) ([]string, map[string][]byte, error) {
+	if err := kioutil.ErrorIfMissingAnnotation(nodes, requiredResourcePackageAnnotations...); err != nil {
+		return nil, nil, err
+	}
+
+	// the files are validated as they are by LocalPackageWriter
+	lw := LocalPackageWriter{PackagePath: r.PackagePath}
+	if err := lw.errorIfMissingRequiredAnnotation(nodes); err != nil {
+		return nil, nil, err
+	}
+	outputFiles, err := lw.indexByFilePath(nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !r.KeepReaderAnnotations {
+		r.ClearAnnotations = append(r.ClearAnnotations, kioutil.PackageAnnotation)
+		r.ClearAnnotations = append(r.ClearAnnotations, kioutil.PathAnnotation)
+	}
+	var paths []string
+	files := map[string][]byte{}
+	for k := range outputFiles {
+		if err = kioutil.SortNodes(outputFiles[k]); err != nil {
+			return nil, nil, errors.Wrap(err)
+		}
+		b := &bytes.Buffer{}
+		w := ByteWriter{
+			Writer:                b,
+			KeepReaderAnnotations: r.KeepReaderAnnotations,
+			ClearAnnotations:      r.ClearAnnotations,
+		}
+		if err = w.Write(outputFiles[k]); err != nil {
+			return nil, nil, errors.Wrap(err)
+		}
+		paths = append(paths, filepath.ToSlash(k))
+		files[filepath.ToSlash(k)] = b.Bytes()
+	}
+	sort.Strings(paths)
+	return paths, files, nil
+}
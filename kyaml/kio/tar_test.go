@@ -0,0 +1,263 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// writeTar writes the files to a tar archive in the order of names
+func writeTar(t *testing.T, gz bool, names []string, files map[string][]byte) *bytes.Buffer {
+	b := &bytes.Buffer{}
+	var out io.Writer = b
+	var gw *gzip.Writer
+	if gz {
+		gw = gzip.NewWriter(b)
+		out = gw
+	}
+	tw := tar.NewWriter(out)
+	for _, name := range names {
+		if !assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg, Name: name, Mode: 0600, Size: int64(len(files[name]))})) {
+			t.FailNow()
+		}
+		if _, err := tw.Write(files[name]); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+	// directories are skipped
+	if !assert.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir, Name: "pkg/a/", Mode: 0700})) {
+		t.FailNow()
+	}
+	assert.NoError(t, tw.Close())
+	if gw != nil {
+		assert.NoError(t, gw.Close())
+	}
+	return b
+}
+
+// stringNodes returns the nodes as strings
+func stringNodes(t *testing.T, nodes []*yaml.RNode) []string {
+	var s []string
+	for i := range nodes {
+		v, err := nodes[i].String()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		s = append(s, v)
+	}
+	return s
+}
+
+func TestTarReader_Read(t *testing.T) {
+	files := map[string][]byte{
+		"a_test.yaml":     readFileA,
+		"a/b_test.yaml":   readFileB,
+		"a-c_test.yaml":   readFileB,
+		"a/c/c_test.yaml": readFileA,
+		"a/c/pkgFile":     pkgFile,
+		"a/d.txt":         readFileB,
+	}
+	s := setupDirectories(t)
+	defer s.clean()
+	var names []string
+	entries := map[string][]byte{}
+	for name, value := range files {
+		s.writeFile(t, "pkg/"+name, value)
+		names = append(names, "./pkg/"+name)
+		entries["./pkg/"+name] = value
+	}
+
+	tests := []struct {
+		name string
+		lr   LocalPackageReader
+		tr   TarReader
+	}{
+		{name: "package",
+			lr: LocalPackageReader{PackagePath: "pkg"},
+			tr: TarReader{PackagePath: "pkg"}},
+		{name: "skip subpackages",
+			lr: LocalPackageReader{PackagePath: "pkg", PackageFileName: "pkgFile"},
+			tr: TarReader{PackagePath: "pkg", PackageFileName: "pkgFile"}},
+		{name: "include subpackages",
+			lr: LocalPackageReader{PackagePath: "pkg", PackageFileName: "pkgFile",
+				IncludeSubpackages: true},
+			tr: TarReader{PackagePath: "pkg", PackageFileName: "pkgFile",
+				IncludeSubpackages: true}},
+		{name: "match files",
+			lr: LocalPackageReader{PackagePath: "pkg", MatchFilesGlob: []string{"*.txt"}},
+			tr: TarReader{PackagePath: "pkg", MatchFilesGlob: []string{"*.txt"}}},
+		{name: "directory",
+			lr: LocalPackageReader{PackagePath: "pkg/a/c"},
+			tr: TarReader{PackagePath: "pkg/a/c"}},
+		{name: "file",
+			lr: LocalPackageReader{PackagePath: "pkg/a/b_test.yaml"},
+			tr: TarReader{PackagePath: "pkg/a/b_test.yaml"}},
+		{name: "archive root",
+			lr: LocalPackageReader{PackagePath: "."},
+			tr: TarReader{}},
+		{name: "annotations",
+			lr: LocalPackageReader{PackagePath: "pkg", OmitReaderAnnotations: true,
+				SetAnnotations: map[string]string{"foo": "bar"}},
+			tr: TarReader{PackagePath: "pkg", OmitReaderAnnotations: true,
+				SetAnnotations: map[string]string{"foo": "bar"}}},
+	}
+	for _, test := range tests {
+		expected, err := test.lr.Read()
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		for _, gz := range []bool{false, true} {
+			test.tr.Reader = writeTar(t, gz, names, entries)
+			actual, err := test.tr.Read()
+			if !assert.NoError(t, err, test.name) {
+				continue
+			}
+			assert.Equal(t, stringNodes(t, expected), stringNodes(t, actual), test.name)
+		}
+	}
+}
+
+func TestTarReader_Read_outsideArchive(t *testing.T) {
+	files := map[string][]byte{"../a.yaml": readFileA}
+	_, err := TarReader{Reader: writeTar(t, false, []string{"../a.yaml"}, files)}.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "archive entry must be within the archive: ../a.yaml")
+	}
+}
+
+func TestTarReader_Read_limits(t *testing.T) {
+	// the entry is read through the limits, rather than decompressed into memory first
+	files := map[string][]byte{"a.yaml": append([]byte("a: "), bytes.Repeat([]byte("b"), 8<<20)...)}
+	_, err := TarReader{
+		Reader: writeTar(t, true, []string{"a.yaml"}, files),
+		Limits: Limits{MaxResourceBytes: 1024},
+	}.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "a.yaml: document at line 1 exceeds the maximum size of 1024 bytes")
+	}
+}
+
+func TestTarWriter_Write(t *testing.T) {
+	input := `kind: A
+metadata:
+  annotations:
+    config.kubernetes.io/index: "1"
+    config.kubernetes.io/path: "a/a.yaml"
+  name: a2
+---
+kind: A
+metadata:
+  annotations:
+    config.kubernetes.io/index: "0"
+    config.kubernetes.io/path: "a/a.yaml"
+  name: a1
+---
+kind: B
+metadata:
+  annotations:
+    config.kubernetes.io/index: "0"
+    config.kubernetes.io/path: "b.yaml"
+  name: b
+`
+	for _, gz := range []bool{false, true} {
+		nodes, err := (&ByteReader{Reader: bytes.NewBufferString(input),
+			OmitReaderAnnotations: true}).Read()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		b := &bytes.Buffer{}
+		err = TarWriter{Writer: b, PackagePath: "pkg", Gzip: gz}.Write(nodes)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		archive := b.Bytes()
+
+		var in io.Reader = bytes.NewReader(archive)
+		if gz {
+			in, err = gzip.NewReader(in)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+		}
+		tr := tar.NewReader(in)
+		actual := map[string]string{}
+		var names []string
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			v, err := ioutil.ReadAll(tr)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			names = append(names, h.Name)
+			actual[h.Name] = string(v)
+			assert.Equal(t, int64(0), h.ModTime.Unix())
+		}
+		assert.Equal(t, []string{"pkg/a/a.yaml", "pkg/b.yaml"}, names)
+		assert.Equal(t, map[string]string{
+			"pkg/a/a.yaml": `kind: A
+metadata:
+  name: a1
+---
+kind: A
+metadata:
+  name: a2
+`,
+			"pkg/b.yaml": `kind: B
+metadata:
+  name: b
+`,
+		}, actual)
+
+		// the archive is read back as it was written
+		output, err := TarReader{Reader: bytes.NewReader(archive), PackagePath: "pkg"}.Read()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		var paths []string
+		for i := range output {
+			meta, err := output[i].GetMeta()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			paths = append(paths, meta.Name+" "+meta.Annotations["config.kubernetes.io/path"]+
+				" "+meta.Annotations["config.kubernetes.io/index"])
+		}
+		assert.Equal(t, []string{"a1 a/a.yaml 0", "a2 a/a.yaml 1", "b b.yaml 0"}, paths)
+
+		// writing the Resources read back produces the same archive
+		b.Reset()
+		err = TarWriter{Writer: b, PackagePath: "pkg", Gzip: gz}.Write(output)
+		if assert.NoError(t, err) {
+			assert.Equal(t, archive, b.Bytes())
+		}
+	}
+}
+
+func TestTarWriter_Write_missingPath(t *testing.T) {
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString("kind: A\n")}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = TarWriter{Writer: &bytes.Buffer{}}.Write(nodes)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "config.kubernetes.io/path")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TeeWriter writes the Resources to each of its Writers -- e.g. to write a package to
+// disk and to stdout.  Analogous to io.MultiWriter, except that the Resources are written
+// to all of the Writers even if some fail, and their errors are returned together.
+//
+// Each Writer but the last is given a copy of the Resources, so that the Writers which
+// modify them -- e.g. by clearing the reader annotations -- don't affect the others.
+type TeeWriter struct {
+	// Writers are where the Resources are written, in order.
+	Writers []Writer
+}
+
+var _ MetadataWriter = TeeWriter{}
+
+func (w TeeWriter) Write(nodes []*yaml.RNode) error {
+	return w.WriteWithMetadata(nodes, nil)
+}
+
+// WriteWithMetadata writes the Resources to each of the Writers, passing the Metadata to
+// the Writers which implement MetadataWriter.
+func (w TeeWriter) WriteWithMetadata(nodes []*yaml.RNode, m *Metadata) error {
+	var errs []string
+	for i, o := range w.Writers {
+		input := nodes
+		if i < len(w.Writers)-1 {
+			input = copyNodes(nodes)
+		}
+		var err error
+		if mw, ok := o.(MetadataWriter); ok && m != nil {
+			err = mw.WriteWithMetadata(input, m)
+		} else {
+			err = o.Write(input)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("writer %d: %v", i, err))
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errors.Errorf("%s", errs[0])
+	default:
+		return errors.Errorf("%d of %d writers failed: %s",
+			len(errs), len(w.Writers), strings.Join(errs, "; "))
+	}
+}
+
+// copyNodes returns a deep copy of the nodes
+func copyNodes(nodes []*yaml.RNode) []*yaml.RNode {
+	result := make([]*yaml.RNode, len(nodes))
+	for i := range nodes {
+		result[i] = nodes[i].Copy()
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestTeeWriter_Write(t *testing.T) {
+	var indexes []string
+	first, second := &bytes.Buffer{}, &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString("a: b\n---\nc: d\n")}},
+		Outputs: []Writer{TeeWriter{Writers: []Writer{
+			ByteWriter{Writer: first},
+			// the Writers don't see the changes of the others
+			WriterFunc(func(nodes []*yaml.RNode) error {
+				for i := range nodes {
+					_, index, err := kioutil.GetFileAnnotations(nodes[i])
+					if err != nil {
+						return err
+					}
+					indexes = append(indexes, index)
+				}
+				return nil
+			}),
+			ByteWriter{Writer: second, KeepReaderAnnotations: true},
+		}}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "a: b\n---\nc: d\n", first.String())
+	assert.Equal(t, []string{"0", "1"}, indexes)
+	assert.Equal(t, `a: b
+metadata:
+  annotations:
+    config.kubernetes.io/index: 0
+---
+c: d
+metadata:
+  annotations:
+    config.kubernetes.io/index: 1
+`, second.String())
+}
+
+func TestTeeWriter_Write_errors(t *testing.T) {
+	fail := func(msg string) Writer {
+		return WriterFunc(func([]*yaml.RNode) error { return fmt.Errorf("%s", msg) })
+	}
+	out := &bytes.Buffer{}
+	nodes := []*yaml.RNode{yaml.MustParse("a: b\n")}
+
+	// the Writers after a failed Writer are still written
+	err := TeeWriter{Writers: []Writer{fail("disk full"), ByteWriter{Writer: out}}}.Write(nodes)
+	assert.EqualError(t, err, "writer 0: disk full")
+	assert.Equal(t, "a: b\n", out.String())
+
+	err = TeeWriter{Writers: []Writer{fail("disk full"), ByteWriter{Writer: out}, fail("closed")}}.Write(nodes)
+	assert.EqualError(t, err, "2 of 3 writers failed: writer 0: disk full; writer 2: closed")
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stages of a Pipeline recorded by PipelineTimings
+const (
+	StageRead   = "read"
+	StageFilter = "filter"
+	StageWrite  = "write"
+)
+
+// StageTiming is the cost of a stage of a Pipeline -- reading an Input, applying a Filter
+// or writing an Output.
+type StageTiming struct {
+	// Pipeline is the number of the Pipeline execution, counted from 0 for each
+	// PipelineTimings.
+	Pipeline int
+
+	// Stage is the kind of stage -- StageRead, StageFilter or StageWrite.
+	Stage string
+
+	// Index is the index of the Input, Filter or Output within the Pipeline.
+	Index int
+
+	// Name is the type of the Input, Filter or Output -- e.g. filters.FormatFilter.
+	Name string
+
+	// Resources is the number of Resources the stage read, returned or wrote.
+	Resources int
+
+	// Elapsed is the wall time of the stage.
+	Elapsed time.Duration
+
+	// Allocs and AllocBytes are the number and size of the heap allocations during the
+	// stage, including those of any goroutines running concurrently.
+	Allocs     uint64
+	AllocBytes uint64
+}
+
+// PipelineTimings records the cost of each stage of the Pipelines executed with it, so
+// that the slow stages of large Pipelines may be found.  Consecutive Filters run
+// concurrently with Parallelism are timed together, as for PipelineHooks.  Recording
+// allocations briefly stops the world for each stage, so Pipelines should only be timed
+// when diagnosing them.  Safe for use by concurrent Pipelines.
+type PipelineTimings struct {
+	mu        sync.Mutex
+	pipelines int
+	stages    []StageTiming
+}
+
+// Stages returns the timings of the stages recorded, in the order they were executed.
+func (t *PipelineTimings) Stages() []StageTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]StageTiming{}, t.stages...)
+}
+
+// stageStart is the state at the start of a stage, to compute its cost from
+type stageStart struct {
+	time       time.Time
+	allocs     uint64
+	allocBytes uint64
+}
+
+// pipeline returns the number of the next Pipeline execution, or 0 if t is nil
+func (t *PipelineTimings) pipeline() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pipelines++
+	return t.pipelines - 1
+}
+
+// start returns the state at the start of a stage
+func (t *PipelineTimings) start() stageStart {
+	if t == nil {
+		return stageStart{}
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return stageStart{time: time.Now(), allocs: m.Mallocs, allocBytes: m.TotalAlloc}
+}
+
+// record records the cost of a stage started at start
+func (t *PipelineTimings) record(pipeline int, stage string, index int, op interface{},
+	resources int, start stageStart) {
+	if t == nil {
+		return
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	s := StageTiming{
+		Pipeline:   pipeline,
+		Stage:      stage,
+		Index:      index,
+		Name:       strings.TrimPrefix(fmt.Sprintf("%T", op), "*"),
+		Resources:  resources,
+		Elapsed:    time.Since(start.time),
+		Allocs:     m.Mallocs - start.allocs,
+		AllocBytes: m.TotalAlloc - start.allocBytes,
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stages = append(t.stages, s)
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestPipeline_Execute_timings(t *testing.T) {
+	timings := &PipelineTimings{}
+	for i := 0; i < 2; i++ {
+		err := Pipeline{
+			Inputs:  []Reader{&ByteReader{Reader: bytes.NewBufferString("a: b\n---\nc: d\n")}},
+			Filters: []Filter{filters.FormatFilter{}},
+			Outputs: []Writer{ByteWriter{Writer: &bytes.Buffer{}}},
+			Timings: timings,
+		}.Execute()
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	var stages []string
+	for _, s := range timings.Stages() {
+		stages = append(stages, fmt.Sprintf("%d %s %d %s: %d", s.Pipeline, s.Stage, s.Index, s.Name, s.Resources))
+	}
+	assert.Equal(t, []string{
+		"0 read 0 kio.ByteReader: 2",
+		"0 filter 0 filters.FormatFilter: 2",
+		"0 write 0 kio.ByteWriter: 2",
+		"1 read 0 kio.ByteReader: 2",
+		"1 filter 0 filters.FormatFilter: 2",
+		"1 write 0 kio.ByteWriter: 2",
+	}, stages)
+	// formatting allocates
+	assert.True(t, timings.Stages()[1].Allocs > 0)
+
+	// Pipelines without Timings record nothing
+	err := Pipeline{
+		Inputs:  []Reader{&ByteReader{Reader: bytes.NewBufferString("a: b\n")}},
+		Filters: []Filter{FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) { return nodes, nil })},
+	}.Execute()
+	assert.NoError(t, err)
+}
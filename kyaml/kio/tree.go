@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/xlab/treeprint"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
@@ -35,8 +36,76 @@ type TreeWriter struct {
 	Root      string
 	Fields    []TreeWriterField
 	Structure TreeStructure
+
+	// Events if set will correlate the Events in the input to their involvedObject, and
+	// print the most recent Warning Events beneath each Resource rather than printing the
+	// Events as Resources.  Only used with TreeStructureGraph.
+	Events bool
+
+	// MaxEvents is the maximum number of Events printed beneath each Resource.
+	// Defaults to 3.
+	MaxEvents int
+
+	// Generators if set will print the ConfigMaps and Secrets generated by kustomization
+	// files beneath them, with the name suffix hashes predicted from the generator sources
+	// read relative to Root.  The Resources in the package of the kustomization which
+	// reference a generated ConfigMap or Secret by its base name are printed beneath it.
+	// Only used with TreeStructurePackage.
+	Generators bool
+
+	// Template if set is a text/template used to render the tree rather than printing it
+	// as ascii.  The template is executed with the root *TreeNode, and may use the
+	// indent, repeat and field functions.
+	Template string
+
+	// FoldDuplicates if set will print the sibling Resources which are identical except
+	// for their namespace -- ignoring server-set metadata and status -- as a single
+	// Resource with the number of namespaces it is in, e.g. for clusters with the same
+	// Resources in many namespaces.  With TreeStructureGraph, the children of the
+	// folded Resources are merged and folded in turn.
+	FoldDuplicates bool
+
+	// ExpandFolded if set will print the namespaces of the Resources folded by
+	// FoldDuplicates beneath them.
+	ExpandFolded bool
+
+	// ResolveSelectors if set will print the Deployments and StatefulSets beneath the
+	// Services in the same namespace whose selector matches their pod template labels,
+	// rather than beneath their owners.  A workload matched by several Services is printed
+	// beneath the first of them, and listed beneath the others.  Services whose selector
+	// matches no workloads are flagged.  Only used with TreeStructureGraph.
+	ResolveSelectors bool
+
+	// GroupBy configures how the Resources are grouped within the Structure.  With
+	// TreeGroupByCRD, the instances of the CustomResourceDefinitions in the input are
+	// printed beneath their definition rather than beneath their package, or the root
+	// with TreeStructureGraph.  Defaults to TreeGroupByNone.
+	GroupBy TreeGroupBy
+
+	// Age if set will print the age of each Resource, from its metadata.creationTimestamp
+	// -- e.g. for live Resources -- formatted as by kubectl get.
+	Age bool
+
+	// Restarts if set will print the number of times the containers of each Pod have
+	// restarted, from its status, broken down by container if more than one restarted.
+	Restarts bool
+
+	// Now is the time the ages are computed from.  Defaults to the current time.
+	Now time.Time
+
+	// Renderer if set renders the Meta and Value of each node of the tree, in place of
+	// the defaults -- e.g. to add prefixes, colors or columns computed from the Resources.
+	// The nodes are rendered before they are printed, executed with the Template or
+	// returned by BuildTree.
+	Renderer NodeRenderer
+
+	// treeNodes if set builds the tree as TreeNodes -- set by BuildTree
+	treeNodes bool
 }
 
+// defaultMaxEvents is the number of Events printed beneath each Resource if MaxEvents is unset
+const defaultMaxEvents = 3
+
 // TreeWriterField configures a Resource field to be included in the tree
 type TreeWriterField struct {
 	yaml.PathMatcher
@@ -44,11 +113,18 @@ type TreeWriterField struct {
 	SubName string
 }
 
-func (p TreeWriter) packageStructure(nodes []*yaml.RNode) error {
+func (p TreeWriter) packageStructure(nodes []*yaml.RNode) (treeprint.Tree, error) {
 	indexByPackage := p.index(nodes)
+	var crds crdGroups
+	if p.GroupBy == TreeGroupByCRD {
+		var err error
+		if crds, err = groupCRDs(nodes); err != nil {
+			return nil, err
+		}
+	}
 
 	// create the new tree
-	tree := treeprint.New()
+	tree := p.newTree()
 	tree.SetValue(p.Root)
 
 	// add each package to the tree
@@ -76,29 +152,81 @@ func (p TreeWriter) packageStructure(nodes []*yaml.RNode) error {
 		// cache the branch for this package
 		treeIndex[pkg] = branch
 
-		// print each resource in the package
-		for i := range indexByPackage[pkg] {
+		var resources []*yaml.RNode
+		for _, r := range indexByPackage[pkg] {
+			// instances of CustomResourceDefinitions are printed beneath the definition
+			if !crds.grouped[r] {
+				resources = append(resources, r)
+			}
+		}
+		namespaces := make([][]string, len(resources))
+		if p.FoldDuplicates {
 			var err error
-			if _, err = p.doResource(indexByPackage[pkg][i], "", branch); err != nil {
-				return err
+			if resources, namespaces, err = foldResources(resources); err != nil {
+				return nil, err
+			}
+		}
+
+		// print each resource in the package
+		for i := range resources {
+			n, err := p.doResource(resources[i], "", namespaces[i], branch)
+			if err != nil {
+				return nil, err
+			}
+			if meta, _ := resources[i].GetMeta(); p.Generators && isKustomization(meta) {
+				if err := p.doGenerators(resources[i], indexByPackage[pkg], n); err != nil {
+					return nil, err
+				}
+			}
+			if instances, ok := crds.instances[resources[i]]; ok {
+				if err := p.doCRDInstances(pkg, instances, n); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
-	_, err := io.WriteString(p.Writer, tree.String())
-	return err
+	return tree, nil
 }
 
 // Write writes the ascii tree to p.Writer
 func (p TreeWriter) Write(nodes []*yaml.RNode) error {
+	if p.Template != "" {
+		// fail before building the tree if the template is invalid
+		if _, err := parseTreeTemplate(p.Template); err != nil {
+			return err
+		}
+	}
+	tree, err := p.buildTree(nodes)
+	if err != nil {
+		return err
+	}
+	return p.writeTree(tree)
+}
+
+// buildTree builds the tree using the Structure, and renders it using the Renderer
+func (p TreeWriter) buildTree(nodes []*yaml.RNode) (treeprint.Tree, error) {
+	if p.GroupBy != TreeGroupByNone && p.GroupBy != TreeGroupByCRD {
+		return nil, fmt.Errorf("unknown tree grouping %q", p.GroupBy)
+	}
+
+	var tree treeprint.Tree
+	var err error
 	switch p.Structure {
 	case TreeStructurePackage:
-		return p.packageStructure(nodes)
+		tree, err = p.packageStructure(nodes)
 	case TreeStructureGraph:
-		return p.graphStructure(nodes)
+		tree, err = p.graphStructure(nodes)
 	default:
-		return p.packageStructure(nodes)
+		tree, err = p.packageStructure(nodes)
+	}
+	if err != nil {
+		return nil, err
 	}
+	if n, ok := tree.(*TreeNode); ok && p.Renderer != nil {
+		renderTree(n, p.Renderer)
+	}
+	return tree, nil
 }
 
 // node wraps a tree node, and any children nodes
@@ -106,6 +234,18 @@ type node struct {
 	p TreeWriter
 	*yaml.RNode
 	children []*node
+	// events are the Warning Events whose involvedObject is the Resource
+	events []*yaml.RNode
+	// namespaces are the namespaces of the Resources folded into this node, if any
+	namespaces []string
+	// selects are the workloads matched by the Service selector which are printed beneath
+	// another Service, and unmatched is set if the selector matches no workloads
+	selects   []string
+	unmatched bool
+	// crd is set if the Resource is a CustomResourceDefinition whose instances are
+	// grouped beneath it, and instances is the number of instances
+	crd       bool
+	instances int
 }
 
 func (a node) Len() int      { return len(a.children) }
@@ -119,13 +259,23 @@ func (a node) Tree(root treeprint.Tree) error {
 	sort.Sort(a)
 	branch := root
 	var err error
+	if a.p.FoldDuplicates {
+		if err := a.foldChildren(); err != nil {
+			return err
+		}
+	}
 
 	// generate a node for the Resource
 	if a.RNode != nil {
-		branch, err = a.p.doResource(a.RNode, "Resource", root)
+		branch, err = a.p.doResource(a.RNode, "Resource", a.namespaces, root)
 		if err != nil {
 			return err
 		}
+		a.p.doEvents(a.events, branch)
+		a.doSelectors(branch)
+		if a.crd {
+			doInstanceCount(a.instances, branch)
+		}
 	}
 
 	// attach children to the branch
@@ -137,15 +287,23 @@ func (a node) Tree(root treeprint.Tree) error {
 	return nil
 }
 
-// graphStructure writes the tree using owners for structure
-func (p TreeWriter) graphStructure(nodes []*yaml.RNode) error {
+// graphStructure builds the tree using owners for structure
+func (p TreeWriter) graphStructure(nodes []*yaml.RNode) (treeprint.Tree, error) {
+	var eventsByObject map[string][]*yaml.RNode
+	if p.Events {
+		var err error
+		if nodes, eventsByObject, err = indexEvents(nodes); err != nil {
+			return nil, err
+		}
+	}
+
 	resourceToOwner := map[string]*node{}
-	root := &node{}
+	root := &node{p: p}
 	// index each of the nodes by their owner
 	for _, n := range nodes {
 		ownerVal, err := ownerToString(n)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		var owner *node
 		if ownerVal == "" {
@@ -164,7 +322,7 @@ func (p TreeWriter) graphStructure(nodes []*yaml.RNode) error {
 
 		nodeVal, err := nodeToString(n)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		val, found := resourceToOwner[nodeVal]
 		if !found {
@@ -174,24 +332,34 @@ func (p TreeWriter) graphStructure(nodes []*yaml.RNode) error {
 			val = resourceToOwner[nodeVal]
 		}
 		val.RNode = n
+		val.events = eventsByObject[nodeVal]
 		owner.children = append(owner.children, val)
 	}
 
 	for k, v := range resourceToOwner {
 		if v.RNode == nil {
-			return fmt.Errorf(
+			return nil, fmt.Errorf(
 				"owner '%s' not found in input, but found as an owner of input objects", k)
 		}
 	}
 
+	if p.ResolveSelectors {
+		if err := resolveSelectors(root); err != nil {
+			return nil, err
+		}
+	}
+	if p.GroupBy == TreeGroupByCRD {
+		if err := groupGraphCRDs(root); err != nil {
+			return nil, err
+		}
+	}
+
 	// print the tree
-	tree := treeprint.New()
+	tree := p.newTree()
 	if err := root.Tree(tree); err != nil {
-		return err
+		return nil, err
 	}
-
-	_, err := io.WriteString(p.Writer, tree.String())
-	return err
+	return tree, nil
 }
 
 // nodeToString generates a string to identify the node -- matches ownerToString format
@@ -240,13 +408,151 @@ func ownerToString(node *yaml.RNode) (string, error) {
 	return fmt.Sprintf("%s %s/%s", kind, namespace, name), nil
 }
 
+// isEvent returns true if the Resource is an Event
+func isEvent(meta yaml.ResourceMeta) bool {
+	return meta.Kind == "Event" &&
+		(meta.ApiVersion == "v1" || strings.HasPrefix(meta.ApiVersion, "events.k8s.io/"))
+}
+
+// eventField returns the value of the first of the fields found on the Event
+func eventField(event *yaml.RNode, fields ...[]string) string {
+	for _, f := range fields {
+		value, err := event.Pipe(yaml.Lookup(f...))
+		if err == nil && !yaml.IsMissingOrNull(value) {
+			return value.YNode().Value
+		}
+	}
+	return ""
+}
+
+// eventTime returns the time the Event was last seen, formatted so that more recent
+// times sort after less recent times
+func eventTime(event *yaml.RNode) string {
+	return eventField(event,
+		[]string{"lastTimestamp"},
+		[]string{"series", "lastObservedTime"},
+		[]string{"eventTime"},
+		[]string{"metadata", "creationTimestamp"})
+}
+
+// indexEvents removes the Events from nodes, and indexes the Warning Events by their
+// involvedObject -- matches nodeToString format.  The Events for each object are sorted
+// from most to least recent.
+func indexEvents(nodes []*yaml.RNode) ([]*yaml.RNode, map[string][]*yaml.RNode, error) {
+	var resources []*yaml.RNode
+	eventsByObject := map[string][]*yaml.RNode{}
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isEvent(meta) {
+			resources = append(resources, nodes[i])
+			continue
+		}
+		if eventField(nodes[i], []string{"type"}) != "Warning" {
+			continue
+		}
+
+		// v1 Events use involvedObject, events.k8s.io Events use regarding
+		object := []string{"involvedObject"}
+		if eventField(nodes[i], []string{"regarding", "kind"}) != "" {
+			object = []string{"regarding"}
+		}
+		key := fmt.Sprintf("%s %s/%s",
+			eventField(nodes[i], append(object, "kind")),
+			eventField(nodes[i], append(object, "namespace")),
+			eventField(nodes[i], append(object, "name")))
+		eventsByObject[key] = append(eventsByObject[key], nodes[i])
+	}
+
+	for _, events := range eventsByObject {
+		sort.SliceStable(events, func(i, j int) bool {
+			return eventTime(events[i]) > eventTime(events[j])
+		})
+	}
+	return resources, eventsByObject, nil
+}
+
+// doEvents adds the most recent events to the branch
+func (p TreeWriter) doEvents(events []*yaml.RNode, branch treeprint.Tree) {
+	max := p.MaxEvents
+	if max <= 0 {
+		max = defaultMaxEvents
+	}
+	for i := range events {
+		if i >= max {
+			break
+		}
+		value := eventField(events[i], []string{"reason"})
+		if count := eventField(events[i],
+			[]string{"count"}, []string{"series", "count"}); count != "" && count != "1" {
+			value = fmt.Sprintf("%s (x%s)", value, count)
+		}
+		if message := eventField(events[i], []string{"message"}, []string{"note"}); message != "" {
+			value = fmt.Sprintf("%s: %s", value, strings.TrimSpace(message))
+		}
+		branch.AddMetaNode("Event", value)
+	}
+}
+
+// treeManager identifies Resources managed by a tool from their labels or annotations
+type treeManager struct {
+	// name is the badge displayed for the Resource
+	name string
+	// labels are labels set by the tool
+	labels []string
+	// annotations are annotations set by the tool
+	annotations []string
+}
+
+// treeManagers are well-known tools which manage Resources in a cluster
+var treeManagers = []treeManager{
+	{name: "argocd",
+		labels:      []string{"argocd.argoproj.io/instance"},
+		annotations: []string{"argocd.argoproj.io/instance"}},
+	{name: "flux",
+		labels: []string{
+			"kustomize.toolkit.fluxcd.io/name",
+			"helm.toolkit.fluxcd.io/name",
+			"fluxcd.io/sync-gc-mark"}},
+	{name: "helm",
+		annotations: []string{"meta.helm.sh/release-name"}},
+	{name: "kubectl",
+		annotations: []string{"kubectl.kubernetes.io/last-applied-configuration"}},
+}
+
+// managers returns the names of the tools managing the Resource
+func managers(meta yaml.ResourceMeta) []string {
+	var names []string
+	for _, m := range treeManagers {
+		found := false
+		for _, l := range m.labels {
+			if _, ok := meta.Labels[l]; ok {
+				found = true
+			}
+		}
+		for _, a := range m.annotations {
+			if _, ok := meta.Annotations[a]; ok {
+				found = true
+			}
+		}
+		if found {
+			names = append(names, m.name)
+		}
+	}
+	return names
+}
+
 // index indexes the Resources by their package
 func (p TreeWriter) index(nodes []*yaml.RNode) map[string][]*yaml.RNode {
 	// index the ResourceNodes by package
 	indexByPackage := map[string][]*yaml.RNode{}
 	for i := range nodes {
 		meta, err := nodes[i].GetMeta()
-		if err != nil || meta.Kind == "" {
+		if p.Generators && isKustomization(meta) {
+			// kustomization files may not have metadata or a kind
+		} else if err != nil || meta.Kind == "" {
 			// not a resource
 			continue
 		}
@@ -307,7 +613,10 @@ func (p TreeWriter) sort(indexByPackage map[string][]*yaml.RNode) []string {
 	return keys
 }
 
-func (p TreeWriter) doResource(leaf *yaml.RNode, metaString string, branch treeprint.Tree) (treeprint.Tree, error) {
+// doResource adds the Resource and its fields to the branch.  namespaces are the namespaces
+// of the Resources folded into the Resource, if any.
+func (p TreeWriter) doResource(leaf *yaml.RNode, metaString string, namespaces []string,
+	branch treeprint.Tree) (treeprint.Tree, error) {
 	meta, _ := leaf.GetMeta()
 	if metaString == "" {
 		path := meta.Annotations[kioutil.PathAnnotation]
@@ -315,17 +624,36 @@ func (p TreeWriter) doResource(leaf *yaml.RNode, metaString string, branch treep
 		metaString = path
 	}
 
+	if meta.Kind == "" && isKustomization(meta) {
+		meta.Kind = "Kustomization"
+	}
 	value := fmt.Sprintf("%s %s", meta.Kind, meta.Name)
-	if len(meta.Namespace) > 0 {
+	if len(namespaces) > 0 {
+		value = fmt.Sprintf("%s %s (%d namespaces)", meta.Kind, meta.Name, len(namespaces))
+	} else if len(meta.Namespace) > 0 {
 		value = fmt.Sprintf("%s %s/%s", meta.Kind, meta.Namespace, meta.Name)
 	}
 
+	// badge Resources from the cluster with the tools managing them
+	if p.Structure == TreeStructureGraph {
+		if m := managers(meta); len(m) > 0 {
+			value = fmt.Sprintf("%s (%s)", value, strings.Join(m, ","))
+		}
+	}
+
 	fields, err := p.getFields(leaf)
 	if err != nil {
 		return nil, err
 	}
 
 	n := branch.AddMetaBranch(metaString, value)
+	if t, ok := n.(*TreeNode); ok {
+		t.Resource = leaf
+		t.Namespaces = namespaces
+	}
+	if err := p.doStatus(leaf, n); err != nil {
+		return nil, err
+	}
 	for i := range fields {
 		field := fields[i]
 
@@ -347,6 +675,12 @@ func (p TreeWriter) doResource(leaf *yaml.RNode, metaString string, branch treep
 		}
 	}
 
+	if p.ExpandFolded {
+		for _, ns := range namespaces {
+			n.AddMetaNode("Namespace", ns)
+		}
+	}
+
 	return n, nil
 }
 
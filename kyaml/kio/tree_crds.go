@@ -0,0 +1,165 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xlab/treeprint"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TreeGroupBy configures how TreeWriter groups the Resources within the tree structure
+type TreeGroupBy string
+
+const (
+	// TreeGroupByNone doesn't group the Resources.  This is the default.
+	TreeGroupByNone TreeGroupBy = ""
+
+	// TreeGroupByCRD configures TreeWriter to print the instances of the
+	// CustomResourceDefinitions in the input beneath their definition, with the number of
+	// instances of each definition.
+	TreeGroupByCRD TreeGroupBy = "crd"
+)
+
+// crdGroups are the CustomResourceDefinitions of the input and their instances
+type crdGroups struct {
+	// instances are the instances of each CustomResourceDefinition, in input order
+	instances map[*yaml.RNode][]*yaml.RNode
+	// grouped is set for the instances of the CustomResourceDefinitions
+	grouped map[*yaml.RNode]bool
+}
+
+// isCRD returns true if the Resource is a CustomResourceDefinition
+func isCRD(meta yaml.ResourceMeta) bool {
+	return meta.Kind == "CustomResourceDefinition" &&
+		strings.HasPrefix(meta.ApiVersion, "apiextensions.k8s.io/")
+}
+
+// instanceKey returns the group and kind of the Resource -- matches crdKey format
+func instanceKey(meta yaml.ResourceMeta) string {
+	var group string
+	if i := strings.Index(meta.ApiVersion, "/"); i >= 0 {
+		group = meta.ApiVersion[:i]
+	}
+	return group + "/" + meta.Kind
+}
+
+// crdKey returns the group and kind of the instances of the CustomResourceDefinition --
+// matches instanceKey format -- or "" if they aren't set
+func crdKey(crd *yaml.RNode) (string, error) {
+	group, err := crd.Pipe(yaml.Lookup("spec", "group"))
+	if err != nil {
+		return "", err
+	}
+	kind, err := crd.Pipe(yaml.Lookup("spec", "names", "kind"))
+	if err != nil {
+		return "", err
+	}
+	if yaml.IsMissingOrNull(group) || yaml.IsMissingOrNull(kind) {
+		return "", nil
+	}
+	return group.YNode().Value + "/" + kind.YNode().Value, nil
+}
+
+// groupCRDs indexes the CustomResourceDefinitions in nodes, and their instances
+func groupCRDs(nodes []*yaml.RNode) (crdGroups, error) {
+	groups := crdGroups{
+		instances: map[*yaml.RNode][]*yaml.RNode{},
+		grouped:   map[*yaml.RNode]bool{},
+	}
+	crds := map[string]*yaml.RNode{}
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil || !isCRD(meta) {
+			continue
+		}
+		key, err := crdKey(nodes[i])
+		if err != nil {
+			return crdGroups{}, err
+		}
+		groups.instances[nodes[i]] = nil
+		if _, found := crds[key]; key != "" && !found {
+			crds[key] = nodes[i]
+		}
+	}
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil || isCRD(meta) {
+			continue
+		}
+		if crd, found := crds[instanceKey(meta)]; found {
+			groups.instances[crd] = append(groups.instances[crd], nodes[i])
+			groups.grouped[nodes[i]] = true
+		}
+	}
+	return groups, nil
+}
+
+// groupGraphCRDs moves the instances attached to the root beneath the
+// CustomResourceDefinitions attached to the root which define them.  Instances with
+// owners are left beneath their owners.
+func groupGraphCRDs(root *node) error {
+	var nodes []*yaml.RNode
+	byResource := map[*yaml.RNode]*node{}
+	for _, c := range root.children {
+		nodes = append(nodes, c.RNode)
+		byResource[c.RNode] = c
+	}
+	groups, err := groupCRDs(nodes)
+	if err != nil {
+		return err
+	}
+
+	var children []*node
+	for _, c := range root.children {
+		instances, found := groups.instances[c.RNode]
+		if found {
+			c.instances = len(instances)
+			c.crd = true
+		}
+		for _, i := range instances {
+			c.children = append(c.children, byResource[i])
+		}
+		if !groups.grouped[c.RNode] {
+			children = append(children, c)
+		}
+	}
+	root.children = children
+	return nil
+}
+
+// doCRDInstances adds the instances of the CustomResourceDefinition printed in the package
+// pkg to its branch.  The instances in other packages are printed with their package.
+func (p TreeWriter) doCRDInstances(pkg string, instances []*yaml.RNode,
+	branch treeprint.Tree) error {
+	doInstanceCount(len(instances), branch)
+	namespaces := make([][]string, len(instances))
+	if p.FoldDuplicates {
+		var err error
+		if instances, namespaces, err = foldResources(instances); err != nil {
+			return err
+		}
+	}
+	for i := range instances {
+		var metaString string
+		meta, _ := instances[i].GetMeta()
+		if instancePkg := meta.Annotations[kioutil.PackageAnnotation]; instancePkg != pkg {
+			metaString = filepath.Join(instancePkg,
+				filepath.Base(meta.Annotations[kioutil.PathAnnotation]))
+		}
+		if _, err := p.doResource(instances[i], metaString, namespaces[i], branch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doInstanceCount adds the number of instances of a CustomResourceDefinition to its branch
+func doInstanceCount(count int, branch treeprint.Tree) {
+	branch.AddMetaNode("Instances", fmt.Sprintf("%d", count))
+}
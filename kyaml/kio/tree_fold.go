@@ -0,0 +1,149 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// foldIgnoredMetadata are the metadata fields which differ between copies of a Resource
+// in different namespaces, and are ignored when folding duplicates
+var foldIgnoredMetadata = []string{
+	"namespace", "uid", "resourceVersion", "creationTimestamp", "selfLink", "generation",
+	"managedFields",
+}
+
+// foldIgnoredAnnotations are the annotations which differ between copies of a Resource
+// in different namespaces, and are ignored when folding duplicates
+var foldIgnoredAnnotations = []string{
+	string(kioutil.PathAnnotation),
+	string(kioutil.IndexAnnotation),
+	// contains the namespace of the Resource
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// foldKey returns the Resource without the fields which differ between copies of the
+// Resource in different namespaces -- its metadata.namespace, server-set metadata and
+// status.  Namespaced Resources with the same key are folded by TreeWriter.
+func foldKey(rn *yaml.RNode) (string, error) {
+	c := rn.Copy()
+	for _, a := range foldIgnoredAnnotations {
+		if _, err := c.Pipe(yaml.ClearAnnotation(a)); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range foldIgnoredMetadata {
+		if _, err := c.Pipe(yaml.Lookup("metadata"), yaml.Clear(f)); err != nil {
+			return "", err
+		}
+	}
+	owners, err := c.Pipe(yaml.Lookup("metadata", "ownerReferences"))
+	if err != nil {
+		return "", err
+	}
+	if owners != nil && owners.YNode().Kind == yaml.SequenceNode {
+		for _, o := range owners.YNode().Content {
+			if _, err := yaml.NewRNode(o).Pipe(yaml.Clear("uid")); err != nil {
+				return "", err
+			}
+		}
+	}
+	if _, err := c.Pipe(yaml.Clear("status")); err != nil {
+		return "", err
+	}
+	return c.String()
+}
+
+// foldGroups groups the Resources which are identical except for their namespace.  Each
+// group is the indexes of the Resources in nodes, and the groups are ordered by their
+// first Resource.  Resources without a namespace are never grouped.
+func foldGroups(nodes []*yaml.RNode) ([][]int, error) {
+	var groups [][]int
+	groupByKey := map[string]int{}
+	for i := range nodes {
+		meta, err := nodes[i].GetMeta()
+		if err != nil || meta.Namespace == "" {
+			groups = append(groups, []int{i})
+			continue
+		}
+		key, err := foldKey(nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		g, found := groupByKey[key]
+		if !found {
+			groupByKey[key] = len(groups)
+			groups = append(groups, []int{i})
+			continue
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups, nil
+}
+
+// foldNamespaces returns the sorted namespaces of the Resources of a group, or nil if the
+// group has a single Resource and isn't folded
+func foldNamespaces(nodes []*yaml.RNode, group []int) []string {
+	if len(group) < 2 {
+		return nil
+	}
+	var namespaces []string
+	for _, i := range group {
+		meta, _ := nodes[i].GetMeta()
+		namespaces = append(namespaces, meta.Namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// foldResources folds the Resources which are duplicates in different namespaces into
+// the first of them, returning the remaining Resources and the namespaces folded into
+// each -- nil for Resources which weren't folded.
+func foldResources(nodes []*yaml.RNode) ([]*yaml.RNode, [][]string, error) {
+	groups, err := foldGroups(nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	var folded []*yaml.RNode
+	var namespaces [][]string
+	for _, g := range groups {
+		folded = append(folded, nodes[g[0]])
+		namespaces = append(namespaces, foldNamespaces(nodes, g))
+	}
+	return folded, namespaces, nil
+}
+
+// foldChildren folds the children of the node which are duplicates in different
+// namespaces into the first of them.  The children and Events of the folded nodes are
+// merged, so that the children may be folded in turn.
+func (a *node) foldChildren() error {
+	var resources []*yaml.RNode
+	for _, c := range a.children {
+		resources = append(resources, c.RNode)
+	}
+	groups, err := foldGroups(resources)
+	if err != nil {
+		return err
+	}
+	var children []*node
+	for _, g := range groups {
+		n := a.children[g[0]]
+		n.namespaces = foldNamespaces(resources, g)
+		for _, i := range g[1:] {
+			n.children = append(n.children, a.children[i].children...)
+			n.events = append(n.events, a.children[i].events...)
+		}
+		if len(g) > 1 {
+			sort.SliceStable(n.events, func(i, j int) bool {
+				return eventTime(n.events[i]) > eventTime(n.events[j])
+			})
+		}
+		children = append(children, n)
+	}
+	a.children = children
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xlab/treeprint"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// kustomizationFileNames are the names of kustomization files
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// isKustomization returns true if the Resource was read from a kustomization file
+func isKustomization(meta yaml.ResourceMeta) bool {
+	base := filepath.Base(meta.Annotations[kioutil.PathAnnotation])
+	for _, name := range kustomizationFileNames {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// generatorOptions are the options of kustomization generators
+type generatorOptions struct {
+	DisableNameSuffixHash bool `yaml:"disableNameSuffixHash,omitempty"`
+}
+
+// generatorArgs is a configMapGenerator or secretGenerator entry of a kustomization
+type generatorArgs struct {
+	Name      string            `yaml:"name,omitempty"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Literals  []string          `yaml:"literals,omitempty"`
+	Files     []string          `yaml:"files,omitempty"`
+	Envs      []string          `yaml:"envs,omitempty"`
+	Env       string            `yaml:"env,omitempty"`
+	Type      string            `yaml:"type,omitempty"`
+	Options   *generatorOptions `yaml:"options,omitempty"`
+}
+
+// kustomizationGenerators are the fields of a kustomization used to generate
+// ConfigMaps and Secrets
+type kustomizationGenerators struct {
+	NamePrefix         string            `yaml:"namePrefix,omitempty"`
+	NameSuffix         string            `yaml:"nameSuffix,omitempty"`
+	Namespace          string            `yaml:"namespace,omitempty"`
+	ConfigMapGenerator []generatorArgs   `yaml:"configMapGenerator,omitempty"`
+	SecretGenerator    []generatorArgs   `yaml:"secretGenerator,omitempty"`
+	GeneratorOptions   *generatorOptions `yaml:"generatorOptions,omitempty"`
+}
+
+// doGenerators adds the ConfigMaps and Secrets generated by the kustomization to its
+// branch, and the Resources referencing them by their base name beneath them.
+func (p TreeWriter) doGenerators(
+	kustomization *yaml.RNode, resources []*yaml.RNode, branch treeprint.Tree) error {
+	k := kustomizationGenerators{}
+	if err := kustomization.YNode().Decode(&k); err != nil {
+		return err
+	}
+	meta, _ := kustomization.GetMeta()
+	dir := filepath.Join(p.Root, filepath.Dir(meta.Annotations[kioutil.PathAnnotation]))
+
+	for _, g := range []struct {
+		kind string
+		args []generatorArgs
+	}{{"ConfigMap", k.ConfigMapGenerator}, {"Secret", k.SecretGenerator}} {
+		for _, args := range g.args {
+			name := k.NamePrefix + args.Name + k.NameSuffix
+			value := name
+			if !(k.GeneratorOptions != nil && k.GeneratorOptions.DisableNameSuffixHash) &&
+				!(args.Options != nil && args.Options.DisableNameSuffixHash) {
+				if hash, err := generatorHash(g.kind, name, dir, args); err != nil {
+					value = fmt.Sprintf("%s (%v)", name, err)
+				} else {
+					value = name + "-" + hash
+				}
+			}
+			namespace := args.Namespace
+			if namespace == "" {
+				namespace = k.Namespace
+			}
+			if namespace != "" {
+				value = namespace + "/" + value
+			}
+			b := branch.AddMetaBranch("generated", g.kind+" "+value)
+
+			for i := range resources {
+				refMeta, _ := resources[i].GetMeta()
+				if isKustomization(refMeta) || !referencesGenerated(resources[i].YNode(), g.kind, args.Name) {
+					continue
+				}
+				ref := fmt.Sprintf("%s %s", refMeta.Kind, refMeta.Name)
+				if refMeta.Namespace != "" {
+					ref = fmt.Sprintf("%s %s/%s", refMeta.Kind, refMeta.Namespace, refMeta.Name)
+				}
+				b.AddMetaNode(filepath.Base(refMeta.Annotations[kioutil.PathAnnotation]), ref)
+			}
+		}
+	}
+	return nil
+}
+
+// generatorHash predicts the name suffix hash of a generated ConfigMap or Secret, by
+// reading the generator sources from dir and hashing them as kustomize does.
+func generatorHash(kind, name, dir string, args generatorArgs) (string, error) {
+	data := map[string][]byte{}
+	for _, l := range args.Literals {
+		kv := strings.SplitN(l, "=", 2)
+		if len(kv) != 2 {
+			return "", fmt.Errorf("invalid literal '%s': must be key=value", l)
+		}
+		value := kv[1]
+		if len(value) >= 2 && value[0] == value[len(value)-1] &&
+			(value[0] == '"' || value[0] == '\'') {
+			value = value[1 : len(value)-1]
+		}
+		data[kv[0]] = []byte(value)
+	}
+	for _, f := range args.Files {
+		key, file := filepath.Base(f), f
+		if kv := strings.SplitN(f, "=", 2); len(kv) == 2 {
+			key, file = kv[0], kv[1]
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return "", err
+		}
+		data[key] = b
+	}
+	envs := args.Envs
+	if args.Env != "" {
+		envs = append(envs, args.Env)
+	}
+	for _, env := range envs {
+		b, err := ioutil.ReadFile(filepath.Join(dir, env))
+		if err != nil {
+			return "", err
+		}
+		s := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))))
+		for s.Scan() {
+			line := strings.TrimLeft(s.Text(), " \t")
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) == 2 {
+				data[kv[0]] = []byte(kv[1])
+			} else {
+				// kustomize reads keys without values from the environment
+				data[kv[0]] = []byte(os.Getenv(kv[0]))
+			}
+		}
+	}
+
+	// json.Marshal sorts the keys, so that the encoding is stable
+	encoded := map[string]interface{}{"kind": kind, "name": name}
+	switch kind {
+	case "ConfigMap":
+		strData, binaryData := map[string]string{}, map[string][]byte{}
+		for k, v := range data {
+			if utf8.Valid(v) {
+				strData[k] = string(v)
+			} else {
+				binaryData[k] = v
+			}
+		}
+		encoded["data"] = strData
+		if len(binaryData) > 0 {
+			encoded["binaryData"] = binaryData
+		}
+	case "Secret":
+		t := args.Type
+		if t == "" {
+			t = "Opaque"
+		}
+		encoded["type"] = t
+		encoded["data"] = data
+	}
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	// encode the first 10 characters of the hex sha256, replacing characters to avoid
+	// generating words
+	hex := []rune(fmt.Sprintf("%x", sha256.Sum256(b))[:10])
+	for i := range hex {
+		switch hex[i] {
+		case '0':
+			hex[i] = 'g'
+		case '1':
+			hex[i] = 'h'
+		case '3':
+			hex[i] = 'k'
+		case 'a':
+			hex[i] = 'm'
+		case 'e':
+			hex[i] = 't'
+		}
+	}
+	return string(hex), nil
+}
+
+// referencesGenerated returns true if node references the ConfigMap or Secret by name
+// -- e.g. from volumes, envFrom or env valueFrom.
+func referencesGenerated(node *yaml.Node, kind, name string) bool {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i := range node.Content {
+			if referencesGenerated(node.Content[i], kind, name) {
+				return true
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			var fields []string
+			switch {
+			case kind == "ConfigMap" &&
+				(key == "configMap" || key == "configMapRef" || key == "configMapKeyRef"):
+				fields = []string{"name"}
+			case kind == "Secret" && key == "secret":
+				// volumes use secretName, projected volume sources use name
+				fields = []string{"secretName", "name"}
+			case kind == "Secret" && (key == "secretRef" || key == "secretKeyRef"):
+				fields = []string{"name"}
+			case kind == "Secret" && key == "imagePullSecrets":
+				for _, elem := range value.Content {
+					if f := yaml.NewRNode(elem).Field("name"); !yaml.IsFieldEmpty(f) &&
+						f.Value.YNode().Value == name {
+						return true
+					}
+				}
+			}
+			for _, field := range fields {
+				if value.Kind != yaml.MappingNode {
+					break
+				}
+				if f := yaml.NewRNode(value).Field(field); !yaml.IsFieldEmpty(f) &&
+					f.Value.YNode().Value == name {
+					return true
+				}
+			}
+			if referencesGenerated(value, kind, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+// NodeRenderer renders the nodes of the tree built by TreeWriter, so that the nodes may be
+// printed with custom prefixes, colors or extra columns without reimplementing the
+// traversal of the Resources.
+type NodeRenderer interface {
+	// Render returns the meta and value printed for the node.  The node has the Meta and
+	// Value rendered by default, and its Resource, Namespaces, Depth and Children.
+	Render(node *TreeNode) (meta, value string)
+}
+
+// NodeRendererFunc implements a NodeRenderer as a function.
+type NodeRendererFunc func(node *TreeNode) (meta, value string)
+
+func (fn NodeRendererFunc) Render(node *TreeNode) (string, string) {
+	return fn(node)
+}
+
+// DefaultNodeRenderer renders the nodes with their default Meta and Value, so that the tree
+// is printed as it is without a Renderer.
+var DefaultNodeRenderer NodeRenderer = NodeRendererFunc(func(node *TreeNode) (string, string) {
+	return node.Meta, node.Value
+})
+
+// renderTree renders the node and its descendants, parents before their children
+func renderTree(node *TreeNode, r NodeRenderer) {
+	node.Meta, node.Value = r.Render(node)
+	for _, c := range node.Children {
+		renderTree(c, r)
+	}
+}
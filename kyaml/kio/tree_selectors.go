@@ -0,0 +1,121 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"sort"
+
+	"github.com/xlab/treeprint"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// selectorWorkloadKinds are the kinds of the workloads whose pods Service selectors are
+// resolved to
+var selectorWorkloadKinds = map[string]bool{"Deployment": true, "StatefulSet": true}
+
+// resolveSelectors moves the workloads attached to the root beneath the first Service
+// whose selector matches their pod template labels.  The other Services matching a
+// workload record it in selects, and the Services whose selector matches no workloads
+// are flagged as unmatched.
+func resolveSelectors(root *node) error {
+	var services, workloads []*node
+	for _, c := range root.children {
+		meta, err := c.GetMeta()
+		if err != nil {
+			return err
+		}
+		switch {
+		case meta.Kind == "Service" && meta.ApiVersion == "v1":
+			services = append(services, c)
+		case selectorWorkloadKinds[meta.Kind]:
+			workloads = append(workloads, c)
+		}
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		return compareNodes(services[i].RNode, services[j].RNode)
+	})
+
+	moved := map[*node]bool{}
+	for _, s := range services {
+		selector, err := stringMap(s.RNode, "spec", "selector")
+		if err != nil {
+			return err
+		}
+		if len(selector) == 0 {
+			// Services without selectors have their endpoints managed elsewhere
+			continue
+		}
+		serviceMeta, _ := s.GetMeta()
+		matched := false
+		for _, w := range workloads {
+			workloadMeta, _ := w.GetMeta()
+			if workloadMeta.Namespace != serviceMeta.Namespace {
+				continue
+			}
+			labels, err := stringMap(w.RNode, "spec", "template", "metadata", "labels")
+			if err != nil {
+				return err
+			}
+			if !selectorMatches(selector, labels) {
+				continue
+			}
+			matched = true
+			if moved[w] {
+				value, err := nodeToString(w.RNode)
+				if err != nil {
+					return err
+				}
+				s.selects = append(s.selects, value)
+				continue
+			}
+			moved[w] = true
+			s.children = append(s.children, w)
+		}
+		s.unmatched = !matched
+	}
+
+	var children []*node
+	for _, c := range root.children {
+		if !moved[c] {
+			children = append(children, c)
+		}
+	}
+	root.children = children
+	return nil
+}
+
+// stringMap returns the string values of the map field at the path
+func stringMap(rn *yaml.RNode, path ...string) (map[string]string, error) {
+	field, err := rn.Pipe(yaml.Lookup(path...))
+	if err != nil || yaml.IsMissingOrNull(field) {
+		return nil, err
+	}
+	values := map[string]string{}
+	err = field.VisitFields(func(node *yaml.MapNode) error {
+		values[node.Key.YNode().Value] = node.Value.YNode().Value
+		return nil
+	})
+	return values, err
+}
+
+// selectorMatches returns true if the labels have all the values of the selector
+func selectorMatches(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if value, found := labels[k]; !found || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// doSelectors adds the workloads selected by the Service which are printed elsewhere, and
+// flags the Service if its selector matches no workloads
+func (a node) doSelectors(branch treeprint.Tree) {
+	if a.unmatched {
+		branch.AddMetaNode("Selector", "matches no workloads")
+	}
+	for _, s := range a.selects {
+		branch.AddMetaNode("Selects", s)
+	}
+}
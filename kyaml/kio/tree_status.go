@@ -0,0 +1,116 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xlab/treeprint"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// doStatus adds the age and restart counts of the live Resource to its branch, if
+// enabled.  Resources without a creationTimestamp have no age, and only Pods have
+// restarts.
+func (p TreeWriter) doStatus(leaf *yaml.RNode, branch treeprint.Tree) error {
+	if p.Age {
+		f, err := leaf.Pipe(yaml.Lookup("metadata", "creationTimestamp"))
+		if err != nil {
+			return err
+		}
+		if !yaml.IsMissingOrNull(f) {
+			if created, err := time.Parse(time.RFC3339, f.YNode().Value); err == nil {
+				now := p.Now
+				if now.IsZero() {
+					now = time.Now()
+				}
+				branch.AddNode(fmt.Sprintf("age: %s", humanDuration(now.Sub(created))))
+			}
+		}
+	}
+
+	meta, _ := leaf.GetMeta()
+	if !p.Restarts || meta.Kind != "Pod" {
+		return nil
+	}
+	total := 0
+	var containers []string
+	for _, list := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, err := leaf.Pipe(yaml.Lookup("status", list))
+		if err != nil {
+			return err
+		}
+		if statuses == nil {
+			continue
+		}
+		elements, err := statuses.Elements()
+		if err != nil {
+			return err
+		}
+		for i := range elements {
+			count := 0
+			if f := elements[i].Field("restartCount"); !yaml.IsFieldEmpty(f) {
+				if _, err := fmt.Sscanf(f.Value.YNode().Value, "%d", &count); err != nil {
+					continue
+				}
+			}
+			total += count
+			if n := elements[i].Field("name"); count > 0 && !yaml.IsFieldEmpty(n) {
+				containers = append(containers, fmt.Sprintf("%s: %d", n.Value.YNode().Value, count))
+			}
+		}
+	}
+	value := fmt.Sprintf("restarts: %d", total)
+	// break the restarts down by container if more than one restarted
+	if len(containers) > 1 {
+		value = fmt.Sprintf("%s (%s)", value, strings.Join(containers, ", "))
+	}
+	branch.AddNode(value)
+	return nil
+}
+
+// humanDuration formats d as kubectl get formats ages -- with a precision decreasing as
+// the duration grows, e.g. 45s, 3m10s, 5h20m, 12d or 2y30d.
+func humanDuration(d time.Duration) string {
+	if seconds := int(d.Seconds()); seconds < -1 {
+		return "<invalid>"
+	} else if seconds < 0 {
+		return "0s"
+	} else if seconds < 60*2 {
+		return fmt.Sprintf("%ds", seconds)
+	}
+	minutes := int(d / time.Minute)
+	if minutes < 10 {
+		if s := int(d/time.Second) % 60; s != 0 {
+			return fmt.Sprintf("%dm%ds", minutes, s)
+		}
+		return fmt.Sprintf("%dm", minutes)
+	} else if minutes < 60*3 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	hours := int(d / time.Hour)
+	if hours < 8 {
+		if m := minutes % 60; m != 0 {
+			return fmt.Sprintf("%dh%dm", hours, m)
+		}
+		return fmt.Sprintf("%dh", hours)
+	} else if hours < 48 {
+		return fmt.Sprintf("%dh", hours)
+	} else if hours < 24*8 {
+		if h := hours % 24; h != 0 {
+			return fmt.Sprintf("%dd%dh", hours/24, h)
+		}
+		return fmt.Sprintf("%dd", hours/24)
+	} else if hours < 24*365*2 {
+		return fmt.Sprintf("%dd", hours/24)
+	} else if hours < 24*365*8 {
+		if dy := (hours / 24) % 365; dy != 0 {
+			return fmt.Sprintf("%dy%dd", hours/24/365, dy)
+		}
+		return fmt.Sprintf("%dy", hours/24/365)
+	}
+	return fmt.Sprintf("%dy", hours/24/365)
+}
@@ -0,0 +1,231 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/xlab/treeprint"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TreeNode is a node of the tree built by TreeWriter, and is the data passed to
+// TreeWriter.Template.  The root node has the package root (if any) as its Value, and
+// is the parent of the packages, Resources, fields and Events.
+type TreeNode struct {
+	// Meta is the bracketed prefix printed before the Value -- e.g. the file name
+	// of a Resource, or "Event".  May be empty.
+	Meta string
+
+	// Value is the text printed for the node -- e.g. "Deployment default/nginx",
+	// "spec.replicas: 3", or the name of a package.
+	Value string
+
+	// Resource is the Resource the node was printed for.  Nil for packages, fields
+	// and Events.
+	Resource *yaml.RNode
+
+	// Namespaces are the namespaces of the Resources folded into Resource by
+	// TreeWriter.FoldDuplicates.  Nil if the Resource wasn't folded.
+	Namespaces []string
+
+	// Depth is the depth of the node in the tree.  The root has a depth of 0.
+	Depth int
+
+	// Children are the nodes beneath this node
+	Children []*TreeNode
+}
+
+// TreeNode is built by TreeWriter in place of the ascii tree when a Template is set
+var _ treeprint.Tree = &TreeNode{}
+
+func (n *TreeNode) add(meta treeprint.MetaValue, v treeprint.Value) *TreeNode {
+	child := &TreeNode{Value: fmt.Sprint(v), Depth: n.Depth + 1}
+	if meta != nil {
+		child.Meta = fmt.Sprint(meta)
+	}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+func (n *TreeNode) AddNode(v treeprint.Value) treeprint.Tree {
+	n.add(nil, v)
+	return n
+}
+
+func (n *TreeNode) AddMetaNode(meta treeprint.MetaValue, v treeprint.Value) treeprint.Tree {
+	n.add(meta, v)
+	return n
+}
+
+func (n *TreeNode) AddBranch(v treeprint.Value) treeprint.Tree {
+	return n.add(nil, v)
+}
+
+func (n *TreeNode) AddMetaBranch(meta treeprint.MetaValue, v treeprint.Value) treeprint.Tree {
+	return n.add(meta, v)
+}
+
+func (n *TreeNode) Branch() treeprint.Tree {
+	return n
+}
+
+func (n *TreeNode) FindByMeta(meta treeprint.MetaValue) treeprint.Tree {
+	for _, c := range n.Children {
+		if c.Meta == fmt.Sprint(meta) {
+			return c
+		}
+		if v := c.FindByMeta(meta); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func (n *TreeNode) FindByValue(value treeprint.Value) treeprint.Tree {
+	for _, c := range n.Children {
+		if c.Value == fmt.Sprint(value) {
+			return c
+		}
+		if v := c.FindByValue(value); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func (n *TreeNode) FindLastNode() treeprint.Tree {
+	return n.Children[len(n.Children)-1]
+}
+
+// String renders the node and its children as an ascii tree
+func (n *TreeNode) String() string {
+	tree := treeprint.New()
+	tree.SetValue(n.Value)
+	if n.Meta != "" {
+		tree.SetMetaValue(n.Meta)
+	}
+	n.print(tree)
+	return tree.String()
+}
+
+func (n *TreeNode) print(tree treeprint.Tree) {
+	for _, c := range n.Children {
+		var branch treeprint.Tree
+		if c.Meta != "" {
+			branch = tree.AddMetaBranch(c.Meta, c.Value)
+		} else {
+			branch = tree.AddBranch(c.Value)
+		}
+		c.print(branch)
+	}
+}
+
+func (n *TreeNode) Bytes() []byte {
+	return []byte(n.String())
+}
+
+func (n *TreeNode) SetValue(value treeprint.Value) {
+	n.Value = fmt.Sprint(value)
+}
+
+func (n *TreeNode) SetMetaValue(meta treeprint.MetaValue) {
+	n.Meta = fmt.Sprint(meta)
+}
+
+// treeTemplateFuncs are the functions available to TreeWriter.Template in addition
+// to the text/template builtins.
+var treeTemplateFuncs = template.FuncMap{
+	// indent indents each line of s by n spaces
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+	},
+
+	// repeat repeats s n times -- e.g. to indent by the Depth of a node
+	"repeat": func(n int, s string) string {
+		if n < 0 {
+			return ""
+		}
+		return strings.Repeat(s, n)
+	},
+
+	// field returns the value of the field at the '.' separated path of the Resource
+	// as flow style yaml, or "" if the field isn't set or the node has no Resource
+	"field": func(resource *yaml.RNode, path string) (string, error) {
+		if resource == nil {
+			return "", nil
+		}
+		field, err := resource.Pipe(yaml.Lookup(strings.Split(path, ".")...))
+		if err != nil || yaml.IsMissingOrNull(field) {
+			return "", err
+		}
+		return yaml.String(field.YNode(), yaml.Trim, yaml.Flow)
+	},
+}
+
+// parseTreeTemplate parses the TreeWriter Template
+func parseTreeTemplate(text string) (*template.Template, error) {
+	return template.New("tree").Funcs(treeTemplateFuncs).Parse(text)
+}
+
+// BuildTree builds the tree of the Resources as TreeNodes rather than writing it -- e.g.
+// to display the tree interactively.  Writer and Template are not used.
+func (p TreeWriter) BuildTree(nodes []*yaml.RNode) (*TreeNode, error) {
+	p.Template, p.treeNodes = "", true
+	tree, err := p.buildTree(nodes)
+	if err != nil {
+		return nil, err
+	}
+	return tree.(*TreeNode), nil
+}
+
+// newTree returns the tree to build -- a TreeNode if a Template or Renderer is set
+func (p TreeWriter) newTree() treeprint.Tree {
+	if p.Template != "" || p.Renderer != nil || p.treeNodes {
+		return &TreeNode{}
+	}
+	return treeprint.New()
+}
+
+// writeTree writes the tree to p.Writer, rendered using the Template if it is set
+func (p TreeWriter) writeTree(tree treeprint.Tree) error {
+	node, ok := tree.(*TreeNode)
+	if !ok {
+		_, err := io.WriteString(p.Writer, tree.String())
+		return err
+	}
+	if p.Template == "" {
+		_, err := io.WriteString(p.Writer, p.asciiTree(node))
+		return err
+	}
+	t, err := parseTreeTemplate(p.Template)
+	if err != nil {
+		return err
+	}
+	b := &bytes.Buffer{}
+	if err := t.Execute(b, node); err != nil {
+		return err
+	}
+	_, err = p.Writer.Write(b.Bytes())
+	return err
+}
+
+// asciiTree renders the TreeNodes as the ascii tree printed without a Renderer
+func (p TreeWriter) asciiTree(node *TreeNode) string {
+	tree := treeprint.New()
+	if p.Structure != TreeStructureGraph || node.Value != "" {
+		// the root of the graph structure has no value, which is printed as "."
+		tree.SetValue(node.Value)
+	}
+	if node.Meta != "" {
+		tree.SetMetaValue(node.Meta)
+	}
+	node.print(tree)
+	return tree.String()
+}
@@ -5,7 +5,12 @@ package kio_test
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	. "sigs.k8s.io/kustomize/kyaml/kio"
@@ -384,3 +389,847 @@ metadata:
 		t.FailNow()
 	}
 }
+
+func TestPrinter_Write_managers(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: argo
+  namespace: default
+  labels:
+    argocd.argoproj.io/instance: myapp
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: flux
+  namespace: default
+  labels:
+    kustomize.toolkit.fluxcd.io/name: myapp
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{}'
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: unmanaged
+  namespace: default
+`
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs:  []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{Writer: out, Structure: TreeStructureGraph}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `.
+├── [Resource]  Deployment default/argo (argocd)
+├── [Resource]  Deployment default/flux (flux,kubectl)
+└── [Resource]  Deployment default/unmanaged
+`, out.String()) {
+		t.FailNow()
+	}
+}
+
+func TestPrinter_Write_events(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+  uid: 1
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: foo-1
+  namespace: default
+  ownerReferences:
+  - kind: Deployment
+    name: foo
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: foo-1.1
+  namespace: default
+involvedObject:
+  kind: Pod
+  name: foo-1
+  namespace: default
+type: Warning
+reason: BackOff
+message: Back-off restarting failed container
+count: 5
+lastTimestamp: "2019-10-26T11:06:19Z"
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: foo-1.2
+  namespace: default
+involvedObject:
+  kind: Pod
+  name: foo-1
+  namespace: default
+type: Warning
+reason: Unhealthy
+message: Liveness probe failed
+lastTimestamp: "2019-10-26T11:07:19Z"
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: foo-1.3
+  namespace: default
+involvedObject:
+  kind: Pod
+  name: foo-1
+  namespace: default
+type: Normal
+reason: Pulled
+message: Container image pulled
+lastTimestamp: "2019-10-26T11:08:19Z"
+---
+apiVersion: events.k8s.io/v1beta1
+kind: Event
+metadata:
+  name: foo.1
+  namespace: default
+regarding:
+  kind: Deployment
+  name: foo
+  namespace: default
+type: Warning
+reason: FailedCreate
+note: 'quota exceeded'
+eventTime: "2019-10-26T11:05:19.000000Z"
+`
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{
+			Writer: out, Structure: TreeStructureGraph, Events: true}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `.
+└── [Resource]  Deployment default/foo
+    ├── [Event]  FailedCreate: quota exceeded
+    └── [Resource]  Pod default/foo-1
+        ├── [Event]  Unhealthy: Liveness probe failed
+        └── [Event]  BackOff (x5): Back-off restarting failed container
+`, out.String()) {
+		t.FailNow()
+	}
+
+	// only print the most recent Event
+	out.Reset()
+	err = Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{
+			Writer: out, Structure: TreeStructureGraph, Events: true, MaxEvents: 1}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `.
+└── [Resource]  Deployment default/foo
+    ├── [Event]  FailedCreate: quota exceeded
+    └── [Resource]  Pod default/foo-1
+        └── [Event]  Unhealthy: Liveness probe failed
+`, out.String()) {
+		t.FailNow()
+	}
+}
+
+func TestPrinter_Write_resolveSelectors(t *testing.T) {
+	in := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web-canary
+  namespace: default
+spec:
+  selector:
+    app: web
+    track: canary
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: missing
+  namespace: default
+spec:
+  selector:
+    app: missing
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: external
+  namespace: default
+spec:
+  type: ExternalName
+  externalName: example.com
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+        track: canary
+---
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-1
+  namespace: default
+  ownerReferences:
+  - kind: Deployment
+    name: web
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: web
+  namespace: other
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        app: db
+`
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{
+			Writer: out, Structure: TreeStructureGraph, ResolveSelectors: true}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `.
+├── [Resource]  StatefulSet default/db
+├── [Resource]  Service default/external
+├── [Resource]  Service default/missing
+│   └── [Selector]  matches no workloads
+├── [Resource]  Service default/web
+│   └── [Resource]  Deployment default/web
+│       └── [Resource]  ReplicaSet default/web-1
+├── [Resource]  Service default/web-canary
+│   └── [Selects]  Deployment default/web
+└── [Resource]  StatefulSet other/web
+`, out.String()) {
+		t.FailNow()
+	}
+}
+
+func TestPrinter_Write_template(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: service.yaml
+spec:
+  selector:
+    app: nginx
+`
+	template := `{{define "node"}}{{repeat .Depth "#"}} {{.Value}}
+{{if .Resource}}{{indent 2 (printf "file: %s\nreplicas: %s" .Meta (field .Resource "spec.replicas"))}}
+{{end}}{{range .Children}}{{template "node" .}}{{end}}{{end}}{{template "node" .}}`
+
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{
+			Root:     "my-package",
+			Writer:   out,
+			Fields:   []TreeWriterField{{Name: "spec.replicas", PathMatcher: yaml.PathMatcher{Path: []string{"spec", "replicas"}}}},
+			Template: template,
+		}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, ` my-package
+# Deployment default/nginx
+  file: deployment.yaml
+  replicas: 3
+## spec.replicas: 3
+# Service default/nginx
+  file: service.yaml
+  replicas: 
+`, out.String())
+}
+
+func TestPrinter_Write_templateError(t *testing.T) {
+	err := Pipeline{
+		Inputs:  []Reader{&ByteReader{Reader: bytes.NewBufferString("kind: Deployment\n")}},
+		Outputs: []Writer{TreeWriter{Writer: &bytes.Buffer{}, Template: "{{.Value"}},
+	}.Execute()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "unclosed action")
+	}
+}
+
+func TestTreeWriter_BuildTree(t *testing.T) {
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  replicas: 3
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tree, err := TreeWriter{Root: "my-package",
+		Fields: []TreeWriterField{{Name: "spec.replicas",
+			PathMatcher: yaml.PathMatcher{Path: []string{"spec", "replicas"}}}},
+	}.BuildTree(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "my-package", tree.Value)
+	if !assert.Len(t, tree.Children, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "deployment.yaml", tree.Children[0].Meta)
+	assert.Equal(t, "Deployment default/nginx", tree.Children[0].Value)
+	assert.Equal(t, nodes[0], tree.Children[0].Resource)
+	if assert.Len(t, tree.Children[0].Children, 1) {
+		assert.Equal(t, "spec.replicas: 3", tree.Children[0].Children[0].Value)
+		assert.Equal(t, 2, tree.Children[0].Children[0].Depth)
+	}
+}
+
+func TestPrinter_Write_renderer(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: cockroachdb
+  namespace: myapp-staging
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: cockroachdb-0
+  namespace: myapp-staging
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: StatefulSet
+    name: cockroachdb
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: cockroachdb-1
+  namespace: myapp-staging
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: StatefulSet
+    name: cockroachdb
+`
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(in)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the default renderer prints the tree as it is printed without a renderer
+	for _, structure := range []TreeStructure{TreeStructurePackage, TreeStructureGraph} {
+		expected, actual := &bytes.Buffer{}, &bytes.Buffer{}
+		if !assert.NoError(t, TreeWriter{Writer: expected, Structure: structure, Root: "pkg"}.
+			Write(nodes)) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, TreeWriter{Writer: actual, Structure: structure, Root: "pkg",
+			Renderer: DefaultNodeRenderer}.Write(nodes)) {
+			t.FailNow()
+		}
+		assert.Equal(t, expected.String(), actual.String(), structure)
+	}
+
+	// renderers may print columns computed from the Resources
+	out := &bytes.Buffer{}
+	err = TreeWriter{
+		Writer:    out,
+		Structure: TreeStructureGraph,
+		Renderer: NodeRendererFunc(func(n *TreeNode) (string, string) {
+			if n.Resource == nil {
+				return n.Meta, n.Value
+			}
+			meta, _ := n.Resource.GetMeta()
+			value := n.Value
+			if replicas, _ := n.Resource.Pipe(yaml.Lookup("spec", "replicas")); replicas != nil {
+				value = fmt.Sprintf("%s  replicas=%s pods=%d",
+					value, replicas.YNode().Value, len(n.Children))
+			}
+			return meta.Kind, value
+		}),
+	}.Write(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `.
+└── [StatefulSet]  StatefulSet myapp-staging/cockroachdb  replicas=2 pods=2
+    ├── [Pod]  Pod myapp-staging/cockroachdb-0
+    └── [Pod]  Pod myapp-staging/cockroachdb-1
+`, out.String())
+}
+
+func TestPrinter_Write_generators(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "creds.env"), []byte(`# credentials
+username=admin
+password=secret
+`), 0600)) {
+		t.FailNow()
+	}
+
+	in := `configMapGenerator:
+- name: config
+  literals:
+  - color="blue"
+secretGenerator:
+- name: creds
+  envs:
+  - creds.env
+- name: static
+  literals:
+  - key=value
+  options:
+    disableNameSuffixHash: true
+- name: missing
+  files:
+  - missing.txt
+namePrefix: app-
+metadata:
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: kustomization.yaml
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/package: .
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        envFrom:
+        - configMapRef:
+            name: config
+      volumes:
+      - name: creds
+        secret:
+          secretName: creds
+`
+	out := &bytes.Buffer{}
+	err = Pipeline{
+		Inputs:  []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{Root: d, Writer: out, Generators: true}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, fmt.Sprintf(`%s
+├── [deployment.yaml]  Deployment nginx
+└── [kustomization.yaml]  Kustomization 
+    ├── [generated]  ConfigMap app-config-k55bgk4dk8
+    │   └── [deployment.yaml]  Deployment nginx
+    ├── [generated]  Secret app-creds-cmc7km5mmd
+    │   └── [deployment.yaml]  Deployment nginx
+    ├── [generated]  Secret app-static
+    └── [generated]  Secret app-missing (open %s: no such file or directory)
+`, d, filepath.Join(d, "missing.txt")), out.String())
+}
+
+func TestPrinter_Write_foldDuplicates(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-b
+  uid: 2
+  resourceVersion: "20"
+spec:
+  replicas: 1
+status:
+  readyReplicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-a
+  uid: 1
+  resourceVersion: "10"
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-c
+  uid: 3
+spec:
+  replicas: 3
+---
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-5d4b
+  namespace: team-a
+  ownerReferences:
+  - kind: Deployment
+    name: web
+    uid: 1
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-5d4b
+  namespace: team-b
+  ownerReferences:
+  - kind: Deployment
+    name: web
+    uid: 2
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-5d4b-x1
+  namespace: team-a
+  ownerReferences:
+  - kind: ReplicaSet
+    name: web-5d4b
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-5d4b-y2
+  namespace: team-b
+  ownerReferences:
+  - kind: ReplicaSet
+    name: web-5d4b
+`
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{
+			Writer: out, Structure: TreeStructureGraph, FoldDuplicates: true}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `.
+├── [Resource]  Deployment web (2 namespaces)
+│   └── [Resource]  ReplicaSet web-5d4b (2 namespaces)
+│       ├── [Resource]  Pod team-a/web-5d4b-x1
+│       └── [Resource]  Pod team-b/web-5d4b-y2
+└── [Resource]  Deployment team-c/web
+`, out.String()) {
+		t.FailNow()
+	}
+
+	// print the namespaces of the folded Resources
+	in = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: team-b
+  annotations:
+    config.kubernetes.io/package: apps
+    config.kubernetes.io/path: apps/team-b.yaml
+data:
+  PORT: "8080"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: team-a
+  annotations:
+    config.kubernetes.io/package: apps
+    config.kubernetes.io/path: apps/team-a.yaml
+data:
+  PORT: "8080"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: team-c
+  annotations:
+    config.kubernetes.io/package: apps
+    config.kubernetes.io/path: apps/team-c.yaml
+data:
+  PORT: "9090"
+`
+	out.Reset()
+	err = Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{Writer: out, Root: ".",
+			FoldDuplicates: true, ExpandFolded: true,
+			Fields: []TreeWriterField{{Name: "data.PORT",
+				PathMatcher: yaml.PathMatcher{Path: []string{"data", "PORT"}}}}}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `.
+└── apps
+    ├── [team-a.yaml]  ConfigMap web (2 namespaces)
+    │   ├── data.PORT: "8080"
+    │   ├── [Namespace]  team-a
+    │   └── [Namespace]  team-b
+    └── [team-c.yaml]  ConfigMap team-c/web
+        └── data.PORT: "9090"
+`, out.String()) {
+		t.FailNow()
+	}
+}
+
+func TestPrinter_Write_groupByCRD(t *testing.T) {
+	in := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+  annotations:
+    config.kubernetes.io/package: crds
+    config.kubernetes.io/path: crds/widgets.yaml
+spec:
+  group: example.com
+  names:
+    kind: Widget
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+  annotations:
+    config.kubernetes.io/package: crds
+    config.kubernetes.io/path: crds/gadgets.yaml
+spec:
+  group: example.com
+  names:
+    kind: Gadget
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: a
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: crds
+    config.kubernetes.io/path: crds/widget-a.yaml
+---
+apiVersion: example.com/v1beta1
+kind: Widget
+metadata:
+  name: b
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: app
+    config.kubernetes.io/path: app/widget-b.yaml
+---
+apiVersion: other.com/v1
+kind: Widget
+metadata:
+  name: c
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: app
+    config.kubernetes.io/path: app/widget-c.yaml
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+  namespace: default
+  annotations:
+    config.kubernetes.io/package: app
+    config.kubernetes.io/path: app/operator.yaml
+`
+	nodes, err := (&ByteReader{Reader: bytes.NewBufferString(in)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// instances are printed beneath their definition, even from other packages
+	out := &bytes.Buffer{}
+	err = TreeWriter{Writer: out, GroupBy: TreeGroupByCRD}.Write(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `
+├── app
+│   ├── [operator.yaml]  Deployment default/operator
+│   └── [widget-c.yaml]  Widget default/c
+└── crds
+    ├── [gadgets.yaml]  CustomResourceDefinition gadgets.example.com
+    │   └── [Instances]  0
+    └── [widgets.yaml]  CustomResourceDefinition widgets.example.com
+        ├── [Instances]  2
+        ├── [widget-a.yaml]  Widget default/a
+        └── [app/widget-b.yaml]  Widget default/b
+`, out.String())
+
+	// instances without owners are printed beneath their definition
+	out = &bytes.Buffer{}
+	err = TreeWriter{Writer: out, Structure: TreeStructureGraph, GroupBy: TreeGroupByCRD}.
+		Write(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `.
+├── [Resource]  CustomResourceDefinition gadgets.example.com
+│   └── [Instances]  0
+├── [Resource]  Deployment default/operator
+├── [Resource]  Widget default/c
+└── [Resource]  CustomResourceDefinition widgets.example.com
+    ├── [Instances]  2
+    ├── [Resource]  Widget default/a
+    └── [Resource]  Widget default/b
+`, out.String())
+
+	err = TreeWriter{Writer: out, GroupBy: "kind"}.Write(nodes)
+	assert.EqualError(t, err, `unknown tree grouping "kind"`)
+}
+
+func TestPrinter_Write_ageAndRestarts(t *testing.T) {
+	in := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+  creationTimestamp: "2019-10-23T09:07:19Z"
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: foo-1
+  namespace: default
+  creationTimestamp: "2019-10-26T09:07:19Z"
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: Deployment
+    name: foo
+status:
+  initContainerStatuses:
+  - name: init
+    restartCount: 0
+  containerStatuses:
+  - name: web
+    restartCount: 4
+  - name: sidecar
+    restartCount: 1
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: foo-2
+  namespace: default
+  creationTimestamp: "2019-10-26T11:04:09Z"
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: Deployment
+    name: foo
+status:
+  containerStatuses:
+  - name: web
+    restartCount: 0
+`
+	out := &bytes.Buffer{}
+	err := Pipeline{
+		Inputs: []Reader{&ByteReader{Reader: bytes.NewBufferString(in)}},
+		Outputs: []Writer{TreeWriter{
+			Writer: out, Structure: TreeStructureGraph, Age: true, Restarts: true,
+			Now: time.Date(2019, 10, 26, 11, 7, 19, 0, time.UTC)}},
+	}.Execute()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, `.
+└── [Resource]  Deployment default/foo
+    ├── age: 3d2h
+    ├── [Resource]  Pod default/foo-1
+    │   ├── age: 120m
+    │   └── restarts: 5 (web: 4, sidecar: 1)
+    └── [Resource]  Pod default/foo-2
+        ├── age: 3m10s
+        └── restarts: 0
+`, out.String()) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,400 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package openapi contains libraries for validating Resources against OpenAPI schemas.
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Schema is the subset of an OpenAPI v3 schema used to validate Resources.
+type Schema struct {
+	// Type is the type of the value -- may be object, array, string, integer, number,
+	// boolean, or empty for any type.
+	Type string `yaml:"type,omitempty"`
+
+	// Description documents the value.
+	Description string `yaml:"description,omitempty"`
+
+	// Properties are the known fields of an object.
+	Properties map[string]*Schema `yaml:"properties,omitempty"`
+
+	// AdditionalProperties is the schema of the values of an object with arbitrary keys.
+	AdditionalProperties *Schema `yaml:"additionalProperties,omitempty"`
+
+	// Items is the schema of the elements of an array.
+	Items *Schema `yaml:"items,omitempty"`
+
+	// PreserveUnknownFields disables unknown field checking for the object.
+	PreserveUnknownFields bool `yaml:"x-kubernetes-preserve-unknown-fields,omitempty"`
+
+	// IntOrString allows the value to be either an integer or a string.
+	IntOrString bool `yaml:"x-kubernetes-int-or-string,omitempty"`
+
+	// Scope is the scope of the Resources of the kind -- ScopeNamespaced or ScopeCluster.
+	// Only set on the schemas of kinds, and empty if unknown.
+	Scope string `yaml:"-"`
+}
+
+const (
+	// ScopeNamespaced is the Scope of kinds whose Resources are in namespaces
+	ScopeNamespaced = "Namespaced"
+
+	// ScopeCluster is the Scope of kinds whose Resources are not in namespaces
+	ScopeCluster = "Cluster"
+)
+
+// UnmarshalYAML unmarshals a Schema, accepting boolean schemas -- e.g.
+// additionalProperties: true
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" {
+		// a true schema accepts any value
+		*s = Schema{PreserveUnknownFields: node.Value == "true"}
+		return nil
+	}
+	type schema Schema
+	return node.Decode((*schema)(s))
+}
+
+// Field returns the Schema of the field at path.  The fields of array elements are
+// looked up in the element Schema -- e.g. spec.containers.image.
+func (s *Schema) Field(path ...string) (*Schema, error) {
+	for i, field := range path {
+		elem := s
+		for elem.Items != nil {
+			elem = elem.Items
+		}
+		next, found := elem.Properties[field]
+		if !found {
+			return nil, fmt.Errorf("field %s does not exist", strings.Join(path[:i+1], "."))
+		}
+		s = next
+	}
+	return s, nil
+}
+
+// TypeName returns the name of the type of the value -- e.g. Object, []Object, string
+// or map[string]string.
+func (s *Schema) TypeName() string {
+	switch {
+	case s.IntOrString:
+		return "string"
+	case s.Items != nil:
+		return "[]" + s.Items.TypeName()
+	case s.AdditionalProperties != nil && s.Properties == nil &&
+		!s.AdditionalProperties.PreserveUnknownFields:
+		return "map[string]" + s.AdditionalProperties.TypeName()
+	case s.Type == "object" || s.Properties != nil:
+		return "Object"
+	case s.Type == "":
+		return "any"
+	}
+	return s.Type
+}
+
+// GroupVersionKind identifies the Resources a Schema applies to.
+type GroupVersionKind struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+func (gvk GroupVersionKind) String() string {
+	if gvk.Group == "" {
+		return fmt.Sprintf("%s/%s", gvk.Version, gvk.Kind)
+	}
+	return fmt.Sprintf("%s/%s/%s", gvk.Group, gvk.Version, gvk.Kind)
+}
+
+// ApiVersion returns the apiVersion of the Resources -- e.g. apps/v1 or v1.
+func (gvk GroupVersionKind) ApiVersion() string {
+	if gvk.Group == "" {
+		return gvk.Version
+	}
+	return gvk.Group + "/" + gvk.Version
+}
+
+// Schemas indexes the Schemas of Resources by their group, version and kind.
+type Schemas map[GroupVersionKind]*Schema
+
+// Lookup returns the Schema for the Resource, or nil if there is no known Schema.
+func (s Schemas) Lookup(meta yaml.ResourceMeta) *Schema {
+	gvk := GroupVersionKind{Kind: meta.Kind}
+	if i := strings.Index(meta.ApiVersion, "/"); i >= 0 {
+		gvk.Group, gvk.Version = meta.ApiVersion[:i], meta.ApiVersion[i+1:]
+	} else {
+		gvk.Version = meta.ApiVersion
+	}
+	return s[gvk]
+}
+
+// IsNamespaced returns false if the Resource kind is cluster scoped.  If the apiVersion of
+// the Resource has no Schema, the Schemas of the other versions of its group and kind are
+// used.  Kinds with an unknown scope are namespaced.
+func (s Schemas) IsNamespaced(meta yaml.ResourceMeta) bool {
+	if schema := s.Lookup(meta); schema != nil && schema.Scope != "" {
+		return schema.Scope != ScopeCluster
+	}
+	group := ""
+	if i := strings.Index(meta.ApiVersion, "/"); i >= 0 {
+		group = meta.ApiVersion[:i]
+	}
+	for gvk, schema := range s {
+		if gvk.Group == group && gvk.Kind == meta.Kind && schema.Scope != "" {
+			return schema.Scope != ScopeCluster
+		}
+	}
+	return true
+}
+
+// Find returns the GroupVersionKinds of the Schemas for kind, which is matched case
+// insensitively and may be plural -- e.g. deployment, Deployment or deployments.  If
+// apiVersion is set only its GroupVersionKinds are returned.  The GroupVersionKinds are
+// sorted with the most stable versions first.
+func (s Schemas) Find(kind, apiVersion string) []GroupVersionKind {
+	kind = strings.ToLower(kind)
+	var gvks []GroupVersionKind
+	for gvk := range s {
+		k := strings.ToLower(gvk.Kind)
+		if kind != k && kind != k+"s" && kind != k+"es" &&
+			!(strings.HasSuffix(k, "y") && kind == strings.TrimSuffix(k, "y")+"ies") {
+			continue
+		}
+		if apiVersion != "" && apiVersion != gvk.ApiVersion() {
+			continue
+		}
+		gvks = append(gvks, gvk)
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		pi, pj := versionPriority(gvks[i].Version), versionPriority(gvks[j].Version)
+		for k := range pi {
+			if pi[k] != pj[k] {
+				return pi[k] > pj[k]
+			}
+		}
+		return gvks[i].String() < gvks[j].String()
+	})
+	return gvks
+}
+
+// kubeVersion matches Kubernetes API versions -- e.g. v1, v2beta1 or v1alpha1
+var kubeVersion = regexp.MustCompile(`^v([0-9]+)(?:(alpha|beta)([0-9]+))?$`)
+
+// versionPriority returns the priority of the API version as (stability, major, minor), so
+// that GA versions sort before beta versions, which sort before alpha versions.
+func versionPriority(version string) [3]int {
+	m := kubeVersion.FindStringSubmatch(version)
+	if m == nil {
+		return [3]int{}
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[3])
+	switch m[2] {
+	case "alpha":
+		return [3]int{1, major, minor}
+	case "beta":
+		return [3]int{2, major, minor}
+	}
+	return [3]int{3, major, minor}
+}
+
+// AddCRDs adds the Schemas from the CustomResourceDefinitions in crds.  Both the
+// spec.validation and spec.versions[].schema forms are supported.
+func (s Schemas) AddCRDs(crds ...*yaml.RNode) error {
+	for i := range crds {
+		meta, err := crds[i].GetMeta()
+		if err != nil {
+			return err
+		}
+		if meta.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		var crd struct {
+			Spec struct {
+				Group   string `yaml:"group"`
+				Version string `yaml:"version"`
+				Scope   string `yaml:"scope"`
+				Names   struct {
+					Kind string `yaml:"kind"`
+				} `yaml:"names"`
+				Validation *struct {
+					OpenAPIV3Schema *Schema `yaml:"openAPIV3Schema"`
+				} `yaml:"validation"`
+				Versions []struct {
+					Name   string `yaml:"name"`
+					Schema *struct {
+						OpenAPIV3Schema *Schema `yaml:"openAPIV3Schema"`
+					} `yaml:"schema"`
+				} `yaml:"versions"`
+			} `yaml:"spec"`
+		}
+		if err := crds[i].YNode().Decode(&crd); err != nil {
+			return fmt.Errorf("unable to parse CustomResourceDefinition %s: %v", meta.Name, err)
+		}
+
+		versions := []string{crd.Spec.Version}
+		for _, v := range crd.Spec.Versions {
+			versions = append(versions, v.Name)
+		}
+		for j, v := range versions {
+			if v == "" {
+				continue
+			}
+			gvk := GroupVersionKind{Group: crd.Spec.Group, Version: v, Kind: crd.Spec.Names.Kind}
+			// version specific schemas take precedence over the top level schema
+			if j > 0 && crd.Spec.Versions[j-1].Schema != nil &&
+				crd.Spec.Versions[j-1].Schema.OpenAPIV3Schema != nil {
+				s[gvk] = crd.Spec.Versions[j-1].Schema.OpenAPIV3Schema
+			} else if crd.Spec.Validation != nil && crd.Spec.Validation.OpenAPIV3Schema != nil {
+				s[gvk] = crd.Spec.Validation.OpenAPIV3Schema
+			} else if crd.Spec.Scope != "" {
+				// keep the scope of kinds without a schema
+				s[gvk] = &Schema{PreserveUnknownFields: true}
+			}
+			if s[gvk] != nil {
+				s[gvk].Scope = crd.Spec.Scope
+			}
+		}
+	}
+	return nil
+}
+
+// ValidationError is a field of a Resource which does not match its Schema.
+type ValidationError struct {
+	// Path is the path to the field.
+	Path string
+	// Line is the line of the field.
+	Line int
+	// Column is the column of the field.
+	Column int
+	// Message describes the error.
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// metadataFields are validated for all Resources, since CRD schemas do not include them
+var metadataFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true}
+
+// Validate validates the Resource against the Schema and returns all errors found.
+// Unknown fields and mismatched types are reported.
+func Validate(rn *yaml.RNode, s *Schema) []ValidationError {
+	v := &validator{}
+	v.validate(rn.YNode(), s, nil, true)
+	return v.errors
+}
+
+type validator struct {
+	errors []ValidationError
+}
+
+func (v *validator) error(node *yaml.Node, path []string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{
+		Path:    "." + strings.Join(path, "."),
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate(node *yaml.Node, s *Schema, path []string, root bool) {
+	if s == nil || node == nil {
+		return
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == yaml.NullNodeTag {
+		// null values are always allowed
+		return
+	}
+
+	if !v.validateType(node, s) {
+		v.error(node, path, "expected %s, found %s", s.Type, nodeType(node))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			p := append(append([]string{}, path...), key.Value)
+			if prop, found := s.Properties[key.Value]; found {
+				v.validate(value, prop, p, false)
+				continue
+			}
+			if s.AdditionalProperties != nil {
+				v.validate(value, s.AdditionalProperties, p, false)
+				continue
+			}
+			if s.Properties != nil && !s.PreserveUnknownFields &&
+				!(root && metadataFields[key.Value]) {
+				v.error(key, p, "unknown field")
+			}
+		}
+	case yaml.SequenceNode:
+		for i := range node.Content {
+			p := append([]string{}, path...)
+			if len(p) > 0 {
+				p[len(p)-1] = fmt.Sprintf("%s[%d]", p[len(p)-1], i)
+			} else {
+				p = append(p, fmt.Sprintf("[%d]", i))
+			}
+			v.validate(node.Content[i], s.Items, p, false)
+		}
+	}
+}
+
+// validateType returns true if the node matches the Schema type
+func (v *validator) validateType(node *yaml.Node, s *Schema) bool {
+	t := nodeType(node)
+	if s.IntOrString {
+		return t == "integer" || t == "string"
+	}
+	switch s.Type {
+	case "":
+		return true
+	case "number":
+		return t == "number" || t == "integer"
+	default:
+		return s.Type == t
+	}
+}
+
+// nodeType returns the OpenAPI type of the node
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	}
+	return "string"
+}
+
+// SortErrors sorts errors by their location.
+func SortErrors(errors []ValidationError) {
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Line != errors[j].Line {
+			return errors[i].Line < errors[j].Line
+		}
+		return errors[i].Column < errors[j].Column
+	})
+}
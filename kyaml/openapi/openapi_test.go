@@ -0,0 +1,213 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const crd = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            replicas:
+              type: integer
+              description: Number of widgets.
+            port:
+              x-kubernetes-int-or-string: true
+            tags:
+              type: array
+              items:
+                type: string
+            labels:
+              type: object
+              additionalProperties:
+                type: string
+            config:
+              type: object
+              x-kubernetes-preserve-unknown-fields: true
+            extra:
+              type: object
+              additionalProperties: true
+  versions:
+  - name: v1
+  - name: v2
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+`
+
+func getSchemas(t *testing.T) openapi.Schemas {
+	s := openapi.Schemas{}
+	if !assert.NoError(t, s.AddCRDs(yaml.MustParse(crd))) {
+		t.FailNow()
+	}
+	return s
+}
+
+func TestSchemas_AddCRDs(t *testing.T) {
+	s := getSchemas(t)
+	assert.Len(t, s, 2)
+	v1 := s[openapi.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}]
+	if assert.NotNil(t, v1) {
+		assert.Contains(t, v1.Properties["spec"].Properties, "replicas")
+	}
+	v2 := s[openapi.GroupVersionKind{Group: "example.com", Version: "v2", Kind: "Widget"}]
+	if assert.NotNil(t, v2) {
+		assert.Empty(t, v2.Properties["spec"].Properties)
+	}
+}
+
+func TestSchemas_IsNamespaced(t *testing.T) {
+	s := getSchemas(t)
+	assert.NoError(t, s.AddCRDs(yaml.MustParse(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  scope: Cluster
+  names:
+    kind: Gadget
+  versions:
+  - name: v1
+`)))
+	meta := func(apiVersion, kind string) yaml.ResourceMeta {
+		return yaml.ResourceMeta{ApiVersion: apiVersion, Kind: kind}
+	}
+
+	assert.Equal(t, openapi.ScopeCluster,
+		s[openapi.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"}].Scope)
+	assert.False(t, s.IsNamespaced(meta("example.com/v1", "Gadget")))
+	// other versions use the scope of the kind
+	assert.False(t, s.IsNamespaced(meta("example.com/v2", "Gadget")))
+	// the scope of Widget is unknown
+	assert.True(t, s.IsNamespaced(meta("example.com/v1", "Widget")))
+	assert.True(t, s.IsNamespaced(meta("v1", "ConfigMap")))
+}
+
+func TestSchemas_Find(t *testing.T) {
+	s := getSchemas(t)
+	s[openapi.GroupVersionKind{Group: "example.com", Version: "v1beta1", Kind: "Widget"}] =
+		&openapi.Schema{}
+	s[openapi.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Policy"}] =
+		&openapi.Schema{}
+
+	assert.Equal(t, []openapi.GroupVersionKind{
+		{Group: "example.com", Version: "v2", Kind: "Widget"},
+		{Group: "example.com", Version: "v1", Kind: "Widget"},
+		{Group: "example.com", Version: "v1beta1", Kind: "Widget"},
+	}, s.Find("widgets", ""))
+	assert.Equal(t, []openapi.GroupVersionKind{
+		{Group: "example.com", Version: "v1", Kind: "Widget"},
+	}, s.Find("Widget", "example.com/v1"))
+	assert.Equal(t, []openapi.GroupVersionKind{
+		{Group: "example.com", Version: "v1", Kind: "Policy"},
+	}, s.Find("policies", ""))
+	assert.Empty(t, s.Find("Gadget", ""))
+}
+
+func TestSchema_Field(t *testing.T) {
+	s := getSchemas(t)[openapi.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}]
+	f, err := s.Field("spec", "replicas")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Number of widgets.", f.Description)
+	assert.Equal(t, "integer", f.TypeName())
+
+	_, err = s.Field("spec", "size")
+	assert.EqualError(t, err, "field spec.size does not exist")
+
+	// fields of array elements are looked up in the element schema
+	a := &openapi.Schema{Type: "array", Items: &openapi.Schema{
+		Type: "object", Properties: map[string]*openapi.Schema{"name": {Type: "string"}}}}
+	f, err = a.Field("name")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "string", f.TypeName())
+	}
+}
+
+func TestSchema_TypeName(t *testing.T) {
+	spec := getSchemas(t)[openapi.GroupVersionKind{
+		Group: "example.com", Version: "v1", Kind: "Widget"}].Properties["spec"]
+	for field, expected := range map[string]string{
+		"port":   "string",
+		"tags":   "[]string",
+		"labels": "map[string]string",
+		"config": "Object",
+		"extra":  "Object",
+	} {
+		assert.Equal(t, expected, spec.Properties[field].TypeName(), field)
+	}
+	assert.Equal(t, "Object", spec.TypeName())
+	assert.Equal(t, "any", (&openapi.Schema{}).TypeName())
+}
+
+func TestValidate(t *testing.T) {
+	s := getSchemas(t)
+	rn := yaml.MustParse(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: foo
+spec:
+  replicas: three
+  port: http
+  tags:
+  - a
+  - 1
+  labels:
+    app: foo
+  config:
+    anything: goes
+  extra:
+    a:
+      b: c
+  unknown: field
+`)
+	schema := s.Lookup(yaml.ResourceMeta{ApiVersion: "example.com/v1", Kind: "Widget"})
+	if !assert.NotNil(t, schema) {
+		t.FailNow()
+	}
+	errs := openapi.Validate(rn, schema)
+	openapi.SortErrors(errs)
+	assert.Equal(t, []openapi.ValidationError{
+		{Path: ".spec.replicas", Line: 6, Column: 13, Message: "expected integer, found string"},
+		{Path: ".spec.tags[1]", Line: 10, Column: 5, Message: "expected string, found integer"},
+		{Path: ".spec.unknown", Line: 18, Column: 3, Message: "unknown field"},
+	}, errs)
+}
+
+func TestValidate_valid(t *testing.T) {
+	s := getSchemas(t)
+	rn := yaml.MustParse(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: foo
+spec:
+  replicas: 3
+  port: 8080
+  labels: null
+`)
+	schema := s.Lookup(yaml.ResourceMeta{ApiVersion: "example.com/v1", Kind: "Widget"})
+	assert.Empty(t, openapi.Validate(rn, schema))
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultRegistryAuthKey is the key of the credentials of defaultRegistry in the docker
+// configuration
+const defaultRegistryAuthKey = "https://index.docker.io/v1/"
+
+// DockerConfig is the registry credentials configuration of the docker CLI --
+// e.g. written by docker login to ~/.docker/config.json.
+type DockerConfig struct {
+	// Auths are the credentials of the registries, by registry
+	Auths map[string]DockerAuth `json:"auths,omitempty"`
+
+	// CredHelpers are the credential helpers of the registries, by registry -- e.g.
+	// gcloud runs docker-credential-gcloud
+	CredHelpers map[string]string `json:"credHelpers,omitempty"`
+
+	// CredsStore is the credential helper of the registries without Auths or CredHelpers
+	CredsStore string `json:"credsStore,omitempty"`
+}
+
+// DockerAuth are the credentials of a registry
+type DockerAuth struct {
+	// Auth is the base64 encoded username:password
+	Auth string `json:"auth,omitempty"`
+
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// DefaultDockerConfigPath returns the path of the docker configuration --
+// $DOCKER_CONFIG/config.json, or ~/.docker/config.json.
+func DefaultDockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// LoadDockerConfig reads the docker configuration at path.  The configuration is empty
+// if the file doesn't exist, so that public images may be resolved anonymously.
+func LoadDockerConfig(path string) (*DockerConfig, error) {
+	c := &DockerConfig{}
+	if path == "" {
+		return c, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid docker config %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Credentials returns the username and password of the registry, or empty strings if
+// the configuration has no credentials for it.
+func (c *DockerConfig) Credentials(registry string) (string, string, error) {
+	if c == nil {
+		return "", "", nil
+	}
+	keys := []string{registry}
+	if registry == defaultRegistry {
+		keys = append(keys, defaultRegistryAuthKey, "index.docker.io")
+	}
+	for _, key := range keys {
+		if helper, found := c.CredHelpers[key]; found {
+			return runCredentialHelper(helper, key)
+		}
+	}
+
+	// the registries may be written as URLs -- e.g. https://gcr.io
+	for k, auth := range c.Auths {
+		if k != defaultRegistryAuthKey {
+			k = strings.TrimPrefix(strings.TrimPrefix(k, "https://"), "http://")
+			k = strings.SplitN(k, "/", 2)[0]
+		}
+		for _, key := range keys {
+			if k != key {
+				continue
+			}
+			if auth.Auth == "" {
+				return auth.Username, auth.Password, nil
+			}
+			b, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return "", "", fmt.Errorf("invalid auth of %s: %v", registry, err)
+			}
+			parts := strings.SplitN(string(b), ":", 2)
+			if len(parts) != 2 {
+				return "", "", fmt.Errorf("invalid auth of %s: must be username:password", registry)
+			}
+			return parts[0], parts[1], nil
+		}
+	}
+
+	if c.CredsStore != "" {
+		if registry == defaultRegistry {
+			registry = defaultRegistryAuthKey
+		}
+		return runCredentialHelper(c.CredsStore, registry)
+	}
+	return "", "", nil
+}
+
+// runCredentialHelper gets the credentials of the registry from the docker credential
+// helper -- the docker-credential-NAME command on the PATH.
+func runCredentialHelper(name, registry string) (string, string, error) {
+	cmd := exec.Command("docker-credential-"+name, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		// the helpers fail with this message if they have no credentials for the registry
+		if strings.Contains(stdout.String()+stderr.String(), "credentials not found") {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("docker-credential-%s: %v: %s",
+			name, err, strings.TrimSpace(stderr.String()))
+	}
+	var creds struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return "", "", fmt.Errorf("docker-credential-%s: invalid output: %v", name, err)
+	}
+	return creds.Username, creds.Secret, nil
+}
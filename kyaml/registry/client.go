@@ -0,0 +1,399 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRegistryHost is the host serving the API of defaultRegistry
+	defaultRegistryHost = "registry-1.docker.io"
+
+	// defaultTimeout is the timeout of the requests to the registries if HTTP is not set
+	defaultTimeout = 30 * time.Second
+
+	// DefaultMaxManifestBytes is the maximum size of the manifests read if
+	// MaxManifestBytes is not set -- the limit of the manifests registries accept
+	DefaultMaxManifestBytes = 4 << 20
+
+	// DefaultMaxBlobBytes is the maximum size of the blobs read if MaxBlobBytes is not set
+	DefaultMaxBlobBytes = 256 << 20
+)
+
+// manifestMediaTypes are the media types of the manifests accepted when resolving
+// digests.  The manifest lists and indexes are preferred, so that the digests of
+// multi-platform images are the digests of the images for all the platforms.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// Client sends requests to the API of registries.
+//
+// The registries are authenticated with the credentials of Auth, using the token or
+// basic authentication scheme they challenge the requests with.  Requests are sent
+// without credentials until a registry challenges them -- e.g. for public images -- and
+// the credentials answering a challenge are reused for the following requests to the
+// same repository.
+type Client struct {
+	// HTTP sends the requests.  Defaults to a client with a 30 second timeout.
+	HTTP *http.Client
+
+	// Auth contains the credentials of the registries.  Defaults to no credentials.
+	Auth *DockerConfig
+
+	// MaxManifestBytes and MaxBlobBytes are the maximum sizes of the manifests and blobs
+	// read, so that a registry can't exhaust the memory of the client.  Default to
+	// DefaultMaxManifestBytes and DefaultMaxBlobBytes.
+	MaxManifestBytes int64
+	MaxBlobBytes     int64
+
+	// authorizations are the Authorization headers answering the challenges of the
+	// registries, by repository and actions
+	authorizations map[string]string
+}
+
+// ManifestDigest returns the digest of the manifest of the reference
+func (c *Client) ManifestDigest(ref Reference) (string, error) {
+	header := http.Header{"Accept": {strings.Join(manifestMediaTypes, ", ")}}
+
+	// the digest is usually returned in a header, so the manifest is only read if it
+	// isn't
+	resp, err := c.do(ref, "pull", http.MethodHead, c.url(ref, "manifests/"+ref.manifest()), header, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	b, _, err := c.GetManifest(ref, manifestMediaTypes...)
+	if err != nil {
+		return "", err
+	}
+	return Digest(b), nil
+}
+
+// GetManifest returns the manifest of the reference and its media type.  mediaTypes are
+// the media types accepted.  The manifest is verified against the digest of the
+// reference, if it is referenced by digest.
+func (c *Client) GetManifest(ref Reference, mediaTypes ...string) ([]byte, string, error) {
+	header := http.Header{"Accept": {strings.Join(mediaTypes, ", ")}}
+	resp, err := c.do(ref, "pull", http.MethodGet, c.url(ref, "manifests/"+ref.manifest()), header, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, "", err
+	}
+	max := c.MaxManifestBytes
+	if max <= 0 {
+		max = DefaultMaxManifestBytes
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(b)) > max {
+		return nil, "", fmt.Errorf("manifest exceeds the maximum size of %d bytes", max)
+	}
+	if ref.Digest != "" {
+		if err := verifyDigest(ref.Digest, b); err != nil {
+			return nil, "", err
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return b, mediaType, nil
+}
+
+// PutManifest pushes the manifest to the tag of the reference, and returns its digest
+func (c *Client) PutManifest(ref Reference, mediaType string, manifest []byte) (string, error) {
+	header := http.Header{"Content-Type": {mediaType}}
+	resp, err := c.do(ref, "pull,push", http.MethodPut, c.url(ref, "manifests/"+ref.manifest()), header, manifest)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp, http.StatusCreated); err != nil {
+		return "", err
+	}
+	return Digest(manifest), nil
+}
+
+// GetBlob returns the blob of the repository of the reference with the digest and size
+// -- e.g. of a layer of a manifest.  The blob is verified against the digest and size.
+func (c *Client) GetBlob(ref Reference, digest string, size int64) ([]byte, error) {
+	max := c.MaxBlobBytes
+	if max <= 0 {
+		max = DefaultMaxBlobBytes
+	}
+	if size < 0 || size > max {
+		return nil, fmt.Errorf("blob size %d exceeds the maximum size of %d bytes", size, max)
+	}
+	resp, err := c.do(ref, "pull", http.MethodGet, c.url(ref, "blobs/"+digest), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	// read one more byte than the size, so that larger blobs are rejected
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) != size {
+		return nil, fmt.Errorf("blob %s doesn't match its size of %d bytes", digest, size)
+	}
+	if err := verifyDigest(digest, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// PushBlob pushes the blob to the repository of the reference, unless the repository
+// already contains it, and returns its digest
+func (c *Client) PushBlob(ref Reference, blob []byte) (string, error) {
+	digest := Digest(blob)
+	resp, err := c.do(ref, "pull,push", http.MethodHead, c.url(ref, "blobs/"+digest), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return digest, nil
+	}
+
+	// a monolithic upload -- the upload is started, and the blob is sent at once
+	resp, err = c.do(ref, "pull,push", http.MethodPost, c.url(ref, "blobs/uploads/"), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp, http.StatusAccepted); err != nil {
+		return "", err
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.Header.Get("Location") == "" {
+		return "", fmt.Errorf("invalid upload location %q", resp.Header.Get("Location"))
+	}
+	q := location.Query()
+	q.Set("digest", digest)
+	location.RawQuery = q.Encode()
+
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err = c.do(ref, "pull,push", http.MethodPut, location.String(), header, blob)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp, http.StatusCreated); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// Digest returns the sha256 digest of b -- e.g. sha256:...
+func Digest(b []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+}
+
+// verifyDigest returns an error if b doesn't have the digest
+func verifyDigest(digest string, b []byte) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("unsupported digest algorithm %s", digest)
+	}
+	if actual := Digest(b); actual != digest {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", digest, actual)
+	}
+	return nil
+}
+
+// checkStatus returns an error if the response doesn't have the status
+func checkStatus(resp *http.Response, status int) error {
+	if resp.StatusCode != status {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// url returns the URL of the path of the API of the repository of the reference
+func (c *Client) url(ref Reference, path string) string {
+	host := ref.Registry
+	if host == defaultRegistry {
+		host = defaultRegistryHost
+	}
+	return fmt.Sprintf("https://%s/v2/%s/%s", host, ref.Repository, path)
+}
+
+// do sends a request for the actions on the repository of the reference, authenticating
+// it if the registry challenges it
+func (c *Client) do(ref Reference, actions, method, u string, header http.Header, body []byte) (*http.Response, error) {
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	send := func(authorization string) (*http.Response, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, u, r)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return client.Do(req)
+	}
+
+	key := ref.Registry + "/" + ref.Repository + ":" + actions
+	resp, err := send(c.authorizations[key])
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+	authorization, err := c.authorize(client, ref, actions, resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+	if c.authorizations == nil {
+		c.authorizations = map[string]string{}
+	}
+	c.authorizations[key] = authorization
+	return send(authorization)
+}
+
+// authorize returns the Authorization header answering the challenge of the registry
+func (c *Client) authorize(client *http.Client, ref Reference, actions, challenge string) (string, error) {
+	username, password, err := c.Auth.Credentials(ref.Registry)
+	if err != nil {
+		return "", err
+	}
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" {
+			return "", fmt.Errorf("no credentials for %s", ref.Registry)
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		if params["scope"] == "" {
+			params["scope"] = "repository:" + ref.Repository + ":" + actions
+		}
+		token, err := requestToken(client, params, username, password)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+// requestToken requests a token for the scope of a bearer challenge from its token
+// service
+func requestToken(client *http.Client, params map[string]string, username, password string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	q := realm.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", params["scope"])
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected token response status %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response: %v", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", fmt.Errorf("token response contains no token")
+}
+
+// parseChallenge parses the scheme and parameters of a WWW-Authenticate header -- e.g.
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	challenge = strings.TrimSpace(challenge)
+	i := strings.Index(challenge, " ")
+	if i < 0 {
+		return challenge, params
+	}
+	scheme, rest := challenge[:i], challenge[i+1:]
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			// quoted values may contain commas -- e.g. scopes with several actions
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.Index(rest, ","); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package registry reads and writes manifests and blobs of container registries using
+// the Docker Registry HTTP API V2, which is also served by OCI registries -- e.g. to
+// resolve image tags to their digests.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRegistry is the registry of images which don't specify one
+	defaultRegistry = "docker.io"
+
+	// DefaultCacheMaxAge is the default age after which cached digests are resolved again
+	DefaultCacheMaxAge = 24 * time.Hour
+)
+
+// Reference is a reference to a manifest of a repository, by tag or digest
+type Reference struct {
+	// Registry is the registry host -- e.g. docker.io, gcr.io or localhost:5000
+	Registry string
+
+	// Repository is the repository within the registry -- e.g. library/nginx
+	Repository string
+
+	// Tag is the tag of the manifest, if it is referenced by tag
+	Tag string
+
+	// Digest is the digest of the manifest, if it is referenced by digest
+	Digest string
+}
+
+// ParseReference parses an image reference, e.g. nginx:1.19, gcr.io/project/app:v1 or
+// gcr.io/project/app@sha256:...  The tag is latest if neither a tag nor a digest is set.
+// Images on docker.io without an organization are in the library organization -- e.g.
+// nginx is docker.io/library/nginx:latest.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{Registry: defaultRegistry, Repository: image}
+	if i := strings.Index(ref.Repository, "@"); i >= 0 {
+		ref.Repository, ref.Digest = ref.Repository[:i], ref.Repository[i+1:]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, fmt.Errorf("invalid image %s: invalid digest", image)
+		}
+	}
+	// the registry host may contain a port
+	if i := strings.LastIndex(ref.Repository, ":"); i > strings.LastIndex(ref.Repository, "/") {
+		ref.Repository, ref.Tag = ref.Repository[:i], ref.Repository[i+1:]
+		if ref.Tag == "" {
+			return Reference{}, fmt.Errorf("invalid image %s", image)
+		}
+	} else if ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	if i := strings.Index(ref.Repository, "/"); i >= 0 {
+		host := ref.Repository[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, ref.Repository = host, ref.Repository[i+1:]
+		}
+	}
+	if ref.Registry == defaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Repository == "" {
+		return Reference{}, fmt.Errorf("invalid image %s", image)
+	}
+	return ref, nil
+}
+
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// manifest returns the digest of the manifest, or its tag if it is referenced by tag
+func (r Reference) manifest() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// Resolver resolves image tags to the digests of their manifests.
+//
+// The digests are cached for the lifetime of the Resolver, and in CacheFile if it is
+// set, so that each tag is resolved once.
+type Resolver struct {
+	// Client sends the requests to the registries.  Defaults to an anonymous Client.
+	Client *Client
+
+	// CacheFile, if set, is a file where the resolved digests are cached across Resolvers
+	// by SaveCache.
+	CacheFile string
+
+	// CacheMaxAge is the age after which the digests of CacheFile are resolved again.
+	// Defaults to DefaultCacheMaxAge.
+	CacheMaxAge time.Duration
+
+	// cache are the digests resolved or loaded from CacheFile, by reference
+	cache map[string]cacheEntry
+}
+
+// cacheEntry is a digest cached in CacheFile
+type cacheEntry struct {
+	Digest   string    `json:"digest"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// Resolve returns the digest of the image tag -- e.g. sha256:...
+func (r *Resolver) Resolve(image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return "", fmt.Errorf("image %s is already pinned to a digest", image)
+	}
+	if err := r.loadCache(); err != nil {
+		return "", err
+	}
+	maxAge := r.CacheMaxAge
+	if maxAge == 0 {
+		maxAge = DefaultCacheMaxAge
+	}
+	if e, found := r.cache[ref.String()]; found && time.Since(e.Resolved) < maxAge {
+		return e.Digest, nil
+	}
+
+	client := r.Client
+	if client == nil {
+		client = &Client{}
+	}
+	digest, err := client.ManifestDigest(ref)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %v", image, err)
+	}
+	r.cache[ref.String()] = cacheEntry{Digest: digest, Resolved: time.Now().UTC()}
+	return digest, nil
+}
+
+// loadCache reads CacheFile the first time it is called
+func (r *Resolver) loadCache() error {
+	if r.cache != nil {
+		return nil
+	}
+	r.cache = map[string]cacheEntry{}
+	if r.CacheFile == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(r.CacheFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &r.cache); err != nil {
+		return fmt.Errorf("invalid cache file %s: %v", r.CacheFile, err)
+	}
+	return nil
+}
+
+// SaveCache writes the digests resolved to CacheFile, if CacheFile is set
+func (r *Resolver) SaveCache() error {
+	if r.CacheFile == "" || r.cache == nil {
+		return nil
+	}
+	b, err := json.MarshalIndent(r.cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.CacheFile), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.CacheFile, append(b, '\n'), 0600)
+}
@@ -0,0 +1,253 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/registry"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected registry.Reference
+		err      string
+	}{
+		{image: "nginx",
+			expected: registry.Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{image: "nginx:1.19",
+			expected: registry.Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.19"}},
+		{image: "org/app:v1",
+			expected: registry.Reference{Registry: "docker.io", Repository: "org/app", Tag: "v1"}},
+		{image: "gcr.io/project/app:v1",
+			expected: registry.Reference{Registry: "gcr.io", Repository: "project/app", Tag: "v1"}},
+		{image: "localhost:5000/app",
+			expected: registry.Reference{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{image: "nginx@sha256:abcd",
+			expected: registry.Reference{Registry: "docker.io", Repository: "library/nginx", Digest: "sha256:abcd"}},
+		{image: "gcr.io/project/app:v1@sha256:abcd",
+			expected: registry.Reference{Registry: "gcr.io", Repository: "project/app", Tag: "v1", Digest: "sha256:abcd"}},
+		{image: "nginx@abcd", err: "invalid image nginx@abcd: invalid digest"},
+		{image: "nginx:", err: "invalid image nginx:"},
+	}
+	for _, test := range tests {
+		ref, err := registry.ParseReference(test.image)
+		if test.err != "" {
+			if assert.Error(t, err, test.image) {
+				assert.Equal(t, test.err, err.Error(), test.image)
+			}
+			continue
+		}
+		if assert.NoError(t, err, test.image) {
+			assert.Equal(t, test.expected, ref, test.image)
+		}
+	}
+}
+
+// fakeRegistry serves the manifests of a registry authenticating the requests with
+// tokens for user:pass, and counts the manifest requests
+func fakeRegistry(t *testing.T) (*httptest.Server, map[string]int) {
+	requests := map[string]int{}
+	var s *httptest.Server
+	s = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			user, pass, _ := r.BasicAuth()
+			if user != "user" || pass != "pass" ||
+				r.URL.Query().Get("scope") != "repository:private/app:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token": "t0ken"}`))
+			return
+		}
+		requests[r.Method+" "+r.URL.Path]++
+		assert.Contains(t, r.Header.Get("Accept"),
+			"application/vnd.docker.distribution.manifest.list.v2+json")
+		switch r.URL.Path {
+		case "/v2/private/app/manifests/v1":
+			if r.Header.Get("Authorization") != "Bearer t0ken" {
+				w.Header().Set("WWW-Authenticate",
+					fmt.Sprintf(`Bearer realm="%s/token",service="registry.test"`, s.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:1111")
+		case "/v2/basic/app/manifests/v1":
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "user" || pass != "pass" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="registry.test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:2222")
+		case "/v2/public/app/manifests/latest":
+			// the digest is only computed from the manifest
+			_, _ = w.Write([]byte("manifest"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return s, requests
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	s, requests := fakeRegistry(t)
+	defer s.Close()
+	host := strings.TrimPrefix(s.URL, "https://")
+	auth := &registry.DockerConfig{Auths: map[string]registry.DockerAuth{
+		"https://" + host: {Auth: base64.StdEncoding.EncodeToString([]byte("user:pass"))},
+	}}
+	r := &registry.Resolver{Client: &registry.Client{HTTP: s.Client(), Auth: auth}}
+
+	digest, err := r.Resolve(host + "/private/app:v1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "sha256:1111", digest)
+	}
+	digest, err = r.Resolve(host + "/basic/app:v1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "sha256:2222", digest)
+	}
+	digest, err = r.Resolve(host + "/public/app")
+	if assert.NoError(t, err) {
+		assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("manifest"))), digest)
+	}
+
+	// the digests are cached
+	digest, err = r.Resolve(host + "/private/app:v1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "sha256:1111", digest)
+	}
+	assert.Equal(t, map[string]int{
+		"HEAD /v2/private/app/manifests/v1":    2,
+		"HEAD /v2/basic/app/manifests/v1":      2,
+		"HEAD /v2/public/app/manifests/latest": 1,
+		"GET /v2/public/app/manifests/latest":  1,
+	}, requests)
+
+	_, err = r.Resolve(host + "/private/app@sha256:1111")
+	if assert.Error(t, err) {
+		assert.Equal(t, "image "+host+"/private/app@sha256:1111 is already pinned to a digest", err.Error())
+	}
+	_, err = r.Resolve(host + "/missing/app:v1")
+	if assert.Error(t, err) {
+		assert.Equal(t, "could not resolve "+host+
+			"/missing/app:v1: unexpected response status 404 Not Found", err.Error())
+	}
+
+	// the private images can't be resolved without credentials
+	_, err = (&registry.Resolver{Client: &registry.Client{HTTP: s.Client()}}).Resolve(host + "/private/app:v1")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "unexpected token response status 401 Unauthorized")
+	}
+	_, err = (&registry.Resolver{Client: &registry.Client{HTTP: s.Client()}}).Resolve(host + "/basic/app:v1")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no credentials for "+host)
+	}
+}
+
+func TestResolver_SaveCache(t *testing.T) {
+	s, requests := fakeRegistry(t)
+	defer s.Close()
+	host := strings.TrimPrefix(s.URL, "https://")
+	d, err := ioutil.TempDir("", "kyaml-registry-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+	cache := filepath.Join(d, "cache", "digests.json")
+
+	r := &registry.Resolver{Client: &registry.Client{HTTP: s.Client()}, CacheFile: cache}
+	_, err = r.Resolve(host + "/public/app")
+	if !assert.NoError(t, err) || !assert.NoError(t, r.SaveCache()) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, len(requests))
+
+	// the cached digests are read by other Resolvers
+	r = &registry.Resolver{Client: &registry.Client{HTTP: s.Client()}, CacheFile: cache}
+	digest, err := r.Resolve(host + "/public/app:latest")
+	if assert.NoError(t, err) {
+		assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("manifest"))), digest)
+	}
+	assert.Equal(t, 2, len(requests))
+
+	// the digests are resolved again once they are older than CacheMaxAge
+	b, err := json.Marshal(map[string]interface{}{
+		host + "/public/app:latest": map[string]interface{}{
+			"digest": "sha256:old", "resolved": time.Now().Add(-2 * time.Hour)},
+	})
+	if !assert.NoError(t, err) || !assert.NoError(t, ioutil.WriteFile(cache, b, 0600)) {
+		t.FailNow()
+	}
+	r = &registry.Resolver{Client: &registry.Client{HTTP: s.Client()}, CacheFile: cache, CacheMaxAge: time.Hour}
+	digest, err = r.Resolve(host + "/public/app")
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, "sha256:old", digest)
+	}
+	assert.Equal(t, 2, requests["HEAD /v2/public/app/manifests/latest"])
+}
+
+func TestDockerConfig_Credentials(t *testing.T) {
+	d, err := ioutil.TempDir("", "kyaml-registry-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(d)
+	path := filepath.Join(d, "config.json")
+	err = ioutil.WriteFile(path, []byte(`{
+  "auths": {
+    "https://index.docker.io/v1/": {"auth": "`+
+		base64.StdEncoding.EncodeToString([]byte("hub:secret"))+`"},
+    "https://gcr.io/v1": {"username": "_json_key", "password": "{}"},
+    "quay.io": {"auth": "invalid!"}
+  }
+}`), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	c, err := registry.LoadDockerConfig(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	for _, test := range []struct {
+		registry, username, password string
+	}{
+		{"docker.io", "hub", "secret"},
+		{"gcr.io", "_json_key", "{}"},
+		{"localhost:5000", "", ""},
+	} {
+		username, password, err := c.Credentials(test.registry)
+		if assert.NoError(t, err, test.registry) {
+			assert.Equal(t, test.username, username, test.registry)
+			assert.Equal(t, test.password, password, test.registry)
+		}
+	}
+	_, _, err = c.Credentials("quay.io")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid auth of quay.io")
+	}
+
+	// a missing configuration has no credentials
+	c, err = registry.LoadDockerConfig(filepath.Join(d, "missing.json"))
+	if assert.NoError(t, err) {
+		username, _, err := c.Credentials("docker.io")
+		assert.NoError(t, err)
+		assert.Empty(t, username)
+	}
+}
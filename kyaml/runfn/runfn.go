@@ -26,6 +26,19 @@ type RunFns struct {
 	// Output can be set to write the result to Output rather than back to the directory
 	Output io.Writer
 
+	// EnableExec allows functions to be run as local executables.  Local executables are
+	// not sandboxed, so they are only run if explicitly enabled.
+	EnableExec bool
+
+	// Limits if set are the Limits of the Pipeline reading the directory
+	Limits *kio.Limits
+
+	// Timings if set records the timings of the Pipeline
+	Timings *kio.PipelineTimings
+
+	// Warnings if set is where the Pipeline writes the warnings of reading the directory
+	Warnings io.Writer
+
 	// containerFilterProvider may be override by tests to fake invoking containers
 	containerFilterProvider func(string, string, *yaml.RNode) kio.Filter
 }
@@ -42,34 +55,17 @@ func (r RunFns) Execute() error {
 	// default the containerFilterProvider if it hasn't been override.  Split out for testing.
 	(&r).init()
 
-	// identify the configuration functions in the directory
-	buff := &kio.PackageBuffer{}
-	err = kio.Pipeline{
-		Inputs:  []kio.Reader{kio.LocalPackageReader{PackagePath: r.Path}},
-		Filters: []kio.Filter{&filters.IsReconcilerFilter{}},
-		Outputs: []kio.Writer{buff},
-	}.Execute()
+	// identify the configuration functions in the directory, and in the function paths
+	fltrs, err := r.getFilters(r.Path)
 	if err != nil {
 		return err
 	}
-
-	// accept a
 	for i := range r.FunctionPaths {
-		err := kio.Pipeline{
-			Inputs:  []kio.Reader{kio.LocalPackageReader{PackagePath: r.FunctionPaths[i]}},
-			Outputs: []kio.Writer{buff},
-		}.Execute()
+		f, err := r.getFilters(r.FunctionPaths[i])
 		if err != nil {
 			return err
 		}
-	}
-
-	// reconcile each local API
-	var fltrs []kio.Filter
-	for i := range buff.Nodes {
-		api := buff.Nodes[i]
-		img, path := filters.GetContainerName(api)
-		fltrs = append(fltrs, r.containerFilterProvider(img, path, api))
+		fltrs = append(fltrs, f...)
 	}
 
 	pkgIO := &kio.LocalPackageReadWriter{PackagePath: r.Path}
@@ -82,7 +78,55 @@ func (r RunFns) Execute() error {
 		// write to the output instead of the directory
 		outputs = append(outputs, kio.ByteWriter{Writer: r.Output})
 	}
-	return kio.Pipeline{Inputs: inputs, Filters: fltrs, Outputs: outputs}.Execute()
+	return kio.Pipeline{
+		Inputs: inputs, Filters: fltrs, Outputs: outputs,
+		Limits: r.Limits, Timings: r.Timings, Warnings: r.Warnings}.Execute()
+}
+
+// getFilters returns a filter for each of the functions configured in the directory.
+//
+// Functions are identified by the config.kubernetes.io/function annotation, and
+// may be run as containers, or as local executables if EnableExec is set.  Resources
+// with a metadata.configFn.container.image field or a config.kubernetes.io/container
+// annotation are also run as containers.
+func (r RunFns) getFilters(dir string) ([]kio.Filter, error) {
+	nodes, err := kio.LocalPackageReader{PackagePath: dir}.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var fltrs []kio.Filter
+	for i := range nodes {
+		api := nodes[i]
+		spec, err := filters.GetFunctionSpec(api)
+		if err != nil {
+			return nil, err
+		}
+
+		img, path := filters.GetContainerName(api)
+		switch {
+		case spec != nil && spec.Exec.Path != "":
+			meta, _ := api.GetMeta()
+			if !r.EnableExec {
+				return nil, errors.Errorf(
+					"%s %s is run as a local executable, which requires enabling exec",
+					meta.Kind, meta.Name)
+			}
+			// the executable path is relative to the function config
+			fnDir := filepath.Join(dir, filepath.Dir(path))
+			exe := spec.Exec.Path
+			if !filepath.IsAbs(exe) {
+				exe = filepath.Join(fnDir, exe)
+			}
+			fltrs = append(fltrs, &filters.ExecFilter{
+				Path: exe, Args: spec.Exec.Args, Dir: fnDir, Config: api})
+		case spec != nil:
+			fltrs = append(fltrs, r.containerFilterProvider(spec.Container.Image, path, api))
+		case img != "":
+			fltrs = append(fltrs, r.containerFilterProvider(img, path, api))
+		}
+	}
+	return fltrs, nil
 }
 
 // init initializes the RunFns with a containerFilterProvider.
@@ -246,3 +246,124 @@ replace: StatefulSet
 	assert.NotContains(t, string(b), "kind: StatefulSet")
 	assert.Contains(t, out.String(), "kind: StatefulSet")
 }
+
+// copyTestData copies the testdata into a temp directory
+func copyTestData(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "kustomize-kyaml-test")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, filename, _, ok := runtime.Caller(0)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	ds, err := filepath.Abs(filepath.Join(filepath.Dir(filename), "test", "testdata"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, copyutil.CopyDir(ds, dir)) {
+		t.FailNow()
+	}
+	return dir
+}
+
+func TestCmd_Execute_functionAnnotation(t *testing.T) {
+	dir := copyTestData(t)
+	defer os.RemoveAll(dir)
+
+	f := `apiVersion: v1
+kind: ValueReplacer
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: gcr.io/example.com/image:version
+stringMatch: Deployment
+replace: StatefulSet
+`
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "filter.yaml"), []byte(f), 0600)) {
+		return
+	}
+
+	var images []string
+	instance := RunFns{
+		Path: dir,
+		containerFilterProvider: func(s, _ string, node *yaml.RNode) kio.Filter {
+			images = append(images, s)
+			return filters.Modifier{}
+		},
+	}
+	if !assert.NoError(t, instance.Execute()) {
+		return
+	}
+	assert.Equal(t, []string{"gcr.io/example.com/image:version"}, images)
+}
+
+func TestCmd_Execute_exec(t *testing.T) {
+	dir := copyTestData(t)
+	defer os.RemoveAll(dir)
+
+	if !assert.NoError(t, os.Mkdir(filepath.Join(dir, "fn"), 0700)) {
+		return
+	}
+	f := `apiVersion: v1
+kind: ValueReplacer
+metadata:
+  name: replacer
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: ./replace.sh
+        args: [StatefulSet]
+`
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "fn", "fn.yaml"), []byte(f), 0600)) {
+		return
+	}
+	script := `#!/bin/sh
+sed "s/kind: Deployment/kind: $1/"
+`
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "fn", "replace.sh"), []byte(script), 0700)) {
+		return
+	}
+
+	// exec must be enabled
+	err := RunFns{Path: dir}.Execute()
+	if !assert.EqualError(t, err,
+		"ValueReplacer replacer is run as a local executable, which requires enabling exec") {
+		return
+	}
+
+	if !assert.NoError(t, RunFns{Path: dir, EnableExec: true}.Execute()) {
+		return
+	}
+	b, err := ioutil.ReadFile(
+		filepath.Join(dir, "java", "java-deployment.resource.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(b), "kind: StatefulSet")
+}
+
+func TestCmd_Execute_invalidFunctionAnnotation(t *testing.T) {
+	dir := copyTestData(t)
+	defer os.RemoveAll(dir)
+
+	f := `apiVersion: v1
+kind: ValueReplacer
+metadata:
+  name: foo
+  annotations:
+    config.kubernetes.io/function: |
+      container: {}
+`
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "filter.yaml"), []byte(f), 0600)) {
+		return
+	}
+	err := RunFns{Path: dir}.Execute()
+	assert.EqualError(t, err, "config.kubernetes.io/function annotation on ValueReplacer foo "+
+		"must specify exactly one of container.image or exec.path")
+}
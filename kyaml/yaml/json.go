@@ -0,0 +1,124 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+var _ json.Marshaler = &RNode{}
+
+// MarshalJSON encodes the RNode as JSON.  Unlike converting the RNode to a map and
+// encoding the map, the order of the fields is preserved.
+func (rn *RNode) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := encodeJSON(buf, rn.YNode()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeJSON writes the JSON encoding of node to buf.
+func encodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	if node == nil {
+		buf.WriteString("null")
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return encodeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return errors.Wrap(err)
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := encodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, node.Content[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.ScalarNode:
+		return encodeJSONScalar(buf, node)
+	}
+	return errors.Errorf("cannot encode yaml node kind %d as json", node.Kind)
+}
+
+// encodeJSONScalar writes the JSON encoding of a scalar node to buf.  Tags without a JSON
+// equivalent (e.g. !!timestamp) are encoded as strings.
+func encodeJSONScalar(buf *bytes.Buffer, node *yaml.Node) error {
+	var value interface{}
+	switch node.ShortTag() {
+	case NullNodeTag:
+		buf.WriteString("null")
+		return nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return errors.Wrap(err)
+		}
+		value = b
+	case "!!int":
+		var i int64
+		if err := node.Decode(&i); err != nil {
+			// may not fit in an int64
+			var u uint64
+			if err := node.Decode(&u); err != nil {
+				return errors.Wrap(err)
+			}
+			value = u
+		} else {
+			value = i
+		}
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return errors.Wrap(err)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return errors.Errorf("cannot encode %s as json", node.Value)
+		}
+		value = f
+	default:
+		value = node.Value
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	buf.Write(b)
+	return nil
+}
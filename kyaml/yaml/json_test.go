@@ -0,0 +1,70 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestRNode_MarshalJSON(t *testing.T) {
+	node, err := Parse(`kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: foo # comment
+  labels:
+    app: foo
+spec:
+  replicas: 3
+  paused: false
+  ratio: 0.5
+  selector: ~
+  template: &template
+    version: "1"
+    ports: [8080, "9090"]
+  copy: *template
+  empty: {}
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b, err := node.MarshalJSON()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `{"kind":"Deployment","apiVersion":"apps/v1",`+
+		`"metadata":{"name":"foo","labels":{"app":"foo"}},`+
+		`"spec":{"replicas":3,"paused":false,"ratio":0.5,"selector":null,`+
+		`"template":{"version":"1","ports":[8080,"9090"]},`+
+		`"copy":{"version":"1","ports":[8080,"9090"]},"empty":{}}}`,
+		string(b))
+}
+
+func TestRNode_MarshalJSON_specialValues(t *testing.T) {
+	node, err := Parse(`a: 0x1F
+b: "true"
+c: 2019-10-26
+d: "quote \" and\nnewline"
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	b, err := node.MarshalJSON()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `{"a":31,"b":"true","c":"2019-10-26","d":"quote \" and\nnewline"}`,
+		string(b))
+
+	node, err = Parse(`a: .inf
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = node.MarshalJSON()
+	assert.Error(t, err)
+}
@@ -64,3 +64,21 @@ func (g AnnotationGetter) Filter(rn *RNode) (*RNode, error) {
 func GetAnnotation(key string) AnnotationGetter {
 	return AnnotationGetter{Key: key}
 }
+
+// LabelSetter sets a label at metadata.labels.
+// Creates metadata.labels if does not exist.
+type LabelSetter struct {
+	Kind  string `yaml:"kind,omitempty"`
+	Key   string `yaml:"key,omitempty"`
+	Value string `yaml:"value,omitempty"`
+}
+
+func (s LabelSetter) Filter(rn *RNode) (*RNode, error) {
+	return rn.Pipe(
+		PathGetter{Path: []string{"metadata", "labels"}, Create: yaml.MappingNode},
+		FieldSetter{Name: s.Key, Value: NewStringRNode(s.Value)})
+}
+
+func SetLabel(key, value string) LabelSetter {
+	return LabelSetter{Key: key, Value: value}
+}
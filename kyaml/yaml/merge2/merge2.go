@@ -152,6 +152,10 @@ func (m Merger) VisitScalar(nodes walk.Sources) (*yaml.RNode, error) {
 	}
 	// Override value
 	if nodes.Origin() != nil {
+		// keep the dest comments if the value is unchanged
+		if nodes.Dest() != nil && nodes.Dest().YNode().Value == nodes.Origin().YNode().Value {
+			m.keepComments(nodes)
+		}
 		return nodes.Origin(), nil
 	}
 	// Keep
@@ -188,6 +192,10 @@ func (m Merger) VisitList(nodes walk.Sources, kind walk.ListKind) (*yaml.RNode,
 func (m Merger) SetComments(sources walk.Sources) error {
 	source := sources.Origin()
 	dest := sources.Dest()
+	if dest == nil || dest.YNode() == nil {
+		// nothing to copy the comments to
+		return nil
+	}
 	if source != nil && source.YNode().FootComment != "" {
 		dest.YNode().FootComment = source.YNode().FootComment
 	}
@@ -199,3 +207,18 @@ func (m Merger) SetComments(sources walk.Sources) error {
 	}
 	return nil
 }
+
+// keepComments copies the dest comments to the source if they are missing from the source.
+func (m Merger) keepComments(sources walk.Sources) {
+	source := sources.Origin().YNode()
+	dest := sources.Dest().YNode()
+	if source.HeadComment == "" {
+		source.HeadComment = dest.HeadComment
+	}
+	if source.LineComment == "" {
+		source.LineComment = dest.LineComment
+	}
+	if source.FootComment == "" {
+		source.FootComment = dest.FootComment
+	}
+}
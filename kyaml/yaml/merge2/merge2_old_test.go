@@ -508,3 +508,66 @@ a:
     c: d # line comment
 `, actual)
 }
+
+func TestMerge_commentsAssociativeList(t *testing.T) {
+	actual, err := MergeStrings(`
+containers:
+# sidecar comment
+- name: sidecar
+  image: sidecar:2
+- name: foo
+  image: foo:2 # foo image
+`,
+		`
+containers:
+# foo comment
+- name: foo # foo name
+  image: foo:1
+  env:
+  # env b
+  - name: B
+    value: b
+  # env a
+  - name: A
+    value: a
+# bar comment
+- name: bar
+  image: bar:1
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `containers:
+- # foo comment
+  name: foo # foo name
+  image: foo:2 # foo image
+  env:
+  - # env b
+    name: B
+    value: b
+  - # env a
+    name: A
+    value: a
+- # bar comment
+  name: bar
+  image: bar:1
+- # sidecar comment
+  name: sidecar
+  image: sidecar:2
+`, actual)
+}
+
+func TestMerge_commentsNewField(t *testing.T) {
+	actual, err := MergeStrings(`
+a: 1 # line comment
+`,
+		`
+b: 2
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `b: 2
+a: 1 # line comment
+`, actual)
+}
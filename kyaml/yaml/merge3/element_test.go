@@ -33,9 +33,8 @@ kind: Deployment
 containers:
 - name: foo
   image: foo:1
-- image: baz:2
-  name: baz
-  
+- name: baz
+  image: baz:2
 `, nil},
 
 	//
@@ -56,8 +55,8 @@ kind: Deployment
 		`
 kind: Deployment
 containers:
-- image: foo:bar
-  name: foo
+- name: foo
+  image: foo:bar
 `, nil},
 
 	{`Add an element to a non-existing list, existing in dest`,
@@ -80,8 +79,8 @@ kind: Deployment
 containers:
 - name: baz
   image: baz:bar
-- image: foo:bar
-  name: foo
+- name: foo
+  image: foo:bar
 `, nil},
 
 	//
@@ -418,5 +417,44 @@ containers:
 `,
 		`
 kind: Deployment
+`, nil},
+
+	//
+	// Test Case
+	//
+	{`Add an element with comments -- comments follow the element`,
+		`
+kind: Deployment
+containers:
+# foo comment
+- name: foo
+  image: foo:1
+`,
+		`
+kind: Deployment
+containers:
+# baz comment
+- name: baz
+  image: baz:2
+# foo comment
+- name: foo
+  image: foo:1
+`,
+		`
+kind: Deployment
+containers:
+# foo comment
+- name: foo # foo name
+  image: foo:1
+`,
+		`
+kind: Deployment
+containers:
+- # foo comment
+  name: foo # foo name
+  image: foo:1
+- # baz comment
+  name: baz
+  image: baz:2
 `, nil},
 }
@@ -53,7 +53,7 @@ list: # new value
 apiVersion: apps/v1`,
 		`
 apiVersion: apps/v1
-list:
+list: # new value
 - 2
 - 3
 - 4
@@ -132,6 +132,18 @@ func NewScalarRNode(value string) *RNode {
 		}}
 }
 
+// NewStringRNode returns a new Scalar *RNode containing the provided string value.
+// The value is tagged as a string so that it is never encoded as another type,
+// e.g. "true" or "1".
+func NewStringRNode(value string) *RNode {
+	return &RNode{
+		value: &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: value,
+		}}
+}
+
 // NewListRNode returns a new List *RNode containing the provided scalar values.
 func NewListRNode(values ...string) *RNode {
 	seq := &RNode{value: &yaml.Node{Kind: yaml.SequenceNode}}
@@ -324,6 +336,28 @@ func (rn *RNode) YNode() *yaml.Node {
 	return rn.value
 }
 
+// Copy returns a deep copy of the RNode.
+func (rn *RNode) Copy() *RNode {
+	if rn == nil {
+		return nil
+	}
+	return &RNode{value: CopyYNode(rn.value)}
+}
+
+// CopyYNode returns a deep copy of the yaml.Node.  Aliases are copied as aliases of the
+// same anchored Nodes.
+func CopyYNode(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.Content = nil
+	for i := range n.Content {
+		c.Content = append(c.Content, CopyYNode(n.Content[i]))
+	}
+	return &c
+}
+
 // SetYNode sets the yaml.Node value on an RNode.
 func (rn *RNode) SetYNode(node *yaml.Node) {
 	if rn.value == nil || node == nil {
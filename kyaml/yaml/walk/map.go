@@ -4,8 +4,6 @@
 package walk
 
 import (
-	"sort"
-
 	"sigs.k8s.io/kustomize/kyaml/sets"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
@@ -16,6 +14,7 @@ import (
 // - set the return value on l.Dest
 // - walk each source field
 // - set each source field value on l.Dest
+// - retain the comments on each field name
 func (l Walker) walkMap() (*yaml.RNode, error) {
 	// get the new map value
 	dest, err := l.Sources.setDestNode(l.VisitMap(l.Sources))
@@ -32,15 +31,45 @@ func (l Walker) walkMap() (*yaml.RNode, error) {
 		}
 
 		// this handles empty and non-empty values
+		added := dest.Field(key) == nil
 		_, err = dest.Pipe(yaml.FieldSetter{Name: key, Value: val})
 		if err != nil {
 			return nil, err
 		}
+
+		// comments on list elements are attached to the first field name of the element,
+		// so they must be copied along with new fields or they will be dropped
+		if added {
+			l.setFieldComments(dest, key)
+		}
 	}
 
 	return dest, nil
 }
 
+// setFieldComments copies the comments on the field name from the highest precedence
+// source containing the field to the dest.
+func (l Walker) setFieldComments(dest *yaml.RNode, fieldName string) {
+	destField := dest.Field(fieldName)
+	if destField == nil {
+		// field was cleared
+		return
+	}
+	for i := len(l.Sources) - 1; i >= 0; i-- {
+		if l.Sources[i] == nil {
+			continue
+		}
+		field := l.Sources[i].Field(fieldName)
+		if field == nil {
+			continue
+		}
+		destField.Key.YNode().HeadComment = field.Key.YNode().HeadComment
+		destField.Key.YNode().LineComment = field.Key.YNode().LineComment
+		destField.Key.YNode().FootComment = field.Key.YNode().FootComment
+		return
+	}
+}
+
 // valueIfPresent returns node.Value if node is non-nil, otherwise returns nil
 func (l Walker) valueIfPresent(node *yaml.MapNode) *yaml.RNode {
 	if node == nil {
@@ -49,20 +78,30 @@ func (l Walker) valueIfPresent(node *yaml.MapNode) *yaml.RNode {
 	return node.Value
 }
 
-// fieldNames returns a sorted slice containing the names of all fields that appear in any of
-// the sources
+// fieldNames returns a slice containing the names of all fields that appear in any of
+// the sources.
+// Return value slice is ordered using the original ordering from the fields, where
+// fields missing from earlier sources appear later.  This keeps the first field of a
+// list element -- which holds the comments for the element -- first.
 func (l Walker) fieldNames() []string {
-	fields := sets.String{}
+	// use slice to to keep fields in the original order
+	// dest node must be first
+	var result []string
+	seen := sets.String{}
 	for _, s := range l.Sources {
 		if s == nil {
 			continue
 		}
 		// don't check error, we know this is a mapping node
 		sFields, _ := s.Fields()
-		fields.Insert(sFields...)
+		for _, f := range sFields {
+			if seen.Has(f) {
+				continue
+			}
+			result = append(result, f)
+			seen.Insert(f)
+		}
 	}
-	result := fields.List()
-	sort.Strings(result)
 	return result
 }
 